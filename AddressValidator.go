@@ -0,0 +1,347 @@
+package goex
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"regexp"
+	"strings"
+)
+
+const (
+	base58Bitcoin = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	base58Ripple  = "rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz"
+	bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+var (
+	ethAddressRegexp = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	eosAccountRegexp = regexp.MustCompile(`^[a-z1-5.]{1,12}$`)
+)
+
+/**
+ * 地址校验规则, keywords为去掉币种后的链名称(或币种), 完全相同时匹配, 按顺序匹配第一个
+ * memo为true时提币必须填写memo/tag
+ */
+type addressRule struct {
+	name     string
+	keywords []string
+	validate func(address string) error
+	memo     bool
+}
+
+var addressRules = []addressRule{
+	{"ETH", []string{"ERC20", "BEP20", "BSC", "ARBITRUM", "ARBITRUM ONE", "OPTIMISM", "POLYGON", "MATIC"}, validateEthAddress, false},
+	{"TRX", []string{"TRC20", "TRX", "TRON"}, validateTronAddress, false},
+	{"BTC", []string{"OMNI"}, validateBtcAddress, false},
+	{"XRP", []string{"XRP"}, validateXrpAddress, true},
+	{"EOS", []string{"EOS"}, validateEosAddress, true},
+	{"XLM", []string{"XLM"}, nil, true},
+	{"ATOM", []string{"ATOM"}, nil, true},
+	{"BTC", []string{"BTC", "BITCOIN"}, validateBtcAddress, false},
+	{"ETH", []string{"ETH"}, validateEthAddress, false},
+}
+
+//去掉链名称中的币种和分隔符, 如USDT-TRC20, trc20usdt => TRC20, BTC-Bitcoin => BITCOIN, 为空时使用币种
+func normalizeChain(currency, chain string) string {
+	currency = strings.ToUpper(currency)
+	name := strings.ToUpper(chain)
+	if name != currency {
+		name = strings.TrimPrefix(name, currency)
+		name = strings.TrimSuffix(name, currency)
+	}
+	name = strings.Trim(name, "-_ ")
+	if name == "" {
+		return currency
+	}
+	return name
+}
+
+func findAddressRule(currency, chain string) *addressRule {
+	name := normalizeChain(currency, chain)
+	for i := range addressRules {
+		for _, k := range addressRules[i].keywords {
+			if name == k {
+				return &addressRules[i]
+			}
+		}
+	}
+	return nil
+}
+
+/**
+ * 校验提币地址格式, chain为空时按币种判断
+ * 支持BTC(base58/bech32/bech32m), ETH及EVM链(EIP-55校验和), TRX, XRP, EOS
+ * EOS, XRP, XLM, ATOM必须填写memo, 无法识别的链不校验
+ */
+func ValidateWithdrawAddress(currency, chain, address, memo string) error {
+	rule := findAddressRule(currency, chain)
+	if rule == nil {
+		return nil
+	}
+	if rule.validate != nil {
+		if err := rule.validate(address); err != nil {
+			return fmt.Errorf("invalid %s address %s: %s", rule.name, address, err.Error())
+		}
+	}
+	if rule.memo && memo == "" {
+		return fmt.Errorf("memo is required for %s", rule.name)
+	}
+	return nil
+}
+
+//全小写或全大写不校验, 大小写混合时必须符合EIP-55
+func validateEthAddress(address string) error {
+	if !ethAddressRegexp.MatchString(address) {
+		return errors.New("must be 0x followed by 40 hex characters")
+	}
+	addr := address[2:]
+	if addr == strings.ToLower(addr) || addr == strings.ToUpper(addr) {
+		return nil
+	}
+	hash := hex.EncodeToString(keccak256([]byte(strings.ToLower(addr))))
+	for i, c := range addr {
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		upper := hash[i] >= '8'
+		if upper != (c >= 'A' && c <= 'F') {
+			return errors.New("checksum mismatch")
+		}
+	}
+	return nil
+}
+
+func validateBtcAddress(address string) error {
+	if strings.HasPrefix(strings.ToLower(address), "bc1") {
+		return validateSegwitAddress("bc", address)
+	}
+	version, err := decodeBase58Check(base58Bitcoin, address, 21)
+	if err != nil {
+		return err
+	}
+	if version != 0x00 && version != 0x05 {
+		return fmt.Errorf("unknown version %d", version)
+	}
+	return nil
+}
+
+func validateTronAddress(address string) error {
+	version, err := decodeBase58Check(base58Bitcoin, address, 21)
+	if err != nil {
+		return err
+	}
+	if version != 0x41 {
+		return fmt.Errorf("unknown version %d", version)
+	}
+	return nil
+}
+
+func validateXrpAddress(address string) error {
+	version, err := decodeBase58Check(base58Ripple, address, 21)
+	if err != nil {
+		return err
+	}
+	if version != 0x00 {
+		return fmt.Errorf("unknown version %d", version)
+	}
+	return nil
+}
+
+func validateEosAddress(address string) error {
+	if !eosAccountRegexp.MatchString(address) {
+		return errors.New("must be 1-12 characters of a-z, 1-5 and .")
+	}
+	return nil
+}
+
+//返回版本号, size为版本号+数据的长度(不含4字节校验和)
+func decodeBase58Check(alphabet, address string, size int) (byte, error) {
+	num := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range address {
+		i := strings.IndexRune(alphabet, c)
+		if i < 0 {
+			return 0, fmt.Errorf("invalid character %q", c)
+		}
+		num.Mul(num, radix)
+		num.Add(num, big.NewInt(int64(i)))
+	}
+
+	//前导的0字符对应前导的0字节
+	leading := 0
+	for leading < len(address) && address[leading] == alphabet[0] {
+		leading++
+	}
+	decoded := append(make([]byte, leading), num.Bytes()...)
+	if len(decoded) != size+4 {
+		return 0, errors.New("invalid length")
+	}
+
+	payload, checksum := decoded[:size], decoded[size:]
+	h := sha256.Sum256(payload)
+	h = sha256.Sum256(h[:])
+	if !bytes.Equal(h[:4], checksum) {
+		return 0, errors.New("checksum mismatch")
+	}
+	return payload[0], nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+/**
+ * 隔离见证地址, BIP173(bech32)和BIP350(bech32m)
+ * v0使用bech32且程序为20或32字节, v1及以上使用bech32m
+ */
+func validateSegwitAddress(hrp, address string) error {
+	if address != strings.ToLower(address) && address != strings.ToUpper(address) {
+		return errors.New("mixed case")
+	}
+	address = strings.ToLower(address)
+	pos := strings.LastIndex(address, "1")
+	if pos < 1 || pos+7 > len(address) || len(address) > 90 || address[:pos] != hrp {
+		return errors.New("invalid format")
+	}
+
+	var data []byte
+	for _, c := range address[pos+1:] {
+		i := strings.IndexRune(bech32Charset, c)
+		if i < 0 {
+			return fmt.Errorf("invalid character %q", c)
+		}
+		data = append(data, byte(i))
+	}
+
+	var values []byte
+	for _, c := range hrp {
+		values = append(values, byte(c)>>5)
+	}
+	values = append(values, 0)
+	for _, c := range hrp {
+		values = append(values, byte(c)&31)
+	}
+	values = append(values, data...)
+	polymod := bech32Polymod(values)
+
+	version := data[0]
+	if version > 16 {
+		return errors.New("invalid witness version")
+	}
+	if (version == 0 && polymod != 1) || (version > 0 && polymod != 0x2bc830a3) {
+		return errors.New("checksum mismatch")
+	}
+
+	//5bit => 8bit
+	var (
+		acc     uint32
+		nbits   uint
+		program []byte
+	)
+	for _, v := range data[1 : len(data)-6] {
+		acc = acc<<5 | uint32(v)
+		nbits += 5
+		if nbits >= 8 {
+			nbits -= 8
+			program = append(program, byte(acc>>nbits))
+		}
+	}
+	if nbits >= 5 || acc&(1<<nbits-1) != 0 {
+		return errors.New("invalid padding")
+	}
+	if len(program) < 2 || len(program) > 40 || (version == 0 && len(program) != 20 && len(program) != 32) {
+		return errors.New("invalid program length")
+	}
+	return nil
+}
+
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808A, 0x8000000080008000,
+	0x000000000000808B, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008A, 0x0000000000000088, 0x0000000080008009, 0x000000008000000A,
+	0x000000008000808B, 0x800000000000008B, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800A, 0x800000008000000A,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+//按a[x+5y]排列的旋转位数
+var keccakRotations = [25]int{
+	0, 1, 62, 28, 27,
+	36, 44, 6, 55, 20,
+	3, 10, 43, 25, 39,
+	41, 45, 15, 21, 8,
+	18, 2, 61, 56, 14,
+}
+
+func keccakF1600(a *[25]uint64) {
+	var (
+		c [5]uint64
+		b [25]uint64
+	)
+	for round := 0; round < 24; round++ {
+		for x := 0; x < 5; x++ {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := 0; x < 5; x++ {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				a[y+x] ^= d
+			}
+		}
+		for x := 0; x < 5; x++ {
+			for y := 0; y < 5; y++ {
+				b[y+5*((2*x+3*y)%5)] = bits.RotateLeft64(a[x+5*y], keccakRotations[x+5*y])
+			}
+		}
+		for y := 0; y < 25; y += 5 {
+			for x := 0; x < 5; x++ {
+				a[y+x] = b[y+x] ^ (^b[y+(x+1)%5] & b[y+(x+2)%5])
+			}
+		}
+		a[0] ^= keccakRoundConstants[round]
+	}
+}
+
+//以太坊使用的keccak256, 与标准SHA3-256的填充不同
+func keccak256(data []byte) []byte {
+	const rate = 136
+	var state [25]uint64
+
+	padded := make([]byte, len(data), len(data)+rate)
+	copy(padded, data)
+	padded = append(padded, 0x01)
+	for len(padded)%rate != 0 {
+		padded = append(padded, 0)
+	}
+	padded[len(padded)-1] |= 0x80
+
+	for off := 0; off < len(padded); off += rate {
+		for i := 0; i < rate/8; i++ {
+			state[i] ^= binary.LittleEndian.Uint64(padded[off+i*8:])
+		}
+		keccakF1600(&state)
+	}
+
+	out := make([]byte, 32)
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint64(out[i*8:], state[i])
+	}
+	return out
+}
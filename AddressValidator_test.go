@@ -0,0 +1,74 @@
+package goex
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeccak256(t *testing.T) {
+	assert.Equal(t, "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", hex.EncodeToString(keccak256(nil)))
+	assert.Equal(t, "4d741b6f1eb29cb2a9b9911c82f56fa8d73b04959d3d9d222895df6c0b28aa15",
+		hex.EncodeToString(keccak256([]byte("The quick brown fox jumps over the lazy dog"))))
+}
+
+func TestValidateWithdrawAddress(t *testing.T) {
+	valid := [][4]string{
+		{"BTC", "", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", ""},
+		{"BTC", "BTC-Bitcoin", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", ""},
+		{"BTC", "BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", ""},
+		{"BTC", "BTC", "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297", ""},
+		{"USDT", "USDT-ERC20", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ""},
+		{"ETH", "", "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359", ""},
+		{"USDT", "trc20usdt", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", ""},
+		{"XRP", "XRP", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", "123456"},
+		{"EOS", "", "eosio.token", "memo"},
+		{"DOGE", "DOGE", "anything", ""},
+		{"BTCZ", "BTCZ", "t1KstPVzcNEK4ZeauQ6cogoqxQBMDSiRnGr", ""},
+		{"USDT", "HRC20", "anything", ""},
+		{"ETH", "ETH-Arbitrum One", "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359", ""},
+	}
+	for _, v := range valid {
+		assert.Nil(t, ValidateWithdrawAddress(v[0], v[1], v[2], v[3]), v[2])
+	}
+
+	invalid := [][4]string{
+		{"BTC", "", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3", ""},
+		{"BTC", "", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", ""},
+		{"BTC", "", "bc1zw508d6qejxtdg4y5r3zarvaryvqyzf3du", ""},
+		{"USDT", "ERC20", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", ""},
+		{"USDT", "ERC20", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beae", ""},
+		{"USDT", "TRC20", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", ""},
+		{"XRP", "", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", ""},
+		{"EOS", "EOS", "EOSIO", "memo"},
+		{"BTC", "BTC-Bitcoin", "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359", ""},
+		{"ETH", "ETH-Arbitrum One", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", ""},
+	}
+	for _, v := range invalid {
+		assert.NotNil(t, ValidateWithdrawAddress(v[0], v[1], v[2], v[3]), v[2])
+	}
+}
+
+func TestFindAddressRule(t *testing.T) {
+	for _, v := range [][3]string{
+		{"USDT", "USDT-TRC20", "TRX"},
+		{"USDT", "trc20usdt", "TRX"},
+		{"USDT", "usdterc20", "ETH"},
+		{"USDT", "ETH", "ETH"},
+		{"BTC", "BTC-Bitcoin", "BTC"},
+		{"BTC", "", "BTC"},
+		{"XRP", "XRP", "XRP"},
+	} {
+		rule := findAddressRule(v[0], v[1])
+		if assert.NotNil(t, rule, v[1]) {
+			assert.Equal(t, v[2], rule.name, v[1])
+		}
+	}
+
+	//名称中包含关键字但不是同一条链
+	assert.Nil(t, findAddressRule("BTCZ", "BTCZ"))
+	assert.Nil(t, findAddressRule("ETHW", ""))
+	assert.Nil(t, findAddressRule("USDT", "HRC20"))
+	assert.Nil(t, findAddressRule("USDT", ""))
+}
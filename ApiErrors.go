@@ -28,4 +28,6 @@ var (
 	EX_ERR_INVALID_CURRENCY_PAIR = ApiError{ErrCode: "EX_ERR_0007", ErrMsg: "invalid currency pair"}
 	EX_ERR_NOT_FIND_ORDER        = ApiError{ErrCode: "EX_ERR_0008", ErrMsg: "not find order"}
 	EX_ERR_SYMBOL_ERR            = ApiError{ErrCode: "EX_ERR_0009", ErrMsg: "symbol error"}
+	EX_ERR_NOT_SUPPORT           = ApiError{ErrCode: "EX_ERR_0010", ErrMsg: "not support"}
+	EX_ERR_WITHDRAW_REJECTED     = ApiError{ErrCode: "EX_ERR_0011", ErrMsg: "withdraw rejected"}
 )
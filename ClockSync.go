@@ -0,0 +1,275 @@
+package goex
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lucas7788/goex/internal/logger"
+)
+
+const (
+	DefaultClockSyncInterval = 5 * time.Minute
+	clockSyncSamples         = 3
+	clockSyncRetryInterval   = 10 * time.Second
+)
+
+// ServerTimeFunc 请求交易所的服务器时间接口,返回毫秒时间戳
+type ServerTimeFunc func() (int64, error)
+
+/**
+ * 交易所服务器时间同步
+ * 每次同步采样多次,取往返时间(RTT)最小的一次估算本地时钟与服务器的偏差,签名时使用Now()代替time.Now()
+ * 首次调用Now()时阻塞同步一次,并启动后台协程每隔Interval重新同步,直到调用Stop()
+ * 后台同步失败时,下一次调用Now()会在clockSyncRetryInterval之后再次尝试
+ */
+type ClockSync struct {
+	Name     string
+	Interval time.Duration
+
+	fetch       ServerTimeFunc
+	lock        sync.RWMutex
+	offset      time.Duration //服务器时间 - 本地时间
+	rtt         time.Duration
+	lastSync    time.Time
+	lastAttempt time.Time
+	syncing     bool
+	syncLock    sync.Mutex
+	startOnce   sync.Once
+	stopOnce    sync.Once
+	stop        chan struct{}
+	done        chan struct{} //后台协程退出时关闭
+}
+
+// HttpDateTime 交易所没有服务器时间接口时,使用响应头Date作为服务器时间,返回毫秒时间戳
+// Date只精确到秒(向下取整),加上500毫秒使平均误差接近0
+func HttpDateTime(client *http.Client, reqUrl string) (int64, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(reqUrl)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, err
+	}
+	return date.UnixNano()/int64(time.Millisecond) + 500, nil
+}
+
+var (
+	clockSyncs     = make(map[string]*ClockSync, 4)
+	clockSyncsLock sync.Mutex
+)
+
+func NewClockSync(name string, fetch ServerTimeFunc) *ClockSync {
+	return &ClockSync{Name: name, Interval: DefaultClockSyncInterval, fetch: fetch, stop: make(chan struct{})}
+}
+
+// SharedClockSync 同一个服务器时间接口(name)只创建一个ClockSync,在多个api实例之间共享
+func SharedClockSync(name string, fetch ServerTimeFunc) *ClockSync {
+	clockSyncsLock.Lock()
+	defer clockSyncsLock.Unlock()
+
+	if c, ok := clockSyncs[name]; ok {
+		return c
+	}
+	c := NewClockSync(name, fetch)
+	clockSyncs[name] = c
+	return c
+}
+
+// StopClockSyncs 停止所有共享ClockSync的后台同步并清空,之后SharedClockSync会创建新的实例
+func StopClockSyncs() {
+	clockSyncsLock.Lock()
+	syncs := clockSyncs
+	clockSyncs = make(map[string]*ClockSync, 4)
+	clockSyncsLock.Unlock()
+
+	for _, c := range syncs {
+		c.Stop()
+	}
+}
+
+// ClockDrifts 返回所有共享ClockSync测量到的时钟偏差(服务器时间-本地时间)
+func ClockDrifts() map[string]time.Duration {
+	clockSyncsLock.Lock()
+	defer clockSyncsLock.Unlock()
+
+	drifts := make(map[string]time.Duration, len(clockSyncs))
+	for name, c := range clockSyncs {
+		drifts[name] = c.Offset()
+	}
+	return drifts
+}
+
+// Sync 立即同步一次服务器时间
+func (c *ClockSync) Sync() error {
+	if c.fetch == nil {
+		return errors.New("server time func is nil")
+	}
+
+	c.syncLock.Lock()
+	defer c.syncLock.Unlock()
+
+	c.lock.Lock()
+	c.lastAttempt = time.Now()
+	c.lock.Unlock()
+
+	var (
+		bestOffset time.Duration
+		bestRtt    time.Duration = -1
+		lastErr    error
+	)
+
+	for i := 0; i < clockSyncSamples; i++ {
+		t0 := time.Now()
+		st, err := c.fetch()
+		t1 := time.Now()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if st <= 0 {
+			lastErr = errors.New("invalid server time")
+			continue
+		}
+
+		rtt := t1.Sub(t0)
+		serverTime := time.Unix(0, st*int64(time.Millisecond))
+		offset := serverTime.Sub(t0.Add(rtt / 2))
+		if bestRtt < 0 || rtt < bestRtt {
+			bestRtt = rtt
+			bestOffset = offset
+		}
+	}
+
+	if bestRtt < 0 {
+		logger.Log.Errorf("[%s] sync server time fail: %v", c.Name, lastErr)
+		return lastErr
+	}
+
+	c.lock.Lock()
+	c.offset = bestOffset
+	c.rtt = bestRtt
+	c.lastSync = time.Now()
+	c.lock.Unlock()
+
+	logger.Log.Debugf("[%s] sync server time, offset=%s, rtt=%s", c.Name, bestOffset, bestRtt)
+	return nil
+}
+
+func (c *ClockSync) refresh() {
+	c.lock.Lock()
+	now := time.Now()
+	firstSync := c.lastAttempt.IsZero()
+	needSync := (c.lastSync.IsZero() || now.Sub(c.lastSync) > c.Interval) &&
+		now.Sub(c.lastAttempt) > clockSyncRetryInterval && !c.syncing
+	if needSync {
+		c.syncing = true
+	}
+	c.lock.Unlock()
+
+	if !needSync {
+		return
+	}
+
+	doSync := func() {
+		c.Sync()
+		c.lock.Lock()
+		c.syncing = false
+		c.lock.Unlock()
+	}
+
+	if firstSync {
+		doSync() //首次同步,阻塞等待结果
+		c.startOnce.Do(func() {
+			c.done = make(chan struct{})
+			go c.loop()
+		})
+	} else {
+		go doSync()
+	}
+}
+
+//后台定时同步,Interval<=0时使用默认间隔
+func (c *ClockSync) loop() {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = DefaultClockSyncInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer close(c.done)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.Sync()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Stop 停止后台定时同步并等待后台协程退出,之后Now()仍然可用,超过Interval时按需同步
+func (c *ClockSync) Stop() {
+	if c == nil {
+		return
+	}
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+	//Stop之后不再启动后台协程
+	c.startOnce.Do(func() {})
+	if c.done != nil {
+		<-c.done
+	}
+}
+
+// Now 返回校正后的当前时间(约等于交易所服务器时间)
+func (c *ClockSync) Now() time.Time {
+	if c == nil {
+		return time.Now()
+	}
+	c.refresh()
+	return time.Now().Add(c.Offset())
+}
+
+// NowMillis 返回校正后的毫秒时间戳
+func (c *ClockSync) NowMillis() int64 {
+	return c.Now().UnixNano() / int64(time.Millisecond)
+}
+
+// Offset 服务器时间 - 本地时间,正数表示本地时钟偏慢
+func (c *ClockSync) Offset() time.Duration {
+	if c == nil {
+		return 0
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.offset
+}
+
+// RTT 最近一次同步的网络往返时间
+func (c *ClockSync) RTT() time.Duration {
+	if c == nil {
+		return 0
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.rtt
+}
+
+func (c *ClockSync) LastSyncTime() time.Time {
+	if c == nil {
+		return time.Time{}
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lastSync
+}
@@ -0,0 +1,117 @@
+package goex
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClockSync_Sync(t *testing.T) {
+	drift := 3 * time.Second
+	c := NewClockSync("test", func() (int64, error) {
+		time.Sleep(10 * time.Millisecond)
+		return time.Now().Add(drift).UnixNano() / int64(time.Millisecond), nil
+	})
+	defer c.Stop()
+
+	assert.True(t, c.LastSyncTime().IsZero())
+	now := c.Now() //首次调用同步
+	assert.False(t, c.LastSyncTime().IsZero())
+	assert.InDelta(t, float64(drift), float64(c.Offset()), float64(20*time.Millisecond))
+	assert.True(t, c.RTT() >= 10*time.Millisecond)
+	assert.InDelta(t, float64(time.Now().Add(drift).UnixNano()), float64(now.UnixNano()), float64(50*time.Millisecond))
+}
+
+func TestClockSync_SyncFail(t *testing.T) {
+	calls := 0
+	c := NewClockSync("test-fail", func() (int64, error) {
+		calls++
+		return 0, errors.New("network error")
+	})
+	defer c.Stop()
+	assert.Error(t, c.Sync())
+	assert.Equal(t, clockSyncSamples, calls)
+	assert.Equal(t, time.Duration(0), c.Offset())
+
+	//同步失败时使用本地时间,并且不会在每次调用时重新同步
+	c.Now()
+	c.Now()
+	assert.Equal(t, clockSyncSamples, calls)
+}
+
+func TestSharedClockSync(t *testing.T) {
+	fetch := func() (int64, error) { return time.Now().UnixNano() / int64(time.Millisecond), nil }
+	c1 := SharedClockSync("shared-test", fetch)
+	c2 := SharedClockSync("shared-test", fetch)
+	assert.True(t, c1 == c2)
+	_, ok := ClockDrifts()["shared-test"]
+	assert.True(t, ok)
+
+	c1.Now()
+	StopClockSyncs()
+	_, ok = ClockDrifts()["shared-test"]
+	assert.False(t, ok)
+	c3 := SharedClockSync("shared-test", fetch)
+	defer StopClockSyncs()
+	assert.True(t, c1 != c3)
+}
+
+func TestClockSync_PeriodicSync(t *testing.T) {
+	var (
+		lock  sync.Mutex
+		calls int
+	)
+	c := NewClockSync("test-periodic", func() (int64, error) {
+		lock.Lock()
+		calls++
+		lock.Unlock()
+		return time.Now().UnixNano() / int64(time.Millisecond), nil
+	})
+	c.Interval = 50 * time.Millisecond
+	defer c.Stop()
+
+	c.Now()
+	first := c.LastSyncTime()
+	time.Sleep(200 * time.Millisecond)
+
+	//没有调用Now()也会在后台定时同步
+	lock.Lock()
+	assert.True(t, calls > clockSyncSamples)
+	lock.Unlock()
+	assert.True(t, c.LastSyncTime().After(first))
+
+	//Stop返回后后台协程已经退出,不会再同步
+	c.Stop()
+	lock.Lock()
+	stopped := calls
+	lock.Unlock()
+	time.Sleep(150 * time.Millisecond)
+	lock.Lock()
+	assert.Equal(t, stopped, calls)
+	lock.Unlock()
+}
+
+func TestClockSync_Nil(t *testing.T) {
+	var c *ClockSync
+	assert.Equal(t, time.Duration(0), c.Offset())
+	assert.Equal(t, time.Duration(0), c.RTT())
+	assert.True(t, c.LastSyncTime().IsZero())
+	assert.InDelta(t, float64(time.Now().UnixNano()), float64(c.Now().UnixNano()), float64(time.Second))
+	c.Stop()
+}
+
+func TestHttpDateTime(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	}))
+	defer srv.Close()
+
+	ts, err := HttpDateTime(srv.Client(), srv.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC).UnixNano()/int64(time.Millisecond)+500, ts)
+}
@@ -124,40 +124,45 @@ var (
 
 //exchanges const
 const (
-	KUCOIN          = "kucoin.com"
-	OKCOIN_COM      = "okcoin.com"
-	OKEX            = "okex.com"
-	OKEX_V3         = "okex.com_v3"
-	OKEX_FUTURE     = "okex.com_future"
-	OKEX_SWAP       = "okex.com_swap"
-	HUOBI           = "huobi.com"
-	HUOBI_PRO       = "huobi.pro"
-	BITSTAMP        = "bitstamp.net"
-	KRAKEN          = "kraken.com"
-	ZB              = "zb.com"
-	BITFINEX        = "bitfinex.com"
-	BINANCE         = "binance.com"
-	BINANCE_SWAP    = "binance.com_swap"
-	BINANCE_FUTURES = "binance.com_futures"
-	POLONIEX        = "poloniex.com"
-	COINEX          = "coinex.com"
-	BITHUMB         = "bithumb.com"
-	GATEIO          = "gate.io"
-	BITTREX         = "bittrex.com"
-	GDAX            = "gdax.com"
-	BIGONE          = "big.one"
-	FCOIN           = "fcoin.com"
-	FCOIN_MARGIN    = "fcoin.com_margin"
-	FMEX            = "fmex.com"
-	HITBTC          = "hitbtc.com"
-	BITMEX          = "bitmex.com"
-	BITMEX_TEST     = "testnet.bitmex.com"
-	CRYPTOPIA       = "cryptopia.co.nz"
-	HBDM            = "hbdm.com"
-	HBDM_SWAP       = "hbdm.com_swap"
-	COINBENE        = "coinbene.com"
-	ATOP            = "a.top"
-	BITGET_SWAP     = "bitget_swap"
+	KUCOIN           = "kucoin.com"
+	OKCOIN_COM       = "okcoin.com"
+	OKEX             = "okex.com"
+	OKEX_V3          = "okex.com_v3"
+	OKEX_FUTURE      = "okex.com_future"
+	OKEX_SWAP        = "okex.com_swap"
+	HUOBI            = "huobi.com"
+	HUOBI_PRO        = "huobi.pro"
+	BITSTAMP         = "bitstamp.net"
+	KRAKEN           = "kraken.com"
+	ZB               = "zb.com"
+	BITFINEX         = "bitfinex.com"
+	BINANCE          = "binance.com"
+	BINANCE_SWAP     = "binance.com_swap"
+	BINANCE_FUTURES  = "binance.com_futures"
+	POLONIEX         = "poloniex.com"
+	COINEX           = "coinex.com"
+	BITHUMB          = "bithumb.com"
+	GATEIO           = "gate.io"
+	BITTREX          = "bittrex.com"
+	GDAX             = "gdax.com"
+	BIGONE           = "big.one"
+	FCOIN            = "fcoin.com"
+	FCOIN_MARGIN     = "fcoin.com_margin"
+	FMEX             = "fmex.com"
+	HITBTC           = "hitbtc.com"
+	BITMEX           = "bitmex.com"
+	BITMEX_TEST      = "testnet.bitmex.com"
+	DERIBIT          = "deribit.com"
+	DERIBIT_TEST     = "test.deribit.com"
+	BYBIT            = "bybit.com"
+	BYBIT_TEST       = "testnet.bybit.com"
+	CRYPTOPIA        = "cryptopia.co.nz"
+	HBDM             = "hbdm.com"
+	HBDM_SWAP        = "hbdm.com_swap"
+	HBDM_LINEAR_SWAP = "hbdm.com_linear_swap"
+	COINBENE         = "coinbene.com"
+	ATOP             = "a.top"
+	BITGET_SWAP      = "bitget_swap"
 )
 
 const (
@@ -174,6 +179,55 @@ const (
 	SWAP_USDT //usdt本位永续合约
 )
 
+//划转使用的逻辑账户类型, 各交易所映射为自己的账户
+type AccountKind int
+
+func (k AccountKind) String() string {
+	switch k {
+	case ACCOUNT_SPOT:
+		return "spot"
+	case ACCOUNT_MARGIN:
+		return "margin"
+	case ACCOUNT_FUTURES_COIN:
+		return "futures-coin"
+	case ACCOUNT_FUTURES_USDT:
+		return "futures-usdt"
+	case ACCOUNT_FUNDING:
+		return "funding"
+	case ACCOUNT_SUB:
+		return "sub-account"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	ACCOUNT_SPOT         AccountKind = iota + 1 //现货(币币)账户
+	ACCOUNT_MARGIN                              //杠杆账户, TransferRequest.Pair不为空时为逐仓
+	ACCOUNT_FUTURES_COIN                        //币本位合约账户
+	ACCOUNT_FUTURES_USDT                        //U本位合约账户
+	ACCOUNT_FUNDING                             //资金账户
+	ACCOUNT_SUB                                 //子账户, 由TransferRequest.SubAccount指定
+)
+
+type OptionType int
+
+func (t OptionType) String() string {
+	switch t {
+	case OPTION_CALL:
+		return "call"
+	case OPTION_PUT:
+		return "put"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	OPTION_CALL OptionType = iota + 1 //看涨
+	OPTION_PUT                        //看跌
+)
+
 type LimitOrderOptionalParameter int
 
 func (opt LimitOrderOptionalParameter) String() string {
@@ -194,3 +248,34 @@ const (
 	Ioc
 	Fok
 )
+
+//充提状态, 各交易所的状态统一转换后保存在DepositWithdrawHistory.Status, 无法识别的状态为WITHDRAW_UNKNOWN
+type WithdrawStatus int
+
+func (s WithdrawStatus) String() string {
+	switch s {
+	case WITHDRAW_UNKNOWN:
+		return "UNKNOWN"
+	case WITHDRAW_CANCELLED:
+		return "CANCELLED"
+	case WITHDRAW_FAILED:
+		return "FAILED"
+	case WITHDRAW_PENDING:
+		return "PENDING"
+	case WITHDRAW_SENT:
+		return "SENT"
+	case WITHDRAW_COMPLETED:
+		return "COMPLETED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+const (
+	WITHDRAW_UNKNOWN   WithdrawStatus = iota //未知状态, 交易所返回了没有映射的状态
+	WITHDRAW_CANCELLED                       //已撤销
+	WITHDRAW_FAILED                          //失败(拒绝)
+	WITHDRAW_PENDING                         //等待审核/处理中
+	WITHDRAW_SENT                            //已发出, 等待区块确认
+	WITHDRAW_COMPLETED                       //已完成
+)
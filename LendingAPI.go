@@ -0,0 +1,21 @@
+package goex
+
+/**
+ * 理财/放贷, 利率均为年化百分比
+ * okex为余币宝, binance为活期赚币, bitfinex为融资放贷挂单
+ */
+type LendingAPI interface {
+	GetExchangeName() string
+
+	//产品列表, currency为UNKNOWN时返回所有币种
+	GetLendingProducts(currency Currency) ([]LendingProduct, error)
+
+	//申购, 返回申购id, 部分交易所不返回
+	SubscribeLending(param LendingParameter) (string, error)
+
+	//赎回, 返回赎回id, 部分交易所不返回
+	RedeemLending(param LendingParameter) (string, error)
+
+	//持仓及累计收益, currency为UNKNOWN时返回所有币种
+	GetLendingPositions(currency Currency) ([]LendingPosition, error)
+}
@@ -0,0 +1,25 @@
+package goex
+
+// 杠杆交易接口
+type MarginAPI interface {
+	GetExchangeName() string
+
+	//杠杆账户, 全仓模式下返回所有币种
+	GetMarginAccount(pair CurrencyPair) (*MarginAccount, error)
+
+	//借币, 返回借币记录ID
+	Borrow(parameter BorrowParameter) (borrowId string, err error)
+	//还币, 返回还币记录ID
+	Repayment(parameter RepaymentParameter) (repaymentId string, err error)
+	//借币记录, 全仓模式下pair传UNKNOWN_PAIR
+	GetLoanHistory(pair CurrencyPair, currency Currency, optional ...OptionalParameter) ([]MarginLoan, error)
+
+	//风险率, 各交易所定义不同, 与MarginAccount.RiskRate一致
+	GetRiskRate(pair CurrencyPair) (float64, error)
+
+	//杠杆下单, ord.Side为BUY/SELL时为限价单, BUY_MARKET时ord.Price为买入金额
+	PlaceOrder(ord *Order) (*Order, error)
+	CancelOrder(orderId string, pair CurrencyPair) (bool, error)
+	GetOneOrder(orderId string, pair CurrencyPair) (*Order, error)
+	GetUnfinishOrders(pair CurrencyPair) ([]Order, error)
+}
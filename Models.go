@@ -56,6 +56,18 @@ type MarginAccount struct {
 	MarginRatio      float64
 }
 
+//借币记录
+type MarginLoan struct {
+	LoanId      string
+	Pair        CurrencyPair //逐仓借币的交易对, 全仓为UNKNOWN_PAIR
+	Currency    Currency
+	Amount      float64 //借币数量
+	Interest    float64 //已产生利息
+	Repaid      float64 //已还数量
+	Status      string  //交易所原始状态
+	CreatedTime int64   //毫秒
+}
+
 type Account struct {
 	Exchange    string
 	Asset       float64 //总资产
@@ -218,6 +230,50 @@ type FuturesContractInfo struct {
 	ContractType string  //	本周 this_week 次周 next_week 季度 quarter
 }
 
+//期权合约
+type OptionInstrument struct {
+	InstrumentId string
+	Underlying   CurrencyPair
+	Strike       float64
+	OptionType   OptionType
+	Expiry       time.Time
+	ContractVal  float64 //每张合约对应的标的数量
+	TickSize     float64
+	MinSize      float64
+}
+
+//希腊字母, 按Black-Scholes模型计算, Theta为每天, Vega为隐含波动率每变化1%
+type Greeks struct {
+	Delta float64
+	Gamma float64
+	Theta float64
+	Vega  float64
+}
+
+//期权行情, 价格的单位与交易所一致(okex和deribit为标的币种), 隐含波动率为小数
+type OptionTicker struct {
+	InstrumentId    string
+	Last            float64
+	Buy             float64
+	Sell            float64
+	MarkPrice       float64
+	BidIV           float64
+	AskIV           float64
+	MarkIV          float64
+	UnderlyingPrice float64
+	Greeks          Greeks
+	Date            int64 //毫秒
+}
+
+type OptionPosition struct {
+	InstrumentId  string
+	Amount        float64
+	AvgPrice      float64
+	MarkPrice     float64
+	UnrealizedPnl float64
+	Greeks        Greeks
+}
+
 //api parameter struct
 
 type BorrowParameter struct {
@@ -241,6 +297,110 @@ type TransferParameter struct {
 	ToInstrumentId string  `json:"to_instrument_id"`
 }
 
+/**
+ * 账户间划转
+ * From或To为ACCOUNT_SUB时为母子账户划转, SubAccount为子账户(okex为子账户名, binance为邮箱, huobi/kucoin为uid)
+ * SubAccountKind为子账户内的账户类型, 为0时使用交易所默认的账户
+ */
+type TransferRequest struct {
+	Currency       Currency
+	Amount         float64
+	From           AccountKind
+	To             AccountKind
+	SubAccount     string
+	SubAccountKind AccountKind
+	Pair           CurrencyPair //逐仓杠杆的交易对
+}
+
+//是否为逐仓划转, Pair未设置(零值)或为UNKNOWN_PAIR时为全仓
+func (req TransferRequest) Isolated() bool {
+	return req.Pair.CurrencyA.Symbol != "" && req.Pair.CurrencyB.Symbol != "" && !req.Pair.Eq(UNKNOWN_PAIR)
+}
+
+//划转记录
+type TransferRecord struct {
+	TransferId  string
+	Currency    Currency
+	Amount      float64
+	From        AccountKind
+	To          AccountKind
+	SubAccount  string
+	Status      string //交易所原始状态
+	CreatedTime int64  //毫秒
+}
+
+//子账户
+type SubAccountUser struct {
+	SubAccount  string //子账户标识, 见SubAccountAPI
+	Uid         string
+	Name        string
+	Label       string //备注
+	Enable      bool   //false为冻结
+	CreatedTime int64  //毫秒, 部分交易所不返回
+}
+
+/**
+ * 子账户api key
+ * CanTrade为false时只读, CanWithdraw只有okex支持
+ * Passphrase okex/kucoin必填
+ * OtpToken huobi必填, 母账户的谷歌验证码
+ */
+type SubAccountApiKeyParameter struct {
+	SubAccount  string
+	Label       string
+	Passphrase  string
+	OtpToken    string
+	CanTrade    bool
+	CanWithdraw bool
+	IpWhitelist []string
+}
+
+type SubAccountApiKey struct {
+	SubAccount  string
+	Label       string
+	ApiKey      string
+	SecretKey   string
+	Passphrase  string
+	Permissions []string //交易所原始的权限名称
+	IpWhitelist []string
+}
+
+//理财产品
+type LendingProduct struct {
+	ProductId    string
+	Currency     Currency
+	Rate         float64 //年化%
+	Period       int     //天数, 0为活期
+	MinAmount    float64
+	CanSubscribe bool
+	CanRedeem    bool
+}
+
+/**
+ * 申购/赎回
+ * Rate okex为最低出借利率, bitfinex为挂单利率, 为0时使用市场利率
+ * Period bitfinex的挂单天数, 为0时为2天
+ * Amount 赎回时为0表示全部赎回
+ */
+type LendingParameter struct {
+	ProductId string
+	Currency  Currency
+	Amount    float64
+	Rate      float64
+	Period    int
+}
+
+//理财持仓
+type LendingPosition struct {
+	ProductId string
+	Currency  Currency
+	Amount    float64 //本金
+	Lent      float64 //已借出的数量
+	Rate      float64 //年化%
+	Period    int
+	Interest  float64 //累计收益
+}
+
 type WithdrawParameter struct {
 	Currency    string  `json:"currency"`
 	Amount      float64 `json:"amount,string"`
@@ -248,19 +408,40 @@ type WithdrawParameter struct {
 	ToAddress   string  `json:"to_address"`
 	TradePwd    string  `json:"trade_pwd"`
 	Fee         string  `json:"fee"`
+	Chain       string  `json:"chain"` //提币链名称, 与WalletApiExt.GetWithdrawChains返回的Chain一致
+	Memo        string  `json:"memo"`  //memo/tag, 部分币种提币时需要
 }
 
 type DepositWithdrawHistory struct {
-	WithdrawalId string    `json:"withdrawal_id,omitempty"`
-	Currency     string    `json:"currency"`
-	Txid         string    `json:"txid"`
-	Amount       float64   `json:"amount,string"`
-	From         string    `json:"from,omitempty"`
-	To           string    `json:"to"`
-	Memo         string    `json:"memo,omitempty"`
-	Fee          string    `json:"fee"`
-	Status       int       `json:"status,string"`
-	Timestamp    time.Time `json:"timestamp"`
+	WithdrawalId string         `json:"withdrawal_id,omitempty"`
+	Currency     string         `json:"currency"`
+	Txid         string         `json:"txid"`
+	Amount       float64        `json:"amount,string"`
+	From         string         `json:"from,omitempty"`
+	To           string         `json:"to"`
+	Memo         string         `json:"memo,omitempty"`
+	Fee          string         `json:"fee"`
+	Status       WithdrawStatus `json:"status,string"`
+	Timestamp    time.Time      `json:"timestamp"`
+}
+
+//充值地址
+type ChainAddress struct {
+	Currency string
+	Chain    string //链名称, 各交易所命名不同, 如USDT-TRC20(okex), trc20usdt(huobi), TRX(binance)
+	Address  string
+	Tag      string //memo/tag, 部分币种充值时必须填写
+}
+
+//币种在某条链上的充提信息
+type WithdrawChain struct {
+	Currency    string
+	Chain       string
+	CanDeposit  bool
+	CanWithdraw bool
+	MinFee      float64 //最小提币手续费
+	MaxFee      float64 //最大提币手续费, 固定手续费时与MinFee相同
+	MinAmount   float64 //最小提币数量
 }
 
 type OptionalParameter map[string]interface{}
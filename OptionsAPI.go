@@ -0,0 +1,27 @@
+package goex
+
+/**
+ * 期权, instrumentId为交易所的期权合约id, 如okex的BTC-USD-210625-50000-C, deribit的BTC-25JUN21-50000-C
+ * underlying为标的, 如BTC_USD
+ * 订单使用FutureOrder, ContractName为instrumentId, OType为OPEN_BUY(买入)或OPEN_SELL(卖出)
+ */
+type OptionsAPI interface {
+	GetExchangeName() string
+
+	//期权链, 返回标的所有未到期的期权合约
+	GetOptionInstruments(underlying CurrencyPair) ([]OptionInstrument, error)
+
+	//行情, 包括标记价格, 隐含波动率和希腊字母
+	GetOptionTicker(instrumentId string) (*OptionTicker, error)
+
+	LimitOptionOrder(instrumentId string, side TradeSide, price, amount string, opt ...LimitOrderOptionalParameter) (*FutureOrder, error)
+
+	CancelOptionOrder(instrumentId, orderId string) error
+
+	GetOptionOrder(instrumentId, orderId string) (*FutureOrder, error)
+
+	GetUnfinishOptionOrders(underlying CurrencyPair) ([]FutureOrder, error)
+
+	//持仓, Amount为正数是多头, 负数是空头
+	GetOptionPositions(underlying CurrencyPair) ([]OptionPosition, error)
+}
@@ -0,0 +1,19 @@
+package goex
+
+/**
+ * 母子账户管理, 需要使用母账户的api key
+ * 子账户标识: okex为子账户名, binance为邮箱, huobi/kucoin为uid, 与TransferRequest.SubAccount一致
+ * 母子账户划转使用TransferAsset, From或To为ACCOUNT_SUB
+ */
+type SubAccountAPI interface {
+	TransferAPI
+
+	//子账户列表
+	GetSubAccounts() ([]SubAccountUser, error)
+
+	//子账户交易账户(现货)的资产
+	GetSubAccountBalance(subAccount string) (*Account, error)
+
+	//为子账户创建api key, 不支持的交易所返回EX_ERR_NOT_SUPPORT
+	CreateSubAccountApiKey(param SubAccountApiKeyParameter) (*SubAccountApiKey, error)
+}
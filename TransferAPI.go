@@ -0,0 +1,12 @@
+package goex
+
+//账户间划转, 包括母子账户之间的划转
+type TransferAPI interface {
+	GetExchangeName() string
+
+	//划转, 返回划转id, 部分交易所不返回
+	TransferAsset(req TransferRequest) (transferId string, err error)
+
+	//划转记录, from/to为0时不过滤, 不支持的交易所返回EX_ERR_NOT_SUPPORT
+	GetTransferHistory(currency Currency, from, to AccountKind, optional ...OptionalParameter) ([]TransferRecord, error)
+}
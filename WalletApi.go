@@ -12,3 +12,14 @@ type WalletApi interface {
 	//获取充值记录
 	GetDepositHistory(currency *Currency) ([]DepositWithdrawHistory, error)
 }
+
+//钱包扩展接口, Withdrawal返回的提币id可用于撤销提币
+type WalletApiExt interface {
+	WalletApi
+	//获取充值地址, chain为空时返回所有链的地址
+	GetDepositAddress(currency Currency, chain string) ([]ChainAddress, error)
+	//获取币种支持的链及提币手续费和最小提币数量
+	GetWithdrawChains(currency Currency) ([]WithdrawChain, error)
+	//撤销提币, 只能撤销未发出的提币
+	CancelWithdrawal(withdrawId string) error
+}
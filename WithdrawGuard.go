@@ -0,0 +1,247 @@
+package goex
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucas7788/goex/internal/logger"
+)
+
+//审计记录的结果
+const (
+	WithdrawAuditRejected   = "rejected"   //未通过策略校验
+	WithdrawAuditUnapproved = "unapproved" //审批未通过
+	WithdrawAuditFailed     = "failed"     //交易所返回错误
+	WithdrawAuditSuccess    = "success"
+)
+
+//白名单地址
+type WhitelistAddress struct {
+	Currency string //币种, 如USDT
+	Chain    string //链名称, 为空时匹配任意链
+	Address  string
+	Memo     string //为空时不校验memo, 需要memo的链(XRP, EOS, XLM, ATOM)必须填写
+}
+
+//提币审计记录, 不包含资金密码
+type WithdrawAudit struct {
+	Time       time.Time
+	Currency   string
+	Chain      string
+	Amount     float64
+	ToAddress  string
+	Memo       string
+	WithdrawId string
+	Result     string
+	Reason     string
+}
+
+/**
+ * 提币安全策略
+ * Whitelist为空时拒绝所有提币
+ * DailyLimit 币种(不区分大小写) => 每个自然日(UTC)的最大提币数量, 未配置的币种不限额
+ * TradePwd 资金密码由策略统一设置, 调用方传入的TradePwd会被忽略
+ * Approve 二次审批, 校验通过后调用, 返回nil表示批准
+ * Audit 审计日志, 默认输出到logger
+ */
+type WithdrawPolicy struct {
+	Whitelist  []WhitelistAddress
+	DailyLimit map[string]float64
+	TradePwd   string
+	Approve    func(param WithdrawParameter) error
+	Audit      func(record WithdrawAudit)
+}
+
+/**
+ * 带安全策略的钱包, 除Withdrawal外的方法直接调用被包装的钱包
+ * 提币依次校验: 白名单 => 地址格式 => 当日限额 => 审批, 每次尝试都会记录审计日志
+ * 当日已提数量只统计成功的提币, 保存在内存中
+ */
+type WithdrawGuard struct {
+	wallet WalletApi
+	policy WithdrawPolicy
+	lock   sync.Mutex
+	day    string
+	used   map[string]float64
+	now    func() time.Time
+}
+
+func NewWithdrawGuard(wallet WalletApi, policy WithdrawPolicy) *WithdrawGuard {
+	//限额按大写币种查找, 复制一份避免修改调用方的map
+	dailyLimit := make(map[string]float64, len(policy.DailyLimit))
+	for currency, limit := range policy.DailyLimit {
+		dailyLimit[strings.ToUpper(currency)] = limit
+	}
+	policy.DailyLimit = dailyLimit
+	return &WithdrawGuard{
+		wallet: wallet,
+		policy: policy,
+		used:   make(map[string]float64, 4),
+		now:    time.Now,
+	}
+}
+
+func (g *WithdrawGuard) GetAccount() (*Account, error) {
+	return g.wallet.GetAccount()
+}
+
+func (g *WithdrawGuard) Transfer(param TransferParameter) error {
+	return g.wallet.Transfer(param)
+}
+
+func (g *WithdrawGuard) GetWithDrawHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	return g.wallet.GetWithDrawHistory(currency)
+}
+
+func (g *WithdrawGuard) GetDepositHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	return g.wallet.GetDepositHistory(currency)
+}
+
+//需要memo的链只匹配填写了memo的白名单地址, 避免memo错误导致资金无法入账
+func (g *WithdrawGuard) inWhitelist(param WithdrawParameter) bool {
+	requireMemo := false
+	if param.Destination == 0 || param.Destination == 4 {
+		if rule := findAddressRule(param.Currency, param.Chain); rule != nil {
+			requireMemo = rule.memo
+		}
+	}
+	for _, w := range g.policy.Whitelist {
+		if !strings.EqualFold(w.Currency, param.Currency) || w.Address != param.ToAddress {
+			continue
+		}
+		if w.Chain != "" && !strings.EqualFold(w.Chain, param.Chain) {
+			continue
+		}
+		if (w.Memo != "" || requireMemo) && w.Memo != param.Memo {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+//不发起提币, 只做白名单, 地址格式和限额校验
+func (g *WithdrawGuard) CheckWithdrawal(param WithdrawParameter) error {
+	if param.Amount <= 0 {
+		return EX_ERR_WITHDRAW_REJECTED.OriginErr("invalid amount")
+	}
+	if !g.inWhitelist(param) {
+		return EX_ERR_WITHDRAW_REJECTED.OriginErr(fmt.Sprintf("address %s is not in the %s whitelist", param.ToAddress, param.Currency))
+	}
+	//提币到交易所账户(邮箱/手机号)时不校验地址格式
+	if param.Destination == 0 || param.Destination == 4 {
+		if err := ValidateWithdrawAddress(param.Currency, param.Chain, param.ToAddress, param.Memo); err != nil {
+			return EX_ERR_WITHDRAW_REJECTED.OriginErr(err.Error())
+		}
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	currency := strings.ToUpper(param.Currency)
+	if limit, ok := g.policy.DailyLimit[currency]; ok && g.usedToday(currency)+param.Amount > limit {
+		return EX_ERR_WITHDRAW_REJECTED.OriginErr(fmt.Sprintf("exceed the %s daily limit %v", currency, limit))
+	}
+	return nil
+}
+
+//需要持有锁
+func (g *WithdrawGuard) usedToday(currency string) float64 {
+	day := g.now().UTC().Format("2006-01-02")
+	if day != g.day {
+		g.day = day
+		g.used = make(map[string]float64, 4)
+	}
+	return g.used[currency]
+}
+
+//当日已提数量
+func (g *WithdrawGuard) UsedToday(currency string) float64 {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.usedToday(strings.ToUpper(currency))
+}
+
+func (g *WithdrawGuard) Withdrawal(param WithdrawParameter) (withdrawId string, err error) {
+	record := WithdrawAudit{
+		Time:      g.now(),
+		Currency:  param.Currency,
+		Chain:     param.Chain,
+		Amount:    param.Amount,
+		ToAddress: param.ToAddress,
+		Memo:      param.Memo,
+	}
+	defer func() {
+		if err != nil {
+			record.Reason = err.Error()
+		}
+		record.WithdrawId = withdrawId
+		g.audit(record)
+	}()
+
+	day, err := g.reserve(param)
+	if err != nil {
+		record.Result = WithdrawAuditRejected
+		return "", err
+	}
+
+	if g.policy.Approve != nil {
+		if err = g.policy.Approve(param); err != nil {
+			g.release(param, day)
+			record.Result = WithdrawAuditUnapproved
+			return "", EX_ERR_WITHDRAW_REJECTED.OriginErr("not approved: " + err.Error())
+		}
+	}
+
+	param.TradePwd = g.policy.TradePwd
+	withdrawId, err = g.wallet.Withdrawal(param)
+	if err != nil {
+		g.release(param, day)
+		record.Result = WithdrawAuditFailed
+		return "", err
+	}
+	record.Result = WithdrawAuditSuccess
+	return withdrawId, nil
+}
+
+//校验并预占当日额度, 避免并发提币超过限额, 返回预占额度的日期
+func (g *WithdrawGuard) reserve(param WithdrawParameter) (string, error) {
+	if err := g.CheckWithdrawal(param); err != nil {
+		return "", err
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	currency := strings.ToUpper(param.Currency)
+	used := g.usedToday(currency)
+	if limit, ok := g.policy.DailyLimit[currency]; ok && used+param.Amount > limit {
+		return "", EX_ERR_WITHDRAW_REJECTED.OriginErr(fmt.Sprintf("exceed the %s daily limit %v", currency, limit))
+	}
+	g.used[currency] = used + param.Amount
+	return g.day, nil
+}
+
+//只释放预占当天的额度, 跨天后额度已经重新计算
+func (g *WithdrawGuard) release(param WithdrawParameter, day string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	currency := strings.ToUpper(param.Currency)
+	used := g.usedToday(currency)
+	if day != g.day {
+		return
+	}
+	if used > param.Amount {
+		g.used[currency] = used - param.Amount
+	} else {
+		g.used[currency] = 0
+	}
+}
+
+func (g *WithdrawGuard) audit(record WithdrawAudit) {
+	if g.policy.Audit != nil {
+		g.policy.Audit(record)
+		return
+	}
+	logger.Infof("[withdraw audit] result=%s currency=%s chain=%s amount=%v to=%s memo=%s id=%s reason=%s",
+		record.Result, record.Currency, record.Chain, record.Amount, record.ToAddress, record.Memo, record.WithdrawId, record.Reason)
+}
@@ -0,0 +1,141 @@
+package goex
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockWallet struct {
+	WalletApi
+	params []WithdrawParameter
+	err    error
+}
+
+func (w *mockWallet) Withdrawal(param WithdrawParameter) (string, error) {
+	if w.err != nil {
+		return "", w.err
+	}
+	w.params = append(w.params, param)
+	return "wd-1", nil
+}
+
+func TestWithdrawGuard_Withdrawal(t *testing.T) {
+	wallet := &mockWallet{}
+	var audits []WithdrawAudit
+	var approved bool
+	guard := NewWithdrawGuard(wallet, WithdrawPolicy{
+		Whitelist: []WhitelistAddress{
+			{Currency: "USDT", Chain: "USDT-TRC20", Address: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+			{Currency: "USDT", Chain: "USDT-ERC20", Address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"},
+			{Currency: "XRP", Address: "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", Memo: "1001"},
+			{Currency: "EOS", Address: "eosaccount11"},
+		},
+		DailyLimit: map[string]float64{"USDT": 1000},
+		TradePwd:   "pwd",
+		Approve: func(param WithdrawParameter) error {
+			if !approved {
+				return errors.New("rejected by ops")
+			}
+			return nil
+		},
+		Audit: func(record WithdrawAudit) {
+			audits = append(audits, record)
+		},
+	})
+	now := time.Date(2020, 1, 1, 23, 0, 0, 0, time.UTC)
+	guard.now = func() time.Time { return now }
+
+	trc20 := WithdrawParameter{Currency: "usdt", Chain: "USDT-TRC20", ToAddress: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", Amount: 600, TradePwd: "caller"}
+
+	//审批未通过
+	_, err := guard.Withdrawal(trc20)
+	assert.Equal(t, EX_ERR_WITHDRAW_REJECTED.ErrCode, err.(ApiError).ErrCode)
+	assert.Equal(t, WithdrawAuditUnapproved, audits[0].Result)
+	assert.Equal(t, 0.0, guard.UsedToday("USDT"))
+
+	approved = true
+	id, err := guard.Withdrawal(trc20)
+	assert.Nil(t, err)
+	assert.Equal(t, "wd-1", id)
+	assert.Equal(t, "pwd", wallet.params[0].TradePwd)
+	assert.Equal(t, WithdrawAuditSuccess, audits[1].Result)
+	assert.Equal(t, 600.0, guard.UsedToday("USDT"))
+
+	//超过当日限额
+	_, err = guard.Withdrawal(trc20)
+	assert.NotNil(t, err)
+	assert.Equal(t, WithdrawAuditRejected, audits[2].Result)
+
+	//白名单中的链不匹配
+	_, err = guard.Withdrawal(WithdrawParameter{Currency: "USDT", Chain: "USDT-ERC20", ToAddress: trc20.ToAddress, Amount: 1})
+	assert.NotNil(t, err)
+
+	//EIP-55校验和错误
+	_, err = guard.Withdrawal(WithdrawParameter{Currency: "USDT", Chain: "USDT-ERC20", ToAddress: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", Amount: 1})
+	assert.NotNil(t, err)
+
+	//缺少memo或memo不匹配
+	assert.NotNil(t, guard.CheckWithdrawal(WithdrawParameter{Currency: "XRP", ToAddress: "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", Amount: 10}))
+	assert.NotNil(t, guard.CheckWithdrawal(WithdrawParameter{Currency: "XRP", ToAddress: "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", Memo: "1002", Amount: 10}))
+	assert.Nil(t, guard.CheckWithdrawal(WithdrawParameter{Currency: "XRP", ToAddress: "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", Memo: "1001", Amount: 10}))
+
+	//需要memo的链, 白名单地址没有填写memo时不匹配
+	assert.NotNil(t, guard.CheckWithdrawal(WithdrawParameter{Currency: "EOS", ToAddress: "eosaccount11", Memo: "1001", Amount: 10}))
+
+	//交易所返回错误时释放额度
+	wallet.err = errors.New("insufficient balance")
+	_, err = guard.Withdrawal(WithdrawParameter{Currency: "USDT", Chain: "USDT-TRC20", ToAddress: trc20.ToAddress, Amount: 400})
+	assert.NotNil(t, err)
+	assert.Equal(t, WithdrawAuditFailed, audits[len(audits)-1].Result)
+	assert.Equal(t, 600.0, guard.UsedToday("USDT"))
+
+	//第二天重新计算
+	now = now.Add(2 * time.Hour)
+	wallet.err = nil
+	_, err = guard.Withdrawal(trc20)
+	assert.Nil(t, err)
+	assert.Equal(t, 600.0, guard.UsedToday("USDT"))
+}
+
+func TestWithdrawGuard_ReleaseAcrossDay(t *testing.T) {
+	wallet := &mockWallet{}
+	guard := NewWithdrawGuard(wallet, WithdrawPolicy{
+		Whitelist:  []WhitelistAddress{{Currency: "USDT", Address: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"}},
+		DailyLimit: map[string]float64{"USDT": 1000},
+		Audit:      func(record WithdrawAudit) {},
+	})
+	now := time.Date(2020, 1, 1, 23, 59, 0, 0, time.UTC)
+	guard.now = func() time.Time { return now }
+	param := WithdrawParameter{Currency: "USDT", Chain: "USDT-TRC20", ToAddress: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", Amount: 400}
+
+	day, err := guard.reserve(param)
+	assert.Nil(t, err)
+	assert.Equal(t, "2020-01-01", day)
+
+	//第二天的提币成功后, 前一天预占的额度释放时不影响当天的额度
+	now = now.Add(2 * time.Minute)
+	_, err = guard.Withdrawal(WithdrawParameter{Currency: "USDT", Chain: "USDT-TRC20", ToAddress: param.ToAddress, Amount: 600})
+	assert.Nil(t, err)
+	guard.release(param, day)
+	assert.Equal(t, 600.0, guard.UsedToday("USDT"))
+}
+
+func TestWithdrawGuard_DailyLimitCase(t *testing.T) {
+	limit := map[string]float64{"usdt": 1000}
+	guard := NewWithdrawGuard(&mockWallet{}, WithdrawPolicy{
+		Whitelist:  []WhitelistAddress{{Currency: "USDT", Address: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"}},
+		DailyLimit: limit,
+		Audit:      func(record WithdrawAudit) {},
+	})
+	param := WithdrawParameter{Currency: "USDT", Chain: "USDT-TRC20", ToAddress: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", Amount: 600}
+
+	_, err := guard.Withdrawal(param)
+	assert.Nil(t, err)
+	//小写币种配置的限额同样生效
+	_, err = guard.Withdrawal(param)
+	assert.NotNil(t, err)
+	assert.Equal(t, map[string]float64{"usdt": 1000}, limit)
+}
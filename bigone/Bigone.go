@@ -27,7 +27,7 @@ type Bigone struct {
 	httpClient *http.Client
 	uid        string
 	baseUri    string
-	timeOffset int64
+	clock      *goex.ClockSync
 }
 
 func New(client *http.Client, api_key, secret_key string) *Bigone {
@@ -44,7 +44,7 @@ func NewV3(client *http.Client, api_key, secret_key string) *BigoneV3 {
 	b1.httpClient = client
 	b1.uid = uuid.New().String()
 	b1.baseUri = V3
-	b1.setTimeOffset()
+	b1.clock = goex.SharedClockSync(V3+"/ping", b1.GetServerTime)
 	return b1
 }
 
@@ -68,20 +68,17 @@ type ServerTimestampResp struct {
 	} `json:"data"`
 }
 
-func (bo *BigoneV3) setTimeOffset() {
+// GetServerTime 返回服务器毫秒时间戳(ping接口返回的是纳秒)
+func (bo *BigoneV3) GetServerTime() (int64, error) {
 	pingUri := fmt.Sprintf("%s/ping", bo.baseUri)
 
 	var resp ServerTimestampResp
-	//log.Printf("GetPing -> %s", pingUri)
 	err := goex.HttpGet4(bo.httpClient, pingUri, nil, &resp)
-
 	if err != nil {
 		log.Printf("GetPing - HttpGet4 failed : %v", err)
-		return
+		return 0, err
 	}
-	bo.timeOffset = time.Now().UnixNano() - resp.Data.Timetamp
-	//log.Println(resp)
-	return
+	return resp.Data.Timetamp / int64(time.Millisecond), nil
 }
 
 func (bo *BigoneV3) GetTicker(currency goex.CurrencyPair) (*goex.Ticker, error) {
@@ -176,7 +173,7 @@ func (bo *BigoneV3) privateHeader() map[string]string {
 	claims := jwt.ClaimSet{
 		"type":  "OpenAPI",
 		"sub":   bo.accessKey,
-		"nonce": time.Now().UnixNano() - bo.timeOffset,
+		"nonce": bo.clock.Now().UnixNano(),
 	}
 	token, err := claims.Sign(bo.secretKey)
 	if nil != err {
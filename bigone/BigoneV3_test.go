@@ -32,7 +32,7 @@ var (
 
 func TestNewV3(t *testing.T) {
 	return
-	b1.setTimeOffset()
+	t.Log(b1.clock.Sync(), b1.clock.Offset())
 }
 func TestBigoneV3_GetTicker(t *testing.T) {
 	return
@@ -52,7 +52,7 @@ func TestBigoneV3_GetUnfinishOrders(t *testing.T) {
 }
 func TestBigoneV3_GetOrderHistorys(t *testing.T) {
 	return
-	t.Log(b1.GetOrderHistorys(BTC_USDT, 1, 1))
+	t.Log(b1.GetOrderHistorys(BTC_USDT, OptionalParameter{}))
 }
 func TestBigoneV3_LimitSell(t *testing.T) {
 	return
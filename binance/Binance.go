@@ -163,13 +163,13 @@ type Binance struct {
 	apiV1      string
 	apiV3      string
 	httpClient *http.Client
-	timeOffset int64 //nanosecond
+	clock      *ClockSync
 	*ExchangeInfo
 }
 
 func (bn *Binance) buildParamsSigned(postForm *url.Values) error {
 	postForm.Set("recvWindow", "60000")
-	tonce := strconv.FormatInt(time.Now().UnixNano()+bn.timeOffset, 10)[0:13]
+	tonce := strconv.FormatInt(bn.clock.NowMillis(), 10)
 	postForm.Set("timestamp", tonce)
 	payload := postForm.Encode()
 	sign, _ := GetParamHmacSHA256Sign(bn.secretKey, payload)
@@ -197,7 +197,7 @@ func NewWithConfig(config *APIConfig) *Binance {
 		accessKey:  config.ApiKey,
 		secretKey:  config.ApiSecretKey,
 		httpClient: config.HttpClient}
-	bn.setTimeOffset()
+	bn.clock = SharedClockSync(bn.apiV3+SERVER_TIME_URL, bn.GetServerTime)
 	return bn
 }
 
@@ -213,18 +213,17 @@ func (bn *Binance) Ping() bool {
 	return true
 }
 
-func (bn *Binance) setTimeOffset() error {
+func (bn *Binance) GetServerTime() (int64, error) {
 	respmap, err := HttpGet(bn.httpClient, bn.apiV3+SERVER_TIME_URL)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	return ToInt64(respmap["serverTime"]), nil
+}
 
-	stime := int64(ToInt(respmap["serverTime"]))
-	st := time.Unix(stime/1000, 1000000*(stime%1000))
-	lt := time.Now()
-	offset := st.Sub(lt).Nanoseconds()
-	bn.timeOffset = int64(offset)
-	return nil
+// GetClockSync 服务器时间同步,可通过Offset()获取本地时钟偏差
+func (bn *Binance) GetClockSync() *ClockSync {
+	return bn.clock
 }
 
 func (bn *Binance) GetTicker(currency CurrencyPair) (*Ticker, error) {
@@ -624,6 +623,35 @@ func (bn *Binance) GetTradeSymbol(currencyPair CurrencyPair) (*TradeSymbol, erro
 	return nil, errors.New("symbol not found")
 }
 
+//签名请求, GET/DELETE参数放在url中
+func (bn *Binance) doRequest(method, uri string, params url.Values, result interface{}) error {
+	bn.buildParamsSigned(&params)
+	reqUrl := bn.baseUrl + uri
+	postData := ""
+	if method == "GET" || method == "DELETE" {
+		reqUrl += "?" + params.Encode()
+	} else {
+		postData = params.Encode()
+	}
+
+	resp, err := NewHttpRequest(bn.httpClient, method, reqUrl, postData, map[string]string{
+		"X-MBX-APIKEY": bn.accessKey,
+		"Content-Type": "application/x-www-form-urlencoded"})
+	if err != nil {
+		return bn.adaptError(err)
+	}
+
+	var errResp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if json.Unmarshal(resp, &errResp) == nil && errResp.Code < 0 {
+		return bn.adaptError(errors.New(string(resp)))
+	}
+
+	return json.Unmarshal(resp, result)
+}
+
 func (bn *Binance) adaptError(err error) error {
 	errStr := err.Error()
 
@@ -94,12 +94,21 @@ func NewBinanceFutures(config *APIConfig) *BinanceFutures {
 	}
 
 	bs.base.apiV1 = config.Endpoint + "/dapi/v1/"
+	bs.base.clock = SharedClockSync(bs.base.apiV1+SERVER_TIME_URL, bs.GetServerTime)
 
 	go bs.GetExchangeInfo()
 
 	return bs
 }
 
+func (bs *BinanceFutures) GetServerTime() (int64, error) {
+	respmap, err := HttpGet(bs.base.httpClient, bs.base.apiV1+SERVER_TIME_URL)
+	if err != nil {
+		return 0, err
+	}
+	return ToInt64(respmap["serverTime"]), nil
+}
+
 func (bs *BinanceFutures) SetBaseUri(uri string) {
 	bs.base.baseUrl = uri
 }
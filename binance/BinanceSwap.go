@@ -42,7 +42,7 @@ func NewBinanceSwap(config *APIConfig) *BinanceSwap {
 			Lever:        config.Lever,
 		}),
 	}
-	bs.setTimeOffset()
+	bs.clock = SharedClockSync(bs.apiV1+SERVER_TIME_URL, bs.GetServerTime)
 	return bs
 }
 
@@ -62,20 +62,6 @@ func (bs *BinanceSwap) Ping() bool {
 	return true
 }
 
-func (bs *BinanceSwap) setTimeOffset() error {
-	respmap, err := HttpGet(bs.httpClient, bs.apiV1+SERVER_TIME_URL)
-	if err != nil {
-		return err
-	}
-
-	stime := int64(ToInt(respmap["serverTime"]))
-	st := time.Unix(stime/1000, 1000000*(stime%1000))
-	lt := time.Now()
-	offset := st.Sub(lt).Nanoseconds()
-	bs.timeOffset = int64(offset)
-	return nil
-}
-
 func (bs *BinanceSwap) GetFutureEstimatedPrice(currencyPair CurrencyPair) (float64, error) {
 	panic("not supported.")
 }
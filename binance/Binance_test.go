@@ -82,9 +82,9 @@ func TestBinance_GetTradeSymbols(t *testing.T) {
 	t.Log(ba.GetTradeSymbol(goex.BTC_USDT))
 }
 
-func TestBinance_SetTimeOffset(t *testing.T) {
-	t.Log(ba.setTimeOffset())
-	t.Log(ba.timeOffset)
+func TestBinance_ClockSync(t *testing.T) {
+	t.Log(ba.GetClockSync().Sync())
+	t.Log(ba.GetClockSync().Offset(), ba.GetClockSync().RTT())
 }
 
 func TestBinance_GetOrderHistorys(t *testing.T) {
@@ -15,9 +15,8 @@ import (
 )
 
 type FuturesWs struct {
-	base  *BinanceFutures
-	fOnce sync.Once
-	dOnce sync.Once
+	base *BinanceFutures
+	lock sync.Mutex //保护f, d和wsBuilder
 
 	wsBuilder *goex.WsBuilder
 	f         *goex.WsConn
@@ -33,7 +32,8 @@ func NewFuturesWs() *FuturesWs {
 
 	futuresWs.wsBuilder = goex.NewWsBuilder().
 		ProxyUrl(os.Getenv("HTTPS_PROXY")).
-		ProtoHandleFunc(futuresWs.handle).AutoReconnect()
+		ProtoHandleFunc(futuresWs.handle).
+		TimestampFunc(futuresWsTimestamp).AutoReconnect()
 
 	httpCli := &http.Client{
 		Timeout: 10 * time.Second,
@@ -57,16 +57,40 @@ func NewFuturesWs() *FuturesWs {
 	return futuresWs
 }
 
-func (s *FuturesWs) connectUsdtFutures() {
-	s.fOnce.Do(func() {
-		s.f = s.wsBuilder.WsUrl("wss://fstream.binance.com/ws").Build()
-	})
+//连接失败时下一次订阅重新连接
+func (s *FuturesWs) connectUsdtFutures() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.f != nil {
+		return nil
+	}
+	var err error
+	s.f, err = s.wsBuilder.WsUrl("wss://fstream.binance.com/ws").Build()
+	return err
 }
 
-func (s *FuturesWs) connectFutures() {
-	s.dOnce.Do(func() {
-		s.d = s.wsBuilder.WsUrl("wss://dstream.binance.com/ws").Build()
-	})
+func (s *FuturesWs) connectFutures() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.d != nil {
+		return nil
+	}
+	var err error
+	s.d, err = s.wsBuilder.WsUrl("wss://dstream.binance.com/ws").Build()
+	return err
+}
+
+//已建立的连接(U本位和币本位)的统计信息, WsUrl区分连接
+func (s *FuturesWs) Stats() []goex.WsStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var stats []goex.WsStats
+	for _, c := range []*goex.WsConn{s.f, s.d} {
+		if c != nil {
+			stats = append(stats, c.Stats())
+		}
+	}
+	return stats
 }
 
 func (s *FuturesWs) DepthCallback(f func(depth *goex.Depth)) {
@@ -84,12 +108,18 @@ func (s *FuturesWs) TradeCallback(f func(trade *goex.Trade, contract string)) {
 func (s *FuturesWs) SubscribeDepth(pair goex.CurrencyPair, contractType string) error {
 	switch contractType {
 	case goex.SWAP_USDT_CONTRACT:
+		if err := s.connectUsdtFutures(); err != nil {
+			return err
+		}
 		return s.f.Subscribe(req{
 			Method: "SUBSCRIBE",
 			Params: []string{pair.AdaptUsdToUsdt().ToLower().ToSymbol("") + "@depth10@100ms"},
 			Id:     1,
 		})
 	default:
+		if err := s.connectFutures(); err != nil {
+			return err
+		}
 		sym, _ := s.base.adaptToSymbol(pair.AdaptUsdtToUsd(), contractType)
 		return s.d.Subscribe(req{
 			Method: "SUBSCRIBE",
@@ -103,14 +133,18 @@ func (s *FuturesWs) SubscribeDepth(pair goex.CurrencyPair, contractType string)
 func (s *FuturesWs) SubscribeTicker(pair goex.CurrencyPair, contractType string) error {
 	switch contractType {
 	case goex.SWAP_USDT_CONTRACT:
-		s.connectUsdtFutures()
+		if err := s.connectUsdtFutures(); err != nil {
+			return err
+		}
 		return s.f.Subscribe(req{
 			Method: "SUBSCRIBE",
 			Params: []string{pair.AdaptUsdToUsdt().ToLower().ToSymbol("") + "@ticker"},
 			Id:     1,
 		})
 	default:
-		s.connectFutures()
+		if err := s.connectFutures(); err != nil {
+			return err
+		}
 		sym, _ := s.base.adaptToSymbol(pair.AdaptUsdtToUsd(), contractType)
 		return s.d.Subscribe(req{
 			Method: "SUBSCRIBE",
@@ -159,6 +193,13 @@ func (s *FuturesWs) handle(data []byte) error {
 	return nil
 }
 
+//消息的事件时间E, 用于统计延迟
+func futuresWsTimestamp(msg []byte) time.Time {
+	var m map[string]json.RawMessage
+	json.Unmarshal(msg, &m)
+	return wsEventTime(m)
+}
+
 func (s *FuturesWs) depthHandle(bids []interface{}, asks []interface{}) *goex.Depth {
 	var dep goex.Depth
 
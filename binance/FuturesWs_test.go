@@ -2,6 +2,7 @@ package binance
 
 import (
 	"github.com/lucas7788/goex"
+	"github.com/stretchr/testify/assert"
 	"log"
 	"os"
 	"testing"
@@ -43,3 +44,10 @@ func TestFuturesWs_SubscribeTicker(t *testing.T) {
 
 	time.Sleep(30 * time.Second)
 }
+
+func TestFuturesWsTimestamp(t *testing.T) {
+	ts := futuresWsTimestamp([]byte(`{"e":"depthUpdate","E":1600000000123,"T":1600000000120,"s":"BTCUSDT"}`))
+	assert.Equal(t, int64(1600000000123), ts.UnixNano()/int64(time.Millisecond))
+	assert.True(t, futuresWsTimestamp([]byte(`{"result":null,"id":1}`)).IsZero())
+	assert.Empty(t, new(FuturesWs).Stats())
+}
@@ -0,0 +1,140 @@
+package binance
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	. "github.com/lucas7788/goex"
+)
+
+//活期赚币, 利率为小数
+type flexibleProduct struct {
+	Asset                      string `json:"asset"`
+	ProductId                  string `json:"productId"`
+	LatestAnnualPercentageRate string `json:"latestAnnualPercentageRate"`
+	CanPurchase                bool   `json:"canPurchase"`
+	CanRedeem                  bool   `json:"canRedeem"`
+	MinPurchaseAmount          string `json:"minPurchaseAmount"`
+}
+
+func lendingParams(currency Currency) url.Values {
+	params := url.Values{}
+	params.Set("size", "100")
+	if currency != UNKNOWN {
+		params.Set("asset", strings.ToUpper(currency.Symbol))
+	}
+	return params
+}
+
+//活期产品, 最多返回100个
+func (w *Wallet) GetLendingProducts(currency Currency) ([]LendingProduct, error) {
+	var response struct {
+		Rows []flexibleProduct `json:"rows"`
+	}
+	err := w.ba.doRequest("GET", "/sapi/v1/simple-earn/flexible/list", lendingParams(currency), &response)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]LendingProduct, 0, len(response.Rows))
+	for _, r := range response.Rows {
+		products = append(products, LendingProduct{
+			ProductId:    r.ProductId,
+			Currency:     NewCurrency(r.Asset, ""),
+			Rate:         ToFloat64(r.LatestAnnualPercentageRate) * 100,
+			MinAmount:    ToFloat64(r.MinPurchaseAmount),
+			CanSubscribe: r.CanPurchase,
+			CanRedeem:    r.CanRedeem,
+		})
+	}
+	return products, nil
+}
+
+//ProductId为空时使用币种的活期产品
+func (w *Wallet) lendingProductId(param LendingParameter) (string, error) {
+	if param.ProductId != "" {
+		return param.ProductId, nil
+	}
+	products, err := w.GetLendingProducts(param.Currency)
+	if err != nil {
+		return "", err
+	}
+	if len(products) == 0 {
+		return "", EX_ERR_NOT_SUPPORT.OriginErr("no flexible product for " + param.Currency.Symbol)
+	}
+	return products[0].ProductId, nil
+}
+
+func (w *Wallet) SubscribeLending(param LendingParameter) (string, error) {
+	productId, err := w.lendingProductId(param)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("productId", productId)
+	params.Set("amount", FloatToString(param.Amount, 8))
+
+	var response struct {
+		PurchaseId json.Number `json:"purchaseId"`
+	}
+	err = w.ba.doRequest("POST", "/sapi/v1/simple-earn/flexible/subscribe", params, &response)
+	if err != nil {
+		return "", err
+	}
+	return response.PurchaseId.String(), nil
+}
+
+func (w *Wallet) RedeemLending(param LendingParameter) (string, error) {
+	productId, err := w.lendingProductId(param)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("productId", productId)
+	if param.Amount == 0 {
+		params.Set("redeemAll", "true")
+	} else {
+		params.Set("amount", FloatToString(param.Amount, 8))
+	}
+
+	var response struct {
+		RedeemId json.Number `json:"redeemId"`
+	}
+	err = w.ba.doRequest("POST", "/sapi/v1/simple-earn/flexible/redeem", params, &response)
+	if err != nil {
+		return "", err
+	}
+	return response.RedeemId.String(), nil
+}
+
+func (w *Wallet) GetLendingPositions(currency Currency) ([]LendingPosition, error) {
+	var response struct {
+		Rows []struct {
+			Asset                      string `json:"asset"`
+			ProductId                  string `json:"productId"`
+			TotalAmount                string `json:"totalAmount"`
+			LatestAnnualPercentageRate string `json:"latestAnnualPercentageRate"`
+			CumulativeTotalRewards     string `json:"cumulativeTotalRewards"`
+		} `json:"rows"`
+	}
+	err := w.ba.doRequest("GET", "/sapi/v1/simple-earn/flexible/position", lendingParams(currency), &response)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]LendingPosition, 0, len(response.Rows))
+	for _, r := range response.Rows {
+		positions = append(positions, LendingPosition{
+			ProductId: r.ProductId,
+			Currency:  NewCurrency(r.Asset, ""),
+			Amount:    ToFloat64(r.TotalAmount),
+			Lent:      ToFloat64(r.TotalAmount),
+			Rate:      ToFloat64(r.LatestAnnualPercentageRate) * 100,
+			Interest:  ToFloat64(r.CumulativeTotalRewards),
+		})
+	}
+	return positions, nil
+}
@@ -0,0 +1,58 @@
+package binance
+
+import (
+	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWallet_Lending(t *testing.T) {
+	var form map[string]string
+	srv := testserver.New(binanceAPI, map[string]testserver.Route{
+		"GET /sapi/v1/simple-earn/flexible/list": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "USDT", params["asset"])
+			return `{"rows":[{"asset":"USDT","latestAnnualPercentageRate":"0.0236","canPurchase":true,"canRedeem":true,
+				"minPurchaseAmount":"0.1","productId":"USDT001"}],"total":1}`
+		},
+		"POST /sapi/v1/simple-earn/flexible/subscribe": func(r *testserver.Request) interface{} {
+			form = r.Params()
+			return `{"purchaseId":40607,"success":true}`
+		},
+		"POST /sapi/v1/simple-earn/flexible/redeem": func(r *testserver.Request) interface{} {
+			form = r.Params()
+			return `{"redeemId":40608,"success":true}`
+		},
+		"GET /sapi/v1/simple-earn/flexible/position": func(r *testserver.Request) interface{} {
+			return `{"rows":[{"totalAmount":"75.46","latestAnnualPercentageRate":"0.02599895","asset":"USDT","productId":"USDT001",
+				"cumulativeTotalRewards":"0.45459183","canRedeem":true}],"total":1}`
+		},
+	})
+	defer srv.Close()
+	conf := testserver.Config(srv)
+
+	w := NewWallet(conf)
+	products, err := w.GetLendingProducts(goex.USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.LendingProduct{{ProductId: "USDT001", Currency: goex.USDT, Rate: 2.36, MinAmount: 0.1,
+		CanSubscribe: true, CanRedeem: true}}, products)
+
+	id, err := w.SubscribeLending(goex.LendingParameter{Currency: goex.USDT, Amount: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, "40607", id)
+	assert.Equal(t, "USDT001", form["productId"])
+	assert.Equal(t, "100", form["amount"])
+
+	id, err = w.RedeemLending(goex.LendingParameter{ProductId: "USDT001"})
+	assert.Nil(t, err)
+	assert.Equal(t, "40608", id)
+	assert.Equal(t, "true", form["redeemAll"])
+
+	positions, err := w.GetLendingPositions(goex.UNKNOWN)
+	assert.Nil(t, err)
+	assert.Len(t, positions, 1)
+	assert.Equal(t, 75.46, positions[0].Amount)
+	assert.Equal(t, 0.45459183, positions[0].Interest)
+}
@@ -0,0 +1,406 @@
+package binance
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/lucas7788/goex"
+	"net/url"
+	"strings"
+)
+
+const (
+	SideEffectNone      = "NO_SIDE_EFFECT" //普通订单
+	SideEffectMarginBuy = "MARGIN_BUY"     //自动借款
+	SideEffectAutoRepay = "AUTO_REPAY"     //自动还款
+)
+
+/**
+ * 币安杠杆交易(全仓/逐仓)
+ * Isolated 为true时使用逐仓杠杆账户, 交易对即为逐仓账户
+ * SideEffectType 下单时的借还款方式, 默认为NO_SIDE_EFFECT
+ */
+type Margin struct {
+	ba             *Binance
+	conf           *APIConfig
+	Isolated       bool
+	SideEffectType string
+}
+
+//利息记录
+type MarginInterest struct {
+	Asset          Currency
+	IsolatedSymbol string
+	Interest       float64
+	InterestRate   float64
+	Principal      float64
+	Type           string //PERIODIC, ON_BORROW, PERIODIC_CONVERTED, ON_BORROW_CONVERTED
+	Timestamp      int64
+}
+
+func NewMargin(c *APIConfig) *Margin {
+	return &Margin{ba: NewWithConfig(c), conf: c, SideEffectType: SideEffectNone}
+}
+
+func NewIsolatedMargin(c *APIConfig) *Margin {
+	m := NewMargin(c)
+	m.Isolated = true
+	return m
+}
+
+func (m *Margin) GetExchangeName() string {
+	return BINANCE
+}
+
+func (m *Margin) symbol(pair CurrencyPair) string {
+	return pair.AdaptUsdToUsdt().ToUpper().ToSymbol("")
+}
+
+//设置逐仓参数
+func (m *Margin) setIsolated(params *url.Values, pair CurrencyPair) {
+	if m.Isolated {
+		params.Set("isIsolated", "TRUE")
+		params.Set("symbol", m.symbol(pair))
+	}
+}
+
+type marginAssetResponse struct {
+	Asset    string  `json:"asset"`
+	Borrowed float64 `json:"borrowed,string"`
+	Free     float64 `json:"free,string"`
+	Interest float64 `json:"interest,string"`
+	Locked   float64 `json:"locked,string"`
+	NetAsset float64 `json:"netAsset,string"`
+}
+
+func (a marginAssetResponse) subAccount() MarginSubAccount {
+	return MarginSubAccount{
+		Balance:     a.Free + a.Locked,
+		Frozen:      a.Locked,
+		Available:   a.Free,
+		CanWithdraw: a.Free,
+		Loan:        a.Borrowed,
+		LendingFee:  a.Interest,
+	}
+}
+
+/**
+ * 杠杆账户
+ * 全仓: 返回全仓账户所有币种, RiskRate为marginLevel
+ * 逐仓: 返回交易对的base/quote币种, 及强平价格
+ */
+func (m *Margin) GetMarginAccount(pair CurrencyPair) (*MarginAccount, error) {
+	acc := &MarginAccount{Sub: make(map[Currency]MarginSubAccount, 2)}
+
+	if !m.Isolated {
+		var response struct {
+			MarginLevel float64               `json:"marginLevel,string"`
+			UserAssets  []marginAssetResponse `json:"userAssets"`
+		}
+		err := m.ba.doRequest("GET", "/sapi/v1/margin/account", url.Values{}, &response)
+		if err != nil {
+			return nil, err
+		}
+		acc.RiskRate = response.MarginLevel
+		for _, a := range response.UserAssets {
+			acc.Sub[NewCurrency(a.Asset, "")] = a.subAccount()
+		}
+		return acc, nil
+	}
+
+	params := url.Values{}
+	params.Set("symbols", m.symbol(pair))
+	var response struct {
+		Assets []struct {
+			Symbol         string              `json:"symbol"`
+			BaseAsset      marginAssetResponse `json:"baseAsset"`
+			QuoteAsset     marginAssetResponse `json:"quoteAsset"`
+			MarginLevel    float64             `json:"marginLevel,string"`
+			MarginRatio    float64             `json:"marginRatio,string"`
+			LiquidatePrice float64             `json:"liquidatePrice,string"`
+		} `json:"assets"`
+	}
+	err := m.ba.doRequest("GET", "/sapi/v1/margin/isolated/account", params, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Assets) == 0 {
+		return nil, errors.New("isolated margin account not found")
+	}
+
+	isolated := response.Assets[0]
+	acc.RiskRate = isolated.MarginLevel
+	acc.MarginRatio = isolated.MarginRatio
+	acc.LiquidationPrice = isolated.LiquidatePrice
+	acc.Sub[NewCurrency(isolated.BaseAsset.Asset, "")] = isolated.BaseAsset.subAccount()
+	acc.Sub[NewCurrency(isolated.QuoteAsset.Asset, "")] = isolated.QuoteAsset.subAccount()
+	return acc, nil
+}
+
+//借币, 返回tranId
+func (m *Margin) Borrow(parameter BorrowParameter) (borrowId string, err error) {
+	params := url.Values{}
+	params.Set("asset", parameter.Currency.Symbol)
+	params.Set("amount", FloatToString(parameter.Amount, 8))
+	m.setIsolated(&params, parameter.CurrencyPair)
+
+	var response struct {
+		TranId int64 `json:"tranId"`
+	}
+	err = m.ba.doRequest("POST", "/sapi/v1/margin/loan", params, &response)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(response.TranId), nil
+}
+
+//还币, BorrowId无效, 优先偿还利息
+func (m *Margin) Repayment(parameter RepaymentParameter) (repaymentId string, err error) {
+	params := url.Values{}
+	params.Set("asset", parameter.Currency.Symbol)
+	params.Set("amount", FloatToString(parameter.Amount, 8))
+	m.setIsolated(&params, parameter.CurrencyPair)
+
+	var response struct {
+		TranId int64 `json:"tranId"`
+	}
+	err = m.ba.doRequest("POST", "/sapi/v1/margin/repay", params, &response)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(response.TranId), nil
+}
+
+//最大可借数量
+func (m *Margin) GetMaxBorrowable(currency Currency, pair CurrencyPair) (float64, error) {
+	params := url.Values{}
+	params.Set("asset", currency.Symbol)
+	if m.Isolated {
+		params.Set("isolatedSymbol", m.symbol(pair))
+	}
+
+	var response struct {
+		Amount      float64 `json:"amount,string"`
+		BorrowLimit float64 `json:"borrowLimit,string"`
+	}
+	err := m.ba.doRequest("GET", "/sapi/v1/margin/maxBorrowable", params, &response)
+	if err != nil {
+		return 0, err
+	}
+	return response.Amount, nil
+}
+
+/**
+ * 利息记录
+ * optional: startTime, endTime, current(页码,从1开始), size(默认10,最大100)
+ */
+func (m *Margin) GetInterestHistory(currency Currency, pair CurrencyPair, optional ...OptionalParameter) ([]MarginInterest, error) {
+	params := url.Values{}
+	if currency.Symbol != "" {
+		params.Set("asset", currency.Symbol)
+	}
+	if m.Isolated {
+		params.Set("isolatedSymbol", m.symbol(pair))
+	}
+	MergeOptionalParameter(&params, optional...)
+
+	var response struct {
+		Rows []struct {
+			IsolatedSymbol      string  `json:"isolatedSymbol"`
+			Asset               string  `json:"asset"`
+			Interest            float64 `json:"interest,string"`
+			InterestAccuredTime int64   `json:"interestAccuredTime"`
+			InterestRate        float64 `json:"interestRate,string"`
+			Principal           float64 `json:"principal,string"`
+			Type                string  `json:"type"`
+		} `json:"rows"`
+		Total int `json:"total"`
+	}
+	err := m.ba.doRequest("GET", "/sapi/v1/margin/interestHistory", params, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	interests := make([]MarginInterest, 0, len(response.Rows))
+	for _, r := range response.Rows {
+		interests = append(interests, MarginInterest{
+			Asset:          NewCurrency(r.Asset, ""),
+			IsolatedSymbol: r.IsolatedSymbol,
+			Interest:       r.Interest,
+			InterestRate:   r.InterestRate,
+			Principal:      r.Principal,
+			Type:           r.Type,
+			Timestamp:      r.InterestAccuredTime,
+		})
+	}
+	return interests, nil
+}
+
+/**
+ * 借币记录, currency必填
+ * optional: txId, startTime, endTime, current(页码,从1开始), size(默认10,最大100)
+ */
+func (m *Margin) GetLoanHistory(pair CurrencyPair, currency Currency, optional ...OptionalParameter) ([]MarginLoan, error) {
+	params := url.Values{}
+	params.Set("asset", currency.Symbol)
+	if m.Isolated {
+		params.Set("isolatedSymbol", m.symbol(pair))
+	}
+	MergeOptionalParameter(&params, optional...)
+
+	var response struct {
+		Rows []struct {
+			IsolatedSymbol string  `json:"isolatedSymbol"`
+			TxId           int64   `json:"txId"`
+			Asset          string  `json:"asset"`
+			Principal      float64 `json:"principal,string"`
+			Timestamp      int64   `json:"timestamp"`
+			Status         string  `json:"status"` //PENDING, CONFIRMED, FAILED
+		} `json:"rows"`
+		Total int `json:"total"`
+	}
+	err := m.ba.doRequest("GET", "/sapi/v1/margin/loan", params, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	loans := make([]MarginLoan, 0, len(response.Rows))
+	for _, r := range response.Rows {
+		loan := MarginLoan{
+			LoanId:      fmt.Sprint(r.TxId),
+			Pair:        UNKNOWN_PAIR,
+			Currency:    NewCurrency(r.Asset, ""),
+			Amount:      r.Principal,
+			Status:      r.Status,
+			CreatedTime: r.Timestamp,
+		}
+		if r.IsolatedSymbol != "" {
+			loan.Pair = pair
+		}
+		loans = append(loans, loan)
+	}
+	return loans, nil
+}
+
+//风险率, 即marginLevel(总资产/总负债)
+func (m *Margin) GetRiskRate(pair CurrencyPair) (float64, error) {
+	acc, err := m.GetMarginAccount(pair)
+	if err != nil {
+		return 0, err
+	}
+	return acc.RiskRate, nil
+}
+
+func (m *Margin) PlaceOrder(ord *Order) (*Order, error) {
+	params := url.Values{}
+	params.Set("symbol", m.symbol(ord.Currency))
+	params.Set("sideEffectType", m.SideEffectType)
+	params.Set("newOrderRespType", "ACK")
+	if ord.Cid != "" {
+		params.Set("newClientOrderId", ord.Cid)
+	}
+	m.setIsolated(&params, ord.Currency)
+
+	switch ord.Side {
+	case BUY, SELL:
+		params.Set("side", strings.ToUpper(ord.Side.String()))
+		params.Set("quantity", FloatToString(ord.Amount, 8))
+		params.Set("price", FloatToString(ord.Price, 8))
+		switch ord.OrderType {
+		case ORDER_FEATURE_POST_ONLY:
+			params.Set("type", "LIMIT_MAKER")
+		case ORDER_FEATURE_FOK:
+			params.Set("type", "LIMIT")
+			params.Set("timeInForce", "FOK")
+		case ORDER_FEATURE_IOC:
+			params.Set("type", "LIMIT")
+			params.Set("timeInForce", "IOC")
+		default:
+			params.Set("type", "LIMIT")
+			params.Set("timeInForce", "GTC")
+		}
+		ord.Type = "limit"
+	case BUY_MARKET:
+		params.Set("side", "BUY")
+		params.Set("type", "MARKET")
+		if ord.Price > 0 { //按金额买入
+			params.Set("quoteOrderQty", FloatToString(ord.Price, 8))
+		} else {
+			params.Set("quantity", FloatToString(ord.Amount, 8))
+		}
+		ord.Type = "market"
+	case SELL_MARKET:
+		params.Set("side", "SELL")
+		params.Set("type", "MARKET")
+		params.Set("quantity", FloatToString(ord.Amount, 8))
+		ord.Type = "market"
+	default:
+		return nil, fmt.Errorf("unsupported order side: %s", ord.Side)
+	}
+
+	var response struct {
+		OrderId       int64  `json:"orderId"`
+		ClientOrderId string `json:"clientOrderId"`
+		TransactTime  int64  `json:"transactTime"`
+	}
+	err := m.ba.doRequest("POST", "/sapi/v1/margin/order", params, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	ord.OrderID = int(response.OrderId)
+	ord.OrderID2 = fmt.Sprint(response.OrderId)
+	ord.Cid = response.ClientOrderId
+	ord.OrderTime = int(response.TransactTime)
+	ord.Status = ORDER_UNFINISH
+	return ord, nil
+}
+
+func (m *Margin) CancelOrder(orderId string, pair CurrencyPair) (bool, error) {
+	params := url.Values{}
+	params.Set("symbol", m.symbol(pair))
+	params.Set("orderId", orderId)
+	m.setIsolated(&params, pair)
+
+	var response struct {
+		OrderId int64 `json:"orderId"`
+	}
+	err := m.ba.doRequest("DELETE", "/sapi/v1/margin/order", params, &response)
+	if err != nil {
+		return false, err
+	}
+	return response.OrderId > 0, nil
+}
+
+func (m *Margin) GetOneOrder(orderId string, pair CurrencyPair) (*Order, error) {
+	params := url.Values{}
+	params.Set("symbol", m.symbol(pair))
+	params.Set("orderId", orderId)
+	m.setIsolated(&params, pair)
+
+	var response map[string]interface{}
+	err := m.ba.doRequest("GET", "/sapi/v1/margin/order", params, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	ord := m.ba.adaptOrder(pair, response)
+	return &ord, nil
+}
+
+func (m *Margin) GetUnfinishOrders(pair CurrencyPair) ([]Order, error) {
+	params := url.Values{}
+	params.Set("symbol", m.symbol(pair))
+	m.setIsolated(&params, pair)
+
+	var response []map[string]interface{}
+	err := m.ba.doRequest("GET", "/sapi/v1/margin/openOrders", params, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]Order, 0, len(response))
+	for _, o := range response {
+		orders = append(orders, m.ba.adaptOrder(pair, o))
+	}
+	return orders, nil
+}
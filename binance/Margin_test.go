@@ -0,0 +1,106 @@
+package binance
+
+import (
+	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+//签名接口, 路由的key为"METHOD /path", 返回值为录制的响应
+var binanceAPI = testserver.Options{
+	Fixed: map[string]testserver.Route{
+		"/api/v3/time": testserver.Reply(`{"serverTime":1603695163000}`),
+	},
+}
+
+func TestMargin_GetMarginAccount(t *testing.T) {
+	srv := testserver.New(binanceAPI, map[string]testserver.Route{
+		"GET /sapi/v1/margin/account": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "key", r.Header.Get("X-MBX-APIKEY"))
+			assert.NotEmpty(t, params["signature"])
+			return `{"marginLevel":"11.64405625","userAssets":[{"asset":"BTC","borrowed":"0.1","free":"0.5","interest":"0.0001","locked":"0.2","netAsset":"0.5999"}]}`
+		},
+		"GET /sapi/v1/margin/isolated/account": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "BTCUSDT", params["symbols"])
+			return `{"assets":[{"symbol":"BTCUSDT","marginLevel":"2.5","marginRatio":"5","liquidatePrice":"9500.5",
+				"baseAsset":{"asset":"BTC","borrowed":"0","free":"1","interest":"0","locked":"0","netAsset":"1"},
+				"quoteAsset":{"asset":"USDT","borrowed":"5000","free":"6000","interest":"1.2","locked":"0","netAsset":"998.8"}}]}`
+		},
+	})
+	defer srv.Close()
+	conf := testserver.Config(srv)
+
+	acc, err := NewMargin(conf).GetMarginAccount(goex.BTC_USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, 11.64405625, acc.RiskRate)
+	assert.Equal(t, goex.MarginSubAccount{Balance: 0.7, Frozen: 0.2, Available: 0.5, CanWithdraw: 0.5, Loan: 0.1, LendingFee: 0.0001}, acc.Sub[goex.BTC])
+
+	acc, err = NewIsolatedMargin(conf).GetMarginAccount(goex.BTC_USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, 9500.5, acc.LiquidationPrice)
+	assert.Equal(t, 5000.0, acc.Sub[goex.USDT].Loan)
+	assert.Equal(t, 1.0, acc.Sub[goex.BTC].Available)
+}
+
+func TestMargin_BorrowAndPlaceOrder(t *testing.T) {
+	var orderParams map[string]string
+	srv := testserver.New(binanceAPI, map[string]testserver.Route{
+		"POST /sapi/v1/margin/loan": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "USDT", params["asset"])
+			assert.Equal(t, "100", params["amount"])
+			assert.Equal(t, "TRUE", params["isIsolated"])
+			assert.Equal(t, "BTCUSDT", params["symbol"])
+			return `{"tranId":100000001}`
+		},
+		"POST /sapi/v1/margin/order": func(r *testserver.Request) interface{} {
+			orderParams = r.Params()
+			return `{"symbol":"BTCUSDT","orderId":28,"clientOrderId":"6gCrw2kRUAF9CvJDGP16IP","transactTime":1507725176595}`
+		},
+		"GET /sapi/v1/margin/maxBorrowable": func(r *testserver.Request) interface{} {
+			return `{"code":-3045,"msg":"The system does not have enough asset now."}`
+		},
+	})
+	defer srv.Close()
+	conf := testserver.Config(srv)
+
+	margin := NewIsolatedMargin(conf)
+	borrowId, err := margin.Borrow(goex.BorrowParameter{CurrencyPair: goex.BTC_USDT, Currency: goex.USDT, Amount: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, "100000001", borrowId)
+
+	margin.SideEffectType = SideEffectMarginBuy
+	ord, err := margin.PlaceOrder(&goex.Order{Currency: goex.BTC_USDT, Side: goex.BUY, Price: 10000, Amount: 0.01, OrderType: goex.ORDER_FEATURE_POST_ONLY})
+	assert.Nil(t, err)
+	assert.Equal(t, "28", ord.OrderID2)
+	assert.Equal(t, "LIMIT_MAKER", orderParams["type"])
+	assert.Equal(t, "MARGIN_BUY", orderParams["sideEffectType"])
+	assert.Equal(t, "10000", orderParams["price"])
+	assert.Equal(t, "TRUE", orderParams["isIsolated"])
+
+	_, err = margin.GetMaxBorrowable(goex.USDT, goex.BTC_USDT)
+	assert.Error(t, err)
+}
+
+func TestMargin_GetLoanHistory(t *testing.T) {
+	srv := testserver.New(binanceAPI, map[string]testserver.Route{
+		"GET /sapi/v1/margin/loan": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "USDT", params["asset"])
+			assert.Equal(t, "BTCUSDT", params["isolatedSymbol"])
+			assert.Equal(t, "20", params["size"])
+			return `{"rows":[{"isolatedSymbol":"BTCUSDT","txId":12807067523,"asset":"USDT","principal":"100.5","timestamp":1555056425000,"status":"CONFIRMED"}],"total":1}`
+		},
+	})
+	defer srv.Close()
+	conf := testserver.Config(srv)
+
+	loans, err := NewIsolatedMargin(conf).GetLoanHistory(goex.BTC_USDT, goex.USDT, goex.OptionalParameter{}.Optional("size", "20"))
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.MarginLoan{{LoanId: "12807067523", Pair: goex.BTC_USDT, Currency: goex.USDT, Amount: 100.5,
+		Status: "CONFIRMED", CreatedTime: 1555056425000}}, loans)
+}
@@ -31,7 +31,7 @@ type depthResp struct {
 
 type SpotWs struct {
 	c         *goex.WsConn
-	once      sync.Once
+	connLock  sync.Mutex
 	wsBuilder *goex.WsBuilder
 
 	reqId int
@@ -48,17 +48,34 @@ func NewSpotWs() *SpotWs {
 	spotWs.wsBuilder = goex.NewWsBuilder().
 		WsUrl("wss://stream.binance.com:9443/stream?streams=depth/miniTicker/ticker/trade").
 		ProxyUrl(os.Getenv("HTTPS_PROXY")).
-		ProtoHandleFunc(spotWs.handle).AutoReconnect()
+		ProtoHandleFunc(spotWs.handle).
+		TimestampFunc(spotWsTimestamp).AutoReconnect()
 
 	spotWs.reqId = 1
 
 	return spotWs
 }
 
-func (s *SpotWs) connect() {
-	s.once.Do(func() {
-		s.c = s.wsBuilder.Build()
-	})
+//连接失败时下一次订阅重新连接
+func (s *SpotWs) connect() error {
+	s.connLock.Lock()
+	defer s.connLock.Unlock()
+	if s.c != nil {
+		return nil
+	}
+	var err error
+	s.c, err = s.wsBuilder.Build()
+	return err
+}
+
+//连接的统计信息, 未连接时返回零值
+func (s *SpotWs) Stats() goex.WsStats {
+	s.connLock.Lock()
+	defer s.connLock.Unlock()
+	if s.c == nil {
+		return goex.WsStats{}
+	}
+	return s.c.Stats()
 }
 
 func (s *SpotWs) DepthCallback(f func(depth *goex.Depth)) {
@@ -78,7 +95,9 @@ func (s *SpotWs) SubscribeDepth(pair goex.CurrencyPair) error {
 		s.reqId++
 	}()
 
-	s.connect()
+	if err := s.connect(); err != nil {
+		return err
+	}
 
 	return s.c.Subscribe(req{
 		Method: "SUBSCRIBE",
@@ -94,7 +113,9 @@ func (s *SpotWs) SubscribeTicker(pair goex.CurrencyPair) error {
 		s.reqId++
 	}()
 
-	s.connect()
+	if err := s.connect(); err != nil {
+		return err
+	}
 
 	return s.c.Subscribe(req{
 		Method: "SUBSCRIBE",
@@ -190,3 +211,22 @@ func (s *SpotWs) tickerHandle(data json2.RawMessage, pair goex.CurrencyPair) err
 
 	return nil
 }
+
+//组合流消息的事件时间data.E, 用于统计延迟
+func spotWsTimestamp(msg []byte) time.Time {
+	var r struct {
+		Data map[string]json2.RawMessage `json:"data"`
+	}
+	json2.Unmarshal(msg, &r)
+	return wsEventTime(r.Data)
+}
+
+//事件时间E(毫秒), 没有事件时间(如部分深度)时返回零值, 不统计延迟
+//按key精确查找, 避免与事件类型e混淆
+func wsEventTime(fields map[string]json2.RawMessage) time.Time {
+	var ms int64
+	if json2.Unmarshal(fields["E"], &ms) != nil || ms <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
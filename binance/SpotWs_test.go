@@ -1,9 +1,14 @@
 package binance
 
 import (
+	"github.com/gorilla/websocket"
 	"github.com/lucas7788/goex"
+	"github.com/stretchr/testify/assert"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -35,3 +40,39 @@ func TestSpotWs_SubscribeTicker(t *testing.T) {
 	spotWs.SubscribeTicker(goex.LTC_USDT)
 	time.Sleep(30 * time.Minute)
 }
+
+//连接失败后, 下一次订阅重新连接
+func TestSpotWs_ConnectRetry(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	ws := &SpotWs{reqId: 1}
+	ws.wsBuilder = goex.NewWsBuilder().WsUrl("ws://127.0.0.1:1").ProtoHandleFunc(ws.handle)
+	assert.NotNil(t, ws.SubscribeTicker(goex.BTC_USDT))
+
+	ws.wsBuilder.WsUrl("ws" + strings.TrimPrefix(srv.URL, "http"))
+	assert.Nil(t, ws.SubscribeTicker(goex.BTC_USDT))
+	assert.True(t, ws.Stats().Connected)
+	ws.c.CloseWs()
+}
+
+func TestSpotWsTimestamp(t *testing.T) {
+	ts := spotWsTimestamp([]byte(`{"stream":"btcusdt@ticker","data":{"e":"24hrTicker","E":1600000000123,"s":"BTCUSDT"}}`))
+	assert.Equal(t, int64(1600000000123), ts.UnixNano()/int64(time.Millisecond))
+
+	//部分深度没有事件时间
+	assert.True(t, spotWsTimestamp([]byte(`{"stream":"btcusdt@depth10@100ms","data":{"lastUpdateId":1,"bids":[],"asks":[]}}`)).IsZero())
+	assert.True(t, spotWsTimestamp([]byte(`{"result":null,"id":1}`)).IsZero())
+}
@@ -0,0 +1,73 @@
+package binance
+
+import (
+	"net/url"
+
+	. "github.com/lucas7788/goex"
+)
+
+//子账户列表, SubAccount为子账户邮箱
+func (w *Wallet) GetSubAccounts() ([]SubAccountUser, error) {
+	params := url.Values{}
+	params.Set("limit", "200")
+
+	var response struct {
+		SubAccounts []struct {
+			Email      string `json:"email"`
+			IsFreeze   bool   `json:"isFreeze"`
+			CreateTime int64  `json:"createTime"`
+		} `json:"subAccounts"`
+	}
+	err := w.ba.doRequest("GET", "/sapi/v1/sub-account/list", params, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]SubAccountUser, 0, len(response.SubAccounts))
+	for _, s := range response.SubAccounts {
+		users = append(users, SubAccountUser{
+			SubAccount:  s.Email,
+			Name:        s.Email,
+			Enable:      !s.IsFreeze,
+			CreatedTime: s.CreateTime,
+		})
+	}
+	return users, nil
+}
+
+//子账户现货资产
+func (w *Wallet) GetSubAccountBalance(subAccount string) (*Account, error) {
+	params := url.Values{}
+	params.Set("email", subAccount)
+
+	var response struct {
+		Balances []struct {
+			Asset  string  `json:"asset"`
+			Free   float64 `json:"free"`
+			Locked float64 `json:"locked"`
+		} `json:"balances"`
+	}
+	err := w.ba.doRequest("GET", "/sapi/v3/sub-account/assets", params, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &Account{
+		Exchange:    BINANCE,
+		SubAccounts: make(map[Currency]SubAccount, len(response.Balances)),
+	}
+	for _, b := range response.Balances {
+		currency := NewCurrency(b.Asset, "")
+		acc.SubAccounts[currency] = SubAccount{
+			Currency:     currency,
+			Amount:       b.Free,
+			ForzenAmount: b.Locked,
+		}
+	}
+	return acc, nil
+}
+
+//只有经纪商账户可以为子账户创建api key
+func (w *Wallet) CreateSubAccountApiKey(param SubAccountApiKeyParameter) (*SubAccountApiKey, error) {
+	return nil, EX_ERR_NOT_SUPPORT
+}
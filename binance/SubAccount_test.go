@@ -0,0 +1,40 @@
+package binance
+
+import (
+	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWallet_SubAccount(t *testing.T) {
+	srv := testserver.New(binanceAPI, map[string]testserver.Route{
+		"GET /sapi/v1/sub-account/list": func(r *testserver.Request) interface{} {
+			return `{"subAccounts":[{"email":"sub1@test.com","isFreeze":false,"createTime":1544433328000},
+				{"email":"sub2@test.com","isFreeze":true,"createTime":1544433328000}]}`
+		},
+		"GET /sapi/v3/sub-account/assets": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "sub1@test.com", params["email"])
+			return `{"balances":[{"asset":"BTC","free":0.5,"locked":0.1},{"asset":"USDT","free":1000,"locked":0}]}`
+		},
+	})
+	defer srv.Close()
+	conf := testserver.Config(srv)
+
+	w := NewWallet(conf)
+	users, err := w.GetSubAccounts()
+	assert.Nil(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, goex.SubAccountUser{SubAccount: "sub1@test.com", Name: "sub1@test.com", Enable: true, CreatedTime: 1544433328000}, users[0])
+	assert.False(t, users[1].Enable)
+
+	acc, err := w.GetSubAccountBalance("sub1@test.com")
+	assert.Nil(t, err)
+	assert.Equal(t, goex.SubAccount{Currency: goex.BTC, Amount: 0.5, ForzenAmount: 0.1}, acc.SubAccounts[goex.BTC])
+	assert.Equal(t, float64(1000), acc.SubAccounts[goex.USDT].Amount)
+
+	_, err = w.CreateSubAccountApiKey(goex.SubAccountApiKeyParameter{SubAccount: "sub1@test.com"})
+	assert.Equal(t, goex.EX_ERR_NOT_SUPPORT, err)
+}
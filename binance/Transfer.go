@@ -0,0 +1,239 @@
+package binance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	. "github.com/lucas7788/goex"
+)
+
+//万能划转的账户类型
+var transferAccountTypes = map[AccountKind]string{
+	ACCOUNT_SPOT:         "MAIN",
+	ACCOUNT_MARGIN:       "MARGIN",
+	ACCOUNT_FUTURES_COIN: "CMFUTURE",
+	ACCOUNT_FUTURES_USDT: "UMFUTURE",
+	ACCOUNT_FUNDING:      "FUNDING",
+}
+
+//子账户万能划转的账户类型
+var subTransferAccountTypes = map[AccountKind]string{
+	ACCOUNT_SPOT:         "SPOT",
+	ACCOUNT_MARGIN:       "MARGIN",
+	ACCOUNT_FUTURES_COIN: "COIN_FUTURE",
+	ACCOUNT_FUTURES_USDT: "USDT_FUTURE",
+}
+
+func (w *Wallet) GetExchangeName() string {
+	return BINANCE
+}
+
+func transferAccountType(kind AccountKind, req TransferRequest, types map[AccountKind]string, isolated string) (string, error) {
+	if kind == ACCOUNT_MARGIN && req.Isolated() {
+		return isolated, nil
+	}
+	t, ok := types[kind]
+	if !ok {
+		return "", fmt.Errorf("unsupported account kind: %s", kind)
+	}
+	return t, nil
+}
+
+/**
+ * 划转, 逐仓杠杆需要指定Pair
+ * 母子账户划转使用子账户万能划转, 需要母账户的api key, 子账户默认为现货账户
+ */
+func (w *Wallet) TransferAsset(req TransferRequest) (string, error) {
+	if req.From == ACCOUNT_SUB || req.To == ACCOUNT_SUB {
+		return w.subTransfer(req)
+	}
+
+	from, err := transferAccountType(req.From, req, transferAccountTypes, "ISOLATEDMARGIN")
+	if err != nil {
+		return "", err
+	}
+	to, err := transferAccountType(req.To, req, transferAccountTypes, "ISOLATEDMARGIN")
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("type", from+"_"+to)
+	params.Set("asset", strings.ToUpper(req.Currency.Symbol))
+	params.Set("amount", FloatToString(req.Amount, 8))
+	symbol := req.Pair.AdaptUsdToUsdt().ToSymbol("")
+	if from == "ISOLATEDMARGIN" {
+		params.Set("fromSymbol", symbol)
+	}
+	if to == "ISOLATEDMARGIN" {
+		params.Set("toSymbol", symbol)
+	}
+
+	var response struct {
+		TranId json.Number `json:"tranId"`
+	}
+	err = w.ba.doRequest("POST", "/sapi/v1/asset/transfer", params, &response)
+	if err != nil {
+		return "", err
+	}
+	return response.TranId.String(), nil
+}
+
+func (w *Wallet) subTransfer(req TransferRequest) (string, error) {
+	subKind := req.SubAccountKind
+	if subKind == 0 {
+		subKind = ACCOUNT_SPOT
+	}
+
+	params := url.Values{}
+	from, to := req.From, req.To
+	if from == ACCOUNT_SUB {
+		params.Set("fromEmail", req.SubAccount)
+		from = subKind
+	}
+	if to == ACCOUNT_SUB {
+		params.Set("toEmail", req.SubAccount)
+		to = subKind
+	}
+
+	fromType, err := transferAccountType(from, req, subTransferAccountTypes, "ISOLATED_MARGIN")
+	if err != nil {
+		return "", err
+	}
+	toType, err := transferAccountType(to, req, subTransferAccountTypes, "ISOLATED_MARGIN")
+	if err != nil {
+		return "", err
+	}
+	params.Set("fromAccountType", fromType)
+	params.Set("toAccountType", toType)
+	params.Set("asset", strings.ToUpper(req.Currency.Symbol))
+	params.Set("amount", FloatToString(req.Amount, 8))
+	if fromType == "ISOLATED_MARGIN" || toType == "ISOLATED_MARGIN" {
+		params.Set("symbol", req.Pair.AdaptUsdToUsdt().ToSymbol(""))
+	}
+
+	var response struct {
+		TranId json.Number `json:"tranId"`
+	}
+	err = w.ba.doRequest("POST", "/sapi/v1/sub-account/universalTransfer", params, &response)
+	if err != nil {
+		return "", err
+	}
+	return response.TranId.String(), nil
+}
+
+func accountKindOf(accountType string, types map[AccountKind]string) AccountKind {
+	for kind, t := range types {
+		if t == accountType {
+			return kind
+		}
+	}
+	if strings.HasPrefix(accountType, "ISOLATED") {
+		return ACCOUNT_MARGIN
+	}
+	return 0
+}
+
+/**
+ * 划转记录, 最近100条
+ * 账户内划转必须指定from和to, 母子账户划转返回所有子账户的记录
+ */
+func (w *Wallet) GetTransferHistory(currency Currency, from, to AccountKind, optional ...OptionalParameter) ([]TransferRecord, error) {
+	if from == ACCOUNT_SUB || to == ACCOUNT_SUB {
+		return w.getSubTransferHistory(currency, optional...)
+	}
+
+	fromType, ok1 := transferAccountTypes[from]
+	toType, ok2 := transferAccountTypes[to]
+	if !ok1 || !ok2 {
+		return nil, errors.New("from and to account kind are required")
+	}
+
+	params := url.Values{}
+	params.Set("type", fromType+"_"+toType)
+	params.Set("size", "100")
+	MergeOptionalParameter(&params, optional...)
+
+	var response struct {
+		Rows []struct {
+			Asset     string      `json:"asset"`
+			Amount    string      `json:"amount"`
+			Status    string      `json:"status"`
+			TranId    json.Number `json:"tranId"`
+			Timestamp int64       `json:"timestamp"`
+		} `json:"rows"`
+	}
+	err := w.ba.doRequest("GET", "/sapi/v1/asset/transfer", params, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []TransferRecord
+	for _, r := range response.Rows {
+		if !strings.EqualFold(r.Asset, currency.Symbol) {
+			continue
+		}
+		records = append(records, TransferRecord{
+			TransferId:  r.TranId.String(),
+			Currency:    NewCurrency(r.Asset, ""),
+			Amount:      ToFloat64(r.Amount),
+			From:        from,
+			To:          to,
+			Status:      r.Status,
+			CreatedTime: r.Timestamp,
+		})
+	}
+	return records, nil
+}
+
+func (w *Wallet) getSubTransferHistory(currency Currency, optional ...OptionalParameter) ([]TransferRecord, error) {
+	params := url.Values{}
+	params.Set("limit", "100")
+	MergeOptionalParameter(&params, optional...)
+
+	var response struct {
+		Result []struct {
+			TranId          json.Number `json:"tranId"`
+			FromEmail       string      `json:"fromEmail"`
+			ToEmail         string      `json:"toEmail"`
+			Asset           string      `json:"asset"`
+			Amount          string      `json:"amount"`
+			FromAccountType string      `json:"fromAccountType"`
+			ToAccountType   string      `json:"toAccountType"`
+			Status          string      `json:"status"`
+			CreateTimeStamp int64       `json:"createTimeStamp"`
+		} `json:"result"`
+	}
+	err := w.ba.doRequest("GET", "/sapi/v1/sub-account/universalTransfer", params, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []TransferRecord
+	for _, r := range response.Result {
+		if !strings.EqualFold(r.Asset, currency.Symbol) {
+			continue
+		}
+		record := TransferRecord{
+			TransferId:  r.TranId.String(),
+			Currency:    NewCurrency(r.Asset, ""),
+			Amount:      ToFloat64(r.Amount),
+			From:        accountKindOf(r.FromAccountType, subTransferAccountTypes),
+			To:          accountKindOf(r.ToAccountType, subTransferAccountTypes),
+			Status:      r.Status,
+			CreatedTime: r.CreateTimeStamp,
+		}
+		//邮箱为空的一方是母账户
+		if r.FromEmail != "" {
+			record.From, record.SubAccount = ACCOUNT_SUB, r.FromEmail
+		}
+		if r.ToEmail != "" {
+			record.To, record.SubAccount = ACCOUNT_SUB, r.ToEmail
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,79 @@
+package binance
+
+import (
+	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWallet_TransferAsset(t *testing.T) {
+	var form map[string]string
+	srv := testserver.New(binanceAPI, map[string]testserver.Route{
+		"POST /sapi/v1/asset/transfer": func(r *testserver.Request) interface{} {
+			form = r.Params()
+			return `{"tranId":13526853623}`
+		},
+		"POST /sapi/v1/sub-account/universalTransfer": func(r *testserver.Request) interface{} {
+			form = r.Params()
+			return `{"tranId":11945860693}`
+		},
+		"GET /sapi/v1/asset/transfer": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "MAIN_UMFUTURE", params["type"])
+			return `{"total":2,"rows":[{"asset":"USDT","amount":"1","type":"MAIN_UMFUTURE","status":"CONFIRMED","tranId":11415955596,"timestamp":1544433328000},
+				{"asset":"BTC","amount":"2","type":"MAIN_UMFUTURE","status":"CONFIRMED","tranId":11366865406,"timestamp":1544433328000}]}`
+		},
+	})
+	defer srv.Close()
+	conf := testserver.Config(srv)
+
+	w := NewWallet(conf)
+	id, err := w.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_SPOT, To: goex.ACCOUNT_FUTURES_USDT})
+	assert.Nil(t, err)
+	assert.Equal(t, "13526853623", id)
+	assert.Equal(t, "MAIN_UMFUTURE", form["type"])
+	assert.Equal(t, "USDT", form["asset"])
+
+	_, err = w.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_MARGIN, To: goex.ACCOUNT_SPOT,
+		Pair: goex.BTC_USDT})
+	assert.Nil(t, err)
+	assert.Equal(t, "ISOLATEDMARGIN_MAIN", form["type"])
+	assert.Equal(t, "BTCUSDT", form["fromSymbol"])
+
+	//未设置Pair和UNKNOWN_PAIR都是全仓
+	for _, pair := range []goex.CurrencyPair{{}, goex.UNKNOWN_PAIR} {
+		form = nil
+		_, err = w.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_SPOT, To: goex.ACCOUNT_MARGIN, Pair: pair})
+		assert.Nil(t, err)
+		assert.Equal(t, "MAIN_MARGIN", form["type"])
+		assert.Empty(t, form["toSymbol"])
+	}
+
+	_, err = w.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_SUB, To: goex.ACCOUNT_SPOT,
+		SubAccount: "sub@test.com", SubAccountKind: goex.ACCOUNT_MARGIN})
+	assert.Nil(t, err)
+	assert.Equal(t, "MARGIN", form["fromAccountType"])
+	assert.Empty(t, form["symbol"])
+
+	id, err = w.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_SPOT, To: goex.ACCOUNT_SUB,
+		SubAccount: "sub@test.com", SubAccountKind: goex.ACCOUNT_FUTURES_COIN})
+	assert.Nil(t, err)
+	assert.Equal(t, "11945860693", id)
+	assert.Equal(t, "sub@test.com", form["toEmail"])
+	assert.Equal(t, "SPOT", form["fromAccountType"])
+	assert.Equal(t, "COIN_FUTURE", form["toAccountType"])
+
+	_, err = w.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_SPOT, To: goex.ACCOUNT_SUB,
+		SubAccount: "sub@test.com", SubAccountKind: goex.ACCOUNT_FUNDING})
+	assert.NotNil(t, err)
+
+	records, err := w.GetTransferHistory(goex.USDT, goex.ACCOUNT_SPOT, goex.ACCOUNT_FUTURES_USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.TransferRecord{{TransferId: "11415955596", Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_SPOT,
+		To: goex.ACCOUNT_FUTURES_USDT, Status: "CONFIRMED", CreatedTime: 1544433328000}}, records)
+
+	_, err = w.GetTransferHistory(goex.USDT, 0, 0)
+	assert.NotNil(t, err)
+}
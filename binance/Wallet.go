@@ -5,8 +5,9 @@ import (
 	"errors"
 	"fmt"
 	. "github.com/lucas7788/goex"
-	"github.com/lucas7788/goex/internal/logger"
 	"net/url"
+	"strings"
+	"time"
 )
 
 type Wallet struct {
@@ -22,8 +23,27 @@ func (w *Wallet) GetAccount() (*Account, error) {
 	return nil, errors.New("not implement")
 }
 
+//提币, Chain为空时使用币种的默认网络
 func (w *Wallet) Withdrawal(param WithdrawParameter) (withdrawId string, err error) {
-	return "", errors.New("not implement")
+	params := url.Values{}
+	params.Set("coin", strings.ToUpper(param.Currency))
+	params.Set("address", param.ToAddress)
+	params.Set("amount", FloatToString(param.Amount, 8))
+	if param.Chain != "" {
+		params.Set("network", param.Chain)
+	}
+	if param.Memo != "" {
+		params.Set("addressTag", param.Memo)
+	}
+
+	var response struct {
+		Id string `json:"id"`
+	}
+	err = w.ba.doRequest("POST", "/sapi/v1/capital/withdraw/apply", params, &response)
+	if err != nil {
+		return "", err
+	}
+	return response.Id, nil
 }
 
 func (w *Wallet) Transfer(param TransferParameter) error {
@@ -71,47 +91,150 @@ func (w *Wallet) Transfer(param TransferParameter) error {
 	return errors.New(string(resp))
 }
 
-func (w *Wallet) GetWithDrawHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
-	//historyUrl := w.conf.Endpoint + "/wapi/v3/withdrawHistory.html"
-	historyUrl := w.conf.Endpoint + "/sapi/v1/accountSnapshot"
-	postParam := url.Values{}
-	postParam.Set("type", "SPOT")
-	w.ba.buildParamsSigned(&postParam)
+//提币: 0邮件已发送 1已取消 2等待确认 3被拒绝 4处理中 5失败 6完成
+var withdrawStatus = map[int]WithdrawStatus{
+	0: WITHDRAW_PENDING,
+	1: WITHDRAW_CANCELLED,
+	2: WITHDRAW_PENDING,
+	3: WITHDRAW_FAILED,
+	4: WITHDRAW_SENT,
+	5: WITHDRAW_FAILED,
+	6: WITHDRAW_COMPLETED,
+}
 
-	resp, err := HttpGet5(w.ba.httpClient, historyUrl+"?"+postParam.Encode(),
-		map[string]string{"X-MBX-APIKEY": w.ba.accessKey})
+//充值: 0处理中 6已上账但不可提币 1成功
+var depositStatus = map[int]WithdrawStatus{
+	0: WITHDRAW_PENDING,
+	6: WITHDRAW_SENT,
+	1: WITHDRAW_COMPLETED,
+}
 
-	if err != nil {
-		return nil, err
+type depositWithdrawResponse struct {
+	Id             string `json:"id"`
+	Amount         string `json:"amount"`
+	TransactionFee string `json:"transactionFee"`
+	Coin           string `json:"coin"`
+	Status         int    `json:"status"`
+	Address        string `json:"address"`
+	AddressTag     string `json:"addressTag"`
+	TxId           string `json:"txId"`
+	ApplyTime      string `json:"applyTime"` //提币: 2006-01-02 15:04:05 UTC
+	InsertTime     int64  `json:"insertTime"`
+	Network        string `json:"network"`
+}
+
+func (w *Wallet) getDepositWithdrawHistory(uri string, currency *Currency) ([]depositWithdrawResponse, error) {
+	params := url.Values{}
+	if currency != nil && *currency != UNKNOWN {
+		params.Set("coin", currency.Symbol)
 	}
-	logger.Debugf("response body: %s", string(resp))
-	respmap := make(map[string]interface{})
-	err = json.Unmarshal(resp, &respmap)
+	var response []depositWithdrawResponse
+	err := w.ba.doRequest("GET", uri, params, &response)
+	return response, err
+}
+
+func (w *Wallet) GetWithDrawHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	response, err := w.getDepositWithdrawHistory("/sapi/v1/capital/withdraw/history", currency)
 	if err != nil {
 		return nil, err
 	}
 
-	return nil, nil
+	history := make([]DepositWithdrawHistory, 0, len(response))
+	for _, r := range response {
+		ts, _ := time.Parse("2006-01-02 15:04:05", r.ApplyTime)
+		history = append(history, DepositWithdrawHistory{
+			WithdrawalId: r.Id,
+			Currency:     r.Coin,
+			Txid:         r.TxId,
+			Amount:       ToFloat64(r.Amount),
+			To:           r.Address,
+			Memo:         r.AddressTag,
+			Fee:          r.TransactionFee,
+			Status:       withdrawStatus[r.Status],
+			Timestamp:    ts,
+		})
+	}
+	return history, nil
 }
 
 func (w *Wallet) GetDepositHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
-	historyUrl := w.conf.Endpoint + "/wapi/v3/depositHistory.html"
-	postParam := url.Values{}
-	postParam.Set("asset", currency.Symbol)
-	w.ba.buildParamsSigned(&postParam)
+	response, err := w.getDepositWithdrawHistory("/sapi/v1/capital/deposit/hisrec", currency)
+	if err != nil {
+		return nil, err
+	}
 
-	resp, err := HttpGet5(w.ba.httpClient, historyUrl+"?"+postParam.Encode(),
-		map[string]string{"X-MBX-APIKEY": w.ba.accessKey})
+	history := make([]DepositWithdrawHistory, 0, len(response))
+	for _, r := range response {
+		history = append(history, DepositWithdrawHistory{
+			Currency:  r.Coin,
+			Txid:      r.TxId,
+			Amount:    ToFloat64(r.Amount),
+			To:        r.Address,
+			Memo:      r.AddressTag,
+			Status:    depositStatus[r.Status],
+			Timestamp: time.Unix(0, r.InsertTime*int64(time.Millisecond)),
+		})
+	}
+	return history, nil
+}
+
+//每次只返回一个地址, chain为空时返回默认网络的地址
+func (w *Wallet) GetDepositAddress(currency Currency, chain string) ([]ChainAddress, error) {
+	params := url.Values{}
+	params.Set("coin", currency.Symbol)
+	if chain != "" {
+		params.Set("network", chain)
+	}
 
+	var response struct {
+		Address string `json:"address"`
+		Coin    string `json:"coin"`
+		Tag     string `json:"tag"`
+	}
+	err := w.ba.doRequest("GET", "/sapi/v1/capital/deposit/address", params, &response)
 	if err != nil {
 		return nil, err
 	}
-	logger.Debugf("response body: %s", string(resp))
-	respmap := make(map[string]interface{})
-	err = json.Unmarshal(resp, &respmap)
+	return []ChainAddress{{Currency: response.Coin, Chain: chain, Address: response.Address, Tag: response.Tag}}, nil
+}
+
+func (w *Wallet) GetWithdrawChains(currency Currency) ([]WithdrawChain, error) {
+	var response []struct {
+		Coin        string `json:"coin"`
+		NetworkList []struct {
+			Network        string `json:"network"`
+			DepositEnable  bool   `json:"depositEnable"`
+			WithdrawEnable bool   `json:"withdrawEnable"`
+			WithdrawFee    string `json:"withdrawFee"`
+			WithdrawMin    string `json:"withdrawMin"`
+		} `json:"networkList"`
+	}
+	err := w.ba.doRequest("GET", "/sapi/v1/capital/config/getall", url.Values{}, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	return nil, nil
+	var chains []WithdrawChain
+	for _, c := range response {
+		if !strings.EqualFold(c.Coin, currency.Symbol) {
+			continue
+		}
+		for _, n := range c.NetworkList {
+			chains = append(chains, WithdrawChain{
+				Currency:    c.Coin,
+				Chain:       n.Network,
+				CanDeposit:  n.DepositEnable,
+				CanWithdraw: n.WithdrawEnable,
+				MinFee:      ToFloat64(n.WithdrawFee),
+				MaxFee:      ToFloat64(n.WithdrawFee),
+				MinAmount:   ToFloat64(n.WithdrawMin),
+			})
+		}
+	}
+	return chains, nil
+}
+
+//币安不支持通过api撤销提币
+func (w *Wallet) CancelWithdrawal(withdrawId string) error {
+	return EX_ERR_NOT_SUPPORT
 }
@@ -1,9 +1,12 @@
 package binance
 
 import (
-	"github.com/lucas7788/goex"
 	"net/http"
 	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
 )
 
 var wallet *Wallet
@@ -24,3 +27,47 @@ func TestWallet_Transfer(t *testing.T) {
 		Amount:   100,
 	}))
 }
+
+func TestWallet_WithdrawChainsAndHistory(t *testing.T) {
+	srv := testserver.New(binanceAPI, map[string]testserver.Route{
+		"GET /sapi/v1/capital/config/getall": func(r *testserver.Request) interface{} {
+			return `[{"coin":"BTC","networkList":[{"network":"BTC","depositEnable":true,"withdrawEnable":true,"withdrawFee":"0.0005","withdrawMin":"0.001"}]},
+				{"coin":"USDT","networkList":[{"network":"TRX","depositEnable":true,"withdrawEnable":true,"withdrawFee":"1","withdrawMin":"10"},
+				{"network":"ETH","depositEnable":true,"withdrawEnable":false,"withdrawFee":"15","withdrawMin":"30"}]}]`
+		},
+		"GET /sapi/v1/capital/withdraw/history": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "USDT", params["coin"])
+			return `[{"id":"b6ae22b3","amount":"8.91","transactionFee":"1","coin":"USDT","status":6,"address":"TXyz","txId":"0xb5ef",
+				"applyTime":"2019-10-12 11:12:02","network":"TRX"},{"id":"156ec387","amount":"5","coin":"USDT","status":4,"applyTime":"2019-09-24 12:43:45"},
+				{"id":"2a9c0d41","amount":"1","coin":"USDT","status":9,"applyTime":"2019-09-24 12:43:45"}]`
+		},
+		"POST /sapi/v1/capital/withdraw/apply": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "TRX", params["network"])
+			assert.Equal(t, "USDT", params["coin"])
+			return `{"id":"7213fea8e94b4a5593d507237e5a555b"}`
+		},
+	})
+	defer srv.Close()
+	conf := testserver.Config(srv)
+
+	w := NewWallet(conf)
+	chains, err := w.GetWithdrawChains(goex.USDT)
+	assert.Nil(t, err)
+	assert.Len(t, chains, 2)
+	assert.Equal(t, goex.WithdrawChain{Currency: "USDT", Chain: "TRX", CanDeposit: true, CanWithdraw: true, MinFee: 1, MaxFee: 1, MinAmount: 10}, chains[0])
+
+	id, err := w.Withdrawal(goex.WithdrawParameter{Currency: "usdt", Amount: 10, ToAddress: "TXyz", Chain: "TRX"})
+	assert.Nil(t, err)
+	assert.Equal(t, "7213fea8e94b4a5593d507237e5a555b", id)
+
+	history, err := w.GetWithDrawHistory(&goex.USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, goex.WITHDRAW_COMPLETED, history[0].Status)
+	assert.Equal(t, goex.WITHDRAW_SENT, history[1].Status)
+	assert.Equal(t, goex.WITHDRAW_UNKNOWN, history[2].Status)
+	assert.Equal(t, int64(1570878722), history[0].Timestamp.Unix())
+
+	assert.Equal(t, goex.EX_ERR_NOT_SUPPORT, w.CancelWithdrawal(id))
+}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	. "github.com/lucas7788/goex"
 )
@@ -128,6 +130,27 @@ func (bfx *Bitfinex) Transfer(amount float64, currency Currency, fromWallet, toW
 	return errors.New(resp[0]["message"].(string))
 }
 
+//交易所(exchange), 保证金(trading), 融资(deposit)钱包
+var walletTypes = map[AccountKind]string{
+	ACCOUNT_SPOT:    "exchange",
+	ACCOUNT_MARGIN:  "trading",
+	ACCOUNT_FUNDING: "deposit",
+}
+
+//钱包之间划转, 接口不返回划转id
+func (bfx *Bitfinex) TransferAsset(req TransferRequest) (string, error) {
+	from, ok1 := walletTypes[req.From]
+	to, ok2 := walletTypes[req.To]
+	if !ok1 || !ok2 {
+		return "", fmt.Errorf("unsupported transfer from %s to %s", req.From, req.To)
+	}
+	return "", bfx.Transfer(req.Amount, req.Currency, from, to)
+}
+
+func (bfx *Bitfinex) GetTransferHistory(currency Currency, from, to AccountKind, optional ...OptionalParameter) ([]TransferRecord, error) {
+	return nil, EX_ERR_NOT_SUPPORT
+}
+
 func (bfx *Bitfinex) newOffer(currency Currency, amount, rate string, period int, direction string) (error, *LendOrder) {
 	path := "offer/new"
 	params := map[string]interface{}{
@@ -221,3 +244,185 @@ func (bfx *Bitfinex) MytradesFunding(currency Currency, limit int) (error, []Tra
 	}
 	return nil, trades
 }
+
+//融资钱包的一笔利息收入, 已扣除手续费
+type FundingPayment struct {
+	Amount float64
+	Time   time.Time
+}
+
+//融资钱包余额变动历史中的利息收入, 按时间升序, since为零值时不限制开始时间
+func (bfx *Bitfinex) FundingPayments(currency Currency, since time.Time) ([]FundingPayment, error) {
+	var history []struct {
+		Amount      string `json:"amount"`
+		Description string `json:"description"`
+		Timestamp   string `json:"timestamp"`
+	}
+	payload := map[string]interface{}{
+		"currency": strings.ToUpper(currency.Symbol),
+		"wallet":   "deposit",
+		"limit":    500,
+	}
+	if !since.IsZero() {
+		payload["since"] = fmt.Sprint(since.Unix())
+	}
+	err := bfx.doAuthenticatedRequest("POST", "history", payload, &history)
+	if err != nil {
+		return nil, err
+	}
+
+	var payments []FundingPayment
+	for _, h := range history {
+		if !strings.Contains(h.Description, "Funding Payment") {
+			continue
+		}
+		ts := ToFloat64(h.Timestamp)
+		payments = append(payments, FundingPayment{
+			Amount: ToFloat64(h.Amount),
+			Time:   time.Unix(0, int64(ts*float64(time.Second))),
+		})
+	}
+	sort.Slice(payments, func(i, j int) bool { return payments[i].Time.Before(payments[j].Time) })
+	return payments, nil
+}
+
+//融资钱包的利息收入, 从余额变动历史中汇总
+func (bfx *Bitfinex) FundingInterest(currency Currency) (float64, error) {
+	payments, err := bfx.FundingPayments(currency, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+
+	interest := 0.0
+	for _, p := range payments {
+		interest += p.Amount
+	}
+	return interest, nil
+}
+
+//每个币种一个产品, ProductId为融资symbol(如fUSD), 利率为最新成交利率
+func (bfx *Bitfinex) GetLendingProducts(currency Currency) ([]LendingProduct, error) {
+	tickers, err := bfx.GetLendTickers()
+	if err != nil {
+		return nil, err
+	}
+
+	var products []LendingProduct
+	for _, t := range tickers {
+		if currency != UNKNOWN && t.Coin != currency {
+			continue
+		}
+		products = append(products, LendingProduct{
+			ProductId:    "f" + t.Coin.Symbol,
+			Currency:     t.Coin,
+			Rate:         t.Last * 365,
+			Period:       2,
+			CanSubscribe: true,
+		})
+	}
+	return products, nil
+}
+
+//挂放贷单, 返回挂单id, Rate为0时使用借贷订单簿中最低的放贷利率
+func (bfx *Bitfinex) SubscribeLending(param LendingParameter) (string, error) {
+	rate := param.Rate
+	if rate == 0 {
+		err, book := bfx.GetLendBook(param.Currency)
+		if err != nil {
+			return "", err
+		}
+		for _, a := range book.Asks {
+			if rate == 0 || a.Rate < rate {
+				rate = a.Rate
+			}
+		}
+		if rate == 0 {
+			return "", fmt.Errorf("no lending rate for %s", param.Currency.Symbol)
+		}
+	}
+	period := param.Period
+	if period == 0 {
+		period = 2
+	}
+
+	err, order := bfx.NewLendOrder(param.Currency, FloatToString(param.Amount, 8), FloatToString(rate, 4), period)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(order.Id), nil
+}
+
+/**
+ * 只能撤销未成交的放贷挂单, 已借出的资金到期后自动返还
+ * ProductId为挂单id时撤销该挂单, 否则撤销该币种所有的放贷挂单
+ */
+func (bfx *Bitfinex) RedeemLending(param LendingParameter) (string, error) {
+	if id := ToInt(param.ProductId); id > 0 {
+		err, _ := bfx.CancelLendOrder(id)
+		return param.ProductId, err
+	}
+
+	err, offers := bfx.ActiveLendOrders()
+	if err != nil {
+		return "", err
+	}
+	for _, o := range offers {
+		if o.Direction != "lend" || !strings.EqualFold(o.Currency, param.Currency.Symbol) {
+			continue
+		}
+		if err, _ := bfx.CancelLendOrder(o.Id); err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+//Amount为借出和挂单中的数量之和, Rate为借出部分的加权利率
+func (bfx *Bitfinex) GetLendingPositions(currency Currency) ([]LendingPosition, error) {
+	err, credits := bfx.ActiveCredits()
+	if err != nil {
+		return nil, err
+	}
+	err, offers := bfx.ActiveLendOrders()
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []*LendingPosition
+	position := func(symbol string) *LendingPosition {
+		c := NewCurrency(symbol, "")
+		for _, p := range positions {
+			if p.Currency == c {
+				return p
+			}
+		}
+		p := &LendingPosition{ProductId: "f" + c.Symbol, Currency: c}
+		positions = append(positions, p)
+		return p
+	}
+
+	for _, c := range credits {
+		if c.Amount <= 0 || (currency != UNKNOWN && !strings.EqualFold(c.Currency, currency.Symbol)) {
+			continue
+		}
+		p := position(c.Currency)
+		p.Rate = (p.Rate*p.Lent + c.Rate*c.Amount) / (p.Lent + c.Amount)
+		p.Lent += c.Amount
+		p.Amount += c.Amount
+	}
+	for _, o := range offers {
+		if o.Direction != "lend" || (currency != UNKNOWN && !strings.EqualFold(o.Currency, currency.Symbol)) {
+			continue
+		}
+		position(o.Currency).Amount += o.RemainingAmount
+	}
+
+	ret := make([]LendingPosition, 0, len(positions))
+	for _, p := range positions {
+		if p.Interest, err = bfx.FundingInterest(p.Currency); err != nil {
+			return nil, err
+		}
+		ret = append(ret, *p)
+	}
+	return ret, nil
+}
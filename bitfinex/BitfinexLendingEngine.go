@@ -0,0 +1,345 @@
+package bitfinex
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/logger"
+)
+
+//放贷引擎使用的接口, *Bitfinex实现了该接口, 测试时可替换为mock
+type LendingClient interface {
+	GetLendBook(currency Currency) (error, *LendBook)
+	GetLendTickers() ([]LendTicker, error)
+	GetDepositWalletBalance() (*Account, error)
+	NewLendOrder(currency Currency, amount, rate string, period int) (error, *LendOrder)
+	CancelLendOrder(id int) (error, *LendOrder)
+	ActiveLendOrders() (error, []LendOrder)
+	ActiveCredits() (error, []LendOrder)
+	FundingPayments(currency Currency, since time.Time) ([]FundingPayment, error)
+}
+
+/**
+ * 阶梯中的一档
+ * Share 分配的资金比例, 按所有档位的Share之和归一
+ * RateFactor 在基准利率上的倍数, 为0时为1
+ */
+type LendingRung struct {
+	Period     int //天数, 2-120
+	Share      float64
+	RateFactor float64
+}
+
+/**
+ * 利率均为年化百分比, 与v1接口一致
+ * DepthAmount 基准利率为借贷订单簿asks累计数量达到DepthAmount的档位利率, 订单簿为空时使用ticker的最新利率
+ * Reserve 融资钱包中保留不放贷的数量
+ * MinAmount 单笔最小放贷数量(币种单位), bitfinex要求价值不低于50USD, USD为0时使用50, 其他币种必须指定
+ * RepriceAfter 未成交的放贷挂单超过该时间后撤销, 按最新利率重新挂单
+ */
+type LendingConfig struct {
+	Currency     Currency
+	Ladder       []LendingRung
+	MinRate      float64
+	DepthAmount  float64
+	Reserve      float64
+	MinAmount    float64
+	RepriceAfter time.Duration
+	Interval     time.Duration
+}
+
+type LendingStats struct {
+	Currency       Currency
+	MarketRate     float64 //基准利率
+	Offered        float64 //挂单中的数量
+	Lent           float64 //借出中的数量
+	Credits        int
+	InterestEarned float64 //引擎启动后收到的利息, 已扣除手续费
+	RealisedAPR    float64 //已实现年化收益率(%), 利息除以借出数量和时间的加权
+	PlacedOffers   int
+	RepricedOffers int
+	UpdateTime     time.Time
+}
+
+/**
+ * 融资放贷引擎
+ * 每轮依次: 从融资钱包流水累计利息并刷新借出 => 撤销超时未成交的挂单 => 用可用余额按阶梯挂单
+ * runLock保证同时只有一轮在执行, lock只保护stats, 网络请求时不持有lock
+ */
+type LendingEngine struct {
+	client  LendingClient
+	config  LendingConfig
+	runLock sync.Mutex
+	lock    sync.Mutex
+	stats   LendingStats
+	credits []LendOrder
+	//借出数量*天数, 用于计算已实现年化
+	capitalDays float64
+	//已统计的最后一笔利息的时间
+	lastPayment time.Time
+	now         func() time.Time
+}
+
+func NewLendingEngine(client LendingClient, config LendingConfig) (*LendingEngine, error) {
+	if len(config.Ladder) == 0 {
+		return nil, errors.New("empty ladder")
+	}
+	for _, r := range config.Ladder {
+		if r.Period < 2 || r.Period > 120 {
+			return nil, fmt.Errorf("invalid period %d", r.Period)
+		}
+		if r.Share <= 0 {
+			return nil, fmt.Errorf("invalid share %v", r.Share)
+		}
+	}
+	if config.MinAmount <= 0 {
+		if config.Currency != USD {
+			return nil, fmt.Errorf("MinAmount is required for %s, bitfinex requires at least 50 USD", config.Currency.Symbol)
+		}
+		config.MinAmount = 50
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	return &LendingEngine{
+		client: client,
+		config: config,
+		stats:  LendingStats{Currency: config.Currency},
+		now:    time.Now,
+	}, nil
+}
+
+func (e *LendingEngine) Stats() LendingStats {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.stats
+}
+
+//按Interval循环执行, 直到stop被关闭
+func (e *LendingEngine) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+	for {
+		if err := e.RunOnce(); err != nil {
+			logger.Errorf("[bitfinex lending] %s", err.Error())
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//修改stats
+func (e *LendingEngine) update(f func(stats *LendingStats)) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	f(&e.stats)
+}
+
+func (e *LendingEngine) RunOnce() error {
+	e.runLock.Lock()
+	defer e.runLock.Unlock()
+
+	if err := e.updateCredits(); err != nil {
+		return err
+	}
+	if err := e.repriceOffers(); err != nil {
+		return err
+	}
+	return e.placeOffers()
+}
+
+func (e *LendingEngine) isCurrency(o LendOrder) bool {
+	return strings.EqualFold(o.Currency, e.config.Currency.Symbol)
+}
+
+/**
+ * 利息取自融资钱包流水中上一轮之后的Funding Payment, 第一轮只记录开始时间
+ * 上一轮的借出按经过的时间累计本金天数, 再刷新借出列表
+ */
+func (e *LendingEngine) updateCredits() error {
+	now := e.now()
+	var payments []FundingPayment
+	if !e.lastPayment.IsZero() {
+		var err error
+		payments, err = e.client.FundingPayments(e.config.Currency, e.lastPayment)
+		if err != nil {
+			return err
+		}
+	}
+	err, credits := e.client.ActiveCredits()
+	if err != nil {
+		return err
+	}
+
+	interest := 0.0
+	for _, p := range payments {
+		if p.Time.After(e.lastPayment) {
+			interest += p.Amount
+			e.lastPayment = p.Time
+		}
+	}
+	if e.lastPayment.IsZero() {
+		e.lastPayment = now
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if !e.stats.UpdateTime.IsZero() {
+		days := now.Sub(e.stats.UpdateTime).Hours() / 24
+		for _, c := range e.credits {
+			e.capitalDays += c.Amount * days
+		}
+	}
+	e.stats.InterestEarned += interest
+	if e.capitalDays > 0 {
+		e.stats.RealisedAPR = e.stats.InterestEarned / e.capitalDays * 365 * 100
+	}
+
+	e.credits = e.credits[:0]
+	e.stats.Lent = 0
+	for _, c := range credits {
+		if !e.isCurrency(c) {
+			continue
+		}
+		e.credits = append(e.credits, c)
+		e.stats.Lent += c.Amount
+	}
+	e.stats.Credits = len(e.credits)
+	e.stats.UpdateTime = now
+	return nil
+}
+
+//timestamp为unix秒, 带小数
+func offerTime(o LendOrder) time.Time {
+	ts := ToFloat64(o.Timestamp)
+	return time.Unix(0, int64(ts*float64(time.Second)))
+}
+
+func (e *LendingEngine) repriceOffers() error {
+	err, offers := e.client.ActiveLendOrders()
+	if err != nil {
+		return err
+	}
+
+	offered, repriced := 0.0, 0
+	for _, o := range offers {
+		if !e.isCurrency(o) || o.Direction != "lend" || !o.IsLive {
+			continue
+		}
+		if e.config.RepriceAfter > 0 && e.now().Sub(offerTime(o)) >= e.config.RepriceAfter {
+			if err, _ := e.client.CancelLendOrder(o.Id); err != nil {
+				return err
+			}
+			repriced++
+			continue
+		}
+		offered += o.RemainingAmount
+	}
+	e.update(func(stats *LendingStats) {
+		stats.Offered = offered
+		stats.RepricedOffers += repriced
+	})
+	return nil
+}
+
+//asks按利率升序累计
+func (e *LendingEngine) marketRate() (float64, error) {
+	err, book := e.client.GetLendBook(e.config.Currency)
+	if err != nil {
+		return 0, err
+	}
+	asks := book.Asks
+	sort.Slice(asks, func(i, j int) bool { return asks[i].Rate < asks[j].Rate })
+	cum := 0.0
+	for _, a := range asks {
+		cum += a.Amount
+		if cum >= e.config.DepthAmount {
+			return a.Rate, nil
+		}
+	}
+	if len(asks) > 0 {
+		return asks[len(asks)-1].Rate, nil
+	}
+
+	//ticker的Last为日利率(%)
+	tickers, err := e.client.GetLendTickers()
+	if err != nil {
+		return 0, err
+	}
+	for _, t := range tickers {
+		if t.Coin == e.config.Currency {
+			return t.Last * 365, nil
+		}
+	}
+	return 0, fmt.Errorf("no lending rate for %s", e.config.Currency.Symbol)
+}
+
+func (e *LendingEngine) placeOffers() error {
+	rate, err := e.marketRate()
+	if err != nil {
+		return err
+	}
+	e.update(func(stats *LendingStats) {
+		stats.MarketRate = rate
+	})
+
+	acc, err := e.client.GetDepositWalletBalance()
+	if err != nil {
+		return err
+	}
+	if acc == nil {
+		return nil
+	}
+	available := acc.SubAccounts[e.config.Currency].Amount - e.config.Reserve
+	if available < e.config.MinAmount {
+		return nil
+	}
+
+	totalShare := 0.0
+	for _, r := range e.config.Ladder {
+		totalShare += r.Share
+	}
+
+	//数量不足MinAmount的档位按MinAmount挂单, 最后一档使用剩余的全部资金
+	remaining := available
+	for i, r := range e.config.Ladder {
+		if remaining < e.config.MinAmount {
+			break
+		}
+		amount := available * r.Share / totalShare
+		if amount < e.config.MinAmount {
+			amount = e.config.MinAmount
+		}
+		if i == len(e.config.Ladder)-1 || remaining-amount < e.config.MinAmount {
+			amount = remaining
+		}
+
+		factor := r.RateFactor
+		if factor == 0 {
+			factor = 1
+		}
+		offerRate := rate * factor
+		if offerRate < e.config.MinRate {
+			offerRate = e.config.MinRate
+		}
+
+		err, _ := e.client.NewLendOrder(e.config.Currency, FloatToString(amount, 8), FloatToString(offerRate, 4), r.Period)
+		if err != nil {
+			return err
+		}
+		remaining -= amount
+		e.update(func(stats *LendingStats) {
+			stats.Offered += amount
+			stats.PlacedOffers++
+		})
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package bitfinex
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLendingClient struct {
+	book      LendBook
+	tickers   []LendTicker
+	available float64
+	offers    []LendOrder
+	credits   []LendOrder
+	placed    []LendOrder
+	cancelled []int
+	payments  []FundingPayment
+	since     []time.Time
+	onRequest func()
+}
+
+func (m *mockLendingClient) GetLendBook(currency goex.Currency) (error, *LendBook) {
+	if m.onRequest != nil {
+		m.onRequest()
+	}
+	return nil, &m.book
+}
+
+func (m *mockLendingClient) GetLendTickers() ([]LendTicker, error) {
+	return m.tickers, nil
+}
+
+func (m *mockLendingClient) GetDepositWalletBalance() (*goex.Account, error) {
+	return &goex.Account{SubAccounts: map[goex.Currency]goex.SubAccount{
+		goex.USD: {Currency: goex.USD, Amount: m.available},
+	}}, nil
+}
+
+func (m *mockLendingClient) NewLendOrder(currency goex.Currency, amount, rate string, period int) (error, *LendOrder) {
+	o := LendOrder{Id: len(m.placed) + 100, Currency: currency.Symbol, Direction: "lend", IsLive: true,
+		Amount: goex.ToFloat64(amount), RemainingAmount: goex.ToFloat64(amount), Rate: goex.ToFloat64(rate), Period: period}
+	m.placed = append(m.placed, o)
+	m.available -= o.Amount
+	return nil, &o
+}
+
+func (m *mockLendingClient) CancelLendOrder(id int) (error, *LendOrder) {
+	for _, o := range m.offers {
+		if o.Id == id {
+			m.cancelled = append(m.cancelled, id)
+			m.available += o.RemainingAmount
+			return nil, &o
+		}
+	}
+	return fmt.Errorf("offer %d not found", id), nil
+}
+
+func (m *mockLendingClient) ActiveLendOrders() (error, []LendOrder) {
+	return nil, m.offers
+}
+
+func (m *mockLendingClient) ActiveCredits() (error, []LendOrder) {
+	return nil, m.credits
+}
+
+func (m *mockLendingClient) FundingPayments(currency goex.Currency, since time.Time) ([]FundingPayment, error) {
+	m.since = append(m.since, since)
+	return m.payments, nil
+}
+
+func TestLendingEngine_RunOnce(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	client := &mockLendingClient{
+		book: LendBook{Asks: []LendBookItem{
+			{Rate: 12, Amount: 3000, Period: 2},
+			{Rate: 10, Amount: 1000, Period: 2},
+			{Rate: 15, Amount: 5000, Period: 30},
+		}},
+		available: 1000,
+		offers: []LendOrder{
+			{Id: 1, Currency: "USD", Direction: "lend", IsLive: true, RemainingAmount: 200, Timestamp: fmt.Sprint(now.Add(-2 * time.Hour).Unix())},
+			{Id: 2, Currency: "USD", Direction: "lend", IsLive: true, RemainingAmount: 300, Timestamp: fmt.Sprint(now.Add(-10 * time.Minute).Unix())},
+			{Id: 3, Currency: "BTC", Direction: "lend", IsLive: true, RemainingAmount: 1, Timestamp: "0"},
+		},
+		credits: []LendOrder{{Id: 10, Currency: "USD", Amount: 3650, Rate: 20, Period: 2}},
+	}
+
+	engine, err := NewLendingEngine(client, LendingConfig{
+		Currency: goex.USD,
+		Ladder: []LendingRung{
+			{Period: 2, Share: 0.6},
+			{Period: 30, Share: 0.3, RateFactor: 1.2},
+			{Period: 120, Share: 0.1, RateFactor: 1.5},
+		},
+		MinRate:      13,
+		DepthAmount:  2000,
+		RepriceAfter: time.Hour,
+	})
+	assert.Nil(t, err)
+	engine.now = func() time.Time { return now }
+
+	//请求交易所时不持有stats的锁
+	client.onRequest = func() { engine.Stats() }
+	assert.Nil(t, engine.RunOnce())
+	assert.Equal(t, []int{1}, client.cancelled)
+
+	//可用1000+撤单200, 第三档不足50按剩余挂单
+	assert.Len(t, client.placed, 3)
+	assert.Equal(t, 720.0, client.placed[0].Amount)
+	assert.Equal(t, 13.0, client.placed[0].Rate)
+	assert.Equal(t, 2, client.placed[0].Period)
+	assert.Equal(t, 360.0, client.placed[1].Amount)
+	assert.Equal(t, 14.4, client.placed[1].Rate)
+	assert.Equal(t, 120.0, client.placed[2].Amount)
+	assert.Equal(t, 18.0, client.placed[2].Rate)
+
+	stats := engine.Stats()
+	assert.Equal(t, 12.0, stats.MarketRate)
+	assert.Equal(t, 1500.0, stats.Offered)
+	assert.Equal(t, 3650.0, stats.Lent)
+	assert.Equal(t, 1, stats.Credits)
+	assert.Equal(t, 0.0, stats.InterestEarned)
+	assert.Empty(t, client.since)
+
+	//一天后收到利息1.7, 引擎启动前的利息不统计
+	start := now
+	now = now.Add(24 * time.Hour)
+	client.offers = nil
+	client.payments = []FundingPayment{
+		{Amount: 3, Time: start.Add(-time.Hour)},
+		{Amount: 1.7, Time: start.Add(23 * time.Hour)},
+	}
+	assert.Nil(t, engine.RunOnce())
+	stats = engine.Stats()
+	assert.Equal(t, []time.Time{start}, client.since)
+	assert.InDelta(t, 1.7, stats.InterestEarned, 1e-9)
+	assert.InDelta(t, 17, stats.RealisedAPR, 1e-9)
+	assert.Len(t, client.placed, 3)
+
+	//已统计的利息不重复累计
+	now = now.Add(24 * time.Hour)
+	assert.Nil(t, engine.RunOnce())
+	stats = engine.Stats()
+	assert.Equal(t, start.Add(23*time.Hour), client.since[1])
+	assert.InDelta(t, 1.7, stats.InterestEarned, 1e-9)
+	assert.InDelta(t, 8.5, stats.RealisedAPR, 1e-9)
+}
+
+func TestLendingEngine_TickerRate(t *testing.T) {
+	client := &mockLendingClient{
+		tickers:   []LendTicker{{Ticker: goex.Ticker{Last: 0.02}, Coin: goex.USD}},
+		available: 100,
+	}
+	engine, err := NewLendingEngine(client, LendingConfig{Currency: goex.USD, Ladder: []LendingRung{{Period: 2, Share: 1}}})
+	assert.Nil(t, err)
+
+	assert.Nil(t, engine.RunOnce())
+	assert.Len(t, client.placed, 1)
+	assert.InDelta(t, 7.3, client.placed[0].Rate, 1e-9)
+	assert.Equal(t, 100.0, client.placed[0].Amount)
+
+	_, err = NewLendingEngine(client, LendingConfig{Currency: goex.USD, Ladder: []LendingRung{{Period: 1, Share: 1}}})
+	assert.NotNil(t, err)
+
+	//非USD币种必须指定MinAmount
+	_, err = NewLendingEngine(client, LendingConfig{Currency: goex.BTC, Ladder: []LendingRung{{Period: 2, Share: 1}}})
+	assert.NotNil(t, err)
+	_, err = NewLendingEngine(client, LendingConfig{Currency: goex.BTC, Ladder: []LendingRung{{Period: 2, Share: 1}}, MinAmount: 0.005})
+	assert.Nil(t, err)
+
+	_, err = NewLendingEngine(bfx, LendingConfig{Currency: goex.USD, Ladder: []LendingRung{{Period: 2, Share: 1}}})
+	assert.Nil(t, err)
+}
+
+func TestBitfinex_FundingPayments(t *testing.T) {
+	srv := testserver.New(bitfinexAPI, map[string]testserver.Route{
+		"/v1/history": func(r *testserver.Request) interface{} {
+			payload := bfxPayload(r)
+			assert.Equal(t, "USD", payload["currency"])
+			assert.Equal(t, "deposit", payload["wallet"])
+			assert.Equal(t, "1600000000", payload["since"])
+			return `[{"currency":"USD","amount":"0.85","balance":"1000.85","description":"Margin Funding Payment on wallet deposit","timestamp":"1600086400.0"},
+				{"currency":"USD","amount":"-100.0","balance":"1000.0","description":"Transfer of 100.0 USD from wallet Deposit to Exchange","timestamp":"1600050000.0"},
+				{"currency":"USD","amount":"0.8","balance":"1100.0","description":"Margin Funding Payment on wallet deposit","timestamp":"1600000000.5"}]`
+		},
+	})
+	defer srv.Close()
+	margin := NewMargin(testserver.RedirectClient(srv), "key", "secret")
+
+	payments, err := margin.FundingPayments(goex.USD, time.Unix(1600000000, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, []FundingPayment{
+		{Amount: 0.8, Time: time.Unix(1600000000, 5e8)},
+		{Amount: 0.85, Time: time.Unix(1600086400, 0)},
+	}, payments)
+}
@@ -1,6 +1,11 @@
 package bitfinex
 
-import . "github.com/lucas7788/goex"
+import (
+	"fmt"
+	"net/http"
+
+	. "github.com/lucas7788/goex"
+)
 
 type MarginLimits struct {
 	Pair              string  `json:"on_pair"`
@@ -53,3 +58,143 @@ func (bfx *Bitfinex) GetMarginInfos() ([]MarginInfo, error) {
 	}
 	return marginInfo, nil
 }
+
+/**
+ * 保证金交易, 实现MarginAPI
+ * 下单时不足部分自动从融资市场借入, 不支持主动借币
+ */
+type BitfinexMargin struct {
+	*Bitfinex
+}
+
+type takenFund struct {
+	Id         int64   `json:"id"`
+	PositionId int64   `json:"position_id"`
+	Currency   string  `json:"currency"`
+	Rate       float64 `json:"rate,string"`
+	Period     int     `json:"period"`
+	Amount     float64 `json:"amount,string"`
+	Timestamp  string  `json:"timestamp"`
+	AutoClose  bool    `json:"auto_close"`
+}
+
+func NewMargin(client *http.Client, accessKey, secretKey string) *BitfinexMargin {
+	return &BitfinexMargin{New(client, accessKey, secretKey)}
+}
+
+func (bfx *BitfinexMargin) getTakenFunds() ([]takenFund, error) {
+	var funds []takenFund
+	err := bfx.doAuthenticatedRequest("POST", "taken_funds", map[string]interface{}{}, &funds)
+	if err != nil {
+		return nil, err
+	}
+	return funds, nil
+}
+
+/**
+ * 保证金钱包, 全仓模式返回所有币种
+ * RiskRate为净值/所需保证金
+ */
+func (bfx *BitfinexMargin) GetMarginAccount(pair CurrencyPair) (*MarginAccount, error) {
+	trading, err := bfx.GetMarginTradingWalletBalance()
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &MarginAccount{Sub: make(map[Currency]MarginSubAccount, 6)}
+	if trading != nil {
+		for c, sub := range trading.SubAccounts {
+			acc.Sub[c] = MarginSubAccount{
+				Balance:     sub.Amount + sub.ForzenAmount,
+				Frozen:      sub.ForzenAmount,
+				Available:   sub.Amount,
+				CanWithdraw: sub.Amount}
+		}
+	}
+
+	funds, err := bfx.getTakenFunds()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range funds {
+		c := NewCurrency(f.Currency, "")
+		sub := acc.Sub[c]
+		sub.Loan += f.Amount
+		acc.Sub[c] = sub
+	}
+
+	acc.RiskRate, err = bfx.GetRiskRate(pair)
+	if err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+func (bfx *BitfinexMargin) Borrow(parameter BorrowParameter) (borrowId string, err error) {
+	return "", EX_ERR_NOT_SUPPORT
+}
+
+//归还融资, BorrowId为taken_funds返回的id
+func (bfx *BitfinexMargin) Repayment(parameter RepaymentParameter) (repaymentId string, err error) {
+	var respmap map[string]interface{}
+	err = bfx.doAuthenticatedRequest("POST", "funding/close", map[string]interface{}{"swap_id": ToInt(parameter.BorrowId)}, &respmap)
+	if err != nil {
+		return "", err
+	}
+	if msg, ok := respmap["message"]; ok {
+		return "", fmt.Errorf("%v", msg)
+	}
+	return fmt.Sprint(ToInt(respmap["id"])), nil
+}
+
+//正在使用的融资
+func (bfx *BitfinexMargin) GetLoanHistory(pair CurrencyPair, currency Currency, optional ...OptionalParameter) ([]MarginLoan, error) {
+	funds, err := bfx.getTakenFunds()
+	if err != nil {
+		return nil, err
+	}
+
+	var loans []MarginLoan
+	for _, f := range funds {
+		c := NewCurrency(f.Currency, "")
+		if currency.Symbol != "" && c != currency {
+			continue
+		}
+		loans = append(loans, MarginLoan{
+			LoanId:      fmt.Sprint(f.Id),
+			Pair:        UNKNOWN_PAIR,
+			Currency:    c,
+			Amount:      f.Amount,
+			Status:      "active",
+			CreatedTime: int64(ToFloat64(f.Timestamp) * 1000),
+		})
+	}
+	return loans, nil
+}
+
+func (bfx *BitfinexMargin) GetRiskRate(pair CurrencyPair) (float64, error) {
+	infos, err := bfx.GetMarginInfos()
+	if err != nil {
+		return 0, err
+	}
+	if len(infos) == 0 || infos[0].RequiredMargin == 0 {
+		return 0, nil
+	}
+	return infos[0].NetValue / infos[0].RequiredMargin, nil
+}
+
+func (bfx *BitfinexMargin) PlaceOrder(ord *Order) (*Order, error) {
+	amount := FloatToString(ord.Amount, 8)
+	price := FloatToString(ord.Price, 8)
+	switch ord.Side {
+	case BUY:
+		return bfx.placeOrder("limit", "buy", amount, price, ord.Currency)
+	case SELL:
+		return bfx.placeOrder("limit", "sell", amount, price, ord.Currency)
+	case BUY_MARKET:
+		return bfx.placeOrder("market", "buy", amount, price, ord.Currency)
+	case SELL_MARKET:
+		return bfx.placeOrder("market", "sell", amount, price, ord.Currency)
+	}
+	return nil, fmt.Errorf("unsupported order side: %s", ord.Side)
+}
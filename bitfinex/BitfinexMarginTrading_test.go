@@ -0,0 +1,98 @@
+package bitfinex
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+//v1签名接口, 路由的key为path, 返回值为录制的响应; 请求地址是常量, 通过testserver.RedirectClient转发到测试服务器
+var bitfinexAPI = testserver.Options{
+	Fixed: map[string]testserver.Route{
+		"/v2/platform/status": testserver.Reply(`[1]`),
+	},
+	Check: func(r *testserver.Request) interface{} {
+		sign, _ := goex.GetParamHmacSha384Sign("secret", r.Header.Get("X-BFX-PAYLOAD"))
+		payload := bfxPayload(r)
+		if sign != r.Header.Get("X-BFX-SIGNATURE") || r.Header.Get("X-BFX-APIKEY") != "key" ||
+			payload["request"] != r.URL.Path || payload["nonce"] == nil {
+			return testserver.Response{Status: http.StatusBadRequest, Body: `{"message":"Invalid signature"}`}
+		}
+		return nil
+	},
+	Key: func(r *testserver.Request) string {
+		return r.URL.Path
+	},
+}
+
+func bfxPayload(r *testserver.Request) map[string]interface{} {
+	var payload map[string]interface{}
+	data, _ := base64.StdEncoding.DecodeString(r.Header.Get("X-BFX-PAYLOAD"))
+	json.Unmarshal(data, &payload)
+	return payload
+}
+
+func TestBitfinexMargin_GetMarginAccount(t *testing.T) {
+	srv := testserver.New(bitfinexAPI, map[string]testserver.Route{
+		"/v1/balances": func(r *testserver.Request) interface{} {
+			return `[{"type":"trading","currency":"btc","amount":"1.5","available":"1.2"},
+				{"type":"trading","currency":"usd","amount":"100.0","available":"100.0"},
+				{"type":"exchange","currency":"eth","amount":"3.0","available":"3.0"}]`
+		},
+		"/v1/taken_funds": func(r *testserver.Request) interface{} {
+			return `[{"id":11576737,"position_id":944309,"currency":"USD","rate":"9.8874","period":2,"amount":"34.24603414","timestamp":"1444280948.0","auto_close":false}]`
+		},
+		"/v1/margin_infos": func(r *testserver.Request) interface{} {
+			return `[{"margin_balance":"14.80039951","tradable_balance":"-12.50620089","unrealized_pl":"-0.18392","unrealized_swap":"-0.00038653",
+				"net_value":"14.61609298","required_margin":"7.3569","leverage":"2.5","margin_requirement":"13.0",
+				"margin_limits":[{"on_pair":"BTCUSD","initial_margin":"30.0","margin_requirement":"15.0","tradable_balance":"-0.329916"}]}]`
+		},
+	})
+	defer srv.Close()
+	margin := NewMargin(testserver.RedirectClient(srv), "key", "secret")
+
+	acc, err := margin.GetMarginAccount(goex.BTC_USD)
+	assert.Nil(t, err)
+	assert.Len(t, acc.Sub, 2)
+	assert.Equal(t, 1.5, acc.Sub[goex.BTC].Balance)
+	assert.Equal(t, 1.2, acc.Sub[goex.BTC].Available)
+	assert.InDelta(t, 0.3, acc.Sub[goex.BTC].Frozen, 1e-9)
+	assert.Equal(t, 100.0, acc.Sub[goex.USD].Balance)
+	assert.Equal(t, 34.24603414, acc.Sub[goex.USD].Loan)
+	assert.InDelta(t, 14.61609298/7.3569, acc.RiskRate, 1e-9)
+
+	loans, err := margin.GetLoanHistory(goex.BTC_USD, goex.USD)
+	assert.Nil(t, err)
+	assert.Len(t, loans, 1)
+	assert.Equal(t, "11576737", loans[0].LoanId)
+	assert.Equal(t, int64(1444280948000), loans[0].CreatedTime)
+}
+
+func TestBitfinexMargin_BorrowAndRepayment(t *testing.T) {
+	srv := testserver.New(bitfinexAPI, map[string]testserver.Route{
+		"/v1/funding/close": func(r *testserver.Request) interface{} {
+			payload := bfxPayload(r)
+			if payload["swap_id"] == 11576737.0 {
+				return `{"id":11576737,"position_id":944309,"currency":"USD","rate":"9.8874","period":2,"amount":"34.24603414","timestamp":"1444280948.0","auto_close":false}`
+			}
+			return `{"message":"Swap not found"}`
+		},
+	})
+	defer srv.Close()
+	margin := NewMargin(testserver.RedirectClient(srv), "key", "secret")
+
+	_, err := margin.Borrow(goex.BorrowParameter{Currency: goex.USD, CurrencyPair: goex.BTC_USD, Amount: 10})
+	assert.Equal(t, goex.EX_ERR_NOT_SUPPORT, err)
+
+	id, err := margin.Repayment(goex.RepaymentParameter{BorrowParameter: goex.BorrowParameter{Currency: goex.USD}, BorrowId: "11576737"})
+	assert.Nil(t, err)
+	assert.Equal(t, "11576737", id)
+
+	_, err = margin.Repayment(goex.RepaymentParameter{BorrowId: "1"})
+	assert.EqualError(t, err, "Swap not found")
+}
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	. "github.com/lucas7788/goex"
 )
@@ -16,6 +15,7 @@ type Bitfinex struct {
 	httpClient *http.Client
 	accessKey,
 	secretKey string
+	clock *ClockSync
 }
 
 const (
@@ -25,7 +25,14 @@ const (
 )
 
 func New(client *http.Client, accessKey, secretKey string) *Bitfinex {
-	return &Bitfinex{client, accessKey, secretKey}
+	bfx := &Bitfinex{httpClient: client, accessKey: accessKey, secretKey: secretKey}
+	bfx.clock = SharedClockSync(apiURLV2+"/platform/status", bfx.GetServerTime)
+	return bfx
+}
+
+//v2没有服务器时间接口, 使用platform/status响应头中的Date
+func (bfx *Bitfinex) GetServerTime() (int64, error) {
+	return HttpDateTime(bfx.httpClient, apiURLV2+"/platform/status")
 }
 
 func (bfx *Bitfinex) GetExchangeName() string {
@@ -314,7 +321,7 @@ func (bfx *Bitfinex) GetOrderHistorys(currencyPair CurrencyPair, optional ...Opt
 }
 
 func (bfx *Bitfinex) doAuthenticatedRequest(method, path string, payload map[string]interface{}, ret interface{}) error {
-	nonce := time.Now().UnixNano()
+	nonce := bfx.clock.Now().UnixNano()
 	payload["request"] = "/v1/" + path
 	payload["nonce"] = fmt.Sprintf("%d.2", nonce)
 
@@ -2,12 +2,15 @@ package bitfinex
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"strings"
 	"sync"
 	"time"
 
 	. "github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/logger"
 )
 
 const subscribe = "subscribe"
@@ -15,16 +18,40 @@ const subscribed = "subscribed"
 const ticker = "ticker"
 const trades = "trades"
 const candles = "candles"
+const book = "book"
 
+//conf flags, 开启后book频道会推送[chanId, "cs", checksum]
+const flagChecksum = 131072
+
+/**
+ * bitfinex v2 websocket, 实现SpotWsApi
+ * book频道本地维护订单簿并校验checksum, 校验失败时重新订阅
+ * 配置了api key时, 每次(重)连接成功后在channel 0登录, 推送订单/仓位/钱包
+ */
 type BitfinexWs struct {
 	*WsBuilder
 	sync.Once
 	wsConn   *WsConn
+	connErr  error
 	eventMap map[int64]SubscribeEvent
 
-	tickerCallback func(*Ticker)
-	tradeCallback  func(*Trade)
-	candleCallback func(*Kline)
+	bfx       *Bitfinex
+	lock      sync.Mutex
+	books     map[int64]*wsBook
+	authResp  chan error
+	authOnce  sync.Once
+	authErr   error
+	BookLen   int    //25, 100
+	Precision string //P0-P4, R0
+
+	tickerCallback   func(*Ticker)
+	tradeCallback    func(*Trade)
+	candleCallback   func(*Kline)
+	depthCallback    func(*Depth)
+	lendBookCallback func(Currency, *LendBook)
+	orderCallback    func(*Order)
+	positionCallback func(*Position)
+	walletCallback   func(walletType string, account *SubAccount)
 }
 
 type SubscribeEvent struct {
@@ -38,16 +65,58 @@ type SubscribeEvent struct {
 	Key       string `json:"key,omitempty"`
 	Len       string `json:"len,omitempty"`
 	Pair      string `json:"pair"`
+	Currency  string `json:"currency,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Msg       string `json:"msg,omitempty"`
+	Code      int    `json:"code,omitempty"`
 }
 
 type EventMap map[int64]SubscribeEvent
 
+//保证金交易仓位
+type Position struct {
+	Pair             CurrencyPair
+	Status           string  //ACTIVE, CLOSED
+	Amount           float64 //>0为多仓, <0为空仓
+	BasePrice        float64
+	MarginFunding    float64
+	ProfitLoss       float64
+	ProfitLossPerc   float64
+	LiquidationPrice float64
+	Leverage         float64
+}
+
 func NewWs() *BitfinexWs {
-	bws := &BitfinexWs{WsBuilder: NewWsBuilder(), eventMap: make(map[int64]SubscribeEvent)}
+	return NewAuthWs(nil)
+}
+
+//bfx不为nil时登录私有频道
+func NewAuthWs(bfx *Bitfinex) *BitfinexWs {
+	bws := &BitfinexWs{
+		WsBuilder: NewWsBuilder(),
+		eventMap:  make(map[int64]SubscribeEvent),
+		bfx:       bfx,
+		books:     make(map[int64]*wsBook, 2),
+		authResp:  make(chan error, 1),
+		BookLen:   25,
+		Precision: "P0",
+	}
 	bws.WsBuilder = bws.WsBuilder.
 		WsUrl("wss://api-pub.bitfinex.com/ws/2").
 		AutoReconnect().
-		ProtoHandleFunc(bws.handle)
+		ProtoHandleFunc(bws.handle).
+		EventHandleFunc(func(event WsEvent) {
+			//重连后chanId会变化
+			if event.Type == WsEventConnected && event.Attempt > 0 {
+				bws.lock.Lock()
+				bws.eventMap = make(map[int64]SubscribeEvent)
+				bws.books = make(map[int64]*wsBook, 2)
+				bws.lock.Unlock()
+			}
+		})
+	if bfx != nil && bfx.accessKey != "" {
+		bws.WsBuilder = bws.WsBuilder.WsUrl("wss://api.bitfinex.com/ws/2").ConnectSuccessAfterSendMessage(bws.authMessage)
+	}
 	return bws
 }
 
@@ -57,6 +126,36 @@ func (bws *BitfinexWs) SetCallbacks(tickerCallback func(*Ticker), tradeCallback
 	bws.candleCallback = candleCallback
 }
 
+func (bws *BitfinexWs) TickerCallback(call func(*Ticker)) {
+	bws.tickerCallback = call
+}
+
+func (bws *BitfinexWs) TradeCallback(call func(*Trade)) {
+	bws.tradeCallback = call
+}
+
+func (bws *BitfinexWs) DepthCallback(call func(*Depth)) {
+	bws.depthCallback = call
+}
+
+//借贷订单簿回调
+func (bws *BitfinexWs) LendBookCallback(call func(Currency, *LendBook)) {
+	bws.lendBookCallback = call
+}
+
+func (bws *BitfinexWs) OrderCallback(call func(*Order)) {
+	bws.orderCallback = call
+}
+
+func (bws *BitfinexWs) PositionCallback(call func(*Position)) {
+	bws.positionCallback = call
+}
+
+//walletType: exchange, margin, funding
+func (bws *BitfinexWs) WalletCallback(call func(walletType string, account *SubAccount)) {
+	bws.walletCallback = call
+}
+
 func (bws *BitfinexWs) SubscribeTicker(pair CurrencyPair) error {
 	if bws.tickerCallback == nil {
 		return fmt.Errorf("please set ticker callback func")
@@ -95,68 +194,394 @@ func (bws *BitfinexWs) SubscribeCandle(pair CurrencyPair, klinePeriod KlinePerio
 	})
 }
 
+func (bws *BitfinexWs) bookRequest(symbol string) map[string]interface{} {
+	req := map[string]interface{}{
+		"event":   subscribe,
+		"channel": book,
+		"symbol":  symbol,
+		"prec":    bws.Precision,
+		"len":     fmt.Sprint(bws.BookLen),
+	}
+	if bws.Precision != "R0" {
+		req["freq"] = "F0"
+	}
+	return req
+}
+
+//订单簿, 精度和档数由Precision和BookLen决定
+func (bws *BitfinexWs) SubscribeDepth(pair CurrencyPair) error {
+	return bws.subscribe(bws.bookRequest(convertPairToBitfinexSymbol("t", pair)))
+}
+
+//借贷订单簿, 如fUSD
+func (bws *BitfinexWs) SubscribeLendBook(currency Currency) error {
+	return bws.subscribe(bws.bookRequest("f" + strings.ToUpper(currency.Symbol)))
+}
+
 func (bws *BitfinexWs) subscribe(sub map[string]interface{}) error {
-	bws.connectWs()
+	if err := bws.connectWs(); err != nil {
+		return err
+	}
 	return bws.wsConn.Subscribe(sub)
 }
 
-func (bws *BitfinexWs) connectWs() {
+func (bws *BitfinexWs) connectWs() error {
 	bws.Do(func() {
-		bws.wsConn = bws.WsBuilder.Build()
+		bws.wsConn, bws.connErr = bws.WsBuilder.Build()
+		if bws.connErr != nil {
+			return
+		}
+		//开启checksum, 重连后也会重新发送
+		bws.connErr = bws.wsConn.Subscribe(map[string]interface{}{"event": "conf", "flags": flagChecksum})
+	})
+	return bws.connErr
+}
+
+//签名: hex(hmac_sha384(secret, 'AUTH' + nonce))
+func (bws *BitfinexWs) authMessage() []byte {
+	nonce := fmt.Sprint(bws.bfx.clock.Now().UnixNano() / int64(time.Microsecond))
+	payload := "AUTH" + nonce
+	sign, _ := GetParamHmacSha384Sign(bws.bfx.secretKey, payload)
+	data, _ := json.Marshal(map[string]interface{}{
+		"event":       "auth",
+		"apiKey":      bws.bfx.accessKey,
+		"authSig":     sign,
+		"authPayload": payload,
+		"authNonce":   nonce,
+	})
+	return data
+}
+
+//等待登录结果, 登录后自动推送订单/仓位/钱包的快照和更新
+func (bws *BitfinexWs) Login() error {
+	if bws.bfx == nil || bws.bfx.accessKey == "" {
+		return errors.New("api key is required")
+	}
+	if err := bws.connectWs(); err != nil {
+		return err
+	}
+	bws.authOnce.Do(func() {
+		select {
+		case bws.authErr = <-bws.authResp:
+		case <-time.After(10 * time.Second):
+			bws.authErr = errors.New("auth timeout")
+		}
 	})
+	return bws.authErr
+}
+
+func (bws *BitfinexWs) Close() {
+	if bws.wsConn != nil {
+		bws.wsConn.CloseWs()
+	}
+}
+
+func (bws *BitfinexWs) notifyAuth(err error) {
+	select {
+	case bws.authResp <- err:
+	default:
+	}
 }
 
 func (bws *BitfinexWs) handle(msg []byte) error {
-	var event SubscribeEvent
-	if err := json.Unmarshal(msg, &event); err == nil {
-		if event.Event == subscribed {
-			bws.eventMap[event.ChanID] = event
+	if len(msg) > 0 && msg[0] == '{' {
+		return bws.handleEvent(msg)
+	}
+
+	var resp []json.RawMessage
+	if err := json.Unmarshal(msg, &resp); err != nil || len(resp) < 2 {
+		return err
+	}
+
+	var channelID int64
+	json.Unmarshal(resp[0], &channelID)
+
+	var msgType string
+	json.Unmarshal(resp[1], &msgType)
+	if msgType == "hb" {
+		return nil
+	}
+
+	if channelID == 0 {
+		if len(resp) < 3 {
 			return nil
 		}
+		return bws.handleAuthData(msgType, resp[2])
+	}
+
+	bws.lock.Lock()
+	event, ok := bws.eventMap[channelID]
+	bws.lock.Unlock()
+	if !ok {
+		return nil
 	}
 
-	var resp []interface{}
-	if err := json.Unmarshal(msg, &resp); err == nil {
-		channelID := ToInt64(resp[0])
-		event, ok := bws.eventMap[channelID]
-		if !ok {
+	if event.Channel == book {
+		return bws.handleBook(event, msgType, resp)
+	}
+
+	var data []interface{}
+	json.Unmarshal(msg, &data)
+
+	switch event.Channel {
+	case ticker:
+		if raw, ok := data[1].([]interface{}); ok && bws.tickerCallback != nil {
+			pair := symbolToCurrencyPair(event.Pair)
+			t := bws.tickerFromRaw(pair, raw)
+			bws.tickerCallback(t)
+			return nil
+		}
+	case trades:
+		if len(data) < 3 || bws.tradeCallback == nil {
 			return nil
 		}
 
-		switch event.Channel {
-		case ticker:
-			if raw, ok := resp[1].([]interface{}); ok {
-				pair := symbolToCurrencyPair(event.Pair)
-				t := bws.tickerFromRaw(pair, raw)
-				bws.tickerCallback(t)
-				return nil
-			}
-		case trades:
-			if len(resp) < 3 {
+		if raw, ok := data[2].([]interface{}); ok {
+			pair := symbolToCurrencyPair(event.Pair)
+			trade := bws.tradeFromRaw(pair, raw)
+			bws.tradeCallback(trade)
+			return nil
+		}
+	case candles:
+		if raw, ok := data[1].([]interface{}); ok && bws.candleCallback != nil {
+			if len(raw) > 6 {
 				return nil
 			}
 
-			if raw, ok := resp[2].([]interface{}); ok {
-				pair := symbolToCurrencyPair(event.Pair)
-				trade := bws.tradeFromRaw(pair, raw)
-				bws.tradeCallback(trade)
-				return nil
+			kline := klineFromRaw(convertKeyToPair(event.Key), raw)
+			bws.candleCallback(kline)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (bws *BitfinexWs) handleEvent(msg []byte) error {
+	var event SubscribeEvent
+	if err := json.Unmarshal(msg, &event); err != nil {
+		return err
+	}
+
+	switch event.Event {
+	case subscribed:
+		bws.lock.Lock()
+		bws.eventMap[event.ChanID] = event
+		bws.lock.Unlock()
+	case "unsubscribed":
+		bws.lock.Lock()
+		delete(bws.eventMap, event.ChanID)
+		delete(bws.books, event.ChanID)
+		bws.lock.Unlock()
+	case "auth":
+		if event.Status == "OK" {
+			logger.Info("[bitfinex ws] auth success")
+			bws.notifyAuth(nil)
+			return nil
+		}
+		err := fmt.Errorf("auth fail, code: %d, msg: %s", event.Code, event.Msg)
+		bws.notifyAuth(err)
+		return err
+	case "error":
+		logger.Errorf("[bitfinex ws] %s", string(msg))
+		return fmt.Errorf("code: %d, msg: %s", event.Code, event.Msg)
+	}
+	return nil
+}
+
+/**
+ * 快照: [chanId, [[...], [...]]]
+ * 更新: [chanId, [...]]
+ * 校验: [chanId, "cs", checksum]
+ */
+func (bws *BitfinexWs) handleBook(event SubscribeEvent, msgType string, resp []json.RawMessage) error {
+	bws.lock.Lock()
+	defer bws.lock.Unlock()
+
+	if msgType == "cs" {
+		b, ok := bws.books[event.ChanID]
+		if !ok || len(resp) < 3 {
+			return nil
+		}
+		var checksum int32
+		json.Unmarshal(resp[2], &checksum)
+		if local := b.checksum(); local != checksum {
+			delete(bws.books, event.ChanID)
+			go bws.resubscribe(event)
+			return fmt.Errorf("book checksum mismatch: %s, local=%d, remote=%d", event.Symbol, local, checksum)
+		}
+		return nil
+	}
+
+	var snapshot [][]json.Number
+	if err := json.Unmarshal(resp[1], &snapshot); err == nil {
+		b := newWsBook(event)
+		for _, item := range snapshot {
+			b.update(item)
+		}
+		bws.books[event.ChanID] = b
+		bws.notifyBook(event, b)
+		return nil
+	}
+
+	var item []json.Number
+	if err := json.Unmarshal(resp[1], &item); err != nil {
+		return err
+	}
+	b, ok := bws.books[event.ChanID]
+	if !ok { //没有快照的增量无法使用
+		return nil
+	}
+	b.update(item)
+	bws.notifyBook(event, b)
+	return nil
+}
+
+func (bws *BitfinexWs) notifyBook(event SubscribeEvent, b *wsBook) {
+	if b.funding {
+		if bws.lendBookCallback != nil {
+			bws.lendBookCallback(NewCurrency(event.Symbol[1:], ""), b.lendBook())
+		}
+		return
+	}
+	if bws.depthCallback != nil {
+		bws.depthCallback(b.depth(symbolToCurrencyPair(event.Symbol[1:])))
+	}
+}
+
+//checksum校验失败, 重新订阅获取快照
+func (bws *BitfinexWs) resubscribe(event SubscribeEvent) {
+	bws.wsConn.SendJsonMessage(map[string]interface{}{"event": "unsubscribe", "chanId": event.ChanID})
+	req := map[string]interface{}{
+		"event":   subscribe,
+		"channel": book,
+		"symbol":  event.Symbol,
+		"prec":    event.Precision,
+		"len":     event.Len,
+	}
+	if event.Frequency != "" {
+		req["freq"] = event.Frequency
+	}
+	bws.wsConn.SendJsonMessage(req)
+}
+
+/**
+ * channel 0 私有数据
+ * 订单: os(快照), on(新建), ou(更新), oc(撤销/完成)
+ * 仓位: ps, pn, pu, pc
+ * 钱包: ws, wu
+ */
+func (bws *BitfinexWs) handleAuthData(msgType string, data json.RawMessage) error {
+	var items [][]interface{}
+	switch msgType {
+	case "os", "ps", "ws":
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+	case "on", "ou", "oc", "pn", "pu", "pc", "wu":
+		var item []interface{}
+		if err := json.Unmarshal(data, &item); err != nil {
+			return err
+		}
+		items = append(items, item)
+	default:
+		return nil
+	}
+
+	for _, item := range items {
+		switch msgType[0] {
+		case 'o':
+			if bws.orderCallback != nil && len(item) > 17 {
+				bws.orderCallback(adaptWsOrder(item))
 			}
-		case candles:
-			if raw, ok := resp[1].([]interface{}); ok {
-				if len(raw) > 6 {
-					return nil
+		case 'p':
+			if bws.positionCallback != nil && len(item) > 9 {
+				bws.positionCallback(&Position{
+					Pair:             symbolToCurrencyPair(fmt.Sprint(item[0])[1:]),
+					Status:           fmt.Sprint(item[1]),
+					Amount:           toFloat64(item[2]),
+					BasePrice:        toFloat64(item[3]),
+					MarginFunding:    toFloat64(item[4]),
+					ProfitLoss:       toFloat64(item[6]),
+					ProfitLossPerc:   toFloat64(item[7]),
+					LiquidationPrice: toFloat64(item[8]),
+					Leverage:         toFloat64(item[9]),
+				})
+			}
+		case 'w':
+			if bws.walletCallback != nil && len(item) > 4 {
+				balance := toFloat64(item[2])
+				available := balance
+				if item[4] != nil { //需要计算时为null
+					available = toFloat64(item[4])
 				}
-
-				kline := klineFromRaw(convertKeyToPair(event.Key), raw)
-				bws.candleCallback(kline)
-				return nil
+				bws.walletCallback(fmt.Sprint(item[0]), &SubAccount{
+					Currency:     NewCurrency(fmt.Sprint(item[1]), ""),
+					Amount:       available,
+					ForzenAmount: balance - available,
+				})
 			}
 		}
+	}
+	return nil
+}
 
+//null字段返回0
+func toFloat64(v interface{}) float64 {
+	if f, ok := v.(float64); ok {
+		return f
 	}
+	return 0
+}
 
-	return nil
+/**
+ * [ID, GID, CID, SYMBOL, MTS_CREATE, MTS_UPDATE, AMOUNT, AMOUNT_ORIG, TYPE, TYPE_PREV, MTS_TIF, _, FLAGS, STATUS, _, _, PRICE, PRICE_AVG, ...]
+ * AMOUNT为剩余数量, <0为卖单
+ */
+func adaptWsOrder(item []interface{}) *Order {
+	amountOrig := toFloat64(item[7])
+	amount := toFloat64(item[6])
+	orderType := strings.ToLower(fmt.Sprint(item[8]))
+	status := fmt.Sprint(item[13])
+
+	ord := &Order{
+		OrderID:    ToInt(item[0]),
+		OrderID2:   fmt.Sprint(ToInt64(item[0])),
+		Cid:        fmt.Sprint(ToInt64(item[2])),
+		Currency:   symbolToCurrencyPair(fmt.Sprint(item[3])[1:]),
+		OrderTime:  ToInt(item[4]),
+		Amount:     math.Abs(amountOrig),
+		DealAmount: math.Abs(amountOrig - amount),
+		Price:      toFloat64(item[16]),
+		AvgPrice:   toFloat64(item[17]),
+		Type:       strings.TrimPrefix(orderType, "exchange "),
+	}
+
+	market := strings.HasSuffix(orderType, "market")
+	switch {
+	case amountOrig > 0 && market:
+		ord.Side = BUY_MARKET
+	case amountOrig > 0:
+		ord.Side = BUY
+	case market:
+		ord.Side = SELL_MARKET
+	default:
+		ord.Side = SELL
+	}
+
+	switch {
+	case strings.HasPrefix(status, "EXECUTED"):
+		ord.Status = ORDER_FINISH
+		ord.FinishedTime = ToInt64(item[5])
+	case strings.Contains(status, "CANCELED"):
+		ord.Status = ORDER_CANCEL
+		ord.FinishedTime = ToInt64(item[5])
+	case strings.HasPrefix(status, "PARTIALLY FILLED"):
+		ord.Status = ORDER_PART_FINISH
+	default:
+		ord.Status = ORDER_UNFINISH
+	}
+	return ord
 }
 
 func (bws *BitfinexWs) tickerFromRaw(pair CurrencyPair, raw []interface{}) *Ticker {
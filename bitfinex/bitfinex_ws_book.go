@@ -0,0 +1,166 @@
+package bitfinex
+
+import (
+	"encoding/json"
+	"hash/crc32"
+	"sort"
+	"strings"
+	"time"
+
+	. "github.com/lucas7788/goex"
+)
+
+//参与checksum计算的档位数
+const checksumDepth = 25
+
+//一个价格档位(聚合book)或一个委托(R0), 保留原始数字字符串用于计算checksum
+type bookEntry struct {
+	id     string //R0为委托id, 聚合book为价格(借贷为利率:天数)
+	price  json.Number
+	amount json.Number
+	period int
+}
+
+/**
+ * 本地订单簿
+ * 交易: amount>0为买单, amount<0为卖单
+ * 借贷: amount>0为放贷(ask), amount<0为借款(bid)
+ */
+type wsBook struct {
+	raw     bool
+	funding bool
+	bids    map[string]bookEntry
+	asks    map[string]bookEntry
+}
+
+func newWsBook(event SubscribeEvent) *wsBook {
+	return &wsBook{
+		raw:     event.Precision == "R0",
+		funding: strings.HasPrefix(event.Symbol, "f"),
+		bids:    make(map[string]bookEntry, 25),
+		asks:    make(map[string]bookEntry, 25),
+	}
+}
+
+/**
+ * 交易 P0-P4: [PRICE, COUNT, AMOUNT]       R0: [ORDER_ID, PRICE, AMOUNT]
+ * 借贷 P0-P4: [RATE, PERIOD, COUNT, AMOUNT] R0: [OFFER_ID, PERIOD, RATE, AMOUNT]
+ * COUNT为0或R0的PRICE为0时删除
+ */
+func (b *wsBook) update(item []json.Number) {
+	var (
+		entry  bookEntry
+		remove bool
+	)
+	switch {
+	case b.funding && b.raw:
+		entry = bookEntry{id: item[0].String(), period: ToInt(item[1].String()), price: item[2], amount: item[3]}
+		remove = ToFloat64(item[2].String()) == 0
+	case b.funding:
+		entry = bookEntry{id: item[0].String() + ":" + item[1].String(), price: item[0], period: ToInt(item[1].String()), amount: item[3]}
+		remove = ToInt(item[2].String()) == 0
+	case b.raw:
+		entry = bookEntry{id: item[0].String(), price: item[1], amount: item[2]}
+		remove = ToFloat64(item[1].String()) == 0
+	default:
+		entry = bookEntry{id: item[0].String(), price: item[0], amount: item[2]}
+		remove = ToInt(item[1].String()) == 0
+	}
+
+	if remove {
+		delete(b.bids, entry.id)
+		delete(b.asks, entry.id)
+		return
+	}
+
+	amount := ToFloat64(entry.amount.String())
+	if (amount > 0) != b.funding {
+		b.bids[entry.id] = entry
+		delete(b.asks, entry.id)
+	} else {
+		b.asks[entry.id] = entry
+		delete(b.bids, entry.id)
+	}
+}
+
+func sortEntries(book map[string]bookEntry, desc bool) []bookEntry {
+	entries := make([]bookEntry, 0, len(book))
+	for _, e := range book {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		pi, pj := ToFloat64(entries[i].price.String()), ToFloat64(entries[j].price.String())
+		if pi == pj { //R0同价格的委托按id排序
+			return ToInt64(entries[i].id) < ToInt64(entries[j].id)
+		}
+		if desc {
+			return pi > pj
+		}
+		return pi < pj
+	})
+	return entries
+}
+
+//bids按价格降序, asks按价格升序
+func (b *wsBook) sorted() (bids, asks []bookEntry) {
+	return sortEntries(b.bids, true), sortEntries(b.asks, false)
+}
+
+/**
+ * crc32(bid0:ask0:bid1:ask1...), 取前25档
+ * 聚合book为price:amount, R0为id:amount
+ */
+func (b *wsBook) checksum() int32 {
+	bids, asks := b.sorted()
+	var parts []string
+	entryString := func(e bookEntry) string {
+		if b.raw {
+			return e.id + ":" + e.amount.String()
+		}
+		return e.price.String() + ":" + e.amount.String()
+	}
+	for i := 0; i < checksumDepth; i++ {
+		if i < len(bids) {
+			parts = append(parts, entryString(bids[i]))
+		}
+		if i < len(asks) {
+			parts = append(parts, entryString(asks[i]))
+		}
+	}
+	return int32(crc32.ChecksumIEEE([]byte(strings.Join(parts, ":"))))
+}
+
+//R0按价格聚合
+func (b *wsBook) depth(pair CurrencyPair) *Depth {
+	bids, asks := b.sorted()
+	dep := &Depth{Pair: pair, UTime: time.Now()}
+	for _, e := range bids {
+		dep.BidList = appendDepthRecord(dep.BidList, ToFloat64(e.price.String()), ToFloat64(e.amount.String()))
+	}
+	for _, e := range asks {
+		dep.AskList = appendDepthRecord(dep.AskList, ToFloat64(e.price.String()), -ToFloat64(e.amount.String()))
+	}
+	sort.Sort(sort.Reverse(dep.AskList))
+	return dep
+}
+
+func appendDepthRecord(records DepthRecords, price, amount float64) DepthRecords {
+	if n := len(records); n > 0 && records[n-1].Price == price {
+		records[n-1].Amount += amount
+		return records
+	}
+	return append(records, DepthRecord{Price: price, Amount: amount})
+}
+
+//借贷订单簿, Rate转换为年化百分比, 与v1的lendbook一致
+func (b *wsBook) lendBook() *LendBook {
+	bids, asks := b.sorted()
+	book := new(LendBook)
+	for _, e := range bids {
+		book.Bids = append(book.Bids, LendBookItem{Rate: ToFloat64(e.price.String()) * 365 * 100, Amount: -ToFloat64(e.amount.String()), Period: e.period})
+	}
+	for _, e := range asks {
+		book.Asks = append(book.Asks, LendBookItem{Rate: ToFloat64(e.price.String()) * 365 * 100, Amount: ToFloat64(e.amount.String()), Period: e.period})
+	}
+	return book
+}
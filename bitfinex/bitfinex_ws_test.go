@@ -1,11 +1,14 @@
 package bitfinex
 
 import (
+	"fmt"
+	"hash/crc32"
 	"log"
 	"testing"
 	"time"
 
 	"github.com/lucas7788/goex"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestNewBitfinexWs(t *testing.T) {
@@ -37,3 +40,68 @@ func TestNewBitfinexWs(t *testing.T) {
 	
 	time.Sleep(time.Minute)
 }
+
+func TestBitfinexWs_Book(t *testing.T) {
+	bws := NewWs()
+	var depth *goex.Depth
+	bws.DepthCallback(func(d *goex.Depth) {
+		depth = d
+	})
+
+	assert.NoError(t, bws.handle([]byte(`{"event":"subscribed","channel":"book","chanId":10,"symbol":"tBTCUSD","prec":"P0","freq":"F0","len":"25","pair":"BTCUSD"}`)))
+	assert.NoError(t, bws.handle([]byte(`[10,[[9000,2,1.5],[8999,1,0.5],[9001,1,-0.3],[9002,3,-2]]]`)))
+	assert.NoError(t, bws.handle([]byte(`[10,[8999,0,1]]`)))
+	assert.NoError(t, bws.handle([]byte(`[10,[9001,1,-0.4]]`)))
+	assert.NoError(t, bws.handle([]byte(`[10,"hb"]`)))
+
+	assert.Equal(t, goex.DepthRecords{{Price: 9000, Amount: 1.5}}, depth.BidList)
+	assert.Equal(t, goex.DepthRecords{{Price: 9002, Amount: 2}, {Price: 9001, Amount: 0.4}}, depth.AskList)
+
+	checksum := int32(crc32.ChecksumIEEE([]byte("9000:1.5:9001:-0.4:9002:-2")))
+	assert.NoError(t, bws.handle([]byte(fmt.Sprintf(`[10,"cs",%d]`, checksum))))
+}
+
+func TestBitfinexWs_FundingBook(t *testing.T) {
+	bws := NewWs()
+	var book *LendBook
+	bws.LendBookCallback(func(currency goex.Currency, b *LendBook) {
+		assert.Equal(t, "USD", currency.Symbol)
+		book = b
+	})
+
+	bws.handle([]byte(`{"event":"subscribed","channel":"book","chanId":11,"symbol":"fUSD","prec":"P0","freq":"F0","len":"25","currency":"USD"}`))
+	bws.handle([]byte(`[11,[[0.0002,2,1,-100],[0.0003,30,2,500]]]`))
+
+	assert.Len(t, book.Bids, 1)
+	assert.Equal(t, 100.0, book.Bids[0].Amount)
+	assert.Equal(t, 30, book.Asks[0].Period)
+	assert.InDelta(t, 10.95, book.Asks[0].Rate, 1e-9)
+}
+
+func TestBitfinexWs_AuthChannel(t *testing.T) {
+	bws := NewWs()
+	var orders []*goex.Order
+	bws.OrderCallback(func(order *goex.Order) {
+		orders = append(orders, order)
+	})
+	var accounts []*goex.SubAccount
+	bws.WalletCallback(func(walletType string, account *goex.SubAccount) {
+		assert.Equal(t, "exchange", walletType)
+		accounts = append(accounts, account)
+	})
+
+	assert.NoError(t, bws.handle([]byte(`{"event":"auth","status":"OK","chanId":0,"userId":1}`)))
+	assert.NoError(t, <-bws.authResp)
+	assert.NoError(t, bws.handle([]byte(`[0,"os",[[123,null,456,"tBTCUSD",1600000000000,1600000000000,0.5,1,"EXCHANGE LIMIT",null,null,null,0,"ACTIVE",null,null,9000,0,0,0,null,null,null,0,0,null,null,null,"API>BFX",null,null,null]]]`)))
+	assert.NoError(t, bws.handle([]byte(`[0,"oc",[123,null,456,"tBTCUSD",1600000000000,1600000001000,0,-1,"EXCHANGE MARKET",null,null,null,0,"EXECUTED @ 9000.0(-1.0)",null,null,9000,9000,0,0,null,null,null,0,0,null,null,null,"API>BFX",null,null,null]]`)))
+	assert.NoError(t, bws.handle([]byte(`[0,"wu",["exchange","BTC",2,0,1.5]]`)))
+
+	assert.Len(t, orders, 2)
+	assert.Equal(t, goex.BUY, orders[0].Side)
+	assert.Equal(t, goex.ORDER_UNFINISH, orders[0].Status)
+	assert.Equal(t, 0.5, orders[0].DealAmount)
+	assert.Equal(t, "BTC_USD", orders[0].Currency.String())
+	assert.Equal(t, goex.SELL_MARKET, orders[1].Side)
+	assert.Equal(t, goex.ORDER_FINISH, orders[1].Status)
+	assert.Equal(t, 0.5, accounts[0].ForzenAmount)
+}
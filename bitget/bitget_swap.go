@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 )
 
 const (
@@ -21,7 +20,7 @@ type BitgetSwap struct {
 	passphrase string
 	baseUrl    string
 	httpClient *http.Client
-	timeOffset int64
+	clock      *ClockSync
 }
 
 func NewSwap(config *APIConfig) *BitgetSwap {
@@ -35,7 +34,7 @@ func NewSwap(config *APIConfig) *BitgetSwap {
 		passphrase: config.ApiPassphrase,
 		httpClient: config.HttpClient,
 	}
-	bs.setTimeOffset()
+	bs.clock = SharedClockSync(bs.baseUrl+"/api/swap/v3/market/time", bs.GetServerTime)
 	return bs
 }
 
@@ -47,16 +46,6 @@ func (bs *BitgetSwap) GetExchangeName() string {
 	return BITGET_SWAP
 }
 
-func (bs *BitgetSwap) setTimeOffset() error {
-	stime, err := bs.GetServerTime()
-	if err != nil {
-		return err
-	}
-	lt := time.Now().UnixNano() / 1000000
-	bs.timeOffset = lt - stime
-	return nil
-}
-
 /**
  *获取交割预估价
  */
@@ -121,7 +110,7 @@ func (bs *BitgetSwap) GetFutureIndex(currencyPair CurrencyPair) (float64, error)
 }
 
 func (bs *BitgetSwap) doAuthRequest(method, uri string, param map[string]interface{}) ([]byte, error) {
-	timestamp := time.Now().Unix() * 1000
+	timestamp := bs.clock.NowMillis()
 	headers := make(map[string]string)
 	headers["Content-Type"] = "application/json"
 	headers["ACCESS-KEY"] = bs.accessKey
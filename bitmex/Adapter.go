@@ -27,7 +27,7 @@ func AdaptCurrencyPairToSymbol(pair CurrencyPair, contract string) string {
 func AdaptWsSymbol(symbol string) (pair CurrencyPair, contract string) {
 	symbol = strings.ToUpper(symbol)
 
-	if symbol == "XBTCUSD" {
+	if symbol == "XBTUSD" || symbol == "XBTCUSD" {
 		return BTC_USD, SWAP_CONTRACT
 	}
 
@@ -19,24 +19,31 @@ const (
 
 type bitmex struct {
 	*APIConfig
-}
-
-func (bm *bitmex) GetFutureOrderHistory(pair CurrencyPair, contractType string, optional ...OptionalParameter) ([]FutureOrder, error) {
-	panic("implement me")
+	clock *ClockSync
 }
 
 func New(config *APIConfig) *bitmex {
-	bm := &bitmex{config}
+	bm := &bitmex{APIConfig: config}
 	if bm.Endpoint == "" {
 		bm.Endpoint = baseUrl
 	}
 	if strings.HasSuffix(bm.Endpoint, "/") {
 		bm.Endpoint = bm.Endpoint[0 : len(bm.Endpoint)-1]
 	}
+	bm.clock = SharedClockSync(bm.Endpoint+"/api/v1", bm.GetServerTime)
 	Log.Debug("endpoint=", bm.Endpoint)
 	return bm
 }
 
+//GET /api/v1 返回的timestamp为服务器毫秒时间戳
+func (bm *bitmex) GetServerTime() (int64, error) {
+	respmap, err := HttpGet(bm.HttpClient, bm.Endpoint+"/api/v1")
+	if err != nil {
+		return 0, err
+	}
+	return ToInt64(respmap["timestamp"]), nil
+}
+
 func (bm *bitmex) generateSignature(httpMethod, uri, data, nonce string) string {
 	payload := strings.ToUpper(httpMethod) + uri + nonce + data
 	//println(payload)
@@ -47,7 +54,7 @@ func (bm *bitmex) generateSignature(httpMethod, uri, data, nonce string) string
 
 func (bm *bitmex) doAuthRequest(m, uri, param string, r interface{}) error {
 
-	nonce := time.Now().UTC().Unix() + 3600
+	nonce := bm.clock.Now().Unix() + 3600
 	sign := bm.generateSignature(m, uri, param, fmt.Sprint(nonce))
 
 	resp, err := NewHttpRequest(bm.HttpClient, m, bm.Endpoint+uri, param, map[string]string{
@@ -74,20 +81,7 @@ func (bm *bitmex) toJson(param interface{}) string {
 
 func (bm *bitmex) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureAccount, error) {
 	uri := "/api/v1/user/margin?currency=XBt"
-	var resp struct {
-		Currency           string  `json:"currency"`
-		RiskLimit          float64 `json:"riskLimit"`
-		Amount             float64 `json:"amount"`
-		MarginBalance      float64 `json:"marginBalance"`
-		WalletBalance      float64 `json:"walletBalance"`
-		AvailableMargin    float64 `json:"availableMargin"`
-		WithdrawableMargin float64 `json:"withdrawableMargin"`
-		InitMargin         float64 `json:"initMargin"`
-		UnrealisedProfit   float64 `json:"unrealisedProfit"`
-		UnrealisedPnl      float64 `json:"unrealisedPnl"`
-		RealisedPnl        float64 `json:"realisedPnl"`
-		RiskValue          float64 `json:"riskValue"`
-	}
+	var resp bitmexMargin
 
 	err := bm.doAuthRequest("GET", uri, "", &resp)
 	if err != nil {
@@ -96,15 +90,35 @@ func (bm *bitmex) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureAccoun
 
 	futureAcc := new(FutureAccount)
 	futureAcc.FutureSubAccounts = make(map[Currency]FutureSubAccount, 1)
-	futureAcc.FutureSubAccounts[BTC] = FutureSubAccount{
+	futureAcc.FutureSubAccounts[BTC] = adaptMargin(resp)
+
+	return futureAcc, nil
+}
+
+type bitmexMargin struct {
+	Currency           string  `json:"currency"`
+	RiskLimit          float64 `json:"riskLimit"`
+	Amount             float64 `json:"amount"`
+	MarginBalance      float64 `json:"marginBalance"`
+	WalletBalance      float64 `json:"walletBalance"`
+	AvailableMargin    float64 `json:"availableMargin"`
+	WithdrawableMargin float64 `json:"withdrawableMargin"`
+	InitMargin         float64 `json:"initMargin"`
+	UnrealisedProfit   float64 `json:"unrealisedProfit"`
+	UnrealisedPnl      float64 `json:"unrealisedPnl"`
+	RealisedPnl        float64 `json:"realisedPnl"`
+	RiskValue          float64 `json:"riskValue"`
+}
+
+//金额单位为聪(XBt)
+func adaptMargin(resp bitmexMargin) FutureSubAccount {
+	return FutureSubAccount{
 		Currency:      BTC,
 		AccountRights: resp.MarginBalance / 100000000,
 		KeepDeposit:   resp.InitMargin / 100000000,
 		ProfitUnreal:  resp.UnrealisedPnl / 100000000,
 		ProfitReal:    resp.RealisedPnl / 100000000,
 		RiskRate:      resp.RiskValue}
-
-	return futureAcc, nil
 }
 
 type BitmexOrder struct {
@@ -118,6 +132,7 @@ type BitmexOrder struct {
 	OrdType     string    `json:"ordType"`
 	Text        string    `json:"text"`
 	TimeInForce string    `json:"timeInForce,omitempty"`
+	ExecInst    string    `json:"execInst,omitempty"`
 	Side        string    `json:"side"`
 	OrdStatus   string    `json:"ordStatus"`
 	Timestamp   time.Time `json:"timestamp"`
@@ -128,7 +143,7 @@ func (bm *bitmex) PlaceFutureOrder(currencyPair CurrencyPair, contractType, pric
 	return fOrder.OrderID2, err
 }
 
-func (bm *bitmex) PlaceFutureOrder2(currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice int, leverRate float64) (*FutureOrder, error) {
+func (bm *bitmex) PlaceFutureOrder2(currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice int, leverRate float64, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
 	var createOrderParameter BitmexOrder
 
 	var resp struct {
@@ -146,6 +161,22 @@ func (bm *bitmex) PlaceFutureOrder2(currencyPair CurrencyPair, contractType, pri
 		createOrderParameter.Price = ToFloat64(price)
 	} else {
 		createOrderParameter.OrdType = "Market"
+		createOrderParameter.TimeInForce = ""
+	}
+
+	orderType := ORDER_FEATURE_ORDINARY
+	if len(opt) > 0 && matchPrice == 0 {
+		switch opt[0] {
+		case PostOnly:
+			orderType = ORDER_FEATURE_POST_ONLY
+			createOrderParameter.ExecInst = "ParticipateDoNotInitiate"
+		case Ioc:
+			orderType = ORDER_FEATURE_IOC
+			createOrderParameter.TimeInForce = "ImmediateOrCancel"
+		case Fok:
+			orderType = ORDER_FEATURE_FOK
+			createOrderParameter.TimeInForce = "FillOrKill"
+		}
 	}
 
 	switch openType {
@@ -165,6 +196,7 @@ func (bm *bitmex) PlaceFutureOrder2(currencyPair CurrencyPair, contractType, pri
 		Price:        ToFloat64(price),
 		Amount:       ToFloat64(amount),
 		OType:        openType,
+		OrderType:    orderType,
 		LeverRate:    leverRate,
 		ContractName: contractType,
 	}
@@ -181,7 +213,7 @@ func (bm *bitmex) PlaceFutureOrder2(currencyPair CurrencyPair, contractType, pri
 }
 
 func (bm *bitmex) LimitFuturesOrder(currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
-	return bm.PlaceFutureOrder2(currencyPair, contractType, price, amount, openType, 0, 10)
+	return bm.PlaceFutureOrder2(currencyPair, contractType, price, amount, openType, 0, 10, opt...)
 }
 
 func (bm *bitmex) MarketFuturesOrder(currencyPair CurrencyPair, contractType, amount string, openType int) (*FutureOrder, error) {
@@ -206,23 +238,49 @@ func (bm *bitmex) FutureCancelOrder(currencyPair CurrencyPair, contractType, ord
 	return true, nil
 }
 
+type bitmexPosition struct {
+	Symbol            string    `json:"symbol"`
+	CurrentQty        int       `json:"currentQty"`
+	OpeningQty        int       `json:"openingQty"`
+	AvgCostPrice      float64   `json:"avgCostPrice"`
+	AvgEntryPrice     float64   `json:"avgEntryPrice"`
+	UnrealisedPnl     float64   `json:"unrealisedPnl"`
+	UnrealisedPnlPcnt float64   `json:"unrealisedPnlPcnt"`
+	OpenOrderBuyQty   float64   `json:"openOrderBuyQty"`
+	OpenOrderSellQty  float64   `json:"OpenOrderSellQty"`
+	OpeningTimestamp  time.Time `json:"openingTimestamp"`
+	LiquidationPrice  float64   `json:"liquidationPrice"`
+	Leverage          float64   `json:"leverage"`
+}
+
+func adaptPosition(p bitmexPosition, currencyPair CurrencyPair, contractType string) FuturePosition {
+	pos := FuturePosition{}
+	pos.Symbol = currencyPair
+	pos.ContractType = contractType
+	pos.CreateDate = p.OpeningTimestamp.Unix()
+	pos.ForceLiquPrice = p.LiquidationPrice
+	pos.LeverRate = p.Leverage
+
+	if p.CurrentQty < 0 {
+		pos.SellAmount = float64(-p.CurrentQty)
+		pos.SellAvailable = pos.SellAmount - p.OpenOrderBuyQty
+		pos.SellPriceCost = p.AvgCostPrice
+		pos.SellPriceAvg = p.AvgEntryPrice
+		pos.SellProfitReal = p.UnrealisedPnlPcnt
+	} else {
+		pos.BuyAmount = float64(p.CurrentQty)
+		pos.BuyPriceCost = p.AvgCostPrice
+		pos.BuyPriceAvg = p.AvgEntryPrice
+		pos.BuyProfitReal = p.UnrealisedPnlPcnt
+		pos.BuyAvailable = pos.BuyAmount - p.OpenOrderSellQty
+	}
+	return pos
+}
+
 func (bm *bitmex) GetFuturePosition(currencyPair CurrencyPair, contractType string) ([]FuturePosition, error) {
 	var (
-		response []struct {
-			Symbol            string    `json:"symbol"`
-			CurrentQty        int       `json:"currentQty"`
-			OpeningQty        int       `json:"openingQty"`
-			AvgCostPrice      float64   `json:"avgCostPrice"`
-			AvgEntryPrice     float64   `json:"avgEntryPrice"`
-			UnrealisedPnl     float64   `json:"unrealisedPnl"`
-			UnrealisedPnlPcnt float64   `json:"unrealisedPnlPcnt"`
-			OpenOrderBuyQty   float64   `json:"openOrderBuyQty"`
-			OpenOrderSellQty  float64   `json:"OpenOrderSellQty"`
-			OpeningTimestamp  time.Time `json:"openingTimestamp"`
-			LiquidationPrice  float64   `json:"liquidationPrice"`
-			Leverage          float64   `json:"leverage"`
-		}
-		param = url.Values{}
+		response []bitmexPosition
+		param    = url.Values{}
 	)
 	param.Set("filter", fmt.Sprintf(`{"symbol":"%s"}`, bm.adaptCurrencyPairToSymbol(currencyPair, contractType)))
 	er := bm.doAuthRequest("GET", "/api/v1/position?"+param.Encode(), "", &response)
@@ -232,35 +290,116 @@ func (bm *bitmex) GetFuturePosition(currencyPair CurrencyPair, contractType stri
 
 	var postions []FuturePosition
 	for _, p := range response {
-		pos := FuturePosition{}
-		pos.Symbol = currencyPair
-		pos.ContractType = contractType
-		pos.CreateDate = p.OpeningTimestamp.Unix()
-		pos.ForceLiquPrice = p.LiquidationPrice
-		pos.LeverRate = p.Leverage
-
-		if p.CurrentQty < 0 {
-			pos.SellAmount = float64(-p.CurrentQty)
-			pos.SellAvailable = pos.SellAmount - p.OpenOrderBuyQty
-			pos.SellPriceCost = p.AvgCostPrice
-			pos.SellPriceAvg = p.AvgEntryPrice
-			pos.SellProfitReal = p.UnrealisedPnlPcnt
-		} else {
-			pos.BuyAmount = float64(p.CurrentQty)
-			pos.BuyPriceCost = p.AvgCostPrice
-			pos.BuyPriceAvg = p.AvgEntryPrice
-			pos.BuyProfitReal = p.UnrealisedPnlPcnt
-			pos.BuyAvailable = pos.BuyAmount - p.OpenOrderSellQty
-		}
-
-		postions = append(postions, pos)
+		postions = append(postions, adaptPosition(p, currencyPair, contractType))
 	}
 
 	return postions, nil
 }
 
+func (bm *bitmex) getOrders(currencyPair CurrencyPair, contractType string, query url.Values) ([]FutureOrder, error) {
+	var response []BitmexOrder
+	query.Set("symbol", bm.adaptCurrencyPairToSymbol(currencyPair, contractType))
+	err := bm.doAuthRequest("GET", "/api/v1/order?"+query.Encode(), "", &response)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]FutureOrder, 0, len(response))
+	for _, v := range response {
+		ord := bm.adaptOrder(v)
+		ord.Currency = currencyPair
+		ord.ContractName = contractType
+		orders = append(orders, ord)
+	}
+	return orders, nil
+}
+
 func (bm *bitmex) GetFutureOrders(orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
-	panic("no support")
+	query := url.Values{}
+	query.Set("filter", bm.toJson(map[string][]string{"orderID": orderIds}))
+	return bm.getOrders(currencyPair, contractType, query)
+}
+
+/**
+ * 历史订单(已成交/已撤销), 按时间倒序
+ * optional: count(默认100,最大500), start, startTime, endTime
+ */
+func (bm *bitmex) GetFutureOrderHistory(pair CurrencyPair, contractType string, optional ...OptionalParameter) ([]FutureOrder, error) {
+	query := url.Values{}
+	query.Set("filter", `{"open":false}`)
+	query.Set("reverse", "true")
+	query.Set("count", "100")
+	MergeOptionalParameter(&query, optional...)
+	return bm.getOrders(pair, contractType, query)
+}
+
+/**
+ * 修改订单价格和数量, price或amount为空时不修改
+ * orderId为goex开头时作为clOrdID
+ */
+func (bm *bitmex) AmendFutureOrder(currencyPair CurrencyPair, contractType, orderId, price, amount string) (*FutureOrder, error) {
+	var param struct {
+		OrderID     string  `json:"orderID,omitempty"`
+		OrigClOrdID string  `json:"origClOrdID,omitempty"`
+		OrderQty    int     `json:"orderQty,omitempty"`
+		Price       float64 `json:"price,omitempty"`
+	}
+	if strings.HasPrefix(orderId, "goex") {
+		param.OrigClOrdID = orderId
+	} else {
+		param.OrderID = orderId
+	}
+	param.OrderQty = ToInt(amount)
+	param.Price = ToFloat64(price)
+
+	var response BitmexOrder
+	err := bm.doAuthRequest("PUT", "/api/v1/order", bm.toJson(param), &response)
+	if err != nil {
+		return nil, err
+	}
+	ord := bm.adaptOrder(response)
+	ord.Currency = currencyPair
+	ord.ContractName = contractType
+	return &ord, nil
+}
+
+//批量撤单, 返回被撤销的订单
+func (bm *bitmex) FutureCancelOrders(currencyPair CurrencyPair, contractType string, orderIds []string) ([]FutureOrder, error) {
+	var param struct {
+		OrderID []string `json:"orderID,omitempty"`
+		ClOrdID []string `json:"clOrdID,omitempty"`
+	}
+	for _, id := range orderIds {
+		if strings.HasPrefix(id, "goex") {
+			param.ClOrdID = append(param.ClOrdID, id)
+		} else {
+			param.OrderID = append(param.OrderID, id)
+		}
+	}
+	return bm.cancelOrders("/api/v1/order", bm.toJson(param), currencyPair, contractType)
+}
+
+//撤销合约的全部委托
+func (bm *bitmex) FutureCancelAllOrders(currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	param := map[string]string{"symbol": bm.adaptCurrencyPairToSymbol(currencyPair, contractType)}
+	return bm.cancelOrders("/api/v1/order/all", bm.toJson(param), currencyPair, contractType)
+}
+
+func (bm *bitmex) cancelOrders(uri, param string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	var response []BitmexOrder
+	err := bm.doAuthRequest("DELETE", uri, param, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]FutureOrder, 0, len(response))
+	for _, v := range response {
+		ord := bm.adaptOrder(v)
+		ord.Currency = currencyPair
+		ord.ContractName = contractType
+		orders = append(orders, ord)
+	}
+	return orders, nil
 }
 
 func (bm *bitmex) GetFutureOrder(orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error) {
@@ -284,30 +423,33 @@ func (bm *bitmex) GetFutureOrder(orderId string, currencyPair CurrencyPair, cont
 }
 
 func (bm *bitmex) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
-	var response []BitmexOrder
-
 	query := url.Values{}
-	query.Set("symbol", bm.adaptCurrencyPairToSymbol(currencyPair, contractType))
 	query.Set("filter", "{\"open\":true}")
-	uri := "/api/v1/order?" + query.Encode()
-	errr := bm.doAuthRequest("GET", uri, "", &response)
-	if errr != nil {
-		return nil, errr
-	}
+	return bm.getOrders(currencyPair, contractType, query)
+}
 
-	var orders []FutureOrder
-	for _, v := range response {
-		ord := bm.adaptOrder(v)
-		ord.Currency = currencyPair
-		ord.ContractName = contractType
-		orders = append(orders, ord)
+func (bm *bitmex) getInstrument(symbol string) (map[string]interface{}, error) {
+	resp, err := HttpGet3(bm.HttpClient, fmt.Sprintf("%s/api/v1/instrument?symbol=%s", bm.Endpoint, url.QueryEscape(symbol)), nil)
+	if err != nil {
+		return nil, err
 	}
-
-	return orders, nil
+	if len(resp) == 0 {
+		return nil, errors.New("instrument not found: " + symbol)
+	}
+	instrument, isok := resp[0].(map[string]interface{})
+	if !isok {
+		return nil, errors.New(fmt.Sprintf("response format error [%s]", resp[0]))
+	}
+	return instrument, nil
 }
 
+//XBTUSD永续合约的taker费率
 func (bm *bitmex) GetFee() (float64, error) {
-	panic("no support")
+	instrument, err := bm.getInstrument("XBTUSD")
+	if err != nil {
+		return 0, err
+	}
+	return ToFloat64(instrument["takerFee"]), nil
 }
 
 func (bm *bitmex) GetFutureDepth(currencyPair CurrencyPair, contractType string, size int) (*Depth, error) {
@@ -395,20 +537,35 @@ func (bm *bitmex) GetExchangeName() string {
 	return BITMEX
 }
 
+//指数价格, 如.BXBT, .BETH
 func (bm *bitmex) GetFutureIndex(currencyPair CurrencyPair) (float64, error) {
-	panic("no support")
+	coin := currencyPair.CurrencyA.Symbol
+	if currencyPair.CurrencyA.Eq(BTC) {
+		coin = XBT.Symbol
+	}
+	instrument, err := bm.getInstrument(".B" + coin)
+	if err != nil {
+		return 0, err
+	}
+	return ToFloat64(instrument["lastPrice"]), nil
 }
 
 func (bm *bitmex) GetContractValue(currencyPair CurrencyPair) (float64, error) {
 	return 1.0, nil
 }
 
+//交割合约在到期月的最后一个周五 12:00 UTC 交割
 func (bm *bitmex) GetDeliveryTime() (int, int, int, int) {
-	panic("no support")
+	return 5, 12, 0, 0
 }
 
+//永续合约的预估结算价
 func (bm *bitmex) GetFutureEstimatedPrice(currencyPair CurrencyPair) (float64, error) {
-	panic("no support")
+	instrument, err := bm.getInstrument(bm.adaptCurrencyPairToSymbol(currencyPair, SWAP_CONTRACT))
+	if err != nil {
+		return 0, err
+	}
+	return ToFloat64(instrument["indicativeSettlePrice"]), nil
 }
 
 func (bm *bitmex) GetKlineRecords(contract_type string, currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]FutureKline, error) {
@@ -514,11 +671,27 @@ func (bm *bitmex) adaptCurrencyPairToSymbol(pair CurrencyPair, contract string)
 
 func (bm *bitmex) adaptOrder(o BitmexOrder) FutureOrder {
 	status := ORDER_UNFINISH
-	if o.OrdStatus == "Filled" {
+	switch o.OrdStatus {
+	case "Filled":
 		status = ORDER_FINISH
-	} else if o.OrdStatus == "Canceled" {
+	case "Canceled":
 		status = ORDER_CANCEL
+	case "PartiallyFilled":
+		status = ORDER_PART_FINISH
+	case "Rejected":
+		status = ORDER_REJECT
 	}
+
+	orderType := ORDER_FEATURE_ORDINARY
+	switch {
+	case strings.Contains(o.ExecInst, "ParticipateDoNotInitiate"):
+		orderType = ORDER_FEATURE_POST_ONLY
+	case o.TimeInForce == "ImmediateOrCancel":
+		orderType = ORDER_FEATURE_IOC
+	case o.TimeInForce == "FillOrKill":
+		orderType = ORDER_FEATURE_FOK
+	}
+
 	return FutureOrder{
 		OrderID2:   o.OrderID,
 		ClientOid:  o.ClOrdID,
@@ -527,5 +700,6 @@ func (bm *bitmex) adaptOrder(o BitmexOrder) FutureOrder {
 		DealAmount: float64(o.CumQty),
 		AvgPrice:   o.AvgPx,
 		Status:     status,
+		OrderType:  orderType,
 		OrderTime:  o.Timestamp.Unix()}
 }
@@ -1,11 +1,14 @@
 package bitmex
 
 import (
+	"fmt"
 	"github.com/lucas7788/goex"
 	"github.com/lucas7788/goex/internal/logger"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
@@ -87,3 +90,52 @@ func TestBitmex_GetFutureOrder(t *testing.T) {
 func TestBitmex_FutureCancelOrder(t *testing.T) {
 	t.Log(mex.FutureCancelOrder(goex.BTC_USD, goex.SWAP_CONTRACT, "goexfd6fd7694877448e8ae81a9cd7ecd89a"))
 }
+
+func TestBitmex_AmendAndCancelOrders(t *testing.T) {
+	drift := 10 * time.Minute
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1" {
+			fmt.Fprintf(w, `{"name":"BitMEX API","timestamp":%d}`, time.Now().Add(drift).UnixNano()/int64(time.Millisecond))
+			return
+		}
+		//api-expires使用同步后的服务器时间
+		expires := time.Unix(goex.ToInt64(r.Header.Get("api-expires")), 0)
+		assert.WithinDuration(t, time.Now().Add(drift+time.Hour), expires, 5*time.Second)
+
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "key", r.Header.Get("api-key"))
+		sign, _ := goex.GetParamHmacSHA256Sign("secret", r.Method+r.URL.RequestURI()+r.Header.Get("api-expires")+string(body))
+		assert.Equal(t, sign, r.Header.Get("api-signature"))
+
+		switch r.Method + " " + r.URL.Path {
+		case "PUT /api/v1/order":
+			assert.JSONEq(t, `{"orderID":"a1","orderQty":200,"price":9100.5}`, string(body))
+			w.Write([]byte(`{"orderID":"a1","symbol":"XBTUSD","orderQty":200,"price":9100.5,"ordStatus":"New","execInst":"ParticipateDoNotInitiate"}`))
+		case "DELETE /api/v1/order":
+			assert.JSONEq(t, `{"orderID":["a1"],"clOrdID":["goex123"]}`, string(body))
+			w.Write([]byte(`[{"orderID":"a1","ordStatus":"Canceled"},{"orderID":"a2","clOrdID":"goex123","ordStatus":"Canceled"}]`))
+		case "GET /api/v1/order":
+			assert.Equal(t, `{"open":false}`, r.URL.Query().Get("filter"))
+			assert.Equal(t, "10", r.URL.Query().Get("count"))
+			w.Write([]byte(`[{"orderID":"a3","symbol":"XBTUSD","orderQty":100,"cumQty":100,"avgPx":9000,"ordStatus":"Filled","timeInForce":"ImmediateOrCancel"}]`))
+		}
+	}))
+	defer srv.Close()
+
+	bm := New(&goex.APIConfig{HttpClient: http.DefaultClient, Endpoint: srv.URL, ApiKey: "key", ApiSecretKey: "secret"})
+
+	ord, err := bm.AmendFutureOrder(goex.BTC_USD, goex.SWAP_CONTRACT, "a1", "9100.5", "200")
+	assert.Nil(t, err)
+	assert.Equal(t, 9100.5, ord.Price)
+	assert.Equal(t, goex.ORDER_FEATURE_POST_ONLY, ord.OrderType)
+
+	orders, err := bm.FutureCancelOrders(goex.BTC_USD, goex.SWAP_CONTRACT, []string{"a1", "goex123"})
+	assert.Nil(t, err)
+	assert.Len(t, orders, 2)
+	assert.Equal(t, goex.ORDER_CANCEL, orders[1].Status)
+
+	orders, err = bm.GetFutureOrderHistory(goex.BTC_USD, goex.SWAP_CONTRACT, goex.OptionalParameter{}.Optional("count", 10))
+	assert.Nil(t, err)
+	assert.Equal(t, goex.ORDER_FINISH, orders[0].Status)
+	assert.Equal(t, goex.ORDER_FEATURE_IOC, orders[0].OrderType)
+}
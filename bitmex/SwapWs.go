@@ -2,23 +2,55 @@ package bitmex
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	. "github.com/lucas7788/goex"
 	"github.com/lucas7788/goex/internal/logger"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
+const wsLoginTimeout = 10 * time.Second
+
 type SubscribeOp struct {
 	Op   string   `json:"op"`
 	Args []string `json:"args"`
 }
 
 type wsMessage struct {
-	Table  string `json:"table"`
-	Action string `json:"action"`
-	Data   json.RawMessage
+	Table   string   `json:"table"`
+	Action  string   `json:"action"`
+	Keys    []string `json:"keys"`
+	Data    json.RawMessage
+	Success bool   `json:"success"`
+	Status  int    `json:"status"`
+	Error   string `json:"error"`
+	Info    string `json:"info"`
+	Request struct {
+		Op string `json:"op"`
+	} `json:"request"`
+}
+
+type tradeData struct {
+	Timestamp  string  `json:"timestamp"`
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"`
+	Size       float64 `json:"size"`
+	Price      float64 `json:"price"`
+	TrdMatchID string  `json:"trdMatchID"`
+}
+
+type executionData struct {
+	ExecID    string  `json:"execID"`
+	OrderID   string  `json:"orderID"`
+	Symbol    string  `json:"symbol"`
+	Side      string  `json:"side"`
+	LastQty   float64 `json:"lastQty"`
+	LastPx    float64 `json:"lastPx"`
+	ExecType  string  `json:"execType"`
+	Timestamp string  `json:"timestamp"`
 }
 
 type tickerData struct {
@@ -42,31 +74,73 @@ type depthData struct {
 	Timestamp string          `json:"timestamp"`
 }
 
+/**
+ * bitmex websocket, 实现FuturesWsApi
+ * 配置了api key时, 每次(重)连接成功后先通过authKeyExpires登录, 再订阅私有表(order, execution, position, margin)
+ * 私有表按partial/insert/update/delete在本地维护, 回调的是合并后的完整数据
+ */
 type SwapWs struct {
 	c         *WsConn
+	connErr   error
 	once      sync.Once
 	wsBuilder *WsBuilder
+	bm        *bitmex
+
+	loginOnce sync.Once
+	loginErr  error
+	loginResp chan error
 
-	depthCall  func(depth *Depth)
-	tickerCall func(ticker *FutureTicker)
+	depthCall     func(depth *Depth)
+	tickerCall    func(ticker *FutureTicker)
+	tradeCall     func(trade *Trade, contract string)
+	orderCall     func(order *FutureOrder)
+	executionCall func(trade *Trade, orderId string)
+	positionCall  func(positions []FuturePosition)
+	accountCall   func(account *FutureAccount)
 
 	tickerCacheMap map[string]FutureTicker
+
+	tableLock sync.Mutex
+	tables    map[string]*wsTable
 }
 
 func NewSwapWs() *SwapWs {
+	return NewSwapWsWithConfig(&APIConfig{})
+}
+
+//config.Endpoint为https://testnet.bitmex.com时连接测试网
+func NewSwapWsWithConfig(config *APIConfig) *SwapWs {
 	s := new(SwapWs)
-	s.wsBuilder = NewWsBuilder().DisableEnableCompression().WsUrl("wss://www.bitmex.com/realtime")
+	s.bm = New(config)
+	s.loginResp = make(chan error, 1)
+	wsUrl := strings.Replace(s.bm.Endpoint, "https://", "wss://", 1) + "/realtime"
+	s.wsBuilder = NewWsBuilder().DisableEnableCompression().WsUrl(wsUrl)
 	s.wsBuilder = s.wsBuilder.Heartbeat(func() []byte { return []byte("ping") }, 5*time.Second)
 	s.wsBuilder = s.wsBuilder.ProtoHandleFunc(s.handle).AutoReconnect()
+	if s.bm.ApiKey != "" {
+		s.wsBuilder = s.wsBuilder.ConnectSuccessAfterSendMessage(s.authMessage)
+	}
 	//s.c = wsBuilder.Build()
 	s.tickerCacheMap = make(map[string]FutureTicker, 10)
+	s.tables = make(map[string]*wsTable, 4)
 	return s
 }
 
-func (s *SwapWs) connect() {
+//签名: hex(hmac_sha256(secret, 'GET/realtime' + expires))
+func (s *SwapWs) authMessage() []byte {
+	expires := fmt.Sprint(s.bm.clock.Now().Unix() + 60)
+	data, _ := json.Marshal(map[string]interface{}{
+		"op":   "authKeyExpires",
+		"args": []interface{}{s.bm.ApiKey, ToInt64(expires), s.bm.generateSignature("GET", "/realtime", "", expires)},
+	})
+	return data
+}
+
+func (s *SwapWs) connect() error {
 	s.once.Do(func() {
-		s.c = s.wsBuilder.Build()
+		s.c, s.connErr = s.wsBuilder.Build()
 	})
+	return s.connErr
 }
 
 func (s *SwapWs) DepthCallback(f func(depth *Depth)) {
@@ -78,12 +152,31 @@ func (s *SwapWs) TickerCallback(f func(ticker *FutureTicker)) {
 }
 
 func (s *SwapWs) TradeCallback(f func(trade *Trade, contract string)) {
-	panic("implement me")
+	s.tradeCall = f
+}
+
+func (s *SwapWs) OrderCallback(f func(order *FutureOrder)) {
+	s.orderCall = f
+}
+
+//个人成交回调
+func (s *SwapWs) ExecutionCallback(f func(trade *Trade, orderId string)) {
+	s.executionCall = f
+}
+
+func (s *SwapWs) PositionCallback(f func(positions []FuturePosition)) {
+	s.positionCall = f
+}
+
+func (s *SwapWs) AccountCallback(f func(account *FutureAccount)) {
+	s.accountCall = f
 }
 
 func (s *SwapWs) SubscribeDepth(pair CurrencyPair, contractType string) error {
 	//{"op": "subscribe", "args": ["orderBook10:XBTUSD"]}
-	s.connect()
+	if err := s.connect(); err != nil {
+		return err
+	}
 
 	op := SubscribeOp{
 		Op: "subscribe",
@@ -95,7 +188,9 @@ func (s *SwapWs) SubscribeDepth(pair CurrencyPair, contractType string) error {
 }
 
 func (s *SwapWs) SubscribeTicker(pair CurrencyPair, contractType string) error {
-	s.connect()
+	if err := s.connect(); err != nil {
+		return err
+	}
 
 	return s.c.Subscribe(SubscribeOp{
 		Op: "subscribe",
@@ -106,7 +201,70 @@ func (s *SwapWs) SubscribeTicker(pair CurrencyPair, contractType string) error {
 }
 
 func (s *SwapWs) SubscribeTrade(pair CurrencyPair, contractType string) error {
-	panic("implement me")
+	if err := s.connect(); err != nil {
+		return err
+	}
+
+	return s.c.Subscribe(SubscribeOp{
+		Op: "subscribe",
+		Args: []string{
+			"trade:" + AdaptCurrencyPairToSymbol(pair, contractType),
+		},
+	})
+}
+
+//等待登录结果, 需要配置api key
+func (s *SwapWs) Login() error {
+	if s.bm.ApiKey == "" {
+		return errors.New("api key is required")
+	}
+	if err := s.connect(); err != nil {
+		return err
+	}
+	s.loginOnce.Do(func() {
+		select {
+		case s.loginErr = <-s.loginResp:
+		case <-time.After(wsLoginTimeout):
+			s.loginErr = errors.New("login timeout")
+		}
+	})
+	return s.loginErr
+}
+
+func (s *SwapWs) subscribePrivate(args ...string) error {
+	if err := s.Login(); err != nil {
+		return err
+	}
+	return s.c.Subscribe(SubscribeOp{Op: "subscribe", Args: args})
+}
+
+func (s *SwapWs) SubscribeOrder(pair CurrencyPair, contractType string) error {
+	return s.subscribePrivate("order:" + AdaptCurrencyPairToSymbol(pair, contractType))
+}
+
+func (s *SwapWs) SubscribeExecution(pair CurrencyPair, contractType string) error {
+	return s.subscribePrivate("execution:" + AdaptCurrencyPairToSymbol(pair, contractType))
+}
+
+func (s *SwapWs) SubscribePosition(pair CurrencyPair, contractType string) error {
+	return s.subscribePrivate("position:" + AdaptCurrencyPairToSymbol(pair, contractType))
+}
+
+func (s *SwapWs) SubscribeAccount() error {
+	return s.subscribePrivate("margin")
+}
+
+func (s *SwapWs) Close() {
+	if s.c != nil {
+		s.c.CloseWs()
+	}
+}
+
+func (s *SwapWs) notifyLogin(err error) {
+	select {
+	case s.loginResp <- err:
+	default:
+	}
 }
 
 func (s *SwapWs) handle(data []byte) error {
@@ -121,7 +279,35 @@ func (s *SwapWs) handle(data []byte) error {
 		return err
 	}
 
+	if msg.Table == "" {
+		return s.handleResponse(msg, data)
+	}
+
 	switch msg.Table {
+	case "order", "execution", "position", "margin":
+		return s.handleTable(msg)
+	case "trade":
+		if msg.Action != "insert" || s.tradeCall == nil {
+			return nil
+		}
+		var trades []tradeData
+		err = json.Unmarshal(msg.Data, &trades)
+		if err != nil {
+			logger.Errorf("trade data unmarshal error , data: %s", string(msg.Data))
+			return err
+		}
+		for _, t := range trades {
+			pair, contract := AdaptWsSymbol(t.Symbol)
+			date, _ := time.Parse(time.RFC3339, t.Timestamp)
+			s.tradeCall(&Trade{
+				Tid:    date.UnixNano() / int64(time.Millisecond),
+				Type:   AdaptTradeSide(t.Side),
+				Amount: t.Size,
+				Price:  t.Price,
+				Date:   date.UnixNano() / int64(time.Millisecond),
+				Pair:   pair,
+			}, contract)
+		}
 	case "orderBook10":
 		if msg.Action != "update" {
 			return nil
@@ -162,7 +348,9 @@ func (s *SwapWs) handle(data []byte) error {
 
 		sort.Sort(sort.Reverse(dep.AskList))
 
-		s.depthCall(&dep)
+		if s.depthCall != nil {
+			s.depthCall(&dep)
+		}
 	case "instrument":
 		var tickerData []tickerData
 
@@ -187,7 +375,9 @@ func (s *SwapWs) handle(data []byte) error {
 			ticker.Date = uint64(tickerTime.Unix())
 
 			s.tickerCacheMap[tickerData[0].Symbol] = ticker
-			s.tickerCall(&ticker)
+			if s.tickerCall != nil {
+				s.tickerCall(&ticker)
+			}
 		}
 
 		if msg.Action == "update" {
@@ -220,7 +410,9 @@ func (s *SwapWs) handle(data []byte) error {
 			}
 
 			s.tickerCacheMap[tickerData[0].Symbol] = ticker
-			s.tickerCall(&ticker)
+			if s.tickerCall != nil {
+				s.tickerCall(&ticker)
+			}
 		}
 	default:
 		logger.Warnf("unknown ws message: %s", string(data))
@@ -228,3 +420,112 @@ func (s *SwapWs) handle(data []byte) error {
 
 	return nil
 }
+
+//订阅/登录的响应
+func (s *SwapWs) handleResponse(msg wsMessage, data []byte) error {
+	if msg.Request.Op == "authKeyExpires" {
+		if msg.Success {
+			logger.Info("[bitmex ws] login success")
+			s.notifyLogin(nil)
+			return nil
+		}
+		err := fmt.Errorf("login fail, status: %d, error: %s", msg.Status, msg.Error)
+		s.notifyLogin(err)
+		return err
+	}
+	if msg.Error != "" {
+		logger.Errorf("[bitmex ws] %s", string(data))
+		return fmt.Errorf("status: %d, error: %s", msg.Status, msg.Error)
+	}
+	logger.Info("[bitmex ws] ", string(data))
+	return nil
+}
+
+func (s *SwapWs) handleTable(msg wsMessage) error {
+	s.tableLock.Lock()
+	defer s.tableLock.Unlock()
+
+	table, ok := s.tables[msg.Table]
+	if !ok {
+		table = newWsTable(msg.Table)
+		s.tables[msg.Table] = table
+	}
+
+	rows, err := table.apply(msg.Action, msg.Keys, msg.Data)
+	if err != nil {
+		logger.Errorf("[bitmex ws] %s data unmarshal error: %s", msg.Table, err)
+		return err
+	}
+
+	switch msg.Table {
+	case "order":
+		for _, row := range rows {
+			var o BitmexOrder
+			if err = decodeRow(row, &o); err != nil {
+				return err
+			}
+			ord := s.bm.adaptOrder(o)
+			ord.Currency, ord.ContractName = AdaptWsSymbol(o.Symbol)
+			if s.orderCall != nil {
+				s.orderCall(&ord)
+			}
+		}
+		//已完成的订单不再保留
+		table.remove(func(row map[string]interface{}) bool {
+			status := fmt.Sprint(row["ordStatus"])
+			return status == "Filled" || status == "Canceled" || status == "Rejected"
+		})
+	case "execution":
+		if msg.Action == "partial" || s.executionCall == nil { //partial为历史成交
+			return nil
+		}
+		for _, row := range rows {
+			var exec executionData
+			if err = decodeRow(row, &exec); err != nil {
+				return err
+			}
+			if exec.ExecType != "Trade" {
+				continue
+			}
+			pair, _ := AdaptWsSymbol(exec.Symbol)
+			date, _ := time.Parse(time.RFC3339, exec.Timestamp)
+			s.executionCall(&Trade{
+				Tid:    date.UnixNano() / int64(time.Millisecond),
+				Type:   AdaptTradeSide(exec.Side),
+				Amount: exec.LastQty,
+				Price:  exec.LastPx,
+				Date:   date.UnixNano() / int64(time.Millisecond),
+				Pair:   pair,
+			}, exec.OrderID)
+		}
+	case "position":
+		if s.positionCall == nil || len(rows) == 0 {
+			return nil
+		}
+		positions := make([]FuturePosition, 0, len(rows))
+		for _, row := range rows {
+			var p bitmexPosition
+			if err = decodeRow(row, &p); err != nil {
+				return err
+			}
+			pair, contract := AdaptWsSymbol(p.Symbol)
+			positions = append(positions, adaptPosition(p, pair, contract))
+		}
+		s.positionCall(positions)
+	case "margin":
+		if s.accountCall == nil {
+			return nil
+		}
+		for _, row := range rows {
+			var m bitmexMargin
+			if err = decodeRow(row, &m); err != nil {
+				return err
+			}
+			if m.Currency != "XBt" {
+				continue
+			}
+			s.accountCall(&FutureAccount{FutureSubAccounts: map[Currency]FutureSubAccount{BTC: adaptMargin(m)}})
+		}
+	}
+	return nil
+}
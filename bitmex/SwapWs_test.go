@@ -1,7 +1,9 @@
 package bitmex
 
 import (
+	"fmt"
 	"github.com/lucas7788/goex"
+	"github.com/stretchr/testify/assert"
 	"os"
 	"testing"
 	"time"
@@ -21,3 +23,68 @@ func TestNewSwapWs(t *testing.T) {
 
 	time.Sleep(5 * time.Minute)
 }
+
+func TestSwapWs_PrivateTables(t *testing.T) {
+	ws := NewSwapWsWithConfig(&goex.APIConfig{Endpoint: "https://testnet.bitmex.com", ApiKey: "key", ApiSecretKey: "secret"})
+
+	var orders []goex.FutureOrder
+	ws.OrderCallback(func(order *goex.FutureOrder) {
+		orders = append(orders, *order)
+	})
+	var positions []goex.FuturePosition
+	ws.PositionCallback(func(pos []goex.FuturePosition) {
+		positions = pos
+	})
+
+	assert.Nil(t, ws.handle([]byte(`{"success":true,"request":{"op":"authKeyExpires","args":["key",1600000000,"sign"]}}`)))
+	assert.Nil(t, <-ws.loginResp)
+
+	assert.Nil(t, ws.handle([]byte(`{"table":"order","action":"partial","keys":["orderID"],"data":[{"orderID":"a1","symbol":"XBTUSD","side":"Buy","orderQty":100,"price":9000,"ordStatus":"New","cumQty":0,"timestamp":"2020-10-26T06:52:43.150Z"}]}`)))
+	assert.Nil(t, ws.handle([]byte(`{"table":"order","action":"update","data":[{"orderID":"a1","symbol":"XBTUSD","cumQty":40,"avgPx":9000,"ordStatus":"PartiallyFilled"}]}`)))
+	assert.Len(t, orders, 2)
+	assert.Equal(t, goex.ORDER_PART_FINISH, orders[1].Status)
+	assert.Equal(t, 100.0, orders[1].Amount)
+	assert.Equal(t, 40.0, orders[1].DealAmount)
+	assert.Equal(t, goex.BTC_USD, orders[1].Currency)
+	assert.Equal(t, goex.SWAP_CONTRACT, orders[1].ContractName)
+
+	//完成的订单从本地表中删除
+	assert.Nil(t, ws.handle([]byte(`{"table":"order","action":"update","data":[{"orderID":"a1","symbol":"XBTUSD","cumQty":100,"ordStatus":"Filled"}]}`)))
+	assert.Equal(t, goex.ORDER_FINISH, orders[2].Status)
+	assert.Empty(t, ws.tables["order"].rows)
+
+	assert.Nil(t, ws.handle([]byte(`{"table":"position","action":"partial","keys":["account","symbol","currency"],"data":[{"account":1,"symbol":"XBTUSD","currency":"XBt","currentQty":0,"leverage":10}]}`)))
+	assert.Nil(t, ws.handle([]byte(`{"table":"position","action":"update","data":[{"account":1,"symbol":"XBTUSD","currency":"XBt","currentQty":-100,"avgEntryPrice":9000,"liquidationPrice":9800}]}`)))
+	assert.Len(t, positions, 1)
+	assert.Equal(t, 100.0, positions[0].SellAmount)
+	assert.Equal(t, 9000.0, positions[0].SellPriceAvg)
+	assert.Equal(t, 9800.0, positions[0].ForceLiquPrice)
+	assert.Equal(t, 10.0, positions[0].LeverRate)
+}
+
+func TestWsTable_Apply(t *testing.T) {
+	//partial之前的增量数据丢弃
+	order := newWsTable("order")
+	rows, err := order.apply("update", nil, []byte(`[{"orderID":"a1","ordStatus":"New"}]`))
+	assert.Nil(t, err)
+	assert.Empty(t, rows)
+	assert.Empty(t, order.rows)
+
+	_, err = order.apply("partial", []string{"orderID"}, []byte(`[]`))
+	assert.Nil(t, err)
+	for i := 0; i < maxTableLen+10; i++ {
+		order.apply("insert", nil, []byte(fmt.Sprintf(`[{"orderID":"o%d","ordStatus":"New"}]`, i)))
+	}
+	//按主键维护的表不截断
+	assert.Len(t, order.rows, maxTableLen+10)
+	rows, _ = order.apply("update", nil, []byte(`[{"orderID":"o0","ordStatus":"Canceled"}]`))
+	assert.Equal(t, "Canceled", rows[0]["ordStatus"])
+
+	execution := newWsTable("execution")
+	execution.apply("partial", []string{"execID"}, []byte(`[]`))
+	for i := 0; i < maxTableLen+10; i++ {
+		execution.apply("insert", nil, []byte(fmt.Sprintf(`[{"execID":"e%d"}]`, i)))
+	}
+	assert.Len(t, execution.rows, maxTableLen)
+	assert.Equal(t, "e10", execution.rows[0]["execID"])
+}
@@ -0,0 +1,115 @@
+package bitmex
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//只有insert的表(execution, trade)最多保留的行数
+const maxTableLen = 200
+
+//只有insert的表, 超过maxTableLen时丢弃最早的行; order, position等按主键维护的表不截断
+var insertOnlyTables = map[string]bool{"execution": true, "trade": true}
+
+/**
+ * 本地维护的ws数据表
+ * partial: 全量替换并记录主键; insert: 追加; update: 按主键合并字段; delete: 按主键删除
+ * 收到partial之前没有主键, 之前的增量数据已经包含在partial中, 直接丢弃
+ */
+type wsTable struct {
+	name    string
+	partial bool
+	keys    []string
+	rows    []map[string]interface{}
+}
+
+func newWsTable(name string) *wsTable {
+	return &wsTable{name: name}
+}
+
+func (t *wsTable) key(row map[string]interface{}) string {
+	var key []string
+	for _, k := range t.keys {
+		key = append(key, fmt.Sprint(row[k]))
+	}
+	return strings.Join(key, "|")
+}
+
+func (t *wsTable) find(row map[string]interface{}) int {
+	key := t.key(row)
+	for i, r := range t.rows {
+		if t.key(r) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+//返回本次变化后的完整行, delete时返回被删除的行
+func (t *wsTable) apply(action string, keys []string, data json.RawMessage) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+
+	if action != "partial" && !t.partial {
+		return nil, nil
+	}
+
+	var changed []map[string]interface{}
+	switch action {
+	case "partial":
+		t.partial = true
+		t.keys = keys
+		t.rows = rows
+		changed = rows
+	case "insert":
+		t.rows = append(t.rows, rows...)
+		if insertOnlyTables[t.name] && len(t.rows) > maxTableLen {
+			t.rows = t.rows[len(t.rows)-maxTableLen:]
+		}
+		changed = rows
+	case "update":
+		for _, row := range rows {
+			i := t.find(row)
+			if i < 0 { //partial中没有的行, 直接作为新行
+				t.rows = append(t.rows, row)
+				changed = append(changed, row)
+				continue
+			}
+			for k, v := range row {
+				t.rows[i][k] = v
+			}
+			changed = append(changed, t.rows[i])
+		}
+	case "delete":
+		for _, row := range rows {
+			if i := t.find(row); i >= 0 {
+				changed = append(changed, t.rows[i])
+				t.rows = append(t.rows[:i], t.rows[i+1:]...)
+			}
+		}
+	}
+	return changed, nil
+}
+
+//删除满足条件的行, 如已完成的订单
+func (t *wsTable) remove(fn func(row map[string]interface{}) bool) {
+	rows := t.rows[:0]
+	for _, r := range t.rows {
+		if !fn(r) {
+			rows = append(rows, r)
+		}
+	}
+	t.rows = rows
+}
+
+//将行转换为结构体
+func decodeRow(row map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
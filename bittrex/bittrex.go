@@ -1,103 +1,434 @@
 package bittrex
 
 import (
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
-	. "github.com/lucas7788/goex"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	. "github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/logger"
 )
 
+//bittrex v3 api, https://bittrex.github.io/api/v3
+
 type Bittrex struct {
 	client *http.Client
 	baseUrl,
 	accesskey,
 	secretkey string
+	clock *ClockSync
 }
 
 func New(client *http.Client, accesskey, secretkey string) *Bittrex {
-	return &Bittrex{client: client, accesskey: accesskey, secretkey: secretkey, baseUrl: "https://bittrex.com/api/v1.1"}
+	return NewWithConfig(&APIConfig{HttpClient: client, ApiKey: accesskey, ApiSecretKey: secretkey})
+}
+
+func NewWithConfig(config *APIConfig) *Bittrex {
+	if config.Endpoint == "" {
+		config.Endpoint = "https://api.bittrex.com/v3"
+	}
+	bx := &Bittrex{
+		client:    config.HttpClient,
+		baseUrl:   config.Endpoint,
+		accesskey: config.ApiKey,
+		secretkey: config.ApiSecretKey,
+	}
+	bx.clock = SharedClockSync(bx.baseUrl+"/ping", bx.GetServerTime)
+	return bx
+}
+
+// GetServerTime 返回服务器毫秒时间戳
+func (bx *Bittrex) GetServerTime() (int64, error) {
+	var response struct {
+		ServerTime int64 `json:"serverTime"`
+	}
+	err := bx.doRequest("GET", "/ping", nil, &response, false)
+	return response.ServerTime, err
+}
+
+/**
+ * 签名: hex(hmac_sha512(secret, timestamp + uri + method + contentHash))
+ * contentHash为请求body的sha512, body为空时也需要计算
+ */
+func (bx *Bittrex) sign(timestamp, uri, method, body string) (contentHash, signature string, err error) {
+	hash := sha512.Sum512([]byte(body))
+	contentHash = hex.EncodeToString(hash[:])
+	signature, err = GetParamHmacSHA512Sign(bx.secretkey, timestamp+uri+method+contentHash)
+	return
+}
+
+//下单接口成功时返回201, 所以不使用NewHttpRequest
+func (bx *Bittrex) doRequest(method, path string, param interface{}, result interface{}, authenticated bool) error {
+	body := ""
+	if param != nil {
+		data, err := json.Marshal(param)
+		if err != nil {
+			return err
+		}
+		body = string(data)
+	}
+
+	uri := bx.baseUrl + path
+	req, err := http.NewRequest(method, uri, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authenticated {
+		timestamp := fmt.Sprint(bx.clock.Now().UnixNano() / int64(time.Millisecond))
+		contentHash, signature, err := bx.sign(timestamp, uri, method, body)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Api-Key", bx.accesskey)
+		req.Header.Set("Api-Timestamp", timestamp)
+		req.Header.Set("Api-Content-Hash", contentHash)
+		req.Header.Set("Api-Signature", signature)
+	}
+
+	logger.Debugf("[%s] request url: %s", method, uri)
+	resp, err := bx.client.Do(req)
+	if err != nil {
+		errCode := HTTP_ERR_CODE
+		errCode.OriginErrMsg = err.Error()
+		return errCode
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	logger.Debugf("response body: %s", string(data))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResponse struct {
+			Code   string `json:"code"`
+			Detail string `json:"detail"`
+		}
+		json.Unmarshal(data, &errResponse)
+		if errResponse.Code == "" {
+			return fmt.Errorf("HttpStatusCode:%d ,Desc:%s", resp.StatusCode, string(data))
+		}
+		return ApiError{ErrCode: errResponse.Code, ErrMsg: errResponse.Detail}
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
+
+//BTC_USDT -> BTC-USDT
+func (bx *Bittrex) marketSymbol(pair CurrencyPair) string {
+	return pair.ToUpper().ToSymbol("-")
+}
+
+type orderParam struct {
+	MarketSymbol  string `json:"marketSymbol"`
+	Direction     string `json:"direction"`
+	Type          string `json:"type"`
+	Quantity      string `json:"quantity,omitempty"`
+	Ceiling       string `json:"ceiling,omitempty"` //市价买单的买入金额
+	Limit         string `json:"limit,omitempty"`
+	TimeInForce   string `json:"timeInForce"`
+	ClientOrderId string `json:"clientOrderId,omitempty"`
+}
+
+type orderResponse struct {
+	Id            string `json:"id"`
+	MarketSymbol  string `json:"marketSymbol"`
+	Direction     string `json:"direction"`
+	Type          string `json:"type"`
+	Quantity      string `json:"quantity"`
+	Limit         string `json:"limit"`
+	Ceiling       string `json:"ceiling"`
+	TimeInForce   string `json:"timeInForce"`
+	ClientOrderId string `json:"clientOrderId"`
+	FillQuantity  string `json:"fillQuantity"`
+	Commission    string `json:"commission"`
+	Proceeds      string `json:"proceeds"`
+	Status        string `json:"status"`
+	CreatedAt     string `json:"createdAt"`
+	ClosedAt      string `json:"closedAt"`
+}
+
+func (bx *Bittrex) placeOrder(param orderParam, pair CurrencyPair) (*Order, error) {
+	param.MarketSymbol = bx.marketSymbol(pair)
+	param.ClientOrderId = uuid.New().String()
+
+	var response orderResponse
+	err := bx.doRequest("POST", "/orders", param, &response, true)
+	if err != nil {
+		return nil, err
+	}
+	ord := bx.adaptOrder(response)
+	ord.Currency = pair
+	return &ord, nil
+}
+
+func (bx *Bittrex) limitOrder(direction, amount, price string, pair CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	param := orderParam{Direction: direction, Type: "LIMIT", Quantity: amount, Limit: price, TimeInForce: "GOOD_TIL_CANCELLED"}
+	if len(opt) > 0 {
+		switch opt[0] {
+		case PostOnly:
+			param.TimeInForce = "POST_ONLY_GOOD_TIL_CANCELLED"
+		case Ioc:
+			param.TimeInForce = "IMMEDIATE_OR_CANCEL"
+		case Fok:
+			param.TimeInForce = "FILL_OR_KILL"
+		}
+	}
+	return bx.placeOrder(param, pair)
 }
 
 func (bx *Bittrex) LimitBuy(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
-	panic("not implement")
+	return bx.limitOrder("BUY", amount, price, currency, opt...)
 }
+
 func (bx *Bittrex) LimitSell(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
-	panic("not implement")
+	return bx.limitOrder("SELL", amount, price, currency, opt...)
 }
+
+//price不为空时按金额买入(CEILING_MARKET), 否则按数量amount买入
 func (bx *Bittrex) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	param := orderParam{Direction: "BUY", Type: "MARKET", Quantity: amount, TimeInForce: "IMMEDIATE_OR_CANCEL"}
+	if ToFloat64(price) > 0 {
+		param.Type = "CEILING_MARKET"
+		param.Quantity = ""
+		param.Ceiling = price
+	}
+	return bx.placeOrder(param, currency)
 }
+
 func (bx *Bittrex) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return bx.placeOrder(orderParam{Direction: "SELL", Type: "MARKET", Quantity: amount, TimeInForce: "IMMEDIATE_OR_CANCEL"}, currency)
 }
+
 func (bx *Bittrex) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	panic("not implement")
+	var response orderResponse
+	err := bx.doRequest("DELETE", "/orders/"+orderId, nil, &response, true)
+	if err != nil {
+		return false, err
+	}
+	return response.Id == orderId, nil
 }
+
 func (bx *Bittrex) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	var response orderResponse
+	err := bx.doRequest("GET", "/orders/"+orderId, nil, &response, true)
+	if err != nil {
+		return nil, err
+	}
+	ord := bx.adaptOrder(response)
+	ord.Currency = currency
+	return &ord, nil
 }
+
+func (bx *Bittrex) getOrders(path string, currency CurrencyPair, params url.Values) ([]Order, error) {
+	params.Set("marketSymbol", bx.marketSymbol(currency))
+	var response []orderResponse
+	err := bx.doRequest("GET", path+"?"+params.Encode(), nil, &response, true)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]Order, 0, len(response))
+	for _, r := range response {
+		ord := bx.adaptOrder(r)
+		ord.Currency = currency
+		orders = append(orders, ord)
+	}
+	return orders, nil
+}
+
 func (bx *Bittrex) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implement")
+	return bx.getOrders("/orders/open", currency, url.Values{})
 }
+
+//optional: pageSize, nextPageToken, previousPageToken, startDate, endDate
 func (bx *Bittrex) GetOrderHistorys(currency CurrencyPair, optional ...OptionalParameter) ([]Order, error) {
-	panic("not implement")
+	params := url.Values{}
+	MergeOptionalParameter(&params, optional...)
+	return bx.getOrders("/orders/closed", currency, params)
 }
+
+func (bx *Bittrex) adaptOrder(r orderResponse) Order {
+	ord := Order{
+		Cid:          r.ClientOrderId,
+		OrderID2:     r.Id,
+		Price:        ToFloat64(r.Limit),
+		Amount:       ToFloat64(r.Quantity),
+		DealAmount:   ToFloat64(r.FillQuantity),
+		Fee:          ToFloat64(r.Commission),
+		Type:         strings.ToLower(r.Type),
+		OrderTime:    int(parseTime(r.CreatedAt)),
+		FinishedTime: parseTime(r.ClosedAt),
+	}
+	if ord.DealAmount > 0 {
+		ord.AvgPrice = ToFloat64(r.Proceeds) / ord.DealAmount
+	}
+	if r.Type == "CEILING_MARKET" || r.Type == "CEILING_LIMIT" {
+		ord.Price = ToFloat64(r.Ceiling)
+	}
+
+	market := strings.HasSuffix(r.Type, "MARKET")
+	switch r.Direction {
+	case "BUY":
+		ord.Side = BUY
+		if market {
+			ord.Side = BUY_MARKET
+		}
+	case "SELL":
+		ord.Side = SELL
+		if market {
+			ord.Side = SELL_MARKET
+		}
+	}
+
+	switch r.TimeInForce {
+	case "POST_ONLY_GOOD_TIL_CANCELLED":
+		ord.OrderType = ORDER_FEATURE_POST_ONLY
+	case "IMMEDIATE_OR_CANCEL":
+		ord.OrderType = ORDER_FEATURE_IOC
+	case "FILL_OR_KILL":
+		ord.OrderType = ORDER_FEATURE_FOK
+	}
+
+	//v3只有OPEN和CLOSED两种状态
+	switch {
+	case r.Status == "CLOSED" && ord.DealAmount >= ord.Amount && ord.Amount > 0:
+		ord.Status = ORDER_FINISH
+	case r.Status == "CLOSED" && ord.Amount == 0 && ord.DealAmount > 0: //按金额买入
+		ord.Status = ORDER_FINISH
+	case r.Status == "CLOSED":
+		ord.Status = ORDER_CANCEL
+	case ord.DealAmount > 0:
+		ord.Status = ORDER_PART_FINISH
+	default:
+		ord.Status = ORDER_UNFINISH
+	}
+	return ord
+}
+
 func (bx *Bittrex) GetAccount() (*Account, error) {
-	panic("not implement")
+	var response []struct {
+		CurrencySymbol string `json:"currencySymbol"`
+		Total          string `json:"total"`
+		Available      string `json:"available"`
+	}
+	err := bx.doRequest("GET", "/balances", nil, &response, true)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &Account{Exchange: BITTREX, SubAccounts: make(map[Currency]SubAccount, len(response))}
+	for _, r := range response {
+		total, available := ToFloat64(r.Total), ToFloat64(r.Available)
+		if total == 0 {
+			continue
+		}
+		currency := NewCurrency(r.CurrencySymbol, "")
+		acc.SubAccounts[currency] = SubAccount{
+			Currency:     currency,
+			Amount:       available,
+			ForzenAmount: total - available,
+		}
+	}
+	return acc, nil
 }
 
-func (bx *Bittrex) GetTicker(currency CurrencyPair) (*Ticker, error) {
-	resp, err := HttpGet(bx.client, fmt.Sprintf("%s/public/getmarketsummary?market=%s", bx.baseUrl, currency.ToSymbol2("-")))
+//返回毫秒时间戳
+func parseTime(t string) int64 {
+	tm, err := time.Parse(time.RFC3339Nano, t)
 	if err != nil {
-		errCode := HTTP_ERR_CODE
-		errCode.OriginErrMsg = err.Error()
-		return nil, errCode
+		return 0
 	}
+	return tm.UnixNano() / int64(time.Millisecond)
+}
+
+func (bx *Bittrex) GetTicker(currency CurrencyPair) (*Ticker, error) {
+	symbol := bx.marketSymbol(currency)
 
-	result, _ := resp["result"].([]interface{})
-	if len(result) <= 0 {
-		return nil, API_ERR
+	var ticker struct {
+		LastTradeRate string `json:"lastTradeRate"`
+		BidRate       string `json:"bidRate"`
+		AskRate       string `json:"askRate"`
+	}
+	err := bx.doRequest("GET", "/markets/"+symbol+"/ticker", nil, &ticker, false)
+	if err != nil {
+		return nil, err
 	}
 
-	tickermap := result[0].(map[string]interface{})
+	var summary struct {
+		High      string `json:"high"`
+		Low       string `json:"low"`
+		Volume    string `json:"volume"`
+		UpdatedAt string `json:"updatedAt"`
+	}
+	err = bx.doRequest("GET", "/markets/"+symbol+"/summary", nil, &summary, false)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Ticker{
-		Last: ToFloat64(tickermap["Last"]),
-		Sell: ToFloat64(tickermap["Ask"]),
-		Buy:  ToFloat64(tickermap["Bid"]),
-		Low:  ToFloat64(tickermap["Low"]),
-		High: ToFloat64(tickermap["High"]),
-		Vol:  ToFloat64(tickermap["Volume"]),
+		Pair: currency,
+		Last: ToFloat64(ticker.LastTradeRate),
+		Sell: ToFloat64(ticker.AskRate),
+		Buy:  ToFloat64(ticker.BidRate),
+		Low:  ToFloat64(summary.Low),
+		High: ToFloat64(summary.High),
+		Vol:  ToFloat64(summary.Volume),
+		Date: uint64(parseTime(summary.UpdatedAt)),
 	}, nil
 }
 
+//depth只支持1, 25, 500
 func (bx *Bittrex) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
-
-	resp, err := HttpGet(bx.client, fmt.Sprintf("%s/public/getorderbook?market=%s&type=both", bx.baseUrl, currency.ToSymbol2("-")))
-	if err != nil {
-		errCode := HTTP_ERR_CODE
-		errCode.OriginErrMsg = err.Error()
-		return nil, errCode
+	depth := 500
+	if size <= 1 {
+		depth = 1
+	} else if size <= 25 {
+		depth = 25
 	}
 
-	result, err2 := resp["result"].(map[string]interface{})
-	if err2 != true {
-		return nil, errors.New(resp["message"].(string))
+	var response struct {
+		Bid []struct {
+			Quantity string `json:"quantity"`
+			Rate     string `json:"rate"`
+		} `json:"bid"`
+		Ask []struct {
+			Quantity string `json:"quantity"`
+			Rate     string `json:"rate"`
+		} `json:"ask"`
 	}
-	bids, _ := result["buy"].([]interface{})
-	asks, _ := result["sell"].([]interface{})
-
-	dep := new(Depth)
-
-	for _, v := range bids {
-		r := v.(map[string]interface{})
-		dep.BidList = append(dep.BidList, DepthRecord{ToFloat64(r["Rate"]), ToFloat64(r["Quantity"])})
+	err := bx.doRequest("GET", fmt.Sprintf("/markets/%s/orderbook?depth=%d", bx.marketSymbol(currency), depth), nil, &response, false)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, v := range asks {
-		r := v.(map[string]interface{})
-		dep.AskList = append(dep.AskList, DepthRecord{ToFloat64(r["Rate"]), ToFloat64(r["Quantity"])})
+	dep := &Depth{Pair: currency, UTime: time.Now()}
+	for i, r := range response.Bid {
+		if i == size {
+			break
+		}
+		dep.BidList = append(dep.BidList, DepthRecord{Price: ToFloat64(r.Rate), Amount: ToFloat64(r.Quantity)})
+	}
+	for i, r := range response.Ask {
+		if i == size {
+			break
+		}
+		dep.AskList = append(dep.AskList, DepthRecord{Price: ToFloat64(r.Rate), Amount: ToFloat64(r.Quantity)})
 	}
 
 	sort.Sort(sort.Reverse(dep.AskList))
@@ -105,13 +436,82 @@ func (bx *Bittrex) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 	return dep, nil
 }
 
+var candleIntervals = map[KlinePeriod]string{
+	KLINE_PERIOD_1MIN: "MINUTE_1",
+	KLINE_PERIOD_5MIN: "MINUTE_5",
+	KLINE_PERIOD_1H:   "HOUR_1",
+	KLINE_PERIOD_1DAY: "DAY_1",
+}
+
+/**
+ * 最近的k线, 只支持1min, 5min, 1h, 1day
+ * 1min/5min最多返回1天, 1h最多31天, 1day最多366天
+ */
 func (bx *Bittrex) GetKlineRecords(currency CurrencyPair, period KlinePeriod, size int, opt ...OptionalParameter) ([]Kline, error) {
-	panic("not implement")
+	interval, ok := candleIntervals[period]
+	if !ok {
+		return nil, errors.New("unsupport the kline period")
+	}
+
+	var response []struct {
+		StartsAt string `json:"startsAt"`
+		Open     string `json:"open"`
+		High     string `json:"high"`
+		Low      string `json:"low"`
+		Close    string `json:"close"`
+		Volume   string `json:"volume"`
+	}
+	err := bx.doRequest("GET", fmt.Sprintf("/markets/%s/candles/TRADE/%s/recent", bx.marketSymbol(currency), interval), nil, &response, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if size > 0 && len(response) > size {
+		response = response[len(response)-size:]
+	}
+
+	klines := make([]Kline, 0, len(response))
+	for _, r := range response {
+		klines = append(klines, Kline{
+			Pair:      currency,
+			Timestamp: parseTime(r.StartsAt) / 1000,
+			Open:      ToFloat64(r.Open),
+			High:      ToFloat64(r.High),
+			Low:       ToFloat64(r.Low),
+			Close:     ToFloat64(r.Close),
+			Vol:       ToFloat64(r.Volume),
+		})
+	}
+	return klines, nil
 }
 
-//非个人，整个交易所的交易记录
+//非个人，整个交易所的交易记录, 只返回最近的成交, 忽略since
 func (bx *Bittrex) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	var response []struct {
+		Id         string `json:"id"`
+		ExecutedAt string `json:"executedAt"`
+		Quantity   string `json:"quantity"`
+		Rate       string `json:"rate"`
+		TakerSide  string `json:"takerSide"`
+	}
+	err := bx.doRequest("GET", fmt.Sprintf("/markets/%s/trades", bx.marketSymbol(currencyPair)), nil, &response, false)
+	if err != nil {
+		return nil, err
+	}
+
+	trades := make([]Trade, 0, len(response))
+	for _, r := range response {
+		date := parseTime(r.ExecutedAt)
+		trades = append(trades, Trade{
+			Tid:    date, //id为uuid, 用成交时间代替
+			Type:   AdaptTradeSide(r.TakerSide),
+			Amount: ToFloat64(r.Quantity),
+			Price:  ToFloat64(r.Rate),
+			Date:   date,
+			Pair:   currencyPair,
+		})
+	}
+	return trades, nil
 }
 
 func (bx *Bittrex) GetExchangeName() string {
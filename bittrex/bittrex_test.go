@@ -1,9 +1,14 @@
 package bittrex
 
 import (
-	"github.com/lucas7788/goex"
+	"crypto/sha512"
+	"encoding/hex"
 	"net/http"
 	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
 )
 
 var b = New(http.DefaultClient, "", "")
@@ -20,3 +25,72 @@ func TestBittrex_GetDepth(t *testing.T) {
 	t.Log("ask=>", dep.AskList)
 	t.Log("bid=>", dep.BidList)
 }
+
+//v3接口并校验签名, 路由的key为"METHOD /path?query", 下单接口返回201
+var bittrexAPI = testserver.Options{
+	Fixed: map[string]testserver.Route{
+		"/ping": testserver.Reply(`{"serverTime":1603695163000}`),
+	},
+	Check: func(r *testserver.Request) interface{} {
+		hash := sha512.Sum512(r.Data)
+		sign, _ := goex.GetParamHmacSHA512Sign("secret", r.Header.Get("Api-Timestamp")+"http://"+r.Host+r.URL.RequestURI()+r.Method+r.Header.Get("Api-Content-Hash"))
+		if r.Header.Get("Api-Key") != "key" || r.Header.Get("Api-Content-Hash") != hex.EncodeToString(hash[:]) || r.Header.Get("Api-Signature") != sign {
+			return testserver.Response{Status: http.StatusUnauthorized, Body: `{"code":"INVALID_SIGNATURE"}`}
+		}
+		return nil
+	},
+	Wrap: func(r *testserver.Request, result interface{}) interface{} {
+		if r.Method == http.MethodPost {
+			return testserver.Response{Status: http.StatusCreated, Body: result}
+		}
+		return result
+	},
+	NotFound: testserver.Reply(testserver.Response{Status: http.StatusNotFound, Body: `{"code":"NOT_FOUND"}`}),
+	Key: func(r *testserver.Request) string {
+		return r.Method + " " + r.URL.RequestURI()
+	},
+}
+
+func TestBittrex_LimitBuy(t *testing.T) {
+	srv := testserver.New(bittrexAPI, map[string]testserver.Route{
+		"POST /orders": testserver.Reply(`{"id":"ab9c4a6b-1b4e-4ac6-9e1a-c1b3e2f1c5ee","marketSymbol":"BTC-USDT","direction":"BUY","type":"LIMIT","quantity":"0.01000000",
+			"limit":"10000.00000000","timeInForce":"POST_ONLY_GOOD_TIL_CANCELLED","fillQuantity":"0.00000000","commission":"0.00000000","proceeds":"0.00000000",
+			"status":"OPEN","createdAt":"2020-10-26T06:52:43.15Z"}`),
+		"GET /orders/open?marketSymbol=BTC-USDT": testserver.Reply(`[]`),
+	})
+	defer srv.Close()
+	bx := NewWithConfig(testserver.Config(srv))
+
+	ord, err := bx.LimitBuy("0.01", "10000", goex.BTC_USDT, goex.PostOnly)
+	assert.Nil(t, err)
+	assert.Equal(t, "ab9c4a6b-1b4e-4ac6-9e1a-c1b3e2f1c5ee", ord.OrderID2)
+	assert.Equal(t, goex.BUY, ord.Side)
+	assert.Equal(t, goex.ORDER_FEATURE_POST_ONLY, ord.OrderType)
+	assert.Equal(t, goex.ORDER_UNFINISH, ord.Status)
+	assert.Equal(t, 10000.0, ord.Price)
+	assert.Equal(t, 1603695163150, ord.OrderTime)
+
+	orders, err := bx.GetUnfinishOrders(goex.BTC_USDT)
+	assert.Nil(t, err)
+	assert.Empty(t, orders)
+
+	_, err = bx.CancelOrder("not-exist", goex.BTC_USDT)
+	assert.Equal(t, "NOT_FOUND", err.(goex.ApiError).ErrCode)
+}
+
+func TestBittrex_GetWithDrawHistory(t *testing.T) {
+	srv := testserver.New(bittrexAPI, map[string]testserver.Route{
+		"GET /withdrawals/closed?currencySymbol=BTC": testserver.Reply(`[{"id":"b1e3f3f0-7d8f-4b8a-8d6e-1f5f2c7a9a11","currencySymbol":"BTC","quantity":"0.50000000",
+			"cryptoAddress":"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2","txCost":"0.00050000","txId":"0xabc","status":"COMPLETED","createdAt":"2020-10-26T06:52:43Z"}]`),
+	})
+	defer srv.Close()
+	bx := NewWithConfig(testserver.Config(srv))
+
+	history, err := bx.GetWithDrawHistory(&goex.BTC)
+	assert.Nil(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, 0.5, history[0].Amount)
+	assert.Equal(t, goex.WITHDRAW_COMPLETED, history[0].Status)
+	assert.Equal(t, "0.00050000", history[0].Fee)
+	assert.Equal(t, int64(1603695163), history[0].Timestamp.Unix())
+}
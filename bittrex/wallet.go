@@ -0,0 +1,103 @@
+package bittrex
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	. "github.com/lucas7788/goex"
+)
+
+//充提状态
+var depositWithdrawStatus = map[string]WithdrawStatus{
+	"REQUESTED":             WITHDRAW_PENDING,
+	"AUTHORIZED":            WITHDRAW_PENDING,
+	"PENDING":               WITHDRAW_SENT,
+	"COMPLETED":             WITHDRAW_COMPLETED,
+	"ERROR_INVALID_ADDRESS": WITHDRAW_FAILED,
+	"ORPHANED":              WITHDRAW_FAILED,
+	"INVALIDATED":           WITHDRAW_FAILED,
+	"CANCELLED":             WITHDRAW_CANCELLED,
+}
+
+type withdrawalParam struct {
+	CurrencySymbol     string `json:"currencySymbol"`
+	Quantity           string `json:"quantity"`
+	CryptoAddress      string `json:"cryptoAddress"`
+	CryptoAddressTag   string `json:"cryptoAddressTag,omitempty"`
+	ClientWithdrawalId string `json:"clientWithdrawalId"`
+}
+
+type depositWithdrawResponse struct {
+	Id               string `json:"id"`
+	CurrencySymbol   string `json:"currencySymbol"`
+	Quantity         string `json:"quantity"`
+	CryptoAddress    string `json:"cryptoAddress"`
+	CryptoAddressTag string `json:"cryptoAddressTag"`
+	TxCost           string `json:"txCost"`
+	TxId             string `json:"txId"`
+	Status           string `json:"status"`
+	CreatedAt        string `json:"createdAt"`
+	UpdatedAt        string `json:"updatedAt"`
+}
+
+//提币到数字货币地址, 返回提币id
+func (bx *Bittrex) Withdrawal(param WithdrawParameter) (withdrawId string, err error) {
+	var response depositWithdrawResponse
+	err = bx.doRequest("POST", "/withdrawals", withdrawalParam{
+		CurrencySymbol:     param.Currency,
+		Quantity:           FloatToString(param.Amount, 8),
+		CryptoAddress:      param.ToAddress,
+		ClientWithdrawalId: uuid.New().String(),
+	}, &response, true)
+	if err != nil {
+		return "", err
+	}
+	return response.Id, nil
+}
+
+//不支持账户间划转
+func (bx *Bittrex) Transfer(param TransferParameter) error {
+	return EX_ERR_NOT_SUPPORT
+}
+
+func (bx *Bittrex) getDepositWithdrawHistory(path string, currency *Currency) ([]DepositWithdrawHistory, error) {
+	if currency != nil {
+		path += "?currencySymbol=" + currency.Symbol
+	}
+	var response []depositWithdrawResponse
+	err := bx.doRequest("GET", path, nil, &response, true)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]DepositWithdrawHistory, 0, len(response))
+	for _, r := range response {
+		ts := r.CreatedAt
+		if ts == "" {
+			ts = r.UpdatedAt
+		}
+		history = append(history, DepositWithdrawHistory{
+			WithdrawalId: r.Id,
+			Currency:     r.CurrencySymbol,
+			Txid:         r.TxId,
+			Amount:       ToFloat64(r.Quantity),
+			To:           r.CryptoAddress,
+			Memo:         r.CryptoAddressTag,
+			Fee:          r.TxCost,
+			Status:       depositWithdrawStatus[r.Status],
+			Timestamp:    time.Unix(0, parseTime(ts)*int64(time.Millisecond)),
+		})
+	}
+	return history, nil
+}
+
+//已完成的提币记录
+func (bx *Bittrex) GetWithDrawHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	return bx.getDepositWithdrawHistory("/withdrawals/closed", currency)
+}
+
+//已完成的充值记录
+func (bx *Bittrex) GetDepositHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	return bx.getDepositWithdrawHistory("/deposits/closed", currency)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/lucas7788/goex/bitmex"
 	"github.com/lucas7788/goex/bitstamp"
 	"github.com/lucas7788/goex/bittrex"
+	"github.com/lucas7788/goex/bybit"
 	"github.com/lucas7788/goex/coinbene"
 	"github.com/lucas7788/goex/kucoin"
 
@@ -23,6 +24,8 @@ import (
 	"time"
 
 	"github.com/lucas7788/goex/coinex"
+	"github.com/lucas7788/goex/deribit"
+	"github.com/lucas7788/goex/gateio"
 	"github.com/lucas7788/goex/gdax"
 	"github.com/lucas7788/goex/hitbtc"
 	"github.com/lucas7788/goex/huobi"
@@ -243,11 +246,20 @@ func (builder *APIBuilder) Build(exName string) (api API) {
 			ApiKey:       builder.apiKey,
 			ApiSecretKey: builder.secretkey})
 	case BITTREX:
-		_api = bittrex.New(builder.client, builder.apiKey, builder.secretkey)
+		_api = bittrex.NewWithConfig(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.endPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey})
 	case BITHUMB:
 		_api = bithumb.New(builder.client, builder.apiKey, builder.secretkey)
 	case GDAX:
-		_api = gdax.New(builder.client, builder.apiKey, builder.secretkey)
+		_api = gdax.NewWithConfig(&APIConfig{
+			HttpClient:    builder.client,
+			Endpoint:      builder.endPoint,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase})
 	case ZB:
 		_api = zb.New(builder.client, builder.apiKey, builder.secretkey)
 	case COINEX:
@@ -258,6 +270,24 @@ func (builder *APIBuilder) Build(exName string) (api API) {
 		_api = hitbtc.New(builder.client, builder.apiKey, builder.secretkey)
 	case ATOP:
 		_api = atop.New(builder.client, builder.apiKey, builder.secretkey)
+	case BYBIT:
+		_api = bybit.NewSpot(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.endPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey})
+	case BYBIT_TEST:
+		_api = bybit.NewSpot(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     bybit.TestnetUrl,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey})
+	case GATEIO:
+		_api = gateio.NewSpot(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.endPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey})
 	default:
 		println("exchange name error [" + exName + "].")
 
@@ -281,7 +311,47 @@ func (builder *APIBuilder) BuildFuture(exName string) (api FutureRestAPI) {
 			ApiKey:       builder.apiKey,
 			ApiSecretKey: builder.secretkey,
 		})
-	case OKEX_FUTURE, OKEX_V3:
+	case DERIBIT:
+		return deribit.New(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.futuresEndPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey})
+	case DERIBIT_TEST:
+		return deribit.New(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     deribit.TestnetUrl,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey})
+	case BYBIT:
+		return bybit.NewFutures(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.futuresEndPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey})
+	case BYBIT_TEST:
+		return bybit.NewFutures(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     bybit.TestnetUrl,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey})
+	case GATEIO:
+		return gateio.NewFutures(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.futuresEndPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey})
+	case OKEX, OKEX_FUTURE, OKEX_SWAP:
+		//v5 统一接口, 交割/永续/期权通过contractType区分
+		return okex.NewOKEx(&APIConfig{
+			HttpClient:    builder.client,
+			Endpoint:      builder.futuresEndPoint,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
+			Lever:         builder.futuresLever,
+			Simulated:     builder.Simulated}).OKExFuturesV5
+	case OKEX_V3:
 		//return okcoin.NewOKEx(builder.client, builder.apiKey, builder.secretkey)
 		return okex.NewOKEx(&APIConfig{
 			HttpClient: builder.client,
@@ -306,14 +376,14 @@ func (builder *APIBuilder) BuildFuture(exName string) (api FutureRestAPI) {
 			ApiSecretKey: builder.secretkey,
 			Lever:        builder.futuresLever,
 		})
-	case OKEX_SWAP:
-		return okex.NewOKEx(&APIConfig{
-			HttpClient:    builder.client,
-			Endpoint:      builder.futuresEndPoint,
-			ApiKey:        builder.apiKey,
-			ApiSecretKey:  builder.secretkey,
-			ApiPassphrase: builder.apiPassphrase,
-			Lever:         builder.futuresLever}).OKExSwap
+	case HBDM_LINEAR_SWAP:
+		return huobi.NewHbdmLinearSwap(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.endPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+			Lever:        builder.futuresLever,
+		})
 	case COINBENE:
 		return coinbene.NewCoinbeneSwap(APIConfig{
 			HttpClient: builder.client,
@@ -348,7 +418,16 @@ func (builder *APIBuilder) BuildFuture(exName string) (api FutureRestAPI) {
 
 func (builder *APIBuilder) BuildFuturesWs(exName string) (FuturesWsApi, error) {
 	switch exName {
-	case OKEX_V3, OKEX, OKEX_FUTURE:
+	case OKEX, OKEX_FUTURE, OKEX_SWAP:
+		return okex.NewOKExV5FuturesWs(okex.NewOKEx(&APIConfig{
+			HttpClient:    builder.client,
+			Endpoint:      builder.futuresEndPoint,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
+			Simulated:     builder.Simulated,
+		})), nil
+	case OKEX_V3:
 		return okex.NewOKExV3FuturesWs(okex.NewOKEx(&APIConfig{
 			HttpClient: builder.client,
 			Endpoint:   builder.futuresEndPoint,
@@ -360,26 +439,136 @@ func (builder *APIBuilder) BuildFuturesWs(exName string) (FuturesWsApi, error) {
 	case BINANCE, BINANCE_FUTURES, BINANCE_SWAP:
 		return binance.NewFuturesWs(), nil
 	case BITMEX:
-		return bitmex.NewSwapWs(), nil
+		return bitmex.NewSwapWsWithConfig(&APIConfig{
+			Endpoint:     builder.futuresEndPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
+	case BITMEX_TEST:
+		return bitmex.NewSwapWsWithConfig(&APIConfig{
+			Endpoint:     "https://testnet.bitmex.com",
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
+	case DERIBIT:
+		return deribit.NewFuturesWsWithConfig(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.futuresEndPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
+	case DERIBIT_TEST:
+		return deribit.NewFuturesWsWithConfig(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     deribit.TestnetUrl,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
+	case BYBIT:
+		return bybit.NewFuturesWsWithConfig(&APIConfig{
+			HttpClient: builder.client,
+			Endpoint:   builder.futuresEndPoint,
+		}), nil
+	case BYBIT_TEST:
+		return bybit.NewFuturesWsWithConfig(&APIConfig{
+			HttpClient: builder.client,
+			Endpoint:   bybit.TestnetUrl,
+		}), nil
 	}
 	return nil, errors.New("not support the exchange " + exName)
 }
 
 func (builder *APIBuilder) BuildSpotWs(exName string) (SpotWsApi, error) {
 	switch exName {
-	case OKEX_V3, OKEX:
+	case OKEX:
+		return okex.NewOKExV5SpotWs(okex.NewOKEx(&APIConfig{
+			HttpClient:    builder.client,
+			Endpoint:      builder.endPoint,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
+			Simulated:     builder.Simulated,
+		})), nil
+	case OKEX_V3:
 		return okex.NewOKExSpotV3Ws(nil), nil
 	case HUOBI_PRO, HUOBI:
 		return huobi.NewSpotWs(), nil
 	case BINANCE:
 		return binance.NewSpotWs(), nil
+	case GDAX:
+		return gdax.NewGdaxWs(gdax.NewWithConfig(&APIConfig{
+			HttpClient:    builder.client,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase})), nil
+	case KRAKEN:
+		return kraken.NewKrakenWs(kraken.New(builder.client, builder.apiKey, builder.secretkey)), nil
+	case BITFINEX:
+		return bitfinex.NewAuthWs(bitfinex.New(builder.client, builder.apiKey, builder.secretkey)), nil
+	case BYBIT:
+		return bybit.NewSpotWs(), nil
+	case BYBIT_TEST:
+		return bybit.NewSpotWsTestnet(), nil
+	case GATEIO:
+		return gateio.NewSpotWs(), nil
 	}
 	return nil, errors.New("not support the exchange " + exName)
 }
 
+//杠杆交易, 默认全仓(okex v3为逐仓), 逐仓请使用各交易所的构造函数
+func (builder *APIBuilder) BuildMargin(exName string) (MarginAPI, error) {
+	switch exName {
+	case OKEX:
+		return okex.NewOKEx(&APIConfig{
+			HttpClient:    builder.client,
+			Endpoint:      builder.endPoint,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
+			Simulated:     builder.Simulated,
+		}).OKExMarginV5, nil
+	case OKEX_V3:
+		return okex.NewOKEx(&APIConfig{
+			HttpClient:    builder.client,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
+		}).OKExMargin, nil
+	case BINANCE:
+		return binance.NewMargin(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.endPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
+	case HUOBI_PRO:
+		return huobi.NewHuobiCrossMargin(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.endPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
+	case BITFINEX:
+		return bitfinex.NewMargin(builder.client, builder.apiKey, builder.secretkey), nil
+	case POLONIEX:
+		return poloniex.NewMargin(builder.client, builder.apiKey, builder.secretkey), nil
+	}
+	return nil, errors.New("not support the margin api for " + exName)
+}
+
+//okex, huobi, binance, kucoin的钱包同时实现了WalletApiExt
 func (builder *APIBuilder) BuildWallet(exName string) (WalletApi, error) {
 	switch exName {
-	case OKEX_V3, OKEX:
+	case OKEX:
+		return okex.NewOKEx(&APIConfig{
+			HttpClient:    builder.client,
+			Endpoint:      builder.endPoint,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
+			Simulated:     builder.Simulated,
+		}).OKExWalletV5, nil
+	case OKEX_V3:
 		return okex.NewOKEx(&APIConfig{
 			HttpClient:    builder.client,
 			ApiKey:        builder.apiKey,
@@ -400,6 +589,147 @@ func (builder *APIBuilder) BuildWallet(exName string) (WalletApi, error) {
 			ApiKey:       builder.apiKey,
 			ApiSecretKey: builder.secretkey,
 		}), nil
+	case BITTREX:
+		return bittrex.NewWithConfig(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.endPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
+	case KUCOIN:
+		return kucoin.NewWallet(&APIConfig{
+			HttpClient:    builder.client,
+			Endpoint:      builder.endPoint,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
+		}), nil
 	}
 	return nil, errors.New("not support the wallet api for  " + exName)
 }
+
+func (builder *APIBuilder) BuildTransfer(exName string) (TransferAPI, error) {
+	switch exName {
+	case OKEX:
+		return okex.NewOKEx(&APIConfig{
+			HttpClient:    builder.client,
+			Endpoint:      builder.endPoint,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
+			Simulated:     builder.Simulated,
+		}).OKExWalletV5, nil
+	case HUOBI_PRO:
+		return huobi.NewWallet(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.endPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
+	case BINANCE:
+		return binance.NewWallet(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.endPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
+	case KUCOIN:
+		return kucoin.NewWallet(&APIConfig{
+			HttpClient:    builder.client,
+			Endpoint:      builder.endPoint,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
+		}), nil
+	case BITFINEX:
+		return bitfinex.New(builder.client, builder.apiKey, builder.secretkey), nil
+	}
+	return nil, errors.New("not support the transfer api for " + exName)
+}
+
+func (builder *APIBuilder) BuildSubAccount(exName string) (SubAccountAPI, error) {
+	switch exName {
+	case OKEX:
+		return okex.NewOKEx(&APIConfig{
+			HttpClient:    builder.client,
+			Endpoint:      builder.endPoint,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
+			Simulated:     builder.Simulated,
+		}).OKExWalletV5, nil
+	case HUOBI_PRO:
+		return huobi.NewWallet(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.endPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
+	case BINANCE:
+		return binance.NewWallet(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.endPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
+	case KUCOIN:
+		return kucoin.NewWallet(&APIConfig{
+			HttpClient:    builder.client,
+			Endpoint:      builder.endPoint,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
+		}), nil
+	}
+	return nil, errors.New("not support the sub account api for " + exName)
+}
+
+func (builder *APIBuilder) BuildLending(exName string) (LendingAPI, error) {
+	switch exName {
+	case OKEX:
+		return okex.NewOKEx(&APIConfig{
+			HttpClient:    builder.client,
+			Endpoint:      builder.endPoint,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
+			Simulated:     builder.Simulated,
+		}).OKExWalletV5, nil
+	case BINANCE:
+		return binance.NewWallet(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.endPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey,
+		}), nil
+	case BITFINEX:
+		return bitfinex.New(builder.client, builder.apiKey, builder.secretkey), nil
+	}
+	return nil, errors.New("not support the lending api for " + exName)
+}
+
+func (builder *APIBuilder) BuildOptions(exName string) (OptionsAPI, error) {
+	switch exName {
+	case OKEX:
+		return okex.NewOKEx(&APIConfig{
+			HttpClient:    builder.client,
+			Endpoint:      builder.futuresEndPoint,
+			ApiKey:        builder.apiKey,
+			ApiSecretKey:  builder.secretkey,
+			ApiPassphrase: builder.apiPassphrase,
+			Simulated:     builder.Simulated}).OKExOptionsV5, nil
+	case DERIBIT:
+		return deribit.New(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     builder.futuresEndPoint,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey}), nil
+	case DERIBIT_TEST:
+		return deribit.New(&APIConfig{
+			HttpClient:   builder.client,
+			Endpoint:     deribit.TestnetUrl,
+			ApiKey:       builder.apiKey,
+			ApiSecretKey: builder.secretkey}), nil
+	}
+	return nil, errors.New("not support the options api for " + exName)
+}
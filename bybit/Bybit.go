@@ -0,0 +1,438 @@
+package bybit
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	. "github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/logger"
+)
+
+const (
+	baseUrl    = "https://api.bybit.com"
+	TestnetUrl = "https://api-testnet.bybit.com"
+
+	defaultRecvWindow = "5000"
+)
+
+//v5接口的category
+const (
+	CategorySpot    = "spot"
+	CategoryLinear  = "linear"  //U本位合约
+	CategoryInverse = "inverse" //币本位合约
+)
+
+type baseResponse struct {
+	RetCode int             `json:"retCode"`
+	RetMsg  string          `json:"retMsg"`
+	Result  json.RawMessage `json:"result"`
+	Time    int64           `json:"time"`
+}
+
+/**
+ * bybit v5 统一接口, 现货(Spot)和合约(Futures)共用签名和请求
+ * 签名: hex(hmac_sha256(secret, timestamp + apiKey + recvWindow + queryString|jsonBody))
+ * timestamp使用同步后的服务器时间, RecvWindow为空时为5000毫秒
+ * config.Endpoint为TestnetUrl时使用测试网
+ */
+type Bybit struct {
+	*APIConfig
+	RecvWindow string
+	clock      *ClockSync
+}
+
+func New(config *APIConfig) *Bybit {
+	if config.Endpoint == "" {
+		config.Endpoint = baseUrl
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	if config.HttpClient == nil {
+		config.HttpClient = http.DefaultClient
+	}
+	bb := &Bybit{APIConfig: config, RecvWindow: defaultRecvWindow}
+	bb.clock = SharedClockSync(bb.Endpoint+"/v5/market/time", bb.GetServerTime)
+	return bb
+}
+
+//服务器毫秒时间戳
+func (bb *Bybit) GetServerTime() (int64, error) {
+	respData, err := NewHttpRequest(bb.HttpClient, "GET", bb.Endpoint+"/v5/market/time", "", nil)
+	if err != nil {
+		return 0, err
+	}
+	var resp baseResponse
+	if err = json.Unmarshal(respData, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Time, nil
+}
+
+func (bb *Bybit) sign(timestamp, payload string) string {
+	sign, _ := GetParamHmacSHA256Sign(bb.ApiSecretKey, timestamp+bb.ApiKey+bb.RecvWindow+payload)
+	return sign
+}
+
+/**
+ * GET请求的params放在query string, POST请求的params转换为json body
+ * 以/v5/market开头的是公共接口, 不签名
+ */
+func (bb *Bybit) doRequest(method, path string, params url.Values, result interface{}) error {
+	var (
+		reqUrl  = bb.Endpoint + path
+		body    string
+		payload string
+	)
+	if method == http.MethodGet {
+		if len(params) > 0 {
+			payload = params.Encode()
+			reqUrl += "?" + payload
+		}
+	} else {
+		data, err := json.Marshal(toBody(params))
+		if err != nil {
+			return err
+		}
+		body = string(data)
+		payload = body
+	}
+
+	header := map[string]string{"Content-Type": "application/json"}
+	if !strings.HasPrefix(path, "/v5/market") {
+		if bb.ApiKey == "" {
+			return EX_ERR_NOT_FIND_APIKEY
+		}
+		timestamp := fmt.Sprint(bb.clock.Now().UnixNano() / 1e6)
+		header["X-BAPI-API-KEY"] = bb.ApiKey
+		header["X-BAPI-TIMESTAMP"] = timestamp
+		header["X-BAPI-RECV-WINDOW"] = bb.RecvWindow
+		header["X-BAPI-SIGN"] = bb.sign(timestamp, payload)
+	}
+
+	respData, err := NewHttpRequest(bb.HttpClient, method, reqUrl, body, header)
+	if err != nil {
+		return HTTP_ERR_CODE.OriginErr(err.Error())
+	}
+	logger.Debugf("[bybit] response: %s", string(respData))
+
+	var resp baseResponse
+	if err = json.Unmarshal(respData, &resp); err != nil {
+		return err
+	}
+	if resp.RetCode != 0 {
+		return toApiError(resp.RetCode, resp.RetMsg)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, result)
+}
+
+//bool值和数字在body中保持json类型
+func toBody(params url.Values) map[string]interface{} {
+	body := make(map[string]interface{}, len(params))
+	for k := range params {
+		v := params.Get(k)
+		switch {
+		case v == "true" || v == "false":
+			body[k] = v == "true"
+		case k == "positionIdx" || k == "mode" || k == "tradeMode":
+			body[k] = ToInt(v)
+		default:
+			body[k] = v
+		}
+	}
+	return body
+}
+
+func toApiError(code int, msg string) error {
+	errMsg := fmt.Sprintf("%d: %s", code, msg)
+	switch code {
+	case 10002, 10003, 10004:
+		return EX_ERR_SIGN.OriginErr(errMsg)
+	case 10006, 10018:
+		return EX_ERR_API_LIMIT.OriginErr(errMsg)
+	case 110001, 170213:
+		return EX_ERR_NOT_FIND_ORDER.OriginErr(errMsg)
+	case 110004, 110007, 170131:
+		return EX_ERR_INSUFFICIENT_BALANCE.OriginErr(errMsg)
+	}
+	return API_ERR.OriginErr(errMsg)
+}
+
+//BTC_USDT => BTCUSDT
+func toSymbol(pair CurrencyPair) string {
+	return pair.ToUpper().ToSymbol("")
+}
+
+type orderInfo struct {
+	OrderId      string `json:"orderId"`
+	OrderLinkId  string `json:"orderLinkId"`
+	Symbol       string `json:"symbol"`
+	Side         string `json:"side"`
+	OrderType    string `json:"orderType"`
+	Price        string `json:"price"`
+	Qty          string `json:"qty"`
+	CumExecQty   string `json:"cumExecQty"`
+	CumExecValue string `json:"cumExecValue"`
+	CumExecFee   string `json:"cumExecFee"`
+	AvgPrice     string `json:"avgPrice"`
+	OrderStatus  string `json:"orderStatus"`
+	TimeInForce  string `json:"timeInForce"`
+	PositionIdx  int    `json:"positionIdx"`
+	ReduceOnly   bool   `json:"reduceOnly"`
+	CreatedTime  string `json:"createdTime"`
+	UpdatedTime  string `json:"updatedTime"`
+}
+
+type orderList struct {
+	List           []orderInfo `json:"list"`
+	NextPageCursor string      `json:"nextPageCursor"`
+}
+
+type tickerInfo struct {
+	Symbol       string `json:"symbol"`
+	LastPrice    string `json:"lastPrice"`
+	Bid1Price    string `json:"bid1Price"`
+	Ask1Price    string `json:"ask1Price"`
+	HighPrice24h string `json:"highPrice24h"`
+	LowPrice24h  string `json:"lowPrice24h"`
+	Volume24h    string `json:"volume24h"`
+	IndexPrice   string `json:"indexPrice"`
+	MarkPrice    string `json:"markPrice"`
+	OpenInterest string `json:"openInterest"`
+	DeliveryTime string `json:"deliveryTime"`
+}
+
+func adaptOrderStatus(status string) TradeStatus {
+	switch status {
+	case "New", "Created", "Untriggered", "Active":
+		return ORDER_UNFINISH
+	case "PartiallyFilled":
+		return ORDER_PART_FINISH
+	case "Filled":
+		return ORDER_FINISH
+	case "Cancelled", "PartiallyFilledCanceled", "Deactivated":
+		return ORDER_CANCEL
+	case "Rejected":
+		return ORDER_REJECT
+	}
+	return ORDER_UNFINISH
+}
+
+func adaptTimeInForce(timeInForce string) int {
+	switch timeInForce {
+	case "PostOnly":
+		return ORDER_FEATURE_POST_ONLY
+	case "IOC":
+		return ORDER_FEATURE_IOC
+	case "FOK":
+		return ORDER_FEATURE_FOK
+	}
+	return ORDER_FEATURE_ORDINARY
+}
+
+//PostOnly, Ioc, Fok => timeInForce
+func timeInForce(opt ...LimitOrderOptionalParameter) (string, int) {
+	if len(opt) > 0 {
+		switch opt[0] {
+		case PostOnly:
+			return "PostOnly", ORDER_FEATURE_POST_ONLY
+		case Ioc:
+			return "IOC", ORDER_FEATURE_IOC
+		case Fok:
+			return "FOK", ORDER_FEATURE_FOK
+		}
+	}
+	return "GTC", ORDER_FEATURE_ORDINARY
+}
+
+func (bb *Bybit) getTicker(category, symbol string) (*tickerInfo, error) {
+	params := url.Values{}
+	params.Set("category", category)
+	params.Set("symbol", symbol)
+	var resp struct {
+		List []tickerInfo `json:"list"`
+	}
+	err := bb.doRequest(http.MethodGet, "/v5/market/tickers", params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.List) == 0 {
+		return nil, EX_ERR_SYMBOL_ERR.OriginErr("no ticker: " + symbol)
+	}
+	return &resp.List[0], nil
+}
+
+//返回的bids, asks均为[price, size]
+func (bb *Bybit) getDepth(category, symbol string, size int) (bids, asks DepthRecords, ts int64, err error) {
+	params := url.Values{}
+	params.Set("category", category)
+	params.Set("symbol", symbol)
+	params.Set("limit", fmt.Sprint(size))
+	var resp struct {
+		B  [][2]string `json:"b"`
+		A  [][2]string `json:"a"`
+		Ts int64       `json:"ts"`
+	}
+	err = bb.doRequest(http.MethodGet, "/v5/market/orderbook", params, &resp)
+	if err != nil {
+		return
+	}
+	for _, b := range resp.B {
+		bids = append(bids, DepthRecord{Price: ToFloat64(b[0]), Amount: ToFloat64(b[1])})
+	}
+	//asks为升序, 转换为降序
+	for i := len(resp.A) - 1; i >= 0; i-- {
+		asks = append(asks, DepthRecord{Price: ToFloat64(resp.A[i][0]), Amount: ToFloat64(resp.A[i][1])})
+	}
+	return bids, asks, resp.Ts, nil
+}
+
+func adaptKlinePeriod(period KlinePeriod) string {
+	switch period {
+	case KLINE_PERIOD_1MIN:
+		return "1"
+	case KLINE_PERIOD_3MIN:
+		return "3"
+	case KLINE_PERIOD_5MIN:
+		return "5"
+	case KLINE_PERIOD_15MIN:
+		return "15"
+	case KLINE_PERIOD_30MIN:
+		return "30"
+	case KLINE_PERIOD_1H, KLINE_PERIOD_60MIN:
+		return "60"
+	case KLINE_PERIOD_2H:
+		return "120"
+	case KLINE_PERIOD_4H:
+		return "240"
+	case KLINE_PERIOD_6H:
+		return "360"
+	case KLINE_PERIOD_12H:
+		return "720"
+	case KLINE_PERIOD_1DAY:
+		return "D"
+	case KLINE_PERIOD_1WEEK:
+		return "W"
+	case KLINE_PERIOD_1MONTH:
+		return "M"
+	}
+	return ""
+}
+
+//optional支持startTime, 返回按时间升序的K线, 每根为[startTime, open, high, low, close, volume, turnover]
+func (bb *Bybit) getKlines(category, symbol string, period KlinePeriod, size int, optional ...OptionalParameter) ([][]string, error) {
+	interval := adaptKlinePeriod(period)
+	if interval == "" {
+		return nil, EX_ERR_NOT_SUPPORT.OriginErr("unsupported kline period")
+	}
+	params := url.Values{}
+	params.Set("category", category)
+	params.Set("symbol", symbol)
+	params.Set("interval", interval)
+	params.Set("limit", fmt.Sprint(size))
+	if len(optional) > 0 && optional[0].GetTime("startTime") != nil {
+		params.Set("start", fmt.Sprint(optional[0].GetTime("startTime").UnixNano()/1e6))
+	}
+
+	var resp struct {
+		List [][]string `json:"list"`
+	}
+	err := bb.doRequest(http.MethodGet, "/v5/market/kline", params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	klines := resp.List
+	for i, j := 0, len(klines)-1; i < j; i, j = i+1, j-1 {
+		klines[i], klines[j] = klines[j], klines[i]
+	}
+	return klines, nil
+}
+
+//since不支持, 返回最近的成交
+func (bb *Bybit) getTrades(category, symbol string, pair CurrencyPair) ([]Trade, error) {
+	params := url.Values{}
+	params.Set("category", category)
+	params.Set("symbol", symbol)
+	params.Set("limit", "100")
+	var resp struct {
+		List []struct {
+			ExecId string `json:"execId"`
+			Price  string `json:"price"`
+			Size   string `json:"size"`
+			Side   string `json:"side"`
+			Time   string `json:"time"`
+		} `json:"list"`
+	}
+	err := bb.doRequest(http.MethodGet, "/v5/market/recent-trade", params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	trades := make([]Trade, 0, len(resp.List))
+	for _, t := range resp.List {
+		side := BUY
+		if t.Side == "Sell" {
+			side = SELL
+		}
+		trades = append(trades, Trade{
+			Tid:    tradeId(t.ExecId),
+			Type:   side,
+			Amount: ToFloat64(t.Size),
+			Price:  ToFloat64(t.Price),
+			Date:   ToInt64(t.Time),
+			Pair:   pair,
+		})
+	}
+	return trades, nil
+}
+
+//合约的execId为uuid, 转为64位hash; 现货的execId为数字, 直接使用
+func tradeId(execId string) int64 {
+	if id, err := strconv.ParseInt(execId, 10, 64); err == nil {
+		return id
+	}
+	h := fnv.New64a()
+	h.Write([]byte(execId))
+	return int64(h.Sum64() & math.MaxInt64)
+}
+
+func (bb *Bybit) getOrders(path string, params url.Values) ([]orderInfo, error) {
+	var resp orderList
+	err := bb.doRequest(http.MethodGet, path, params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.List, nil
+}
+
+func (bb *Bybit) cancelOrder(category, symbol, orderId string) error {
+	params := url.Values{}
+	params.Set("category", category)
+	params.Set("symbol", symbol)
+	params.Set("orderId", orderId)
+	return bb.doRequest(http.MethodPost, "/v5/order/cancel", params, nil)
+}
+
+//先查询未完成订单, 查不到时查询历史订单
+func (bb *Bybit) getOrder(category, symbol, orderId string) (*orderInfo, error) {
+	params := url.Values{}
+	params.Set("category", category)
+	params.Set("symbol", symbol)
+	params.Set("orderId", orderId)
+	for _, path := range []string{"/v5/order/realtime", "/v5/order/history"} {
+		orders, err := bb.getOrders(path, params)
+		if err != nil {
+			return nil, err
+		}
+		if len(orders) > 0 {
+			return &orders[0], nil
+		}
+	}
+	return nil, EX_ERR_NOT_FIND_ORDER
+}
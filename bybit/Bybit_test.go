@@ -0,0 +1,188 @@
+package bybit
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+//v5接口, 路由的key为"METHOD /path", 返回值为result字段
+var bybitAPI = testserver.Options{
+	Fixed: map[string]testserver.Route{
+		"/v5/market/time": func(r *testserver.Request) interface{} {
+			return map[string]interface{}{"retCode": 0, "time": time.Now().UnixNano() / 1e6}
+		},
+	},
+	Wrap: func(r *testserver.Request, result interface{}) interface{} {
+		return map[string]interface{}{"retCode": 0, "retMsg": "OK", "result": result}
+	},
+}
+
+func TestBybit_Sign(t *testing.T) {
+	var header http.Header
+	var body map[string]interface{}
+	srv := testserver.New(bybitAPI, map[string]testserver.Route{
+		"POST /v5/order/create": func(r *testserver.Request) interface{} {
+			b := r.JSON()
+			header, body = r.Header, b
+			return map[string]interface{}{"orderId": "1001"}
+		},
+	})
+	defer srv.Close()
+	config := testserver.Config(srv)
+
+	spot := NewSpot(config)
+	order, err := spot.LimitBuy("0.01", "20000", goex.BTC_USDT, goex.PostOnly)
+	assert.Nil(t, err)
+	assert.Equal(t, "1001", order.OrderID2)
+	assert.Equal(t, "key", header.Get("X-BAPI-API-KEY"))
+	assert.Equal(t, "5000", header.Get("X-BAPI-RECV-WINDOW"))
+	assert.Equal(t, "spot", body["category"])
+	assert.Equal(t, "BTCUSDT", body["symbol"])
+	assert.Equal(t, "PostOnly", body["timeInForce"])
+	assert.Equal(t, order.Cid, body["orderLinkId"])
+
+	data, _ := json.Marshal(body)
+	assert.Equal(t, header.Get("X-BAPI-SIGN"), spot.sign(header.Get("X-BAPI-TIMESTAMP"), string(data)))
+}
+
+func TestFutures_PositionMode(t *testing.T) {
+	var bodies []map[string]interface{}
+	srv := testserver.New(bybitAPI, map[string]testserver.Route{
+		"GET /v5/position/list": func(r *testserver.Request) interface{} {
+			if r.URL.Query().Get("symbol") == "ETHUSDT" {
+				return testserver.Response{Status: http.StatusOK, Body: `{"retCode":10006,"retMsg":"Too many visits!"}`}
+			}
+			if r.URL.Query().Get("symbol") == "BTCUSDT" {
+				return map[string]interface{}{"list": []map[string]interface{}{
+					{"symbol": "BTCUSDT", "positionIdx": 1, "side": "Buy", "size": "0.5", "avgPrice": "20000", "leverage": "10", "liqPrice": "18000"},
+					{"symbol": "BTCUSDT", "positionIdx": 2, "side": "Sell", "size": "0.2", "avgPrice": "21000", "leverage": "10"},
+				}}
+			}
+			return map[string]interface{}{"list": []map[string]interface{}{
+				{"symbol": "BTCUSD", "positionIdx": 0, "side": "Sell", "size": "100", "avgPrice": "20000", "leverage": "5"},
+			}}
+		},
+		"POST /v5/order/create": func(r *testserver.Request) interface{} {
+			body := r.JSON()
+			bodies = append(bodies, body)
+			return map[string]interface{}{"orderId": "1"}
+		},
+		"GET /v5/order/realtime": func(r *testserver.Request) interface{} {
+			return map[string]interface{}{"list": []map[string]interface{}{
+				{"orderId": "1", "symbol": "BTCUSD", "side": "Buy", "orderType": "Limit", "price": "19000", "qty": "100",
+					"cumExecQty": "40", "orderStatus": "PartiallyFilled", "timeInForce": "GTC", "positionIdx": 0, "reduceOnly": true},
+			}}
+		},
+	})
+	defer srv.Close()
+	config := testserver.Config(srv)
+
+	f := NewFutures(config)
+
+	//双向持仓: 平多为positionIdx=1的卖单
+	_, err := f.LimitFuturesOrder(goex.BTC_USDT, goex.SWAP_CONTRACT, "21000", "0.1", goex.CLOSE_BUY)
+	assert.Nil(t, err)
+	assert.Equal(t, "linear", bodies[0]["category"])
+	assert.Equal(t, "Sell", bodies[0]["side"])
+	assert.Equal(t, 1.0, bodies[0]["positionIdx"])
+	assert.Nil(t, bodies[0]["reduceOnly"])
+
+	//单向持仓: 平空为reduceOnly的买单
+	_, err = f.MarketFuturesOrder(goex.BTC_USD, goex.SWAP_CONTRACT, "100", goex.CLOSE_SELL)
+	assert.Nil(t, err)
+	assert.Equal(t, "inverse", bodies[1]["category"])
+	assert.Equal(t, "Buy", bodies[1]["side"])
+	assert.Equal(t, 0.0, bodies[1]["positionIdx"])
+	assert.Equal(t, true, bodies[1]["reduceOnly"])
+	assert.Equal(t, "Market", bodies[1]["orderType"])
+
+	//读取持仓模式失败时不下单
+	_, err = f.LimitFuturesOrder(goex.ETH_USDT, goex.SWAP_CONTRACT, "2000", "1", goex.OPEN_BUY)
+	assert.NotNil(t, err)
+	assert.Len(t, bodies, 2)
+
+	positions, err := f.GetFuturePosition(goex.BTC_USDT, goex.SWAP_CONTRACT)
+	assert.Nil(t, err)
+	assert.Len(t, positions, 1)
+	assert.Equal(t, 0.5, positions[0].BuyAmount)
+	assert.Equal(t, 0.2, positions[0].SellAmount)
+	assert.Equal(t, 21000.0, positions[0].SellPriceAvg)
+
+	orders, err := f.GetUnfinishFutureOrders(goex.BTC_USD, goex.SWAP_CONTRACT)
+	assert.Nil(t, err)
+	assert.Len(t, orders, 1)
+	assert.Equal(t, goex.CLOSE_SELL, orders[0].OType)
+	assert.Equal(t, goex.ORDER_PART_FINISH, orders[0].Status)
+	assert.Equal(t, 40.0, orders[0].DealAmount)
+}
+
+func TestWsClient_Handle(t *testing.T) {
+	ws := NewFuturesWs()
+	ws.subs["BTCUSDT"] = wsSubscription{pair: goex.BTC_USDT, contractType: goex.SWAP_CONTRACT}
+	c := ws.client(CategoryLinear)
+
+	var depth *goex.Depth
+	ws.DepthCallback(func(d *goex.Depth) {
+		depth = d
+	})
+	assert.Nil(t, c.handle([]byte(`{"topic":"orderbook.50.BTCUSDT","type":"snapshot","ts":1672304484978,"data":{"s":"BTCUSDT","b":[["16493.50","0.006"],["16493.00","0.100"]],"a":[["16611.00","0.029"],["16612.00","0.213"]]}}`)))
+	assert.Nil(t, c.handle([]byte(`{"topic":"orderbook.50.BTCUSDT","type":"delta","ts":1672304484979,"data":{"s":"BTCUSDT","b":[["16493.50","0"]],"a":[["16610.00","1.5"]]}}`)))
+	assert.Len(t, depth.BidList, 1)
+	assert.Equal(t, 16493.0, depth.BidList[0].Price)
+	assert.Len(t, depth.AskList, 3)
+	assert.Equal(t, 16612.0, depth.AskList[0].Price)
+	assert.Equal(t, 1.5, depth.AskList[2].Amount)
+	assert.Equal(t, goex.SWAP_CONTRACT, depth.ContractType)
+
+	var ticker *goex.FutureTicker
+	ws.TickerCallback(func(t *goex.FutureTicker) {
+		ticker = t
+	})
+	assert.Nil(t, c.handle([]byte(`{"topic":"tickers.BTCUSDT","type":"snapshot","ts":1673272861686,"data":{"symbol":"BTCUSDT","lastPrice":"17216.00","bid1Price":"17215.50","ask1Price":"17216.00","highPrice24h":"17281.50","openInterest":"68305.1"}}`)))
+	assert.Nil(t, c.handle([]byte(`{"topic":"tickers.BTCUSDT","type":"delta","ts":1673272861700,"data":{"symbol":"BTCUSDT","bid1Price":"17215.00"}}`)))
+	assert.Equal(t, 17216.0, ticker.Last)
+	assert.Equal(t, 17215.0, ticker.Buy)
+	assert.Equal(t, 68305.1, ticker.HoldAmount)
+	assert.True(t, ticker.Pair.Eq(goex.BTC_USDT))
+}
+
+func TestBybit_GetTrades(t *testing.T) {
+	srv := testserver.New(bybitAPI, map[string]testserver.Route{
+		"GET /v5/market/recent-trade": func(r *testserver.Request) interface{} {
+			if r.URL.Query().Get("category") == "spot" {
+				return map[string]interface{}{"category": "spot", "list": []map[string]string{
+					{"execId": "2100000000007764263", "symbol": "BTCUSDT", "price": "16618.49", "size": "0.00012", "side": "Buy", "time": "1672052955758"},
+				}}
+			}
+			return map[string]interface{}{"category": "linear", "list": []map[string]string{
+				{"execId": "b4af4bbc-5ba2-5e35-9b58-0e5bb56f1e32", "symbol": "BTCUSDT", "price": "16618.50", "size": "0.001", "side": "Sell", "time": "1672052955758"},
+				{"execId": "8dbb1a1a-1b1b-5c1c-8d1d-1e1f2a2b3c3d", "symbol": "BTCUSDT", "price": "16618.00", "size": "0.002", "side": "Buy", "time": "1672052955758"},
+			}}
+		},
+	})
+	defer srv.Close()
+	config := testserver.Config(srv)
+
+	trades, err := NewSpot(config).GetTrades(goex.BTC_USDT, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2100000000007764263), trades[0].Tid)
+
+	//uuid的execId转为非负且不同的hash
+	trades, err = NewFutures(config).GetTrades(goex.SWAP_CONTRACT, goex.BTC_USDT, 0)
+	assert.Nil(t, err)
+	assert.Len(t, trades, 2)
+	assert.Equal(t, goex.SELL, trades[0].Type)
+	assert.True(t, trades[0].Tid > 0)
+	assert.True(t, trades[1].Tid > 0)
+	assert.NotEqual(t, trades[0].Tid, trades[1].Tid)
+	assert.Equal(t, tradeId("b4af4bbc-5ba2-5e35-9b58-0e5bb56f1e32"), trades[0].Tid)
+
+	trade := adaptWsTrade(wsTrade{I: "b4af4bbc-5ba2-5e35-9b58-0e5bb56f1e32", T: 1672052955758, D: "Sell", V: "0.001", P: "16618.50"}, goex.BTC_USDT)
+	assert.Equal(t, trades[0].Tid, trade.Tid)
+}
@@ -0,0 +1,632 @@
+package bybit
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/lucas7788/goex"
+)
+
+const (
+	PosModeOneWay = "one_way" //单向持仓, positionIdx=0
+	PosModeHedge  = "hedge"   //双向持仓, 多仓positionIdx=1, 空仓positionIdx=2
+)
+
+/**
+ * U本位(linear)和币本位(inverse)合约, 实现FutureRestAPI
+ * 按currencyPair的计价币区分: BTC_USDT => linear BTCUSDT, BTC_USD => inverse BTCUSD
+ * contractType:
+ *   swap, swap-usdt 永续合约
+ *   quarter/bi_quarter 币本位交割合约, 自动转换为具体的合约, 如 BTCUSDH21
+ *   其他字符串直接作为合约名称
+ * 数量与bybit一致: U本位为币的数量, 币本位为张数(1张=1美元)
+ * PosMode 为空时按合约从持仓接口读取一次
+ */
+type Futures struct {
+	*Bybit
+	PosMode string
+
+	lock     sync.Mutex
+	posModes map[string]string       //symbol => 持仓模式
+	futures  map[string][]instrument //币种 => 未到期的币本位交割合约, 按交割时间排序
+}
+
+type instrument struct {
+	Symbol       string `json:"symbol"`
+	ContractType string `json:"contractType"`
+	BaseCoin     string `json:"baseCoin"`
+	QuoteCoin    string `json:"quoteCoin"`
+	DeliveryTime string `json:"deliveryTime"`
+	Status       string `json:"status"`
+}
+
+func NewFutures(config *APIConfig) *Futures {
+	return &Futures{
+		Bybit:    New(config),
+		posModes: make(map[string]string, 4),
+		futures:  make(map[string][]instrument, 2),
+	}
+}
+
+func (f *Futures) GetExchangeName() string {
+	return BYBIT
+}
+
+func category(pair CurrencyPair) string {
+	if pair.CurrencyB.Eq(USD) {
+		return CategoryInverse
+	}
+	return CategoryLinear
+}
+
+//最近的合约交割前缓存有效
+func (f *Futures) getInverseFutures(coin string) ([]instrument, error) {
+	f.lock.Lock()
+	futures := f.futures[coin]
+	f.lock.Unlock()
+	if len(futures) > 0 && ToInt64(futures[0].DeliveryTime) > time.Now().UnixNano()/int64(time.Millisecond) {
+		return futures, nil
+	}
+
+	params := url.Values{}
+	params.Set("category", CategoryInverse)
+	params.Set("baseCoin", coin)
+	var resp struct {
+		List []instrument `json:"list"`
+	}
+	err := f.doRequest(http.MethodGet, "/v5/market/instruments-info", params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	futures = futures[:0:0]
+	for _, ins := range resp.List {
+		if ins.ContractType == "InverseFutures" && ins.Status == "Trading" {
+			futures = append(futures, ins)
+		}
+	}
+	sort.Slice(futures, func(i, j int) bool {
+		return ToInt64(futures[i].DeliveryTime) < ToInt64(futures[j].DeliveryTime)
+	})
+
+	f.lock.Lock()
+	f.futures[coin] = futures
+	f.lock.Unlock()
+	return futures, nil
+}
+
+//返回category和合约名称
+func (f *Futures) adaptSymbol(pair CurrencyPair, contractType string) (string, string, error) {
+	cat := category(pair)
+	switch contractType {
+	case "", SWAP_CONTRACT, SWAP_USDT_CONTRACT:
+		return cat, toSymbol(pair), nil
+	case QUARTER_CONTRACT, BI_QUARTER_CONTRACT:
+		if cat != CategoryInverse {
+			return "", "", fmt.Errorf("%s contract is only for inverse futures", contractType)
+		}
+		coin := strings.ToUpper(pair.CurrencyA.Symbol)
+		futures, err := f.getInverseFutures(coin)
+		if err != nil {
+			return "", "", err
+		}
+		index := 0
+		if contractType == BI_QUARTER_CONTRACT {
+			index = 1
+		}
+		if index >= len(futures) {
+			return "", "", fmt.Errorf("no %s contract for %s", contractType, coin)
+		}
+		return cat, futures[index].Symbol, nil
+	case THIS_WEEK_CONTRACT, NEXT_WEEK_CONTRACT:
+		return "", "", EX_ERR_NOT_SUPPORT.OriginErr(contractType + " contract not support")
+	}
+	return cat, strings.ToUpper(contractType), nil
+}
+
+//持仓模式, 未设置PosMode时按合约读取一次持仓, 读取失败时返回错误, 避免按错误的模式下单
+func (f *Futures) posMode(cat, symbol string) (string, error) {
+	if f.PosMode != "" {
+		return f.PosMode, nil
+	}
+
+	f.lock.Lock()
+	mode, ok := f.posModes[symbol]
+	f.lock.Unlock()
+	if ok {
+		return mode, nil
+	}
+
+	positions, err := f.getPositions(cat, symbol)
+	if err != nil {
+		return "", err
+	}
+	mode = PosModeOneWay
+	for _, p := range positions {
+		if p.PositionIdx != 0 {
+			mode = PosModeHedge
+		}
+	}
+	f.lock.Lock()
+	f.posModes[symbol] = mode
+	f.lock.Unlock()
+	return mode, nil
+}
+
+//切换持仓模式, 有持仓或挂单时bybit会拒绝
+func (f *Futures) SwitchPositionMode(pair CurrencyPair, contractType, posMode string) error {
+	cat, symbol, err := f.adaptSymbol(pair, contractType)
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	params.Set("category", cat)
+	params.Set("symbol", symbol)
+	params.Set("mode", "0")
+	if posMode == PosModeHedge {
+		params.Set("mode", "3")
+	}
+	err = f.doRequest(http.MethodPost, "/v5/position/switch-mode", params, nil)
+	if err != nil {
+		return err
+	}
+	f.lock.Lock()
+	f.posModes[symbol] = posMode
+	f.lock.Unlock()
+	return nil
+}
+
+//openType => side, positionIdx, reduceOnly
+func adaptOpenType(openType int, posMode string) (side string, positionIdx int, reduceOnly bool, err error) {
+	switch openType {
+	case OPEN_BUY:
+		side, positionIdx = "Buy", 1
+	case OPEN_SELL:
+		side, positionIdx = "Sell", 2
+	case CLOSE_BUY:
+		side, positionIdx = "Sell", 1
+	case CLOSE_SELL:
+		side, positionIdx = "Buy", 2
+	default:
+		return "", 0, false, fmt.Errorf("unknown open type: %d", openType)
+	}
+	if posMode != PosModeHedge {
+		positionIdx = 0
+		reduceOnly = openType == CLOSE_BUY || openType == CLOSE_SELL
+	}
+	return
+}
+
+func adaptPositionIdxToOpenType(side string, positionIdx int, reduceOnly bool) int {
+	switch {
+	case positionIdx == 1 && side == "Buy":
+		return OPEN_BUY
+	case positionIdx == 1:
+		return CLOSE_BUY
+	case positionIdx == 2 && side == "Sell":
+		return OPEN_SELL
+	case positionIdx == 2:
+		return CLOSE_SELL
+	}
+	//单向持仓按reduceOnly区分开平
+	if side == "Buy" {
+		if reduceOnly {
+			return CLOSE_SELL
+		}
+		return OPEN_BUY
+	}
+	if reduceOnly {
+		return CLOSE_BUY
+	}
+	return OPEN_SELL
+}
+
+func (f *Futures) GetFutureTicker(currencyPair CurrencyPair, contractType string) (*Ticker, error) {
+	cat, symbol, err := f.adaptSymbol(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	t, err := f.getTicker(cat, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return &Ticker{
+		Pair: currencyPair,
+		Last: ToFloat64(t.LastPrice),
+		Buy:  ToFloat64(t.Bid1Price),
+		Sell: ToFloat64(t.Ask1Price),
+		High: ToFloat64(t.HighPrice24h),
+		Low:  ToFloat64(t.LowPrice24h),
+		Vol:  ToFloat64(t.Volume24h),
+		Date: uint64(time.Now().UnixNano() / int64(time.Millisecond)),
+	}, nil
+}
+
+func (f *Futures) GetFutureDepth(currencyPair CurrencyPair, contractType string, size int) (*Depth, error) {
+	cat, symbol, err := f.adaptSymbol(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	bids, asks, ts, err := f.getDepth(cat, symbol, size)
+	if err != nil {
+		return nil, err
+	}
+	return &Depth{
+		ContractType: contractType,
+		ContractId:   symbol,
+		Pair:         currencyPair,
+		UTime:        time.Unix(0, ts*int64(time.Millisecond)),
+		BidList:      bids,
+		AskList:      asks,
+	}, nil
+}
+
+//永续合约ticker中的指数价格
+func (f *Futures) GetFutureIndex(currencyPair CurrencyPair) (float64, error) {
+	t, err := f.getTicker(category(currencyPair), toSymbol(currencyPair))
+	if err != nil {
+		return 0, err
+	}
+	return ToFloat64(t.IndexPrice), nil
+}
+
+//当季合约的预估交割价, 只支持币本位
+func (f *Futures) GetFutureEstimatedPrice(currencyPair CurrencyPair) (float64, error) {
+	cat, symbol, err := f.adaptSymbol(currencyPair, QUARTER_CONTRACT)
+	if err != nil {
+		return 0, err
+	}
+	var resp struct {
+		List []struct {
+			PredictedDeliveryPrice string `json:"predictedDeliveryPrice"`
+		} `json:"list"`
+	}
+	err = f.doRequest(http.MethodGet, "/v5/market/tickers", url.Values{"category": {cat}, "symbol": {symbol}}, &resp)
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.List) == 0 {
+		return 0, EX_ERR_SYMBOL_ERR.OriginErr("no ticker: " + symbol)
+	}
+	return ToFloat64(resp.List[0].PredictedDeliveryPrice), nil
+}
+
+//统一交易账户, U本位合约的保证金为USDT, 币本位为对应的币; 不传currencyPair时返回所有币种
+func (f *Futures) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureAccount, error) {
+	var resp struct {
+		List []struct {
+			Coin []struct {
+				Coin            string `json:"coin"`
+				Equity          string `json:"equity"`
+				TotalPositionIM string `json:"totalPositionIM"`
+				TotalPositionMM string `json:"totalPositionMM"`
+				UnrealisedPnl   string `json:"unrealisedPnl"`
+				CumRealisedPnl  string `json:"cumRealisedPnl"`
+			} `json:"coin"`
+		} `json:"list"`
+	}
+	err := f.doRequest(http.MethodGet, "/v5/account/wallet-balance", url.Values{"accountType": {"UNIFIED"}}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(currencyPair))
+	for _, pair := range currencyPair {
+		coin := pair.CurrencyB
+		if category(pair) == CategoryInverse {
+			coin = pair.CurrencyA
+		}
+		wanted[strings.ToUpper(coin.Symbol)] = true
+	}
+
+	acc := &FutureAccount{FutureSubAccounts: make(map[Currency]FutureSubAccount, 4)}
+	for _, itm := range resp.List {
+		for _, c := range itm.Coin {
+			if len(wanted) > 0 && !wanted[strings.ToUpper(c.Coin)] {
+				continue
+			}
+			currency := NewCurrency(c.Coin, "")
+			sub := FutureSubAccount{
+				Currency:      currency,
+				AccountRights: ToFloat64(c.Equity),
+				KeepDeposit:   ToFloat64(c.TotalPositionIM),
+				ProfitReal:    ToFloat64(c.CumRealisedPnl),
+				ProfitUnreal:  ToFloat64(c.UnrealisedPnl),
+			}
+			if sub.AccountRights > 0 {
+				sub.RiskRate = ToFloat64(c.TotalPositionMM) / sub.AccountRights
+			}
+			acc.FutureSubAccounts[currency] = sub
+		}
+	}
+	return acc, nil
+}
+
+func (f *Futures) placeOrder(pair CurrencyPair, contractType, price, amount string, openType int, isMarket bool, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	cat, symbol, err := f.adaptSymbol(pair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	posMode, err := f.posMode(cat, symbol)
+	if err != nil {
+		return nil, err
+	}
+	side, positionIdx, reduceOnly, err := adaptOpenType(openType, posMode)
+	if err != nil {
+		return nil, err
+	}
+
+	fOrder := &FutureOrder{
+		ClientOid:    GenerateOrderClientId(32),
+		Currency:     pair,
+		Price:        ToFloat64(price),
+		Amount:       ToFloat64(amount),
+		OType:        openType,
+		OrderType:    ORDER_FEATURE_ORDINARY,
+		ContractName: contractType,
+		OrderTime:    time.Now().UnixNano() / int64(time.Millisecond),
+	}
+
+	params := url.Values{}
+	params.Set("category", cat)
+	params.Set("symbol", symbol)
+	params.Set("side", side)
+	params.Set("qty", amount)
+	params.Set("positionIdx", fmt.Sprint(positionIdx))
+	params.Set("orderLinkId", fOrder.ClientOid)
+	if reduceOnly {
+		params.Set("reduceOnly", "true")
+	}
+	if isMarket {
+		params.Set("orderType", "Market")
+		fOrder.Price = 0
+	} else {
+		tif, feature := timeInForce(opt...)
+		params.Set("orderType", "Limit")
+		params.Set("price", price)
+		params.Set("timeInForce", tif)
+		fOrder.OrderType = feature
+	}
+
+	var resp struct {
+		OrderId string `json:"orderId"`
+	}
+	err = f.doRequest(http.MethodPost, "/v5/order/create", params, &resp)
+	if err != nil {
+		return fOrder, err
+	}
+	fOrder.OrderID2 = resp.OrderId
+	return fOrder, nil
+}
+
+//杠杆倍数在bybit按合约设置, 下单时不使用leverRate
+func (f *Futures) PlaceFutureOrder(currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice int, leverRate float64) (string, error) {
+	fOrder, err := f.placeOrder(currencyPair, contractType, price, amount, openType, matchPrice == 1)
+	if err != nil {
+		return "", err
+	}
+	return fOrder.OrderID2, nil
+}
+
+func (f *Futures) LimitFuturesOrder(currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	return f.placeOrder(currencyPair, contractType, price, amount, openType, false, opt...)
+}
+
+func (f *Futures) MarketFuturesOrder(currencyPair CurrencyPair, contractType, amount string, openType int) (*FutureOrder, error) {
+	return f.placeOrder(currencyPair, contractType, "0", amount, openType, true)
+}
+
+func (f *Futures) FutureCancelOrder(currencyPair CurrencyPair, contractType, orderId string) (bool, error) {
+	cat, symbol, err := f.adaptSymbol(currencyPair, contractType)
+	if err != nil {
+		return false, err
+	}
+	if err = f.cancelOrder(cat, symbol, orderId); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+type positionInfo struct {
+	Symbol         string `json:"symbol"`
+	PositionIdx    int    `json:"positionIdx"`
+	Side           string `json:"side"`
+	Size           string `json:"size"`
+	AvgPrice       string `json:"avgPrice"`
+	LiqPrice       string `json:"liqPrice"`
+	Leverage       string `json:"leverage"`
+	UnrealisedPnl  string `json:"unrealisedPnl"`
+	CumRealisedPnl string `json:"cumRealisedPnl"`
+	CreatedTime    string `json:"createdTime"`
+}
+
+func (f *Futures) getPositions(cat, symbol string) ([]positionInfo, error) {
+	params := url.Values{}
+	params.Set("category", cat)
+	params.Set("symbol", symbol)
+	var resp struct {
+		List []positionInfo `json:"list"`
+	}
+	err := f.doRequest(http.MethodGet, "/v5/position/list", params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.List, nil
+}
+
+//单向和双向持仓都合并为一条FuturePosition
+func (f *Futures) GetFuturePosition(currencyPair CurrencyPair, contractType string) ([]FuturePosition, error) {
+	cat, symbol, err := f.adaptSymbol(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	positions, err := f.getPositions(cat, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := FuturePosition{Symbol: currencyPair, ContractType: contractType}
+	for _, p := range positions {
+		size := ToFloat64(p.Size)
+		if size == 0 {
+			continue
+		}
+		pos.LeverRate = ToFloat64(p.Leverage)
+		pos.CreateDate = ToInt64(p.CreatedTime)
+		if p.PositionIdx == 1 || (p.PositionIdx == 0 && p.Side == "Buy") {
+			pos.BuyAmount = size
+			pos.BuyAvailable = size
+			pos.BuyPriceAvg = ToFloat64(p.AvgPrice)
+			pos.BuyPriceCost = pos.BuyPriceAvg
+			pos.BuyProfit = ToFloat64(p.UnrealisedPnl)
+			pos.BuyProfitReal = ToFloat64(p.CumRealisedPnl)
+		} else {
+			pos.SellAmount = size
+			pos.SellAvailable = size
+			pos.SellPriceAvg = ToFloat64(p.AvgPrice)
+			pos.SellPriceCost = pos.SellPriceAvg
+			pos.SellProfit = ToFloat64(p.UnrealisedPnl)
+			pos.SellProfitReal = ToFloat64(p.CumRealisedPnl)
+		}
+		pos.ForceLiquPrice = ToFloat64(p.LiqPrice)
+	}
+	if pos.BuyAmount == 0 && pos.SellAmount == 0 {
+		return nil, nil
+	}
+	return []FuturePosition{pos}, nil
+}
+
+func (f *Futures) adaptOrder(pair CurrencyPair, contractType string, o orderInfo) FutureOrder {
+	order := FutureOrder{
+		ClientOid:    o.OrderLinkId,
+		OrderID2:     o.OrderId,
+		Price:        ToFloat64(o.Price),
+		Amount:       ToFloat64(o.Qty),
+		AvgPrice:     ToFloat64(o.AvgPrice),
+		DealAmount:   ToFloat64(o.CumExecQty),
+		OrderTime:    ToInt64(o.CreatedTime),
+		Status:       adaptOrderStatus(o.OrderStatus),
+		Currency:     pair,
+		OrderType:    adaptTimeInForce(o.TimeInForce),
+		OType:        adaptPositionIdxToOpenType(o.Side, o.PositionIdx, o.ReduceOnly),
+		Fee:          ToFloat64(o.CumExecFee),
+		ContractName: contractType,
+	}
+	if order.Status == ORDER_FINISH || order.Status == ORDER_CANCEL || order.Status == ORDER_REJECT {
+		order.FinishedTime = ToInt64(o.UpdatedTime)
+	}
+	return order
+}
+
+func (f *Futures) GetFutureOrder(orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error) {
+	cat, symbol, err := f.adaptSymbol(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	o, err := f.getOrder(cat, symbol, orderId)
+	if err != nil {
+		return nil, err
+	}
+	order := f.adaptOrder(currencyPair, contractType, *o)
+	return &order, nil
+}
+
+func (f *Futures) GetFutureOrders(orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	orders := make([]FutureOrder, 0, len(orderIds))
+	for _, id := range orderIds {
+		order, err := f.GetFutureOrder(id, currencyPair, contractType)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+	return orders, nil
+}
+
+func (f *Futures) listOrders(path string, pair CurrencyPair, contractType string, optional ...OptionalParameter) ([]FutureOrder, error) {
+	cat, symbol, err := f.adaptSymbol(pair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("category", cat)
+	params.Set("symbol", symbol)
+	MergeOptionalParameter(&params, optional...)
+	list, err := f.getOrders(path, params)
+	if err != nil {
+		return nil, err
+	}
+	orders := make([]FutureOrder, 0, len(list))
+	for _, o := range list {
+		orders = append(orders, f.adaptOrder(pair, contractType, o))
+	}
+	return orders, nil
+}
+
+func (f *Futures) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	return f.listOrders("/v5/order/realtime", currencyPair, contractType)
+}
+
+//optional支持limit, startTime, endTime, cursor
+func (f *Futures) GetFutureOrderHistory(pair CurrencyPair, contractType string, optional ...OptionalParameter) ([]FutureOrder, error) {
+	return f.listOrders("/v5/order/history", pair, contractType, optional...)
+}
+
+//非VIP的taker手续费
+func (f *Futures) GetFee() (float64, error) {
+	return 0.00055, nil
+}
+
+//U本位为1个币, 币本位为1美元
+func (f *Futures) GetContractValue(currencyPair CurrencyPair) (float64, error) {
+	return 1, nil
+}
+
+//币本位交割合约在交割日(周五) 08:00 UTC 交割
+func (f *Futures) GetDeliveryTime() (int, int, int, int) {
+	return 5, 8, 0, 0
+}
+
+func (f *Futures) GetKlineRecords(contractType string, currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]FutureKline, error) {
+	cat, symbol, err := f.adaptSymbol(currency, contractType)
+	if err != nil {
+		return nil, err
+	}
+	records, err := f.getKlines(cat, symbol, period, size, optional...)
+	if err != nil {
+		return nil, err
+	}
+
+	//volume为下单数量的单位, turnover为另一侧(U本位为USDT, 币本位为币)
+	klines := make([]FutureKline, 0, len(records))
+	for _, r := range records {
+		if len(r) < 7 {
+			continue
+		}
+		klines = append(klines, FutureKline{
+			Kline: &Kline{
+				Pair:      currency,
+				Timestamp: ToInt64(r[0]) / 1000,
+				Open:      ToFloat64(r[1]),
+				High:      ToFloat64(r[2]),
+				Low:       ToFloat64(r[3]),
+				Close:     ToFloat64(r[4]),
+				Vol:       ToFloat64(r[5]),
+			},
+			Vol2: ToFloat64(r[6]),
+		})
+	}
+	return klines, nil
+}
+
+func (f *Futures) GetTrades(contractType string, currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	cat, symbol, err := f.adaptSymbol(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	return f.getTrades(cat, symbol, currencyPair)
+}
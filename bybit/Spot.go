@@ -0,0 +1,261 @@
+package bybit
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	. "github.com/lucas7788/goex"
+)
+
+/**
+ * 现货, 实现API接口
+ * 账户为统一交易账户(UNIFIED), 市价买单的数量为基础币数量(marketUnit=baseCoin)
+ */
+type Spot struct {
+	*Bybit
+}
+
+func NewSpot(config *APIConfig) *Spot {
+	return &Spot{Bybit: New(config)}
+}
+
+func (s *Spot) GetExchangeName() string {
+	return BYBIT
+}
+
+func (s *Spot) placeOrder(amount, price string, pair CurrencyPair, orderType string, side TradeSide, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	order := &Order{
+		Cid:       GenerateOrderClientId(32),
+		Price:     ToFloat64(price),
+		Amount:    ToFloat64(amount),
+		Currency:  pair,
+		Side:      side,
+		Type:      "limit",
+		OrderTime: int(time.Now().UnixNano() / int64(time.Millisecond)),
+	}
+
+	params := url.Values{}
+	params.Set("category", CategorySpot)
+	params.Set("symbol", toSymbol(pair))
+	params.Set("side", "Buy")
+	if side == SELL || side == SELL_MARKET {
+		params.Set("side", "Sell")
+	}
+	params.Set("orderType", orderType)
+	params.Set("qty", amount)
+	params.Set("orderLinkId", order.Cid)
+	if orderType == "Limit" {
+		tif, feature := timeInForce(opt...)
+		params.Set("price", price)
+		params.Set("timeInForce", tif)
+		order.OrderType = feature
+	} else {
+		params.Set("marketUnit", "baseCoin")
+		order.Type = "market"
+		order.Price = 0
+	}
+
+	var resp struct {
+		OrderId string `json:"orderId"`
+	}
+	err := s.doRequest(http.MethodPost, "/v5/order/create", params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	order.OrderID2 = resp.OrderId
+	return order, nil
+}
+
+func (s *Spot) LimitBuy(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	return s.placeOrder(amount, price, currency, "Limit", BUY, opt...)
+}
+
+func (s *Spot) LimitSell(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	return s.placeOrder(amount, price, currency, "Limit", SELL, opt...)
+}
+
+func (s *Spot) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
+	return s.placeOrder(amount, price, currency, "Market", BUY_MARKET)
+}
+
+func (s *Spot) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
+	return s.placeOrder(amount, price, currency, "Market", SELL_MARKET)
+}
+
+func (s *Spot) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
+	err := s.cancelOrder(CategorySpot, toSymbol(currency), orderId)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *Spot) adaptOrder(pair CurrencyPair, o orderInfo) Order {
+	side := BUY
+	switch {
+	case o.Side == "Buy" && o.OrderType == "Market":
+		side = BUY_MARKET
+	case o.Side == "Sell" && o.OrderType == "Market":
+		side = SELL_MARKET
+	case o.Side == "Sell":
+		side = SELL
+	}
+	orderType := "limit"
+	if o.OrderType == "Market" {
+		orderType = "market"
+	}
+
+	order := Order{
+		Price:      ToFloat64(o.Price),
+		Amount:     ToFloat64(o.Qty),
+		AvgPrice:   ToFloat64(o.AvgPrice),
+		DealAmount: ToFloat64(o.CumExecQty),
+		Fee:        ToFloat64(o.CumExecFee),
+		Cid:        o.OrderLinkId,
+		OrderID2:   o.OrderId,
+		Status:     adaptOrderStatus(o.OrderStatus),
+		Currency:   pair,
+		Side:       side,
+		Type:       orderType,
+		OrderType:  adaptTimeInForce(o.TimeInForce),
+		OrderTime:  ToInt(o.CreatedTime),
+	}
+	if order.Status == ORDER_FINISH || order.Status == ORDER_CANCEL || order.Status == ORDER_REJECT {
+		order.FinishedTime = ToInt64(o.UpdatedTime)
+	}
+	return order
+}
+
+func (s *Spot) adaptOrders(pair CurrencyPair, orders []orderInfo) []Order {
+	result := make([]Order, 0, len(orders))
+	for _, o := range orders {
+		result = append(result, s.adaptOrder(pair, o))
+	}
+	return result
+}
+
+func (s *Spot) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
+	o, err := s.getOrder(CategorySpot, toSymbol(currency), orderId)
+	if err != nil {
+		return nil, err
+	}
+	order := s.adaptOrder(currency, *o)
+	return &order, nil
+}
+
+func (s *Spot) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
+	params := url.Values{}
+	params.Set("category", CategorySpot)
+	params.Set("symbol", toSymbol(currency))
+	orders, err := s.getOrders("/v5/order/realtime", params)
+	if err != nil {
+		return nil, err
+	}
+	return s.adaptOrders(currency, orders), nil
+}
+
+//opt支持limit, startTime, endTime, cursor
+func (s *Spot) GetOrderHistorys(currency CurrencyPair, opt ...OptionalParameter) ([]Order, error) {
+	params := url.Values{}
+	params.Set("category", CategorySpot)
+	params.Set("symbol", toSymbol(currency))
+	MergeOptionalParameter(&params, opt...)
+	orders, err := s.getOrders("/v5/order/history", params)
+	if err != nil {
+		return nil, err
+	}
+	return s.adaptOrders(currency, orders), nil
+}
+
+func (s *Spot) GetAccount() (*Account, error) {
+	var resp struct {
+		List []struct {
+			TotalEquity string `json:"totalEquity"`
+			Coin        []struct {
+				Coin          string `json:"coin"`
+				WalletBalance string `json:"walletBalance"`
+				Locked        string `json:"locked"`
+				BorrowAmount  string `json:"borrowAmount"`
+			} `json:"coin"`
+		} `json:"list"`
+	}
+	err := s.doRequest(http.MethodGet, "/v5/account/wallet-balance", url.Values{"accountType": {"UNIFIED"}}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &Account{Exchange: s.GetExchangeName(), SubAccounts: make(map[Currency]SubAccount, 8)}
+	for _, itm := range resp.List {
+		acc.NetAsset = ToFloat64(itm.TotalEquity)
+		acc.Asset = acc.NetAsset
+		for _, c := range itm.Coin {
+			currency := NewCurrency(c.Coin, "")
+			locked := ToFloat64(c.Locked)
+			acc.SubAccounts[currency] = SubAccount{
+				Currency:     currency,
+				Amount:       ToFloat64(c.WalletBalance) - locked,
+				ForzenAmount: locked,
+				LoanAmount:   ToFloat64(c.BorrowAmount),
+			}
+		}
+	}
+	return acc, nil
+}
+
+func (s *Spot) GetTicker(currency CurrencyPair) (*Ticker, error) {
+	t, err := s.getTicker(CategorySpot, toSymbol(currency))
+	if err != nil {
+		return nil, err
+	}
+	return &Ticker{
+		Pair: currency,
+		Last: ToFloat64(t.LastPrice),
+		Buy:  ToFloat64(t.Bid1Price),
+		Sell: ToFloat64(t.Ask1Price),
+		High: ToFloat64(t.HighPrice24h),
+		Low:  ToFloat64(t.LowPrice24h),
+		Vol:  ToFloat64(t.Volume24h),
+		Date: uint64(time.Now().UnixNano() / int64(time.Millisecond)),
+	}, nil
+}
+
+func (s *Spot) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
+	bids, asks, ts, err := s.getDepth(CategorySpot, toSymbol(currency), size)
+	if err != nil {
+		return nil, err
+	}
+	return &Depth{
+		Pair:    currency,
+		UTime:   time.Unix(0, ts*int64(time.Millisecond)),
+		BidList: bids,
+		AskList: asks,
+	}, nil
+}
+
+func (s *Spot) GetKlineRecords(currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
+	records, err := s.getKlines(CategorySpot, toSymbol(currency), period, size, optional...)
+	if err != nil {
+		return nil, err
+	}
+	klines := make([]Kline, 0, len(records))
+	for _, r := range records {
+		if len(r) < 6 {
+			continue
+		}
+		klines = append(klines, Kline{
+			Pair:      currency,
+			Timestamp: ToInt64(r[0]) / 1000,
+			Open:      ToFloat64(r[1]),
+			High:      ToFloat64(r[2]),
+			Low:       ToFloat64(r[3]),
+			Close:     ToFloat64(r[4]),
+			Vol:       ToFloat64(r[5]),
+		})
+	}
+	return klines, nil
+}
+
+func (s *Spot) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	return s.getTrades(CategorySpot, toSymbol(currencyPair), currencyPair)
+}
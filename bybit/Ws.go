@@ -0,0 +1,436 @@
+package bybit
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/logger"
+)
+
+const (
+	wsUrl        = "wss://stream.bybit.com/v5/public/"
+	TestnetWsUrl = "wss://stream-testnet.bybit.com/v5/public/"
+
+	wsPingInterval = 20 * time.Second
+)
+
+type wsOp struct {
+	Op   string   `json:"op"`
+	Args []string `json:"args,omitempty"`
+}
+
+type wsMessage struct {
+	Topic   string          `json:"topic"`
+	Type    string          `json:"type"` //snapshot, delta
+	Ts      int64           `json:"ts"`
+	Data    json.RawMessage `json:"data"`
+	Op      string          `json:"op"`
+	Success *bool           `json:"success"`
+	RetMsg  string          `json:"ret_msg"`
+}
+
+type wsTrade struct {
+	I string `json:"i"` //成交id
+	T int64  `json:"T"`
+	S string `json:"s"`
+	D string `json:"S"` //Buy, Sell
+	V string `json:"v"`
+	P string `json:"p"`
+}
+
+//本地订单簿, snapshot时重建, delta时数量为0删除档位
+type wsBook struct {
+	bids map[float64]float64
+	asks map[float64]float64
+}
+
+func (b *wsBook) update(levels [][2]string, side map[float64]float64) {
+	for _, l := range levels {
+		price, amount := ToFloat64(l[0]), ToFloat64(l[1])
+		if amount == 0 {
+			delete(side, price)
+		} else {
+			side[price] = amount
+		}
+	}
+}
+
+//bids, asks均为降序
+func (b *wsBook) records() (bids, asks DepthRecords) {
+	for p, a := range b.bids {
+		bids = append(bids, DepthRecord{Price: p, Amount: a})
+	}
+	for p, a := range b.asks {
+		asks = append(asks, DepthRecord{Price: p, Amount: a})
+	}
+	sort.Sort(sort.Reverse(bids))
+	sort.Sort(sort.Reverse(asks))
+	return
+}
+
+/**
+ * v5 公共行情websocket, 每个category(spot/linear/inverse)一个连接
+ * 每20秒发送一次{"op":"ping"}, 深度订阅orderbook.50.{symbol}, 增量数据在本地合并
+ * 合约的tickers推送的delta只包含变化的字段, 同样在本地合并
+ */
+type wsClient struct {
+	c         *WsConn
+	connErr   error
+	once      sync.Once
+	wsBuilder *WsBuilder
+
+	lock    sync.Mutex
+	books   map[string]*wsBook
+	tickers map[string]*tickerInfo
+
+	onDepth  func(symbol string, bids, asks DepthRecords, ts int64)
+	onTicker func(ticker *tickerInfo, ts int64)
+	onTrade  func(trades []wsTrade)
+}
+
+func newWsClient(url string) *wsClient {
+	c := &wsClient{
+		books:   make(map[string]*wsBook, 4),
+		tickers: make(map[string]*tickerInfo, 4),
+	}
+	c.wsBuilder = NewWsBuilder().WsUrl(url).ProtoHandleFunc(c.handle).AutoReconnect().
+		Heartbeat(func() []byte {
+			data, _ := json.Marshal(wsOp{Op: "ping"})
+			return data
+		}, wsPingInterval)
+	return c
+}
+
+func (c *wsClient) subscribe(topic string) error {
+	c.once.Do(func() {
+		c.c, c.connErr = c.wsBuilder.Build()
+	})
+	if c.connErr != nil {
+		return c.connErr
+	}
+	return c.c.Subscribe(wsOp{Op: "subscribe", Args: []string{topic}})
+}
+
+func (c *wsClient) close() {
+	if c.c != nil {
+		c.c.CloseWs()
+	}
+}
+
+func (c *wsClient) handle(data []byte) error {
+	var msg wsMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		logger.Errorf("[bybit ws] unmarshal error, message: %s", string(data))
+		return err
+	}
+	if msg.Topic == "" {
+		if msg.Success != nil && !*msg.Success {
+			logger.Errorf("[bybit ws] %s error: %s", msg.Op, msg.RetMsg)
+		}
+		return nil
+	}
+
+	switch {
+	case strings.HasPrefix(msg.Topic, "orderbook."):
+		var book struct {
+			S string      `json:"s"`
+			B [][2]string `json:"b"`
+			A [][2]string `json:"a"`
+		}
+		if err := json.Unmarshal(msg.Data, &book); err != nil {
+			return err
+		}
+		c.lock.Lock()
+		b, ok := c.books[book.S]
+		if !ok || msg.Type == "snapshot" {
+			b = &wsBook{bids: make(map[float64]float64, 64), asks: make(map[float64]float64, 64)}
+			c.books[book.S] = b
+		}
+		b.update(book.B, b.bids)
+		b.update(book.A, b.asks)
+		bids, asks := b.records()
+		c.lock.Unlock()
+		if c.onDepth != nil {
+			c.onDepth(book.S, bids, asks, msg.Ts)
+		}
+	case strings.HasPrefix(msg.Topic, "tickers."):
+		symbol := strings.TrimPrefix(msg.Topic, "tickers.")
+		c.lock.Lock()
+		t, ok := c.tickers[symbol]
+		if !ok || msg.Type == "snapshot" {
+			t = &tickerInfo{}
+			c.tickers[symbol] = t
+		}
+		err := json.Unmarshal(msg.Data, t)
+		ticker := *t
+		c.lock.Unlock()
+		if err != nil {
+			return err
+		}
+		if c.onTicker != nil {
+			c.onTicker(&ticker, msg.Ts)
+		}
+	case strings.HasPrefix(msg.Topic, "publicTrade."):
+		var trades []wsTrade
+		if err := json.Unmarshal(msg.Data, &trades); err != nil {
+			return err
+		}
+		if c.onTrade != nil {
+			c.onTrade(trades)
+		}
+	}
+	return nil
+}
+
+func adaptWsTrade(t wsTrade, pair CurrencyPair) *Trade {
+	side := BUY
+	if t.D == "Sell" {
+		side = SELL
+	}
+	return &Trade{
+		Tid:    tradeId(t.I),
+		Type:   side,
+		Amount: ToFloat64(t.V),
+		Price:  ToFloat64(t.P),
+		Date:   t.T,
+		Pair:   pair,
+	}
+}
+
+func adaptWsTicker(t *tickerInfo, pair CurrencyPair, ts int64) *Ticker {
+	return &Ticker{
+		Pair: pair,
+		Last: ToFloat64(t.LastPrice),
+		Buy:  ToFloat64(t.Bid1Price),
+		Sell: ToFloat64(t.Ask1Price),
+		High: ToFloat64(t.HighPrice24h),
+		Low:  ToFloat64(t.LowPrice24h),
+		Vol:  ToFloat64(t.Volume24h),
+		Date: uint64(ts),
+	}
+}
+
+//SpotWs, 实现SpotWsApi
+type SpotWs struct {
+	ws *wsClient
+
+	lock  sync.Mutex
+	pairs map[string]CurrencyPair //symbol => pair
+
+	depthCall  func(depth *Depth)
+	tickerCall func(ticker *Ticker)
+	tradeCall  func(trade *Trade)
+}
+
+func NewSpotWs() *SpotWs {
+	return newSpotWs(wsUrl)
+}
+
+func NewSpotWsTestnet() *SpotWs {
+	return newSpotWs(TestnetWsUrl)
+}
+
+func newSpotWs(baseWsUrl string) *SpotWs {
+	s := &SpotWs{ws: newWsClient(baseWsUrl + CategorySpot), pairs: make(map[string]CurrencyPair, 4)}
+	s.ws.onDepth = func(symbol string, bids, asks DepthRecords, ts int64) {
+		if s.depthCall != nil {
+			s.depthCall(&Depth{Pair: s.pair(symbol), UTime: time.Unix(0, ts*int64(time.Millisecond)), BidList: bids, AskList: asks})
+		}
+	}
+	s.ws.onTicker = func(t *tickerInfo, ts int64) {
+		if s.tickerCall != nil {
+			s.tickerCall(adaptWsTicker(t, s.pair(t.Symbol), ts))
+		}
+	}
+	s.ws.onTrade = func(trades []wsTrade) {
+		if s.tradeCall == nil {
+			return
+		}
+		for _, t := range trades {
+			s.tradeCall(adaptWsTrade(t, s.pair(t.S)))
+		}
+	}
+	return s
+}
+
+func (s *SpotWs) pair(symbol string) CurrencyPair {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.pairs[symbol]
+}
+
+func (s *SpotWs) subscribe(pair CurrencyPair, topic string) error {
+	symbol := toSymbol(pair)
+	s.lock.Lock()
+	s.pairs[symbol] = pair
+	s.lock.Unlock()
+	return s.ws.subscribe(topic + symbol)
+}
+
+func (s *SpotWs) DepthCallback(f func(depth *Depth)) {
+	s.depthCall = f
+}
+
+func (s *SpotWs) TickerCallback(f func(ticker *Ticker)) {
+	s.tickerCall = f
+}
+
+func (s *SpotWs) TradeCallback(f func(trade *Trade)) {
+	s.tradeCall = f
+}
+
+func (s *SpotWs) SubscribeDepth(pair CurrencyPair) error {
+	return s.subscribe(pair, "orderbook.50.")
+}
+
+func (s *SpotWs) SubscribeTicker(pair CurrencyPair) error {
+	return s.subscribe(pair, "tickers.")
+}
+
+func (s *SpotWs) SubscribeTrade(pair CurrencyPair) error {
+	return s.subscribe(pair, "publicTrade.")
+}
+
+func (s *SpotWs) Close() {
+	s.ws.close()
+}
+
+/**
+ * FuturesWs, 实现FuturesWsApi
+ * U本位和币本位合约分别连接linear和inverse, contractType的转换同Futures
+ */
+type FuturesWs struct {
+	f         *Futures
+	baseWsUrl string
+
+	lock    sync.Mutex
+	clients map[string]*wsClient //category => 连接
+	subs    map[string]wsSubscription
+
+	depthCall  func(depth *Depth)
+	tickerCall func(ticker *FutureTicker)
+	tradeCall  func(trade *Trade, contract string)
+}
+
+type wsSubscription struct {
+	pair         CurrencyPair
+	contractType string
+}
+
+func NewFuturesWs() *FuturesWs {
+	return NewFuturesWsWithConfig(&APIConfig{})
+}
+
+//config.Endpoint为TestnetUrl时连接测试网, quarter/bi_quarter需要通过rest接口查询具体的合约
+func NewFuturesWsWithConfig(config *APIConfig) *FuturesWs {
+	s := &FuturesWs{
+		f:         NewFutures(config),
+		baseWsUrl: wsUrl,
+		clients:   make(map[string]*wsClient, 2),
+		subs:      make(map[string]wsSubscription, 4),
+	}
+	if s.f.Endpoint == TestnetUrl {
+		s.baseWsUrl = TestnetWsUrl
+	}
+	return s
+}
+
+func (s *FuturesWs) sub(symbol string) wsSubscription {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.subs[symbol]
+}
+
+func (s *FuturesWs) client(cat string) *wsClient {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if c, ok := s.clients[cat]; ok {
+		return c
+	}
+
+	c := newWsClient(s.baseWsUrl + cat)
+	c.onDepth = func(symbol string, bids, asks DepthRecords, ts int64) {
+		if s.depthCall == nil {
+			return
+		}
+		sub := s.sub(symbol)
+		s.depthCall(&Depth{
+			ContractType: sub.contractType,
+			ContractId:   symbol,
+			Pair:         sub.pair,
+			UTime:        time.Unix(0, ts*int64(time.Millisecond)),
+			BidList:      bids,
+			AskList:      asks,
+		})
+	}
+	c.onTicker = func(t *tickerInfo, ts int64) {
+		if s.tickerCall == nil {
+			return
+		}
+		sub := s.sub(t.Symbol)
+		s.tickerCall(&FutureTicker{
+			Ticker:       adaptWsTicker(t, sub.pair, ts),
+			ContractType: sub.contractType,
+			ContractId:   t.Symbol,
+			HoldAmount:   ToFloat64(t.OpenInterest),
+		})
+	}
+	c.onTrade = func(trades []wsTrade) {
+		if s.tradeCall == nil {
+			return
+		}
+		for _, t := range trades {
+			sub := s.sub(t.S)
+			s.tradeCall(adaptWsTrade(t, sub.pair), sub.contractType)
+		}
+	}
+	s.clients[cat] = c
+	return c
+}
+
+func (s *FuturesWs) subscribe(pair CurrencyPair, contractType, topic string) error {
+	cat, symbol, err := s.f.adaptSymbol(pair, contractType)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	s.subs[symbol] = wsSubscription{pair: pair, contractType: contractType}
+	s.lock.Unlock()
+	return s.client(cat).subscribe(topic + symbol)
+}
+
+func (s *FuturesWs) DepthCallback(f func(depth *Depth)) {
+	s.depthCall = f
+}
+
+func (s *FuturesWs) TickerCallback(f func(ticker *FutureTicker)) {
+	s.tickerCall = f
+}
+
+func (s *FuturesWs) TradeCallback(f func(trade *Trade, contract string)) {
+	s.tradeCall = f
+}
+
+func (s *FuturesWs) SubscribeDepth(pair CurrencyPair, contractType string) error {
+	return s.subscribe(pair, contractType, "orderbook.50.")
+}
+
+func (s *FuturesWs) SubscribeTicker(pair CurrencyPair, contractType string) error {
+	return s.subscribe(pair, contractType, "tickers.")
+}
+
+func (s *FuturesWs) SubscribeTrade(pair CurrencyPair, contractType string) error {
+	return s.subscribe(pair, contractType, "publicTrade.")
+}
+
+func (s *FuturesWs) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, c := range s.clients {
+		c.close()
+	}
+}
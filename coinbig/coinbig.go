@@ -9,7 +9,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"sort"
 	"strconv"
 	"strings"
@@ -24,11 +23,13 @@ type CoinBig struct {
 	httpClient *http.Client
 	accessKey,
 	secretKey string
-	timeoffset int64
+	clock     *ClockSync
 }
 
 func New(client *http.Client, api_key, secret_key string) *CoinBig {
-	return &CoinBig{accessKey: api_key, secretKey: secret_key, httpClient: client}
+	cb := &CoinBig{accessKey: api_key, secretKey: secret_key, httpClient: client}
+	cb.clock = SharedClockSync(API_BASE_URL+"/api/publics/v1/getClientIpAndServerTime", cb.GetServerTime)
+	return cb
 }
 
 func (cb *CoinBig) GetExchangeName() string {
@@ -46,7 +47,7 @@ func (cb *CoinBig) GetAccount() (*Account, error) {
 	api_url := API_BASE_URL + "/api/publics/v1/userinfo"
 
 	params := url.Values{}
-	params.Set("time", strconv.Itoa(int(time.Now().UnixNano()/1000000)))
+	params.Set("time", strconv.FormatInt(cb.clock.NowMillis(), 10))
 	params.Set("apikey", cb.accessKey)
 	cb.buildSigned(&params)
 	body, err := HttpPostForm(cb.httpClient, api_url, params)
@@ -105,7 +106,7 @@ func (cb *CoinBig) placeOrder(amount, price string, pair CurrencyPair, orderType
 	api_url := API_BASE_URL + "/api/publics/v1/trade"
 
 	params := url.Values{}
-	params.Set("time", strconv.Itoa(int(time.Now().UnixNano()/1000000)))
+	params.Set("time", strconv.FormatInt(cb.clock.NowMillis(), 10))
 	params.Set("apikey", cb.accessKey)
 	params.Set("symbol", strings.ToLower(pair.String()))
 
@@ -188,7 +189,7 @@ func (cb *CoinBig) CancelOrder(orderId string, currencyPair CurrencyPair) (bool,
 
 	params.Set("apikey", cb.accessKey)
 
-	params.Set("time", strconv.Itoa(int(time.Now().UnixNano()/1000000)))
+	params.Set("time", strconv.FormatInt(cb.clock.NowMillis(), 10))
 	params.Set("order_id", orderId)
 	cb.buildSigned(&params)
 
@@ -250,7 +251,7 @@ func (cb *CoinBig) GetOneOrder(orderId string, currencyPair CurrencyPair) (*Orde
 	params.Set("order_id", orderId)
 	//params.Set("symbol", strings.ToLower(currencyPair.String()))
 
-	params.Set("time", strconv.Itoa(int(time.Now().UnixNano()/1000000)))
+	params.Set("time", strconv.FormatInt(cb.clock.NowMillis(), 10))
 	//params.Set("size", "50")
 	//params.Set("type", "1,2")
 	cb.buildSigned(&params)
@@ -298,7 +299,7 @@ func (cb *CoinBig) GetUnfinishOrders(currencyPair CurrencyPair) ([]Order, error)
 	params.Set("apikey", cb.accessKey)
 	params.Set("symbol", strings.ToLower(currencyPair.String()))
 
-	params.Set("time", strconv.Itoa(int(time.Now().UnixNano()/1000000)))
+	params.Set("time", strconv.FormatInt(cb.clock.NowMillis(), 10))
 	params.Set("size", "50")
 	params.Set("type", "1,2")
 	cb.buildSigned(&params)
@@ -419,29 +420,23 @@ func (cb *CoinBig) GetTicker(currencyPair CurrencyPair) (*Ticker, error) {
 
 }
 
-func (cb *CoinBig) GetServerSync() error {
+// GetServerTime 返回服务器毫秒时间戳
+func (cb *CoinBig) GetServerTime() (int64, error) {
 	path := API_BASE_URL + "/api/publics/v1/getClientIpAndServerTime"
 	bodyDataMap, err := HttpGet(cb.httpClient, path)
-
-	log.Println("GetServerSync resp:", bodyDataMap, "err:", err)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	if bodyDataMap["code"].(float64) != 0 {
-		// log.Println("respData", string(body))
-		return errors.New(bodyDataMap["msg"].(string))
+		return 0, errors.New(bodyDataMap["msg"].(string))
 	}
 	data, _ := bodyDataMap["data"].(map[string]interface{})
+	return ToInt64(data["time"]), nil
+}
 
-	stime := int64(ToInt(data["time"]))
-
-	st := time.Unix(stime/1000, (stime%1000)*1000)
-	lt := time.Now()
-	offset := st.Sub(lt).Seconds()
-	cb.timeoffset = int64(offset)
-
-	return nil
-
+// GetServerSync 立即同步一次服务器时间
+func (cb *CoinBig) GetServerSync() error {
+	return cb.clock.Sync()
 }
 
 func (cb *CoinBig) GetKlineRecords(currency CurrencyPair, period, size, since int) ([]Kline, error) {
@@ -0,0 +1,953 @@
+package deribit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/logger"
+)
+
+const (
+	baseUrl    = "https://www.deribit.com"
+	TestnetUrl = "https://test.deribit.com"
+
+	//access token到期前提前刷新
+	tokenRefreshAhead = time.Minute
+)
+
+type rpcError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type rpcResponse struct {
+	Id     int64           `json:"id"`
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+	Params json.RawMessage `json:"params"`
+}
+
+type authResult struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type Instrument struct {
+	InstrumentName      string  `json:"instrument_name"`
+	Kind                string  `json:"kind"`
+	BaseCurrency        string  `json:"base_currency"`
+	QuoteCurrency       string  `json:"quote_currency"`
+	SettlementPeriod    string  `json:"settlement_period"`
+	ContractSize        float64 `json:"contract_size"`
+	TickSize            float64 `json:"tick_size"`
+	MinTradeAmount      float64 `json:"min_trade_amount"`
+	Strike              float64 `json:"strike"`
+	OptionType          string  `json:"option_type"`
+	ExpirationTimestamp int64   `json:"expiration_timestamp"`
+	IsActive            bool    `json:"is_active"`
+}
+
+type orderInfo struct {
+	OrderId             string      `json:"order_id"`
+	OrderState          string      `json:"order_state"`
+	InstrumentName      string      `json:"instrument_name"`
+	Direction           string      `json:"direction"`
+	Price               interface{} `json:"price"` //市价单为market_price
+	Amount              float64     `json:"amount"`
+	FilledAmount        float64     `json:"filled_amount"`
+	AveragePrice        float64     `json:"average_price"`
+	Commission          float64     `json:"commission"`
+	Label               string      `json:"label"`
+	ReduceOnly          bool        `json:"reduce_only"`
+	PostOnly            bool        `json:"post_only"`
+	TimeInForce         string      `json:"time_in_force"`
+	CreationTimestamp   int64       `json:"creation_timestamp"`
+	LastUpdateTimestamp int64       `json:"last_update_timestamp"`
+}
+
+type tradeInfo struct {
+	TradeSeq       int64   `json:"trade_seq"`
+	InstrumentName string  `json:"instrument_name"`
+	Direction      string  `json:"direction"`
+	Price          float64 `json:"price"`
+	Amount         float64 `json:"amount"`
+	Timestamp      int64   `json:"timestamp"`
+}
+
+type tickerInfo struct {
+	InstrumentName         string  `json:"instrument_name"`
+	LastPrice              float64 `json:"last_price"`
+	BestBidPrice           float64 `json:"best_bid_price"`
+	BestAskPrice           float64 `json:"best_ask_price"`
+	MarkPrice              float64 `json:"mark_price"`
+	IndexPrice             float64 `json:"index_price"`
+	UnderlyingPrice        float64 `json:"underlying_price"`
+	EstimatedDeliveryPrice float64 `json:"estimated_delivery_price"`
+	OpenInterest           float64 `json:"open_interest"`
+	MaxPrice               float64 `json:"max_price"`
+	MinPrice               float64 `json:"min_price"`
+	MarkIv                 float64 `json:"mark_iv"`
+	BidIv                  float64 `json:"bid_iv"`
+	AskIv                  float64 `json:"ask_iv"`
+	Greeks                 struct {
+		Delta float64 `json:"delta"`
+		Gamma float64 `json:"gamma"`
+		Theta float64 `json:"theta"`
+		Vega  float64 `json:"vega"`
+	} `json:"greeks"`
+	Stats struct {
+		High   float64 `json:"high"`
+		Low    float64 `json:"low"`
+		Volume float64 `json:"volume"`
+	} `json:"stats"`
+	Timestamp int64 `json:"timestamp"`
+}
+
+/**
+ * deribit v2, 只支持币本位(反向)合约, 如BTC-PERPETUAL, BTC-25JUN21
+ * 以HTTP GET调用JSON-RPC接口 /api/v2/{method}, public/auth带有凭证, 以POST的JSON-RPC请求体发送
+ * 私有接口使用public/auth获取的access token, 到期前通过refresh token刷新, 刷新失败时重新用api key认证
+ * 下单, 持仓, 深度和成交的数量统一为张数, 与deribit的美元数量按合约面值(contract_size)换算
+ */
+type Deribit struct {
+	*APIConfig
+
+	tokenLock    sync.Mutex
+	accessToken  string
+	refreshToken string
+	expireAt     time.Time
+
+	instLock    sync.Mutex
+	instruments map[string]Instrument   //instrument_name => Instrument
+	futures     map[string][]Instrument //币种 => 未到期的交割合约, 按到期时间排序
+
+	now func() time.Time
+}
+
+//config.Endpoint为TestnetUrl时使用测试网, ApiKey和ApiSecretKey为client_id和client_secret
+func New(config *APIConfig) *Deribit {
+	if config.Endpoint == "" {
+		config.Endpoint = baseUrl
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	if config.HttpClient == nil {
+		config.HttpClient = http.DefaultClient
+	}
+	return &Deribit{
+		APIConfig:   config,
+		instruments: make(map[string]Instrument, 16),
+		futures:     make(map[string][]Instrument, 2),
+		now:         time.Now,
+	}
+}
+
+func (dr *Deribit) GetExchangeName() string {
+	return DERIBIT
+}
+
+func (dr *Deribit) authParams() url.Values {
+	params := url.Values{}
+	if dr.refreshToken != "" {
+		params.Set("grant_type", "refresh_token")
+		params.Set("refresh_token", dr.refreshToken)
+		return params
+	}
+	params.Set("grant_type", "client_credentials")
+	params.Set("client_id", dr.ApiKey)
+	params.Set("client_secret", dr.ApiSecretKey)
+	return params
+}
+
+func (dr *Deribit) setToken(auth authResult) {
+	dr.accessToken = auth.AccessToken
+	dr.refreshToken = auth.RefreshToken
+	dr.expireAt = dr.now().Add(time.Duration(auth.ExpiresIn) * time.Second)
+}
+
+//返回有效的access token, 快到期时刷新
+func (dr *Deribit) token() (string, error) {
+	if dr.ApiKey == "" || dr.ApiSecretKey == "" {
+		return "", EX_ERR_NOT_FIND_APIKEY
+	}
+
+	dr.tokenLock.Lock()
+	defer dr.tokenLock.Unlock()
+
+	if dr.accessToken != "" && dr.now().Add(tokenRefreshAhead).Before(dr.expireAt) {
+		return dr.accessToken, nil
+	}
+
+	var auth authResult
+	err := dr.doRequest("public/auth", dr.authParams(), &auth)
+	if err != nil && dr.refreshToken != "" {
+		logger.Warnf("[deribit] refresh token error: %s", err.Error())
+		dr.refreshToken = ""
+		err = dr.doRequest("public/auth", dr.authParams(), &auth)
+	}
+	if err != nil {
+		return "", err
+	}
+	dr.setToken(auth)
+	return dr.accessToken, nil
+}
+
+func (dr *Deribit) doRequest(method string, params url.Values, result interface{}) error {
+	header := map[string]string{"Content-Type": "application/json"}
+	if strings.HasPrefix(method, "private/") {
+		token, err := dr.token()
+		if err != nil {
+			return err
+		}
+		header["Authorization"] = "Bearer " + token
+	}
+
+	var (
+		reqType  = "GET"
+		reqUrl   = fmt.Sprintf("%s/api/v2/%s", dr.Endpoint, method)
+		postData string
+	)
+	if method == "public/auth" {
+		//client_secret和refresh_token不能出现在url中, 请求url会被记录到日志
+		reqType = "POST"
+		rpcParams := make(map[string]string, len(params))
+		for k := range params {
+			rpcParams[k] = params.Get(k)
+		}
+		data, err := json.Marshal(rpcRequest{JsonRpc: "2.0", Id: 1, Method: method, Params: rpcParams})
+		if err != nil {
+			return err
+		}
+		postData = string(data)
+	} else if len(params) > 0 {
+		reqUrl += "?" + params.Encode()
+	}
+
+	//错误时http状态码为400, 响应体仍然是JSON-RPC格式
+	respData, err := NewHttpRequest(dr.HttpClient, reqType, reqUrl, postData, header)
+	if err != nil {
+		if idx := strings.Index(err.Error(), "{"); idx > 0 {
+			respData = []byte(err.Error()[idx:])
+		} else {
+			return HTTP_ERR_CODE.OriginErr(err.Error())
+		}
+	}
+	//认证的响应包含access_token和refresh_token, 不记录到日志
+	if method != "public/auth" {
+		logger.Debugf("[deribit] response: %s", string(respData))
+	}
+
+	var resp rpcResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return toApiError(resp.Error)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, result)
+}
+
+func toApiError(e *rpcError) error {
+	msg := fmt.Sprintf("%d: %s", e.Code, e.Message)
+	if len(e.Data) > 0 {
+		msg += " " + string(e.Data)
+	}
+	switch e.Code {
+	case 10009:
+		return EX_ERR_INSUFFICIENT_BALANCE.OriginErr(msg)
+	case 10028:
+		return EX_ERR_API_LIMIT.OriginErr(msg)
+	case 10004, 11044:
+		return EX_ERR_NOT_FIND_ORDER.OriginErr(msg)
+	case 13009, 13004:
+		return EX_ERR_SIGN.OriginErr(msg)
+	}
+	return API_ERR.OriginErr(msg)
+}
+
+//kind为future或option
+func (dr *Deribit) GetInstruments(currency Currency, kind string) ([]Instrument, error) {
+	params := url.Values{}
+	params.Set("currency", currency.Symbol)
+	params.Set("kind", kind)
+	params.Set("expired", "false")
+
+	var instruments []Instrument
+	err := dr.doRequest("public/get_instruments", params, &instruments)
+	if err != nil {
+		return nil, err
+	}
+
+	dr.instLock.Lock()
+	for _, ins := range instruments {
+		dr.instruments[ins.InstrumentName] = ins
+	}
+	dr.instLock.Unlock()
+	return instruments, nil
+}
+
+func (dr *Deribit) getInstrument(name string) (*Instrument, error) {
+	dr.instLock.Lock()
+	ins, ok := dr.instruments[name]
+	dr.instLock.Unlock()
+	if ok {
+		return &ins, nil
+	}
+
+	err := dr.doRequest("public/get_instrument", url.Values{"instrument_name": {name}}, &ins)
+	if err != nil {
+		return nil, err
+	}
+	dr.instLock.Lock()
+	dr.instruments[name] = ins
+	dr.instLock.Unlock()
+	return &ins, nil
+}
+
+//最近的合约到期前缓存有效
+func (dr *Deribit) getFutures(currency Currency) ([]Instrument, error) {
+	dr.instLock.Lock()
+	futures := dr.futures[currency.Symbol]
+	dr.instLock.Unlock()
+	nowMs := dr.now().UnixNano() / int64(time.Millisecond)
+	if len(futures) > 0 && futures[0].ExpirationTimestamp > nowMs {
+		return futures, nil
+	}
+
+	instruments, err := dr.GetInstruments(currency, "future")
+	if err != nil {
+		return nil, err
+	}
+	futures = futures[:0:0]
+	for _, ins := range instruments {
+		if ins.SettlementPeriod != "perpetual" {
+			futures = append(futures, ins)
+		}
+	}
+	sort.Slice(futures, func(i, j int) bool {
+		return futures[i].ExpirationTimestamp < futures[j].ExpirationTimestamp
+	})
+
+	dr.instLock.Lock()
+	dr.futures[currency.Symbol] = futures
+	dr.instLock.Unlock()
+	return futures, nil
+}
+
+/**
+ * contractType转换为合约名称
+ * swap => BTC-PERPETUAL
+ * this_week, next_week => 按到期时间排序的第一, 二个交割合约
+ * quarter, bi_quarter => 3, 6, 9, 12月到期的第一, 二个月度合约
+ * 包含"-"时认为已经是合约名称, 如BTC-25JUN21
+ */
+func (dr *Deribit) instrumentName(pair CurrencyPair, contractType string) (string, error) {
+	if strings.Contains(contractType, "-") {
+		return strings.ToUpper(contractType), nil
+	}
+
+	coin := strings.ToUpper(pair.CurrencyA.Symbol)
+	if contractType == SWAP_CONTRACT || contractType == "" {
+		return coin + "-PERPETUAL", nil
+	}
+
+	futures, err := dr.getFutures(pair.CurrencyA)
+	if err != nil {
+		return "", err
+	}
+
+	index := 0
+	switch contractType {
+	case THIS_WEEK_CONTRACT:
+		index = 0
+	case NEXT_WEEK_CONTRACT:
+		index = 1
+	case QUARTER_CONTRACT, BI_QUARTER_CONTRACT:
+		var quarters []Instrument
+		for _, ins := range futures {
+			expiry := time.Unix(0, ins.ExpirationTimestamp*int64(time.Millisecond)).UTC()
+			if ins.SettlementPeriod == "month" && expiry.Month()%3 == 0 {
+				quarters = append(quarters, ins)
+			}
+		}
+		futures = quarters
+		if contractType == BI_QUARTER_CONTRACT {
+			index = 1
+		}
+	default:
+		return "", fmt.Errorf("unsupported contract type %s", contractType)
+	}
+
+	if index >= len(futures) {
+		return "", fmt.Errorf("no %s contract for %s", contractType, coin)
+	}
+	return futures[index].InstrumentName, nil
+}
+
+//BTC-PERPETUAL, BTC-25JUN21, BTC-25JUN21-50000-C => BTC_USD
+func adaptInstrumentToPair(instrumentName string) CurrencyPair {
+	coin := strings.Split(instrumentName, "-")[0]
+	return NewCurrencyPair2(coin + "_USD")
+}
+
+//美元数量 => 张数
+func (dr *Deribit) toContracts(instrumentName string, amount float64) float64 {
+	ins, err := dr.getInstrument(instrumentName)
+	if err != nil || ins.ContractSize == 0 {
+		return amount
+	}
+	return amount / ins.ContractSize
+}
+
+func (dr *Deribit) GetContractValue(currencyPair CurrencyPair) (float64, error) {
+	ins, err := dr.getInstrument(strings.ToUpper(currencyPair.CurrencyA.Symbol) + "-PERPETUAL")
+	if err != nil {
+		return 0, err
+	}
+	return ins.ContractSize, nil
+}
+
+//交割合约在到期日(周五) 08:00 UTC 交割
+func (dr *Deribit) GetDeliveryTime() (int, int, int, int) {
+	return 5, 8, 0, 0
+}
+
+//taker手续费
+func (dr *Deribit) GetFee() (float64, error) {
+	return 0.0005, nil
+}
+
+func (dr *Deribit) getIndexPrice(currencyPair CurrencyPair) (index, estimated float64, err error) {
+	var resp struct {
+		IndexPrice             float64 `json:"index_price"`
+		EstimatedDeliveryPrice float64 `json:"estimated_delivery_price"`
+	}
+	indexName := strings.ToLower(currencyPair.CurrencyA.Symbol) + "_usd"
+	err = dr.doRequest("public/get_index_price", url.Values{"index_name": {indexName}}, &resp)
+	return resp.IndexPrice, resp.EstimatedDeliveryPrice, err
+}
+
+func (dr *Deribit) GetFutureIndex(currencyPair CurrencyPair) (float64, error) {
+	index, _, err := dr.getIndexPrice(currencyPair)
+	return index, err
+}
+
+func (dr *Deribit) GetFutureEstimatedPrice(currencyPair CurrencyPair) (float64, error) {
+	_, estimated, err := dr.getIndexPrice(currencyPair)
+	return estimated, err
+}
+
+func (dr *Deribit) getTicker(instrumentName string) (*tickerInfo, error) {
+	var ticker tickerInfo
+	err := dr.doRequest("public/ticker", url.Values{"instrument_name": {instrumentName}}, &ticker)
+	if err != nil {
+		return nil, err
+	}
+	return &ticker, nil
+}
+
+func (dr *Deribit) GetFutureTicker(currencyPair CurrencyPair, contractType string) (*Ticker, error) {
+	name, err := dr.instrumentName(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	ticker, err := dr.getTicker(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Ticker{
+		Pair: currencyPair,
+		Last: ticker.LastPrice,
+		Buy:  ticker.BestBidPrice,
+		Sell: ticker.BestAskPrice,
+		High: ticker.Stats.High,
+		Low:  ticker.Stats.Low,
+		Vol:  ticker.Stats.Volume,
+		Date: uint64(ticker.Timestamp),
+	}, nil
+}
+
+func (dr *Deribit) GetFutureDepth(currencyPair CurrencyPair, contractType string, size int) (*Depth, error) {
+	name, err := dr.instrumentName(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Timestamp int64        `json:"timestamp"`
+		Bids      [][2]float64 `json:"bids"`
+		Asks      [][2]float64 `json:"asks"`
+	}
+	params := url.Values{}
+	params.Set("instrument_name", name)
+	params.Set("depth", fmt.Sprint(size))
+	err = dr.doRequest("public/get_order_book", params, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := &Depth{
+		ContractType: contractType,
+		ContractId:   name,
+		Pair:         currencyPair,
+		UTime:        time.Unix(0, resp.Timestamp*int64(time.Millisecond)),
+	}
+	for _, bid := range resp.Bids {
+		depth.BidList = append(depth.BidList, DepthRecord{Price: bid[0], Amount: dr.toContracts(name, bid[1])})
+	}
+	for _, ask := range resp.Asks {
+		depth.AskList = append(depth.AskList, DepthRecord{Price: ask[0], Amount: dr.toContracts(name, ask[1])})
+	}
+	sort.Sort(sort.Reverse(depth.AskList))
+	return depth, nil
+}
+
+//不传currencyPair时返回BTC和ETH账户
+func (dr *Deribit) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureAccount, error) {
+	currencies := []Currency{BTC, ETH}
+	if len(currencyPair) > 0 {
+		currencies = currencies[:0]
+		for _, pair := range currencyPair {
+			currencies = append(currencies, pair.CurrencyA)
+		}
+	}
+
+	acc := &FutureAccount{FutureSubAccounts: make(map[Currency]FutureSubAccount, len(currencies))}
+	for _, currency := range currencies {
+		var resp struct {
+			Equity            float64 `json:"equity"`
+			MarginBalance     float64 `json:"margin_balance"`
+			InitialMargin     float64 `json:"initial_margin"`
+			MaintenanceMargin float64 `json:"maintenance_margin"`
+			SessionUpl        float64 `json:"session_upl"`
+			SessionRpl        float64 `json:"session_rpl"`
+		}
+		err := dr.doRequest("private/get_account_summary", url.Values{"currency": {currency.Symbol}}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		sub := FutureSubAccount{
+			Currency:      currency,
+			AccountRights: resp.Equity,
+			KeepDeposit:   resp.InitialMargin,
+			ProfitReal:    resp.SessionRpl,
+			ProfitUnreal:  resp.SessionUpl,
+		}
+		if resp.MarginBalance > 0 {
+			sub.RiskRate = resp.MaintenanceMargin / resp.MarginBalance
+		}
+		acc.FutureSubAccounts[currency] = sub
+	}
+	return acc, nil
+}
+
+func (dr *Deribit) placeOrder(pair CurrencyPair, contractType, instrumentName, price, amount string, openType int, isMarket bool, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	method := "private/buy"
+	if openType == OPEN_SELL || openType == CLOSE_BUY {
+		method = "private/sell"
+	}
+
+	ins, err := dr.getInstrument(instrumentName)
+	if err != nil {
+		return nil, err
+	}
+
+	fOrder := &FutureOrder{
+		ClientOid:    GenerateOrderClientId(32),
+		Currency:     pair,
+		Price:        ToFloat64(price),
+		Amount:       ToFloat64(amount),
+		OType:        openType,
+		OrderType:    ORDER_FEATURE_ORDINARY,
+		ContractName: contractType,
+		OrderTime:    dr.now().UnixNano() / int64(time.Millisecond),
+	}
+
+	params := url.Values{}
+	params.Set("instrument_name", instrumentName)
+	params.Set("amount", FloatToString(fOrder.Amount*ins.ContractSize, 8))
+	params.Set("label", fOrder.ClientOid)
+	if isMarket {
+		params.Set("type", "market")
+	} else {
+		params.Set("type", "limit")
+		params.Set("price", price)
+	}
+	if openType == CLOSE_BUY || openType == CLOSE_SELL {
+		params.Set("reduce_only", "true")
+	}
+	if len(opt) > 0 && !isMarket {
+		switch opt[0] {
+		case PostOnly:
+			fOrder.OrderType = ORDER_FEATURE_POST_ONLY
+			params.Set("post_only", "true")
+		case Ioc:
+			fOrder.OrderType = ORDER_FEATURE_IOC
+			params.Set("time_in_force", "immediate_or_cancel")
+		case Fok:
+			fOrder.OrderType = ORDER_FEATURE_FOK
+			params.Set("time_in_force", "fill_or_kill")
+		}
+	}
+
+	var resp struct {
+		Order orderInfo `json:"order"`
+	}
+	err = dr.doRequest(method, params, &resp)
+	if err != nil {
+		return fOrder, err
+	}
+	fOrder.OrderID2 = resp.Order.OrderId
+	fOrder.Status = adaptOrderStatus(resp.Order)
+	fOrder.DealAmount = resp.Order.FilledAmount / ins.ContractSize
+	fOrder.AvgPrice = resp.Order.AveragePrice
+	return fOrder, nil
+}
+
+func (dr *Deribit) PlaceFutureOrder(currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice int, leverRate float64) (string, error) {
+	name, err := dr.instrumentName(currencyPair, contractType)
+	if err != nil {
+		return "", err
+	}
+	fOrder, err := dr.placeOrder(currencyPair, contractType, name, price, amount, openType, matchPrice == 1)
+	if err != nil {
+		return "", err
+	}
+	return fOrder.OrderID2, nil
+}
+
+func (dr *Deribit) LimitFuturesOrder(currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	name, err := dr.instrumentName(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	return dr.placeOrder(currencyPair, contractType, name, price, amount, openType, false, opt...)
+}
+
+func (dr *Deribit) MarketFuturesOrder(currencyPair CurrencyPair, contractType, amount string, openType int) (*FutureOrder, error) {
+	name, err := dr.instrumentName(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	return dr.placeOrder(currencyPair, contractType, name, "0", amount, openType, true)
+}
+
+func (dr *Deribit) FutureCancelOrder(currencyPair CurrencyPair, contractType, orderId string) (bool, error) {
+	err := dr.doRequest("private/cancel", url.Values{"order_id": {orderId}}, nil)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (dr *Deribit) GetFuturePosition(currencyPair CurrencyPair, contractType string) ([]FuturePosition, error) {
+	name, err := dr.instrumentName(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Size                      float64 `json:"size"`
+		Direction                 string  `json:"direction"`
+		AveragePrice              float64 `json:"average_price"`
+		FloatingProfitLoss        float64 `json:"floating_profit_loss"`
+		RealizedProfitLoss        float64 `json:"realized_profit_loss"`
+		EstimatedLiquidationPrice float64 `json:"estimated_liquidation_price"`
+		Leverage                  float64 `json:"leverage"`
+	}
+	err = dr.doRequest("private/get_position", url.Values{"instrument_name": {name}}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := FuturePosition{
+		Symbol:         currencyPair,
+		ContractType:   contractType,
+		LeverRate:      resp.Leverage,
+		ForceLiquPrice: resp.EstimatedLiquidationPrice,
+	}
+	amount := dr.toContracts(name, resp.Size)
+	switch resp.Direction {
+	case "buy":
+		pos.BuyAmount = amount
+		pos.BuyAvailable = amount
+		pos.BuyPriceAvg = resp.AveragePrice
+		pos.BuyPriceCost = resp.AveragePrice
+		pos.BuyProfit = resp.FloatingProfitLoss
+		pos.BuyProfitReal = resp.RealizedProfitLoss
+	case "sell":
+		//空头的size为负数
+		pos.SellAmount = -amount
+		pos.SellAvailable = -amount
+		pos.SellPriceAvg = resp.AveragePrice
+		pos.SellPriceCost = resp.AveragePrice
+		pos.SellProfit = resp.FloatingProfitLoss
+		pos.SellProfitReal = resp.RealizedProfitLoss
+	default:
+		return nil, nil
+	}
+	return []FuturePosition{pos}, nil
+}
+
+func adaptOrderStatus(o orderInfo) TradeStatus {
+	switch o.OrderState {
+	case "open", "untriggered":
+		if o.FilledAmount > 0 {
+			return ORDER_PART_FINISH
+		}
+		return ORDER_UNFINISH
+	case "filled":
+		return ORDER_FINISH
+	case "cancelled":
+		return ORDER_CANCEL
+	case "rejected":
+		return ORDER_REJECT
+	}
+	return ORDER_UNFINISH
+}
+
+func (dr *Deribit) adaptOrder(pair CurrencyPair, contractType string, o orderInfo) FutureOrder {
+	oType := OPEN_BUY
+	switch {
+	case o.Direction == "buy" && o.ReduceOnly:
+		oType = CLOSE_SELL
+	case o.Direction == "sell" && o.ReduceOnly:
+		oType = CLOSE_BUY
+	case o.Direction == "sell":
+		oType = OPEN_SELL
+	}
+
+	orderType := ORDER_FEATURE_ORDINARY
+	switch {
+	case o.PostOnly:
+		orderType = ORDER_FEATURE_POST_ONLY
+	case o.TimeInForce == "immediate_or_cancel":
+		orderType = ORDER_FEATURE_IOC
+	case o.TimeInForce == "fill_or_kill":
+		orderType = ORDER_FEATURE_FOK
+	}
+
+	if contractType == "" {
+		contractType = o.InstrumentName
+	}
+	order := FutureOrder{
+		ClientOid:    o.Label,
+		OrderID2:     o.OrderId,
+		Price:        ToFloat64(o.Price),
+		Amount:       dr.toContracts(o.InstrumentName, o.Amount),
+		AvgPrice:     o.AveragePrice,
+		DealAmount:   dr.toContracts(o.InstrumentName, o.FilledAmount),
+		OrderTime:    o.CreationTimestamp,
+		Status:       adaptOrderStatus(o),
+		Currency:     pair,
+		OrderType:    orderType,
+		OType:        oType,
+		Fee:          o.Commission,
+		ContractName: contractType,
+	}
+	if order.Status == ORDER_FINISH || order.Status == ORDER_CANCEL || order.Status == ORDER_REJECT {
+		order.FinishedTime = o.LastUpdateTimestamp
+	}
+	return order
+}
+
+func (dr *Deribit) GetFutureOrder(orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error) {
+	var resp orderInfo
+	err := dr.doRequest("private/get_order_state", url.Values{"order_id": {orderId}}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	order := dr.adaptOrder(currencyPair, contractType, resp)
+	return &order, nil
+}
+
+func (dr *Deribit) GetFutureOrders(orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	orders := make([]FutureOrder, 0, len(orderIds))
+	for _, id := range orderIds {
+		order, err := dr.GetFutureOrder(id, currencyPair, contractType)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+	return orders, nil
+}
+
+func (dr *Deribit) getOrders(method string, params url.Values, pair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	var resp []orderInfo
+	err := dr.doRequest(method, params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	orders := make([]FutureOrder, 0, len(resp))
+	for _, o := range resp {
+		orders = append(orders, dr.adaptOrder(pair, contractType, o))
+	}
+	return orders, nil
+}
+
+func (dr *Deribit) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	name, err := dr.instrumentName(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	return dr.getOrders("private/get_open_orders_by_instrument", url.Values{"instrument_name": {name}}, currencyPair, contractType)
+}
+
+//optional支持count, offset
+func (dr *Deribit) GetFutureOrderHistory(pair CurrencyPair, contractType string, optional ...OptionalParameter) ([]FutureOrder, error) {
+	name, err := dr.instrumentName(pair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("instrument_name", name)
+	MergeOptionalParameter(&params, optional...)
+	return dr.getOrders("private/get_order_history_by_instrument", params, pair, contractType)
+}
+
+func adaptKlinePeriod(period KlinePeriod) (string, time.Duration) {
+	switch period {
+	case KLINE_PERIOD_1MIN:
+		return "1", time.Minute
+	case KLINE_PERIOD_3MIN:
+		return "3", 3 * time.Minute
+	case KLINE_PERIOD_5MIN:
+		return "5", 5 * time.Minute
+	case KLINE_PERIOD_15MIN:
+		return "15", 15 * time.Minute
+	case KLINE_PERIOD_30MIN:
+		return "30", 30 * time.Minute
+	case KLINE_PERIOD_1H, KLINE_PERIOD_60MIN:
+		return "60", time.Hour
+	case KLINE_PERIOD_2H:
+		return "120", 2 * time.Hour
+	case KLINE_PERIOD_3H:
+		return "180", 3 * time.Hour
+	case KLINE_PERIOD_6H:
+		return "360", 6 * time.Hour
+	case KLINE_PERIOD_12H:
+		return "720", 12 * time.Hour
+	case KLINE_PERIOD_1DAY:
+		return "1D", 24 * time.Hour
+	}
+	return "", 0
+}
+
+//optional支持startTime, 默认返回最近size根
+func (dr *Deribit) GetKlineRecords(contractType string, currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]FutureKline, error) {
+	resolution, duration := adaptKlinePeriod(period)
+	if resolution == "" {
+		return nil, errors.New("unsupported kline period")
+	}
+	name, err := dr.instrumentName(currency, contractType)
+	if err != nil {
+		return nil, err
+	}
+
+	end := dr.now()
+	start := end.Add(-time.Duration(size) * duration)
+	if len(optional) > 0 && optional[0].GetTime("startTime") != nil {
+		start = *optional[0].GetTime("startTime")
+		end = start.Add(time.Duration(size) * duration)
+	}
+
+	params := url.Values{}
+	params.Set("instrument_name", name)
+	params.Set("resolution", resolution)
+	params.Set("start_timestamp", fmt.Sprint(start.UnixNano()/int64(time.Millisecond)))
+	params.Set("end_timestamp", fmt.Sprint(end.UnixNano()/int64(time.Millisecond)))
+
+	var resp struct {
+		Ticks  []int64   `json:"ticks"`
+		Open   []float64 `json:"open"`
+		High   []float64 `json:"high"`
+		Low    []float64 `json:"low"`
+		Close  []float64 `json:"close"`
+		Volume []float64 `json:"volume"`
+		Cost   []float64 `json:"cost"`
+	}
+	err = dr.doRequest("public/get_tradingview_chart_data", params, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	//volume为币的数量, cost为美元数量
+	klines := make([]FutureKline, 0, len(resp.Ticks))
+	for i := range resp.Ticks {
+		if i >= len(resp.Open) || i >= len(resp.Cost) {
+			break
+		}
+		klines = append(klines, FutureKline{
+			Kline: &Kline{
+				Pair:      currency,
+				Timestamp: resp.Ticks[i] / 1000,
+				Open:      resp.Open[i],
+				High:      resp.High[i],
+				Low:       resp.Low[i],
+				Close:     resp.Close[i],
+				Vol:       dr.toContracts(name, resp.Cost[i]),
+			},
+			Vol2: resp.Volume[i],
+		})
+	}
+	return klines, nil
+}
+
+//since为毫秒时间戳, 为0时返回最近的成交
+func (dr *Deribit) GetTrades(contractType string, currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	name, err := dr.instrumentName(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("instrument_name", name)
+	params.Set("count", "100")
+	if since > 0 {
+		params.Set("start_timestamp", fmt.Sprint(since))
+		params.Set("sorting", "asc")
+	}
+
+	var resp struct {
+		Trades []tradeInfo `json:"trades"`
+	}
+	err = dr.doRequest("public/get_last_trades_by_instrument", params, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	trades := make([]Trade, 0, len(resp.Trades))
+	for _, t := range resp.Trades {
+		trades = append(trades, dr.adaptTrade(currencyPair, t))
+	}
+	return trades, nil
+}
+
+func (dr *Deribit) adaptTrade(pair CurrencyPair, t tradeInfo) Trade {
+	side := BUY
+	if t.Direction == "sell" {
+		side = SELL
+	}
+	return Trade{
+		Tid:    t.TradeSeq,
+		Type:   side,
+		Amount: dr.toContracts(t.InstrumentName, t.Amount),
+		Price:  t.Price,
+		Date:   t.Timestamp,
+		Pair:   pair,
+	}
+}
@@ -0,0 +1,227 @@
+package deribit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+//JSON-RPC接口, 路由的key为method, 返回值为result字段, 返回*rpcError时为错误响应
+var deribitAPI = testserver.Options{
+	Check: func(r *testserver.Request) interface{} {
+		var req struct {
+			Method string `json:"method"`
+		}
+		json.Unmarshal(r.Data, &req)
+		//POST请求体中的method需要和path一致
+		if r.Method == http.MethodPost && "/api/v2/"+req.Method != r.URL.Path {
+			return testserver.Response{Status: http.StatusBadRequest}
+		}
+		return nil
+	},
+	Wrap: func(r *testserver.Request, result interface{}) interface{} {
+		if e, ok := result.(*rpcError); ok {
+			return testserver.Response{Status: http.StatusBadRequest, Body: map[string]interface{}{"jsonrpc": "2.0", "error": e}}
+		}
+		return map[string]interface{}{"jsonrpc": "2.0", "result": result}
+	},
+	Key: func(r *testserver.Request) string {
+		return strings.TrimPrefix(r.URL.Path, "/api/v2/")
+	},
+}
+
+//GET请求的参数在query中, POST请求的参数在JSON-RPC请求体的params中
+func rpcParams(r *testserver.Request) url.Values {
+	params := r.URL.Query()
+	var req struct {
+		Params map[string]string `json:"params"`
+	}
+	json.Unmarshal(r.Data, &req)
+	for k, v := range req.Params {
+		params.Set(k, v)
+	}
+	return params
+}
+
+func TestDeribit_Auth(t *testing.T) {
+	var grants []string
+	var authorization string
+	srv := testserver.New(deribitAPI, map[string]testserver.Route{
+		"public/auth": func(r *testserver.Request) interface{} {
+			params := rpcParams(r)
+			//凭证不能出现在url中
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Empty(t, r.URL.RawQuery)
+			if params.Get("grant_type") == "client_credentials" {
+				assert.Equal(t, "key", params.Get("client_id"))
+				assert.Equal(t, "secret", params.Get("client_secret"))
+			}
+			grants = append(grants, params.Get("grant_type"))
+			if params.Get("grant_type") == "refresh_token" && params.Get("refresh_token") != "r1" {
+				return &rpcError{Code: 13004, Message: "invalid_credentials"}
+			}
+			return map[string]interface{}{"access_token": fmt.Sprintf("a%d", len(grants)), "refresh_token": "r1", "expires_in": 900}
+		},
+		"private/cancel": func(r *testserver.Request) interface{} {
+			params := rpcParams(r)
+			authorization = r.Header.Get("Authorization")
+			return map[string]interface{}{"order_id": params.Get("order_id")}
+		},
+	})
+	defer srv.Close()
+	dr := New(testserver.Config(srv))
+
+	now := time.Unix(1600000000, 0)
+	dr.now = func() time.Time { return now }
+
+	ok, err := dr.FutureCancelOrder(goex.BTC_USD, goex.SWAP_CONTRACT, "1")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "Bearer a1", authorization)
+
+	//未到期时复用token
+	dr.FutureCancelOrder(goex.BTC_USD, goex.SWAP_CONTRACT, "1")
+	assert.Equal(t, []string{"client_credentials"}, grants)
+
+	//到期前一分钟内使用refresh token刷新
+	now = now.Add(850 * time.Second)
+	dr.FutureCancelOrder(goex.BTC_USD, goex.SWAP_CONTRACT, "1")
+	assert.Equal(t, []string{"client_credentials", "refresh_token"}, grants)
+	assert.Equal(t, "Bearer a2", authorization)
+
+	//refresh token失效时重新认证
+	dr.refreshToken = "expired"
+	now = now.Add(time.Hour)
+	_, err = dr.FutureCancelOrder(goex.BTC_USD, goex.SWAP_CONTRACT, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"client_credentials", "refresh_token", "refresh_token", "client_credentials"}, grants)
+}
+
+func TestDeribit_Orders(t *testing.T) {
+	var placed url.Values
+	srv := testserver.New(deribitAPI, map[string]testserver.Route{
+		"public/auth": func(r *testserver.Request) interface{} {
+			return map[string]interface{}{"access_token": "a", "refresh_token": "r", "expires_in": 900}
+		},
+		"public/get_instruments": func(r *testserver.Request) interface{} {
+			return []map[string]interface{}{
+				{"instrument_name": "BTC-PERPETUAL", "settlement_period": "perpetual", "contract_size": 10, "expiration_timestamp": 32503708800000},
+				{"instrument_name": "BTC-25SEP20", "settlement_period": "month", "contract_size": 10, "expiration_timestamp": 1601020800000},
+				{"instrument_name": "BTC-18SEP20", "settlement_period": "week", "contract_size": 10, "expiration_timestamp": 1600416000000},
+				{"instrument_name": "BTC-25DEC20", "settlement_period": "month", "contract_size": 10, "expiration_timestamp": 1608883200000},
+				{"instrument_name": "BTC-30OCT20", "settlement_period": "month", "contract_size": 10, "expiration_timestamp": 1604044800000},
+			}
+		},
+		"private/sell": func(r *testserver.Request) interface{} {
+			placed = rpcParams(r)
+			return map[string]interface{}{"order": map[string]interface{}{
+				"order_id": "1001", "order_state": "open", "instrument_name": "BTC-25DEC20", "direction": "sell",
+				"price": 11000, "amount": 100, "filled_amount": 20, "average_price": 11000, "reduce_only": true}}
+		},
+		"private/get_order_state": func(r *testserver.Request) interface{} {
+			params := rpcParams(r)
+			return map[string]interface{}{
+				"order_id": params.Get("order_id"), "order_state": "filled", "instrument_name": "BTC-PERPETUAL", "direction": "buy",
+				"price": "market_price", "amount": 50, "filled_amount": 50, "average_price": 10500, "label": "cid",
+				"creation_timestamp": 1600000000000, "last_update_timestamp": 1600000001000}
+		},
+		"private/get_position": func(r *testserver.Request) interface{} {
+			return map[string]interface{}{"size": -200, "direction": "sell", "average_price": 10800, "estimated_liquidation_price": 15000, "leverage": 50}
+		},
+	})
+	defer srv.Close()
+	dr := New(testserver.Config(srv))
+	dr.now = func() time.Time { return time.Unix(1600000000, 0) }
+
+	name, err := dr.instrumentName(goex.BTC_USD, goex.THIS_WEEK_CONTRACT)
+	assert.Nil(t, err)
+	assert.Equal(t, "BTC-18SEP20", name)
+	name, _ = dr.instrumentName(goex.BTC_USD, goex.NEXT_WEEK_CONTRACT)
+	assert.Equal(t, "BTC-25SEP20", name)
+	name, _ = dr.instrumentName(goex.BTC_USD, goex.BI_QUARTER_CONTRACT)
+	assert.Equal(t, "BTC-25DEC20", name)
+	name, _ = dr.instrumentName(goex.BTC_USD, goex.SWAP_CONTRACT)
+	assert.Equal(t, "BTC-PERPETUAL", name)
+
+	//平多为reduce_only的卖单, 10张 = 100美元
+	order, err := dr.LimitFuturesOrder(goex.BTC_USD, goex.BI_QUARTER_CONTRACT, "11000", "10", goex.CLOSE_BUY, goex.PostOnly)
+	assert.Nil(t, err)
+	assert.Equal(t, "BTC-25DEC20", placed.Get("instrument_name"))
+	assert.Equal(t, "100", placed.Get("amount"))
+	assert.Equal(t, "true", placed.Get("reduce_only"))
+	assert.Equal(t, "true", placed.Get("post_only"))
+	assert.Equal(t, "limit", placed.Get("type"))
+	assert.Equal(t, order.ClientOid, placed.Get("label"))
+	assert.Equal(t, "1001", order.OrderID2)
+	assert.Equal(t, goex.ORDER_PART_FINISH, order.Status)
+	assert.Equal(t, 2.0, order.DealAmount)
+
+	order, err = dr.GetFutureOrder("7", goex.BTC_USD, goex.SWAP_CONTRACT)
+	assert.Nil(t, err)
+	assert.Equal(t, goex.ORDER_FINISH, order.Status)
+	assert.Equal(t, goex.OPEN_BUY, order.OType)
+	assert.Equal(t, 5.0, order.Amount)
+	assert.Equal(t, 0.0, order.Price)
+	assert.Equal(t, int64(1600000001000), order.FinishedTime)
+
+	positions, err := dr.GetFuturePosition(goex.BTC_USD, goex.SWAP_CONTRACT)
+	assert.Nil(t, err)
+	assert.Len(t, positions, 1)
+	assert.Equal(t, 20.0, positions[0].SellAmount)
+	assert.Equal(t, 10800.0, positions[0].SellPriceAvg)
+	assert.Equal(t, 15000.0, positions[0].ForceLiquPrice)
+}
+
+func TestDeribit_Error(t *testing.T) {
+	srv := testserver.New(deribitAPI, map[string]testserver.Route{
+		"public/ticker": func(r *testserver.Request) interface{} {
+			return &rpcError{Code: 10028, Message: "too_many_requests"}
+		},
+	})
+	defer srv.Close()
+	dr := New(testserver.Config(srv))
+
+	_, err := dr.GetFutureTicker(goex.BTC_USD, goex.SWAP_CONTRACT)
+	assert.NotNil(t, err)
+	assert.Equal(t, goex.EX_ERR_API_LIMIT.ErrCode, err.(goex.ApiError).ErrCode)
+}
+
+func TestDeribit_Options(t *testing.T) {
+	srv := testserver.New(deribitAPI, map[string]testserver.Route{
+		"public/get_instruments": func(r *testserver.Request) interface{} {
+			return []map[string]interface{}{
+				{"instrument_name": "BTC-25DEC20-12000-P", "kind": "option", "option_type": "put", "strike": 12000,
+					"contract_size": 1, "tick_size": 0.0005, "min_trade_amount": 0.1, "expiration_timestamp": 1608883200000},
+			}
+		},
+		"public/ticker": func(r *testserver.Request) interface{} {
+			params := rpcParams(r)
+			return map[string]interface{}{"instrument_name": params.Get("instrument_name"), "mark_price": 0.05,
+				"mark_iv": 65.5, "bid_iv": 60, "ask_iv": 70, "underlying_price": 10900,
+				"greeks": map[string]interface{}{"delta": -0.6, "gamma": 0.0002, "theta": -10, "vega": 15}}
+		},
+	})
+	defer srv.Close()
+	dr := New(testserver.Config(srv))
+
+	instruments, err := dr.GetOptionInstruments(goex.BTC_USD)
+	assert.Nil(t, err)
+	assert.Len(t, instruments, 1)
+	assert.Equal(t, goex.OPTION_PUT, instruments[0].OptionType)
+	assert.Equal(t, 12000.0, instruments[0].Strike)
+	assert.True(t, instruments[0].Underlying.Eq(goex.BTC_USD))
+
+	ticker, err := dr.GetOptionTicker("btc-25dec20-12000-p")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.655, ticker.MarkIV)
+	assert.Equal(t, -0.6, ticker.Greeks.Delta)
+	assert.Equal(t, 10900.0, ticker.UnderlyingPrice)
+}
@@ -0,0 +1,394 @@
+package deribit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/logger"
+)
+
+const (
+	wsLoginTimeout     = 10 * time.Second
+	wsHeartbeatSeconds = 10
+
+	//控制消息使用固定的id, 订阅从wsSubscribeId开始递增
+	wsHeartbeatId = 1
+	wsAuthId      = 2
+	wsTestId      = 3
+	wsSubscribeId = 100
+)
+
+type rpcRequest struct {
+	JsonRpc string      `json:"jsonrpc"`
+	Id      int64       `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type wsSubscription struct {
+	pair         CurrencyPair
+	contractType string
+}
+
+/**
+ * deribit JSON-RPC 2.0 websocket, 实现FuturesWsApi
+ * 每次(重)连接成功后发送public/set_heartbeat, 服务端的test_request用public/test回复
+ * 配置了api key时, 收到set_heartbeat的响应后通过public/auth认证, access token到期前用refresh token刷新
+ * 深度订阅的是20档快照(book.{instrument}.none.20.100ms), 不需要本地维护增量
+ */
+type FuturesWs struct {
+	c         *WsConn
+	connErr   error
+	once      sync.Once
+	wsBuilder *WsBuilder
+	dr        *Deribit
+	reqId     int64
+
+	loginOnce sync.Once
+	loginErr  error
+	loginResp chan error
+
+	tokenLock    sync.Mutex
+	refreshToken string
+	refreshTimer *time.Timer
+
+	subLock sync.Mutex
+	subs    map[string]wsSubscription //instrument_name => 订阅时的pair和contractType
+
+	depthCall  func(depth *Depth)
+	tickerCall func(ticker *FutureTicker)
+	tradeCall  func(trade *Trade, contract string)
+	orderCall  func(order *FutureOrder)
+}
+
+func NewFuturesWs() *FuturesWs {
+	return NewFuturesWsWithConfig(&APIConfig{})
+}
+
+//config.Endpoint为TestnetUrl时连接测试网
+func NewFuturesWsWithConfig(config *APIConfig) *FuturesWs {
+	s := new(FuturesWs)
+	s.dr = New(config)
+	s.reqId = wsSubscribeId
+	s.loginResp = make(chan error, 1)
+	s.subs = make(map[string]wsSubscription, 4)
+	wsUrl := strings.Replace(s.dr.Endpoint, "https://", "wss://", 1) + "/ws/api/v2"
+	s.wsBuilder = NewWsBuilder().WsUrl(wsUrl).ProtoHandleFunc(s.handle).AutoReconnect()
+	s.wsBuilder = s.wsBuilder.ConnectSuccessAfterSendMessage(s.heartbeatMessage)
+	return s
+}
+
+func (s *FuturesWs) heartbeatMessage() []byte {
+	data, _ := json.Marshal(rpcRequest{
+		JsonRpc: "2.0",
+		Id:      wsHeartbeatId,
+		Method:  "public/set_heartbeat",
+		Params:  map[string]interface{}{"interval": wsHeartbeatSeconds},
+	})
+	return data
+}
+
+func (s *FuturesWs) connect() error {
+	s.once.Do(func() {
+		s.c, s.connErr = s.wsBuilder.Build()
+	})
+	return s.connErr
+}
+
+func (s *FuturesWs) send(id int64, method string, params interface{}) error {
+	if s.c == nil {
+		return errors.New("websocket not connected")
+	}
+	return s.c.SendJsonMessage(rpcRequest{JsonRpc: "2.0", Id: id, Method: method, Params: params})
+}
+
+//重连后refresh token可能已失效, 每次连接都使用api key认证
+func (s *FuturesWs) authenticate(refresh bool) error {
+	params := map[string]string{
+		"grant_type":    "client_credentials",
+		"client_id":     s.dr.ApiKey,
+		"client_secret": s.dr.ApiSecretKey,
+	}
+	if refresh {
+		s.tokenLock.Lock()
+		params = map[string]string{"grant_type": "refresh_token", "refresh_token": s.refreshToken}
+		s.tokenLock.Unlock()
+	}
+	return s.send(wsAuthId, "public/auth", params)
+}
+
+func (s *FuturesWs) onAuth(auth authResult) {
+	s.tokenLock.Lock()
+	defer s.tokenLock.Unlock()
+	s.refreshToken = auth.RefreshToken
+	if s.refreshTimer != nil {
+		s.refreshTimer.Stop()
+	}
+	delay := time.Duration(auth.ExpiresIn)*time.Second - tokenRefreshAhead
+	if delay <= 0 {
+		delay = time.Duration(auth.ExpiresIn) * time.Second / 2
+	}
+	s.refreshTimer = time.AfterFunc(delay, func() {
+		if err := s.authenticate(true); err != nil {
+			logger.Errorf("[deribit ws] refresh token error: %s", err.Error())
+		}
+	})
+}
+
+func (s *FuturesWs) DepthCallback(f func(depth *Depth)) {
+	s.depthCall = f
+}
+
+func (s *FuturesWs) TickerCallback(f func(ticker *FutureTicker)) {
+	s.tickerCall = f
+}
+
+func (s *FuturesWs) TradeCallback(f func(trade *Trade, contract string)) {
+	s.tradeCall = f
+}
+
+func (s *FuturesWs) OrderCallback(f func(order *FutureOrder)) {
+	s.orderCall = f
+}
+
+//contractType转换为合约名称并缓存合约信息, 推送数据中的数量换算不再请求rest接口
+func (s *FuturesWs) subscribe(pair CurrencyPair, contractType, format string, private bool) error {
+	name, err := s.dr.instrumentName(pair, contractType)
+	if err != nil {
+		return err
+	}
+	if _, err = s.dr.getInstrument(name); err != nil {
+		return err
+	}
+
+	if private {
+		err = s.Login()
+	} else {
+		err = s.connect()
+	}
+	if err != nil {
+		return err
+	}
+
+	s.subLock.Lock()
+	s.subs[name] = wsSubscription{pair: pair, contractType: contractType}
+	s.subLock.Unlock()
+
+	method := "public/subscribe"
+	if private {
+		method = "private/subscribe"
+	}
+	return s.c.Subscribe(rpcRequest{
+		JsonRpc: "2.0",
+		Id:      atomic.AddInt64(&s.reqId, 1),
+		Method:  method,
+		Params:  map[string][]string{"channels": {fmt.Sprintf(format, name)}},
+	})
+}
+
+func (s *FuturesWs) SubscribeDepth(pair CurrencyPair, contractType string) error {
+	return s.subscribe(pair, contractType, "book.%s.none.20.100ms", false)
+}
+
+func (s *FuturesWs) SubscribeTicker(pair CurrencyPair, contractType string) error {
+	return s.subscribe(pair, contractType, "ticker.%s.100ms", false)
+}
+
+func (s *FuturesWs) SubscribeTrade(pair CurrencyPair, contractType string) error {
+	return s.subscribe(pair, contractType, "trades.%s.100ms", false)
+}
+
+//需要登录
+func (s *FuturesWs) SubscribeOrder(pair CurrencyPair, contractType string) error {
+	return s.subscribe(pair, contractType, "user.orders.%s.100ms", true)
+}
+
+//等待认证结果, 需要配置api key
+func (s *FuturesWs) Login() error {
+	if s.dr.ApiKey == "" || s.dr.ApiSecretKey == "" {
+		return errors.New("api key is required")
+	}
+	if err := s.connect(); err != nil {
+		return err
+	}
+	s.loginOnce.Do(func() {
+		select {
+		case s.loginErr = <-s.loginResp:
+		case <-time.After(wsLoginTimeout):
+			s.loginErr = errors.New("login timeout")
+		}
+	})
+	return s.loginErr
+}
+
+func (s *FuturesWs) Close() {
+	s.tokenLock.Lock()
+	if s.refreshTimer != nil {
+		s.refreshTimer.Stop()
+	}
+	s.tokenLock.Unlock()
+	if s.c != nil {
+		s.c.CloseWs()
+	}
+}
+
+func (s *FuturesWs) notifyLogin(err error) {
+	select {
+	case s.loginResp <- err:
+	default:
+	}
+}
+
+func (s *FuturesWs) handle(data []byte) error {
+	var msg rpcResponse
+	if err := json.Unmarshal(data, &msg); err != nil {
+		logger.Errorf("[deribit ws] unmarshal error, message: %s", string(data))
+		return err
+	}
+
+	switch {
+	case msg.Method == "heartbeat":
+		var params struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal(msg.Params, &params)
+		if params.Type == "test_request" {
+			return s.send(wsTestId, "public/test", map[string]string{})
+		}
+		return nil
+	case msg.Method == "subscription":
+		var params struct {
+			Channel string          `json:"channel"`
+			Data    json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return err
+		}
+		return s.handleSubscription(params.Channel, params.Data)
+	case msg.Id == wsHeartbeatId:
+		if msg.Error != nil {
+			logger.Errorf("[deribit ws] set heartbeat error: %s", msg.Error.Message)
+		}
+		if s.dr.ApiKey != "" && s.dr.ApiSecretKey != "" {
+			return s.authenticate(false)
+		}
+		return nil
+	case msg.Id == wsAuthId:
+		if msg.Error != nil {
+			err := toApiError(msg.Error)
+			logger.Errorf("[deribit ws] auth error: %s", err.Error())
+			s.notifyLogin(err)
+			return nil
+		}
+		var auth authResult
+		if err := json.Unmarshal(msg.Result, &auth); err != nil {
+			return err
+		}
+		s.onAuth(auth)
+		s.notifyLogin(nil)
+		return nil
+	case msg.Error != nil:
+		logger.Errorf("[deribit ws] request %d error: %d %s", msg.Id, msg.Error.Code, msg.Error.Message)
+	}
+	return nil
+}
+
+//channel如book.BTC-PERPETUAL.none.20.100ms, 第二段为合约名称
+func (s *FuturesWs) handleSubscription(channel string, data json.RawMessage) error {
+	parts := strings.Split(channel, ".")
+	if len(parts) < 2 {
+		return nil
+	}
+	name := parts[1]
+	if parts[0] == "user" && len(parts) > 2 {
+		name = parts[2]
+	}
+
+	s.subLock.Lock()
+	sub, ok := s.subs[name]
+	s.subLock.Unlock()
+	if !ok {
+		sub = wsSubscription{pair: adaptInstrumentToPair(name), contractType: name}
+	}
+
+	switch parts[0] {
+	case "book":
+		var book struct {
+			Timestamp int64        `json:"timestamp"`
+			Bids      [][2]float64 `json:"bids"`
+			Asks      [][2]float64 `json:"asks"`
+		}
+		if err := json.Unmarshal(data, &book); err != nil {
+			return err
+		}
+		depth := &Depth{
+			ContractType: sub.contractType,
+			ContractId:   name,
+			Pair:         sub.pair,
+			UTime:        time.Unix(0, book.Timestamp*int64(time.Millisecond)),
+		}
+		for _, bid := range book.Bids {
+			depth.BidList = append(depth.BidList, DepthRecord{Price: bid[0], Amount: s.dr.toContracts(name, bid[1])})
+		}
+		//asks为升序, 与rest接口一致转换为降序
+		for i := len(book.Asks) - 1; i >= 0; i-- {
+			depth.AskList = append(depth.AskList, DepthRecord{Price: book.Asks[i][0], Amount: s.dr.toContracts(name, book.Asks[i][1])})
+		}
+		if s.depthCall != nil {
+			s.depthCall(depth)
+		}
+	case "ticker":
+		var ticker tickerInfo
+		if err := json.Unmarshal(data, &ticker); err != nil {
+			return err
+		}
+		if s.tickerCall != nil {
+			s.tickerCall(&FutureTicker{
+				Ticker: &Ticker{
+					Pair: sub.pair,
+					Last: ticker.LastPrice,
+					Buy:  ticker.BestBidPrice,
+					Sell: ticker.BestAskPrice,
+					High: ticker.Stats.High,
+					Low:  ticker.Stats.Low,
+					Vol:  ticker.Stats.Volume,
+					Date: uint64(ticker.Timestamp),
+				},
+				ContractType: sub.contractType,
+				ContractId:   name,
+				LimitHigh:    ticker.MaxPrice,
+				LimitLow:     ticker.MinPrice,
+				HoldAmount:   s.dr.toContracts(name, ticker.OpenInterest),
+			})
+		}
+	case "trades":
+		var trades []tradeInfo
+		if err := json.Unmarshal(data, &trades); err != nil {
+			return err
+		}
+		if s.tradeCall != nil {
+			for _, t := range trades {
+				trade := s.dr.adaptTrade(sub.pair, t)
+				s.tradeCall(&trade, sub.contractType)
+			}
+		}
+	case "user":
+		var orders []orderInfo
+		if err := json.Unmarshal(data, &orders); err != nil {
+			return err
+		}
+		if s.orderCall != nil {
+			for _, o := range orders {
+				order := s.dr.adaptOrder(sub.pair, sub.contractType, o)
+				s.orderCall(&order)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package deribit
+
+import (
+	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuturesWs_Handle(t *testing.T) {
+	ws := NewFuturesWsWithConfig(&goex.APIConfig{Endpoint: TestnetUrl, ApiKey: "id", ApiSecretKey: "secret"})
+	ws.dr.instruments["BTC-PERPETUAL"] = Instrument{InstrumentName: "BTC-PERPETUAL", ContractSize: 10}
+	ws.subs["BTC-PERPETUAL"] = wsSubscription{pair: goex.BTC_USD, contractType: goex.SWAP_CONTRACT}
+
+	assert.Nil(t, ws.handle([]byte(`{"jsonrpc":"2.0","id":2,"result":{"access_token":"a","refresh_token":"r","expires_in":900}}`)))
+	assert.Nil(t, <-ws.loginResp)
+	assert.Equal(t, "r", ws.refreshToken)
+	ws.Close()
+
+	var depth *goex.Depth
+	ws.DepthCallback(func(d *goex.Depth) {
+		depth = d
+	})
+	assert.Nil(t, ws.handle([]byte(`{"jsonrpc":"2.0","method":"subscription","params":{"channel":"book.BTC-PERPETUAL.none.20.100ms","data":{"timestamp":1600000000000,"instrument_name":"BTC-PERPETUAL","bids":[[10000,500],[9999.5,100]],"asks":[[10000.5,200],[10001,1000]]}}}`)))
+	assert.Equal(t, goex.SWAP_CONTRACT, depth.ContractType)
+	assert.Equal(t, 50.0, depth.BidList[0].Amount)
+	assert.Equal(t, 10001.0, depth.AskList[0].Price)
+	assert.Equal(t, 20.0, depth.AskList[1].Amount)
+
+	var trades []goex.Trade
+	ws.TradeCallback(func(trade *goex.Trade, contract string) {
+		assert.Equal(t, goex.SWAP_CONTRACT, contract)
+		trades = append(trades, *trade)
+	})
+	assert.Nil(t, ws.handle([]byte(`{"jsonrpc":"2.0","method":"subscription","params":{"channel":"trades.BTC-PERPETUAL.100ms","data":[{"trade_seq":5,"instrument_name":"BTC-PERPETUAL","direction":"sell","price":10000,"amount":30,"timestamp":1600000000000}]}}`)))
+	assert.Len(t, trades, 1)
+	assert.Equal(t, goex.SELL, trades[0].Type)
+	assert.Equal(t, 3.0, trades[0].Amount)
+
+	var orders []goex.FutureOrder
+	ws.OrderCallback(func(order *goex.FutureOrder) {
+		orders = append(orders, *order)
+	})
+	assert.Nil(t, ws.handle([]byte(`{"jsonrpc":"2.0","method":"subscription","params":{"channel":"user.orders.BTC-PERPETUAL.100ms","data":[{"order_id":"1","order_state":"cancelled","instrument_name":"BTC-PERPETUAL","direction":"sell","price":10000,"amount":100}]}}`)))
+	assert.Len(t, orders, 1)
+	assert.Equal(t, goex.ORDER_CANCEL, orders[0].Status)
+	assert.Equal(t, goex.OPEN_SELL, orders[0].OType)
+	assert.Equal(t, 10.0, orders[0].Amount)
+}
@@ -0,0 +1,133 @@
+package deribit
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/lucas7788/goex"
+)
+
+/**
+ * 期权, 实现OptionsAPI, 合约名称如BTC-25JUN21-50000-C
+ * 期权的contract_size为1个币, 数量即币的数量, 价格以币计价
+ * 隐含波动率deribit返回的是百分比, 转换为小数与okex保持一致
+ */
+func (dr *Deribit) GetOptionInstruments(underlying CurrencyPair) ([]OptionInstrument, error) {
+	instruments, err := dr.GetInstruments(underlying.CurrencyA, "option")
+	if err != nil {
+		return nil, err
+	}
+
+	options := make([]OptionInstrument, 0, len(instruments))
+	for _, ins := range instruments {
+		optType := OPTION_CALL
+		if ins.OptionType == "put" {
+			optType = OPTION_PUT
+		}
+		options = append(options, OptionInstrument{
+			InstrumentId: ins.InstrumentName,
+			Underlying:   adaptInstrumentToPair(ins.InstrumentName),
+			Strike:       ins.Strike,
+			OptionType:   optType,
+			Expiry:       time.Unix(0, ins.ExpirationTimestamp*int64(time.Millisecond)),
+			ContractVal:  ins.ContractSize,
+			TickSize:     ins.TickSize,
+			MinSize:      ins.MinTradeAmount,
+		})
+	}
+	return options, nil
+}
+
+func (dr *Deribit) GetOptionTicker(instrumentId string) (*OptionTicker, error) {
+	ticker, err := dr.getTicker(strings.ToUpper(instrumentId))
+	if err != nil {
+		return nil, err
+	}
+	return &OptionTicker{
+		InstrumentId:    ticker.InstrumentName,
+		Last:            ticker.LastPrice,
+		Buy:             ticker.BestBidPrice,
+		Sell:            ticker.BestAskPrice,
+		MarkPrice:       ticker.MarkPrice,
+		BidIV:           ticker.BidIv / 100,
+		AskIV:           ticker.AskIv / 100,
+		MarkIV:          ticker.MarkIv / 100,
+		UnderlyingPrice: ticker.UnderlyingPrice,
+		Greeks: Greeks{
+			Delta: ticker.Greeks.Delta,
+			Gamma: ticker.Greeks.Gamma,
+			Theta: ticker.Greeks.Theta,
+			Vega:  ticker.Greeks.Vega,
+		},
+		Date: ticker.Timestamp,
+	}, nil
+}
+
+func (dr *Deribit) LimitOptionOrder(instrumentId string, side TradeSide, price, amount string, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	openType := OPEN_BUY
+	if side == SELL {
+		openType = OPEN_SELL
+	}
+	name := strings.ToUpper(instrumentId)
+	return dr.placeOrder(adaptInstrumentToPair(name), name, name, price, amount, openType, false, opt...)
+}
+
+func (dr *Deribit) CancelOptionOrder(instrumentId, orderId string) error {
+	_, err := dr.FutureCancelOrder(adaptInstrumentToPair(instrumentId), instrumentId, orderId)
+	return err
+}
+
+func (dr *Deribit) GetOptionOrder(instrumentId, orderId string) (*FutureOrder, error) {
+	name := strings.ToUpper(instrumentId)
+	return dr.GetFutureOrder(orderId, adaptInstrumentToPair(name), name)
+}
+
+func (dr *Deribit) GetUnfinishOptionOrders(underlying CurrencyPair) ([]FutureOrder, error) {
+	params := url.Values{}
+	params.Set("currency", underlying.CurrencyA.Symbol)
+	params.Set("kind", "option")
+	return dr.getOrders("private/get_open_orders_by_currency", params, underlying, "")
+}
+
+func (dr *Deribit) GetOptionPositions(underlying CurrencyPair) ([]OptionPosition, error) {
+	var resp []struct {
+		InstrumentName     string  `json:"instrument_name"`
+		Size               float64 `json:"size"`
+		AveragePrice       float64 `json:"average_price"`
+		MarkPrice          float64 `json:"mark_price"`
+		FloatingProfitLoss float64 `json:"floating_profit_loss"`
+		Delta              float64 `json:"delta"`
+		Gamma              float64 `json:"gamma"`
+		Theta              float64 `json:"theta"`
+		Vega               float64 `json:"vega"`
+	}
+	params := url.Values{}
+	params.Set("currency", underlying.CurrencyA.Symbol)
+	params.Set("kind", "option")
+	err := dr.doRequest("private/get_positions", params, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []OptionPosition
+	for _, p := range resp {
+		if p.Size == 0 {
+			continue
+		}
+		positions = append(positions, OptionPosition{
+			InstrumentId:  p.InstrumentName,
+			Amount:        p.Size,
+			AvgPrice:      p.AveragePrice,
+			MarkPrice:     p.MarkPrice,
+			UnrealizedPnl: p.FloatingProfitLoss,
+			Greeks: Greeks{
+				Delta: p.Delta,
+				Gamma: p.Gamma,
+				Theta: p.Theta,
+				Vega:  p.Vega,
+			},
+		})
+	}
+	return positions, nil
+}
@@ -0,0 +1,584 @@
+package gateio
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	. "github.com/lucas7788/goex"
+)
+
+const futuresPath = "/futures/usdt"
+
+/**
+ * U本位(USDT结算)永续合约, 实现FutureRestAPI
+ * contractType只支持swap, swap-usdt, 合约名称与现货相同, 如BTC_USDT
+ * 数量单位为张, 每张的币数量见GetContractValue
+ * 下单时开多/平空的size为正, 开空/平多的size为负, 平仓单使用reduce_only, 单向和双向持仓模式通用
+ */
+type Futures struct {
+	*Gateio
+
+	lock      sync.Mutex
+	contracts map[string]contractInfo
+}
+
+type contractInfo struct {
+	Name             string `json:"name"`
+	QuantoMultiplier string `json:"quanto_multiplier"`
+	OrderSizeMin     int64  `json:"order_size_min"`
+}
+
+func NewFutures(config *APIConfig) *Futures {
+	return &Futures{Gateio: New(config), contracts: make(map[string]contractInfo, 4)}
+}
+
+func (f *Futures) GetExchangeName() string {
+	return GATEIO
+}
+
+func (f *Futures) adaptContract(pair CurrencyPair, contractType string) (string, error) {
+	switch contractType {
+	case "", SWAP_CONTRACT, SWAP_USDT_CONTRACT:
+	default:
+		return "", EX_ERR_NOT_SUPPORT.OriginErr(contractType + " contract not support")
+	}
+	if !pair.CurrencyB.Eq(USDT) {
+		return "", EX_ERR_SYMBOL_ERR.OriginErr("only support usdt futures: " + pair.String())
+	}
+	return toSymbol(pair), nil
+}
+
+//合约信息不会变化, 缓存后不再请求
+func (f *Futures) getContract(contract string) (*contractInfo, error) {
+	f.lock.Lock()
+	info, ok := f.contracts[contract]
+	f.lock.Unlock()
+	if ok {
+		return &info, nil
+	}
+
+	err := f.doRequest(http.MethodGet, futuresPath+"/contracts/"+contract, nil, nil, &info, false)
+	if err != nil {
+		return nil, err
+	}
+	f.lock.Lock()
+	f.contracts[contract] = info
+	f.lock.Unlock()
+	return &info, nil
+}
+
+type futuresTicker struct {
+	Contract      string `json:"contract"`
+	Last          string `json:"last"`
+	LowestAsk     string `json:"lowest_ask"`
+	HighestBid    string `json:"highest_bid"`
+	High24h       string `json:"high_24h"`
+	Low24h        string `json:"low_24h"`
+	Volume24hBase string `json:"volume_24h_base"`
+	IndexPrice    string `json:"index_price"`
+	MarkPrice     string `json:"mark_price"`
+}
+
+func (f *Futures) getTicker(contract string) (*futuresTicker, error) {
+	var resp []futuresTicker
+	err := f.doRequest(http.MethodGet, futuresPath+"/tickers", url.Values{"contract": {contract}}, nil, &resp, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) == 0 {
+		return nil, EX_ERR_SYMBOL_ERR.OriginErr("no ticker: " + contract)
+	}
+	return &resp[0], nil
+}
+
+func (f *Futures) GetFutureTicker(currencyPair CurrencyPair, contractType string) (*Ticker, error) {
+	contract, err := f.adaptContract(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	t, err := f.getTicker(contract)
+	if err != nil {
+		return nil, err
+	}
+	return &Ticker{
+		Pair: currencyPair,
+		Last: ToFloat64(t.Last),
+		Buy:  ToFloat64(t.HighestBid),
+		Sell: ToFloat64(t.LowestAsk),
+		High: ToFloat64(t.High24h),
+		Low:  ToFloat64(t.Low24h),
+		Vol:  ToFloat64(t.Volume24hBase),
+		Date: uint64(time.Now().UnixNano() / int64(time.Millisecond)),
+	}, nil
+}
+
+//深度的数量为张数
+func (f *Futures) GetFutureDepth(currencyPair CurrencyPair, contractType string, size int) (*Depth, error) {
+	contract, err := f.adaptContract(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("contract", contract)
+	params.Set("limit", fmt.Sprint(size))
+	var resp struct {
+		Current float64 `json:"current"`
+		Asks    []struct {
+			P string `json:"p"`
+			S int64  `json:"s"`
+		} `json:"asks"`
+		Bids []struct {
+			P string `json:"p"`
+			S int64  `json:"s"`
+		} `json:"bids"`
+	}
+	err = f.doRequest(http.MethodGet, futuresPath+"/order_book", params, nil, &resp, false)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := &Depth{
+		ContractType: contractType,
+		ContractId:   contract,
+		Pair:         currencyPair,
+		UTime:        time.Unix(0, toMillis(resp.Current)*int64(time.Millisecond)),
+	}
+	for _, b := range resp.Bids {
+		depth.BidList = append(depth.BidList, DepthRecord{Price: ToFloat64(b.P), Amount: float64(b.S)})
+	}
+	for _, a := range resp.Asks {
+		depth.AskList = append(depth.AskList, DepthRecord{Price: ToFloat64(a.P), Amount: float64(a.S)})
+	}
+	depth.AskList = reverseAsks(depth.AskList)
+	return depth, nil
+}
+
+func (f *Futures) GetFutureIndex(currencyPair CurrencyPair) (float64, error) {
+	contract, err := f.adaptContract(currencyPair, SWAP_CONTRACT)
+	if err != nil {
+		return 0, err
+	}
+	t, err := f.getTicker(contract)
+	if err != nil {
+		return 0, err
+	}
+	return ToFloat64(t.IndexPrice), nil
+}
+
+//永续合约没有交割预估价
+func (f *Futures) GetFutureEstimatedPrice(currencyPair CurrencyPair) (float64, error) {
+	return 0, EX_ERR_NOT_SUPPORT.OriginErr("perpetual contract has no estimated delivery price")
+}
+
+//保证金账户只有USDT, total不包含未实现盈亏
+func (f *Futures) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureAccount, error) {
+	var resp struct {
+		Total          string `json:"total"`
+		UnrealisedPnl  string `json:"unrealised_pnl"`
+		PositionMargin string `json:"position_margin"`
+		OrderMargin    string `json:"order_margin"`
+		Available      string `json:"available"`
+		Currency       string `json:"currency"`
+	}
+	err := f.doRequest(http.MethodGet, futuresPath+"/accounts", nil, nil, &resp, true)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := NewCurrency(resp.Currency, "")
+	unrealised := ToFloat64(resp.UnrealisedPnl)
+	return &FutureAccount{FutureSubAccounts: map[Currency]FutureSubAccount{
+		currency: {
+			Currency:      currency,
+			AccountRights: ToFloat64(resp.Total) + unrealised,
+			KeepDeposit:   ToFloat64(resp.PositionMargin) + ToFloat64(resp.OrderMargin),
+			ProfitUnreal:  unrealised,
+		},
+	}}, nil
+}
+
+type futuresOrderParam struct {
+	Contract   string `json:"contract"`
+	Size       int64  `json:"size"`
+	Price      string `json:"price"`
+	Tif        string `json:"tif"`
+	Text       string `json:"text"`
+	ReduceOnly bool   `json:"reduce_only,omitempty"`
+}
+
+type futuresOrder struct {
+	Id           int64   `json:"id"`
+	Contract     string  `json:"contract"`
+	CreateTime   float64 `json:"create_time"`
+	FinishTime   float64 `json:"finish_time"`
+	FinishAs     string  `json:"finish_as"`
+	Status       string  `json:"status"` //open, finished
+	Size         int64   `json:"size"`
+	Price        string  `json:"price"`
+	IsReduceOnly bool    `json:"is_reduce_only"`
+	IsClose      bool    `json:"is_close"`
+	Tif          string  `json:"tif"`
+	Left         int64   `json:"left"`
+	FillPrice    string  `json:"fill_price"`
+	Text         string  `json:"text"`
+}
+
+//openType => size的符号和reduce_only
+func adaptOpenType(openType int) (sign int64, reduceOnly bool, err error) {
+	switch openType {
+	case OPEN_BUY:
+		return 1, false, nil
+	case OPEN_SELL:
+		return -1, false, nil
+	case CLOSE_BUY:
+		return -1, true, nil
+	case CLOSE_SELL:
+		return 1, true, nil
+	}
+	return 0, false, fmt.Errorf("unknown open type: %d", openType)
+}
+
+func adaptSizeToOpenType(size int64, reduceOnly bool) int {
+	switch {
+	case size > 0 && reduceOnly:
+		return CLOSE_SELL
+	case size > 0:
+		return OPEN_BUY
+	case reduceOnly:
+		return CLOSE_BUY
+	}
+	return OPEN_SELL
+}
+
+func (f *Futures) placeOrder(pair CurrencyPair, contractType, price, amount string, openType int, isMarket bool, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	contract, err := f.adaptContract(pair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	sign, reduceOnly, err := adaptOpenType(openType)
+	if err != nil {
+		return nil, err
+	}
+
+	fOrder := &FutureOrder{
+		ClientOid:    newClientOid(),
+		Currency:     pair,
+		Price:        ToFloat64(price),
+		Amount:       ToFloat64(amount),
+		OType:        openType,
+		OrderType:    ORDER_FEATURE_ORDINARY,
+		ContractName: contractType,
+		OrderTime:    time.Now().UnixNano() / int64(time.Millisecond),
+	}
+
+	param := futuresOrderParam{
+		Contract:   contract,
+		Size:       sign * int64(fOrder.Amount),
+		Price:      price,
+		Text:       fOrder.ClientOid,
+		ReduceOnly: reduceOnly,
+	}
+	if isMarket {
+		//价格为0, tif为ioc时是市价单
+		param.Price = "0"
+		param.Tif = "ioc"
+		fOrder.Price = 0
+	} else {
+		param.Tif, fOrder.OrderType = timeInForce(opt...)
+	}
+
+	var resp futuresOrder
+	err = f.doRequest(http.MethodPost, futuresPath+"/orders", nil, param, &resp, true)
+	if err != nil {
+		return fOrder, err
+	}
+	fOrder.OrderID2 = fmt.Sprint(resp.Id)
+	fOrder.Status = adaptFuturesOrderStatus(resp)
+	return fOrder, nil
+}
+
+//杠杆倍数在gate.io按合约设置, 下单时不使用leverRate
+func (f *Futures) PlaceFutureOrder(currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice int, leverRate float64) (string, error) {
+	fOrder, err := f.placeOrder(currencyPair, contractType, price, amount, openType, matchPrice == 1)
+	if err != nil {
+		return "", err
+	}
+	return fOrder.OrderID2, nil
+}
+
+func (f *Futures) LimitFuturesOrder(currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	return f.placeOrder(currencyPair, contractType, price, amount, openType, false, opt...)
+}
+
+func (f *Futures) MarketFuturesOrder(currencyPair CurrencyPair, contractType, amount string, openType int) (*FutureOrder, error) {
+	return f.placeOrder(currencyPair, contractType, "0", amount, openType, true)
+}
+
+func (f *Futures) FutureCancelOrder(currencyPair CurrencyPair, contractType, orderId string) (bool, error) {
+	if _, err := f.adaptContract(currencyPair, contractType); err != nil {
+		return false, err
+	}
+	err := f.doRequest(http.MethodDelete, futuresPath+"/orders/"+orderId, nil, nil, nil, true)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+//双向持仓时多空各一条, 合并为一条FuturePosition
+func (f *Futures) GetFuturePosition(currencyPair CurrencyPair, contractType string) ([]FuturePosition, error) {
+	contract, err := f.adaptContract(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	var resp []struct {
+		Contract           string `json:"contract"`
+		Size               int64  `json:"size"`
+		Leverage           string `json:"leverage"`
+		CrossLeverageLimit string `json:"cross_leverage_limit"`
+		EntryPrice         string `json:"entry_price"`
+		LiqPrice           string `json:"liq_price"`
+		UnrealisedPnl      string `json:"unrealised_pnl"`
+		RealisedPnl        string `json:"realised_pnl"`
+		OpenTime           int64  `json:"open_time"`
+	}
+	err = f.doRequest(http.MethodGet, futuresPath+"/positions", url.Values{"holding": {"true"}}, nil, &resp, true)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := FuturePosition{Symbol: currencyPair, ContractType: contractType}
+	for _, p := range resp {
+		if p.Contract != contract || p.Size == 0 {
+			continue
+		}
+		//leverage为0时是全仓
+		pos.LeverRate = ToFloat64(p.Leverage)
+		if pos.LeverRate == 0 {
+			pos.LeverRate = ToFloat64(p.CrossLeverageLimit)
+		}
+		pos.CreateDate = p.OpenTime * 1000
+		pos.ForceLiquPrice = ToFloat64(p.LiqPrice)
+		size := math.Abs(float64(p.Size))
+		if p.Size > 0 {
+			pos.BuyAmount = size
+			pos.BuyAvailable = size
+			pos.BuyPriceAvg = ToFloat64(p.EntryPrice)
+			pos.BuyPriceCost = pos.BuyPriceAvg
+			pos.BuyProfit = ToFloat64(p.UnrealisedPnl)
+			pos.BuyProfitReal = ToFloat64(p.RealisedPnl)
+		} else {
+			pos.SellAmount = size
+			pos.SellAvailable = size
+			pos.SellPriceAvg = ToFloat64(p.EntryPrice)
+			pos.SellPriceCost = pos.SellPriceAvg
+			pos.SellProfit = ToFloat64(p.UnrealisedPnl)
+			pos.SellProfitReal = ToFloat64(p.RealisedPnl)
+		}
+	}
+	if pos.BuyAmount == 0 && pos.SellAmount == 0 {
+		return nil, nil
+	}
+	return []FuturePosition{pos}, nil
+}
+
+func adaptFuturesOrderStatus(o futuresOrder) TradeStatus {
+	switch {
+	case o.Status == "finished" && o.Left == 0:
+		return ORDER_FINISH
+	case o.Status == "finished":
+		return ORDER_CANCEL
+	case math.Abs(float64(o.Left)) < math.Abs(float64(o.Size)):
+		return ORDER_PART_FINISH
+	}
+	return ORDER_UNFINISH
+}
+
+func (f *Futures) adaptOrder(pair CurrencyPair, contractType string, o futuresOrder) FutureOrder {
+	order := FutureOrder{
+		ClientOid:    o.Text,
+		OrderID2:     fmt.Sprint(o.Id),
+		Price:        ToFloat64(o.Price),
+		Amount:       math.Abs(float64(o.Size)),
+		AvgPrice:     ToFloat64(o.FillPrice),
+		DealAmount:   math.Abs(float64(o.Size)) - math.Abs(float64(o.Left)),
+		OrderTime:    toMillis(o.CreateTime),
+		Status:       adaptFuturesOrderStatus(o),
+		Currency:     pair,
+		OrderType:    adaptTimeInForce(o.Tif),
+		OType:        adaptSizeToOpenType(o.Size, o.IsReduceOnly || o.IsClose),
+		ContractName: contractType,
+	}
+	if o.Status == "finished" {
+		order.FinishedTime = toMillis(o.FinishTime)
+	}
+	return order
+}
+
+func (f *Futures) GetFutureOrder(orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error) {
+	if _, err := f.adaptContract(currencyPair, contractType); err != nil {
+		return nil, err
+	}
+	var resp futuresOrder
+	err := f.doRequest(http.MethodGet, futuresPath+"/orders/"+orderId, nil, nil, &resp, true)
+	if err != nil {
+		return nil, err
+	}
+	order := f.adaptOrder(currencyPair, contractType, resp)
+	return &order, nil
+}
+
+func (f *Futures) GetFutureOrders(orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	orders := make([]FutureOrder, 0, len(orderIds))
+	for _, id := range orderIds {
+		order, err := f.GetFutureOrder(id, currencyPair, contractType)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+	return orders, nil
+}
+
+func (f *Futures) listOrders(pair CurrencyPair, contractType, status string, optional ...OptionalParameter) ([]FutureOrder, error) {
+	contract, err := f.adaptContract(pair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("contract", contract)
+	params.Set("status", status)
+	MergeOptionalParameter(&params, optional...)
+	var resp []futuresOrder
+	err = f.doRequest(http.MethodGet, futuresPath+"/orders", params, nil, &resp, true)
+	if err != nil {
+		return nil, err
+	}
+	orders := make([]FutureOrder, 0, len(resp))
+	for _, o := range resp {
+		orders = append(orders, f.adaptOrder(pair, contractType, o))
+	}
+	return orders, nil
+}
+
+func (f *Futures) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	return f.listOrders(currencyPair, contractType, "open")
+}
+
+//optional支持limit, offset, last_id
+func (f *Futures) GetFutureOrderHistory(pair CurrencyPair, contractType string, optional ...OptionalParameter) ([]FutureOrder, error) {
+	return f.listOrders(pair, contractType, "finished", optional...)
+}
+
+//非VIP的taker手续费
+func (f *Futures) GetFee() (float64, error) {
+	return 0.0005, nil
+}
+
+//每张合约的币数量, 如BTC_USDT为0.0001
+func (f *Futures) GetContractValue(currencyPair CurrencyPair) (float64, error) {
+	contract, err := f.adaptContract(currencyPair, SWAP_CONTRACT)
+	if err != nil {
+		return 0, err
+	}
+	info, err := f.getContract(contract)
+	if err != nil {
+		return 0, err
+	}
+	return ToFloat64(info.QuantoMultiplier), nil
+}
+
+//永续合约没有交割时间
+func (f *Futures) GetDeliveryTime() (int, int, int, int) {
+	return 0, 0, 0, 0
+}
+
+//Vol为张数, Vol2为币的数量
+func (f *Futures) GetKlineRecords(contractType string, currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]FutureKline, error) {
+	contract, err := f.adaptContract(currency, contractType)
+	if err != nil {
+		return nil, err
+	}
+	params, err := klineParams("contract", contract, period, size, optional...)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.getContract(contract)
+	if err != nil {
+		return nil, err
+	}
+	var resp []struct {
+		T int64  `json:"t"`
+		V int64  `json:"v"`
+		C string `json:"c"`
+		H string `json:"h"`
+		L string `json:"l"`
+		O string `json:"o"`
+	}
+	err = f.doRequest(http.MethodGet, futuresPath+"/candlesticks", params, nil, &resp, false)
+	if err != nil {
+		return nil, err
+	}
+
+	multiplier := ToFloat64(info.QuantoMultiplier)
+	klines := make([]FutureKline, 0, len(resp))
+	for _, r := range resp {
+		klines = append(klines, FutureKline{
+			Kline: &Kline{
+				Pair:      currency,
+				Timestamp: r.T,
+				Open:      ToFloat64(r.O),
+				High:      ToFloat64(r.H),
+				Low:       ToFloat64(r.L),
+				Close:     ToFloat64(r.C),
+				Vol:       float64(r.V),
+			},
+			Vol2: float64(r.V) * multiplier,
+		})
+	}
+	return klines, nil
+}
+
+//since为毫秒时间戳, 大于0时返回该时间之后的成交, 数量为张数
+func (f *Futures) GetTrades(contractType string, currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	contract, err := f.adaptContract(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("contract", contract)
+	params.Set("limit", "100")
+	if since > 0 {
+		params.Set("from", fmt.Sprint(since/1000))
+	}
+	var resp []struct {
+		Id         int64   `json:"id"`
+		CreateTime float64 `json:"create_time"`
+		Size       int64   `json:"size"`
+		Price      string  `json:"price"`
+	}
+	err = f.doRequest(http.MethodGet, futuresPath+"/trades", params, nil, &resp, false)
+	if err != nil {
+		return nil, err
+	}
+	trades := make([]Trade, 0, len(resp))
+	for _, t := range resp {
+		side := BUY
+		if t.Size < 0 {
+			side = SELL
+		}
+		trades = append(trades, Trade{
+			Tid:    t.Id,
+			Type:   side,
+			Amount: math.Abs(float64(t.Size)),
+			Price:  ToFloat64(t.Price),
+			Date:   toMillis(t.CreateTime),
+			Pair:   currencyPair,
+		})
+	}
+	return trades, nil
+}
@@ -0,0 +1,246 @@
+package gateio
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/logger"
+)
+
+const (
+	baseUrl   = "https://api.gateio.ws"
+	apiPrefix = "/api/v4"
+)
+
+/**
+ * gate.io v4 接口, 现货(Spot)和U本位永续合约(Futures)共用签名和请求
+ * 签名: hex(hmac_sha512(secret, method\npath\nquery\nhex(sha512(body))\ntimestamp))
+ * path包含/api/v4前缀, timestamp为同步后服务器时间的秒数
+ */
+type Gateio struct {
+	*APIConfig
+	clock *ClockSync
+}
+
+func New(config *APIConfig) *Gateio {
+	if config.Endpoint == "" {
+		config.Endpoint = baseUrl
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	if config.HttpClient == nil {
+		config.HttpClient = http.DefaultClient
+	}
+	g := &Gateio{APIConfig: config}
+	g.clock = SharedClockSync(g.Endpoint+apiPrefix+"/spot/time", g.GetServerTime)
+	return g
+}
+
+//服务器毫秒时间戳
+func (g *Gateio) GetServerTime() (int64, error) {
+	respData, err := NewHttpRequest(g.HttpClient, "GET", g.Endpoint+apiPrefix+"/spot/time", "", nil)
+	if err != nil {
+		return 0, err
+	}
+	var resp struct {
+		ServerTime int64 `json:"server_time"`
+	}
+	if err = json.Unmarshal(respData, &resp); err != nil {
+		return 0, err
+	}
+	return resp.ServerTime, nil
+}
+
+func (g *Gateio) sign(method, path, query, body, timestamp string) string {
+	hash := sha512.Sum512([]byte(body))
+	payload := strings.Join([]string{method, path, query, hex.EncodeToString(hash[:]), timestamp}, "\n")
+	sign, _ := GetParamHmacSHA512Sign(g.ApiSecretKey, payload)
+	return sign
+}
+
+/**
+ * 下单接口成功时返回201, 所以不使用NewHttpRequest
+ * params放在query string, body不为nil时序列化为json
+ */
+func (g *Gateio) doRequest(method, path string, params url.Values, body interface{}, result interface{}, authenticated bool) error {
+	var (
+		uri      = apiPrefix + path
+		query    = params.Encode()
+		postData string
+	)
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		postData = string(data)
+	}
+	reqUrl := g.Endpoint + uri
+	if query != "" {
+		reqUrl += "?" + query
+	}
+
+	req, err := http.NewRequest(method, reqUrl, strings.NewReader(postData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if authenticated {
+		if g.ApiKey == "" {
+			return EX_ERR_NOT_FIND_APIKEY
+		}
+		timestamp := fmt.Sprint(g.clock.Now().Unix())
+		req.Header.Set("KEY", g.ApiKey)
+		req.Header.Set("Timestamp", timestamp)
+		req.Header.Set("SIGN", g.sign(method, uri, query, postData, timestamp))
+	}
+
+	logger.Debugf("[%s] request url: %s", method, reqUrl)
+	resp, err := g.HttpClient.Do(req)
+	if err != nil {
+		return HTTP_ERR_CODE.OriginErr(err.Error())
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	logger.Debugf("[gateio] response: %s", string(data))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResponse struct {
+			Label   string `json:"label"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &errResponse)
+		if errResponse.Label == "" {
+			return fmt.Errorf("HttpStatusCode:%d ,Desc:%s", resp.StatusCode, string(data))
+		}
+		return toApiError(errResponse.Label, errResponse.Message)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
+
+func toApiError(label, msg string) error {
+	errMsg := label + ": " + msg
+	switch label {
+	case "INVALID_SIGNATURE", "INVALID_KEY", "MISSING_REQUIRED_HEADER", "REQUEST_EXPIRED":
+		return EX_ERR_SIGN.OriginErr(errMsg)
+	case "TOO_MANY_REQUESTS":
+		return EX_ERR_API_LIMIT.OriginErr(errMsg)
+	case "ORDER_NOT_FOUND", "ORDER_CLOSED", "ORDER_CANCELLED":
+		return EX_ERR_NOT_FIND_ORDER.OriginErr(errMsg)
+	case "BALANCE_NOT_ENOUGH", "INSUFFICIENT_AVAILABLE", "MARGIN_BALANCE_NOT_ENOUGH":
+		return EX_ERR_INSUFFICIENT_BALANCE.OriginErr(errMsg)
+	case "INVALID_CURRENCY_PAIR", "INVALID_CURRENCY", "CONTRACT_NOT_FOUND":
+		return EX_ERR_SYMBOL_ERR.OriginErr(errMsg)
+	}
+	return API_ERR.OriginErr(errMsg)
+}
+
+//BTC_USDT => BTC_USDT, 现货和合约的名称相同
+func toSymbol(pair CurrencyPair) string {
+	return pair.ToUpper().ToSymbol("_")
+}
+
+//订单的text必须以t-开头, t-之后不超过28个字符
+func newClientOid() string {
+	return "t-" + GenerateOrderClientId(29)
+}
+
+//PostOnly, Ioc, Fok => time_in_force
+func timeInForce(opt ...LimitOrderOptionalParameter) (string, int) {
+	if len(opt) > 0 {
+		switch opt[0] {
+		case PostOnly:
+			return "poc", ORDER_FEATURE_POST_ONLY
+		case Ioc:
+			return "ioc", ORDER_FEATURE_IOC
+		case Fok:
+			return "fok", ORDER_FEATURE_FOK
+		}
+	}
+	return "gtc", ORDER_FEATURE_ORDINARY
+}
+
+func adaptTimeInForce(tif string) int {
+	switch tif {
+	case "poc":
+		return ORDER_FEATURE_POST_ONLY
+	case "ioc":
+		return ORDER_FEATURE_IOC
+	case "fok":
+		return ORDER_FEATURE_FOK
+	}
+	return ORDER_FEATURE_ORDINARY
+}
+
+func adaptKlinePeriod(period KlinePeriod) string {
+	switch period {
+	case KLINE_PERIOD_1MIN:
+		return "1m"
+	case KLINE_PERIOD_5MIN:
+		return "5m"
+	case KLINE_PERIOD_15MIN:
+		return "15m"
+	case KLINE_PERIOD_30MIN:
+		return "30m"
+	case KLINE_PERIOD_1H, KLINE_PERIOD_60MIN:
+		return "1h"
+	case KLINE_PERIOD_4H:
+		return "4h"
+	case KLINE_PERIOD_8H:
+		return "8h"
+	case KLINE_PERIOD_1DAY:
+		return "1d"
+	case KLINE_PERIOD_1WEEK:
+		return "7d"
+	case KLINE_PERIOD_1MONTH:
+		return "30d"
+	}
+	return ""
+}
+
+//K线查询参数, optional支持startTime
+func klineParams(key, symbol string, period KlinePeriod, size int, optional ...OptionalParameter) (url.Values, error) {
+	interval := adaptKlinePeriod(period)
+	if interval == "" {
+		return nil, EX_ERR_NOT_SUPPORT.OriginErr("unsupported kline period")
+	}
+	params := url.Values{}
+	params.Set(key, symbol)
+	params.Set("interval", interval)
+	if len(optional) > 0 && optional[0].GetTime("startTime") != nil {
+		//指定from时不能同时指定limit
+		params.Set("from", fmt.Sprint(optional[0].GetTime("startTime").Unix()))
+	} else {
+		params.Set("limit", fmt.Sprint(size))
+	}
+	return params, nil
+}
+
+//asks为升序, 转换为降序
+func reverseAsks(asks DepthRecords) DepthRecords {
+	for i, j := 0, len(asks)-1; i < j; i, j = i+1, j-1 {
+		asks[i], asks[j] = asks[j], asks[i]
+	}
+	return asks
+}
+
+//秒级浮点时间戳(合约接口) => 毫秒
+func toMillis(ts float64) int64 {
+	return int64(ts * float64(time.Second/time.Millisecond))
+}
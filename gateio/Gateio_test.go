@@ -0,0 +1,228 @@
+package gateio
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+//v4接口, 路由的key为"METHOD /path", 返回值直接序列化为body
+var gateioAPI = testserver.Options{
+	Fixed: map[string]testserver.Route{
+		"/api/v4/spot/time": func(r *testserver.Request) interface{} {
+			return map[string]interface{}{"server_time": time.Now().UnixNano() / 1e6}
+		},
+	},
+}
+
+func TestSpot_Order(t *testing.T) {
+	var (
+		header  http.Header
+		body    map[string]interface{}
+		rawBody []byte
+	)
+	srv := testserver.New(gateioAPI, map[string]testserver.Route{
+		"POST /api/v4/spot/orders": func(r *testserver.Request) interface{} {
+			b := r.JSON()
+			header, body = r.Header, b
+			rawBody, _ = ioutil.ReadAll(r.Body)
+			return testserver.Response{Status: http.StatusCreated, Body: map[string]interface{}{"id": "1001", "text": b["text"], "status": "open", "amount": "0.01", "left": "0.01"}}
+		},
+		"GET /api/v4/spot/orders/1001": func(r *testserver.Request) interface{} {
+			return map[string]interface{}{"id": "1001", "text": "t-abc", "status": "open", "type": "limit", "side": "sell",
+				"amount": "0.01", "left": "0.004", "price": "20000", "time_in_force": "poc", "avg_deal_price": "20000", "create_time_ms": 1700000000123}
+		},
+		"DELETE /api/v4/spot/orders/1002": func(r *testserver.Request) interface{} {
+			return testserver.Response{Status: http.StatusNotFound, Body: map[string]interface{}{"label": "ORDER_NOT_FOUND", "message": "Order not found"}}
+		},
+	})
+	defer srv.Close()
+	config := testserver.Config(srv)
+
+	spot := NewSpot(config)
+	order, err := spot.LimitBuy("0.01", "20000", goex.BTC_USDT, goex.PostOnly)
+	assert.Nil(t, err)
+	assert.Equal(t, "1001", order.OrderID2)
+	assert.Equal(t, goex.ORDER_UNFINISH, order.Status)
+	assert.Equal(t, "BTC_USDT", body["currency_pair"])
+	assert.Equal(t, "poc", body["time_in_force"])
+	assert.Equal(t, order.Cid, body["text"])
+	assert.Len(t, order.Cid, 30)
+
+	assert.Equal(t, "key", header.Get("KEY"))
+	assert.Equal(t, header.Get("SIGN"), spot.sign(http.MethodPost, "/api/v4/spot/orders", "", string(rawBody), header.Get("Timestamp")))
+
+	o, err := spot.GetOneOrder("1001", goex.BTC_USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, goex.SELL, o.Side)
+	assert.Equal(t, goex.ORDER_PART_FINISH, o.Status)
+	assert.Equal(t, goex.ORDER_FEATURE_POST_ONLY, o.OrderType)
+	assert.InDelta(t, 0.006, o.DealAmount, 1e-9)
+
+	_, err = spot.CancelOrder("1002", goex.BTC_USDT)
+	assert.Equal(t, goex.EX_ERR_NOT_FIND_ORDER.ErrCode, err.(goex.ApiError).ErrCode)
+}
+
+func TestFutures_Order(t *testing.T) {
+	var bodies []map[string]interface{}
+	srv := testserver.New(gateioAPI, map[string]testserver.Route{
+		"POST /api/v4/futures/usdt/orders": func(r *testserver.Request) interface{} {
+			b := r.JSON()
+			bodies = append(bodies, b)
+			return testserver.Response{Status: http.StatusCreated, Body: map[string]interface{}{"id": 15675394, "status": "open", "size": b["size"], "left": b["size"]}}
+		},
+		"GET /api/v4/futures/usdt/orders": func(r *testserver.Request) interface{} {
+			return []map[string]interface{}{
+				{"id": 1, "contract": "BTC_USDT", "create_time": 1700000000.5, "status": "open", "size": -10, "left": -4,
+					"price": "30000", "is_reduce_only": true, "tif": "gtc", "fill_price": "30000", "text": "t-abc"},
+			}
+		},
+	})
+	defer srv.Close()
+	config := testserver.Config(srv)
+
+	f := NewFutures(config)
+
+	//平多为负数size的reduce_only单
+	order, err := f.LimitFuturesOrder(goex.BTC_USDT, goex.SWAP_CONTRACT, "30000", "10", goex.CLOSE_BUY)
+	assert.Nil(t, err)
+	assert.Equal(t, "15675394", order.OrderID2)
+	assert.Equal(t, -10.0, bodies[0]["size"])
+	assert.Equal(t, true, bodies[0]["reduce_only"])
+	assert.Equal(t, "gtc", bodies[0]["tif"])
+
+	//市价开空为价格0的ioc单
+	_, err = f.MarketFuturesOrder(goex.BTC_USDT, goex.SWAP_CONTRACT, "5", goex.OPEN_SELL)
+	assert.Nil(t, err)
+	assert.Equal(t, -5.0, bodies[1]["size"])
+	assert.Nil(t, bodies[1]["reduce_only"])
+	assert.Equal(t, "0", bodies[1]["price"])
+	assert.Equal(t, "ioc", bodies[1]["tif"])
+
+	orders, err := f.GetUnfinishFutureOrders(goex.BTC_USDT, goex.SWAP_CONTRACT)
+	assert.Nil(t, err)
+	assert.Len(t, orders, 1)
+	assert.Equal(t, goex.CLOSE_BUY, orders[0].OType)
+	assert.Equal(t, goex.ORDER_PART_FINISH, orders[0].Status)
+	assert.Equal(t, 10.0, orders[0].Amount)
+	assert.Equal(t, 6.0, orders[0].DealAmount)
+	assert.Equal(t, int64(1700000000500), orders[0].OrderTime)
+
+	_, err = f.GetFutureTicker(goex.BTC_USDT, goex.QUARTER_CONTRACT)
+	assert.NotNil(t, err)
+}
+
+func TestHistory(t *testing.T) {
+	var query url.Values
+	srv := testserver.New(gateioAPI, map[string]testserver.Route{
+		"GET /api/v4/spot/orders": func(r *testserver.Request) interface{} {
+			query = r.URL.Query()
+			return []map[string]interface{}{
+				{"id": "1", "text": "t-a", "status": "closed", "type": "limit", "side": "buy", "amount": "0.01", "left": "0",
+					"price": "20000", "time_in_force": "gtc", "filled_amount": "0.01", "avg_deal_price": "19999", "fee": "0.00002",
+					"create_time_ms": 1700000000123, "update_time_ms": 1700000001456},
+				{"id": "2", "status": "cancelled", "type": "market", "side": "sell", "amount": "0.02", "left": "0.015",
+					"time_in_force": "ioc", "avg_deal_price": "19990", "create_time_ms": 1700000002000, "update_time_ms": 1700000003000},
+			}
+		},
+		"GET /api/v4/spot/trades": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "1700000000", r.URL.Query().Get("from"))
+			return []map[string]interface{}{
+				{"id": "1232893232", "create_time": "1700000000", "create_time_ms": "1700000000123.456", "side": "sell", "amount": "0.5", "price": "20001"},
+			}
+		},
+		"GET /api/v4/futures/usdt/orders": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "finished", r.URL.Query().Get("status"))
+			return []map[string]interface{}{
+				{"id": 11, "contract": "BTC_USDT", "create_time": 1700000000.5, "finish_time": 1700000010.25, "finish_as": "filled",
+					"status": "finished", "size": 10, "left": 0, "price": "30000", "tif": "gtc", "fill_price": "29999"},
+				{"id": 12, "contract": "BTC_USDT", "create_time": 1700000000.5, "finish_time": 1700000020, "finish_as": "cancelled",
+					"status": "finished", "size": -10, "left": -6, "price": "31000", "tif": "poc", "is_close": true},
+			}
+		},
+		"GET /api/v4/futures/usdt/trades": func(r *testserver.Request) interface{} {
+			return []map[string]interface{}{
+				{"id": 121234231, "create_time": 1514764800.123, "contract": "BTC_USDT", "size": -100, "price": "100.123"},
+				{"id": 121234232, "create_time": 1514764801, "contract": "BTC_USDT", "size": 20, "price": "100.2"},
+			}
+		},
+	})
+	defer srv.Close()
+	config := testserver.Config(srv)
+
+	spot := NewSpot(config)
+	orders, err := spot.GetOrderHistorys(goex.BTC_USDT, goex.OptionalParameter{}.Optional("limit", "2"))
+	assert.Nil(t, err)
+	assert.Equal(t, "finished", query.Get("status"))
+	assert.Equal(t, "BTC_USDT", query.Get("currency_pair"))
+	assert.Equal(t, "2", query.Get("limit"))
+	assert.Len(t, orders, 2)
+	assert.Equal(t, goex.Order{Price: 20000, Amount: 0.01, AvgPrice: 19999, DealAmount: 0.01, Fee: 0.00002, Cid: "t-a", OrderID2: "1",
+		Status: goex.ORDER_FINISH, Currency: goex.BTC_USDT, Side: goex.BUY, Type: "limit", OrderType: goex.ORDER_FEATURE_ORDINARY,
+		OrderTime: 1700000000123, FinishedTime: 1700000001456}, orders[0])
+	//没有filled_amount时按amount-left计算
+	assert.Equal(t, goex.SELL_MARKET, orders[1].Side)
+	assert.Equal(t, goex.ORDER_CANCEL, orders[1].Status)
+	assert.InDelta(t, 0.005, orders[1].DealAmount, 1e-9)
+	assert.Equal(t, goex.ORDER_FEATURE_IOC, orders[1].OrderType)
+
+	trades, err := spot.GetTrades(goex.BTC_USDT, 1700000000123)
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.Trade{{Tid: 1232893232, Type: goex.SELL, Amount: 0.5, Price: 20001, Date: 1700000000123, Pair: goex.BTC_USDT}}, trades)
+
+	f := NewFutures(config)
+	futureOrders, err := f.GetFutureOrderHistory(goex.BTC_USDT, goex.SWAP_CONTRACT)
+	assert.Nil(t, err)
+	assert.Len(t, futureOrders, 2)
+	assert.Equal(t, goex.ORDER_FINISH, futureOrders[0].Status)
+	assert.Equal(t, goex.OPEN_BUY, futureOrders[0].OType)
+	assert.Equal(t, 10.0, futureOrders[0].DealAmount)
+	assert.Equal(t, 29999.0, futureOrders[0].AvgPrice)
+	assert.Equal(t, int64(1700000000500), futureOrders[0].OrderTime)
+	assert.Equal(t, int64(1700000010250), futureOrders[0].FinishedTime)
+	//部分成交后撤单, 平多
+	assert.Equal(t, goex.ORDER_CANCEL, futureOrders[1].Status)
+	assert.Equal(t, goex.CLOSE_BUY, futureOrders[1].OType)
+	assert.Equal(t, 4.0, futureOrders[1].DealAmount)
+	assert.Equal(t, goex.ORDER_FEATURE_POST_ONLY, futureOrders[1].OrderType)
+	assert.Equal(t, goex.SWAP_CONTRACT, futureOrders[1].ContractName)
+
+	trades, err = f.GetTrades(goex.SWAP_CONTRACT, goex.BTC_USDT, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.Trade{
+		{Tid: 121234231, Type: goex.SELL, Amount: 100, Price: 100.123, Date: 1514764800123, Pair: goex.BTC_USDT},
+		{Tid: 121234232, Type: goex.BUY, Amount: 20, Price: 100.2, Date: 1514764801000, Pair: goex.BTC_USDT},
+	}, trades)
+}
+
+func TestSpotWs_Handle(t *testing.T) {
+	ws := NewSpotWs()
+
+	var depth *goex.Depth
+	ws.DepthCallback(func(d *goex.Depth) {
+		depth = d
+	})
+	assert.Nil(t, ws.handle([]byte(`{"time":1606295412,"time_ms":1606295412213,"channel":"spot.order_book","event":"update","result":{"t":1606295412123,"lastUpdateId":48791820,"s":"BTC_USDT","bids":[["19079.55","0.0195"],["19079.07","0.7341"]],"asks":[["19080.24","0.1638"],["19080.91","0.1366"]]}}`)))
+	assert.True(t, depth.Pair.Eq(goex.BTC_USDT))
+	assert.Equal(t, 19079.55, depth.BidList[0].Price)
+	assert.Equal(t, 19080.91, depth.AskList[0].Price)
+	assert.Equal(t, 19080.24, depth.AskList[1].Price)
+
+	var trade *goex.Trade
+	ws.TradeCallback(func(tr *goex.Trade) {
+		trade = tr
+	})
+	assert.Nil(t, ws.handle([]byte(`{"time":1606292218,"time_ms":1606292218231,"channel":"spot.trades","event":"update","result":{"id":309143071,"create_time":1606292218,"create_time_ms":"1606292218213.4578","side":"sell","currency_pair":"GT_USDT","amount":"16.47","price":"0.4705"}}`)))
+	assert.Equal(t, goex.SELL, trade.Type)
+	assert.Equal(t, int64(1606292218213), trade.Date)
+	assert.Equal(t, "GT_USDT", trade.Pair.String())
+
+	//订阅失败只记录日志
+	assert.Nil(t, ws.handle([]byte(`{"time":1606292218,"channel":"spot.tickers","event":"subscribe","error":{"code":2,"message":"unknown currency pair"}}`)))
+}
@@ -0,0 +1,354 @@
+package gateio
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	. "github.com/lucas7788/goex"
+)
+
+/**
+ * 现货, 实现API接口
+ * 市价买单的amount为计价币金额(如USDT), 市价卖单为基础币数量
+ */
+type Spot struct {
+	*Gateio
+}
+
+func NewSpot(config *APIConfig) *Spot {
+	return &Spot{Gateio: New(config)}
+}
+
+func (s *Spot) GetExchangeName() string {
+	return GATEIO
+}
+
+type spotOrderParam struct {
+	Text         string `json:"text"`
+	CurrencyPair string `json:"currency_pair"`
+	Type         string `json:"type"`
+	Account      string `json:"account"`
+	Side         string `json:"side"`
+	Amount       string `json:"amount"`
+	Price        string `json:"price,omitempty"`
+	TimeInForce  string `json:"time_in_force"`
+}
+
+type spotOrder struct {
+	Id           string `json:"id"`
+	Text         string `json:"text"`
+	CurrencyPair string `json:"currency_pair"`
+	Status       string `json:"status"` //open, closed, cancelled
+	Type         string `json:"type"`
+	Side         string `json:"side"`
+	Amount       string `json:"amount"`
+	Price        string `json:"price"`
+	TimeInForce  string `json:"time_in_force"`
+	Left         string `json:"left"`
+	FilledAmount string `json:"filled_amount"`
+	FilledTotal  string `json:"filled_total"`
+	AvgDealPrice string `json:"avg_deal_price"`
+	Fee          string `json:"fee"`
+	CreateTimeMs int64  `json:"create_time_ms"`
+	UpdateTimeMs int64  `json:"update_time_ms"`
+}
+
+func (s *Spot) placeOrder(amount, price string, pair CurrencyPair, side TradeSide, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	order := &Order{
+		Cid:       newClientOid(),
+		Price:     ToFloat64(price),
+		Amount:    ToFloat64(amount),
+		Currency:  pair,
+		Side:      side,
+		Type:      "limit",
+		OrderTime: int(time.Now().UnixNano() / int64(time.Millisecond)),
+	}
+
+	param := spotOrderParam{
+		Text:         order.Cid,
+		CurrencyPair: toSymbol(pair),
+		Type:         "limit",
+		Account:      "spot",
+		Side:         "buy",
+		Amount:       amount,
+	}
+	if side == SELL || side == SELL_MARKET {
+		param.Side = "sell"
+	}
+	if side == BUY_MARKET || side == SELL_MARKET {
+		//市价单只支持ioc
+		param.Type = "market"
+		param.TimeInForce = "ioc"
+		order.Type = "market"
+		order.Price = 0
+	} else {
+		param.Price = price
+		param.TimeInForce, order.OrderType = timeInForce(opt...)
+	}
+
+	var resp spotOrder
+	err := s.doRequest(http.MethodPost, "/spot/orders", nil, param, &resp, true)
+	if err != nil {
+		return nil, err
+	}
+	order.OrderID2 = resp.Id
+	order.Status = s.adaptOrderStatus(resp)
+	return order, nil
+}
+
+func (s *Spot) LimitBuy(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	return s.placeOrder(amount, price, currency, BUY, opt...)
+}
+
+func (s *Spot) LimitSell(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	return s.placeOrder(amount, price, currency, SELL, opt...)
+}
+
+func (s *Spot) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
+	return s.placeOrder(amount, price, currency, BUY_MARKET)
+}
+
+func (s *Spot) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
+	return s.placeOrder(amount, price, currency, SELL_MARKET)
+}
+
+func (s *Spot) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
+	params := url.Values{}
+	params.Set("currency_pair", toSymbol(currency))
+	err := s.doRequest(http.MethodDelete, "/spot/orders/"+orderId, params, nil, nil, true)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *Spot) adaptOrderStatus(o spotOrder) TradeStatus {
+	switch o.Status {
+	case "closed":
+		return ORDER_FINISH
+	case "cancelled":
+		return ORDER_CANCEL
+	}
+	if o.Left != "" && ToFloat64(o.Left) < ToFloat64(o.Amount) {
+		return ORDER_PART_FINISH
+	}
+	return ORDER_UNFINISH
+}
+
+func (s *Spot) adaptOrder(pair CurrencyPair, o spotOrder) Order {
+	side := BUY
+	switch {
+	case o.Side == "buy" && o.Type == "market":
+		side = BUY_MARKET
+	case o.Side == "sell" && o.Type == "market":
+		side = SELL_MARKET
+	case o.Side == "sell":
+		side = SELL
+	}
+
+	order := Order{
+		Price:     ToFloat64(o.Price),
+		Amount:    ToFloat64(o.Amount),
+		AvgPrice:  ToFloat64(o.AvgDealPrice),
+		Fee:       ToFloat64(o.Fee),
+		Cid:       o.Text,
+		OrderID2:  o.Id,
+		Status:    s.adaptOrderStatus(o),
+		Currency:  pair,
+		Side:      side,
+		Type:      o.Type,
+		OrderType: adaptTimeInForce(o.TimeInForce),
+		OrderTime: int(o.CreateTimeMs),
+	}
+	if o.FilledAmount != "" {
+		order.DealAmount = ToFloat64(o.FilledAmount)
+	} else {
+		order.DealAmount = order.Amount - ToFloat64(o.Left)
+	}
+	if order.Status == ORDER_FINISH || order.Status == ORDER_CANCEL {
+		order.FinishedTime = o.UpdateTimeMs
+	}
+	return order
+}
+
+func (s *Spot) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
+	params := url.Values{}
+	params.Set("currency_pair", toSymbol(currency))
+	var resp spotOrder
+	err := s.doRequest(http.MethodGet, "/spot/orders/"+orderId, params, nil, &resp, true)
+	if err != nil {
+		return nil, err
+	}
+	order := s.adaptOrder(currency, resp)
+	return &order, nil
+}
+
+func (s *Spot) getOrders(pair CurrencyPair, status string, opt ...OptionalParameter) ([]Order, error) {
+	params := url.Values{}
+	params.Set("currency_pair", toSymbol(pair))
+	params.Set("status", status)
+	MergeOptionalParameter(&params, opt...)
+	var resp []spotOrder
+	err := s.doRequest(http.MethodGet, "/spot/orders", params, nil, &resp, true)
+	if err != nil {
+		return nil, err
+	}
+	orders := make([]Order, 0, len(resp))
+	for _, o := range resp {
+		orders = append(orders, s.adaptOrder(pair, o))
+	}
+	return orders, nil
+}
+
+func (s *Spot) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
+	return s.getOrders(currency, "open")
+}
+
+//opt支持page, limit, from, to(秒)
+func (s *Spot) GetOrderHistorys(currency CurrencyPair, opt ...OptionalParameter) ([]Order, error) {
+	return s.getOrders(currency, "finished", opt...)
+}
+
+func (s *Spot) GetAccount() (*Account, error) {
+	var resp []struct {
+		Currency  string `json:"currency"`
+		Available string `json:"available"`
+		Locked    string `json:"locked"`
+	}
+	err := s.doRequest(http.MethodGet, "/spot/accounts", nil, nil, &resp, true)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &Account{Exchange: s.GetExchangeName(), SubAccounts: make(map[Currency]SubAccount, len(resp))}
+	for _, itm := range resp {
+		currency := NewCurrency(itm.Currency, "")
+		acc.SubAccounts[currency] = SubAccount{
+			Currency:     currency,
+			Amount:       ToFloat64(itm.Available),
+			ForzenAmount: ToFloat64(itm.Locked),
+		}
+	}
+	return acc, nil
+}
+
+func (s *Spot) GetTicker(currency CurrencyPair) (*Ticker, error) {
+	var resp []struct {
+		Last       string `json:"last"`
+		LowestAsk  string `json:"lowest_ask"`
+		HighestBid string `json:"highest_bid"`
+		BaseVolume string `json:"base_volume"`
+		High24h    string `json:"high_24h"`
+		Low24h     string `json:"low_24h"`
+	}
+	err := s.doRequest(http.MethodGet, "/spot/tickers", url.Values{"currency_pair": {toSymbol(currency)}}, nil, &resp, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) == 0 {
+		return nil, EX_ERR_SYMBOL_ERR.OriginErr("no ticker: " + toSymbol(currency))
+	}
+	return &Ticker{
+		Pair: currency,
+		Last: ToFloat64(resp[0].Last),
+		Buy:  ToFloat64(resp[0].HighestBid),
+		Sell: ToFloat64(resp[0].LowestAsk),
+		High: ToFloat64(resp[0].High24h),
+		Low:  ToFloat64(resp[0].Low24h),
+		Vol:  ToFloat64(resp[0].BaseVolume),
+		Date: uint64(time.Now().UnixNano() / int64(time.Millisecond)),
+	}, nil
+}
+
+func (s *Spot) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
+	params := url.Values{}
+	params.Set("currency_pair", toSymbol(currency))
+	params.Set("limit", fmt.Sprint(size))
+	var resp struct {
+		Current int64       `json:"current"`
+		Asks    [][2]string `json:"asks"`
+		Bids    [][2]string `json:"bids"`
+	}
+	err := s.doRequest(http.MethodGet, "/spot/order_book", params, nil, &resp, false)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := &Depth{Pair: currency, UTime: time.Unix(0, resp.Current*int64(time.Millisecond))}
+	for _, b := range resp.Bids {
+		depth.BidList = append(depth.BidList, DepthRecord{Price: ToFloat64(b[0]), Amount: ToFloat64(b[1])})
+	}
+	for _, a := range resp.Asks {
+		depth.AskList = append(depth.AskList, DepthRecord{Price: ToFloat64(a[0]), Amount: ToFloat64(a[1])})
+	}
+	depth.AskList = reverseAsks(depth.AskList)
+	return depth, nil
+}
+
+//每根K线为[时间(秒), 计价币成交额, close, high, low, open, 基础币成交量, 是否完结]
+func (s *Spot) GetKlineRecords(currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
+	params, err := klineParams("currency_pair", toSymbol(currency), period, size, optional...)
+	if err != nil {
+		return nil, err
+	}
+	var resp [][]interface{}
+	err = s.doRequest(http.MethodGet, "/spot/candlesticks", params, nil, &resp, false)
+	if err != nil {
+		return nil, err
+	}
+	klines := make([]Kline, 0, len(resp))
+	for _, r := range resp {
+		if len(r) < 7 {
+			continue
+		}
+		klines = append(klines, Kline{
+			Pair:      currency,
+			Timestamp: ToInt64(r[0]),
+			Close:     ToFloat64(r[2]),
+			High:      ToFloat64(r[3]),
+			Low:       ToFloat64(r[4]),
+			Open:      ToFloat64(r[5]),
+			Vol:       ToFloat64(r[6]),
+		})
+	}
+	return klines, nil
+}
+
+//since为毫秒时间戳, 大于0时返回该时间之后的成交
+func (s *Spot) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	params := url.Values{}
+	params.Set("currency_pair", toSymbol(currencyPair))
+	params.Set("limit", "100")
+	if since > 0 {
+		params.Set("from", fmt.Sprint(since/1000))
+	}
+	var resp []struct {
+		Id           string `json:"id"`
+		CreateTimeMs string `json:"create_time_ms"`
+		Side         string `json:"side"`
+		Amount       string `json:"amount"`
+		Price        string `json:"price"`
+	}
+	err := s.doRequest(http.MethodGet, "/spot/trades", params, nil, &resp, false)
+	if err != nil {
+		return nil, err
+	}
+	trades := make([]Trade, 0, len(resp))
+	for _, t := range resp {
+		side := BUY
+		if t.Side == "sell" {
+			side = SELL
+		}
+		trades = append(trades, Trade{
+			Tid:    ToInt64(t.Id),
+			Type:   side,
+			Amount: ToFloat64(t.Amount),
+			Price:  ToFloat64(t.Price),
+			Date:   int64(ToFloat64(t.CreateTimeMs)),
+			Pair:   currencyPair,
+		})
+	}
+	return trades, nil
+}
@@ -0,0 +1,216 @@
+package gateio
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	. "github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/logger"
+)
+
+const (
+	spotWsUrl      = "wss://api.gateio.ws/ws/v4/"
+	wsPingInterval = 15 * time.Second
+)
+
+type wsRequest struct {
+	Time    int64    `json:"time"`
+	Channel string   `json:"channel"`
+	Event   string   `json:"event,omitempty"`
+	Payload []string `json:"payload,omitempty"`
+}
+
+type wsResponse struct {
+	Time    int64           `json:"time"`
+	TimeMs  int64           `json:"time_ms"`
+	Channel string          `json:"channel"`
+	Event   string          `json:"event"` //subscribe, update, all
+	Error   *wsError        `json:"error"`
+	Result  json.RawMessage `json:"result"`
+}
+
+type wsError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+/**
+ * v4 现货行情websocket, 实现SpotWsApi
+ * 深度订阅spot.order_book(每100ms推送20档全量), 每15秒发送一次spot.ping
+ */
+type SpotWs struct {
+	c         *WsConn
+	connErr   error
+	once      sync.Once
+	wsBuilder *WsBuilder
+
+	lock  sync.Mutex
+	pairs map[string]CurrencyPair //symbol => pair
+
+	depthCall  func(depth *Depth)
+	tickerCall func(ticker *Ticker)
+	tradeCall  func(trade *Trade)
+}
+
+func NewSpotWs() *SpotWs {
+	s := &SpotWs{pairs: make(map[string]CurrencyPair, 4)}
+	s.wsBuilder = NewWsBuilder().WsUrl(spotWsUrl).ProtoHandleFunc(s.handle).AutoReconnect().
+		Heartbeat(func() []byte {
+			data, _ := json.Marshal(wsRequest{Time: time.Now().Unix(), Channel: "spot.ping"})
+			return data
+		}, wsPingInterval)
+	return s
+}
+
+func (s *SpotWs) pair(symbol string) CurrencyPair {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if pair, ok := s.pairs[symbol]; ok {
+		return pair
+	}
+	return NewCurrencyPair3(symbol, "_")
+}
+
+func (s *SpotWs) subscribe(pair CurrencyPair, channel string, args ...string) error {
+	s.once.Do(func() {
+		s.c, s.connErr = s.wsBuilder.Build()
+	})
+	if s.connErr != nil {
+		return s.connErr
+	}
+	symbol := toSymbol(pair)
+	s.lock.Lock()
+	s.pairs[symbol] = pair
+	s.lock.Unlock()
+	return s.c.Subscribe(wsRequest{
+		Time:    time.Now().Unix(),
+		Channel: channel,
+		Event:   "subscribe",
+		Payload: append([]string{symbol}, args...),
+	})
+}
+
+func (s *SpotWs) DepthCallback(f func(depth *Depth)) {
+	s.depthCall = f
+}
+
+func (s *SpotWs) TickerCallback(f func(ticker *Ticker)) {
+	s.tickerCall = f
+}
+
+func (s *SpotWs) TradeCallback(f func(trade *Trade)) {
+	s.tradeCall = f
+}
+
+func (s *SpotWs) SubscribeDepth(pair CurrencyPair) error {
+	return s.subscribe(pair, "spot.order_book", "20", "100ms")
+}
+
+func (s *SpotWs) SubscribeTicker(pair CurrencyPair) error {
+	return s.subscribe(pair, "spot.tickers")
+}
+
+func (s *SpotWs) SubscribeTrade(pair CurrencyPair) error {
+	return s.subscribe(pair, "spot.trades")
+}
+
+func (s *SpotWs) Close() {
+	if s.c != nil {
+		s.c.CloseWs()
+	}
+}
+
+func (s *SpotWs) handle(data []byte) error {
+	var msg wsResponse
+	if err := json.Unmarshal(data, &msg); err != nil {
+		logger.Errorf("[gateio ws] unmarshal error, message: %s", string(data))
+		return err
+	}
+	if msg.Error != nil {
+		logger.Errorf("[gateio ws] %s %s error: %d %s", msg.Channel, msg.Event, msg.Error.Code, msg.Error.Message)
+		return nil
+	}
+	if msg.Event != "update" {
+		return nil
+	}
+
+	switch msg.Channel {
+	case "spot.order_book":
+		var book struct {
+			T    int64       `json:"t"`
+			S    string      `json:"s"`
+			Bids [][2]string `json:"bids"`
+			Asks [][2]string `json:"asks"`
+		}
+		if err := json.Unmarshal(msg.Result, &book); err != nil {
+			return err
+		}
+		if s.depthCall == nil {
+			return nil
+		}
+		depth := &Depth{Pair: s.pair(book.S), UTime: time.Unix(0, book.T*int64(time.Millisecond))}
+		for _, b := range book.Bids {
+			depth.BidList = append(depth.BidList, DepthRecord{Price: ToFloat64(b[0]), Amount: ToFloat64(b[1])})
+		}
+		for _, a := range book.Asks {
+			depth.AskList = append(depth.AskList, DepthRecord{Price: ToFloat64(a[0]), Amount: ToFloat64(a[1])})
+		}
+		depth.AskList = reverseAsks(depth.AskList)
+		s.depthCall(depth)
+	case "spot.tickers":
+		var t struct {
+			CurrencyPair string `json:"currency_pair"`
+			Last         string `json:"last"`
+			LowestAsk    string `json:"lowest_ask"`
+			HighestBid   string `json:"highest_bid"`
+			BaseVolume   string `json:"base_volume"`
+			High24h      string `json:"high_24h"`
+			Low24h       string `json:"low_24h"`
+		}
+		if err := json.Unmarshal(msg.Result, &t); err != nil {
+			return err
+		}
+		if s.tickerCall == nil {
+			return nil
+		}
+		s.tickerCall(&Ticker{
+			Pair: s.pair(t.CurrencyPair),
+			Last: ToFloat64(t.Last),
+			Buy:  ToFloat64(t.HighestBid),
+			Sell: ToFloat64(t.LowestAsk),
+			High: ToFloat64(t.High24h),
+			Low:  ToFloat64(t.Low24h),
+			Vol:  ToFloat64(t.BaseVolume),
+			Date: uint64(msg.TimeMs),
+		})
+	case "spot.trades":
+		var t struct {
+			Id           int64  `json:"id"`
+			CreateTimeMs string `json:"create_time_ms"`
+			Side         string `json:"side"`
+			CurrencyPair string `json:"currency_pair"`
+			Amount       string `json:"amount"`
+			Price        string `json:"price"`
+		}
+		if err := json.Unmarshal(msg.Result, &t); err != nil {
+			return err
+		}
+		if s.tradeCall == nil {
+			return nil
+		}
+		side := BUY
+		if t.Side == "sell" {
+			side = SELL
+		}
+		s.tradeCall(&Trade{
+			Tid:    t.Id,
+			Type:   side,
+			Amount: ToFloat64(t.Amount),
+			Price:  ToFloat64(t.Price),
+			Date:   int64(ToFloat64(t.CreateTimeMs)),
+			Pair:   s.pair(t.CurrencyPair),
+		})
+	}
+	return nil
+}
@@ -1,10 +1,18 @@
 package gdax
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"sort"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/lucas7788/goex"
 	. "github.com/lucas7788/goex"
@@ -17,39 +25,348 @@ type Gdax struct {
 	httpClient *http.Client
 	baseUrl,
 	accessKey,
-	secretKey string
+	secretKey,
+	passphrase string
+	clock *ClockSync
 }
 
 func New(client *http.Client, accesskey, secretkey string) *Gdax {
-	return &Gdax{client, "https://api.gdax.com", accesskey, secretkey}
+	return NewWithConfig(&APIConfig{HttpClient: client, ApiKey: accesskey, ApiSecretKey: secretkey})
+}
+
+func NewWithConfig(config *APIConfig) *Gdax {
+	if config.Endpoint == "" {
+		config.Endpoint = "https://api.pro.coinbase.com"
+	}
+	g := &Gdax{
+		httpClient: config.HttpClient,
+		baseUrl:    config.Endpoint,
+		accessKey:  config.ApiKey,
+		secretKey:  config.ApiSecretKey,
+		passphrase: config.ApiPassphrase,
+	}
+	g.clock = SharedClockSync(g.baseUrl+"/time", g.GetServerTime)
+	return g
+}
+
+// GetServerTime 返回服务器毫秒时间戳
+func (g *Gdax) GetServerTime() (int64, error) {
+	resp, err := HttpGet(g.httpClient, g.baseUrl+"/time")
+	if err != nil {
+		return 0, err
+	}
+	return int64(ToFloat64(resp["epoch"]) * 1000), nil
+}
+
+//签名: base64(hmac_sha256(base64decode(secret), timestamp + method + requestPath + body))
+func (g *Gdax) sign(timestamp, method, requestPath, body string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(g.secretKey)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp + method + requestPath + body))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (g *Gdax) doAuthenticatedRequest(method, requestPath string, param interface{}, result interface{}) error {
+	body := ""
+	if param != nil {
+		data, err := json.Marshal(param)
+		if err != nil {
+			return err
+		}
+		body = string(data)
+	}
+
+	timestamp := fmt.Sprint(g.clock.Now().Unix())
+	sign, err := g.sign(timestamp, method, requestPath, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := NewHttpRequest(g.httpClient, method, g.baseUrl+requestPath, body, map[string]string{
+		"Content-Type":         "application/json",
+		"CB-ACCESS-KEY":        g.accessKey,
+		"CB-ACCESS-SIGN":       sign,
+		"CB-ACCESS-TIMESTAMP":  timestamp,
+		"CB-ACCESS-PASSPHRASE": g.passphrase})
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(resp, result)
+}
+
+type orderParam struct {
+	ClientOid   string `json:"client_oid"`
+	Type        string `json:"type"`
+	Side        string `json:"side"`
+	ProductId   string `json:"product_id"`
+	Price       string `json:"price,omitempty"`
+	Size        string `json:"size,omitempty"`
+	Funds       string `json:"funds,omitempty"` //市价买单的买入金额
+	TimeInForce string `json:"time_in_force,omitempty"`
+	PostOnly    bool   `json:"post_only,omitempty"`
+}
+
+type orderResponse struct {
+	Id            string `json:"id"`
+	ClientOid     string `json:"client_oid"`
+	Price         string `json:"price"`
+	Size          string `json:"size"`
+	ProductId     string `json:"product_id"`
+	Side          string `json:"side"`
+	Type          string `json:"type"`
+	TimeInForce   string `json:"time_in_force"`
+	PostOnly      bool   `json:"post_only"`
+	CreatedAt     string `json:"created_at"`
+	FillFees      string `json:"fill_fees"`
+	FilledSize    string `json:"filled_size"`
+	ExecutedValue string `json:"executed_value"`
+	Status        string `json:"status"`
+	DoneReason    string `json:"done_reason"`
+	Funds         string `json:"funds"`
+}
+
+func (g *Gdax) productId(pair CurrencyPair) string {
+	return pair.ToUpper().ToSymbol("-")
+}
+
+func (g *Gdax) placeOrder(param orderParam, pair CurrencyPair) (*Order, error) {
+	param.ClientOid = uuid.New().String()
+	param.ProductId = g.productId(pair)
+
+	var response orderResponse
+	err := g.doAuthenticatedRequest("POST", "/orders", param, &response)
+	if err != nil {
+		return nil, err
+	}
+	ord := g.adaptOrder(response)
+	ord.Currency = pair
+	return &ord, nil
+}
+
+func (g *Gdax) limitOrder(side, amount, price string, pair CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
+	param := orderParam{Type: "limit", Side: side, Price: price, Size: amount, TimeInForce: "GTC"}
+	if len(opt) > 0 {
+		switch opt[0] {
+		case PostOnly:
+			param.PostOnly = true
+		case Ioc:
+			param.TimeInForce = "IOC"
+		case Fok:
+			param.TimeInForce = "FOK"
+		}
+	}
+	return g.placeOrder(param, pair)
 }
 
 func (g *Gdax) LimitBuy(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
-	panic("not implement")
+	return g.limitOrder("buy", amount, price, currency, opt...)
 }
+
 func (g *Gdax) LimitSell(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
-	panic("not implement")
+	return g.limitOrder("sell", amount, price, currency, opt...)
 }
+
+//price不为空时按金额买入, 否则按数量amount买入
 func (g *Gdax) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	param := orderParam{Type: "market", Side: "buy"}
+	if ToFloat64(price) > 0 {
+		param.Funds = price
+	} else {
+		param.Size = amount
+	}
+	return g.placeOrder(param, currency)
 }
+
 func (g *Gdax) MarketSell(amount, price string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	return g.placeOrder(orderParam{Type: "market", Side: "sell", Size: amount}, currency)
 }
+
 func (g *Gdax) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	panic("not implement")
+	requestPath := fmt.Sprintf("/orders/%s?product_id=%s", orderId, g.productId(currency))
+	var canceledId string
+	err := g.doAuthenticatedRequest("DELETE", requestPath, nil, &canceledId)
+	if err != nil {
+		return false, err
+	}
+	return canceledId == orderId, nil
 }
+
 func (g *Gdax) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
-	panic("not implement")
+	var response orderResponse
+	err := g.doAuthenticatedRequest("GET", "/orders/"+orderId, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	ord := g.adaptOrder(response)
+	ord.Currency = currency
+	return &ord, nil
+}
+
+func (g *Gdax) getOrders(currency CurrencyPair, params url.Values) ([]Order, error) {
+	params.Set("product_id", g.productId(currency))
+	var response []orderResponse
+	err := g.doAuthenticatedRequest("GET", "/orders?"+params.Encode(), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]Order, 0, len(response))
+	for _, r := range response {
+		ord := g.adaptOrder(r)
+		ord.Currency = currency
+		orders = append(orders, ord)
+	}
+	return orders, nil
 }
+
 func (g *Gdax) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
-	panic("not implement")
+	params := url.Values{}
+	params.Add("status", "open")
+	params.Add("status", "pending")
+	params.Add("status", "active")
+	return g.getOrders(currency, params)
 }
+
+//optional: limit, before, after (分页游标)
 func (g *Gdax) GetOrderHistorys(currency CurrencyPair, optional ...OptionalParameter) ([]Order, error) {
-	panic("not implement")
+	params := url.Values{}
+	params.Set("status", "done")
+	MergeOptionalParameter(&params, optional...)
+	return g.getOrders(currency, params)
 }
+
+func (g *Gdax) adaptOrder(r orderResponse) Order {
+	ord := Order{
+		Cid:        r.ClientOid,
+		OrderID2:   r.Id,
+		Price:      ToFloat64(r.Price),
+		Amount:     ToFloat64(r.Size),
+		DealAmount: ToFloat64(r.FilledSize),
+		Fee:        ToFloat64(r.FillFees),
+		Type:       r.Type,
+		OrderTime:  int(parseTime(r.CreatedAt)),
+	}
+	if ord.DealAmount > 0 {
+		ord.AvgPrice = ToFloat64(r.ExecutedValue) / ord.DealAmount
+	}
+
+	switch r.Side {
+	case "buy":
+		ord.Side = BUY
+		if r.Type == "market" {
+			ord.Side = BUY_MARKET
+		}
+	case "sell":
+		ord.Side = SELL
+		if r.Type == "market" {
+			ord.Side = SELL_MARKET
+		}
+	}
+
+	switch {
+	case r.PostOnly:
+		ord.OrderType = ORDER_FEATURE_POST_ONLY
+	case r.TimeInForce == "IOC":
+		ord.OrderType = ORDER_FEATURE_IOC
+	case r.TimeInForce == "FOK":
+		ord.OrderType = ORDER_FEATURE_FOK
+	}
+
+	switch r.Status {
+	case "done", "settled":
+		if r.DoneReason == "filled" {
+			ord.Status = ORDER_FINISH
+		} else {
+			ord.Status = ORDER_CANCEL
+		}
+	case "rejected":
+		ord.Status = ORDER_REJECT
+	default:
+		ord.Status = ORDER_UNFINISH
+		if ord.DealAmount > 0 {
+			ord.Status = ORDER_PART_FINISH
+		}
+	}
+	return ord
+}
+
 func (g *Gdax) GetAccount() (*Account, error) {
-	panic("not implement")
+	var response []struct {
+		Id        string `json:"id"`
+		Currency  string `json:"currency"`
+		Balance   string `json:"balance"`
+		Available string `json:"available"`
+		Hold      string `json:"hold"`
+	}
+	err := g.doAuthenticatedRequest("GET", "/accounts", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &Account{Exchange: GDAX, SubAccounts: make(map[Currency]SubAccount, len(response))}
+	for _, r := range response {
+		if ToFloat64(r.Balance) == 0 {
+			continue
+		}
+		currency := NewCurrency(r.Currency, "")
+		acc.SubAccounts[currency] = SubAccount{
+			Currency:     currency,
+			Amount:       ToFloat64(r.Available),
+			ForzenAmount: ToFloat64(r.Hold),
+		}
+	}
+	return acc, nil
+}
+
+/**
+ * 个人成交记录
+ * optional: order_id, limit, before, after
+ */
+func (g *Gdax) GetFills(currency CurrencyPair, optional ...OptionalParameter) ([]Trade, error) {
+	params := url.Values{}
+	params.Set("product_id", g.productId(currency))
+	MergeOptionalParameter(&params, optional...)
+
+	var response []struct {
+		TradeId   int64  `json:"trade_id"`
+		OrderId   string `json:"order_id"`
+		Price     string `json:"price"`
+		Size      string `json:"size"`
+		Side      string `json:"side"`
+		CreatedAt string `json:"created_at"`
+	}
+	err := g.doAuthenticatedRequest("GET", "/fills?"+params.Encode(), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	trades := make([]Trade, 0, len(response))
+	for _, r := range response {
+		trades = append(trades, Trade{
+			Tid:    r.TradeId,
+			Type:   AdaptTradeSide(r.Side),
+			Amount: ToFloat64(r.Size),
+			Price:  ToFloat64(r.Price),
+			Date:   parseTime(r.CreatedAt),
+			Pair:   currency,
+		})
+	}
+	return trades, nil
+}
+
+//返回毫秒时间戳
+func parseTime(t string) int64 {
+	tm, err := time.Parse(time.RFC3339Nano, t)
+	if err != nil {
+		return 0
+	}
+	return tm.UnixNano() / int64(time.Millisecond)
 }
 
 func (g *Gdax) GetTicker(currency CurrencyPair) (*Ticker, error) {
@@ -164,9 +481,40 @@ func (g *Gdax) GetKlineRecords(currency CurrencyPair, period KlinePeriod, size i
 	return klines, nil
 }
 
-//非个人，整个交易所的交易记录
+//非个人，整个交易所的交易记录, since不为0时作为分页游标(trade_id), 返回更早的成交
 func (g *Gdax) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("not implement")
+	urlpath := fmt.Sprintf("%s/products/%s/trades", g.baseUrl, g.productId(currencyPair))
+	if since > 0 {
+		urlpath += fmt.Sprintf("?after=%d", since)
+	}
+	resp, err := HttpGet3(g.httpClient, urlpath, map[string]string{})
+	if err != nil {
+		errCode := HTTP_ERR_CODE
+		errCode.OriginErrMsg = err.Error()
+		return nil, errCode
+	}
+
+	var trades []Trade
+	for _, v := range resp {
+		t, is := v.(map[string]interface{})
+		if !is {
+			continue
+		}
+		//side为maker方向, 转为taker方向
+		side := SELL
+		if t["side"] == "sell" {
+			side = BUY
+		}
+		trades = append(trades, Trade{
+			Tid:    ToInt64(t["trade_id"]),
+			Type:   side,
+			Amount: ToFloat64(t["size"]),
+			Price:  ToFloat64(t["price"]),
+			Date:   parseTime(fmt.Sprint(t["time"])),
+			Pair:   currencyPair,
+		})
+	}
+	return trades, nil
 }
 
 func (g *Gdax) GetExchangeName() string {
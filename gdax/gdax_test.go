@@ -1,11 +1,18 @@
 package gdax
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/lucas7788/goex"
 	"github.com/lucas7788/goex/internal/logger"
+	"github.com/stretchr/testify/assert"
 )
 
 var gdax = New(http.DefaultClient, "", "")
@@ -31,5 +38,47 @@ func TestGdax_GetDepth(t *testing.T) {
 
 func TestGdax_GetKlineRecords(t *testing.T) {
 	logger.SetLevel(logger.DEBUG)
-	t.Log(gdax.GetKlineRecords(goex.BTC_USD, goex.KLINE_PERIOD_1DAY, 0, 0))
+	t.Log(gdax.GetKlineRecords(goex.BTC_USD, goex.KLINE_PERIOD_1DAY, 0))
+}
+
+func TestGdax_LimitBuy(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString([]byte("secret"))
+	var param map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/time":
+			w.Write([]byte(`{"iso":"2015-01-07T23:47:25.201Z","epoch":1420674445.201}`))
+		case "/orders":
+			body, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(body, &param)
+
+			mac := hmac.New(sha256.New, []byte("secret"))
+			mac.Write([]byte(r.Header.Get("CB-ACCESS-TIMESTAMP") + "POST/orders" + string(body)))
+			assert.Equal(t, base64.StdEncoding.EncodeToString(mac.Sum(nil)), r.Header.Get("CB-ACCESS-SIGN"))
+			assert.Equal(t, "key", r.Header.Get("CB-ACCESS-KEY"))
+			assert.Equal(t, "pass", r.Header.Get("CB-ACCESS-PASSPHRASE"))
+
+			w.Write([]byte(`{"id":"d0c5340b-6d6c-49d9-b567-48c4bfca13d2","price":"0.10000000","size":"0.01000000",
+				"product_id":"BTC-USD","side":"buy","type":"limit","time_in_force":"GTC","post_only":true,
+				"created_at":"2016-12-08T20:02:28.53864Z","fill_fees":"0","filled_size":"0","executed_value":"0","status":"pending"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	g := NewWithConfig(&goex.APIConfig{HttpClient: http.DefaultClient, Endpoint: srv.URL, ApiKey: "key",
+		ApiSecretKey: secret, ApiPassphrase: "pass"})
+	ord, err := g.LimitBuy("0.01", "0.1", goex.BTC_USD, goex.PostOnly)
+	assert.Nil(t, err)
+	assert.Equal(t, "d0c5340b-6d6c-49d9-b567-48c4bfca13d2", ord.OrderID2)
+	assert.Equal(t, goex.ORDER_UNFINISH, ord.Status)
+	assert.Equal(t, goex.ORDER_FEATURE_POST_ONLY, ord.OrderType)
+	assert.Equal(t, int(1481227348538), ord.OrderTime)
+
+	assert.Equal(t, "BTC-USD", param["product_id"])
+	assert.Equal(t, "limit", param["type"])
+	assert.Equal(t, "0.01", param["size"])
+	assert.Equal(t, true, param["post_only"])
+	assert.NotEmpty(t, param["client_oid"])
 }
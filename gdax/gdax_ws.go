@@ -0,0 +1,292 @@
+package gdax
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/logger"
+)
+
+const wsFeedUrl = "wss://ws-feed.pro.coinbase.com"
+
+type wsChannel struct {
+	Name       string   `json:"name"`
+	ProductIds []string `json:"product_ids"`
+}
+
+type wsSubscribe struct {
+	Type       string      `json:"type"`
+	Channels   []wsChannel `json:"channels"`
+	Signature  string      `json:"signature,omitempty"`
+	Key        string      `json:"key,omitempty"`
+	Passphrase string      `json:"passphrase,omitempty"`
+	Timestamp  string      `json:"timestamp,omitempty"`
+}
+
+type wsMessage struct {
+	Type      string      `json:"type"`
+	ProductId string      `json:"product_id"`
+	Message   string      `json:"message"`
+	Reason    string      `json:"reason"`
+	Time      string      `json:"time"`
+	Bids      [][2]string `json:"bids"`
+	Asks      [][2]string `json:"asks"`
+	Changes   [][3]string `json:"changes"`
+	TradeId   int64       `json:"trade_id"`
+	Side      string      `json:"side"`
+	Price     string      `json:"price"`
+	Size      string      `json:"size"`
+	BestBid   string      `json:"best_bid"`
+	BestAsk   string      `json:"best_ask"`
+	Volume24h string      `json:"volume_24h"`
+	High24h   string      `json:"high_24h"`
+	Low24h    string      `json:"low_24h"`
+}
+
+//level2本地订单簿, key为价格字符串
+type level2Book struct {
+	asks map[string]float64
+	bids map[string]float64
+}
+
+func (b *level2Book) update(side, price, size string) {
+	book := b.bids
+	if side == "sell" {
+		book = b.asks
+	}
+	if ToFloat64(size) == 0 {
+		delete(book, price)
+		return
+	}
+	book[price] = ToFloat64(size)
+}
+
+func (b *level2Book) depth(size int) *Depth {
+	dep := new(Depth)
+	for p, s := range b.bids {
+		dep.BidList = append(dep.BidList, DepthRecord{Price: ToFloat64(p), Amount: s})
+	}
+	for p, s := range b.asks {
+		dep.AskList = append(dep.AskList, DepthRecord{Price: ToFloat64(p), Amount: s})
+	}
+
+	sort.Sort(sort.Reverse(dep.BidList))
+	sort.Sort(dep.AskList)
+	if size > 0 && len(dep.BidList) > size {
+		dep.BidList = dep.BidList[:size]
+	}
+	if size > 0 && len(dep.AskList) > size {
+		dep.AskList = dep.AskList[:size]
+	}
+	sort.Sort(sort.Reverse(dep.AskList))
+	return dep
+}
+
+/**
+ * Coinbase Pro websocket feed, 实现SpotWsApi
+ * 深度使用level2频道, 本地维护订单簿, 回调前DepthSize档
+ * 配置了api key时订阅消息会带上签名(level2需要鉴权)
+ */
+type GdaxWs struct {
+	*WsBuilder
+	sync.Once
+	wsConn  *WsConn
+	connErr error
+	g       *Gdax
+
+	DepthSize int
+
+	lock  sync.Mutex
+	subs  map[string][]string //channel -> product ids
+	books map[string]*level2Book
+
+	depthCallback  func(depth *Depth)
+	tickerCallback func(ticker *Ticker)
+	tradeCallback  func(trade *Trade)
+}
+
+//g可为nil, 此时只能订阅公共频道
+func NewGdaxWs(g *Gdax) *GdaxWs {
+	ws := &GdaxWs{
+		g:         g,
+		DepthSize: 20,
+		subs:      make(map[string][]string, 3),
+		books:     make(map[string]*level2Book, 2),
+	}
+	ws.WsBuilder = NewWsBuilder().
+		WsUrl(wsFeedUrl).
+		AutoReconnect().
+		ProtoHandleFunc(ws.handle).
+		ConnectSuccessAfterSendMessage(ws.resubscribeMessage)
+	return ws
+}
+
+func (ws *GdaxWs) DepthCallback(call func(depth *Depth)) {
+	ws.depthCallback = call
+}
+
+func (ws *GdaxWs) TickerCallback(call func(ticker *Ticker)) {
+	ws.tickerCallback = call
+}
+
+func (ws *GdaxWs) TradeCallback(call func(trade *Trade)) {
+	ws.tradeCallback = call
+}
+
+func (ws *GdaxWs) connect() error {
+	ws.Do(func() {
+		ws.wsConn, ws.connErr = ws.WsBuilder.Build()
+	})
+	return ws.connErr
+}
+
+func (ws *GdaxWs) subscribeMessage(channels []wsChannel) wsSubscribe {
+	msg := wsSubscribe{Type: "subscribe", Channels: channels}
+	if ws.g == nil || ws.g.accessKey == "" {
+		return msg
+	}
+	timestamp := fmt.Sprint(ws.g.clock.Now().Unix())
+	sign, err := ws.g.sign(timestamp, "GET", "/users/self/verify", "")
+	if err != nil {
+		logger.Error("[gdax ws] sign error: ", err)
+		return msg
+	}
+	msg.Signature = sign
+	msg.Key = ws.g.accessKey
+	msg.Passphrase = ws.g.passphrase
+	msg.Timestamp = timestamp
+	return msg
+}
+
+//重连后重新签名并订阅所有频道
+func (ws *GdaxWs) resubscribeMessage() []byte {
+	ws.lock.Lock()
+	var channels []wsChannel
+	for name, ids := range ws.subs {
+		channels = append(channels, wsChannel{Name: name, ProductIds: ids})
+	}
+	ws.books = make(map[string]*level2Book, 2)
+	ws.lock.Unlock()
+
+	data, _ := json.Marshal(ws.subscribeMessage(channels))
+	return data
+}
+
+func (ws *GdaxWs) subscribe(channel string, pair CurrencyPair) error {
+	if err := ws.connect(); err != nil {
+		return err
+	}
+	productId := pair.ToUpper().ToSymbol("-")
+
+	ws.lock.Lock()
+	ws.subs[channel] = append(ws.subs[channel], productId)
+	ws.lock.Unlock()
+
+	return ws.wsConn.SendJsonMessage(ws.subscribeMessage([]wsChannel{{Name: channel, ProductIds: []string{productId}}}))
+}
+
+func (ws *GdaxWs) SubscribeDepth(pair CurrencyPair) error {
+	return ws.subscribe("level2", pair)
+}
+
+func (ws *GdaxWs) SubscribeTicker(pair CurrencyPair) error {
+	return ws.subscribe("ticker", pair)
+}
+
+func (ws *GdaxWs) SubscribeTrade(pair CurrencyPair) error {
+	return ws.subscribe("matches", pair)
+}
+
+func (ws *GdaxWs) Close() {
+	if ws.wsConn != nil {
+		ws.wsConn.CloseWs()
+	}
+}
+
+func (ws *GdaxWs) handle(data []byte) error {
+	var msg wsMessage
+	err := json.Unmarshal(data, &msg)
+	if err != nil {
+		logger.Errorf("[gdax ws] json unmarshal error [%s], data = %s", err, string(data))
+		return err
+	}
+
+	pair := NewCurrencyPair3(msg.ProductId, "-")
+
+	switch msg.Type {
+	case "snapshot":
+		book := &level2Book{asks: make(map[string]float64, len(msg.Asks)), bids: make(map[string]float64, len(msg.Bids))}
+		for _, ask := range msg.Asks {
+			book.update("sell", ask[0], ask[1])
+		}
+		for _, bid := range msg.Bids {
+			book.update("buy", bid[0], bid[1])
+		}
+		ws.lock.Lock()
+		ws.books[msg.ProductId] = book
+		dep := book.depth(ws.DepthSize)
+		ws.lock.Unlock()
+		dep.Pair = pair
+		dep.UTime = time.Now()
+		if ws.depthCallback != nil {
+			ws.depthCallback(dep)
+		}
+	case "l2update":
+		ws.lock.Lock()
+		book, ok := ws.books[msg.ProductId]
+		if !ok { //没有快照的增量无法使用
+			ws.lock.Unlock()
+			return nil
+		}
+		for _, change := range msg.Changes {
+			book.update(change[0], change[1], change[2])
+		}
+		dep := book.depth(ws.DepthSize)
+		ws.lock.Unlock()
+		dep.Pair = pair
+		dep.UTime = time.Unix(0, parseTime(msg.Time)*int64(time.Millisecond))
+		if ws.depthCallback != nil {
+			ws.depthCallback(dep)
+		}
+	case "ticker":
+		if ws.tickerCallback != nil {
+			ws.tickerCallback(&Ticker{
+				Pair: pair,
+				Last: ToFloat64(msg.Price),
+				Buy:  ToFloat64(msg.BestBid),
+				Sell: ToFloat64(msg.BestAsk),
+				High: ToFloat64(msg.High24h),
+				Low:  ToFloat64(msg.Low24h),
+				Vol:  ToFloat64(msg.Volume24h),
+				Date: uint64(parseTime(msg.Time)),
+			})
+		}
+	case "match", "last_match":
+		if ws.tradeCallback != nil {
+			//side为maker方向, 转为taker方向
+			side := SELL
+			if msg.Side == "sell" {
+				side = BUY
+			}
+			ws.tradeCallback(&Trade{
+				Tid:    msg.TradeId,
+				Type:   side,
+				Amount: ToFloat64(msg.Size),
+				Price:  ToFloat64(msg.Price),
+				Date:   parseTime(msg.Time),
+				Pair:   pair,
+			})
+		}
+	case "error":
+		logger.Errorf("[gdax ws] %s: %s", msg.Message, msg.Reason)
+		return fmt.Errorf("%s: %s", msg.Message, msg.Reason)
+	case "subscriptions", "heartbeat":
+	default:
+		logger.Warn("[gdax ws] unknown message: ", string(data))
+	}
+	return nil
+}
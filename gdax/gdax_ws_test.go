@@ -0,0 +1,44 @@
+package gdax
+
+import (
+	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGdaxWs_Level2(t *testing.T) {
+	ws := NewGdaxWs(nil)
+	ws.DepthSize = 2
+
+	var depth *goex.Depth
+	ws.DepthCallback(func(dep *goex.Depth) {
+		depth = dep
+	})
+
+	//没有快照的增量被忽略
+	assert.Nil(t, ws.handle([]byte(`{"type":"l2update","product_id":"BTC-USD","changes":[["buy","10100.00","1"]]}`)))
+	assert.Nil(t, depth)
+
+	assert.Nil(t, ws.handle([]byte(`{"type":"snapshot","product_id":"BTC-USD",
+		"bids":[["10101.10","0.45"],["10101.00","1.2"],["10100.00","3"]],
+		"asks":[["10102.55","0.57"],["10103.00","2"],["10104.00","1"]]}`)))
+	assert.Equal(t, "BTC_USD", depth.Pair.String())
+	assert.Equal(t, goex.DepthRecords{{Price: 10101.10, Amount: 0.45}, {Price: 10101.00, Amount: 1.2}}, depth.BidList)
+	assert.Equal(t, goex.DepthRecords{{Price: 10103.00, Amount: 2}, {Price: 10102.55, Amount: 0.57}}, depth.AskList)
+
+	assert.Nil(t, ws.handle([]byte(`{"type":"l2update","product_id":"BTC-USD","time":"2019-08-14T20:42:27.265Z",
+		"changes":[["buy","10101.10","0"],["sell","10102.00","0.1"]]}`)))
+	assert.Equal(t, goex.DepthRecords{{Price: 10101.00, Amount: 1.2}, {Price: 10100.00, Amount: 3}}, depth.BidList)
+	assert.Equal(t, goex.DepthRecords{{Price: 10102.55, Amount: 0.57}, {Price: 10102.00, Amount: 0.1}}, depth.AskList)
+	assert.Equal(t, int64(1565815347265), depth.UTime.UnixNano()/1e6)
+
+	var trade *goex.Trade
+	ws.TradeCallback(func(tr *goex.Trade) {
+		trade = tr
+	})
+	assert.Nil(t, ws.handle([]byte(`{"type":"match","trade_id":10,"side":"sell","size":"5.23512","price":"400.23",
+		"product_id":"BTC-USD","time":"2014-11-07T08:19:27.028459Z"}`)))
+	assert.Equal(t, goex.BUY, trade.Type)
+	assert.Equal(t, 400.23, trade.Price)
+}
@@ -16,6 +16,7 @@ import (
 
 type Hbdm struct {
 	config *APIConfig
+	clock  *ClockSync
 }
 
 type OrderInfo struct {
@@ -126,7 +127,21 @@ func NewHbdm(conf *APIConfig) *Hbdm {
 		conf.Lever = 10
 	}
 	hbdmInit()
-	return &Hbdm{conf}
+	dm := &Hbdm{config: conf}
+	dm.clock = SharedClockSync(conf.Endpoint+"/api/v1/timestamp", dm.GetServerTime)
+	return dm
+}
+
+// GetServerTime 返回服务器毫秒时间戳
+func (dm *Hbdm) GetServerTime() (int64, error) {
+	respMap, err := HttpGet(dm.config.HttpClient, dm.config.Endpoint+"/api/v1/timestamp")
+	if err != nil {
+		return 0, err
+	}
+	if respMap["status"] != "ok" {
+		return 0, errors.New(fmt.Sprint(respMap["err_msg"]))
+	}
+	return ToInt64(respMap["ts"]), nil
 }
 
 func (dm *Hbdm) GetExchangeName() string {
@@ -767,7 +782,7 @@ func (dm *Hbdm) buildPostForm(reqMethod, path string, postForm *url.Values) erro
 	postForm.Set("AccessKeyId", dm.config.ApiKey)
 	postForm.Set("SignatureMethod", "HmacSHA256")
 	postForm.Set("SignatureVersion", "2")
-	postForm.Set("Timestamp", time.Now().UTC().Format("2006-01-02T15:04:05"))
+	postForm.Set("Timestamp", dm.clock.Now().UTC().Format("2006-01-02T15:04:05"))
 	domain := strings.Replace(dm.config.Endpoint, "https://", "", len(dm.config.Endpoint))
 	payload := fmt.Sprintf("%s\n%s\n%s\n%s", reqMethod, domain, path, postForm.Encode())
 	sign, _ := GetParamHmacSHA256Base64Sign(dm.config.ApiSecretKey, payload)
@@ -0,0 +1,682 @@
+package huobi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	. "github.com/lucas7788/goex"
+)
+
+const (
+	LinearSwapMarginCross    = "cross"    //全仓
+	LinearSwapMarginIsolated = "isolated" //逐仓
+
+	linearSwapContractInfoApiPath    = "/linear-swap-api/v1/swap_contract_info"
+	linearSwapIndexApiPath           = "/linear-swap-api/v1/swap_index"
+	linearSwapFundingRateApiPath     = "/linear-swap-api/v1/swap_funding_rate"
+	linearSwapHisFundingRateApiPath  = "/linear-swap-api/v1/swap_historical_funding_rate"
+	linearSwapTickerApiPath          = "/linear-swap-ex/market/detail/merged"
+	linearSwapDepthApiPath           = "/linear-swap-ex/market/depth"
+	linearSwapKlineApiPath           = "/linear-swap-ex/market/history/kline"
+	linearSwapTradeApiPath           = "/linear-swap-ex/market/history/trade"
+	linearSwapPrivateApiPathTemplate = "/linear-swap-api/v1/swap_%s%s" //逐仓: swap_xxx, 全仓: swap_cross_xxx
+)
+
+/**
+ * 火币USDT本位永续合约(linear-swap-api)
+ * MarginMode 为 LinearSwapMarginCross(默认) 或 LinearSwapMarginIsolated
+ * contractType 参数只支持 SWAP_CONTRACT / SWAP_USDT_CONTRACT, 合约代码为 BTC-USDT
+ */
+type HbdmLinearSwap struct {
+	base       *Hbdm
+	c          *APIConfig
+	MarginMode string
+}
+
+func NewHbdmLinearSwap(c *APIConfig) *HbdmLinearSwap {
+	if c.Lever <= 0 {
+		c.Lever = 10
+	}
+
+	return &HbdmLinearSwap{
+		base:       NewHbdm(c),
+		c:          c,
+		MarginMode: LinearSwapMarginCross,
+	}
+}
+
+func (swap *HbdmLinearSwap) GetExchangeName() string {
+	return HBDM_LINEAR_SWAP
+}
+
+func (swap *HbdmLinearSwap) isCross() bool {
+	return swap.MarginMode != LinearSwapMarginIsolated
+}
+
+func (swap *HbdmLinearSwap) privateApiPath(name string) string {
+	if swap.isCross() {
+		return fmt.Sprintf(linearSwapPrivateApiPathTemplate, "cross_", name)
+	}
+	return fmt.Sprintf(linearSwapPrivateApiPathTemplate, "", name)
+}
+
+func (swap *HbdmLinearSwap) adaptContractCode(currencyPair CurrencyPair) string {
+	return currencyPair.AdaptUsdToUsdt().ToUpper().ToSymbol("-")
+}
+
+func (swap *HbdmLinearSwap) checkContractType(contractType string) error {
+	if contractType == "" || contractType == SWAP_CONTRACT || contractType == SWAP_USDT_CONTRACT {
+		return nil
+	}
+	return errors.New("contract type is error")
+}
+
+func (swap *HbdmLinearSwap) doGet(path string, params url.Values, data interface{}) error {
+	var ret BaseResponse
+	reqUrl := swap.base.config.Endpoint + path
+	if len(params) > 0 {
+		reqUrl += "?" + params.Encode()
+	}
+	err := HttpGet4(swap.base.config.HttpClient, reqUrl, nil, &ret)
+	if err != nil {
+		return err
+	}
+	if ret.Status != "ok" {
+		return errors.New(fmt.Sprintf("%d:[%s]", ret.ErrCode, ret.ErrMsg))
+	}
+	return json.Unmarshal(ret.Data, data)
+}
+
+func (swap *HbdmLinearSwap) GetFutureTicker(currencyPair CurrencyPair, contractType string) (*Ticker, error) {
+	var tickResponse struct {
+		BaseResponse
+		Tick struct {
+			Vol   float64   `json:"vol,string"`
+			Open  float64   `json:"open,string"`
+			Close float64   `json:"close,string"`
+			Low   float64   `json:"low,string"`
+			High  float64   `json:"high,string"`
+			Ask   []float64 `json:"ask"`
+			Bid   []float64 `json:"bid"`
+			Ts    int64     `json:"ts"`
+		} `json:"tick"`
+	}
+
+	tickerUrl := fmt.Sprintf("%s%s?contract_code=%s", swap.base.config.Endpoint, linearSwapTickerApiPath, swap.adaptContractCode(currencyPair))
+	err := HttpGet4(swap.base.config.HttpClient, tickerUrl, nil, &tickResponse)
+	if err != nil {
+		return nil, err
+	}
+	if tickResponse.Status != "ok" {
+		return nil, errors.New(tickResponse.ErrMsg)
+	}
+
+	ticker := &Ticker{
+		Pair: currencyPair,
+		Last: tickResponse.Tick.Close,
+		High: tickResponse.Tick.High,
+		Low:  tickResponse.Tick.Low,
+		Vol:  tickResponse.Tick.Vol,
+		Date: uint64(tickResponse.Tick.Ts),
+	}
+	if len(tickResponse.Tick.Bid) > 0 {
+		ticker.Buy = tickResponse.Tick.Bid[0]
+	}
+	if len(tickResponse.Tick.Ask) > 0 {
+		ticker.Sell = tickResponse.Tick.Ask[0]
+	}
+	return ticker, nil
+}
+
+func (swap *HbdmLinearSwap) GetFutureDepth(currencyPair CurrencyPair, contractType string, size int) (*Depth, error) {
+	step := 0
+	if size <= 20 {
+		step = 6
+	}
+
+	var depthResponse struct {
+		BaseResponse
+		Tick struct {
+			Ts   int64       `json:"ts"`
+			Bids [][]float64 `json:"bids"`
+			Asks [][]float64 `json:"asks"`
+		} `json:"tick"`
+	}
+
+	depthUrl := fmt.Sprintf("%s%s?contract_code=%s&type=step%d", swap.base.config.Endpoint, linearSwapDepthApiPath, swap.adaptContractCode(currencyPair), step)
+	err := HttpGet4(swap.base.config.HttpClient, depthUrl, nil, &depthResponse)
+	if err != nil {
+		return nil, err
+	}
+	if depthResponse.Status != "ok" {
+		return nil, errors.New(depthResponse.ErrMsg)
+	}
+
+	dep := &Depth{
+		Pair:         currencyPair,
+		ContractType: contractType,
+		UTime:        time.Unix(0, depthResponse.Tick.Ts*int64(time.Millisecond)),
+	}
+	for i, item := range depthResponse.Tick.Bids {
+		if i >= size {
+			break
+		}
+		dep.BidList = append(dep.BidList, DepthRecord{Price: item[0], Amount: item[1]})
+	}
+	for i, item := range depthResponse.Tick.Asks {
+		if i >= size {
+			break
+		}
+		dep.AskList = append(dep.AskList, DepthRecord{Price: item[0], Amount: item[1]})
+	}
+	sort.Sort(sort.Reverse(dep.AskList))
+
+	return dep, nil
+}
+
+func (swap *HbdmLinearSwap) GetFutureIndex(currencyPair CurrencyPair) (float64, error) {
+	var indexResponse []struct {
+		ContractCode string  `json:"contract_code"`
+		IndexPrice   float64 `json:"index_price"`
+	}
+	err := swap.doGet(linearSwapIndexApiPath, url.Values{"contract_code": {swap.adaptContractCode(currencyPair)}}, &indexResponse)
+	if err != nil {
+		return 0, err
+	}
+	if len(indexResponse) == 0 {
+		return 0, errors.New("not found")
+	}
+	return indexResponse[0].IndexPrice, nil
+}
+
+//当期资金费率及预测费率
+func (swap *HbdmLinearSwap) GetFundingRate(currencyPair CurrencyPair) (fundingRate, estimatedRate float64, fundingTime time.Time, err error) {
+	var fundingResponse struct {
+		FundingRate     float64 `json:"funding_rate,string"`
+		EstimatedRate   float64 `json:"estimated_rate,string"`
+		FundingTime     int64   `json:"funding_time,string"`
+		NextFundingTime int64   `json:"next_funding_time,string"`
+	}
+	err = swap.doGet(linearSwapFundingRateApiPath, url.Values{"contract_code": {swap.adaptContractCode(currencyPair)}}, &fundingResponse)
+	if err != nil {
+		return
+	}
+	return fundingResponse.FundingRate, fundingResponse.EstimatedRate, time.Unix(0, fundingResponse.FundingTime*int64(time.Millisecond)), nil
+}
+
+//历史资金费率, pageIndex从1开始, pageSize最大50
+func (swap *HbdmLinearSwap) GetHistoricalFunding(currencyPair CurrencyPair, pageIndex, pageSize int) ([]HistoricalFunding, error) {
+	params := url.Values{}
+	params.Set("contract_code", swap.adaptContractCode(currencyPair))
+	params.Set("page_index", fmt.Sprint(pageIndex))
+	params.Set("page_size", fmt.Sprint(pageSize))
+
+	var fundingResponse struct {
+		Data []struct {
+			ContractCode string  `json:"contract_code"`
+			RealizedRate float64 `json:"realized_rate,string"`
+			FundingTime  int64   `json:"funding_time,string"`
+		} `json:"data"`
+	}
+	err := swap.doGet(linearSwapHisFundingRateApiPath, params, &fundingResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	fundings := make([]HistoricalFunding, 0, len(fundingResponse.Data))
+	for _, f := range fundingResponse.Data {
+		fundings = append(fundings, HistoricalFunding{
+			InstrumentId: f.ContractCode,
+			RealizedRate: f.RealizedRate,
+			FundingTime:  time.Unix(0, f.FundingTime*int64(time.Millisecond)),
+		})
+	}
+	return fundings, nil
+}
+
+func (swap *HbdmLinearSwap) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureAccount, error) {
+	//全仓账户为USDT, 逐仓账户为每个合约(BTC-USDT)一个保证金账户
+	var accountInfoResponse []struct {
+		MarginAccount   string  `json:"margin_account"`
+		MarginAsset     string  `json:"margin_asset"`
+		MarginBalance   float64 `json:"margin_balance"`
+		MarginPosition  float64 `json:"margin_position"`
+		MarginFrozen    float64 `json:"margin_frozen"`
+		MarginAvailable float64 `json:"margin_available"`
+		ProfitReal      float64 `json:"profit_real"`
+		ProfitUnreal    float64 `json:"profit_unreal"`
+		RiskRate        float64 `json:"risk_rate"`
+	}
+
+	param := url.Values{}
+	if swap.isCross() {
+		param.Set("margin_account", "USDT")
+	} else if len(currencyPair) > 0 {
+		param.Set("contract_code", swap.adaptContractCode(currencyPair[0]))
+	}
+
+	err := swap.base.doRequest(swap.privateApiPath("account_info"), &param, &accountInfoResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	futureAccount := &FutureAccount{FutureSubAccounts: make(map[Currency]FutureSubAccount, 4)}
+	for _, acc := range accountInfoResponse {
+		//逐仓以合约的base币种作为key, 全仓为USDT
+		currency := NewCurrency(acc.MarginAsset, "")
+		if !swap.isCross() {
+			currency = NewCurrencyPair3(acc.MarginAccount, "-").CurrencyA
+		}
+		futureAccount.FutureSubAccounts[currency] = FutureSubAccount{
+			Currency:      currency,
+			AccountRights: acc.MarginBalance,
+			KeepDeposit:   acc.MarginPosition,
+			ProfitReal:    acc.ProfitReal,
+			ProfitUnreal:  acc.ProfitUnreal,
+			RiskRate:      acc.RiskRate,
+		}
+	}
+
+	return futureAccount, nil
+}
+
+//切换杠杆倍数, 有持仓或挂单时需要与当前杠杆一致才能下单
+func (swap *HbdmLinearSwap) SwitchLeverRate(currencyPair CurrencyPair, leverRate int) error {
+	param := url.Values{}
+	param.Set("contract_code", swap.adaptContractCode(currencyPair))
+	param.Set("lever_rate", fmt.Sprint(leverRate))
+	var response struct {
+		LeverRate int `json:"lever_rate"`
+	}
+	return swap.base.doRequest(swap.privateApiPath("switch_lever_rate"), &param, &response)
+}
+
+func (swap *HbdmLinearSwap) PlaceFutureOrder(currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice int, leverRate float64) (string, error) {
+	fOrder, err := swap.PlaceFutureOrder2(currencyPair, contractType, price, amount, openType, matchPrice, leverRate)
+	return fOrder.OrderID2, err
+}
+
+func (swap *HbdmLinearSwap) PlaceFutureOrder2(currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice int, leverRate float64, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	fOrd := &FutureOrder{
+		ClientOid:    fmt.Sprint(time.Now().UnixNano()),
+		ContractName: contractType,
+		Currency:     currencyPair,
+		Price:        ToFloat64(price),
+		Amount:       ToFloat64(amount),
+		OType:        openType,
+		LeverRate:    leverRate,
+	}
+	if err := swap.checkContractType(contractType); err != nil {
+		return fOrd, err
+	}
+
+	param := url.Values{}
+	param.Set("contract_code", swap.adaptContractCode(currencyPair))
+	param.Set("client_order_id", fOrd.ClientOid)
+	param.Set("volume", amount)
+	param.Set("lever_rate", fmt.Sprintf("%.0f", leverRate))
+
+	direction, offset := swap.base.adaptOpenType(openType)
+	param.Set("direction", direction)
+	param.Set("offset", offset)
+
+	if matchPrice == 1 {
+		param.Set("order_price_type", "opponent") //对手价下单
+	} else {
+		orderPriceType := "limit"
+		if len(opt) > 0 {
+			switch opt[0] {
+			case Fok:
+				orderPriceType = "fok"
+			case Ioc:
+				orderPriceType = "ioc"
+			case PostOnly:
+				orderPriceType = "post_only"
+			}
+		}
+		param.Set("order_price_type", orderPriceType)
+		param.Set("price", price)
+	}
+
+	var orderResponse struct {
+		OrderId       string `json:"order_id_str"`
+		ClientOrderId int64  `json:"client_order_id"`
+	}
+
+	err := swap.base.doRequest(swap.privateApiPath("order"), &param, &orderResponse)
+	if err != nil {
+		return fOrd, err
+	}
+
+	fOrd.OrderID2 = orderResponse.OrderId
+	fOrd.OrderTime = time.Now().UnixNano() / int64(time.Millisecond)
+	return fOrd, nil
+}
+
+func (swap *HbdmLinearSwap) LimitFuturesOrder(currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	return swap.PlaceFutureOrder2(currencyPair, contractType, price, amount, openType, 0, swap.c.Lever, opt...)
+}
+
+func (swap *HbdmLinearSwap) MarketFuturesOrder(currencyPair CurrencyPair, contractType, amount string, openType int) (*FutureOrder, error) {
+	return swap.PlaceFutureOrder2(currencyPair, contractType, "", amount, openType, 1, swap.c.Lever)
+}
+
+func (swap *HbdmLinearSwap) FutureCancelOrder(currencyPair CurrencyPair, contractType, orderId string) (bool, error) {
+	param := url.Values{}
+	param.Set("order_id", orderId)
+	param.Set("contract_code", swap.adaptContractCode(currencyPair))
+
+	var cancelResponse struct {
+		Errors []struct {
+			OrderId string `json:"order_id"`
+			ErrCode int    `json:"err_code"`
+			ErrMsg  string `json:"err_msg"`
+		} `json:"errors"`
+		Successes string `json:"successes"`
+	}
+
+	err := swap.base.doRequest(swap.privateApiPath("cancel"), &param, &cancelResponse)
+	if err != nil {
+		return false, err
+	}
+
+	if len(cancelResponse.Errors) > 0 {
+		return false, errors.New(fmt.Sprintf("%d:[%s]", cancelResponse.Errors[0].ErrCode, cancelResponse.Errors[0].ErrMsg))
+	}
+
+	return true, nil
+}
+
+func (swap *HbdmLinearSwap) GetFuturePosition(currencyPair CurrencyPair, contractType string) ([]FuturePosition, error) {
+	param := url.Values{}
+	param.Set("contract_code", swap.adaptContractCode(currencyPair))
+
+	var positionResponse []struct {
+		ContractCode   string  `json:"contract_code"`
+		Volume         float64 `json:"volume"`
+		Available      float64 `json:"available"`
+		CostOpen       float64 `json:"cost_open"`
+		CostHold       float64 `json:"cost_hold"`
+		ProfitUnreal   float64 `json:"profit_unreal"`
+		ProfitRate     float64 `json:"profit_rate"`
+		Profit         float64 `json:"profit"`
+		PositionMargin float64 `json:"position_margin"`
+		LeverRate      float64 `json:"lever_rate"`
+		Direction      string  `json:"direction"`
+	}
+
+	err := swap.base.doRequest(swap.privateApiPath("position_info"), &param, &positionResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	positionMap := make(map[string]*FuturePosition, 2)
+	for _, pos := range positionResponse {
+		p := positionMap[pos.ContractCode]
+		if p == nil {
+			p = &FuturePosition{
+				ContractType: pos.ContractCode,
+				Symbol:       NewCurrencyPair3(pos.ContractCode, "-"),
+				LeverRate:    pos.LeverRate,
+			}
+			positionMap[pos.ContractCode] = p
+		}
+		switch pos.Direction {
+		case "sell":
+			p.SellAmount = pos.Volume
+			p.SellAvailable = pos.Available
+			p.SellPriceAvg = pos.CostOpen
+			p.SellPriceCost = pos.CostHold
+			p.SellProfitReal = pos.ProfitRate
+			p.SellProfit = pos.ProfitUnreal
+			p.ShortPnlRatio = pos.ProfitRate
+		case "buy":
+			p.BuyAmount = pos.Volume
+			p.BuyAvailable = pos.Available
+			p.BuyPriceAvg = pos.CostOpen
+			p.BuyPriceCost = pos.CostHold
+			p.BuyProfitReal = pos.ProfitRate
+			p.BuyProfit = pos.ProfitUnreal
+			p.LongPnlRatio = pos.ProfitRate
+		}
+	}
+
+	futuresPositions := make([]FuturePosition, 0, len(positionMap))
+	for _, pos := range positionMap {
+		futuresPositions = append(futuresPositions, *pos)
+	}
+
+	return futuresPositions, nil
+}
+
+func (swap *HbdmLinearSwap) adaptOrderInfo(currencyPair CurrencyPair, ord OrderInfo) FutureOrder {
+	orderTime := ord.CreatedAt
+	if orderTime == 0 {
+		orderTime = ord.CreateDate
+	}
+	return FutureOrder{
+		Currency:     currencyPair,
+		ClientOid:    fmt.Sprint(ord.ClientOrderId),
+		OrderID2:     fmt.Sprint(ord.OrderId),
+		OrderID:      ord.OrderId,
+		Price:        ord.Price,
+		Amount:       ord.Volume,
+		AvgPrice:     ord.TradeAvgPrice,
+		DealAmount:   ord.TradeVolume,
+		Status:       swap.base.adaptOrderStatus(ord.Status),
+		OType:        swap.base.adaptOffsetDirectionToOpenType(ord.Offset, ord.Direction),
+		LeverRate:    ord.LeverRate,
+		Fee:          ord.Fee,
+		ContractName: ord.ContractCode,
+		OrderTime:    orderTime,
+	}
+}
+
+func (swap *HbdmLinearSwap) GetFutureOrders(orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	if len(orderIds) == 0 {
+		return nil, nil
+	}
+
+	var (
+		orderInfoResponse []OrderInfo
+		param             = url.Values{}
+	)
+	param.Set("contract_code", swap.adaptContractCode(currencyPair))
+	param.Set("order_id", strings.Join(orderIds, ","))
+
+	err := swap.base.doRequest(swap.privateApiPath("order_info"), &param, &orderInfoResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]FutureOrder, 0, len(orderInfoResponse))
+	for _, ord := range orderInfoResponse {
+		orders = append(orders, swap.adaptOrderInfo(currencyPair, ord))
+	}
+	return orders, nil
+}
+
+func (swap *HbdmLinearSwap) GetFutureOrder(orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error) {
+	orders, err := swap.GetFutureOrders([]string{orderId}, currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, errors.New("not found")
+	}
+	return &orders[0], nil
+}
+
+func (swap *HbdmLinearSwap) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	param := url.Values{}
+	param.Set("contract_code", swap.adaptContractCode(currencyPair))
+	param.Set("page_size", "50")
+
+	var openOrderResponse struct {
+		Orders []OrderInfo `json:"orders"`
+	}
+
+	err := swap.base.doRequest(swap.privateApiPath("openorders"), &param, &openOrderResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	openOrders := make([]FutureOrder, 0, len(openOrderResponse.Orders))
+	for _, ord := range openOrderResponse.Orders {
+		openOrders = append(openOrders, swap.adaptOrderInfo(currencyPair, ord))
+	}
+	return openOrders, nil
+}
+
+func (swap *HbdmLinearSwap) GetFutureOrderHistory(pair CurrencyPair, contractType string, optional ...OptionalParameter) ([]FutureOrder, error) {
+	if err := swap.checkContractType(contractType); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Add("contract_code", swap.adaptContractCode(pair))
+	params.Add("status", "0")     //all
+	params.Add("type", "1")       //all
+	params.Add("trade_type", "0") //all
+	MergeOptionalParameter(&params, optional...)
+
+	var historyOrderResp struct {
+		Orders     []OrderInfo `json:"orders"`
+		RemainSize int64       `json:"remain_size"`
+		NextId     int64       `json:"next_id"`
+	}
+
+	err := swap.base.doRequest(swap.privateApiPath("hisorders_exact"), &params, &historyOrderResp)
+	if err != nil {
+		return nil, err
+	}
+
+	historyOrders := make([]FutureOrder, 0, len(historyOrderResp.Orders))
+	for _, ord := range historyOrderResp.Orders {
+		historyOrders = append(historyOrders, swap.adaptOrderInfo(pair, ord))
+	}
+	return historyOrders, nil
+}
+
+//合约面值, 单位为base币种, 如BTC-USDT为0.001
+func (swap *HbdmLinearSwap) GetContractValue(currencyPair CurrencyPair) (float64, error) {
+	var contractInfoResponse []struct {
+		ContractCode string  `json:"contract_code"`
+		ContractSize float64 `json:"contract_size"`
+		PriceTick    float64 `json:"price_tick"`
+	}
+	err := swap.doGet(linearSwapContractInfoApiPath, url.Values{"contract_code": {swap.adaptContractCode(currencyPair)}}, &contractInfoResponse)
+	if err != nil {
+		return 0, err
+	}
+	if len(contractInfoResponse) == 0 {
+		return 0, errors.New("not found")
+	}
+	return contractInfoResponse[0].ContractSize, nil
+}
+
+func (swap *HbdmLinearSwap) GetKlineRecords(contractType string, currency CurrencyPair, period KlinePeriod, size int, opt ...OptionalParameter) ([]FutureKline, error) {
+	params := url.Values{}
+	params.Set("contract_code", swap.adaptContractCode(currency))
+	params.Set("period", swap.base.adaptKLinePeriod(period))
+	params.Set("size", fmt.Sprint(size))
+	MergeOptionalParameter(&params, opt...)
+
+	var ret struct {
+		BaseResponse
+		Data []struct {
+			Id            int64   `json:"id"`
+			Amount        float64 `json:"amount"`
+			Close         float64 `json:"close"`
+			High          float64 `json:"high"`
+			Low           float64 `json:"low"`
+			Open          float64 `json:"open"`
+			Vol           float64 `json:"vol"`
+			TradeTurnover float64 `json:"trade_turnover"`
+		} `json:"data"`
+	}
+
+	err := HttpGet4(swap.base.config.HttpClient, swap.base.config.Endpoint+linearSwapKlineApiPath+"?"+params.Encode(), nil, &ret)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != "ok" {
+		return nil, errors.New(ret.ErrMsg)
+	}
+
+	klines := make([]FutureKline, 0, len(ret.Data))
+	for _, d := range ret.Data {
+		klines = append(klines, FutureKline{
+			Kline: &Kline{
+				Pair:      currency,
+				Vol:       d.Amount,
+				Open:      d.Open,
+				Close:     d.Close,
+				High:      d.High,
+				Low:       d.Low,
+				Timestamp: d.Id},
+			Vol2: d.Vol})
+	}
+
+	return klines, nil
+}
+
+//since 参数无效, 返回最近的成交
+func (swap *HbdmLinearSwap) GetTrades(contractType string, currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	var ret struct {
+		BaseResponse
+		Data []struct {
+			Data []struct {
+				Id        int64   `json:"id"`
+				Amount    float64 `json:"amount"`
+				Quantity  float64 `json:"quantity"`
+				Direction string  `json:"direction"`
+				Price     float64 `json:"price"`
+				Ts        int64   `json:"ts"`
+			} `json:"data"`
+		} `json:"data"`
+	}
+
+	tradeUrl := fmt.Sprintf("%s%s?contract_code=%s&size=100", swap.base.config.Endpoint, linearSwapTradeApiPath, swap.adaptContractCode(currencyPair))
+	err := HttpGet4(swap.base.config.HttpClient, tradeUrl, nil, &ret)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != "ok" {
+		return nil, errors.New(ret.ErrMsg)
+	}
+
+	var trades []Trade
+	for _, d := range ret.Data {
+		for _, t := range d.Data {
+			trades = append(trades, Trade{
+				Tid:    t.Id,
+				Type:   AdaptTradeSide(t.Direction),
+				Amount: t.Quantity,
+				Price:  t.Price,
+				Date:   t.Ts,
+				Pair:   currencyPair,
+			})
+		}
+	}
+	return trades, nil
+}
+
+func (swap *HbdmLinearSwap) GetFee() (float64, error) {
+	return 0, EX_ERR_NOT_SUPPORT
+}
+
+//永续合约没有交割时间
+func (swap *HbdmLinearSwap) GetDeliveryTime() (int, int, int, int) {
+	return 0, 0, 0, 0
+}
+
+//永续合约没有预估交割价
+func (swap *HbdmLinearSwap) GetFutureEstimatedPrice(currencyPair CurrencyPair) (float64, error) {
+	return 0, EX_ERR_NOT_SUPPORT
+}
@@ -0,0 +1,108 @@
+package huobi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+//USDT本位永续合约接口, 路由的key为path
+var linearSwapAPI = testserver.Options{
+	Wrap: func(r *testserver.Request, data interface{}) interface{} {
+		return map[string]interface{}{"status": "ok", "data": data, "ts": 1603695163000}
+	},
+	NotFound: func(r *testserver.Request) interface{} {
+		return map[string]interface{}{"status": "error", "err_code": 404, "err_msg": r.URL.Path}
+	},
+	Key: func(r *testserver.Request) string {
+		return r.URL.Path
+	},
+}
+
+func TestHbdmLinearSwap_LimitFuturesOrder(t *testing.T) {
+	var orderParams []map[string]string
+	order := func(r *testserver.Request) interface{} {
+		params := r.Params()
+		orderParams = append(orderParams, params)
+		return map[string]interface{}{"order_id": 770434885714452480, "order_id_str": "770434885714452480"}
+	}
+	srv := testserver.New(linearSwapAPI, map[string]testserver.Route{
+		"/linear-swap-api/v1/swap_cross_order": order,
+		"/linear-swap-api/v1/swap_order":       order,
+	})
+	defer srv.Close()
+	swap := NewHbdmLinearSwap(&goex.APIConfig{HttpClient: srv.Client(), Endpoint: srv.URL, Lever: 5})
+
+	ord, err := swap.LimitFuturesOrder(goex.BTC_USDT, goex.SWAP_USDT_CONTRACT, "13000", "1", goex.CLOSE_BUY, goex.PostOnly)
+	assert.Nil(t, err)
+	assert.Equal(t, "770434885714452480", ord.OrderID2)
+
+	swap.MarginMode = LinearSwapMarginIsolated
+	_, err = swap.MarketFuturesOrder(goex.BTC_USD, goex.SWAP_CONTRACT, "2", goex.OPEN_SELL)
+	assert.Nil(t, err)
+
+	_, err = swap.MarketFuturesOrder(goex.BTC_USD, goex.QUARTER_CONTRACT, "2", goex.OPEN_SELL)
+	assert.Error(t, err)
+
+	assert.Len(t, orderParams, 2)
+	assert.Equal(t, "BTC-USDT", orderParams[0]["contract_code"])
+	assert.Equal(t, "sell", orderParams[0]["direction"])
+	assert.Equal(t, "close", orderParams[0]["offset"])
+	assert.Equal(t, "post_only", orderParams[0]["order_price_type"])
+	assert.Equal(t, "13000", orderParams[0]["price"])
+	assert.Equal(t, "5", orderParams[0]["lever_rate"])
+	assert.Equal(t, "opponent", orderParams[1]["order_price_type"])
+	assert.Equal(t, "open", orderParams[1]["offset"])
+	assert.Equal(t, "", orderParams[1]["price"])
+}
+
+func TestHbdmLinearSwap_GetFuturePosition(t *testing.T) {
+	srv := testserver.New(linearSwapAPI, map[string]testserver.Route{
+		"/linear-swap-api/v1/swap_cross_position_info": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "ETH-USDT", params["contract_code"])
+			return []map[string]interface{}{
+				{"contract_code": "ETH-USDT", "volume": 3, "available": 2, "cost_open": 390.5, "cost_hold": 391.2,
+					"profit_unreal": 1.2, "profit_rate": 0.03, "lever_rate": 5, "direction": "buy", "margin_mode": "cross"},
+				{"contract_code": "ETH-USDT", "volume": 1, "available": 1, "cost_open": 392, "cost_hold": 392,
+					"profit_unreal": -0.1, "profit_rate": -0.01, "lever_rate": 5, "direction": "sell", "margin_mode": "cross"},
+			}
+		},
+		"/linear-swap-api/v1/swap_cross_account_info": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "USDT", params["margin_account"])
+			return []map[string]interface{}{{"margin_mode": "cross", "margin_account": "USDT", "margin_asset": "USDT",
+				"margin_balance": 100.5, "margin_position": 23.4, "profit_unreal": 1.1, "risk_rate": 4.2}}
+		},
+	})
+	defer srv.Close()
+	swap := NewHbdmLinearSwap(&goex.APIConfig{HttpClient: srv.Client(), Endpoint: srv.URL, Lever: 5})
+
+	positions, err := swap.GetFuturePosition(goex.ETH_USDT, goex.SWAP_USDT_CONTRACT)
+	assert.Nil(t, err)
+	assert.Len(t, positions, 1)
+	assert.Equal(t, 3.0, positions[0].BuyAmount)
+	assert.Equal(t, 390.5, positions[0].BuyPriceAvg)
+	assert.Equal(t, 1.0, positions[0].SellAmount)
+	assert.Equal(t, 5.0, positions[0].LeverRate)
+	assert.Equal(t, "ETH_USDT", positions[0].Symbol.String())
+
+	acc, err := swap.GetFutureUserinfo()
+	assert.Nil(t, err)
+	assert.Equal(t, 100.5, acc.FutureSubAccounts[goex.USDT].AccountRights)
+	assert.Equal(t, 23.4, acc.FutureSubAccounts[goex.USDT].KeepDeposit)
+}
+
+func TestHbdmLinearSwap_NotSupport(t *testing.T) {
+	swap := NewHbdmLinearSwap(&goex.APIConfig{HttpClient: http.DefaultClient})
+
+	_, err := swap.GetFee()
+	assert.Equal(t, goex.EX_ERR_NOT_SUPPORT, err)
+	_, err = swap.GetFutureEstimatedPrice(goex.BTC_USDT)
+	assert.Equal(t, goex.EX_ERR_NOT_SUPPORT, err)
+	d, h, m, s := swap.GetDeliveryTime()
+	assert.Equal(t, []int{0, 0, 0, 0}, []int{d, h, m, s})
+}
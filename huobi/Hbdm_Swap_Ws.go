@@ -15,7 +15,9 @@ import (
 type HbdmSwapWs struct {
 	*WsBuilder
 	sync.Once
-	wsConn *WsConn
+	connLock sync.Mutex //保护wsConn, Stats可能与连接并发调用
+	wsConn   *WsConn
+	connErr  error
 
 	tickerCallback func(*FutureTicker)
 	depthCallback  func(*Depth)
@@ -29,7 +31,8 @@ func NewHbdmSwapWs() *HbdmSwapWs {
 		//ProxyUrl("socks5://127.0.0.1:1080").
 		AutoReconnect().
 		DecompressFunc(GzipDecompress).
-		ProtoHandleFunc(ws.handle)
+		ProtoHandleFunc(ws.handle).
+		TimestampFunc(wsTimestamp)
 	return ws
 }
 
@@ -41,7 +44,8 @@ func NewHbdmLinearSwapWs() *HbdmSwapWs {
 		//ProxyUrl("socks5://127.0.0.1:1080").
 		AutoReconnect().
 		DecompressFunc(GzipDecompress).
-		ProtoHandleFunc(ws.handle)
+		ProtoHandleFunc(ws.handle).
+		TimestampFunc(wsTimestamp)
 	return ws
 }
 
@@ -108,14 +112,30 @@ func (ws *HbdmSwapWs) SubscribeTrade(pair CurrencyPair, contract string) error {
 
 func (ws *HbdmSwapWs) subscribe(sub map[string]interface{}) error {
 	//	log.Println(sub)
-	ws.connectWs()
+	if err := ws.connectWs(); err != nil {
+		return err
+	}
 	return ws.wsConn.Subscribe(sub)
 }
 
-func (ws *HbdmSwapWs) connectWs() {
+func (ws *HbdmSwapWs) connectWs() error {
 	ws.Do(func() {
-		ws.wsConn = ws.WsBuilder.Build()
+		conn, err := ws.WsBuilder.Build()
+		ws.connLock.Lock()
+		ws.wsConn, ws.connErr = conn, err
+		ws.connLock.Unlock()
 	})
+	return ws.connErr
+}
+
+//连接的统计信息, 未连接时返回零值
+func (ws *HbdmSwapWs) Stats() WsStats {
+	ws.connLock.Lock()
+	defer ws.connLock.Unlock()
+	if ws.wsConn == nil {
+		return WsStats{}
+	}
+	return ws.wsConn.Stats()
 }
 
 func (ws *HbdmSwapWs) handle(msg []byte) error {
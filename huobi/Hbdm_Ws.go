@@ -18,6 +18,17 @@ type WsResponse struct {
 	Tick json.RawMessage
 }
 
+//消息的ts(毫秒), 用于统计延迟, 心跳等没有ts的消息返回零值
+func wsTimestamp(msg []byte) time.Time {
+	var resp struct {
+		Ts int64 `json:"ts"`
+	}
+	if json.Unmarshal(msg, &resp) != nil || resp.Ts <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, resp.Ts*int64(time.Millisecond))
+}
+
 type TradeResponse struct {
 	Id   int64
 	Ts   int64
@@ -60,7 +71,9 @@ type DepthResponse struct {
 type HbdmWs struct {
 	*WsBuilder
 	sync.Once
-	wsConn *WsConn
+	connLock sync.Mutex //保护wsConn, Stats可能与连接并发调用
+	wsConn   *WsConn
+	connErr  error
 
 	tickerCallback func(*FutureTicker)
 	depthCallback  func(*Depth)
@@ -75,7 +88,8 @@ func NewHbdmWs() *HbdmWs {
 		//Heartbeat([]byte("{\"event\": \"ping\"} "), 30*time.Second).
 		//Heartbeat(func() []byte { return []byte("{\"op\":\"ping\"}") }(), 5*time.Second).
 		DecompressFunc(GzipDecompress).
-		ProtoHandleFunc(hbdmWs.handle)
+		ProtoHandleFunc(hbdmWs.handle).
+		TimestampFunc(wsTimestamp)
 	go hbdmInit()
 	return hbdmWs
 }
@@ -128,14 +142,30 @@ func (hbdmWs *HbdmWs) SubscribeTrade(pair CurrencyPair, contract string) error {
 
 func (hbdmWs *HbdmWs) subscribe(sub map[string]interface{}) error {
 	//	log.Println(sub)
-	hbdmWs.connectWs()
+	if err := hbdmWs.connectWs(); err != nil {
+		return err
+	}
 	return hbdmWs.wsConn.Subscribe(sub)
 }
 
-func (hbdmWs *HbdmWs) connectWs() {
+func (hbdmWs *HbdmWs) connectWs() error {
 	hbdmWs.Do(func() {
-		hbdmWs.wsConn = hbdmWs.WsBuilder.Build()
+		conn, err := hbdmWs.WsBuilder.Build()
+		hbdmWs.connLock.Lock()
+		hbdmWs.wsConn, hbdmWs.connErr = conn, err
+		hbdmWs.connLock.Unlock()
 	})
+	return hbdmWs.connErr
+}
+
+//连接的统计信息, 未连接时返回零值
+func (hbdmWs *HbdmWs) Stats() WsStats {
+	hbdmWs.connLock.Lock()
+	defer hbdmWs.connLock.Unlock()
+	if hbdmWs.wsConn == nil {
+		return WsStats{}
+	}
+	return hbdmWs.wsConn.Stats()
 }
 
 func (hbdmWs *HbdmWs) handle(msg []byte) error {
@@ -2,6 +2,7 @@ package huobi
 
 import (
 	"github.com/lucas7788/goex"
+	"github.com/stretchr/testify/assert"
 	"log"
 	"testing"
 	"time"
@@ -24,7 +25,14 @@ func TestNewHbdmWs(t *testing.T) {
 	})
 
 	t.Log(ws.SubscribeTicker(goex.BTC_USD, goex.QUARTER_CONTRACT))
-	t.Log(ws.SubscribeDepth(goex.BTC_USD, goex.NEXT_WEEK_CONTRACT, 0))
+	t.Log(ws.SubscribeDepth(goex.BTC_USD, goex.NEXT_WEEK_CONTRACT))
 	t.Log(ws.SubscribeTrade(goex.LTC_USD, goex.THIS_WEEK_CONTRACT))
 	time.Sleep(time.Minute)
 }
+
+func TestWsTimestamp(t *testing.T) {
+	ts := wsTimestamp([]byte(`{"ch":"market.BTC_CQ.detail","ts":1600000000123,"tick":{"id":1}}`))
+	assert.Equal(t, int64(1600000000123), ts.UnixNano()/int64(time.Millisecond))
+	assert.True(t, wsTimestamp([]byte(`{"ping":1600000000123}`)).IsZero())
+	assert.Equal(t, goex.WsStats{}, NewHbdmSwapWs().Stats())
+}
@@ -0,0 +1,300 @@
+package huobi
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	. "github.com/lucas7788/goex"
+)
+
+const (
+	HB_MARGIN_ACCOUNT       = "margin"       //逐仓杠杆账户
+	HB_SUPER_MARGIN_ACCOUNT = "super-margin" //全仓杠杆账户
+)
+
+/**
+ * 杠杆交易, 实现MarginAPI
+ * Cross为true时使用全仓杠杆账户, 否则为逐仓杠杆账户(每个交易对一个账户)
+ */
+type HuobiMargin struct {
+	*HuoBiPro
+	Cross      bool
+	accountIds map[string]string
+	lock       sync.Mutex
+}
+
+func NewHuobiMargin(config *APIConfig) *HuobiMargin {
+	return &HuobiMargin{HuoBiPro: NewHuobiWithConfig(config), accountIds: make(map[string]string, 2)}
+}
+
+func NewHuobiCrossMargin(config *APIConfig) *HuobiMargin {
+	m := NewHuobiMargin(config)
+	m.Cross = true
+	return m
+}
+
+func (m *HuobiMargin) symbol(pair CurrencyPair) string {
+	return pair.AdaptUsdToUsdt().ToLower().ToSymbol("")
+}
+
+//逐仓接口前缀为/v1/margin, 全仓为/v1/cross-margin
+func (m *HuobiMargin) apiPath(path string) string {
+	if m.Cross {
+		return "/v1/cross-margin" + path
+	}
+	return "/v1/margin" + path
+}
+
+//杠杆账户id, 逐仓账户的subtype为交易对
+func (m *HuobiMargin) getAccountId(pair CurrencyPair) (string, error) {
+	key := ""
+	if !m.Cross {
+		key = m.symbol(pair)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if id, ok := m.accountIds[key]; ok {
+		return id, nil
+	}
+
+	var accounts []struct {
+		Id      int64  `json:"id"`
+		Type    string `json:"type"`
+		Subtype string `json:"subtype"`
+		State   string `json:"state"`
+	}
+	err := m.doRequest("GET", "/v1/account/accounts", url.Values{}, &accounts)
+	if err != nil {
+		return "", err
+	}
+	for _, acc := range accounts {
+		if (m.Cross && acc.Type == HB_SUPER_MARGIN_ACCOUNT) ||
+			(!m.Cross && acc.Type == HB_MARGIN_ACCOUNT && acc.Subtype == key) {
+			m.accountIds[key] = fmt.Sprint(acc.Id)
+			return m.accountIds[key], nil
+		}
+	}
+	return "", errors.New("margin account not found")
+}
+
+type marginBalanceResponse struct {
+	RiskRate float64 `json:"risk-rate,string"`
+	FlPrice  string  `json:"fl-price"`
+	List     []struct {
+		Currency string  `json:"currency"`
+		Type     string  `json:"type"`
+		Balance  float64 `json:"balance,string"`
+	} `json:"list"`
+}
+
+/**
+ * 杠杆账户
+ * 逐仓: 返回交易对的base/quote币种及爆仓价
+ * 全仓: 返回所有币种
+ */
+func (m *HuobiMargin) GetMarginAccount(pair CurrencyPair) (*MarginAccount, error) {
+	var balance marginBalanceResponse
+	if m.Cross {
+		err := m.doRequest("GET", m.apiPath("/accounts/balance"), url.Values{}, &balance)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		params := url.Values{}
+		params.Set("symbol", m.symbol(pair))
+		var response []marginBalanceResponse
+		err := m.doRequest("GET", m.apiPath("/accounts/balance"), params, &response)
+		if err != nil {
+			return nil, err
+		}
+		if len(response) == 0 {
+			return nil, errors.New("margin account not found")
+		}
+		balance = response[0]
+	}
+
+	acc := &MarginAccount{
+		Sub:              make(map[Currency]MarginSubAccount, 2),
+		RiskRate:         balance.RiskRate,
+		LiquidationPrice: ToFloat64(balance.FlPrice),
+	}
+	for _, itm := range balance.List {
+		c := NewCurrency(itm.Currency, "")
+		sub := acc.Sub[c]
+		switch itm.Type {
+		case "trade":
+			sub.Available = itm.Balance
+			sub.Balance += itm.Balance
+		case "frozen":
+			sub.Frozen = itm.Balance
+			sub.Balance += itm.Balance
+		case "loan":
+			sub.Loan = -itm.Balance
+		case "interest":
+			sub.LendingFee = -itm.Balance
+		case "transfer-out-available":
+			sub.CanWithdraw = itm.Balance
+		default:
+			continue
+		}
+		acc.Sub[c] = sub
+	}
+	return acc, nil
+}
+
+//借币, 返回借币订单号
+func (m *HuobiMargin) Borrow(parameter BorrowParameter) (borrowId string, err error) {
+	params := url.Values{}
+	params.Set("currency", strings.ToLower(parameter.Currency.Symbol))
+	params.Set("amount", FloatToString(parameter.Amount, 8))
+	if !m.Cross {
+		params.Set("symbol", m.symbol(parameter.CurrencyPair))
+	}
+
+	var id int64
+	err = m.doRequest("POST", m.apiPath("/orders"), params, &id)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(id), nil
+}
+
+//还币, BorrowId为借币订单号
+func (m *HuobiMargin) Repayment(parameter RepaymentParameter) (repaymentId string, err error) {
+	if parameter.BorrowId == "" {
+		return "", errors.New("borrow id is required")
+	}
+	params := url.Values{}
+	params.Set("amount", FloatToString(parameter.Amount, 8))
+
+	var id int64
+	err = m.doRequest("POST", m.apiPath(fmt.Sprintf("/orders/%s/repay", parameter.BorrowId)), params, &id)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(id), nil
+}
+
+/**
+ * 借币订单
+ * optional: states(created,accrual,cleared,invalid), start-date, end-date, from, direct, size
+ */
+func (m *HuobiMargin) GetLoanHistory(pair CurrencyPair, currency Currency, optional ...OptionalParameter) ([]MarginLoan, error) {
+	params := url.Values{}
+	if currency.Symbol != "" {
+		params.Set("currency", strings.ToLower(currency.Symbol))
+	}
+	if !m.Cross {
+		params.Set("symbol", m.symbol(pair))
+	}
+	MergeOptionalParameter(&params, optional...)
+
+	var response []struct {
+		Id              int64   `json:"id"`
+		Currency        string  `json:"currency"`
+		LoanAmount      float64 `json:"loan-amount,string"`
+		LoanBalance     float64 `json:"loan-balance,string"`
+		InterestAmount  float64 `json:"interest-amount,string"`
+		InterestBalance float64 `json:"interest-balance,string"`
+		State           string  `json:"state"`
+		CreatedAt       int64   `json:"created-at"`
+	}
+	err := m.doRequest("GET", m.apiPath("/loan-orders"), params, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	loans := make([]MarginLoan, 0, len(response))
+	for _, r := range response {
+		loan := MarginLoan{
+			LoanId:      fmt.Sprint(r.Id),
+			Pair:        pair,
+			Currency:    NewCurrency(r.Currency, ""),
+			Amount:      r.LoanAmount,
+			Interest:    r.InterestAmount,
+			Repaid:      r.LoanAmount - r.LoanBalance,
+			Status:      r.State,
+			CreatedTime: r.CreatedAt,
+		}
+		if m.Cross {
+			loan.Pair = UNKNOWN_PAIR
+		}
+		loans = append(loans, loan)
+	}
+	return loans, nil
+}
+
+func (m *HuobiMargin) GetRiskRate(pair CurrencyPair) (float64, error) {
+	acc, err := m.GetMarginAccount(pair)
+	if err != nil {
+		return 0, err
+	}
+	return acc.RiskRate, nil
+}
+
+/**
+ * 杠杆下单, 不自动借币
+ * BUY_MARKET时ord.Price为买入金额
+ */
+func (m *HuobiMargin) PlaceOrder(ord *Order) (*Order, error) {
+	accountId, err := m.getAccountId(ord.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	amountPrecision, pricePrecision := 8, 8
+	if symbol, ok := m.Symbols[m.symbol(ord.Currency)]; ok {
+		amountPrecision, pricePrecision = int(symbol.AmountPrecision), int(symbol.PricePrecision)
+	}
+
+	params := url.Values{}
+	params.Set("account-id", accountId)
+	params.Set("symbol", m.symbol(ord.Currency))
+	params.Set("client-order-id", GenerateOrderClientId(32))
+	params.Set("source", "margin-api")
+	if m.Cross {
+		params.Set("source", "super-margin-api")
+	}
+
+	switch ord.Side {
+	case BUY, SELL:
+		orderType := strings.ToLower(ord.Side.String())
+		switch ord.OrderType {
+		case ORDER_FEATURE_POST_ONLY:
+			orderType += "-limit-maker"
+		case ORDER_FEATURE_IOC:
+			orderType += "-ioc"
+		case ORDER_FEATURE_FOK:
+			orderType += "-limit-fok"
+		default:
+			orderType += "-limit"
+		}
+		params.Set("type", orderType)
+		params.Set("price", FloatToString(ord.Price, pricePrecision))
+		params.Set("amount", FloatToString(ord.Amount, amountPrecision))
+	case BUY_MARKET:
+		params.Set("type", "buy-market")
+		params.Set("amount", FloatToString(ord.Price, pricePrecision))
+	case SELL_MARKET:
+		params.Set("type", "sell-market")
+		params.Set("amount", FloatToString(ord.Amount, amountPrecision))
+	default:
+		return nil, fmt.Errorf("unsupported order side: %s", ord.Side)
+	}
+
+	var orderId string
+	err = m.doRequest("POST", "/v1/order/orders/place", params, &orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	ord.OrderID = ToInt(orderId)
+	ord.OrderID2 = orderId
+	ord.Cid = params.Get("client-order-id")
+	ord.Status = ORDER_UNFINISH
+	return ord, nil
+}
@@ -0,0 +1,113 @@
+package huobi
+
+import (
+	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+//现货接口, 路由的key为"METHOD /path", 返回值为data字段, v1和v2接口共用
+var huobiAPI = testserver.Options{
+	Fixed: map[string]testserver.Route{
+		"/v1/common/timestamp": testserver.Reply(map[string]interface{}{"status": "ok", "data": 1603695163000}),
+		"/v1/common/symbols":   testserver.Reply(map[string]interface{}{"status": "ok", "data": []interface{}{}}),
+	},
+	Check: func(r *testserver.Request) interface{} {
+		query := r.URL.Query()
+		sign := query.Get("Signature")
+		if sign == "" {
+			return nil
+		}
+		query.Del("Signature")
+		expected, _ := goex.GetParamHmacSHA256Base64Sign("secret", r.Method+"\nhttp://"+r.Host+"\n"+r.URL.Path+"\n"+query.Encode())
+		if sign != expected {
+			return map[string]interface{}{"status": "error", "err-code": "api-signature-not-valid", "err-msg": "Signature not valid"}
+		}
+		return nil
+	},
+	Wrap: func(r *testserver.Request, data interface{}) interface{} {
+		return map[string]interface{}{"status": "ok", "code": 200, "data": data}
+	},
+	NotFound: func(r *testserver.Request) interface{} {
+		return map[string]interface{}{"status": "error", "err-code": "not-found", "err-msg": r.URL.Path}
+	},
+}
+
+func TestHuobiMargin_GetMarginAccount(t *testing.T) {
+	srv := testserver.New(huobiAPI, map[string]testserver.Route{
+		"GET /v1/margin/accounts/balance": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "btcusdt", params["symbol"])
+			assert.NotEmpty(t, params["Signature"])
+			return []map[string]interface{}{{"id": 5, "type": "margin", "symbol": "btcusdt", "state": "working",
+				"risk-rate": "1.52", "fl-price": "8500.1", "list": []map[string]string{
+					{"currency": "btc", "type": "trade", "balance": "0.5"},
+					{"currency": "btc", "type": "frozen", "balance": "0.1"},
+					{"currency": "usdt", "type": "trade", "balance": "1200"},
+					{"currency": "usdt", "type": "loan", "balance": "-1000"},
+					{"currency": "usdt", "type": "interest", "balance": "-0.5"},
+					{"currency": "usdt", "type": "transfer-out-available", "balance": "150"},
+				}}}
+		},
+	})
+	defer srv.Close()
+	margin := NewHuobiMargin(testserver.Config(srv))
+
+	acc, err := margin.GetMarginAccount(goex.BTC_USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, 1.52, acc.RiskRate)
+	assert.Equal(t, 8500.1, acc.LiquidationPrice)
+	assert.Equal(t, goex.MarginSubAccount{Balance: 0.6, Frozen: 0.1, Available: 0.5}, acc.Sub[goex.BTC])
+	assert.Equal(t, goex.MarginSubAccount{Balance: 1200, Available: 1200, CanWithdraw: 150, Loan: 1000, LendingFee: 0.5}, acc.Sub[goex.USDT])
+}
+
+func TestHuobiMargin_BorrowAndPlaceOrder(t *testing.T) {
+	var orderParams map[string]string
+	srv := testserver.New(huobiAPI, map[string]testserver.Route{
+		"GET /v1/account/accounts": func(r *testserver.Request) interface{} {
+			return []map[string]interface{}{
+				{"id": 1, "type": "spot", "subtype": "", "state": "working"},
+				{"id": 2, "type": "margin", "subtype": "ethusdt", "state": "working"},
+				{"id": 3, "type": "super-margin", "subtype": "", "state": "working"},
+			}
+		},
+		"POST /v1/cross-margin/orders": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "usdt", params["currency"])
+			assert.Equal(t, "100", params["amount"])
+			assert.Equal(t, "", params["symbol"])
+			return 1000
+		},
+		"POST /v1/order/orders/place": func(r *testserver.Request) interface{} {
+			orderParams = r.Params()
+			return "59378"
+		},
+	})
+	defer srv.Close()
+	margin := NewHuobiMargin(testserver.Config(srv))
+
+	margin.Cross = true
+	borrowId, err := margin.Borrow(goex.BorrowParameter{CurrencyPair: goex.ETH_USDT, Currency: goex.USDT, Amount: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, "1000", borrowId)
+
+	ord, err := margin.PlaceOrder(&goex.Order{Currency: goex.ETH_USDT, Side: goex.SELL, Price: 400, Amount: 1.5, OrderType: goex.ORDER_FEATURE_POST_ONLY})
+	assert.Nil(t, err)
+	assert.Equal(t, "59378", ord.OrderID2)
+	assert.Equal(t, "3", orderParams["account-id"])
+	assert.Equal(t, "super-margin-api", orderParams["source"])
+	assert.Equal(t, "sell-limit-maker", orderParams["type"])
+	assert.Equal(t, "1.5", orderParams["amount"])
+
+	margin.Cross = false
+	_, err = margin.PlaceOrder(&goex.Order{Currency: goex.BTC_USDT, Side: goex.BUY_MARKET, Price: 100})
+	assert.Error(t, err)
+	_, err = margin.PlaceOrder(&goex.Order{Currency: goex.ETH_USDT, Side: goex.BUY_MARKET, Price: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, "2", orderParams["account-id"])
+	assert.Equal(t, "margin-api", orderParams["source"])
+	assert.Equal(t, "buy-market", orderParams["type"])
+	assert.Equal(t, "100", orderParams["amount"])
+}
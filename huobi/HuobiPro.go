@@ -47,6 +47,7 @@ type HuoBiPro struct {
 	accessKey  string
 	secretKey  string
 	Symbols    map[string]HuoBiProSymbol
+	clock      *ClockSync
 	//ECDSAPrivateKey string
 }
 
@@ -71,6 +72,7 @@ func NewHuobiWithConfig(config *APIConfig) *HuoBiPro {
 	hbpro.httpClient = config.HttpClient
 	hbpro.accessKey = config.ApiKey
 	hbpro.secretKey = config.ApiSecretKey
+	hbpro.clock = SharedClockSync(hbpro.baseUrl+"/v1/common/timestamp", hbpro.GetServerTime)
 
 	if config.ApiKey != "" && config.ApiSecretKey != "" {
 		accinfo, err := hbpro.GetAccountInfo(HB_SPOT_ACCOUNT)
@@ -99,9 +101,27 @@ func NewHuoBiPro(client *http.Client, apikey, secretkey, accountId string) *HuoB
 	hbpro.accessKey = apikey
 	hbpro.secretKey = secretkey
 	hbpro.accountId = accountId
+	hbpro.clock = SharedClockSync(hbpro.baseUrl+"/v1/common/timestamp", hbpro.GetServerTime)
 	return hbpro
 }
 
+// GetServerTime 返回服务器毫秒时间戳
+func (hbpro *HuoBiPro) GetServerTime() (int64, error) {
+	respMap, err := HttpGet(hbpro.httpClient, hbpro.baseUrl+"/v1/common/timestamp")
+	if err != nil {
+		return 0, err
+	}
+	if respMap["status"] != "ok" {
+		return 0, errors.New(fmt.Sprint(respMap["err-msg"]))
+	}
+	return ToInt64(respMap["data"]), nil
+}
+
+// GetClockSync 服务器时间同步,可通过Offset()获取本地时钟偏差
+func (hbpro *HuoBiPro) GetClockSync() *ClockSync {
+	return hbpro.clock
+}
+
 /**
  *现货交易
  */
@@ -655,7 +675,7 @@ func (hbpro *HuoBiPro) buildPostForm(reqMethod, path string, postForm *url.Value
 	postForm.Set("AccessKeyId", hbpro.accessKey)
 	postForm.Set("SignatureMethod", "HmacSHA256")
 	postForm.Set("SignatureVersion", "2")
-	postForm.Set("Timestamp", time.Now().UTC().Format("2006-01-02T15:04:05"))
+	postForm.Set("Timestamp", hbpro.clock.Now().UTC().Format("2006-01-02T15:04:05"))
 	domain := strings.Replace(hbpro.baseUrl, "https://", "", len(hbpro.baseUrl))
 	payload := fmt.Sprintf("%s\n%s\n%s\n%s", reqMethod, domain, path, postForm.Encode())
 	sign, _ := GetParamHmacSHA256Base64Sign(hbpro.secretKey, payload)
@@ -673,6 +693,48 @@ func (hbpro *HuoBiPro) buildPostForm(reqMethod, path string, postForm *url.Value
 	return nil
 }
 
+//签名请求, 兼容v1(status)和v2(code)的返回格式
+func (hbpro *HuoBiPro) doRequest(method, path string, params url.Values, result interface{}) error {
+	hbpro.buildPostForm(method, path, &params)
+
+	var (
+		resp []byte
+		err  error
+	)
+	if method == "GET" {
+		resp, err = HttpGet5(hbpro.httpClient, hbpro.baseUrl+path+"?"+params.Encode(), map[string]string{})
+	} else {
+		resp, err = HttpPostForm3(hbpro.httpClient, hbpro.baseUrl+path+"?"+params.Encode(), hbpro.toJson(params),
+			map[string]string{"Content-Type": "application/json", "Accept-Language": "zh-cn"})
+	}
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Status  string          `json:"status"`
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		ErrCode string          `json:"err-code"`
+		ErrMsg  string          `json:"err-msg"`
+		Data    json.RawMessage `json:"data"`
+	}
+	err = json.Unmarshal(resp, &response)
+	if err != nil {
+		return err
+	}
+	if response.Code != 0 && response.Code != 200 { //v2接口
+		return fmt.Errorf("%d: %s", response.Code, response.Message)
+	}
+	if response.Code == 0 && response.Status != "ok" {
+		return fmt.Errorf("%s: %s", response.ErrCode, response.ErrMsg)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Data, result)
+}
+
 func (hbpro *HuoBiPro) toJson(params url.Values) string {
 	parammap := make(map[string]string)
 	for k, v := range params {
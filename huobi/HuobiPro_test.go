@@ -35,7 +35,8 @@ var hbpro *HuoBiPro
 
 func init() {
 	logger.Log.SetLevel(logger.DEBUG)
-	hbpro = NewHuoBiProSpot(httpProxyClient, apikey, secretkey)
+	//NewHuoBiProSpot初始化时需要请求账户信息, 离线运行的测试会panic
+	hbpro = NewHuoBiPro(httpProxyClient, apikey, secretkey, "")
 }
 
 func TestHuobiPro_GetTicker(t *testing.T) {
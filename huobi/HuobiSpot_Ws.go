@@ -15,7 +15,9 @@ import (
 type SpotWs struct {
 	*WsBuilder
 	sync.Once
-	wsConn *WsConn
+	connLock sync.Mutex //保护wsConn, Stats可能与连接并发调用
+	wsConn   *WsConn
+	connErr  error
 
 	tickerCallback func(*Ticker)
 	depthCallback  func(*Depth)
@@ -30,7 +32,8 @@ func NewSpotWs() *SpotWs {
 		WsUrl("wss://api.huobi.pro/ws").
 		AutoReconnect().
 		DecompressFunc(GzipDecompress).
-		ProtoHandleFunc(ws.handle)
+		ProtoHandleFunc(ws.handle).
+		TimestampFunc(wsTimestamp)
 	return ws
 }
 
@@ -45,14 +48,30 @@ func (ws *SpotWs) TradeCallback(call func(trade *Trade)) {
 	ws.tradeCallback = call
 }
 
-func (ws *SpotWs) connectWs() {
+func (ws *SpotWs) connectWs() error {
 	ws.Do(func() {
-		ws.wsConn = ws.WsBuilder.Build()
+		conn, err := ws.WsBuilder.Build()
+		ws.connLock.Lock()
+		ws.wsConn, ws.connErr = conn, err
+		ws.connLock.Unlock()
 	})
+	return ws.connErr
+}
+
+//连接的统计信息, 未连接时返回零值
+func (ws *SpotWs) Stats() WsStats {
+	ws.connLock.Lock()
+	defer ws.connLock.Unlock()
+	if ws.wsConn == nil {
+		return WsStats{}
+	}
+	return ws.wsConn.Stats()
 }
 
 func (ws *SpotWs) subscribe(sub map[string]interface{}) error {
-	ws.connectWs()
+	if err := ws.connectWs(); err != nil {
+		return err
+	}
 	return ws.wsConn.Subscribe(sub)
 }
 
@@ -0,0 +1,116 @@
+package huobi
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	. "github.com/lucas7788/goex"
+)
+
+//子账户列表, SubAccount为子用户uid
+func (w *Wallet) GetSubAccounts() ([]SubAccountUser, error) {
+	var response []struct {
+		Uid         json.Number `json:"uid"`
+		UserState   string      `json:"userState"`
+		SubUserName string      `json:"subUserName"`
+		Note        string      `json:"note"`
+	}
+	err := w.pro.doRequest("GET", "/v2/sub-user/user-list", url.Values{}, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]SubAccountUser, 0, len(response))
+	for _, u := range response {
+		users = append(users, SubAccountUser{
+			SubAccount: u.Uid.String(),
+			Uid:        u.Uid.String(),
+			Name:       u.SubUserName,
+			Label:      u.Note,
+			Enable:     u.UserState == "normal",
+		})
+	}
+	return users, nil
+}
+
+//子用户现货账户资产
+func (w *Wallet) GetSubAccountBalance(subAccount string) (*Account, error) {
+	var response []struct {
+		Type string `json:"type"`
+		List []struct {
+			Currency string `json:"currency"`
+			Type     string `json:"type"`
+			Balance  string `json:"balance"`
+		} `json:"list"`
+	}
+	err := w.pro.doRequest("GET", "/v1/account/accounts/"+subAccount, url.Values{}, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &Account{
+		Exchange:    HUOBI_PRO,
+		SubAccounts: make(map[Currency]SubAccount, 4),
+	}
+	for _, a := range response {
+		if a.Type != "spot" {
+			continue
+		}
+		for _, b := range a.List {
+			currency := NewCurrency(b.Currency, "")
+			sub := acc.SubAccounts[currency]
+			sub.Currency = currency
+			switch b.Type {
+			case "trade":
+				sub.Amount = ToFloat64(b.Balance)
+			case "frozen":
+				sub.ForzenAmount = ToFloat64(b.Balance)
+			}
+			acc.SubAccounts[currency] = sub
+		}
+	}
+	return acc, nil
+}
+
+//权限: readOnly, trade, 需要母用户的谷歌验证码
+func (w *Wallet) CreateSubAccountApiKey(param SubAccountApiKeyParameter) (*SubAccountApiKey, error) {
+	permission := "readOnly"
+	if param.CanTrade {
+		permission += ",trade"
+	}
+	params := url.Values{}
+	params.Set("otpToken", param.OtpToken)
+	params.Set("subUid", param.SubAccount)
+	params.Set("note", param.Label)
+	params.Set("permission", permission)
+	if len(param.IpWhitelist) > 0 {
+		params.Set("ipAddresses", strings.Join(param.IpWhitelist, ","))
+	}
+
+	var response struct {
+		Note        string `json:"note"`
+		AccessKey   string `json:"accessKey"`
+		SecretKey   string `json:"secretKey"`
+		Permission  string `json:"permission"`
+		IpAddresses string `json:"ipAddresses"`
+	}
+	err := w.pro.doRequest("POST", "/v2/sub-user/api-key-generation", params, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SubAccountApiKey{
+		SubAccount: param.SubAccount,
+		Label:      response.Note,
+		ApiKey:     response.AccessKey,
+		SecretKey:  response.SecretKey,
+	}
+	if response.Permission != "" {
+		key.Permissions = strings.Split(response.Permission, ",")
+	}
+	if response.IpAddresses != "" {
+		key.IpWhitelist = strings.Split(response.IpAddresses, ",")
+	}
+	return key, nil
+}
@@ -0,0 +1,72 @@
+package huobi
+
+import (
+	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWallet_SubAccount(t *testing.T) {
+	var form map[string]string
+	srv := testserver.New(huobiAPI, map[string]testserver.Route{
+		"GET /v2/sub-user/user-list": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "key", params["AccessKeyId"])
+			assert.NotEmpty(t, params["Signature"])
+			return []map[string]interface{}{
+				{"uid": 63628520, "userState": "normal", "subUserName": "sub1", "note": "market maker"},
+				{"uid": 132208121, "userState": "lock", "subUserName": "sub2", "note": ""},
+			}
+		},
+		"GET /v1/account/accounts/63628520": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.NotEmpty(t, params["Signature"])
+			return []map[string]interface{}{
+				{"id": 9910049, "type": "spot", "list": []map[string]string{
+					{"currency": "btc", "type": "trade", "balance": "1.5"},
+					{"currency": "btc", "type": "frozen", "balance": "0.5"},
+					{"currency": "usdt", "type": "trade", "balance": "100"},
+				}},
+				{"id": 9910050, "type": "margin", "list": []map[string]string{
+					{"currency": "eth", "type": "trade", "balance": "3"},
+				}},
+			}
+		},
+		"POST /v2/sub-user/api-key-generation": func(r *testserver.Request) interface{} {
+			form = r.Params()
+			return map[string]string{"note": "bot", "accessKey": "sub-key", "secretKey": "sub-secret",
+				"permission": "readOnly,trade", "ipAddresses": "1.1.1.1,2.2.2.2"}
+		},
+	})
+	defer srv.Close()
+	w := NewWallet(testserver.Config(srv))
+
+	users, err := w.GetSubAccounts()
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.SubAccountUser{
+		{SubAccount: "63628520", Uid: "63628520", Name: "sub1", Label: "market maker", Enable: true},
+		{SubAccount: "132208121", Uid: "132208121", Name: "sub2"},
+	}, users)
+
+	//只统计现货账户
+	acc, err := w.GetSubAccountBalance("63628520")
+	assert.Nil(t, err)
+	assert.Len(t, acc.SubAccounts, 2)
+	assert.Equal(t, goex.SubAccount{Currency: goex.BTC, Amount: 1.5, ForzenAmount: 0.5}, acc.SubAccounts[goex.BTC])
+	assert.Equal(t, 100.0, acc.SubAccounts[goex.USDT].Amount)
+
+	key, err := w.CreateSubAccountApiKey(goex.SubAccountApiKeyParameter{SubAccount: "63628520", Label: "bot", OtpToken: "123456",
+		CanTrade: true, IpWhitelist: []string{"1.1.1.1", "2.2.2.2"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "63628520", form["subUid"])
+	assert.Equal(t, "123456", form["otpToken"])
+	assert.Equal(t, "readOnly,trade", form["permission"])
+	assert.Equal(t, "1.1.1.1,2.2.2.2", form["ipAddresses"])
+	assert.Equal(t, &goex.SubAccountApiKey{SubAccount: "63628520", Label: "bot", ApiKey: "sub-key", SecretKey: "sub-secret",
+		Permissions: []string{"readOnly", "trade"}, IpWhitelist: []string{"1.1.1.1", "2.2.2.2"}}, key)
+
+	_, err = w.GetSubAccountBalance("1")
+	assert.NotNil(t, err)
+}
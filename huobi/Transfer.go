@@ -0,0 +1,153 @@
+package huobi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+
+	. "github.com/lucas7788/goex"
+)
+
+func (w *Wallet) GetExchangeName() string {
+	return HUOBI_PRO
+}
+
+/**
+ * 划转, 只支持现货账户与其他账户之间划转
+ * 杠杆: 指定Pair为逐仓, 否则为全仓
+ * U本位合约: 指定Pair为逐仓, 否则为全仓
+ * 母子账户: SubAccount为子账户uid, 只能与母账户现货账户划转
+ */
+func (w *Wallet) TransferAsset(req TransferRequest) (string, error) {
+	var (
+		path   string
+		params = url.Values{}
+		other  = req.To
+		in     = true //现货账户转出
+	)
+	if req.To == ACCOUNT_SPOT {
+		other, in = req.From, false
+	} else if req.From != ACCOUNT_SPOT {
+		return "", fmt.Errorf("unsupported transfer from %s to %s", req.From, req.To)
+	}
+	currency := strings.ToLower(req.Currency.Symbol)
+	params.Set("currency", currency)
+	params.Set("amount", FloatToString(req.Amount, 8))
+
+	switch other {
+	case ACCOUNT_SUB:
+		path = "/v1/subuser/transfer"
+		params.Set("sub-uid", req.SubAccount)
+		if in {
+			params.Set("type", "master-transfer-out")
+		} else {
+			params.Set("type", "master-transfer-in")
+		}
+	case ACCOUNT_FUTURES_COIN:
+		path = "/v1/futures/transfer"
+		if in {
+			params.Set("type", "pro-to-futures")
+		} else {
+			params.Set("type", "futures-to-pro")
+		}
+	case ACCOUNT_FUTURES_USDT:
+		path = "/v2/account/transfer"
+		if in {
+			params.Set("from", "spot")
+			params.Set("to", "linear-swap")
+		} else {
+			params.Set("from", "linear-swap")
+			params.Set("to", "spot")
+		}
+		if req.Isolated() {
+			params.Set("margin-account", req.Pair.AdaptUsdToUsdt().ToLower().ToSymbol("-"))
+		} else {
+			params.Set("margin-account", "USDT")
+		}
+	case ACCOUNT_MARGIN:
+		direction := "in"
+		if !in {
+			direction = "out"
+		}
+		if req.Isolated() {
+			path = "/v1/dw/transfer-" + direction + "/margin"
+			params.Set("symbol", req.Pair.AdaptUsdToUsdt().ToLower().ToSymbol(""))
+		} else {
+			path = "/v1/cross-margin/transfer-" + direction
+		}
+	default:
+		return "", fmt.Errorf("unsupported transfer from %s to %s", req.From, req.To)
+	}
+
+	var id json.Number
+	err := w.pro.doRequest("POST", path, params, &id)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+//财务流水中的划转类型 => [from, to]
+var ledgerTransferTypes = map[string][2]AccountKind{
+	"margin-transfer-in":        {ACCOUNT_SPOT, ACCOUNT_MARGIN},
+	"margin-transfer-out":       {ACCOUNT_MARGIN, ACCOUNT_SPOT},
+	"cross-margin-transfer-in":  {ACCOUNT_SPOT, ACCOUNT_MARGIN},
+	"cross-margin-transfer-out": {ACCOUNT_MARGIN, ACCOUNT_SPOT},
+	"pro-to-futures":            {ACCOUNT_SPOT, ACCOUNT_FUTURES_COIN},
+	"futures-to-pro":            {ACCOUNT_FUTURES_COIN, ACCOUNT_SPOT},
+	"master-transfer-out":       {ACCOUNT_SPOT, ACCOUNT_SUB},
+	"master-transfer-in":        {ACCOUNT_SUB, ACCOUNT_SPOT},
+}
+
+/**
+ * 划转记录, 查询现货账户的财务流水(/v2/account/ledger), 最近100条
+ * from或to为0时不过滤该方向, 母子账户划转的SubAccount为子账户的account-id
+ */
+func (w *Wallet) GetTransferHistory(currency Currency, from, to AccountKind, optional ...OptionalParameter) ([]TransferRecord, error) {
+	params := url.Values{}
+	params.Set("accountId", w.pro.accountId)
+	params.Set("currency", strings.ToLower(currency.Symbol))
+	params.Set("transactTypes", "transfer")
+	params.Set("limit", "100")
+	MergeOptionalParameter(&params, optional...)
+
+	var response []struct {
+		Currency     string      `json:"currency"`
+		TransactAmt  float64     `json:"transactAmt"`
+		TransferType string      `json:"transferType"`
+		TransactId   json.Number `json:"transactId"`
+		TransactTime int64       `json:"transactTime"`
+		Transferer   json.Number `json:"transferer"`
+		Transferee   json.Number `json:"transferee"`
+	}
+	err := w.pro.doRequest("GET", "/v2/account/ledger", params, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []TransferRecord
+	for _, r := range response {
+		kinds, ok := ledgerTransferTypes[r.TransferType]
+		if !ok || (from != 0 && kinds[0] != from) || (to != 0 && kinds[1] != to) {
+			continue
+		}
+		record := TransferRecord{
+			TransferId:  r.TransactId.String(),
+			Currency:    NewCurrency(r.Currency, ""),
+			Amount:      math.Abs(r.TransactAmt),
+			From:        kinds[0],
+			To:          kinds[1],
+			Status:      "completed", //流水只包含已完成的划转
+			CreatedTime: r.TransactTime,
+		}
+		if kinds[0] == ACCOUNT_SUB {
+			record.SubAccount = r.Transferer.String()
+		} else if kinds[1] == ACCOUNT_SUB {
+			record.SubAccount = r.Transferee.String()
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
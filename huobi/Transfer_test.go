@@ -0,0 +1,103 @@
+package huobi
+
+import (
+	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWallet_TransferAsset(t *testing.T) {
+	var (
+		path string
+		form map[string]string
+	)
+	route := func(p string) testserver.Route {
+		return func(r *testserver.Request) interface{} {
+			params := r.Params()
+			path, form = p, params
+			assert.NotEmpty(t, params["Signature"])
+			return 1001
+		}
+	}
+	srv := testserver.New(huobiAPI, map[string]testserver.Route{
+		"POST /v1/cross-margin/transfer-in": route("/v1/cross-margin/transfer-in"),
+		"POST /v1/dw/transfer-out/margin":   route("/v1/dw/transfer-out/margin"),
+		"POST /v2/account/transfer":         route("/v2/account/transfer"),
+		"POST /v1/futures/transfer":         route("/v1/futures/transfer"),
+		"POST /v1/subuser/transfer":         route("/v1/subuser/transfer"),
+		"GET /v2/account/ledger": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "100009", params["accountId"])
+			assert.Equal(t, "usdt", params["currency"])
+			assert.Equal(t, "transfer", params["transactTypes"])
+			return []map[string]interface{}{
+				{"accountId": 100009, "currency": "usdt", "transactAmt": 10, "transactType": "transfer", "transferType": "cross-margin-transfer-in",
+					"transactId": 2001, "transactTime": 1583127417000, "transferer": 100009, "transferee": 100010},
+				{"accountId": 100009, "currency": "usdt", "transactAmt": -5, "transactType": "transfer", "transferType": "master-transfer-out",
+					"transactId": 2002, "transactTime": 1583127418000, "transferer": 100009, "transferee": 200001},
+				{"accountId": 100009, "currency": "usdt", "transactAmt": 3, "transactType": "transfer", "transferType": "futures-to-pro",
+					"transactId": 2003, "transactTime": 1583127419000, "transferer": 100011, "transferee": 100009},
+			}
+		},
+	})
+	defer srv.Close()
+	hbpro := NewHuobiWithConfig(testserver.Config(srv))
+	hbpro.accountId = "100009"
+	w := &Wallet{pro: hbpro}
+
+	//未设置Pair和UNKNOWN_PAIR都是全仓
+	for _, pair := range []goex.CurrencyPair{{}, goex.UNKNOWN_PAIR} {
+		id, err := w.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 10, From: goex.ACCOUNT_SPOT, To: goex.ACCOUNT_MARGIN, Pair: pair})
+		assert.Nil(t, err)
+		assert.Equal(t, "1001", id)
+		assert.Equal(t, "/v1/cross-margin/transfer-in", path)
+		assert.Equal(t, "usdt", form["currency"])
+		assert.Equal(t, "10", form["amount"])
+		assert.Empty(t, form["symbol"])
+	}
+
+	_, err := w.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_MARGIN, To: goex.ACCOUNT_SPOT, Pair: goex.BTC_USDT})
+	assert.Nil(t, err)
+	assert.Equal(t, "/v1/dw/transfer-out/margin", path)
+	assert.Equal(t, "btcusdt", form["symbol"])
+
+	_, err = w.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_SPOT, To: goex.ACCOUNT_FUTURES_USDT})
+	assert.Nil(t, err)
+	assert.Equal(t, "/v2/account/transfer", path)
+	assert.Equal(t, "spot", form["from"])
+	assert.Equal(t, "linear-swap", form["to"])
+	assert.Equal(t, "USDT", form["margin-account"])
+
+	_, err = w.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_FUTURES_USDT, To: goex.ACCOUNT_SPOT, Pair: goex.BTC_USDT})
+	assert.Nil(t, err)
+	assert.Equal(t, "btc-usdt", form["margin-account"])
+
+	_, err = w.TransferAsset(goex.TransferRequest{Currency: goex.BTC, Amount: 1, From: goex.ACCOUNT_FUTURES_COIN, To: goex.ACCOUNT_SPOT})
+	assert.Nil(t, err)
+	assert.Equal(t, "/v1/futures/transfer", path)
+	assert.Equal(t, "futures-to-pro", form["type"])
+
+	_, err = w.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_SPOT, To: goex.ACCOUNT_SUB, SubAccount: "200001"})
+	assert.Nil(t, err)
+	assert.Equal(t, "/v1/subuser/transfer", path)
+	assert.Equal(t, "200001", form["sub-uid"])
+	assert.Equal(t, "master-transfer-out", form["type"])
+
+	_, err = w.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_MARGIN, To: goex.ACCOUNT_FUTURES_USDT})
+	assert.NotNil(t, err)
+
+	records, err := w.GetTransferHistory(goex.USDT, 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.TransferRecord{
+		{TransferId: "2001", Currency: goex.USDT, Amount: 10, From: goex.ACCOUNT_SPOT, To: goex.ACCOUNT_MARGIN, Status: "completed", CreatedTime: 1583127417000},
+		{TransferId: "2002", Currency: goex.USDT, Amount: 5, From: goex.ACCOUNT_SPOT, To: goex.ACCOUNT_SUB, SubAccount: "200001", Status: "completed", CreatedTime: 1583127418000},
+		{TransferId: "2003", Currency: goex.USDT, Amount: 3, From: goex.ACCOUNT_FUTURES_COIN, To: goex.ACCOUNT_SPOT, Status: "completed", CreatedTime: 1583127419000},
+	}, records)
+
+	records, err = w.GetTransferHistory(goex.USDT, goex.ACCOUNT_SPOT, goex.ACCOUNT_SUB)
+	assert.Nil(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "2002", records[0].TransferId)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/lucas7788/goex/internal/logger"
 	"net/url"
 	"strings"
+	"time"
 )
 
 type Wallet struct {
@@ -23,8 +24,26 @@ func (w *Wallet) GetAccount() (*Account, error) {
 	return nil, errors.New("not implement")
 }
 
+//提币到数字货币地址, Fee为必填, Chain为空时使用币种的默认链
 func (w *Wallet) Withdrawal(param WithdrawParameter) (withdrawId string, err error) {
-	return "", errors.New("not implement")
+	params := url.Values{}
+	params.Set("currency", strings.ToLower(param.Currency))
+	params.Set("address", param.ToAddress)
+	params.Set("amount", FloatToString(param.Amount, 8))
+	params.Set("fee", param.Fee)
+	if param.Chain != "" {
+		params.Set("chain", param.Chain)
+	}
+	if param.Memo != "" {
+		params.Set("addr-tag", param.Memo)
+	}
+
+	var id int64
+	err = w.pro.doRequest("POST", "/v1/dw/withdraw/api/create", params, &id)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(id), nil
 }
 
 func (w *Wallet) Transfer(param TransferParameter) error {
@@ -115,10 +134,165 @@ func (w *Wallet) Transfer(param TransferParameter) error {
 	return errors.New(string(responseBody))
 }
 
+//充提状态, 提币和充值的状态不重复
+var depositWithdrawStatus = map[string]WithdrawStatus{
+	"submitted":       WITHDRAW_PENDING,
+	"reexamine":       WITHDRAW_PENDING,
+	"pass":            WITHDRAW_PENDING,
+	"pre-transfer":    WITHDRAW_PENDING,
+	"wallet-transfer": WITHDRAW_SENT,
+	"confirmed":       WITHDRAW_COMPLETED,
+	"canceled":        WITHDRAW_CANCELLED,
+	"repealed":        WITHDRAW_CANCELLED,
+	"reject":          WITHDRAW_FAILED,
+	"wallet-reject":   WITHDRAW_FAILED,
+	"confirm-error":   WITHDRAW_FAILED,
+	"unknown":         WITHDRAW_PENDING,
+	"orphan":          WITHDRAW_PENDING,
+	"confirming":      WITHDRAW_SENT,
+	"safe":            WITHDRAW_COMPLETED,
+}
+
+type depositWithdrawResponse struct {
+	Id         int64   `json:"id"`
+	Currency   string  `json:"currency"`
+	Chain      string  `json:"chain"`
+	TxHash     string  `json:"tx-hash"`
+	Amount     float64 `json:"amount"`
+	Address    string  `json:"address"`
+	AddressTag string  `json:"address-tag"`
+	Fee        float64 `json:"fee"`
+	State      string  `json:"state"`
+	CreatedAt  int64   `json:"created-at"`
+}
+
+//最近100条记录
+func (w *Wallet) getDepositWithdrawHistory(typ string, currency *Currency) ([]DepositWithdrawHistory, error) {
+	params := url.Values{}
+	params.Set("type", typ)
+	params.Set("size", "100")
+	if currency != nil && *currency != UNKNOWN {
+		params.Set("currency", strings.ToLower(currency.Symbol))
+	}
+
+	var response []depositWithdrawResponse
+	err := w.pro.doRequest("GET", "/v1/query/deposit-withdraw", params, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]DepositWithdrawHistory, 0, len(response))
+	for _, r := range response {
+		history = append(history, DepositWithdrawHistory{
+			WithdrawalId: fmt.Sprint(r.Id),
+			Currency:     strings.ToUpper(r.Currency),
+			Txid:         r.TxHash,
+			Amount:       r.Amount,
+			To:           r.Address,
+			Memo:         r.AddressTag,
+			Fee:          FloatToString(r.Fee, 8),
+			Status:       depositWithdrawStatus[r.State],
+			Timestamp:    time.Unix(0, r.CreatedAt*int64(time.Millisecond)),
+		})
+	}
+	return history, nil
+}
+
 func (w *Wallet) GetWithDrawHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
-	return nil, errors.New("not implement")
+	return w.getDepositWithdrawHistory("withdraw", currency)
 }
 
 func (w *Wallet) GetDepositHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
-	return nil, errors.New("not implement")
+	return w.getDepositWithdrawHistory("deposit", currency)
+}
+
+//chain为链名称, 如trc20usdt
+func (w *Wallet) GetDepositAddress(currency Currency, chain string) ([]ChainAddress, error) {
+	params := url.Values{}
+	params.Set("currency", strings.ToLower(currency.Symbol))
+
+	var response []struct {
+		Currency   string `json:"currency"`
+		Address    string `json:"address"`
+		AddressTag string `json:"addressTag"`
+		Chain      string `json:"chain"`
+	}
+	err := w.pro.doRequest("GET", "/v2/account/deposit/address", params, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]ChainAddress, 0, len(response))
+	for _, r := range response {
+		if chain != "" && !strings.EqualFold(r.Chain, chain) {
+			continue
+		}
+		addresses = append(addresses, ChainAddress{
+			Currency: strings.ToUpper(r.Currency),
+			Chain:    r.Chain,
+			Address:  r.Address,
+			Tag:      r.AddressTag,
+		})
+	}
+	return addresses, nil
+}
+
+type referenceChain struct {
+	Chain                  string `json:"chain"`
+	DepositStatus          string `json:"depositStatus"`
+	WithdrawStatus         string `json:"withdrawStatus"`
+	MinWithdrawAmt         string `json:"minWithdrawAmt"`
+	WithdrawFeeType        string `json:"withdrawFeeType"` //fixed, circulated, ratio
+	TransactFeeWithdraw    string `json:"transactFeeWithdraw"`
+	MinTransactFeeWithdraw string `json:"minTransactFeeWithdraw"`
+	MaxTransactFeeWithdraw string `json:"maxTransactFeeWithdraw"`
+}
+
+//公共接口, 不需要签名
+func (w *Wallet) GetWithdrawChains(currency Currency) ([]WithdrawChain, error) {
+	respData, err := HttpGet5(w.pro.httpClient, w.pro.baseUrl+"/v2/reference/currencies?currency="+strings.ToLower(currency.Symbol), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    []struct {
+			Currency string           `json:"currency"`
+			Chains   []referenceChain `json:"chains"`
+		} `json:"data"`
+	}
+	err = json.Unmarshal(respData, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.Code != 200 {
+		return nil, fmt.Errorf("%d: %s", response.Code, response.Message)
+	}
+
+	var chains []WithdrawChain
+	for _, d := range response.Data {
+		for _, c := range d.Chains {
+			chain := WithdrawChain{
+				Currency:    strings.ToUpper(d.Currency),
+				Chain:       c.Chain,
+				CanDeposit:  c.DepositStatus == "allowed",
+				CanWithdraw: c.WithdrawStatus == "allowed",
+				MinFee:      ToFloat64(c.MinTransactFeeWithdraw),
+				MaxFee:      ToFloat64(c.MaxTransactFeeWithdraw),
+				MinAmount:   ToFloat64(c.MinWithdrawAmt),
+			}
+			if c.WithdrawFeeType == "fixed" {
+				chain.MinFee = ToFloat64(c.TransactFeeWithdraw)
+				chain.MaxFee = chain.MinFee
+			}
+			chains = append(chains, chain)
+		}
+	}
+	return chains, nil
+}
+
+func (w *Wallet) CancelWithdrawal(withdrawId string) error {
+	return w.pro.doRequest("POST", fmt.Sprintf("/v1/dw/withdraw-virtual/%s/cancel", withdrawId), url.Values{}, nil)
 }
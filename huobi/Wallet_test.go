@@ -2,17 +2,17 @@ package huobi
 
 import (
 	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 var wallet *Wallet
 
 func init() {
-	wallet = NewWallet(&goex.APIConfig{
-		HttpClient:   httpProxyClient,
-		ApiKey:       "",
-		ApiSecretKey: "",
-	})
+	//NewWallet初始化时需要请求交易对精度, 离线运行的测试会panic
+	wallet = &Wallet{pro: NewHuoBiPro(httpProxyClient, "", "", "")}
 }
 
 func TestWallet_Transfer(t *testing.T) {
@@ -23,3 +23,82 @@ func TestWallet_Transfer(t *testing.T) {
 		Amount:   11,
 	}))
 }
+
+func TestWallet_WithdrawChainsAndHistory(t *testing.T) {
+	var withdrawParams, cancelParams map[string]string
+	srv := testserver.New(huobiAPI, map[string]testserver.Route{
+		"GET /v2/account/deposit/address": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "usdt", params["currency"])
+			assert.NotEmpty(t, params["Signature"])
+			return []map[string]string{
+				{"currency": "usdt", "address": "0xabc", "addressTag": "", "chain": "usdterc20"},
+				{"currency": "usdt", "address": "TXyz", "addressTag": "", "chain": "trc20usdt"},
+			}
+		},
+		"GET /v2/reference/currencies": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "usdt", params["currency"])
+			return []map[string]interface{}{{"currency": "usdt", "chains": []map[string]string{
+				{"chain": "trc20usdt", "depositStatus": "allowed", "withdrawStatus": "allowed", "minWithdrawAmt": "2",
+					"withdrawFeeType": "fixed", "transactFeeWithdraw": "1"},
+				{"chain": "usdterc20", "depositStatus": "allowed", "withdrawStatus": "prohibited", "minWithdrawAmt": "10",
+					"withdrawFeeType": "circulated", "minTransactFeeWithdraw": "5", "maxTransactFeeWithdraw": "20"},
+			}}}
+		},
+		"GET /v1/query/deposit-withdraw": func(r *testserver.Request) interface{} {
+			params := r.Params()
+			assert.Equal(t, "withdraw", params["type"])
+			assert.Equal(t, "usdt", params["currency"])
+			return []map[string]interface{}{
+				{"id": 101, "currency": "usdt", "chain": "trc20usdt", "tx-hash": "tx1", "amount": 10, "address": "TXyz", "fee": 1, "state": "confirmed", "created-at": 1510912472199},
+				{"id": 102, "currency": "usdt", "amount": 20, "address": "TXyz", "fee": 1, "state": "wallet-transfer", "created-at": 1510912472199},
+				{"id": 103, "currency": "usdt", "amount": 30, "address": "TXyz", "fee": 1, "state": "wallet-reject", "created-at": 1510912472199},
+				{"id": 104, "currency": "usdt", "amount": 40, "address": "TXyz", "fee": 1, "state": "repealed", "created-at": 1510912472199},
+			}
+		},
+		"POST /v1/dw/withdraw/api/create": func(r *testserver.Request) interface{} {
+			withdrawParams = r.Params()
+			return 700
+		},
+		"POST /v1/dw/withdraw-virtual/700/cancel": func(r *testserver.Request) interface{} {
+			cancelParams = r.Params()
+			return 700
+		},
+	})
+	defer srv.Close()
+	w := NewWallet(testserver.Config(srv))
+
+	addresses, err := w.GetDepositAddress(goex.USDT, "TRC20USDT")
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.ChainAddress{{Currency: "USDT", Chain: "trc20usdt", Address: "TXyz"}}, addresses)
+
+	chains, err := w.GetWithdrawChains(goex.USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, goex.WithdrawChain{Currency: "USDT", Chain: "trc20usdt", CanDeposit: true, CanWithdraw: true,
+		MinFee: 1, MaxFee: 1, MinAmount: 2}, chains[0])
+	assert.Equal(t, goex.WithdrawChain{Currency: "USDT", Chain: "usdterc20", CanDeposit: true, CanWithdraw: false,
+		MinFee: 5, MaxFee: 20, MinAmount: 10}, chains[1])
+
+	history, err := w.GetWithDrawHistory(&goex.USDT)
+	assert.Nil(t, err)
+	assert.Len(t, history, 4)
+	assert.Equal(t, "101", history[0].WithdrawalId)
+	assert.Equal(t, "USDT", history[0].Currency)
+	assert.Equal(t, goex.WITHDRAW_COMPLETED, history[0].Status)
+	assert.Equal(t, goex.WITHDRAW_SENT, history[1].Status)
+	assert.Equal(t, goex.WITHDRAW_FAILED, history[2].Status)
+	assert.Equal(t, goex.WITHDRAW_CANCELLED, history[3].Status)
+	assert.Equal(t, time.Unix(0, 1510912472199*int64(time.Millisecond)), history[0].Timestamp)
+
+	id, err := w.Withdrawal(goex.WithdrawParameter{Currency: "USDT", ToAddress: "TXyz", Amount: 12.5, Fee: "1", Chain: "trc20usdt"})
+	assert.Nil(t, err)
+	assert.Equal(t, "700", id)
+	assert.Equal(t, "usdt", withdrawParams["currency"])
+	assert.Equal(t, "12.5", withdrawParams["amount"])
+	assert.Equal(t, "trc20usdt", withdrawParams["chain"])
+	assert.NotEmpty(t, withdrawParams["Signature"])
+
+	assert.Nil(t, w.CancelWithdrawal("700"))
+	assert.NotEmpty(t, cancelParams["Signature"])
+}
@@ -0,0 +1,171 @@
+package testserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/lucas7788/goex"
+)
+
+//测试服务器收到的请求, Data为请求体
+type Request struct {
+	*http.Request
+	Data []byte
+}
+
+//请求体解析为json对象
+func (r *Request) JSON() map[string]interface{} {
+	var body map[string]interface{}
+	json.Unmarshal(r.Data, &body)
+	return body
+}
+
+//query, 表单和json请求体的顶层字段合并后的参数
+func (r *Request) Params() map[string]string {
+	params := map[string]string{}
+	var body map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(r.Data))
+	decoder.UseNumber()
+	decoder.Decode(&body)
+	for k, v := range body {
+		if s, ok := v.(string); ok {
+			params[k] = s
+		} else if v != nil {
+			params[k] = fmt.Sprint(v)
+		}
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		form, _ := url.ParseQuery(string(r.Data))
+		for k := range form {
+			params[k] = form.Get(k)
+		}
+	}
+	for k := range r.URL.Query() {
+		params[k] = r.URL.Query().Get(k)
+	}
+	return params
+}
+
+//处理一个请求
+type Route func(r *Request) interface{}
+
+//指定状态码的响应, 不经过Options.Wrap
+type Response struct {
+	Status int
+	Body   interface{}
+}
+
+//固定返回resp的路由
+func Reply(resp interface{}) Route {
+	return func(r *Request) interface{} {
+		return resp
+	}
+}
+
+/**
+ * 交易所接口的公共部分
+ * Fixed 按path匹配, 优先于路由, 不经过Check和Wrap, 用于服务器时间等公共接口
+ * Check 返回值不为nil时直接作为响应, 用于校验签名
+ * Wrap 包装路由的返回值, 如{"code":0,"data":result}
+ * NotFound 没有匹配的路由时的响应, 为nil时返回404
+ * Key 生成路由的key, 默认为"METHOD /path"
+ */
+type Options struct {
+	Fixed    map[string]Route
+	Check    Route
+	Wrap     func(r *Request, result interface{}) interface{}
+	NotFound Route
+	Key      func(r *Request) string
+}
+
+/**
+ * 各交易所适配器的测试共用的http测试服务器
+ * 路由的返回值: string和[]byte原样输出, Response指定状态码, 其他类型序列化为json
+ */
+func New(opts Options, routes map[string]Route) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		req := &Request{Request: r, Data: data}
+		reset := func() {
+			r.Body = ioutil.NopCloser(bytes.NewReader(data))
+		}
+		reset()
+
+		if route, ok := opts.Fixed[r.URL.Path]; ok {
+			write(w, route(req))
+			return
+		}
+		if opts.Check != nil {
+			resp := opts.Check(req)
+			reset()
+			if resp != nil {
+				write(w, resp)
+				return
+			}
+		}
+
+		key := r.Method + " " + r.URL.Path
+		if opts.Key != nil {
+			key = opts.Key(req)
+			reset()
+		}
+		route, ok := routes[key]
+		if !ok {
+			if opts.NotFound == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			write(w, opts.NotFound(req))
+			return
+		}
+
+		result := route(req)
+		if _, ok := result.(Response); !ok && opts.Wrap != nil {
+			result = opts.Wrap(req, result)
+		}
+		write(w, result)
+	}))
+}
+
+func write(w http.ResponseWriter, resp interface{}) {
+	if r, ok := resp.(Response); ok {
+		w.WriteHeader(r.Status)
+		resp = r.Body
+	}
+	switch v := resp.(type) {
+	case nil:
+	case string:
+		w.Write([]byte(v))
+	case []byte:
+		w.Write(v)
+	default:
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+//请求srv的接口配置, 签名使用key, secret和pass
+func Config(srv *httptest.Server) *goex.APIConfig {
+	return &goex.APIConfig{HttpClient: srv.Client(), Endpoint: srv.URL, ApiKey: "key", ApiSecretKey: "secret", ApiPassphrase: "pass"}
+}
+
+//请求地址是常量时, 通过Transport把请求转发到测试服务器
+type RedirectTransport struct {
+	Host string
+}
+
+func (t RedirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = "http"
+	req.URL.Host = t.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+//所有请求都转发到srv的http client
+func RedirectClient(srv *httptest.Server) *http.Client {
+	return &http.Client{Transport: RedirectTransport{Host: srv.Listener.Addr().String()}}
+}
@@ -0,0 +1,84 @@
+package testserver
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func do(t *testing.T, client *http.Client, method, url, contentType, body string) (int, string) {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := client.Do(req)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	data, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, strings.TrimSpace(string(data))
+}
+
+func TestNew(t *testing.T) {
+	srv := New(Options{
+		Fixed: map[string]Route{
+			"/time": Reply(`1600000000000`),
+		},
+		Check: func(r *Request) interface{} {
+			if r.Header.Get("Sign") != "ok" {
+				return Response{Status: http.StatusUnauthorized, Body: "invalid sign"}
+			}
+			return nil
+		},
+		Wrap: func(r *Request, result interface{}) interface{} {
+			return map[string]interface{}{"code": 0, "data": result}
+		},
+	}, map[string]Route{
+		"POST /order": func(r *Request) interface{} {
+			data, _ := ioutil.ReadAll(r.Body)
+			assert.Equal(t, r.Data, data)
+			return r.Params()
+		},
+		"DELETE /order": Reply(Response{Status: http.StatusBadRequest, Body: `{"error":"not found"}`}),
+	})
+	defer srv.Close()
+
+	//Fixed不经过Check和Wrap
+	code, body := do(t, srv.Client(), http.MethodGet, srv.URL+"/time", "", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "1600000000000", body)
+
+	code, body = do(t, srv.Client(), http.MethodPost, srv.URL+"/order", "", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, "invalid sign", body)
+
+	client := &http.Client{Transport: signTransport{}}
+	code, body = do(t, client, http.MethodPost, srv.URL+"/order?id=1", "application/json", `{"amount":1.5,"side":"buy"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `{"code":0,"data":{"amount":"1.5","id":"1","side":"buy"}}`, body)
+
+	code, body = do(t, client, http.MethodPost, srv.URL+"/order", "application/x-www-form-urlencoded", "id=2&side=sell")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `{"code":0,"data":{"id":"2","side":"sell"}}`, body)
+
+	code, body = do(t, client, http.MethodDelete, srv.URL+"/order", "", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, `{"error":"not found"}`, body)
+
+	code, _ = do(t, client, http.MethodGet, srv.URL+"/order", "", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	//请求地址是常量时转发到测试服务器
+	code, body = do(t, RedirectClient(srv), http.MethodGet, "https://api.example.com/time", "", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "1600000000000", body)
+}
+
+type signTransport struct{}
+
+func (signTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Sign", "ok")
+	return http.DefaultTransport.RoundTrip(req)
+}
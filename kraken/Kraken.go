@@ -13,7 +13,6 @@ import (
 	"net/url"
 	"sort"
 	"strings"
-	"time"
 )
 
 type BaseResponse struct {
@@ -30,6 +29,7 @@ type Kraken struct {
 	httpClient *http.Client
 	accessKey,
 	secretKey string
+	clock *ClockSync
 }
 
 var (
@@ -41,7 +41,21 @@ var (
 )
 
 func New(client *http.Client, accesskey, secretkey string) *Kraken {
-	return &Kraken{client, accesskey, secretkey}
+	k := &Kraken{httpClient: client, accessKey: accesskey, secretKey: secretkey}
+	k.clock = SharedClockSync(BASE_URL+API_V0+PUBLIC+"Time", k.GetServerTime)
+	return k
+}
+
+//服务器时间只精确到秒, 返回毫秒时间戳
+func (k *Kraken) GetServerTime() (int64, error) {
+	var result struct {
+		UnixTime int64 `json:"unixtime"`
+	}
+	err := k.doAuthenticatedRequest("GET", PUBLIC+"Time", url.Values{}, &result)
+	if err != nil {
+		return 0, err
+	}
+	return result.UnixTime * 1000, nil
 }
 
 func (k *Kraken) placeOrder(orderType, side, amount, price string, pair CurrencyPair) (*Order, error) {
@@ -270,13 +284,153 @@ func (k *Kraken) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
 	return &dep, nil
 }
 
+var krakenKlinePeriods = map[KlinePeriod]int{
+	KLINE_PERIOD_1MIN:  1,
+	KLINE_PERIOD_5MIN:  5,
+	KLINE_PERIOD_15MIN: 15,
+	KLINE_PERIOD_30MIN: 30,
+	KLINE_PERIOD_60MIN: 60,
+	KLINE_PERIOD_1H:    60,
+	KLINE_PERIOD_4H:    240,
+	KLINE_PERIOD_1DAY:  1440,
+	KLINE_PERIOD_1WEEK: 10080,
+}
+
+//optional: since 返回该时间(秒)之后的k线
 func (k *Kraken) GetKlineRecords(currency CurrencyPair, period KlinePeriod, size int, opt ...OptionalParameter) ([]Kline, error) {
-	panic("")
+	var since int64
+	if len(opt) > 0 {
+		since = opt[0].GetInt64("since")
+	}
+	klines, _, err := k.GetOHLC(currency, period, since)
+	if err != nil {
+		return nil, err
+	}
+	if size > 0 && len(klines) > size {
+		klines = klines[len(klines)-size:]
+	}
+	return klines, nil
 }
 
-//非个人，整个交易所的交易记录
+/**
+ * OHLC数据, 最多返回720条
+ * since为0时返回最新数据, 返回的last可作为下一次请求的since
+ */
+func (k *Kraken) GetOHLC(currency CurrencyPair, period KlinePeriod, since int64) ([]Kline, int64, error) {
+	interval, ok := krakenKlinePeriods[period]
+	if !ok {
+		return nil, 0, errors.New("unsupport the kline period")
+	}
+
+	params := url.Values{}
+	params.Set("pair", k.convertPair(currency).ToSymbol(""))
+	params.Set("interval", fmt.Sprint(interval))
+	if since > 0 {
+		params.Set("since", fmt.Sprint(since))
+	}
+
+	var resultmap map[string]json.RawMessage
+	err := k.doAuthenticatedRequest("GET", "public/OHLC?"+params.Encode(), url.Values{}, &resultmap)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		klines []Kline
+		last   int64
+	)
+	for key, v := range resultmap {
+		if key == "last" {
+			json.Unmarshal(v, &last)
+			continue
+		}
+		var items [][]interface{}
+		if err = json.Unmarshal(v, &items); err != nil {
+			return nil, 0, err
+		}
+		//[time, open, high, low, close, vwap, volume, count]
+		for _, itm := range items {
+			klines = append(klines, Kline{
+				Pair:      currency,
+				Timestamp: ToInt64(itm[0]),
+				Open:      ToFloat64(itm[1]),
+				High:      ToFloat64(itm[2]),
+				Low:       ToFloat64(itm[3]),
+				Close:     ToFloat64(itm[4]),
+				Vol:       ToFloat64(itm[6]),
+			})
+		}
+	}
+
+	return klines, last, nil
+}
+
+//非个人，整个交易所的交易记录, since为纳秒时间戳游标, 0时返回最新的成交
 func (k *Kraken) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
-	panic("")
+	cursor := ""
+	if since > 0 {
+		cursor = fmt.Sprint(since)
+	}
+	trades, _, err := k.GetTradesSince(currencyPair, cursor)
+	return trades, err
+}
+
+/**
+ * 成交记录, 最多返回1000条
+ * 返回的last可作为下一次请求的since, 用于分页拉取
+ */
+func (k *Kraken) GetTradesSince(currencyPair CurrencyPair, since string) ([]Trade, string, error) {
+	params := url.Values{}
+	params.Set("pair", k.convertPair(currencyPair).ToSymbol(""))
+	if since != "" {
+		params.Set("since", since)
+	}
+
+	var resultmap map[string]json.RawMessage
+	err := k.doAuthenticatedRequest("GET", "public/Trades?"+params.Encode(), url.Values{}, &resultmap)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var (
+		trades []Trade
+		last   string
+	)
+	for key, v := range resultmap {
+		if key == "last" {
+			json.Unmarshal(v, &last)
+			continue
+		}
+		var items [][]interface{}
+		if err = json.Unmarshal(v, &items); err != nil {
+			return nil, "", err
+		}
+		//[price, volume, time, buy/sell, market/limit, miscellaneous, trade_id]
+		for _, itm := range items {
+			trades = append(trades, k.adaptTrade(itm, currencyPair))
+		}
+	}
+
+	return trades, last, nil
+}
+
+func (k *Kraken) adaptTrade(itm []interface{}, pair CurrencyPair) Trade {
+	ts := ToFloat64(itm[2])
+	trade := Trade{
+		Tid:    int64(ts * 1e9),
+		Type:   SELL,
+		Amount: ToFloat64(itm[1]),
+		Price:  ToFloat64(itm[0]),
+		Date:   int64(ts * 1000),
+		Pair:   pair,
+	}
+	if itm[3] == "b" {
+		trade.Type = BUY
+	}
+	if len(itm) > 6 {
+		trade.Tid = ToInt64(itm[6])
+	}
+	return trade
 }
 
 func (k *Kraken) GetExchangeName() string {
@@ -284,7 +438,7 @@ func (k *Kraken) GetExchangeName() string {
 }
 
 func (k *Kraken) buildParamsSigned(apiuri string, postForm *url.Values) string {
-	postForm.Set("nonce", fmt.Sprintf("%d", time.Now().UnixNano()))
+	postForm.Set("nonce", fmt.Sprintf("%d", k.clock.Now().UnixNano()))
 	urlPath := API_V0 + apiuri
 
 	secretByte, _ := base64.StdEncoding.DecodeString(k.secretKey)
@@ -371,3 +525,16 @@ func (k *Kraken) convertOrderStatus(status string) TradeStatus {
 	}
 	return ORDER_UNFINISH
 }
+
+//websocket私有频道订阅使用的token, 15分钟内需使用
+func (k *Kraken) GetWebSocketsToken() (string, error) {
+	var result struct {
+		Token   string `json:"token"`
+		Expires int    `json:"expires"`
+	}
+	err := k.doAuthenticatedRequest("POST", "private/GetWebSocketsToken", url.Values{}, &result)
+	if err != nil {
+		return "", err
+	}
+	return result.Token, nil
+}
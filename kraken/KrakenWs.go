@@ -0,0 +1,539 @@
+package kraken
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/logger"
+)
+
+const (
+	wsPublicUrl  = "wss://ws.kraken.com"
+	wsPrivateUrl = "wss://ws-auth.kraken.com"
+)
+
+type wsSubscription struct {
+	Name  string `json:"name"`
+	Depth int    `json:"depth,omitempty"`
+	Token string `json:"token,omitempty"`
+}
+
+type wsRequest struct {
+	Event        string         `json:"event"`
+	Pair         []string       `json:"pair,omitempty"`
+	Subscription wsSubscription `json:"subscription"`
+}
+
+type wsEvent struct {
+	Event        string `json:"event"`
+	Status       string `json:"status"`
+	ErrorMessage string `json:"errorMessage"`
+	ChannelName  string `json:"channelName"`
+	Pair         string `json:"pair"`
+}
+
+//本地订单簿, 价格和数量保留原始字符串用于计算checksum
+type krakenBook struct {
+	asks map[string]string
+	bids map[string]string
+}
+
+type krakenBookLevel struct {
+	price, volume string
+}
+
+func (b *krakenBook) update(book map[string]string, levels [][]interface{}) {
+	for _, lv := range levels {
+		price, volume := fmt.Sprint(lv[0]), fmt.Sprint(lv[1])
+		if ToFloat64(volume) == 0 {
+			delete(book, price)
+			continue
+		}
+		book[price] = volume
+	}
+}
+
+//asks按价格升序, bids按价格降序, 超过depth的档位会被删除
+func (b *krakenBook) sorted(depth int) (asks, bids []krakenBookLevel) {
+	asks = sortBookLevels(b.asks, false)
+	bids = sortBookLevels(b.bids, true)
+	if len(asks) > depth {
+		for _, lv := range asks[depth:] {
+			delete(b.asks, lv.price)
+		}
+		asks = asks[:depth]
+	}
+	if len(bids) > depth {
+		for _, lv := range bids[depth:] {
+			delete(b.bids, lv.price)
+		}
+		bids = bids[:depth]
+	}
+	return asks, bids
+}
+
+func sortBookLevels(book map[string]string, desc bool) []krakenBookLevel {
+	levels := make([]krakenBookLevel, 0, len(book))
+	for p, v := range book {
+		levels = append(levels, krakenBookLevel{p, v})
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if desc {
+			return ToFloat64(levels[i].price) > ToFloat64(levels[j].price)
+		}
+		return ToFloat64(levels[i].price) < ToFloat64(levels[j].price)
+	})
+	return levels
+}
+
+//crc32(前10档asks + 前10档bids), 价格和数量去掉小数点及前导0
+func bookChecksum(asks, bids []krakenBookLevel) uint32 {
+	var buf strings.Builder
+	format := func(s string) string {
+		return strings.TrimLeft(strings.Replace(s, ".", "", 1), "0")
+	}
+	for _, levels := range [][]krakenBookLevel{asks, bids} {
+		for i, lv := range levels {
+			if i == 10 {
+				break
+			}
+			buf.WriteString(format(lv.price))
+			buf.WriteString(format(lv.volume))
+		}
+	}
+	return crc32.ChecksumIEEE([]byte(buf.String()))
+}
+
+/**
+ * kraken websocket, 实现SpotWsApi
+ * 公共频道: book(本地维护订单簿并校验checksum), ticker, trade
+ * 私有频道: openOrders, ownTrades, 需通过rest接口获取token
+ */
+type KrakenWs struct {
+	k *Kraken
+
+	pubOnce, priOnce sync.Once
+	pubWs, priWs     *WsConn
+	pubErr, priErr   error
+
+	DepthSize int //10, 25, 100, 500, 1000
+
+	lock       sync.Mutex
+	books      map[string]*krakenBook
+	privateSub map[string]bool
+
+	depthCallback    func(depth *Depth)
+	tickerCallback   func(ticker *Ticker)
+	tradeCallback    func(trade *Trade)
+	orderCallback    func(order *Order)
+	ownTradeCallback func(trade *Trade, orderId string)
+}
+
+//k为nil时只能订阅公共频道
+func NewKrakenWs(k *Kraken) *KrakenWs {
+	return &KrakenWs{
+		k:          k,
+		DepthSize:  10,
+		books:      make(map[string]*krakenBook, 2),
+		privateSub: make(map[string]bool, 2),
+	}
+}
+
+func (ws *KrakenWs) DepthCallback(call func(depth *Depth)) {
+	ws.depthCallback = call
+}
+
+func (ws *KrakenWs) TickerCallback(call func(ticker *Ticker)) {
+	ws.tickerCallback = call
+}
+
+func (ws *KrakenWs) TradeCallback(call func(trade *Trade)) {
+	ws.tradeCallback = call
+}
+
+func (ws *KrakenWs) OrderCallback(call func(order *Order)) {
+	ws.orderCallback = call
+}
+
+func (ws *KrakenWs) OwnTradeCallback(call func(trade *Trade, orderId string)) {
+	ws.ownTradeCallback = call
+}
+
+func (ws *KrakenWs) connectPublic() error {
+	ws.pubOnce.Do(func() {
+		ws.pubWs, ws.pubErr = NewWsBuilder().
+			WsUrl(wsPublicUrl).
+			AutoReconnect().
+			Heartbeat(func() []byte { return []byte(`{"event":"ping"}`) }, 30*time.Second).
+			ProtoHandleFunc(ws.handle).
+			EventHandleFunc(func(event WsEvent) {
+				if event.Type == WsEventConnected && event.Attempt > 0 {
+					ws.lock.Lock()
+					ws.books = make(map[string]*krakenBook, 2) //重连后会重新推送快照
+					ws.lock.Unlock()
+				}
+			}).
+			Build()
+	})
+	return ws.pubErr
+}
+
+func (ws *KrakenWs) connectPrivate() error {
+	ws.priOnce.Do(func() {
+		ws.priWs, ws.priErr = NewWsBuilder().
+			WsUrl(wsPrivateUrl).
+			AutoReconnect().
+			Heartbeat(func() []byte { return []byte(`{"event":"ping"}`) }, 30*time.Second).
+			ProtoHandleFunc(ws.handle).
+			EventHandleFunc(func(event WsEvent) {
+				//token只能使用一次, 重连后重新获取token再订阅
+				if event.Type == WsEventConnected && event.Attempt > 0 {
+					go ws.resubscribePrivate()
+				}
+			}).
+			Build()
+	})
+	return ws.priErr
+}
+
+func (ws *KrakenWs) wsPair(pair CurrencyPair) string {
+	return ws.k.convertPair(pair).ToUpper().ToSymbol("/")
+}
+
+func (ws *KrakenWs) subscribe(name string, depth int, pair CurrencyPair) error {
+	if err := ws.connectPublic(); err != nil {
+		return err
+	}
+	return ws.pubWs.Subscribe(wsRequest{
+		Event:        "subscribe",
+		Pair:         []string{ws.wsPair(pair)},
+		Subscription: wsSubscription{Name: name, Depth: depth},
+	})
+}
+
+func (ws *KrakenWs) SubscribeDepth(pair CurrencyPair) error {
+	return ws.subscribe("book", ws.DepthSize, pair)
+}
+
+func (ws *KrakenWs) SubscribeTicker(pair CurrencyPair) error {
+	return ws.subscribe("ticker", 0, pair)
+}
+
+func (ws *KrakenWs) SubscribeTrade(pair CurrencyPair) error {
+	return ws.subscribe("trade", 0, pair)
+}
+
+func (ws *KrakenWs) subscribePrivate(name string) error {
+	if ws.k == nil {
+		return errors.New("private channel need api key")
+	}
+	if err := ws.connectPrivate(); err != nil {
+		return err
+	}
+	token, err := ws.k.GetWebSocketsToken()
+	if err != nil {
+		return err
+	}
+
+	ws.lock.Lock()
+	ws.privateSub[name] = true
+	ws.lock.Unlock()
+
+	return ws.priWs.SendJsonMessage(wsRequest{Event: "subscribe", Subscription: wsSubscription{Name: name, Token: token}})
+}
+
+func (ws *KrakenWs) resubscribePrivate() {
+	ws.lock.Lock()
+	var names []string
+	for name := range ws.privateSub {
+		names = append(names, name)
+	}
+	ws.lock.Unlock()
+
+	for _, name := range names {
+		if err := ws.subscribePrivate(name); err != nil {
+			logger.Errorf("[kraken ws] resubscribe %s error: %s", name, err)
+		}
+	}
+}
+
+//订阅委托更新, 首次推送全部未成交委托
+func (ws *KrakenWs) SubscribeOrder() error {
+	return ws.subscribePrivate("openOrders")
+}
+
+//订阅个人成交, 首次推送最近50条
+func (ws *KrakenWs) SubscribeOwnTrade() error {
+	return ws.subscribePrivate("ownTrades")
+}
+
+func (ws *KrakenWs) Close() {
+	if ws.pubWs != nil {
+		ws.pubWs.CloseWs()
+	}
+	if ws.priWs != nil {
+		ws.priWs.CloseWs()
+	}
+}
+
+func (ws *KrakenWs) handle(data []byte) error {
+	if len(data) > 0 && data[0] == '{' {
+		var event wsEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return err
+		}
+		if event.Event == "subscriptionStatus" && event.Status == "error" {
+			logger.Errorf("[kraken ws] subscribe %s %s error: %s", event.Pair, event.ChannelName, event.ErrorMessage)
+			return errors.New(event.ErrorMessage)
+		}
+		return nil
+	}
+
+	var msg []json.RawMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		logger.Errorf("[kraken ws] json unmarshal error [%s], data = %s", err, string(data))
+		return err
+	}
+	if len(msg) < 3 {
+		return nil
+	}
+
+	//私有频道: [data, channelName, {"sequence":n}]
+	var channelName string
+	if json.Unmarshal(msg[1], &channelName) == nil {
+		switch channelName {
+		case "openOrders":
+			return ws.handleOpenOrders(msg[0])
+		case "ownTrades":
+			return ws.handleOwnTrades(msg[0])
+		}
+		return nil
+	}
+
+	//公共频道: [channelID, data..., channelName, pair]
+	var pairName string
+	json.Unmarshal(msg[len(msg)-2], &channelName)
+	json.Unmarshal(msg[len(msg)-1], &pairName)
+	pair := adaptWsPair(pairName)
+	payloads := msg[1 : len(msg)-2]
+
+	switch {
+	case strings.HasPrefix(channelName, "book-"):
+		return ws.handleBook(pairName, pair, payloads)
+	case channelName == "ticker":
+		return ws.handleTicker(pair, payloads[0])
+	case channelName == "trade":
+		return ws.handleTrade(pair, payloads[0])
+	}
+	return nil
+}
+
+func (ws *KrakenWs) handleBook(pairName string, pair CurrencyPair, payloads []json.RawMessage) error {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	var checksum string
+	for _, payload := range payloads {
+		var update struct {
+			As [][]interface{} `json:"as"`
+			Bs [][]interface{} `json:"bs"`
+			A  [][]interface{} `json:"a"`
+			B  [][]interface{} `json:"b"`
+			C  string          `json:"c"`
+		}
+		if err := json.Unmarshal(payload, &update); err != nil {
+			return err
+		}
+
+		book, exist := ws.books[pairName]
+		if update.As != nil || update.Bs != nil { //快照
+			book = &krakenBook{asks: make(map[string]string, ws.DepthSize), bids: make(map[string]string, ws.DepthSize)}
+			ws.books[pairName] = book
+			exist = true
+		}
+		if !exist { //没有快照的增量无法使用
+			return nil
+		}
+
+		book.update(book.asks, update.As)
+		book.update(book.bids, update.Bs)
+		book.update(book.asks, update.A)
+		book.update(book.bids, update.B)
+		if update.C != "" {
+			checksum = update.C
+		}
+	}
+
+	book := ws.books[pairName]
+	asks, bids := book.sorted(ws.DepthSize)
+	if checksum != "" {
+		if cs := bookChecksum(asks, bids); fmt.Sprint(cs) != checksum {
+			delete(ws.books, pairName)
+			go ws.resubscribeBook(pair)
+			return fmt.Errorf("book checksum mismatch: %s, local=%d, remote=%s", pairName, cs, checksum)
+		}
+	}
+
+	dep := &Depth{Pair: pair, UTime: time.Now()}
+	for i := len(asks) - 1; i >= 0; i-- {
+		dep.AskList = append(dep.AskList, DepthRecord{Price: ToFloat64(asks[i].price), Amount: ToFloat64(asks[i].volume)})
+	}
+	for _, lv := range bids {
+		dep.BidList = append(dep.BidList, DepthRecord{Price: ToFloat64(lv.price), Amount: ToFloat64(lv.volume)})
+	}
+	if ws.depthCallback != nil {
+		ws.depthCallback(dep)
+	}
+	return nil
+}
+
+//checksum校验失败, 重新订阅获取快照
+func (ws *KrakenWs) resubscribeBook(pair CurrencyPair) {
+	if ws.pubWs == nil {
+		return
+	}
+	req := wsRequest{Event: "unsubscribe", Pair: []string{ws.wsPair(pair)}, Subscription: wsSubscription{Name: "book", Depth: ws.DepthSize}}
+	ws.pubWs.SendJsonMessage(req)
+	req.Event = "subscribe"
+	ws.pubWs.SendJsonMessage(req)
+}
+
+func (ws *KrakenWs) handleTicker(pair CurrencyPair, payload json.RawMessage) error {
+	var t struct {
+		A []interface{} `json:"a"`
+		B []interface{} `json:"b"`
+		C []interface{} `json:"c"`
+		V []interface{} `json:"v"`
+		L []interface{} `json:"l"`
+		H []interface{} `json:"h"`
+	}
+	if err := json.Unmarshal(payload, &t); err != nil {
+		return err
+	}
+	if ws.tickerCallback == nil || len(t.C) == 0 || len(t.V) < 2 {
+		return nil
+	}
+	ws.tickerCallback(&Ticker{
+		Pair: pair,
+		Last: ToFloat64(t.C[0]),
+		Sell: ToFloat64(t.A[0]),
+		Buy:  ToFloat64(t.B[0]),
+		Vol:  ToFloat64(t.V[1]),
+		Low:  ToFloat64(t.L[1]),
+		High: ToFloat64(t.H[1]),
+		Date: uint64(time.Now().UnixNano() / int64(time.Millisecond)),
+	})
+	return nil
+}
+
+func (ws *KrakenWs) handleTrade(pair CurrencyPair, payload json.RawMessage) error {
+	var items [][]interface{}
+	if err := json.Unmarshal(payload, &items); err != nil {
+		return err
+	}
+	if ws.tradeCallback == nil {
+		return nil
+	}
+	//[price, volume, time, side, orderType, misc]
+	for _, itm := range items {
+		trade := ws.k.adaptTrade(itm, pair)
+		ws.tradeCallback(&trade)
+	}
+	return nil
+}
+
+func (ws *KrakenWs) handleOpenOrders(payload json.RawMessage) error {
+	var items []map[string]struct {
+		Status   string `json:"status"`
+		Vol      string `json:"vol"`
+		VolExec  string `json:"vol_exec"`
+		Fee      string `json:"fee"`
+		AvgPrice string `json:"avg_price"`
+		Opentm   string `json:"opentm"`
+		Descr    struct {
+			Pair      string `json:"pair"`
+			Type      string `json:"type"`
+			OrderType string `json:"ordertype"`
+			Price     string `json:"price"`
+		} `json:"descr"`
+	}
+	if err := json.Unmarshal(payload, &items); err != nil {
+		return err
+	}
+	if ws.orderCallback == nil {
+		return nil
+	}
+
+	//增量推送只包含变化的字段
+	for _, itm := range items {
+		for txid, o := range itm {
+			ord := &Order{
+				OrderID2:   txid,
+				Currency:   adaptWsPair(o.Descr.Pair),
+				Amount:     ToFloat64(o.Vol),
+				Price:      ToFloat64(o.Descr.Price),
+				DealAmount: ToFloat64(o.VolExec),
+				AvgPrice:   ToFloat64(o.AvgPrice),
+				Fee:        ToFloat64(o.Fee),
+				OrderTime:  int(ToFloat64(o.Opentm) * 1000),
+				Type:       o.Descr.OrderType,
+				Status:     ws.k.convertOrderStatus(o.Status),
+			}
+			if o.Descr.Type != "" {
+				ord.Side = AdaptTradeSide(o.Descr.Type)
+			}
+			ws.orderCallback(ord)
+		}
+	}
+	return nil
+}
+
+func (ws *KrakenWs) handleOwnTrades(payload json.RawMessage) error {
+	var items []map[string]struct {
+		OrderTxid string `json:"ordertxid"`
+		Pair      string `json:"pair"`
+		Time      string `json:"time"`
+		Type      string `json:"type"`
+		Price     string `json:"price"`
+		Vol       string `json:"vol"`
+	}
+	if err := json.Unmarshal(payload, &items); err != nil {
+		return err
+	}
+	if ws.ownTradeCallback == nil {
+		return nil
+	}
+
+	for _, itm := range items {
+		for _, t := range itm {
+			ws.ownTradeCallback(&Trade{
+				Tid:    int64(ToFloat64(t.Time) * 1e9),
+				Type:   AdaptTradeSide(t.Type),
+				Amount: ToFloat64(t.Vol),
+				Price:  ToFloat64(t.Price),
+				Date:   int64(ToFloat64(t.Time) * 1000),
+				Pair:   adaptWsPair(t.Pair),
+			}, t.OrderTxid)
+		}
+	}
+	return nil
+}
+
+//XBT/USD -> BTC_USD
+func adaptWsPair(pairName string) CurrencyPair {
+	pair := NewCurrencyPair3(pairName, "/")
+	if pair.CurrencyA == XBT {
+		pair.CurrencyA = BTC
+	}
+	if pair.CurrencyB == XBT {
+		pair.CurrencyB = BTC
+	}
+	return pair
+}
@@ -0,0 +1,60 @@
+package kraken
+
+import (
+	"fmt"
+	"hash/crc32"
+	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKrakenWs_Book(t *testing.T) {
+	ws := NewKrakenWs(nil)
+	ws.DepthSize = 2
+
+	var depth *goex.Depth
+	ws.DepthCallback(func(dep *goex.Depth) {
+		depth = dep
+	})
+
+	err := ws.handle([]byte(`[0,{"as":[["5541.30000","2.50700000","1534614248.123678"],["5541.80000","0.33000000","1534614098.345543"],["5542.70000","0.64700000","1534614244.654432"]],
+		"bs":[["5541.20000","1.52900000","1534614248.765567"],["5539.90000","0.30000000","1534614241.769870"]]},"book-10","XBT/USD"]`))
+	assert.Nil(t, err)
+	assert.Equal(t, "BTC_USD", depth.Pair.String())
+	assert.Equal(t, goex.DepthRecords{{Price: 5541.8, Amount: 0.33}, {Price: 5541.3, Amount: 2.507}}, depth.AskList)
+	assert.Equal(t, goex.DepthRecords{{Price: 5541.2, Amount: 1.529}, {Price: 5539.9, Amount: 0.3}}, depth.BidList)
+
+	//删除5541.3的卖单并补上5542.7, 新增5539.5的买单(超出深度被丢弃)
+	checksum := crc32.ChecksumIEEE([]byte("55418000033000000" + "55427000064700000" + "554120000152900000" + "55399000030000000"))
+	err = ws.handle([]byte(fmt.Sprintf(`[0,{"a":[["5541.30000","0.00000000","1534614335.345903"],["5542.70000","0.64700000","1534614335.345903","r"]]},{"b":[["5539.50000","1.00000000","1534614335.345903"]],"c":"%d"},"book-10","XBT/USD"]`, checksum)))
+	assert.Nil(t, err)
+	assert.Equal(t, goex.DepthRecords{{Price: 5542.7, Amount: 0.647}, {Price: 5541.8, Amount: 0.33}}, depth.AskList)
+	assert.Len(t, depth.BidList, 2)
+
+	//checksum不一致时丢弃本地订单簿
+	err = ws.handle([]byte(`[0,{"b":[["5541.20000","2.00000000","1534614335.345903"]],"c":"12345"},"book-10","XBT/USD"]`))
+	assert.Error(t, err)
+	assert.Empty(t, ws.books)
+}
+
+func TestKrakenWs_OwnTrades(t *testing.T) {
+	ws := NewKrakenWs(nil)
+
+	var (
+		trade   *goex.Trade
+		orderId string
+	)
+	ws.OwnTradeCallback(func(tr *goex.Trade, ordId string) {
+		trade, orderId = tr, ordId
+	})
+
+	err := ws.handle([]byte(`[[{"TDLH43-DVQXD-2KHVYY":{"cost":"1000000.00000","fee":"1600.00000","margin":"0.00000","ordertxid":"TDLH43-DVQXD-2KHVYY",
+		"ordertype":"limit","pair":"XBT/EUR","postxid":"OGTT3Y-C6I3P-XRI6HX","price":"100000.00000","time":"1560516023.070651","type":"sell","vol":"1000000000.00000000"}}],"ownTrades",{"sequence":2}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, "TDLH43-DVQXD-2KHVYY", orderId)
+	assert.Equal(t, "BTC_EUR", trade.Pair.String())
+	assert.Equal(t, goex.SELL, trade.Type)
+	assert.Equal(t, 100000.0, trade.Price)
+	assert.Equal(t, int64(1560516023070), trade.Date)
+}
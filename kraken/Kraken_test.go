@@ -4,6 +4,7 @@ import (
 	"github.com/lucas7788/goex"
 	"github.com/stretchr/testify/assert"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -61,3 +62,48 @@ func TestKraken_GetOneOrder(t *testing.T) {
 	assert.Nil(t, err)
 	t.Log(ord)
 }
+
+func TestKraken_GetOHLCAndTrades(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "XBTUSD", r.URL.Query().Get("pair"))
+		switch r.URL.Path {
+		case "/0/public/OHLC":
+			assert.Equal(t, "60", r.URL.Query().Get("interval"))
+			assert.Equal(t, "1548111600", r.URL.Query().Get("since"))
+			w.Write([]byte(`{"error":[],"result":{"XXBTZUSD":[[1548115200,"3533.4","3543.7","3530.7","3539.4","3539.6","88.73208471",333]],"last":1548111600}}`))
+		case "/0/public/Trades":
+			assert.Equal(t, "1548111600000000000", r.URL.Query().Get("since"))
+			w.Write([]byte(`{"error":[],"result":{"XXBTZUSD":[["3533.40000","0.01000000",1548115200.1234,"b","l","",41000]],"last":"1548115200123400000"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	domain := API_DOMAIN
+	API_DOMAIN = srv.URL + "/0/"
+	defer func() { API_DOMAIN = domain }()
+
+	klines, err := k.GetKlineRecords(goex.BTC_USD, goex.KLINE_PERIOD_1H, 10, goex.OptionalParameter{}.Optional("since", 1548111600))
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.Kline{{Pair: goex.BTC_USD, Timestamp: 1548115200, Open: 3533.4, High: 3543.7, Low: 3530.7, Close: 3539.4, Vol: 88.73208471}}, klines)
+
+	trades, last, err := k.GetTradesSince(goex.BTC_USD, "1548111600000000000")
+	assert.Nil(t, err)
+	assert.Equal(t, "1548115200123400000", last)
+	assert.Equal(t, []goex.Trade{{Tid: 41000, Type: goex.BUY, Amount: 0.01, Price: 3533.4, Date: 1548115200123, Pair: goex.BTC_USD}}, trades)
+}
+
+func TestKraken_GetServerTime(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/0/public/Time", r.URL.Path)
+		w.Write([]byte(`{"error":[],"result":{"unixtime":1548115200,"rfc1123":"Mon, 21 Jan 19 24:00:00 +0000"}}`))
+	}))
+	defer srv.Close()
+
+	domain := API_DOMAIN
+	API_DOMAIN = srv.URL + "/0/"
+	defer func() { API_DOMAIN = domain }()
+
+	ts, err := k.GetServerTime()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1548115200000), ts)
+}
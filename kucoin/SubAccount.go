@@ -0,0 +1,115 @@
+package kucoin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Kucoin/kucoin-go-sdk"
+	. "github.com/lucas7788/goex"
+)
+
+//子账户列表, SubAccount为子账户userId
+func (w *Wallet) GetSubAccounts() ([]SubAccountUser, error) {
+	model, err := w.kc.SubAccountUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]SubAccountUser, 0, len(model))
+	for _, u := range model {
+		users = append(users, SubAccountUser{
+			SubAccount: u.UserId,
+			Uid:        u.UserId,
+			Name:       u.SubName,
+			Label:      u.Remarks,
+			Enable:     true,
+		})
+	}
+	return users, nil
+}
+
+//子账户交易账户资产
+func (w *Wallet) GetSubAccountBalance(subAccount string) (*Account, error) {
+	model, err := w.kc.SubAccount(subAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &Account{
+		Exchange:    KUCOIN,
+		SubAccounts: make(map[Currency]SubAccount, len(model.TradeAccounts)),
+	}
+	for _, v := range model.TradeAccounts {
+		currency := NewCurrency(v.Currency, "")
+		acc.SubAccounts[currency] = SubAccount{
+			Currency:     currency,
+			Amount:       ToFloat64(v.Available),
+			ForzenAmount: ToFloat64(v.Holds),
+		}
+	}
+	return acc, nil
+}
+
+//权限: General, Trade, 接口使用子账户名, 先通过userId查询
+func (w *Wallet) CreateSubAccountApiKey(param SubAccountApiKeyParameter) (*SubAccountApiKey, error) {
+	users, err := w.kc.SubAccountUsers()
+	if err != nil {
+		return nil, err
+	}
+	subName := ""
+	for _, u := range users {
+		if u.UserId == param.SubAccount {
+			subName = u.SubName
+			break
+		}
+	}
+	if subName == "" {
+		return nil, fmt.Errorf("sub account %s not found", param.SubAccount)
+	}
+
+	permission := "General"
+	if param.CanTrade {
+		permission += ",Trade"
+	}
+	params := map[string]string{
+		"subName":    subName,
+		"passphrase": param.Passphrase,
+		"remark":     param.Label,
+		"permission": permission,
+	}
+	if len(param.IpWhitelist) > 0 {
+		params["ipWhitelist"] = strings.Join(param.IpWhitelist, ",")
+	}
+	resp, err := w.kc.service.Call(kucoin.NewRequest(http.MethodPost, "/api/v1/sub/api-key", params))
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Remark      string `json:"remark"`
+		ApiKey      string `json:"apiKey"`
+		ApiSecret   string `json:"apiSecret"`
+		Passphrase  string `json:"passphrase"`
+		Permission  string `json:"permission"`
+		IpWhitelist string `json:"ipWhitelist"`
+	}
+	if err = resp.ReadData(&model); err != nil {
+		return nil, err
+	}
+
+	key := &SubAccountApiKey{
+		SubAccount: param.SubAccount,
+		Label:      model.Remark,
+		ApiKey:     model.ApiKey,
+		SecretKey:  model.ApiSecret,
+		Passphrase: model.Passphrase,
+	}
+	if model.Permission != "" {
+		key.Permissions = strings.Split(model.Permission, ",")
+	}
+	if model.IpWhitelist != "" {
+		key.IpWhitelist = strings.Split(model.IpWhitelist, ",")
+	}
+	return key, nil
+}
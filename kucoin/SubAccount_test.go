@@ -0,0 +1,65 @@
+package kucoin
+
+import (
+	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWallet_SubAccount(t *testing.T) {
+	var keyBody map[string]interface{}
+	srv := testserver.New(kucoinAPI, map[string]testserver.Route{
+		"GET /api/v1/sub/user": func(r *testserver.Request) interface{} {
+			return []map[string]string{
+				{"userId": "5cbd31ab9c93e9280cd36a0a", "subName": "kucoin1", "remarks": "market maker"},
+				{"userId": "5cbd31b89c93e9280cd36a0d", "subName": "kucoin2", "remarks": ""},
+			}
+		},
+		"GET /api/v1/sub-accounts/5cbd31ab9c93e9280cd36a0a": func(r *testserver.Request) interface{} {
+			return map[string]interface{}{"subUserId": "5cbd31ab9c93e9280cd36a0a", "subName": "kucoin1",
+				"mainAccounts": []map[string]string{{"currency": "ETH", "balance": "5", "available": "5", "holds": "0"}},
+				"tradeAccounts": []map[string]string{
+					{"currency": "BTC", "balance": "1.5", "available": "1", "holds": "0.5"},
+					{"currency": "USDT", "balance": "100", "available": "100", "holds": "0"},
+				}}
+		},
+		"POST /api/v1/sub/api-key": func(r *testserver.Request) interface{} {
+			keyBody = r.JSON()
+			return map[string]string{"subName": "kucoin1", "remark": "bot", "apiKey": "sub-key", "apiSecret": "sub-secret",
+				"passphrase": "sub-pass", "permission": "General,Trade", "ipWhitelist": "1.1.1.1"}
+		},
+	})
+	defer srv.Close()
+	kc := NewWithConfig(testserver.Config(srv))
+	wallet := &Wallet{kc: kc}
+
+	users, err := wallet.GetSubAccounts()
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.SubAccountUser{
+		{SubAccount: "5cbd31ab9c93e9280cd36a0a", Uid: "5cbd31ab9c93e9280cd36a0a", Name: "kucoin1", Label: "market maker", Enable: true},
+		{SubAccount: "5cbd31b89c93e9280cd36a0d", Uid: "5cbd31b89c93e9280cd36a0d", Name: "kucoin2", Enable: true},
+	}, users)
+
+	//只统计交易账户
+	acc, err := wallet.GetSubAccountBalance("5cbd31ab9c93e9280cd36a0a")
+	assert.Nil(t, err)
+	assert.Len(t, acc.SubAccounts, 2)
+	assert.Equal(t, goex.SubAccount{Currency: goex.BTC, Amount: 1, ForzenAmount: 0.5}, acc.SubAccounts[goex.BTC])
+	assert.Equal(t, 100.0, acc.SubAccounts[goex.USDT].Amount)
+
+	//接口使用子账户名
+	key, err := wallet.CreateSubAccountApiKey(goex.SubAccountApiKeyParameter{SubAccount: "5cbd31ab9c93e9280cd36a0a", Label: "bot",
+		Passphrase: "sub-pass", CanTrade: true, IpWhitelist: []string{"1.1.1.1"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "kucoin1", keyBody["subName"])
+	assert.Equal(t, "sub-pass", keyBody["passphrase"])
+	assert.Equal(t, "General,Trade", keyBody["permission"])
+	assert.Equal(t, "1.1.1.1", keyBody["ipWhitelist"])
+	assert.Equal(t, &goex.SubAccountApiKey{SubAccount: "5cbd31ab9c93e9280cd36a0a", Label: "bot", ApiKey: "sub-key", SecretKey: "sub-secret",
+		Passphrase: "sub-pass", Permissions: []string{"General", "Trade"}, IpWhitelist: []string{"1.1.1.1"}}, key)
+
+	_, err = wallet.CreateSubAccountApiKey(goex.SubAccountApiKeyParameter{SubAccount: "unknown"})
+	assert.EqualError(t, err, "sub account unknown not found")
+}
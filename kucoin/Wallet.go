@@ -0,0 +1,264 @@
+package kucoin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Kucoin/kucoin-go-sdk"
+	. "github.com/lucas7788/goex"
+)
+
+/**
+ * 钱包, 实现WalletApiExt
+ * 资产在储蓄账户(main), 交易前需要划转到交易账户(trade)
+ */
+type Wallet struct {
+	kc *KuCoin
+}
+
+func NewWallet(c *APIConfig) *Wallet {
+	return &Wallet{kc: NewWithConfig(c)}
+}
+
+//储蓄账户资产
+func (w *Wallet) GetAccount() (*Account, error) {
+	accs, err := w.kc.Accounts("", "main")
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &Account{
+		Exchange:    KUCOIN,
+		SubAccounts: make(map[Currency]SubAccount, len(accs)),
+	}
+	for _, v := range accs {
+		currency := NewCurrency(v.Currency, "")
+		acc.SubAccounts[currency] = SubAccount{
+			Currency:     currency,
+			Amount:       ToFloat64(v.Available),
+			ForzenAmount: ToFloat64(v.Holds),
+		}
+	}
+	return acc, nil
+}
+
+//提币, Chain为空时使用币种的默认链
+func (w *Wallet) Withdrawal(param WithdrawParameter) (withdrawId string, err error) {
+	return w.kc.ApplyWithdrawal(strings.ToUpper(param.Currency), param.ToAddress, FloatToString(param.Amount, 8),
+		param.Memo, "false", "", param.Chain)
+}
+
+//goex账户类型 => kucoin账户类型
+var accountTypes = map[int]string{
+	WALLET:      "main",
+	SPOT:        "trade",
+	SPOT_MARGIN: "margin",
+}
+
+//储蓄, 交易, 杠杆账户之间划转
+func (w *Wallet) Transfer(param TransferParameter) error {
+	from, ok1 := accountTypes[param.From]
+	to, ok2 := accountTypes[param.To]
+	if !ok1 || !ok2 {
+		return errors.New("unsupported account type")
+	}
+	_, err := w.kc.InnerTransfer(strings.ToUpper(param.Currency), from, to, FloatToString(param.Amount, 8))
+	return err
+}
+
+//提币: PROCESSING, WALLET_PROCESSING, SUCCESS, FAILURE
+//充值: PROCESSING, SUCCESS, FAILURE
+var depositWithdrawStatus = map[string]WithdrawStatus{
+	"PROCESSING":        WITHDRAW_PENDING,
+	"WALLET_PROCESSING": WITHDRAW_SENT,
+	"SUCCESS":           WITHDRAW_COMPLETED,
+	"FAILURE":           WITHDRAW_FAILED,
+}
+
+//最近一页记录
+func (w *Wallet) GetWithDrawHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	resp, err := w.kc.service.Withdrawals(historyParams(currency), &kucoin.PaginationParam{CurrentPage: 1, PageSize: 100})
+	if err != nil {
+		return nil, err
+	}
+
+	var model kucoin.WithdrawalsModel
+	if _, err = resp.ReadPaginationData(&model); err != nil {
+		return nil, err
+	}
+
+	history := make([]DepositWithdrawHistory, 0, len(model))
+	for _, r := range model {
+		history = append(history, DepositWithdrawHistory{
+			WithdrawalId: r.Id,
+			Currency:     r.Currency,
+			Txid:         r.WalletTxId,
+			Amount:       ToFloat64(r.Amount),
+			To:           r.Address,
+			Memo:         r.Memo,
+			Fee:          r.Fee,
+			Status:       depositWithdrawStatus[r.Status],
+			Timestamp:    time.Unix(0, r.CreatedAt*int64(time.Millisecond)),
+		})
+	}
+	return history, nil
+}
+
+func (w *Wallet) GetDepositHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	resp, err := w.kc.service.Deposits(historyParams(currency), &kucoin.PaginationParam{CurrentPage: 1, PageSize: 100})
+	if err != nil {
+		return nil, err
+	}
+
+	var model kucoin.DepositsModel
+	if _, err = resp.ReadPaginationData(&model); err != nil {
+		return nil, err
+	}
+
+	history := make([]DepositWithdrawHistory, 0, len(model))
+	for _, r := range model {
+		history = append(history, DepositWithdrawHistory{
+			Currency:  r.Currency,
+			Txid:      r.WalletTxId,
+			Amount:    ToFloat64(r.Amount),
+			To:        r.Address,
+			Memo:      r.Memo,
+			Fee:       r.Fee,
+			Status:    depositWithdrawStatus[r.Status],
+			Timestamp: time.Unix(0, r.CreatedAt*int64(time.Millisecond)),
+		})
+	}
+	return history, nil
+}
+
+func historyParams(currency *Currency) map[string]string {
+	params := map[string]string{}
+	if currency != nil && *currency != UNKNOWN {
+		params["currency"] = currency.Symbol
+	}
+	return params
+}
+
+//v2接口返回所有链的充值地址, chain为链名称, 如TRC20
+func (w *Wallet) GetDepositAddress(currency Currency, chain string) ([]ChainAddress, error) {
+	req := kucoin.NewRequest(http.MethodGet, "/api/v2/deposit-addresses", map[string]string{"currency": currency.Symbol})
+	resp, err := w.kc.service.Call(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var model []struct {
+		Address string `json:"address"`
+		Memo    string `json:"memo"`
+		Chain   string `json:"chain"`
+	}
+	if err = resp.ReadData(&model); err != nil {
+		return nil, err
+	}
+
+	addresses := make([]ChainAddress, 0, len(model))
+	for _, r := range model {
+		if chain != "" && !strings.EqualFold(r.Chain, chain) {
+			continue
+		}
+		addresses = append(addresses, ChainAddress{
+			Currency: currency.Symbol,
+			Chain:    r.Chain,
+			Address:  r.Address,
+			Tag:      r.Memo,
+		})
+	}
+	return addresses, nil
+}
+
+func (w *Wallet) GetWithdrawChains(currency Currency) ([]WithdrawChain, error) {
+	req := kucoin.NewRequest(http.MethodGet, "/api/v2/currencies/"+currency.Symbol, nil)
+	resp, err := w.kc.service.Call(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Currency string `json:"currency"`
+		Chains   []struct {
+			Chain             string `json:"chain"`
+			WithdrawalMinSize string `json:"withdrawalMinSize"`
+			WithdrawalMinFee  string `json:"withdrawalMinFee"`
+			IsWithdrawEnabled bool   `json:"isWithdrawEnabled"`
+			IsDepositEnabled  bool   `json:"isDepositEnabled"`
+		} `json:"chains"`
+	}
+	if err = resp.ReadData(&model); err != nil {
+		return nil, err
+	}
+
+	chains := make([]WithdrawChain, 0, len(model.Chains))
+	for _, c := range model.Chains {
+		chains = append(chains, WithdrawChain{
+			Currency:    model.Currency,
+			Chain:       c.Chain,
+			CanDeposit:  c.IsDepositEnabled,
+			CanWithdraw: c.IsWithdrawEnabled,
+			MinFee:      ToFloat64(c.WithdrawalMinFee),
+			MaxFee:      ToFloat64(c.WithdrawalMinFee),
+			MinAmount:   ToFloat64(c.WithdrawalMinSize),
+		})
+	}
+	return chains, nil
+}
+
+//只能撤销PROCESSING状态的提币
+func (w *Wallet) CancelWithdrawal(withdrawId string) error {
+	_, err := w.kc.CancelWithdrawal(withdrawId)
+	return err
+}
+
+func (w *Wallet) GetExchangeName() string {
+	return KUCOIN
+}
+
+//资金账户对应储蓄账户(main)
+var accountKinds = map[AccountKind]string{
+	ACCOUNT_FUNDING: "main",
+	ACCOUNT_SPOT:    "trade",
+	ACCOUNT_MARGIN:  "margin",
+}
+
+/**
+ * 储蓄, 交易, 杠杆账户之间划转, 不支持合约账户
+ * 母子账户划转只能使用母账户的储蓄账户, SubAccount为子账户userId, 子账户默认为储蓄账户
+ */
+func (w *Wallet) TransferAsset(req TransferRequest) (string, error) {
+	currency := strings.ToUpper(req.Currency.Symbol)
+	amount := FloatToString(req.Amount, 8)
+
+	if req.From == ACCOUNT_SUB || req.To == ACCOUNT_SUB {
+		subKind := req.SubAccountKind
+		if subKind == 0 {
+			subKind = ACCOUNT_FUNDING
+		}
+		subType, ok := accountKinds[subKind]
+		if !ok {
+			return "", fmt.Errorf("unsupported sub account kind: %s", subKind)
+		}
+		direction := "OUT" //母账户转出
+		if req.From == ACCOUNT_SUB {
+			direction = "IN"
+		}
+		return w.kc.SubTransfer(currency, amount, direction, req.SubAccount, "MAIN", strings.ToUpper(subType))
+	}
+
+	from, ok1 := accountKinds[req.From]
+	to, ok2 := accountKinds[req.To]
+	if !ok1 || !ok2 {
+		return "", fmt.Errorf("unsupported transfer from %s to %s", req.From, req.To)
+	}
+	return w.kc.InnerTransfer(currency, from, to, amount)
+}
+
+func (w *Wallet) GetTransferHistory(currency Currency, from, to AccountKind, optional ...OptionalParameter) ([]TransferRecord, error) {
+	return nil, EX_ERR_NOT_SUPPORT
+}
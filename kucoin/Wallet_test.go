@@ -0,0 +1,126 @@
+package kucoin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWallet_WithdrawChainsAndHistory(t *testing.T) {
+	var withdrawBody map[string]interface{}
+	srv := testserver.New(kucoinAPI, map[string]testserver.Route{
+		"GET /api/v2/deposit-addresses": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "USDT", r.URL.Query().Get("currency"))
+			return []map[string]string{
+				{"address": "0xabc", "memo": "", "chain": "ERC20"},
+				{"address": "TXyz", "memo": "", "chain": "TRC20"},
+			}
+		},
+		"GET /api/v2/currencies/USDT": func(r *testserver.Request) interface{} {
+			return map[string]interface{}{"currency": "USDT", "chains": []map[string]interface{}{
+				{"chain": "ERC20", "withdrawalMinSize": "20", "withdrawalMinFee": "10", "isWithdrawEnabled": true, "isDepositEnabled": true},
+				{"chain": "TRC20", "withdrawalMinSize": "2", "withdrawalMinFee": "1", "isWithdrawEnabled": false, "isDepositEnabled": true},
+			}}
+		},
+		"GET /api/v1/withdrawals": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "USDT", r.URL.Query().Get("currency"))
+			return map[string]interface{}{"currentPage": 1, "pageSize": 100, "totalNum": 3, "totalPage": 1, "items": []map[string]interface{}{
+				{"id": "w1", "address": "TXyz", "currency": "USDT", "amount": "10", "fee": "1", "walletTxId": "tx1", "status": "SUCCESS", "createdAt": 1544178843000},
+				{"id": "w2", "address": "TXyz", "currency": "USDT", "amount": "20", "fee": "1", "status": "WALLET_PROCESSING", "createdAt": 1544178843000},
+				{"id": "w3", "address": "TXyz", "currency": "USDT", "amount": "30", "fee": "1", "status": "FAILURE", "createdAt": 1544178843000},
+			}}
+		},
+		"POST /api/v1/withdrawals": func(r *testserver.Request) interface{} {
+			withdrawBody = r.JSON()
+			return map[string]string{"withdrawalId": "w4"}
+		},
+		"DELETE /api/v1/withdrawals/w4": func(r *testserver.Request) interface{} {
+			return map[string]interface{}{}
+		},
+	})
+	defer srv.Close()
+	kc := NewWithConfig(testserver.Config(srv))
+	wallet := &Wallet{kc: kc}
+
+	addresses, err := wallet.GetDepositAddress(goex.USDT, "trc20")
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.ChainAddress{{Currency: "USDT", Chain: "TRC20", Address: "TXyz"}}, addresses)
+
+	chains, err := wallet.GetWithdrawChains(goex.USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, goex.WithdrawChain{Currency: "USDT", Chain: "ERC20", CanDeposit: true, CanWithdraw: true,
+		MinFee: 10, MaxFee: 10, MinAmount: 20}, chains[0])
+	assert.False(t, chains[1].CanWithdraw)
+
+	history, err := wallet.GetWithDrawHistory(&goex.USDT)
+	assert.Nil(t, err)
+	assert.Len(t, history, 3)
+	assert.Equal(t, "w1", history[0].WithdrawalId)
+	assert.Equal(t, goex.WITHDRAW_COMPLETED, history[0].Status)
+	assert.Equal(t, goex.WITHDRAW_SENT, history[1].Status)
+	assert.Equal(t, goex.WITHDRAW_FAILED, history[2].Status)
+	assert.Equal(t, time.Unix(1544178843, 0), history[0].Timestamp)
+
+	id, err := wallet.Withdrawal(goex.WithdrawParameter{Currency: "usdt", ToAddress: "TXyz", Amount: 12.5, Chain: "TRC20"})
+	assert.Nil(t, err)
+	assert.Equal(t, "w4", id)
+	assert.Equal(t, "USDT", withdrawBody["currency"])
+	assert.Equal(t, "12.5", withdrawBody["amount"])
+	assert.Equal(t, "TRC20", withdrawBody["chain"])
+	assert.Equal(t, "false", withdrawBody["isInner"])
+
+	assert.Nil(t, wallet.CancelWithdrawal("w4"))
+	assert.Error(t, wallet.CancelWithdrawal("w5"))
+}
+
+func TestWallet_TransferAsset(t *testing.T) {
+	var inner, sub map[string]interface{}
+	srv := testserver.New(kucoinAPI, map[string]testserver.Route{
+		"POST /api/v2/accounts/inner-transfer": func(r *testserver.Request) interface{} {
+			inner = r.JSON()
+			return map[string]string{"orderId": "5bd6e9286d99522a52c80c01"}
+		},
+		"POST /api/v1/accounts/sub-transfer": func(r *testserver.Request) interface{} {
+			sub = r.JSON()
+			return map[string]string{"orderId": "5cbd870fd9575a18e4438b9a"}
+		},
+	})
+	defer srv.Close()
+	kc := NewWithConfig(testserver.Config(srv))
+	wallet := &Wallet{kc: kc}
+
+	id, err := wallet.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 10, From: goex.ACCOUNT_FUNDING, To: goex.ACCOUNT_MARGIN})
+	assert.Nil(t, err)
+	assert.Equal(t, "5bd6e9286d99522a52c80c01", id)
+	assert.Equal(t, "USDT", inner["currency"])
+	assert.Equal(t, "main", inner["from"])
+	assert.Equal(t, "margin", inner["to"])
+	assert.Equal(t, "10", inner["amount"])
+	assert.NotEmpty(t, inner["clientOid"])
+
+	id, err = wallet.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1.5, From: goex.ACCOUNT_SUB, To: goex.ACCOUNT_FUNDING,
+		SubAccount: "5caefba7d9575a0688f83c45", SubAccountKind: goex.ACCOUNT_SPOT})
+	assert.Nil(t, err)
+	assert.Equal(t, "5cbd870fd9575a18e4438b9a", id)
+	assert.Equal(t, "IN", sub["direction"])
+	assert.Equal(t, "5caefba7d9575a0688f83c45", sub["subUserId"])
+	assert.Equal(t, "MAIN", sub["accountType"])
+	assert.Equal(t, "TRADE", sub["subAccountType"])
+	assert.Equal(t, "1.5", sub["amount"])
+
+	//子账户默认为储蓄账户
+	_, err = wallet.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_FUNDING, To: goex.ACCOUNT_SUB,
+		SubAccount: "5caefba7d9575a0688f83c45"})
+	assert.Nil(t, err)
+	assert.Equal(t, "OUT", sub["direction"])
+	assert.Equal(t, "MAIN", sub["subAccountType"])
+
+	_, err = wallet.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_SPOT, To: goex.ACCOUNT_FUTURES_USDT})
+	assert.NotNil(t, err)
+	_, err = wallet.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 1, From: goex.ACCOUNT_FUNDING, To: goex.ACCOUNT_SUB,
+		SubAccount: "5caefba7d9575a0688f83c45", SubAccountKind: goex.ACCOUNT_FUTURES_USDT})
+	assert.NotNil(t, err)
+}
@@ -1,6 +1,8 @@
 package kucoin
 
 import (
+	"fmt"
+
 	"github.com/Kucoin/kucoin-go-sdk"
 	. "github.com/lucas7788/goex"
 	log "github.com/lucas7788/goex/internal/logger"
@@ -28,11 +30,13 @@ func NewWithConfig(config *APIConfig) *KuCoin {
 		apiPassphrase: config.ApiPassphrase,
 	}
 
+	kc.clock = SharedClockSync(kc.baseUrl+timestampPath, kc.GetServerTime)
 	kc.service = kucoin.NewApiService(
 		kucoin.ApiBaseURIOption(kc.baseUrl),
 		kucoin.ApiKeyOption(kc.apiKey),
 		kucoin.ApiSecretOption(kc.apiSecret),
 		kucoin.ApiPassPhraseOption(kc.apiPassphrase),
+		kucoin.ApiRequesterOption(&clockRequester{clock: kc.clock, secret: kc.apiSecret}),
 	)
 
 	return kc
@@ -44,6 +48,43 @@ type KuCoin struct {
 	baseUrl       string
 	apiPassphrase string
 	service       *kucoin.ApiService
+	clock         *ClockSync
+}
+
+const timestampPath = "/api/v1/timestamp"
+
+/**
+ * sdk的KcSigner固定使用本地时间签名, 并且不能替换
+ * 发送前用同步后的服务器时间重新生成KC-API-TIMESTAMP和KC-API-SIGN
+ * 签名: base64(hmac_sha256(secret, timestamp + method + requestURI + body))
+ */
+type clockRequester struct {
+	kucoin.BasicRequester
+	clock  *ClockSync
+	secret string
+}
+
+func (r *clockRequester) Request(request *kucoin.Request, timeout time.Duration) (*kucoin.Response, error) {
+	if request.Header.Get("KC-API-SIGN") != "" && request.Path != timestampPath {
+		timestamp := fmt.Sprint(r.clock.NowMillis())
+		sign, _ := GetParamHmacSHA256Base64Sign(r.secret, timestamp+request.Method+request.RequestURI()+string(request.Body))
+		request.Header.Set("KC-API-TIMESTAMP", timestamp)
+		request.Header.Set("KC-API-SIGN", sign)
+	}
+	return r.BasicRequester.Request(request, timeout)
+}
+
+//服务器毫秒时间戳
+func (kc *KuCoin) GetServerTime() (int64, error) {
+	resp, err := kc.service.ServerTime()
+	if err != nil {
+		return 0, err
+	}
+	var ts int64
+	if err = resp.ReadData(&ts); err != nil {
+		return 0, err
+	}
+	return ts, nil
 }
 
 var inernalKlinePeriodConverter = map[KlinePeriod]string{
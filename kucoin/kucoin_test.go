@@ -1,12 +1,41 @@
 package kucoin
 
 import (
+	"fmt"
 	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 var kc = New("", "", "")
 
+//kucoin接口并校验签名, 路由的key为"METHOD /path", 返回值为data字段
+var kucoinAPI = testserver.Options{
+	Fixed: map[string]testserver.Route{
+		"/api/v1/timestamp": func(r *testserver.Request) interface{} {
+			return map[string]interface{}{"code": "200000", "data": time.Now().UnixNano() / int64(time.Millisecond)}
+		},
+	},
+	Check: func(r *testserver.Request) interface{} {
+		sign, _ := goex.GetParamHmacSHA256Base64Sign("secret", r.Header.Get("KC-API-TIMESTAMP")+r.Method+r.URL.RequestURI()+string(r.Data))
+		if sign != r.Header.Get("KC-API-SIGN") || r.Header.Get("KC-API-PASSPHRASE") != "pass" {
+			return map[string]interface{}{"code": "400005", "msg": "Invalid KC-API-SIGN"}
+		}
+		return nil
+	},
+	Wrap: func(r *testserver.Request, result interface{}) interface{} {
+		return map[string]interface{}{"code": "200000", "data": result}
+	},
+	NotFound: func(r *testserver.Request) interface{} {
+		return map[string]interface{}{"code": "404000", "msg": "Not Found: " + r.URL.Path}
+	},
+}
+
 func TestKuCoin_GetTicker(t *testing.T) {
 	ticker, _ := kc.GetTicker(goex.BTC_USDT)
 	t.Log(ticker)
@@ -31,3 +60,28 @@ func TestKuCoin_GetAccount(t *testing.T) {
 	acc, _ := kc.GetAccount()
 	t.Log(acc)
 }
+
+func TestKuCoin_SignWithServerTime(t *testing.T) {
+	drift := 10 * time.Minute
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/timestamp" {
+			fmt.Fprintf(w, `{"code":"200000","data":%d}`, time.Now().Add(drift).UnixNano()/int64(time.Millisecond))
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		timestamp := r.Header.Get("KC-API-TIMESTAMP")
+		sign, _ := goex.GetParamHmacSHA256Base64Sign("secret", timestamp+r.Method+r.URL.RequestURI()+string(body))
+		assert.Equal(t, sign, r.Header.Get("KC-API-SIGN"))
+		assert.Equal(t, "key", r.Header.Get("KC-API-KEY"))
+		//签名时间使用同步后的服务器时间
+		assert.WithinDuration(t, time.Now().Add(drift), time.Unix(0, goex.ToInt64(timestamp)*int64(time.Millisecond)), 5*time.Second)
+		w.Write([]byte(`{"code":"200000","data":[{"id":"1","currency":"BTC","type":"trade","balance":"1.5","available":"1","holds":"0.5"}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewWithConfig(&goex.APIConfig{Endpoint: srv.URL, ApiKey: "key", ApiSecretKey: "secret", ApiPassphrase: "pass"})
+	acc, err := c.GetAccount()
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, acc.SubAccounts[goex.BTC].Amount)
+	assert.Equal(t, 0.5, acc.SubAccounts[goex.BTC].ForzenAmount)
+}
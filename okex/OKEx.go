@@ -10,7 +10,6 @@ import (
 	"github.com/lucas7788/goex/internal/logger"
 	"strings"
 	"sync"
-	"time"
 )
 
 const baseUrl = "https://www.okex.com"
@@ -27,7 +26,11 @@ type OKEx struct {
 	OKExV3SwapWs    *OKExV3SwapWs
 	OKExAssetV5     *OKExAssetV5
 	OKExWalletV5    *OKExWalletV5
+	OKExFuturesV5   *OKExFuturesV5
+	OKExMarginV5    *OKExMarginV5
+	OKExOptionsV5   *OKExOptionsV5
 	Simulated       bool
+	clock           *ClockSync
 }
 
 func NewOKEx(config *APIConfig) *OKEx {
@@ -35,7 +38,7 @@ func NewOKEx(config *APIConfig) *OKEx {
 		config.Endpoint = baseUrl
 	}
 	okex := &OKEx{config: config}
-	okex.OKExSpot = &OKExSpotV5{okex}
+	okex.OKExSpot = &OKExSpotV5{OKEx: okex, TdMode: TdModeCash}
 	okex.OKExFuture = &OKExFuture{OKEx: okex, Locker: new(sync.Mutex)}
 	okex.OKExWallet = &OKExWallet{okex}
 	okex.OKExMargin = &OKExMargin{okex}
@@ -46,6 +49,10 @@ func NewOKEx(config *APIConfig) *OKEx {
 	okex.Simulated = config.Simulated
 	okex.OKExAssetV5 = &OKExAssetV5{okex}
 	okex.OKExWalletV5 = &OKExWalletV5{okex}
+	okex.OKExFuturesV5 = NewOKExFuturesV5(okex)
+	okex.OKExMarginV5 = &OKExMarginV5{&OKExSpotV5{OKEx: okex, TdMode: TdModeCross}}
+	okex.OKExOptionsV5 = NewOKExOptionsV5(okex)
+	okex.clock = SharedClockSync(config.Endpoint+"/api/v5/public/time", okex.GetServerTime)
 	return okex
 }
 
@@ -136,13 +143,32 @@ func (ok *OKEx) doParamSign(httpMethod, uri, requestBody string) (string, string
   eg: 2018-03-16T18:02:48.284Z
 */
 func (ok *OKEx) IsoTime() string {
-	utcTime := time.Now().UTC()
+	utcTime := ok.clock.Now().UTC()
 	iso := utcTime.String()
 	isoBytes := []byte(iso)
 	iso = string(isoBytes[:10]) + "T" + string(isoBytes[11:23]) + "Z"
 	return iso
 }
 
+// GetServerTime 返回服务器毫秒时间戳
+func (ok *OKEx) GetServerTime() (int64, error) {
+	respMap, err := HttpGet(ok.config.HttpClient, ok.config.Endpoint+"/api/v5/public/time")
+	if err != nil {
+		return 0, err
+	}
+	data, _ := respMap["data"].([]interface{})
+	if len(data) == 0 {
+		return 0, fmt.Errorf("server time response error: %v", respMap)
+	}
+	ts, _ := data[0].(map[string]interface{})
+	return ToInt64(ts["ts"]), nil
+}
+
+// GetClockSync 服务器时间同步,可通过Offset()获取本地时钟偏差
+func (ok *OKEx) GetClockSync() *ClockSync {
+	return ok.clock
+}
+
 func (ok *OKEx) LimitBuy(amount, price string, currency CurrencyPair, opt ...LimitOrderOptionalParameter) (*Order, error) {
 	return ok.OKExSpot.LimitBuy(amount, price, currency, opt...)
 }
@@ -160,7 +186,7 @@ func (ok *OKEx) MarketSell(amount, price string, currency CurrencyPair) (*Order,
 }
 
 func (ok *OKEx) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
-	return ok.OKExSpot.OKExSpot.CancelOrder(orderId, currency)
+	return ok.OKExSpot.CancelOrder(orderId, currency)
 }
 
 func (ok *OKEx) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
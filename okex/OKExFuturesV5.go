@@ -0,0 +1,638 @@
+package okex
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/lucas7788/goex"
+)
+
+/**
+ * v5 交割/永续/期权
+ * contractType:
+ *   this_week/next_week/quarter/bi_quarter 交割合约, 自动转换为具体的instId, 如 BTC-USD-210625
+ *   swap 永续合约, BTC_USD => BTC-USD-SWAP(币本位), BTC_USDT => BTC-USDT-SWAP(U本位)
+ *   swap-usdt U本位永续合约
+ *   其他包含'-'的字符串直接作为instId, 如期权 BTC-USD-210625-50000-C
+ * TdMode 默认为全仓cross; PosMode 为空时从账户配置读取
+ */
+type OKExFuturesV5 struct {
+	*OKEx
+	TdMode  string
+	PosMode string
+
+	lock        sync.Mutex
+	instruments map[string]*futuresInstrumentsCache //key: uly
+}
+
+type futuresInstrumentsCache struct {
+	instruments []InstrumentV5
+	expireTime  time.Time
+}
+
+type InstrumentV5 struct {
+	InstType  string `json:"instType"`
+	InstId    string `json:"instId"`
+	Uly       string `json:"uly"`
+	BaseCcy   string `json:"baseCcy"`
+	QuoteCcy  string `json:"quoteCcy"`
+	SettleCcy string `json:"settleCcy"`
+	CtVal     string `json:"ctVal"`
+	CtMult    string `json:"ctMult"`
+	CtValCcy  string `json:"ctValCcy"`
+	CtType    string `json:"ctType"` //linear：正向合约 inverse：反向合约
+	OptType   string `json:"optType"`
+	Stk       string `json:"stk"`
+	Alias     string `json:"alias"`
+	ExpTime   string `json:"expTime"`
+	Lever     string `json:"lever"`
+	TickSz    string `json:"tickSz"`
+	LotSz     string `json:"lotSz"`
+	MinSz     string `json:"minSz"`
+	State     string `json:"state"`
+}
+
+func NewOKExFuturesV5(okex *OKEx) *OKExFuturesV5 {
+	return &OKExFuturesV5{OKEx: okex, instruments: make(map[string]*futuresInstrumentsCache, 4)}
+}
+
+func (ok *OKExFuturesV5) GetExchangeName() string {
+	return OKEX
+}
+
+func (ok *OKExFuturesV5) tdMode() string {
+	if ok.TdMode == "" {
+		return TdModeCross
+	}
+	return ok.TdMode
+}
+
+//持仓模式, 未设置时读取一次账户配置, 读取失败时返回错误, 避免按错误的模式下单
+func (ok *OKExFuturesV5) posMode() (string, error) {
+	ok.lock.Lock()
+	defer ok.lock.Unlock()
+
+	if ok.PosMode != "" {
+		return ok.PosMode, nil
+	}
+
+	var response []struct {
+		PosMode string `json:"posMode"`
+	}
+	err := ok.DoRequestV5("GET", "/api/v5/account/config", nil, &response)
+	if err != nil {
+		return "", err
+	}
+	if len(response) == 0 {
+		return "", fmt.Errorf("no account config")
+	}
+	ok.PosMode = response[0].PosMode
+	return ok.PosMode, nil
+}
+
+func (ok *OKExFuturesV5) GetInstruments(instType, uly string) ([]InstrumentV5, error) {
+	param := url.Values{}
+	param.Set("instType", instType)
+	if uly != "" {
+		param.Set("uly", uly)
+	}
+	var response []InstrumentV5
+	err := ok.DoRequestV5("GET", "/api/v5/public/instruments?"+param.Encode(), nil, &response)
+	return response, err
+}
+
+//交割合约按别名查找instId, 缓存到最近一个合约交割
+func (ok *OKExFuturesV5) getFuturesInstId(uly, alias string) (string, error) {
+	if alias == BI_QUARTER_CONTRACT {
+		alias = "next_quarter"
+	}
+
+	ok.lock.Lock()
+	defer ok.lock.Unlock()
+
+	cache, exist := ok.instruments[uly]
+	if !exist || time.Now().After(cache.expireTime) {
+		instruments, err := ok.GetInstruments(InstTypeFutures, uly)
+		if err != nil {
+			return "", err
+		}
+		cache = &futuresInstrumentsCache{instruments: instruments, expireTime: time.Now().Add(time.Hour)}
+		for _, ins := range instruments {
+			exp := time.Unix(0, ToInt64(ins.ExpTime)*int64(time.Millisecond))
+			if exp.Before(cache.expireTime) {
+				cache.expireTime = exp
+			}
+		}
+		ok.instruments[uly] = cache
+	}
+
+	for _, ins := range cache.instruments {
+		if ins.Alias == alias {
+			return ins.InstId, nil
+		}
+	}
+	return "", fmt.Errorf("not found %s contract of %s", alias, uly)
+}
+
+func (ok *OKExFuturesV5) GetInstId(currencyPair CurrencyPair, contractType string) (string, error) {
+	pair := currencyPair.ToUpper()
+	switch contractType {
+	case SWAP_CONTRACT:
+		return pair.ToSymbol("-") + "-SWAP", nil
+	case SWAP_USDT_CONTRACT:
+		return pair.AdaptUsdToUsdt().ToSymbol("-") + "-SWAP", nil
+	case THIS_WEEK_CONTRACT, NEXT_WEEK_CONTRACT, QUARTER_CONTRACT, BI_QUARTER_CONTRACT:
+		return ok.getFuturesInstId(pair.ToSymbol("-"), contractType)
+	}
+	if strings.Contains(contractType, "-") {
+		return strings.ToUpper(contractType), nil
+	}
+	return "", fmt.Errorf("unsupported contract type: %s", contractType)
+}
+
+func (ok *OKExFuturesV5) GetFutureEstimatedPrice(currencyPair CurrencyPair) (float64, error) {
+	instId, err := ok.GetInstId(currencyPair, QUARTER_CONTRACT)
+	if err != nil {
+		return 0, err
+	}
+	var response []struct {
+		SettlePx string `json:"settlePx"`
+	}
+	err = ok.DoRequestV5("GET", "/api/v5/public/estimated-price?instId="+instId, nil, &response)
+	if err != nil {
+		return 0, err
+	}
+	if len(response) == 0 {
+		return 0, fmt.Errorf("no estimated price: %s", instId)
+	}
+	return ToFloat64(response[0].SettlePx), nil
+}
+
+func (ok *OKExFuturesV5) GetFutureTicker(currencyPair CurrencyPair, contractType string) (*Ticker, error) {
+	instId, err := ok.GetInstId(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	response, err := ok.getTickerV5(instId)
+	if err != nil {
+		return nil, err
+	}
+	return &Ticker{
+		Pair: currencyPair,
+		Last: ToFloat64(response.Last),
+		High: ToFloat64(response.High24h),
+		Low:  ToFloat64(response.Low24h),
+		Sell: ToFloat64(response.AskPx),
+		Buy:  ToFloat64(response.BidPx),
+		Vol:  ToFloat64(response.Vol24h),
+		Date: uint64(ToInt64(response.Ts))}, nil
+}
+
+func (ok *OKExFuturesV5) GetFutureDepth(currencyPair CurrencyPair, contractType string, size int) (*Depth, error) {
+	instId, err := ok.GetInstId(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	dep, err := ok.getDepthV5(instId, size)
+	if err != nil {
+		return nil, err
+	}
+	dep.Pair = currencyPair
+	dep.ContractType = contractType
+	dep.ContractId = instId
+	return dep, nil
+}
+
+func (ok *OKExFuturesV5) GetFutureIndex(currencyPair CurrencyPair) (float64, error) {
+	var response []struct {
+		IdxPx string `json:"idxPx"`
+	}
+	err := ok.DoRequestV5("GET", "/api/v5/market/index-tickers?instId="+currencyPair.ToUpper().ToSymbol("-"), nil, &response)
+	if err != nil {
+		return 0, err
+	}
+	if len(response) == 0 {
+		return 0, fmt.Errorf("no index: %s", currencyPair.String())
+	}
+	return ToFloat64(response[0].IdxPx), nil
+}
+
+func (ok *OKExFuturesV5) GetFutureUserinfo(currencyPair ...CurrencyPair) (*FutureAccount, error) {
+	var ccy []string
+	for _, pair := range currencyPair {
+		ccy = append(ccy, pair.ToUpper().CurrencyA.Symbol)
+	}
+
+	balance, err := ok.getAccountBalanceV5(ccy...)
+	if err != nil {
+		return nil, err
+	}
+
+	return adaptFutureAccountV5(balance), nil
+}
+
+func adaptFutureAccountV5(balance *accountBalanceV5) *FutureAccount {
+	acc := &FutureAccount{FutureSubAccounts: make(map[Currency]FutureSubAccount, len(balance.Details))}
+	for _, itm := range balance.Details {
+		currency := NewCurrency(itm.Ccy, "")
+		acc.FutureSubAccounts[currency] = FutureSubAccount{
+			Currency:      currency,
+			AccountRights: ToFloat64(itm.Eq),
+			KeepDeposit:   ToFloat64(itm.FrozenBal),
+			ProfitUnreal:  ToFloat64(itm.Upl),
+			RiskRate:      ToFloat64(itm.MgnRatio),
+		}
+	}
+	return acc
+}
+
+//设置杠杆倍数, 逐仓双向持仓时需要指定posSide
+func (ok *OKExFuturesV5) SetLeverage(instId string, lever float64, posSide string) error {
+	param := map[string]string{
+		"instId":  instId,
+		"lever":   FloatToString(lever, 2),
+		"mgnMode": ok.tdMode(),
+	}
+	if posSide != "" {
+		param["posSide"] = posSide
+	}
+	return ok.DoRequestV5("POST", "/api/v5/account/set-leverage", param, nil)
+}
+
+func (ok *OKExFuturesV5) buildOrderParam(ord *FutureOrder, ordType string, contractType string) (OrderParamV5, error) {
+	var param OrderParamV5
+	instId, err := ok.GetInstId(ord.Currency, contractType)
+	if err != nil {
+		return param, err
+	}
+
+	instType := adaptInstTypeV5(instId)
+	posMode := PosModeNet
+	if instType != InstTypeOption {
+		if posMode, err = ok.posMode(); err != nil {
+			return param, err
+		}
+	}
+
+	side, posSide, err := adaptOpenTypeV5(ord.OType, posMode)
+	if err != nil {
+		return param, err
+	}
+
+	param = OrderParamV5{
+		InstId:  instId,
+		TdMode:  ok.tdMode(),
+		ClOrdId: ord.ClientOid,
+		Side:    side,
+		PosSide: posSide,
+		OrdType: ordType,
+		Sz:      FloatToString(ord.Amount, 8),
+	}
+	if param.ClOrdId == "" {
+		param.ClOrdId = GenerateOrderClientId(32)
+	}
+	if ordType != "market" && ordType != "optimal_limit_ioc" {
+		param.Px = FloatToString(ord.Price, 8)
+	}
+	if instType == InstTypeOption {
+		param.PosSide = ""
+	} else if posMode == PosModeNet && (ord.OType == CLOSE_BUY || ord.OType == CLOSE_SELL) {
+		param.ReduceOnly = true
+	}
+	return param, nil
+}
+
+func (ok *OKExFuturesV5) PlaceFutureOrder2(ord *FutureOrder, ordType string, contractType string) (*FutureOrder, error) {
+	param, err := ok.buildOrderParam(ord, ordType, contractType)
+	if err != nil {
+		return nil, err
+	}
+
+	var response []PlaceOrderResponseV5
+	err = ok.DoRequestV5("POST", "/api/v5/trade/order", param, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response) == 0 {
+		return nil, fmt.Errorf("take order failed")
+	}
+	if err = response[0].check(); err != nil {
+		return nil, err
+	}
+
+	ord.ClientOid = response[0].ClOrdId
+	ord.OrderID2 = response[0].OrdId
+	ord.ContractName = param.InstId
+	ord.OrderType = adaptOrderFeatureV5(ordType)
+	ord.OrderTime = time.Now().UnixNano() / int64(time.Millisecond)
+	return ord, nil
+}
+
+//v5下单不再指定杠杆倍数, leverRate被忽略, 请使用SetLeverage
+func (ok *OKExFuturesV5) PlaceFutureOrder(currencyPair CurrencyPair, contractType, price, amount string, openType, matchPrice int, leverRate float64) (string, error) {
+	ordType := "limit"
+	if matchPrice == 1 {
+		ordType = "optimal_limit_ioc"
+	}
+	ord, err := ok.PlaceFutureOrder2(&FutureOrder{
+		Currency:  currencyPair,
+		Price:     ToFloat64(price),
+		Amount:    ToFloat64(amount),
+		OType:     openType,
+		LeverRate: leverRate,
+	}, ordType, contractType)
+	if err != nil {
+		return "", err
+	}
+	return ord.OrderID2, nil
+}
+
+func (ok *OKExFuturesV5) LimitFuturesOrder(currencyPair CurrencyPair, contractType, price, amount string, openType int, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	ordType := "limit"
+	if len(opt) > 0 {
+		ordType = opt[0].String()
+	}
+	return ok.PlaceFutureOrder2(&FutureOrder{
+		Currency: currencyPair,
+		Price:    ToFloat64(price),
+		Amount:   ToFloat64(amount),
+		OType:    openType,
+	}, ordType, contractType)
+}
+
+func (ok *OKExFuturesV5) MarketFuturesOrder(currencyPair CurrencyPair, contractType, amount string, openType int) (*FutureOrder, error) {
+	return ok.PlaceFutureOrder2(&FutureOrder{
+		Currency: currencyPair,
+		Amount:   ToFloat64(amount),
+		OType:    openType,
+	}, "market", contractType)
+}
+
+func (ok *OKExFuturesV5) FutureCancelOrder(currencyPair CurrencyPair, contractType, orderId string) (bool, error) {
+	instId, err := ok.GetInstId(currencyPair, contractType)
+	if err != nil {
+		return false, err
+	}
+	param := map[string]string{
+		"instId": instId,
+		"ordId":  orderId,
+	}
+	var response []PlaceOrderResponseV5
+	err = ok.DoRequestV5("POST", "/api/v5/trade/cancel-order", param, &response)
+	if err != nil {
+		return false, err
+	}
+	if len(response) == 0 {
+		return false, fmt.Errorf("cancel order %s failed", orderId)
+	}
+	if err = response[0].check(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+type positionResponseV5 struct {
+	InstType string `json:"instType"`
+	InstId   string `json:"instId"`
+	MgnMode  string `json:"mgnMode"`
+	PosSide  string `json:"posSide"`
+	Pos      string `json:"pos"`
+	AvailPos string `json:"availPos"`
+	AvgPx    string `json:"avgPx"`
+	Upl      string `json:"upl"`
+	UplRatio string `json:"uplRatio"`
+	Lever    string `json:"lever"`
+	LiqPx    string `json:"liqPx"`
+	Margin   string `json:"margin"`
+	MgnRatio string `json:"mgnRatio"`
+	CTime    string `json:"cTime"`
+	UTime    string `json:"uTime"`
+}
+
+func (ok *OKExFuturesV5) GetFuturePosition(currencyPair CurrencyPair, contractType string) ([]FuturePosition, error) {
+	instId, err := ok.GetInstId(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+
+	var response []positionResponseV5
+	err = ok.DoRequestV5("GET", "/api/v5/account/positions?instId="+instId, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := adaptPositionsV5(response)
+	for i := range positions {
+		positions[i].Symbol = currencyPair
+		positions[i].ContractType = contractType
+	}
+	return positions, nil
+}
+
+//同一个合约的多空仓位合并为一个FuturePosition, ContractType为instId
+func adaptPositionsV5(response []positionResponseV5) []FuturePosition {
+	var positions []FuturePosition
+	index := make(map[string]int, 2)
+	for _, itm := range response {
+		key := itm.InstId + itm.MgnMode
+		i, exist := index[key]
+		if !exist {
+			positions = append(positions, FuturePosition{
+				Symbol:         adaptInstIdToPair(itm.InstId),
+				ContractType:   itm.InstId,
+				LeverRate:      ToFloat64(itm.Lever),
+				ForceLiquPrice: ToFloat64(itm.LiqPx),
+				CreateDate:     ToInt64(itm.CTime),
+			})
+			i = len(positions) - 1
+			index[key] = i
+		}
+
+		pos := &positions[i]
+		amount := ToFloat64(itm.Pos)
+		isLong := itm.PosSide == "long" || (itm.PosSide == "net" && amount > 0)
+		if isLong {
+			pos.BuyAmount = math.Abs(amount)
+			pos.BuyAvailable = math.Abs(ToFloat64(itm.AvailPos))
+			pos.BuyPriceAvg = ToFloat64(itm.AvgPx)
+			pos.BuyPriceCost = ToFloat64(itm.AvgPx)
+			pos.BuyProfit = ToFloat64(itm.Upl)
+			pos.LongPnlRatio = ToFloat64(itm.UplRatio)
+		} else {
+			pos.SellAmount = math.Abs(amount)
+			pos.SellAvailable = math.Abs(ToFloat64(itm.AvailPos))
+			pos.SellPriceAvg = ToFloat64(itm.AvgPx)
+			pos.SellPriceCost = ToFloat64(itm.AvgPx)
+			pos.SellProfit = ToFloat64(itm.Upl)
+			pos.ShortPnlRatio = ToFloat64(itm.UplRatio)
+		}
+	}
+	return positions
+}
+
+func (ok *OKExFuturesV5) adaptOrder(response orderResponseV5) FutureOrder {
+	ord := FutureOrder{
+		ClientOid:    response.ClOrdId,
+		OrderID2:     response.OrdId,
+		Price:        ToFloat64(response.Px),
+		Amount:       ToFloat64(response.Sz),
+		AvgPrice:     ToFloat64(response.AvgPx),
+		DealAmount:   ToFloat64(response.AccFillSz),
+		OrderTime:    ToInt64(response.CTime),
+		Status:       adaptOrderStateV5(response.State),
+		Currency:     adaptInstIdToPair(response.InstId),
+		OrderType:    adaptOrderFeatureV5(response.OrdType),
+		OType:        adaptPosSideToOpenType(response.Side, response.PosSide),
+		LeverRate:    ToFloat64(response.Lever),
+		Fee:          ToFloat64(response.Fee),
+		ContractName: response.InstId,
+	}
+	if ord.Status == ORDER_FINISH || ord.Status == ORDER_CANCEL {
+		ord.FinishedTime = ToInt64(response.UTime)
+	}
+	return ord
+}
+
+func (ok *OKExFuturesV5) getOrders(urlPath string, currencyPair CurrencyPair) ([]FutureOrder, error) {
+	var response []orderResponseV5
+	err := ok.DoRequestV5("GET", urlPath, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	ords := make([]FutureOrder, 0, len(response))
+	for _, itm := range response {
+		ord := ok.adaptOrder(itm)
+		ord.Currency = currencyPair
+		ords = append(ords, ord)
+	}
+	return ords, nil
+}
+
+func (ok *OKExFuturesV5) GetFutureOrders(orderIds []string, currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	ords := make([]FutureOrder, 0, len(orderIds))
+	for _, orderId := range orderIds {
+		ord, err := ok.GetFutureOrder(orderId, currencyPair, contractType)
+		if err != nil {
+			return nil, err
+		}
+		ords = append(ords, *ord)
+	}
+	return ords, nil
+}
+
+func (ok *OKExFuturesV5) GetFutureOrder(orderId string, currencyPair CurrencyPair, contractType string) (*FutureOrder, error) {
+	instId, err := ok.GetInstId(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	ords, err := ok.getOrders(fmt.Sprintf("/api/v5/trade/order?instId=%s&ordId=%s", instId, orderId), currencyPair)
+	if err != nil {
+		return nil, err
+	}
+	if len(ords) == 0 {
+		return nil, fmt.Errorf("order %s not found", orderId)
+	}
+	return &ords[0], nil
+}
+
+func (ok *OKExFuturesV5) GetUnfinishFutureOrders(currencyPair CurrencyPair, contractType string) ([]FutureOrder, error) {
+	instId, err := ok.GetInstId(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	urlPath := fmt.Sprintf("/api/v5/trade/orders-pending?instType=%s&instId=%s", adaptInstTypeV5(instId), instId)
+	return ok.getOrders(urlPath, currencyPair)
+}
+
+//最近7天的已完成订单, 可选参数: after, before, limit, ordType, state
+func (ok *OKExFuturesV5) GetFutureOrderHistory(pair CurrencyPair, contractType string, optional ...OptionalParameter) ([]FutureOrder, error) {
+	instId, err := ok.GetInstId(pair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	param := url.Values{}
+	param.Set("instType", adaptInstTypeV5(instId))
+	param.Set("instId", instId)
+	MergeOptionalParameter(&param, optional...)
+	return ok.getOrders("/api/v5/trade/orders-history?"+param.Encode(), pair)
+}
+
+//永续合约taker费率
+func (ok *OKExFuturesV5) GetFee() (float64, error) {
+	var response []struct {
+		Maker string `json:"maker"`
+		Taker string `json:"taker"`
+	}
+	err := ok.DoRequestV5("GET", "/api/v5/account/trade-fee?instType="+InstTypeSwap, nil, &response)
+	if err != nil {
+		return 0, err
+	}
+	if len(response) == 0 {
+		return 0, fmt.Errorf("trade fee response is empty")
+	}
+	return -ToFloat64(response[0].Taker), nil //v5 负数表示平台扣除的手续费
+}
+
+func (ok *OKExFuturesV5) GetContractValue(currencyPair CurrencyPair) (float64, error) {
+	var response []InstrumentV5
+	instId := currencyPair.ToUpper().ToSymbol("-") + "-SWAP"
+	err := ok.DoRequestV5("GET", fmt.Sprintf("/api/v5/public/instruments?instType=%s&instId=%s", InstTypeSwap, instId), nil, &response)
+	if err != nil {
+		return 0, err
+	}
+	if len(response) == 0 {
+		return 0, fmt.Errorf("no instrument: %s", instId)
+	}
+	return ToFloat64(response[0].CtVal), nil
+}
+
+func (ok *OKExFuturesV5) GetDeliveryTime() (int, int, int, int) {
+	return 4, 16, 0, 0 //星期五，下午4点交割
+}
+
+func (ok *OKExFuturesV5) GetKlineRecords(contractType string, currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]FutureKline, error) {
+	instId, err := ok.GetInstId(currency, contractType)
+	if err != nil {
+		return nil, err
+	}
+	response, err := ok.getCandlesV5(instId, period, size, optional...)
+	if err != nil {
+		return nil, err
+	}
+
+	klines := make([]FutureKline, 0, len(response))
+	for _, itm := range response {
+		klines = append(klines, FutureKline{
+			Kline: &Kline{
+				Pair:      currency,
+				Timestamp: ToInt64(itm[0]) / 1000,
+				Open:      ToFloat64(itm[1]),
+				High:      ToFloat64(itm[2]),
+				Low:       ToFloat64(itm[3]),
+				Close:     ToFloat64(itm[4]),
+				Vol:       ToFloat64(itm[5])},
+			Vol2: ToFloat64(itm[6]),
+		})
+	}
+	return klines, nil
+}
+
+func (ok *OKExFuturesV5) GetTrades(contractType string, currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	instId, err := ok.GetInstId(currencyPair, contractType)
+	if err != nil {
+		return nil, err
+	}
+	trades, err := ok.getTradesV5(instId, since)
+	if err != nil {
+		return nil, err
+	}
+	for i := range trades {
+		trades[i].Pair = currencyPair
+	}
+	return trades, nil
+}
@@ -0,0 +1,102 @@
+package okex
+
+import (
+	"strings"
+
+	. "github.com/lucas7788/goex"
+)
+
+//余币宝, 每个币种一个活期产品, ProductId为币种
+func (ok *OKExWalletV5) GetLendingProducts(currency Currency) ([]LendingProduct, error) {
+	uri := "/api/v5/asset/lending-rate-summary"
+	if currency != UNKNOWN {
+		uri += "?ccy=" + strings.ToUpper(currency.Symbol)
+	}
+	var response []struct {
+		Ccy     string `json:"ccy"`
+		EstRate string `json:"estRate"`
+	}
+	err := ok.DoRequestV5("GET", uri, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]LendingProduct, 0, len(response))
+	for _, itm := range response {
+		products = append(products, LendingProduct{
+			ProductId:    itm.Ccy,
+			Currency:     NewCurrency(itm.Ccy, ""),
+			Rate:         ToFloat64(itm.EstRate) * 100,
+			CanSubscribe: true,
+			CanRedeem:    true,
+		})
+	}
+	return products, nil
+}
+
+func (ok *OKExWalletV5) purchaseRedempt(side string, ccy string, amount, rate float64) error {
+	reqBody := map[string]string{
+		"ccy":  ccy,
+		"amt":  FloatToString(amount, 8),
+		"side": side,
+	}
+	if side == "purchase" {
+		reqBody["rate"] = FloatToString(rate/100, 4)
+	}
+	return ok.DoRequestV5("POST", "/api/v5/asset/purchase_redempt", reqBody, nil)
+}
+
+//申购需要设置最低出借利率, 范围1%-365%, Rate为0时使用1%
+func (ok *OKExWalletV5) SubscribeLending(param LendingParameter) (string, error) {
+	rate := param.Rate
+	if rate == 0 {
+		rate = 1
+	}
+	return "", ok.purchaseRedempt("purchase", strings.ToUpper(param.Currency.Symbol), param.Amount, rate)
+}
+
+func (ok *OKExWalletV5) RedeemLending(param LendingParameter) (string, error) {
+	ccy := strings.ToUpper(param.Currency.Symbol)
+	amount := param.Amount
+	if amount == 0 {
+		positions, err := ok.GetLendingPositions(param.Currency)
+		if err != nil {
+			return "", err
+		}
+		for _, p := range positions {
+			amount += p.Amount
+		}
+	}
+	return "", ok.purchaseRedempt("redempt", ccy, amount, 0)
+}
+
+func (ok *OKExWalletV5) GetLendingPositions(currency Currency) ([]LendingPosition, error) {
+	uri := "/api/v5/asset/saving-balance"
+	if currency != UNKNOWN {
+		uri += "?ccy=" + strings.ToUpper(currency.Symbol)
+	}
+	var response []struct {
+		Ccy      string `json:"ccy"`
+		Amt      string `json:"amt"`
+		Earnings string `json:"earnings"`
+		Rate     string `json:"rate"`
+		LoanAmt  string `json:"loanAmt"`
+	}
+	err := ok.DoRequestV5("GET", uri, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]LendingPosition, 0, len(response))
+	for _, itm := range response {
+		positions = append(positions, LendingPosition{
+			ProductId: itm.Ccy,
+			Currency:  NewCurrency(itm.Ccy, ""),
+			Amount:    ToFloat64(itm.Amt),
+			Lent:      ToFloat64(itm.LoanAmt),
+			Rate:      ToFloat64(itm.Rate) * 100,
+			Interest:  ToFloat64(itm.Earnings),
+		})
+	}
+	return positions, nil
+}
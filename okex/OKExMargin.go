@@ -4,7 +4,9 @@ import (
 	"fmt"
 	. "github.com/lucas7788/goex"
 	"errors"
+	"net/url"
 	"strings"
+	"time"
 )
 
 type OKExMargin struct {
@@ -169,10 +171,9 @@ func (ok *OKExMargin) GetUnfinishOrders(currency CurrencyPair) ([]Order, error)
 	var orders []Order
 
 	for _, info := range response {
-		fmt.Println(info)
-		//ord := ok.OKExSpot.adaptOrder(info)
-		//ord.Currency = currency
-		//orders = append(orders, *ord)
+		ord := adaptMarginOrderV3(info)
+		ord.Currency = currency
+		orders = append(orders, ord)
 	}
 
 	return orders, nil
@@ -203,18 +204,120 @@ func (ok *OKExMargin) CancelOrder(orderId string, currency CurrencyPair) (bool,
 //orderId can set client oid or orderId
 func (ok *OKExMargin) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
 	urlPath := "/api/margin/v3/orders/" + orderId + "?instrument_id=" + currency.AdaptUsdToUsdt().ToSymbol("-")
-	//param := struct {
-	//	InstrumentId string `json:"instrument_id"`
-	//}{currency.AdaptUsdToUsdt().ToLower().ToSymbol("-")}
-	//reqBody, _, _ := ok.BuildRequestBody(param)
 	var response OrderResponse
 	err := ok.OKEx.DoRequest("GET", urlPath, "", &response)
 	if err != nil {
 		return nil, err
 	}
 
-	//ordInfo := ok.OKExSpot.adaptOrder(response)
-	//ordInfo.Currency = currency
+	ordInfo := adaptMarginOrderV3(response)
+	ordInfo.Currency = currency
 
-	return nil, nil
+	return &ordInfo, nil
+}
+
+/**
+  借币记录
+  optional: status(0:未还清 1:已还清), after, before, limit
+*/
+func (ok *OKExMargin) GetLoanHistory(pair CurrencyPair, currency Currency, optional ...OptionalParameter) ([]MarginLoan, error) {
+	params := url.Values{}
+	MergeOptionalParameter(&params, optional...)
+	urlPath := fmt.Sprintf("/api/margin/v3/accounts/%s/borrowed", pair.AdaptUsdToUsdt().ToSymbol("-"))
+	if len(params) > 0 {
+		urlPath += "?" + params.Encode()
+	}
+
+	var response []struct {
+		BorrowId       string  `json:"borrow_id"`
+		Currency       string  `json:"currency"`
+		Amount         float64 `json:"amount,string"`
+		Interest       float64 `json:"interest,string"`
+		ReturnedAmount float64 `json:"returned_amount,string"`
+		CreatedAt      string  `json:"created_at"`
+	}
+	err := ok.DoRequest("GET", urlPath, "", &response)
+	if err != nil {
+		return nil, err
+	}
+
+	var loans []MarginLoan
+	for _, r := range response {
+		c := NewCurrency(r.Currency, "")
+		if currency.Symbol != "" && c != currency {
+			continue
+		}
+		status := "unrepaid"
+		if r.ReturnedAmount >= r.Amount {
+			status = "repaid"
+		}
+		createdTime, _ := time.Parse(time.RFC3339, r.CreatedAt)
+		loans = append(loans, MarginLoan{
+			LoanId:      r.BorrowId,
+			Pair:        pair,
+			Currency:    c,
+			Amount:      r.Amount,
+			Interest:    r.Interest,
+			Repaid:      r.ReturnedAmount,
+			Status:      status,
+			CreatedTime: createdTime.UnixNano() / int64(time.Millisecond),
+		})
+	}
+	return loans, nil
+}
+
+func (ok *OKExMargin) GetRiskRate(pair CurrencyPair) (float64, error) {
+	acc, err := ok.GetMarginAccount(pair)
+	if err != nil {
+		return 0, err
+	}
+	return acc.RiskRate, nil
+}
+
+//state: -2:失败 -1:撤单成功 0:等待成交 1:部分成交 2:完全成交 3:下单中 4:撤单中
+func adaptMarginOrderV3(response OrderResponse) Order {
+	ord := Order{
+		Cid:        response.ClientOid,
+		OrderID2:   response.OrderId,
+		OrderID:    ToInt(response.OrderId),
+		Price:      ToFloat64(response.Price),
+		Amount:     response.Size,
+		AvgPrice:   ToFloat64(response.PriceAvg),
+		DealAmount: ToFloat64(response.FilledSize),
+		Fee:        ToFloat64(response.Fee),
+		Type:       response.Type,
+		OrderType:  response.OrderType}
+
+	switch response.State {
+	case -2, -1:
+		ord.Status = ORDER_CANCEL
+	case 1:
+		ord.Status = ORDER_PART_FINISH
+	case 2:
+		ord.Status = ORDER_FINISH
+	case 4:
+		ord.Status = ORDER_CANCEL_ING
+	default:
+		ord.Status = ORDER_UNFINISH
+	}
+
+	switch response.Side {
+	case "buy":
+		ord.Side = BUY
+		if response.Type == "market" {
+			ord.Side = BUY_MARKET
+			ord.Price = ToFloat64(response.Notional)
+		}
+	case "sell":
+		ord.Side = SELL
+		if response.Type == "market" {
+			ord.Side = SELL_MARKET
+		}
+	}
+
+	date, err := time.Parse(time.RFC3339, response.Timestamp)
+	if err == nil {
+		ord.OrderTime = int(date.UnixNano() / int64(time.Millisecond))
+	}
+	return ord
 }
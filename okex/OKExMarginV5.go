@@ -0,0 +1,200 @@
+package okex
+
+import (
+	"fmt"
+	"net/url"
+
+	. "github.com/lucas7788/goex"
+)
+
+/**
+ * v5 币币杠杆, 实现MarginAPI
+ * TdMode为cross(全仓)时下单自动借币, 借币记录为计息记录
+ * TdMode为isolated(逐仓)时通过逐仓一键借还接口借币
+ */
+type OKExMarginV5 struct {
+	*OKExSpotV5
+}
+
+type marginPositionV5 struct {
+	InstId   string `json:"instId"`
+	Ccy      string `json:"ccy"`
+	Liab     string `json:"liab"`
+	LiabCcy  string `json:"liabCcy"`
+	Interest string `json:"interest"`
+	LiqPx    string `json:"liqPx"`
+	MgnRatio string `json:"mgnRatio"`
+}
+
+func (ok *OKExMarginV5) getMarginPositions(pair CurrencyPair) ([]marginPositionV5, error) {
+	var response []marginPositionV5
+	err := ok.DoRequestV5("GET", "/api/v5/account/positions?instType=MARGIN&instId="+ok.instId(pair), nil, &response)
+	return response, err
+}
+
+/**
+ * 杠杆账户
+ * 全仓: 返回交易账户所有币种, RiskRate为账户保证金率
+ * 逐仓: 返回交易对的base/quote币种, 负债及强平价取自杠杆持仓
+ */
+func (ok *OKExMarginV5) GetMarginAccount(pair CurrencyPair) (*MarginAccount, error) {
+	var ccy []string
+	if ok.tdMode() == TdModeIsolated {
+		pair = pair.AdaptUsdToUsdt().ToUpper()
+		ccy = []string{pair.CurrencyA.Symbol, pair.CurrencyB.Symbol}
+	}
+	balance, err := ok.getAccountBalanceV5(ccy...)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &MarginAccount{Sub: make(map[Currency]MarginSubAccount, len(balance.Details))}
+	for _, itm := range balance.Details {
+		acc.Sub[NewCurrency(itm.Ccy, "")] = MarginSubAccount{
+			Balance:     ToFloat64(itm.CashBal),
+			Frozen:      ToFloat64(itm.FrozenBal),
+			Available:   ToFloat64(itm.AvailBal),
+			CanWithdraw: ToFloat64(itm.AvailBal),
+			Loan:        ToFloat64(itm.Liab),
+			LendingFee:  ToFloat64(itm.Interest),
+		}
+	}
+
+	if ok.tdMode() != TdModeIsolated {
+		acc.RiskRate = ToFloat64(balance.MgnRatio)
+		return acc, nil
+	}
+
+	positions, err := ok.getMarginPositions(pair)
+	if err != nil {
+		return nil, err
+	}
+	for _, pos := range positions {
+		acc.LiquidationPrice = ToFloat64(pos.LiqPx)
+		acc.RiskRate = ToFloat64(pos.MgnRatio)
+		liabCcy := NewCurrency(pos.LiabCcy, "")
+		sub := acc.Sub[liabCcy]
+		//逐仓负债为负数
+		sub.Loan = -ToFloat64(pos.Liab)
+		sub.LendingFee = ToFloat64(pos.Interest)
+		acc.Sub[liabCcy] = sub
+	}
+	return acc, nil
+}
+
+func (ok *OKExMarginV5) quickMarginBorrowRepay(side string, parameter BorrowParameter) (string, error) {
+	if ok.tdMode() != TdModeIsolated {
+		//全仓杠杆下单时自动借币
+		return "", EX_ERR_NOT_SUPPORT
+	}
+	param := map[string]string{
+		"instId": ok.instId(parameter.CurrencyPair),
+		"ccy":    parameter.Currency.Symbol,
+		"side":   side,
+		"amt":    FloatToString(parameter.Amount, 8),
+	}
+	var response []struct {
+		RefId string `json:"refId"`
+	}
+	err := ok.DoRequestV5("POST", "/api/v5/account/quick-margin-borrow-repay", param, &response)
+	if err != nil {
+		return "", err
+	}
+	if len(response) == 0 {
+		return "", fmt.Errorf("%s failed", side)
+	}
+	return response[0].RefId, nil
+}
+
+//逐仓借币
+func (ok *OKExMarginV5) Borrow(parameter BorrowParameter) (borrowId string, err error) {
+	return ok.quickMarginBorrowRepay("borrow", parameter)
+}
+
+//逐仓还币, BorrowId无效
+func (ok *OKExMarginV5) Repayment(parameter RepaymentParameter) (repaymentId string, err error) {
+	return ok.quickMarginBorrowRepay("repay", parameter.BorrowParameter)
+}
+
+/**
+ * 借币记录
+ * 逐仓: 一键借还历史, optional: after, before, begin, end, limit
+ * 全仓: 计息记录, Amount为计息时的负债
+ */
+func (ok *OKExMarginV5) GetLoanHistory(pair CurrencyPair, currency Currency, optional ...OptionalParameter) ([]MarginLoan, error) {
+	params := url.Values{}
+	if currency.Symbol != "" {
+		params.Set("ccy", currency.Symbol)
+	}
+	MergeOptionalParameter(&params, optional...)
+
+	var loans []MarginLoan
+	if ok.tdMode() == TdModeIsolated {
+		params.Set("instId", ok.instId(pair))
+		params.Set("side", "borrow")
+		var response []struct {
+			InstId      string `json:"instId"`
+			Ccy         string `json:"ccy"`
+			Amt         string `json:"amt"`
+			AccBorrowed string `json:"accBorrowed"`
+			RefId       string `json:"refId"`
+			Ts          string `json:"ts"`
+		}
+		err := ok.DoRequestV5("GET", "/api/v5/account/quick-margin-borrow-repay-history?"+params.Encode(), nil, &response)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range response {
+			loans = append(loans, MarginLoan{
+				LoanId:      r.RefId,
+				Pair:        pair,
+				Currency:    NewCurrency(r.Ccy, ""),
+				Amount:      ToFloat64(r.Amt),
+				Status:      "borrow",
+				CreatedTime: ToInt64(r.Ts),
+			})
+		}
+		return loans, nil
+	}
+
+	params.Set("mgnMode", TdModeCross)
+	var response []struct {
+		InstId   string `json:"instId"`
+		Ccy      string `json:"ccy"`
+		Interest string `json:"interest"`
+		Liab     string `json:"liab"`
+		Ts       string `json:"ts"`
+	}
+	err := ok.DoRequestV5("GET", "/api/v5/account/interest-accrued?"+params.Encode(), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range response {
+		loans = append(loans, MarginLoan{
+			Pair:        UNKNOWN_PAIR,
+			Currency:    NewCurrency(r.Ccy, ""),
+			Amount:      ToFloat64(r.Liab),
+			Interest:    ToFloat64(r.Interest),
+			Status:      "accrued",
+			CreatedTime: ToInt64(r.Ts),
+		})
+	}
+	return loans, nil
+}
+
+func (ok *OKExMarginV5) GetRiskRate(pair CurrencyPair) (float64, error) {
+	acc, err := ok.GetMarginAccount(pair)
+	if err != nil {
+		return 0, err
+	}
+	return acc.RiskRate, nil
+}
+
+//杠杆下单, 市价单ord.Side为BUY_MARKET/SELL_MARKET
+func (ok *OKExMarginV5) PlaceOrder(ord *Order) (*Order, error) {
+	ty := adaptOrderFeatureToOrdTypeV5(ord.OrderType)
+	if ord.Side == BUY_MARKET || ord.Side == SELL_MARKET {
+		ty = "market"
+	}
+	return ok.OKExSpotV5.PlaceOrder(ty, ord)
+}
@@ -0,0 +1,208 @@
+package okex
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/lucas7788/goex"
+)
+
+/**
+ * v5 期权, 下单和查询订单复用OKExFuturesV5
+ * 期权只支持单向持仓, 买入和卖出不区分开平
+ * 希腊字母使用okex返回的Black-Scholes模型的值(deltaBS等)
+ */
+type OKExOptionsV5 struct {
+	*OKExFuturesV5
+}
+
+func NewOKExOptionsV5(okex *OKEx) *OKExOptionsV5 {
+	return &OKExOptionsV5{OKExFuturesV5: okex.OKExFuturesV5}
+}
+
+//BTC_USD => BTC-USD
+func optionUly(underlying CurrencyPair) string {
+	return underlying.ToUpper().ToSymbol("-")
+}
+
+//BTC-USD-210625-50000-C => BTC-USD
+func optionUlyOfInstId(instId string) string {
+	parts := strings.Split(instId, "-")
+	if len(parts) < 2 {
+		return instId
+	}
+	return parts[0] + "-" + parts[1]
+}
+
+func (ok *OKExOptionsV5) GetOptionInstruments(underlying CurrencyPair) ([]OptionInstrument, error) {
+	instruments, err := ok.GetInstruments(InstTypeOption, optionUly(underlying))
+	if err != nil {
+		return nil, err
+	}
+
+	options := make([]OptionInstrument, 0, len(instruments))
+	for _, ins := range instruments {
+		optType := OPTION_CALL
+		if ins.OptType == "P" {
+			optType = OPTION_PUT
+		}
+		ctVal := ToFloat64(ins.CtVal)
+		if ctMult := ToFloat64(ins.CtMult); ctMult > 0 {
+			ctVal *= ctMult
+		}
+		options = append(options, OptionInstrument{
+			InstrumentId: ins.InstId,
+			Underlying:   adaptInstIdToPair(ins.InstId),
+			Strike:       ToFloat64(ins.Stk),
+			OptionType:   optType,
+			Expiry:       time.Unix(0, ToInt64(ins.ExpTime)*int64(time.Millisecond)),
+			ContractVal:  ctVal,
+			TickSize:     ToFloat64(ins.TickSz),
+			MinSize:      ToFloat64(ins.MinSz),
+		})
+	}
+	return options, nil
+}
+
+//行情, 标记价格和期权定价分别来自ticker, mark-price和opt-summary接口
+func (ok *OKExOptionsV5) GetOptionTicker(instrumentId string) (*OptionTicker, error) {
+	instId := strings.ToUpper(instrumentId)
+
+	var tickers []struct {
+		Last  string `json:"last"`
+		BidPx string `json:"bidPx"`
+		AskPx string `json:"askPx"`
+		Ts    string `json:"ts"`
+	}
+	err := ok.DoRequestV5("GET", "/api/v5/market/ticker?instId="+instId, nil, &tickers)
+	if err != nil {
+		return nil, err
+	}
+	if len(tickers) == 0 {
+		return nil, fmt.Errorf("no ticker: %s", instId)
+	}
+
+	ticker := &OptionTicker{
+		InstrumentId: instId,
+		Last:         ToFloat64(tickers[0].Last),
+		Buy:          ToFloat64(tickers[0].BidPx),
+		Sell:         ToFloat64(tickers[0].AskPx),
+		Date:         ToInt64(tickers[0].Ts),
+	}
+
+	var markPrices []struct {
+		MarkPx string `json:"markPx"`
+	}
+	param := url.Values{}
+	param.Set("instType", InstTypeOption)
+	param.Set("instId", instId)
+	err = ok.DoRequestV5("GET", "/api/v5/public/mark-price?"+param.Encode(), nil, &markPrices)
+	if err != nil {
+		return nil, err
+	}
+	if len(markPrices) > 0 {
+		ticker.MarkPrice = ToFloat64(markPrices[0].MarkPx)
+	}
+
+	var summaries []struct {
+		InstId  string `json:"instId"`
+		DeltaBS string `json:"deltaBS"`
+		GammaBS string `json:"gammaBS"`
+		ThetaBS string `json:"thetaBS"`
+		VegaBS  string `json:"vegaBS"`
+		MarkVol string `json:"markVol"`
+		BidVol  string `json:"bidVol"`
+		AskVol  string `json:"askVol"`
+		FwdPx   string `json:"fwdPx"`
+	}
+	err = ok.DoRequestV5("GET", "/api/v5/public/opt-summary?uly="+optionUlyOfInstId(instId), nil, &summaries)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range summaries {
+		if s.InstId != instId {
+			continue
+		}
+		ticker.MarkIV = ToFloat64(s.MarkVol)
+		ticker.BidIV = ToFloat64(s.BidVol)
+		ticker.AskIV = ToFloat64(s.AskVol)
+		ticker.UnderlyingPrice = ToFloat64(s.FwdPx)
+		ticker.Greeks = Greeks{
+			Delta: ToFloat64(s.DeltaBS),
+			Gamma: ToFloat64(s.GammaBS),
+			Theta: ToFloat64(s.ThetaBS),
+			Vega:  ToFloat64(s.VegaBS),
+		}
+		break
+	}
+	return ticker, nil
+}
+
+func (ok *OKExOptionsV5) LimitOptionOrder(instrumentId string, side TradeSide, price, amount string, opt ...LimitOrderOptionalParameter) (*FutureOrder, error) {
+	openType := OPEN_BUY
+	if side == SELL {
+		openType = OPEN_SELL
+	}
+	instId := strings.ToUpper(instrumentId)
+	return ok.LimitFuturesOrder(adaptInstIdToPair(instId), instId, price, amount, openType, opt...)
+}
+
+func (ok *OKExOptionsV5) CancelOptionOrder(instrumentId, orderId string) error {
+	instId := strings.ToUpper(instrumentId)
+	_, err := ok.FutureCancelOrder(adaptInstIdToPair(instId), instId, orderId)
+	return err
+}
+
+func (ok *OKExOptionsV5) GetOptionOrder(instrumentId, orderId string) (*FutureOrder, error) {
+	instId := strings.ToUpper(instrumentId)
+	return ok.GetFutureOrder(orderId, adaptInstIdToPair(instId), instId)
+}
+
+func (ok *OKExOptionsV5) GetUnfinishOptionOrders(underlying CurrencyPair) ([]FutureOrder, error) {
+	param := url.Values{}
+	param.Set("instType", InstTypeOption)
+	param.Set("uly", optionUly(underlying))
+	return ok.getOrders("/api/v5/trade/orders-pending?"+param.Encode(), underlying)
+}
+
+func (ok *OKExOptionsV5) GetOptionPositions(underlying CurrencyPair) ([]OptionPosition, error) {
+	var response []struct {
+		InstId  string `json:"instId"`
+		Pos     string `json:"pos"`
+		AvgPx   string `json:"avgPx"`
+		MarkPx  string `json:"markPx"`
+		Upl     string `json:"upl"`
+		DeltaBS string `json:"deltaBS"`
+		GammaBS string `json:"gammaBS"`
+		ThetaBS string `json:"thetaBS"`
+		VegaBS  string `json:"vegaBS"`
+	}
+	err := ok.DoRequestV5("GET", "/api/v5/account/positions?instType="+InstTypeOption, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	uly := optionUly(underlying)
+	var positions []OptionPosition
+	for _, itm := range response {
+		if optionUlyOfInstId(itm.InstId) != uly {
+			continue
+		}
+		positions = append(positions, OptionPosition{
+			InstrumentId:  itm.InstId,
+			Amount:        ToFloat64(itm.Pos),
+			AvgPrice:      ToFloat64(itm.AvgPx),
+			MarkPrice:     ToFloat64(itm.MarkPx),
+			UnrealizedPnl: ToFloat64(itm.Upl),
+			Greeks: Greeks{
+				Delta: ToFloat64(itm.DeltaBS),
+				Gamma: ToFloat64(itm.GammaBS),
+				Theta: ToFloat64(itm.ThetaBS),
+				Vega:  ToFloat64(itm.VegaBS),
+			},
+		})
+	}
+	return positions, nil
+}
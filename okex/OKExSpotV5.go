@@ -2,16 +2,21 @@ package okex
 
 import (
 	"fmt"
-	"github.com/go-openapi/errors"
-	. "github.com/lucas7788/goex"
+	"net/url"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
+
+	. "github.com/lucas7788/goex"
 )
 
+/**
+ * v5 币币/杠杆
+ * TdMode为空或cash时为币币交易, cross/isolated时为币币杠杆交易
+ */
 type OKExSpotV5 struct {
 	*OKEx
+	TdMode string
 }
 
 //OrdType 订单类型
@@ -24,16 +29,16 @@ type OKExSpotV5 struct {
 type OrderParamV5 struct {
 	InstId     string `json:"instId"` //产品ID
 	TdMode     string `json:"tdMode"`
-	Ccy        string `json:"ccy"`
-	ClOrdId    string `json:"clOrdId"`
-	Tag        string `json:"tag"`
+	Ccy        string `json:"ccy,omitempty"`
+	ClOrdId    string `json:"clOrdId,omitempty"`
+	Tag        string `json:"tag,omitempty"`
 	Side       string `json:"side"` //订单方向 buy：买 sell：卖
-	PosSide    string `json:"posSide"`
-	OrdType    string `json:"ordType"` //
-	Sz         string `json:"sz"`      //委托数量
-	Px         string `json:"px"`      //委托价格，
-	ReduceOnly bool   `json:"reduceOnly"`
-	TgtCcy     string `json:"tgtCcy"` //委托数量的类型 base_ccy：交易货币 ；quote_ccy：计价货币 仅适用于币币订单
+	PosSide    string `json:"posSide,omitempty"`
+	OrdType    string `json:"ordType"`      //
+	Sz         string `json:"sz"`           //委托数量
+	Px         string `json:"px,omitempty"` //委托价格，
+	ReduceOnly bool   `json:"reduceOnly,omitempty"`
+	TgtCcy     string `json:"tgtCcy,omitempty"` //委托数量的类型 base_ccy：交易货币 ；quote_ccy：计价货币 仅适用于币币订单
 }
 
 type PlaceOrderResponseV5 struct {
@@ -44,71 +49,137 @@ type PlaceOrderResponseV5 struct {
 	SMsg    string `json:"sMsg"`
 }
 
-func (ok *OKExSpotV5) PlaceOrder(ty string, ord *Order) (*Order, error) {
-	urlPath := "/api/v5/trade/order"
+func (r PlaceOrderResponseV5) check() error {
+	if r.SCode != "" && r.SCode != "0" {
+		return fmt.Errorf("sCode: %s, sMsg: %s", r.SCode, r.SMsg)
+	}
+	return nil
+}
+
+func (ok *OKExSpotV5) tdMode() string {
+	if ok.TdMode == "" {
+		return TdModeCash
+	}
+	return ok.TdMode
+}
+
+func (ok *OKExSpotV5) instType() string {
+	if ok.tdMode() == TdModeCash {
+		return InstTypeSpot
+	}
+	return InstTypeMargin
+}
+
+func (ok *OKExSpotV5) instId(currency CurrencyPair) string {
+	return currency.AdaptUsdToUsdt().ToUpper().ToSymbol("-")
+}
+
+func (ok *OKExSpotV5) buildOrderParam(ty string, ord *Order) (OrderParamV5, error) {
 	param := OrderParamV5{
-		ClOrdId: GenerateOrderClientId(32),
-		InstId:  ord.Currency.AdaptUsdToUsdt().ToUpper().ToSymbol("-"),
+		ClOrdId: ord.Cid,
+		InstId:  ok.instId(ord.Currency),
+		TdMode:  ok.tdMode(),
+		OrdType: ty,
 	}
+	if param.ClOrdId == "" {
+		param.ClOrdId = GenerateOrderClientId(32)
+	}
+
+	//全仓杠杆需要指定保证金币种
+	if param.TdMode == TdModeCross {
+		param.Ccy = ord.Currency.AdaptUsdToUsdt().ToUpper().CurrencyB.Symbol
+	}
+
 	switch ord.Side {
 	case BUY, SELL:
 		param.Side = strings.ToLower(ord.Side.String())
-		param.Px = FloatToString(ord.Price, 5)
-		param.Sz = FloatToString(ord.Amount, 5)
+		param.Px = FloatToString(ord.Price, 8)
+		param.Sz = FloatToString(ord.Amount, 8)
 	case SELL_MARKET:
-		param.TdMode = "cash"
 		param.Side = "sell"
-		param.Sz = FloatToString(ord.Amount, 5)
+		param.Sz = FloatToString(ord.Amount, 8)
 	case BUY_MARKET:
-		param.TdMode = "cash"
 		param.Side = "buy"
-		param.Sz = FloatToString(ord.Amount, 5)
+		if ord.Price > 0 { //与v3一致, 市价买单price为买入金额
+			param.TgtCcy = "quote_ccy"
+			param.Sz = FloatToString(ord.Price, 8)
+		} else {
+			param.TgtCcy = "base_ccy"
+			param.Sz = FloatToString(ord.Amount, 8)
+		}
 	default:
-		panic("not support")
+		return param, fmt.Errorf("unsupported order side: %s", ord.Side)
 	}
+	return param, nil
+}
 
-	switch ty {
-	case "limit":
-		param.OrdType = "limit"
-		param.TdMode = "cash"
-	case "market":
-		param.OrdType = "market"
-	case "post_only":
-		param.OrdType = "post_only"
-	case "fok":
-		param.OrdType = "fok"
-	case "ioc":
-		param.OrdType = "ioc"
-	}
-
-	param.Sz = FloatToString(ord.Amount, 5)
-
-	jsonStr, _, _ := ok.OKEx.BuildRequestBody(param)
-	fmt.Println("jsonStr:", jsonStr)
-	var response OKRes
-	err := ok.OKEx.DoRequest("POST", urlPath, jsonStr, &response)
+func (ok *OKExSpotV5) PlaceOrder(ty string, ord *Order) (*Order, error) {
+	param, err := ok.buildOrderParam(ty, ord)
 	if err != nil {
 		return nil, err
 	}
 
-	if response.Code != "0" {
-		fmt.Println("response.Data: %v", response.Data)
-		return nil, errors.New(int32(ToInt(response.Code)), response.Msg)
+	var response []PlaceOrderResponseV5
+	err = ok.DoRequestV5("POST", "/api/v5/trade/order", param, &response)
+	if err != nil {
+		return nil, err
 	}
-
-	res := response.Data.([]interface{})
-	if len(res) == 0 {
+	if len(response) == 0 {
 		return nil, fmt.Errorf("take order failed")
 	}
-	r := res[0].(map[string]interface{})
-	if r["sCode"] != "0" {
-		return nil, fmt.Errorf("take order failed, erroCode: %s, errorMsg:%s", r["sCode"], r["sMsg"])
+	if err = response[0].check(); err != nil {
+		return nil, err
 	}
-	ord.Cid = r["clOrdId"].(string)
-	ord.OrderID2 = r["ordId"].(string)
+
+	ord.Cid = response[0].ClOrdId
+	ord.OrderID2 = response[0].OrdId
+	ord.Type = ty
+	ord.OrderType = adaptOrderFeatureV5(ty)
 	return ord, nil
 }
 
+func adaptOrderFeatureToOrdTypeV5(orderType int) string {
+	switch orderType {
+	case ORDER_FEATURE_POST_ONLY:
+		return "post_only"
+	case ORDER_FEATURE_FOK:
+		return "fok"
+	case ORDER_FEATURE_IOC:
+		return "ioc"
+	}
+	return "limit"
+}
+
+/**
+ * 批量下限价单,最多20个; Cid为空时自动生成
+ * 返回结果与orders一一对应,需检查每一项的SCode
+ */
+func (ok *OKExSpotV5) BatchPlaceOrders(orders []Order) ([]PlaceOrderResponseV5, error) {
+	param := make([]OrderParamV5, 0, len(orders))
+	for _, ord := range orders {
+		p := OrderParamV5{
+			InstId:  ok.instId(ord.Currency),
+			TdMode:  ok.tdMode(),
+			ClOrdId: ord.Cid,
+			Side:    strings.ToLower(ord.Side.String()),
+			OrdType: adaptOrderFeatureToOrdTypeV5(ord.OrderType),
+			Px:      FloatToString(ord.Price, 8),
+			Sz:      FloatToString(ord.Amount, 8),
+		}
+		if p.ClOrdId == "" {
+			p.ClOrdId = GenerateOrderClientId(32)
+		}
+		if p.TdMode == TdModeCross {
+			p.Ccy = ord.Currency.AdaptUsdToUsdt().ToUpper().CurrencyB.Symbol
+		}
+		param = append(param, p)
+	}
+
+	var response []PlaceOrderResponseV5
+	err := ok.DoRequestV5("POST", "/api/v5/trade/batch-orders", param, &response)
+	return response, err
+}
+
 func (ok *OKExSpotV5) MarketBuy(amount, price string, currency CurrencyPair) (*Order, error) {
 	return ok.PlaceOrder("market", &Order{
 		Price:    ToFloat64(price),
@@ -153,79 +224,354 @@ func (ok *OKExSpotV5) LimitBuy(amount, price string, currency CurrencyPair, opt
 	})
 }
 
+//orderId为ordId
+func (ok *OKExSpotV5) CancelOrder(orderId string, currency CurrencyPair) (bool, error) {
+	param := map[string]string{
+		"instId": ok.instId(currency),
+		"ordId":  orderId,
+	}
+	var response []PlaceOrderResponseV5
+	err := ok.DoRequestV5("POST", "/api/v5/trade/cancel-order", param, &response)
+	if err != nil {
+		return false, err
+	}
+	if len(response) == 0 {
+		return false, fmt.Errorf("cancel order %s failed", orderId)
+	}
+	if err = response[0].check(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (ok *OKExSpotV5) adaptOrder(response orderResponseV5) Order {
+	ord := Order{
+		Cid:        response.ClOrdId,
+		OrderID2:   response.OrdId,
+		Price:      ToFloat64(response.Px),
+		Amount:     ToFloat64(response.Sz),
+		AvgPrice:   ToFloat64(response.AvgPx),
+		DealAmount: ToFloat64(response.AccFillSz),
+		Status:     adaptOrderStateV5(response.State),
+		Fee:        ToFloat64(response.Fee),
+		Currency:   adaptInstIdToPair(response.InstId),
+		Type:       response.OrdType,
+		OrderType:  adaptOrderFeatureV5(response.OrdType),
+		OrderTime:  ToInt(response.CTime),
+	}
+
+	switch response.Side {
+	case "buy":
+		ord.Side = BUY
+		if response.OrdType == "market" {
+			ord.Side = BUY_MARKET
+		}
+	case "sell":
+		ord.Side = SELL
+		if response.OrdType == "market" {
+			ord.Side = SELL_MARKET
+		}
+	}
+
+	if ord.Status == ORDER_FINISH || ord.Status == ORDER_CANCEL {
+		ord.FinishedTime = ToInt64(response.UTime)
+	}
+
+	return ord
+}
+
+func (ok *OKExSpotV5) getOrders(urlPath string, currency CurrencyPair) ([]Order, error) {
+	var response []orderResponseV5
+	err := ok.DoRequestV5("GET", urlPath, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	ords := make([]Order, 0, len(response))
+	for _, itm := range response {
+		ord := ok.adaptOrder(itm)
+		ord.Currency = currency
+		ords = append(ords, ord)
+	}
+	return ords, nil
+}
+
+func (ok *OKExSpotV5) GetOneOrder(orderId string, currency CurrencyPair) (*Order, error) {
+	urlPath := fmt.Sprintf("/api/v5/trade/order?instId=%s&ordId=%s", ok.instId(currency), orderId)
+	ords, err := ok.getOrders(urlPath, currency)
+	if err != nil {
+		return nil, err
+	}
+	if len(ords) == 0 {
+		return nil, fmt.Errorf("order %s not found", orderId)
+	}
+	return &ords[0], nil
+}
+
+func (ok *OKExSpotV5) GetUnfinishOrders(currency CurrencyPair) ([]Order, error) {
+	urlPath := fmt.Sprintf("/api/v5/trade/orders-pending?instType=%s&instId=%s", ok.instType(), ok.instId(currency))
+	return ok.getOrders(urlPath, currency)
+}
+
+//最近7天的已完成订单, 可选参数: after, before, limit, ordType, state
+func (ok *OKExSpotV5) GetOrderHistorys(currency CurrencyPair, optional ...OptionalParameter) ([]Order, error) {
+	param := url.Values{}
+	param.Set("instType", ok.instType())
+	param.Set("instId", ok.instId(currency))
+	MergeOptionalParameter(&param, optional...)
+	return ok.getOrders("/api/v5/trade/orders-history?"+param.Encode(), currency)
+}
+
+type accountBalanceV5 struct {
+	TotalEq  string `json:"totalEq"`
+	AdjEq    string `json:"adjEq"`
+	MgnRatio string `json:"mgnRatio"`
+	Details  []struct {
+		Ccy       string `json:"ccy"`
+		Eq        string `json:"eq"`
+		CashBal   string `json:"cashBal"`
+		AvailBal  string `json:"availBal"`
+		AvailEq   string `json:"availEq"`
+		FrozenBal string `json:"frozenBal"`
+		Liab      string `json:"liab"`
+		Interest  string `json:"interest"`
+		Upl       string `json:"upl"`
+		MgnRatio  string `json:"mgnRatio"`
+		Imr       string `json:"imr"`
+	} `json:"details"`
+}
+
+func (ok *OKEx) getAccountBalanceV5(ccy ...string) (*accountBalanceV5, error) {
+	urlPath := "/api/v5/account/balance"
+	if len(ccy) > 0 {
+		urlPath += "?ccy=" + strings.Join(ccy, ",")
+	}
+	var response []accountBalanceV5
+	err := ok.DoRequestV5("GET", urlPath, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response) == 0 {
+		return nil, fmt.Errorf("account balance response is empty")
+	}
+	return &response[0], nil
+}
+
+//交易账户资产
+func (ok *OKExSpotV5) GetAccount() (*Account, error) {
+	balance, err := ok.getAccountBalanceV5()
+	if err != nil {
+		return nil, err
+	}
+	return adaptAccountV5(balance), nil
+}
+
+func adaptAccountV5(balance *accountBalanceV5) *Account {
+	acc := &Account{
+		Exchange:    OKEX,
+		Asset:       ToFloat64(balance.TotalEq),
+		NetAsset:    ToFloat64(balance.AdjEq),
+		SubAccounts: make(map[Currency]SubAccount, len(balance.Details)),
+	}
+	for _, itm := range balance.Details {
+		currency := NewCurrency(itm.Ccy, "")
+		acc.SubAccounts[currency] = SubAccount{
+			Currency:     currency,
+			Amount:       ToFloat64(itm.AvailBal),
+			ForzenAmount: ToFloat64(itm.FrozenBal),
+			LoanAmount:   ToFloat64(itm.Liab),
+		}
+	}
+	return acc
+}
+
+type tickerResponseV5 struct {
+	InstId    string `json:"instId"`
+	Last      string `json:"last"`
+	AskPx     string `json:"askPx"`
+	BidPx     string `json:"bidPx"`
+	High24h   string `json:"high24h"`
+	Low24h    string `json:"low24h"`
+	Vol24h    string `json:"vol24h"`
+	VolCcy24h string `json:"volCcy24h"`
+	Ts        string `json:"ts"`
+}
+
+func (ok *OKEx) getTickerV5(instId string) (*tickerResponseV5, error) {
+	var response []tickerResponseV5
+	err := ok.DoRequestV5("GET", "/api/v5/market/ticker?instId="+instId, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response) == 0 {
+		return nil, fmt.Errorf("no ticker: %s", instId)
+	}
+	return &response[0], nil
+}
+
 func (ok *OKExSpotV5) GetTicker(currency CurrencyPair) (*Ticker, error) {
-	urlPath := fmt.Sprintf("/api/v5/market/ticker?instId=%s", currency.AdaptUsdToUsdt().ToSymbol("-"))
-	var responses OKRes
-	err := ok.OKEx.DoRequest("GET", urlPath, "", &responses)
+	response, err := ok.getTickerV5(ok.instId(currency))
 	if err != nil {
 		return nil, err
 	}
-	if responses.Code != "0" {
-		return nil, fmt.Errorf("responses.Code: %s", responses.Code)
-	}
-	dat := responses.Data.([]interface{})
-	if len(dat) < 1 {
-		return nil, fmt.Errorf("no pair: %s", currency.String())
-	}
-	response := dat[0].(map[string]interface{})
-	date, _ := time.Parse(time.RFC3339, response["ts"].(string))
-	last, _ := strconv.ParseFloat(response["last"].(string), 64)
-	high24h, _ := strconv.ParseFloat(response["high24h"].(string), 64)
-	low24h, _ := strconv.ParseFloat(response["low24h"].(string), 64)
-	sell, _ := strconv.ParseFloat(response["askPx"].(string), 64)
-	buy, _ := strconv.ParseFloat(response["bidPx"].(string), 64)
-	vol, _ := strconv.ParseFloat(response["volCcy24h"].(string), 64)
 	return &Ticker{
 		Pair: currency,
-		Last: last,
-		High: high24h,
-		Low:  low24h,
-		Sell: sell,
-		Buy:  buy,
-		Vol:  vol,
-		Date: uint64(time.Duration(date.UnixNano() / int64(time.Millisecond)))}, nil
+		Last: ToFloat64(response.Last),
+		High: ToFloat64(response.High24h),
+		Low:  ToFloat64(response.Low24h),
+		Sell: ToFloat64(response.AskPx),
+		Buy:  ToFloat64(response.BidPx),
+		Vol:  ToFloat64(response.Vol24h),
+		Date: uint64(ToInt64(response.Ts))}, nil
+}
+
+func (ok *OKEx) getDepthV5(instId string, size int) (*Depth, error) {
+	urlPath := fmt.Sprintf("/api/v5/market/books?instId=%s&sz=%d", instId, size)
+	var response []struct {
+		Asks [][]string `json:"asks"`
+		Bids [][]string `json:"bids"`
+		Ts   string     `json:"ts"`
+	}
+	err := ok.DoRequestV5("GET", urlPath, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response) == 0 {
+		return nil, fmt.Errorf("no depth: %s", instId)
+	}
+
+	dep := new(Depth)
+	dep.UTime = time.Unix(0, ToInt64(response[0].Ts)*int64(time.Millisecond))
+	for _, itm := range response[0].Asks {
+		dep.AskList = append(dep.AskList, DepthRecord{Price: ToFloat64(itm[0]), Amount: ToFloat64(itm[1])})
+	}
+	for _, itm := range response[0].Bids {
+		dep.BidList = append(dep.BidList, DepthRecord{Price: ToFloat64(itm[0]), Amount: ToFloat64(itm[1])})
+	}
+	sort.Sort(sort.Reverse(dep.AskList))
+	return dep, nil
 }
 
 func (ok *OKExSpotV5) GetDepth(size int, currency CurrencyPair) (*Depth, error) {
+	dep, err := ok.getDepthV5(ok.instId(currency), size)
+	if err != nil {
+		return nil, err
+	}
+	dep.Pair = currency
+	return dep, nil
+}
 
-	urlPath := fmt.Sprintf("/api/v5/market/books?instId=%s&sz=%d", currency.AdaptUsdToUsdt().ToSymbol("-"), size)
+//返回 [ts,o,h,l,c,vol,volCcy], 按时间倒序
+func (ok *OKEx) getCandlesV5(instId string, period KlinePeriod, size int, optional ...OptionalParameter) ([][]string, error) {
+	bar := adaptKLinePeriodV5(period)
+	if bar == "" {
+		return nil, fmt.Errorf("kline period parameter is error")
+	}
+
+	param := url.Values{}
+	param.Set("instId", instId)
+	param.Set("bar", bar)
+	if size > 0 {
+		param.Set("limit", fmt.Sprint(size))
+	}
+	MergeOptionalParameter(&param, optional...)
 
-	//var response struct {
-	//	Asks      [][]interface{} `json:"asks"`
-	//	Bids      [][]interface{} `json:"bids"`
-	//	Timestamp string          `json:"timestamp"`
-	//}
-	var response OKRes
-	err := ok.OKEx.DoRequest("GET", urlPath, "", &response)
+	var response [][]string
+	err := ok.DoRequestV5("GET", "/api/v5/market/candles?"+param.Encode(), nil, &response)
+	return response, err
+}
+
+func (ok *OKExSpotV5) GetKlineRecords(currency CurrencyPair, period KlinePeriod, size int, optional ...OptionalParameter) ([]Kline, error) {
+	response, err := ok.getCandlesV5(ok.instId(currency), period, size, optional...)
 	if err != nil {
 		return nil, err
 	}
-	if response.Code != "0" {
-		return nil, fmt.Errorf("responses.Code: %s", response.Code)
+
+	klines := make([]Kline, 0, len(response))
+	for _, itm := range response {
+		klines = append(klines, Kline{
+			Pair:      currency,
+			Timestamp: ToInt64(itm[0]) / 1000,
+			Open:      ToFloat64(itm[1]),
+			High:      ToFloat64(itm[2]),
+			Low:       ToFloat64(itm[3]),
+			Close:     ToFloat64(itm[4]),
+			Vol:       ToFloat64(itm[5])})
 	}
-	dep := new(Depth)
-	dep.Pair = currency
-	r := response.Data.([]interface{})
-	res := r[0].(map[string]interface{})
-	dep.UTime, _ = time.Parse(time.RFC3339, res["ts"].(string))
-	as := res["asks"].([]interface{})
-	for _, itm := range as {
-		i := itm.([]interface{})
-		dep.AskList = append(dep.AskList, DepthRecord{
-			Price:  ToFloat64(i[0].(string)),
-			Amount: ToFloat64(i[1].(string)),
-		})
+	return klines, nil
+}
+
+//v5只提供最近的成交记录, since作为条数(最大500)
+func (ok *OKEx) getTradesV5(instId string, limit int64) ([]Trade, error) {
+	urlPath := "/api/v5/market/trades?instId=" + instId
+	if limit > 0 {
+		urlPath += fmt.Sprintf("&limit=%d", limit)
+	}
+	var response []struct {
+		TradeId string `json:"tradeId"`
+		Px      string `json:"px"`
+		Sz      string `json:"sz"`
+		Side    string `json:"side"`
+		Ts      string `json:"ts"`
 	}
-	bs := res["bids"].([]interface{})
-	for _, itm := range bs {
-		i := itm.([]interface{})
-		dep.BidList = append(dep.BidList, DepthRecord{
-			Price:  ToFloat64(i[0]),
-			Amount: ToFloat64(i[1]),
+	err := ok.DoRequestV5("GET", urlPath, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	trades := make([]Trade, 0, len(response))
+	for _, itm := range response {
+		trades = append(trades, Trade{
+			Tid:    ToInt64(itm.TradeId),
+			Type:   AdaptTradeSide(itm.Side),
+			Amount: ToFloat64(itm.Sz),
+			Price:  ToFloat64(itm.Px),
+			Date:   ToInt64(itm.Ts),
 		})
 	}
+	return trades, nil
+}
 
-	sort.Sort(sort.Reverse(dep.AskList))
+func (ok *OKExSpotV5) GetTrades(currencyPair CurrencyPair, since int64) ([]Trade, error) {
+	trades, err := ok.getTradesV5(ok.instId(currencyPair), since)
+	if err != nil {
+		return nil, err
+	}
+	for i := range trades {
+		trades[i].Pair = currencyPair
+	}
+	return trades, nil
+}
 
-	return dep, nil
+func (ok *OKExSpotV5) GetCurrenciesPrecision() ([]OKExSpotSymbol, error) {
+	var response []InstrumentV5
+	err := ok.DoRequestV5("GET", "/api/v5/public/instruments?instType="+InstTypeSpot, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	precision := func(sz string) float64 {
+		pres := strings.Split(sz, ".")
+		if len(pres) == 1 {
+			return 0
+		}
+		return float64(len(strings.TrimRight(pres[1], "0")))
+	}
+
+	symbols := make([]OKExSpotSymbol, 0, len(response))
+	for _, v := range response {
+		symbols = append(symbols, OKExSpotSymbol{
+			BaseCurrency:    v.BaseCcy,
+			QuoteCurrency:   v.QuoteCcy,
+			Symbol:          v.InstId,
+			MinAmount:       ToFloat64(v.MinSz),
+			PricePrecision:  precision(v.TickSz),
+			AmountPrecision: precision(v.LotSz),
+		})
+	}
+	return symbols, nil
+}
+
+func (ok *OKExSpotV5) GetExchangeName() string {
+	return OKEX
 }
@@ -0,0 +1,117 @@
+package okex
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	. "github.com/lucas7788/goex"
+)
+
+//子账户列表, SubAccount为子账户名
+func (ok *OKExWalletV5) GetSubAccounts() ([]SubAccountUser, error) {
+	var response []struct {
+		Enable  bool   `json:"enable"`
+		SubAcct string `json:"subAcct"`
+		Label   string `json:"label"`
+		Ts      string `json:"ts"`
+	}
+	err := ok.DoRequestV5("GET", "/api/v5/users/subaccount/list", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]SubAccountUser, 0, len(response))
+	for _, itm := range response {
+		users = append(users, SubAccountUser{
+			SubAccount:  itm.SubAcct,
+			Name:        itm.SubAcct,
+			Label:       itm.Label,
+			Enable:      itm.Enable,
+			CreatedTime: ToInt64(itm.Ts),
+		})
+	}
+	return users, nil
+}
+
+//子账户交易账户资产
+func (ok *OKExWalletV5) GetSubAccountBalance(subAccount string) (*Account, error) {
+	var response []struct {
+		TotalEq string        `json:"totalEq"`
+		Details []AcctBalance `json:"details"`
+	}
+	err := ok.DoRequestV5("GET", "/api/v5/account/subaccount/balances?subAcct="+url.QueryEscape(subAccount), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &Account{
+		Exchange:    OKEX,
+		SubAccounts: make(map[Currency]SubAccount, 4),
+	}
+	for _, itm := range response {
+		acc.Asset += ToFloat64(itm.TotalEq)
+		for _, d := range itm.Details {
+			currency := NewCurrency(d.Ccy, "")
+			acc.SubAccounts[currency] = SubAccount{
+				Currency:     currency,
+				Amount:       ToFloat64(d.AvailBal),
+				ForzenAmount: ToFloat64(d.FrozenBal),
+			}
+		}
+	}
+	return acc, nil
+}
+
+//权限: read_only, trade, withdraw, 最多绑定20个ip
+func (ok *OKExWalletV5) CreateSubAccountApiKey(param SubAccountApiKeyParameter) (*SubAccountApiKey, error) {
+	perm := []string{"read_only"}
+	if param.CanTrade {
+		perm = append(perm, "trade")
+	}
+	if param.CanWithdraw {
+		perm = append(perm, "withdraw")
+	}
+	reqBody := map[string]string{
+		"subAcct":    param.SubAccount,
+		"label":      param.Label,
+		"passphrase": param.Passphrase,
+		"perm":       strings.Join(perm, ","),
+	}
+	if len(param.IpWhitelist) > 0 {
+		reqBody["ip"] = strings.Join(param.IpWhitelist, ",")
+	}
+
+	var response []struct {
+		SubAcct    string `json:"subAcct"`
+		Label      string `json:"label"`
+		ApiKey     string `json:"apiKey"`
+		SecretKey  string `json:"secretKey"`
+		Passphrase string `json:"passphrase"`
+		Perm       string `json:"perm"`
+		Ip         string `json:"ip"`
+	}
+	err := ok.DoRequestV5("POST", "/api/v5/users/subaccount/apikey", reqBody, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response) == 0 {
+		return nil, fmt.Errorf("create api key for %s failed", param.SubAccount)
+	}
+
+	r := response[0]
+	key := &SubAccountApiKey{
+		SubAccount: r.SubAcct,
+		Label:      r.Label,
+		ApiKey:     r.ApiKey,
+		SecretKey:  r.SecretKey,
+		Passphrase: r.Passphrase,
+	}
+	if r.Perm != "" {
+		key.Permissions = strings.Split(r.Perm, ",")
+	}
+	if r.Ip != "" {
+		key.IpWhitelist = strings.Split(r.Ip, ",")
+	}
+	return key, nil
+}
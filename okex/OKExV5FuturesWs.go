@@ -0,0 +1,334 @@
+package okex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/lucas7788/goex"
+)
+
+/**
+ * v5 交割/永续/期权 websocket
+ * contractType 与OKExFuturesV5.GetInstId一致, 回调中的ContractType为订阅时传入的contractType
+ */
+type OKExV5FuturesWs struct {
+	base         *OKEx
+	pubWs        *OKExV5Ws
+	priWs        *OKExV5Ws
+	depth        *depthHandlerV5
+	DepthChannel string
+
+	lock          sync.RWMutex
+	contractTypes map[string]string //key: instId
+
+	tickerCallback             func(*FutureTicker)
+	depthCallback              func(*Depth)
+	tradeCallback              func(*Trade, string)
+	klineCallback              func(*FutureKline, KlinePeriod)
+	orderCallback              func(*FutureOrder)
+	positionCallback           func([]FuturePosition)
+	accountCallback            func(*FutureAccount)
+	balanceAndPositionCallback func(*BalanceAndPositionV5)
+}
+
+func NewOKExV5FuturesWs(base *OKEx) *OKExV5FuturesWs {
+	okV5Ws := &OKExV5FuturesWs{
+		base:          base,
+		depth:         newDepthHandlerV5(20),
+		DepthChannel:  "books5",
+		contractTypes: make(map[string]string, 4),
+	}
+	okV5Ws.pubWs = NewOKExV5Ws(base, false, okV5Ws.handle)
+	okV5Ws.priWs = NewOKExV5Ws(base, true, okV5Ws.handle)
+	return okV5Ws
+}
+
+func (okV5Ws *OKExV5FuturesWs) TickerCallback(tickerCallback func(*FutureTicker)) {
+	okV5Ws.tickerCallback = tickerCallback
+}
+
+func (okV5Ws *OKExV5FuturesWs) DepthCallback(depthCallback func(*Depth)) {
+	okV5Ws.depthCallback = depthCallback
+}
+
+func (okV5Ws *OKExV5FuturesWs) TradeCallback(tradeCallback func(*Trade, string)) {
+	okV5Ws.tradeCallback = tradeCallback
+}
+
+func (okV5Ws *OKExV5FuturesWs) KLineCallback(klineCallback func(kline *FutureKline, period KlinePeriod)) {
+	okV5Ws.klineCallback = klineCallback
+}
+
+func (okV5Ws *OKExV5FuturesWs) OrderCallback(orderCallback func(*FutureOrder)) {
+	okV5Ws.orderCallback = orderCallback
+}
+
+func (okV5Ws *OKExV5FuturesWs) PositionCallback(positionCallback func([]FuturePosition)) {
+	okV5Ws.positionCallback = positionCallback
+}
+
+func (okV5Ws *OKExV5FuturesWs) AccountCallback(accountCallback func(*FutureAccount)) {
+	okV5Ws.accountCallback = accountCallback
+}
+
+func (okV5Ws *OKExV5FuturesWs) BalanceAndPositionCallback(callback func(*BalanceAndPositionV5)) {
+	okV5Ws.balanceAndPositionCallback = callback
+}
+
+func (okV5Ws *OKExV5FuturesWs) instId(currencyPair CurrencyPair, contractType string) (string, error) {
+	instId, err := okV5Ws.base.OKExFuturesV5.GetInstId(currencyPair, contractType)
+	if err != nil {
+		return "", err
+	}
+	okV5Ws.lock.Lock()
+	okV5Ws.contractTypes[instId] = contractType
+	okV5Ws.lock.Unlock()
+	return instId, nil
+}
+
+func (okV5Ws *OKExV5FuturesWs) contractType(instId string) string {
+	okV5Ws.lock.RLock()
+	defer okV5Ws.lock.RUnlock()
+	if contractType, ok := okV5Ws.contractTypes[instId]; ok {
+		return contractType
+	}
+	return instId
+}
+
+func (okV5Ws *OKExV5FuturesWs) subscribe(channel string, currencyPair CurrencyPair, contractType string) error {
+	instId, err := okV5Ws.instId(currencyPair, contractType)
+	if err != nil {
+		return err
+	}
+	arg := WsArgV5{Channel: channel, InstId: instId}
+	if strings.HasSuffix(channel, "-l2-tbt") { //逐笔深度需要登录
+		return okV5Ws.priWs.Subscribe(arg)
+	}
+	return okV5Ws.pubWs.Subscribe(arg)
+}
+
+func (okV5Ws *OKExV5FuturesWs) SubscribeDepth(currencyPair CurrencyPair, contractType string) error {
+	if okV5Ws.depthCallback == nil {
+		return errors.New("please set depth callback func")
+	}
+	return okV5Ws.subscribe(okV5Ws.DepthChannel, currencyPair, contractType)
+}
+
+func (okV5Ws *OKExV5FuturesWs) SubscribeTicker(currencyPair CurrencyPair, contractType string) error {
+	if okV5Ws.tickerCallback == nil {
+		return errors.New("please set ticker callback func")
+	}
+	return okV5Ws.subscribe("tickers", currencyPair, contractType)
+}
+
+func (okV5Ws *OKExV5FuturesWs) SubscribeTrade(currencyPair CurrencyPair, contractType string) error {
+	if okV5Ws.tradeCallback == nil {
+		return errors.New("please set trade callback func")
+	}
+	return okV5Ws.subscribe("trades", currencyPair, contractType)
+}
+
+func (okV5Ws *OKExV5FuturesWs) SubscribeKline(currencyPair CurrencyPair, contractType string, period int) error {
+	if okV5Ws.klineCallback == nil {
+		return errors.New("place set kline callback func")
+	}
+	bar := adaptKLinePeriodV5(KlinePeriod(period))
+	if bar == "" {
+		return fmt.Errorf("unsupported kline period %d in okex", period)
+	}
+	return okV5Ws.subscribe("candle"+bar, currencyPair, contractType)
+}
+
+//登录私有频道
+func (okV5Ws *OKExV5FuturesWs) Login() error {
+	return okV5Ws.priWs.ConnectWs()
+}
+
+func (okV5Ws *OKExV5FuturesWs) subscribePrivate(channel string, currencyPair CurrencyPair, contractType string) error {
+	instId, err := okV5Ws.instId(currencyPair, contractType)
+	if err != nil {
+		return err
+	}
+	return okV5Ws.priWs.Subscribe(WsArgV5{Channel: channel, InstType: adaptInstTypeV5(instId), InstId: instId})
+}
+
+func (okV5Ws *OKExV5FuturesWs) SubscribeOrder(currencyPair CurrencyPair, contractType string) error {
+	if okV5Ws.orderCallback == nil {
+		return errors.New("please set order callback func")
+	}
+	return okV5Ws.subscribePrivate("orders", currencyPair, contractType)
+}
+
+func (okV5Ws *OKExV5FuturesWs) SubscribePosition(currencyPair CurrencyPair, contractType string) error {
+	if okV5Ws.positionCallback == nil {
+		return errors.New("please set position callback func")
+	}
+	return okV5Ws.subscribePrivate("positions", currencyPair, contractType)
+}
+
+//账户频道, 推送保证金币种的余额, 币本位为pair的base币种, U本位为quote币种
+func (okV5Ws *OKExV5FuturesWs) SubscribeAccount(ccy ...Currency) error {
+	if okV5Ws.accountCallback == nil {
+		return errors.New("please set account callback func")
+	}
+	arg := WsArgV5{Channel: "account"}
+	if len(ccy) > 0 {
+		arg.Ccy = ccy[0].Symbol
+	}
+	return okV5Ws.priWs.Subscribe(arg)
+}
+
+func (okV5Ws *OKExV5FuturesWs) SubscribeBalanceAndPosition() error {
+	if okV5Ws.balanceAndPositionCallback == nil {
+		return errors.New("please set balance and position callback func")
+	}
+	return okV5Ws.priWs.Subscribe(WsArgV5{Channel: "balance_and_position"})
+}
+
+//ws下单, 参数与OKExFuturesV5.PlaceFutureOrder2一致
+func (okV5Ws *OKExV5FuturesWs) PlaceFutureOrder(ord *FutureOrder, ordType string, contractType string) (*FutureOrder, error) {
+	param, err := okV5Ws.base.OKExFuturesV5.buildOrderParam(ord, ordType, contractType)
+	if err != nil {
+		return nil, err
+	}
+	response, err := okV5Ws.priWs.DoOp("order", []OrderParamV5{param})
+	if err != nil {
+		return nil, err
+	}
+	if len(response) == 0 {
+		return nil, fmt.Errorf("take order failed")
+	}
+	if err = response[0].check(); err != nil {
+		return nil, err
+	}
+
+	ord.ClientOid = response[0].ClOrdId
+	ord.OrderID2 = response[0].OrdId
+	ord.ContractName = param.InstId
+	ord.OrderType = adaptOrderFeatureV5(ordType)
+	ord.OrderTime = time.Now().UnixNano() / int64(time.Millisecond)
+	return ord, nil
+}
+
+//ws撤单
+func (okV5Ws *OKExV5FuturesWs) FutureCancelOrder(currencyPair CurrencyPair, contractType, orderId string) (bool, error) {
+	instId, err := okV5Ws.base.OKExFuturesV5.GetInstId(currencyPair, contractType)
+	if err != nil {
+		return false, err
+	}
+	response, err := okV5Ws.priWs.DoOp("cancel-order", []map[string]string{{"instId": instId, "ordId": orderId}})
+	if err != nil {
+		return false, err
+	}
+	if len(response) == 0 {
+		return false, fmt.Errorf("cancel order %s failed", orderId)
+	}
+	if err = response[0].check(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+//公共连接和私有连接的统计信息, 未连接时为零值
+func (okV5Ws *OKExV5FuturesWs) Stats() (pub, pri WsStats) {
+	return okV5Ws.pubWs.Stats(), okV5Ws.priWs.Stats()
+}
+
+func (okV5Ws *OKExV5FuturesWs) Close() {
+	okV5Ws.pubWs.Close()
+	okV5Ws.priWs.Close()
+}
+
+func (okV5Ws *OKExV5FuturesWs) handle(arg WsArgV5, action string, data json.RawMessage) error {
+	switch {
+	case arg.Channel == "tickers":
+		var tickers []tickerResponseV5
+		if err := json.Unmarshal(data, &tickers); err != nil {
+			return err
+		}
+		for _, t := range tickers {
+			okV5Ws.tickerCallback(&FutureTicker{Ticker: t.ticker(), ContractType: okV5Ws.contractType(t.InstId), ContractId: t.InstId})
+		}
+		return nil
+	case strings.HasPrefix(arg.Channel, "books"):
+		dep, err := okV5Ws.depth.handle(arg, action, data)
+		if err != nil {
+			if strings.HasSuffix(arg.Channel, "-l2-tbt") {
+				okV5Ws.priWs.resubscribe(arg)
+			} else {
+				okV5Ws.pubWs.resubscribe(arg)
+			}
+			return err
+		}
+		if dep != nil {
+			dep.ContractType = okV5Ws.contractType(arg.InstId)
+			dep.ContractId = arg.InstId
+			okV5Ws.depthCallback(dep)
+		}
+		return nil
+	case arg.Channel == "trades":
+		var trades []wsTradeRespV5
+		if err := json.Unmarshal(data, &trades); err != nil {
+			return err
+		}
+		for _, t := range trades {
+			okV5Ws.tradeCallback(t.trade(), okV5Ws.contractType(t.InstId))
+		}
+		return nil
+	case strings.HasPrefix(arg.Channel, "candle"):
+		var candles [][]string
+		if err := json.Unmarshal(data, &candles); err != nil {
+			return err
+		}
+		period := adaptBarToKlinePeriodV5(strings.TrimPrefix(arg.Channel, "candle"))
+		for _, c := range candles {
+			kline := &FutureKline{Kline: adaptCandleV5(arg.InstId, c)}
+			if len(c) > 6 {
+				kline.Vol2 = ToFloat64(c[6])
+			}
+			okV5Ws.klineCallback(kline, period)
+		}
+		return nil
+	case arg.Channel == "orders":
+		var orders []orderResponseV5
+		if err := json.Unmarshal(data, &orders); err != nil {
+			return err
+		}
+		for _, o := range orders {
+			ord := okV5Ws.base.OKExFuturesV5.adaptOrder(o)
+			okV5Ws.orderCallback(&ord)
+		}
+		return nil
+	case arg.Channel == "positions":
+		var positions []positionResponseV5
+		if err := json.Unmarshal(data, &positions); err != nil {
+			return err
+		}
+		okV5Ws.positionCallback(adaptPositionsV5(positions))
+		return nil
+	case arg.Channel == "account":
+		var balances []accountBalanceV5
+		if err := json.Unmarshal(data, &balances); err != nil {
+			return err
+		}
+		for i := range balances {
+			okV5Ws.accountCallback(adaptFutureAccountV5(&balances[i]))
+		}
+		return nil
+	case arg.Channel == "balance_and_position":
+		var resp []BalanceAndPositionV5
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return err
+		}
+		for i := range resp {
+			okV5Ws.balanceAndPositionCallback(&resp[i])
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown websocket message: %s", string(data))
+}
@@ -0,0 +1,266 @@
+package okex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	. "github.com/lucas7788/goex"
+)
+
+/**
+ * v5 币币/杠杆 websocket
+ * DepthChannel 默认为books5, 可设置为books、books-l2-tbt、books50-l2-tbt(需要登录)使用增量深度并校验checksum
+ */
+type OKExV5SpotWs struct {
+	base         *OKEx
+	pubWs        *OKExV5Ws
+	priWs        *OKExV5Ws
+	depth        *depthHandlerV5
+	DepthChannel string
+
+	tickerCallback             func(*Ticker)
+	depthCallback              func(*Depth)
+	tradeCallback              func(*Trade)
+	klineCallback              func(*Kline, KlinePeriod)
+	orderCallback              func(*Order)
+	accountCallback            func(*Account)
+	balanceAndPositionCallback func(*BalanceAndPositionV5)
+}
+
+func NewOKExV5SpotWs(base *OKEx) *OKExV5SpotWs {
+	okV5Ws := &OKExV5SpotWs{
+		base:         base,
+		depth:        newDepthHandlerV5(20),
+		DepthChannel: "books5",
+	}
+	okV5Ws.pubWs = NewOKExV5Ws(base, false, okV5Ws.handle)
+	okV5Ws.priWs = NewOKExV5Ws(base, true, okV5Ws.handle)
+	return okV5Ws
+}
+
+func (okV5Ws *OKExV5SpotWs) TickerCallback(tickerCallback func(*Ticker)) {
+	okV5Ws.tickerCallback = tickerCallback
+}
+
+func (okV5Ws *OKExV5SpotWs) DepthCallback(depthCallback func(*Depth)) {
+	okV5Ws.depthCallback = depthCallback
+}
+
+func (okV5Ws *OKExV5SpotWs) TradeCallback(tradeCallback func(*Trade)) {
+	okV5Ws.tradeCallback = tradeCallback
+}
+
+func (okV5Ws *OKExV5SpotWs) KLineCallback(klineCallback func(kline *Kline, period KlinePeriod)) {
+	okV5Ws.klineCallback = klineCallback
+}
+
+func (okV5Ws *OKExV5SpotWs) OrderCallback(orderCallback func(*Order)) {
+	okV5Ws.orderCallback = orderCallback
+}
+
+func (okV5Ws *OKExV5SpotWs) AccountCallback(accountCallback func(*Account)) {
+	okV5Ws.accountCallback = accountCallback
+}
+
+func (okV5Ws *OKExV5SpotWs) BalanceAndPositionCallback(callback func(*BalanceAndPositionV5)) {
+	okV5Ws.balanceAndPositionCallback = callback
+}
+
+func (okV5Ws *OKExV5SpotWs) instId(currencyPair CurrencyPair) string {
+	return currencyPair.AdaptUsdToUsdt().ToUpper().ToSymbol("-")
+}
+
+func (okV5Ws *OKExV5SpotWs) SubscribeDepth(currencyPair CurrencyPair) error {
+	if okV5Ws.depthCallback == nil {
+		return errors.New("please set depth callback func")
+	}
+	arg := WsArgV5{Channel: okV5Ws.DepthChannel, InstId: okV5Ws.instId(currencyPair)}
+	if strings.HasSuffix(arg.Channel, "-l2-tbt") { //逐笔深度需要登录
+		return okV5Ws.priWs.Subscribe(arg)
+	}
+	return okV5Ws.pubWs.Subscribe(arg)
+}
+
+func (okV5Ws *OKExV5SpotWs) SubscribeTicker(currencyPair CurrencyPair) error {
+	if okV5Ws.tickerCallback == nil {
+		return errors.New("please set ticker callback func")
+	}
+	return okV5Ws.pubWs.Subscribe(WsArgV5{Channel: "tickers", InstId: okV5Ws.instId(currencyPair)})
+}
+
+func (okV5Ws *OKExV5SpotWs) SubscribeTrade(currencyPair CurrencyPair) error {
+	if okV5Ws.tradeCallback == nil {
+		return errors.New("please set trade callback func")
+	}
+	return okV5Ws.pubWs.Subscribe(WsArgV5{Channel: "trades", InstId: okV5Ws.instId(currencyPair)})
+}
+
+func (okV5Ws *OKExV5SpotWs) SubscribeKline(currencyPair CurrencyPair, period int) error {
+	if okV5Ws.klineCallback == nil {
+		return errors.New("place set kline callback func")
+	}
+	bar := adaptKLinePeriodV5(KlinePeriod(period))
+	if bar == "" {
+		return fmt.Errorf("unsupported kline period %d in okex", period)
+	}
+	return okV5Ws.pubWs.Subscribe(WsArgV5{Channel: "candle" + bar, InstId: okV5Ws.instId(currencyPair)})
+}
+
+//登录私有频道
+func (okV5Ws *OKExV5SpotWs) Login() error {
+	return okV5Ws.priWs.ConnectWs()
+}
+
+//订单频道, instType为SPOT或MARGIN, 由OKExSpot.TdMode决定
+func (okV5Ws *OKExV5SpotWs) SubscribeOrder(currencyPair CurrencyPair) error {
+	if okV5Ws.orderCallback == nil {
+		return errors.New("please set order callback func")
+	}
+	return okV5Ws.priWs.Subscribe(WsArgV5{Channel: "orders", InstType: okV5Ws.base.OKExSpot.instType(), InstId: okV5Ws.instId(currencyPair)})
+}
+
+//账户频道, ccy为空时推送所有币种
+func (okV5Ws *OKExV5SpotWs) SubscribeAccount(ccy ...Currency) error {
+	if okV5Ws.accountCallback == nil {
+		return errors.New("please set account callback func")
+	}
+	arg := WsArgV5{Channel: "account"}
+	if len(ccy) > 0 {
+		arg.Ccy = ccy[0].Symbol
+	}
+	return okV5Ws.priWs.Subscribe(arg)
+}
+
+func (okV5Ws *OKExV5SpotWs) SubscribeBalanceAndPosition() error {
+	if okV5Ws.balanceAndPositionCallback == nil {
+		return errors.New("please set balance and position callback func")
+	}
+	return okV5Ws.priWs.Subscribe(WsArgV5{Channel: "balance_and_position"})
+}
+
+//ws下单, 参数与OKExSpot.PlaceOrder一致
+func (okV5Ws *OKExV5SpotWs) PlaceOrder(ty string, ord *Order) (*Order, error) {
+	param, err := okV5Ws.base.OKExSpot.buildOrderParam(ty, ord)
+	if err != nil {
+		return nil, err
+	}
+	response, err := okV5Ws.priWs.DoOp("order", []OrderParamV5{param})
+	if err != nil {
+		return nil, err
+	}
+	if len(response) == 0 {
+		return nil, fmt.Errorf("take order failed")
+	}
+	if err = response[0].check(); err != nil {
+		return nil, err
+	}
+	ord.Cid = response[0].ClOrdId
+	ord.OrderID2 = response[0].OrdId
+	ord.Type = ty
+	ord.OrderType = adaptOrderFeatureV5(ty)
+	return ord, nil
+}
+
+//ws撤单
+func (okV5Ws *OKExV5SpotWs) CancelOrder(orderId string, currencyPair CurrencyPair) (bool, error) {
+	response, err := okV5Ws.priWs.DoOp("cancel-order", []map[string]string{{"instId": okV5Ws.instId(currencyPair), "ordId": orderId}})
+	if err != nil {
+		return false, err
+	}
+	if len(response) == 0 {
+		return false, fmt.Errorf("cancel order %s failed", orderId)
+	}
+	if err = response[0].check(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+//公共连接和私有连接的统计信息, 未连接时为零值
+func (okV5Ws *OKExV5SpotWs) Stats() (pub, pri WsStats) {
+	return okV5Ws.pubWs.Stats(), okV5Ws.priWs.Stats()
+}
+
+func (okV5Ws *OKExV5SpotWs) Close() {
+	okV5Ws.pubWs.Close()
+	okV5Ws.priWs.Close()
+}
+
+func (okV5Ws *OKExV5SpotWs) handle(arg WsArgV5, action string, data json.RawMessage) error {
+	switch {
+	case arg.Channel == "tickers":
+		var tickers []tickerResponseV5
+		if err := json.Unmarshal(data, &tickers); err != nil {
+			return err
+		}
+		for _, t := range tickers {
+			okV5Ws.tickerCallback(t.ticker())
+		}
+		return nil
+	case strings.HasPrefix(arg.Channel, "books"):
+		dep, err := okV5Ws.depth.handle(arg, action, data)
+		if err != nil {
+			if strings.HasSuffix(arg.Channel, "-l2-tbt") {
+				okV5Ws.priWs.resubscribe(arg)
+			} else {
+				okV5Ws.pubWs.resubscribe(arg)
+			}
+			return err
+		}
+		if dep != nil {
+			okV5Ws.depthCallback(dep)
+		}
+		return nil
+	case arg.Channel == "trades":
+		var trades []wsTradeRespV5
+		if err := json.Unmarshal(data, &trades); err != nil {
+			return err
+		}
+		for _, t := range trades {
+			okV5Ws.tradeCallback(t.trade())
+		}
+		return nil
+	case strings.HasPrefix(arg.Channel, "candle"):
+		var candles [][]string
+		if err := json.Unmarshal(data, &candles); err != nil {
+			return err
+		}
+		period := adaptBarToKlinePeriodV5(strings.TrimPrefix(arg.Channel, "candle"))
+		for _, c := range candles {
+			okV5Ws.klineCallback(adaptCandleV5(arg.InstId, c), period)
+		}
+		return nil
+	case arg.Channel == "orders":
+		var orders []orderResponseV5
+		if err := json.Unmarshal(data, &orders); err != nil {
+			return err
+		}
+		for _, o := range orders {
+			ord := okV5Ws.base.OKExSpot.adaptOrder(o)
+			okV5Ws.orderCallback(&ord)
+		}
+		return nil
+	case arg.Channel == "account":
+		var balances []accountBalanceV5
+		if err := json.Unmarshal(data, &balances); err != nil {
+			return err
+		}
+		for i := range balances {
+			okV5Ws.accountCallback(adaptAccountV5(&balances[i]))
+		}
+		return nil
+	case arg.Channel == "balance_and_position":
+		var resp []BalanceAndPositionV5
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return err
+		}
+		for i := range resp {
+			okV5Ws.balanceAndPositionCallback(&resp[i])
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown websocket message: %s", string(data))
+}
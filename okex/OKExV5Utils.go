@@ -0,0 +1,225 @@
+package okex
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	. "github.com/lucas7788/goex"
+)
+
+//v5 交易模式 tdMode
+const (
+	TdModeCash     = "cash"     //非保证金(币币)
+	TdModeCross    = "cross"    //全仓
+	TdModeIsolated = "isolated" //逐仓
+)
+
+//v5 产品类型 instType
+const (
+	InstTypeSpot    = "SPOT"
+	InstTypeMargin  = "MARGIN"
+	InstTypeSwap    = "SWAP"
+	InstTypeFutures = "FUTURES"
+	InstTypeOption  = "OPTION"
+)
+
+//v5 持仓模式
+const (
+	PosModeLongShort = "long_short_mode" //双向持仓
+	PosModeNet       = "net_mode"        //单向持仓
+)
+
+type okResV5 struct {
+	Code string          `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+/**
+ * v5 统一请求, params为nil时发送空body; data为nil时忽略返回的data
+ * 批量/下单类接口即使code=0, data中每一项也可能带有sCode错误,由调用方处理
+ */
+func (ok *OKEx) DoRequestV5(httpMethod, uri string, params interface{}, data interface{}) error {
+	reqBody := ""
+	if params != nil {
+		var err error
+		reqBody, _, err = ok.BuildRequestBody(params)
+		if err != nil {
+			return err
+		}
+	}
+
+	var res okResV5
+	err := ok.DoRequest(httpMethod, uri, reqBody, &res)
+	if err != nil {
+		return err
+	}
+
+	if res.Code != "0" {
+		//下单类接口的具体错误信息在data里
+		var items []struct {
+			SCode string `json:"sCode"`
+			SMsg  string `json:"sMsg"`
+		}
+		if json.Unmarshal(res.Data, &items) == nil && len(items) > 0 && items[0].SCode != "" && items[0].SCode != "0" {
+			return fmt.Errorf("code: %s, msg: %s, sCode: %s, sMsg: %s", res.Code, res.Msg, items[0].SCode, items[0].SMsg)
+		}
+		return fmt.Errorf("code: %s, msg: %s", res.Code, res.Msg)
+	}
+
+	if data == nil || len(res.Data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(res.Data, data)
+}
+
+type orderResponseV5 struct {
+	InstType  string `json:"instType"`
+	InstId    string `json:"instId"`
+	OrdId     string `json:"ordId"`
+	ClOrdId   string `json:"clOrdId"`
+	Px        string `json:"px"`
+	Sz        string `json:"sz"`
+	OrdType   string `json:"ordType"`
+	Side      string `json:"side"`
+	PosSide   string `json:"posSide"`
+	TdMode    string `json:"tdMode"`
+	AccFillSz string `json:"accFillSz"`
+	AvgPx     string `json:"avgPx"`
+	State     string `json:"state"`
+	Lever     string `json:"lever"`
+	Fee       string `json:"fee"`
+	CTime     string `json:"cTime"`
+	UTime     string `json:"uTime"`
+}
+
+func adaptOrderStateV5(state string) TradeStatus {
+	switch state {
+	case "live":
+		return ORDER_UNFINISH
+	case "partially_filled":
+		return ORDER_PART_FINISH
+	case "filled":
+		return ORDER_FINISH
+	case "canceled", "mmp_canceled":
+		return ORDER_CANCEL
+	}
+	return ORDER_UNFINISH
+}
+
+func adaptOrderFeatureV5(ordType string) int {
+	switch ordType {
+	case "post_only":
+		return ORDER_FEATURE_POST_ONLY
+	case "fok":
+		return ORDER_FEATURE_FOK
+	case "ioc", "optimal_limit_ioc":
+		return ORDER_FEATURE_IOC
+	}
+	return ORDER_FEATURE_ORDINARY
+}
+
+//k线周期 bar
+func adaptKLinePeriodV5(period KlinePeriod) string {
+	switch period {
+	case KLINE_PERIOD_1MIN:
+		return "1m"
+	case KLINE_PERIOD_3MIN:
+		return "3m"
+	case KLINE_PERIOD_5MIN:
+		return "5m"
+	case KLINE_PERIOD_15MIN:
+		return "15m"
+	case KLINE_PERIOD_30MIN:
+		return "30m"
+	case KLINE_PERIOD_1H, KLINE_PERIOD_60MIN:
+		return "1H"
+	case KLINE_PERIOD_2H:
+		return "2H"
+	case KLINE_PERIOD_4H:
+		return "4H"
+	case KLINE_PERIOD_6H:
+		return "6H"
+	case KLINE_PERIOD_12H:
+		return "12H"
+	case KLINE_PERIOD_1DAY:
+		return "1D"
+	case KLINE_PERIOD_1WEEK:
+		return "1W"
+	case KLINE_PERIOD_1MONTH:
+		return "1M"
+	}
+	return ""
+}
+
+//根据instId推断产品类型, 如 BTC-USDT, BTC-USD-SWAP, BTC-USD-210625, BTC-USD-210625-50000-C
+func adaptInstTypeV5(instId string) string {
+	parts := strings.Split(instId, "-")
+	switch {
+	case len(parts) == 3 && parts[2] == "SWAP":
+		return InstTypeSwap
+	case len(parts) == 3:
+		return InstTypeFutures
+	case len(parts) == 5:
+		return InstTypeOption
+	}
+	return InstTypeSpot
+}
+
+//instId中的币对, 如 BTC-USD-SWAP => BTC_USD
+func adaptInstIdToPair(instId string) CurrencyPair {
+	parts := strings.Split(instId, "-")
+	if len(parts) < 2 {
+		return UNKNOWN_PAIR
+	}
+	return NewCurrencyPair2(parts[0] + "_" + parts[1])
+}
+
+func adaptSideV5(side string) TradeSide {
+	if side == "sell" {
+		return SELL
+	}
+	return BUY
+}
+
+//openType => side, posSide, 单向持仓模式下posSide为net
+func adaptOpenTypeV5(openType int, posMode string) (side, posSide string, err error) {
+	switch openType {
+	case OPEN_BUY:
+		side, posSide = "buy", "long"
+	case OPEN_SELL:
+		side, posSide = "sell", "short"
+	case CLOSE_BUY:
+		side, posSide = "sell", "long"
+	case CLOSE_SELL:
+		side, posSide = "buy", "short"
+	default:
+		return "", "", fmt.Errorf("unknown open type: %d", openType)
+	}
+	if posMode == PosModeNet {
+		posSide = "net"
+	}
+	return
+}
+
+func adaptPosSideToOpenType(side, posSide string) int {
+	switch posSide {
+	case "long":
+		if side == "buy" {
+			return OPEN_BUY
+		}
+		return CLOSE_BUY
+	case "short":
+		if side == "sell" {
+			return OPEN_SELL
+		}
+		return CLOSE_SELL
+	}
+	//单向持仓无法区分开平
+	if side == "buy" {
+		return OPEN_BUY
+	}
+	return OPEN_SELL
+}
@@ -0,0 +1,504 @@
+package okex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/logger"
+)
+
+const (
+	wsV5PublicUrl           = "wss://ws.okx.com:8443/ws/v5/public"
+	wsV5PrivateUrl          = "wss://ws.okx.com:8443/ws/v5/private"
+	wsV5SimulatedPublicUrl  = "wss://wspap.okx.com:8443/ws/v5/public?brokerId=9999"
+	wsV5SimulatedPrivateUrl = "wss://wspap.okx.com:8443/ws/v5/private?brokerId=9999"
+
+	wsV5LoginTimeout = 10 * time.Second
+	wsV5OpTimeout    = 10 * time.Second
+	wsV5ChecksumSize = 25
+)
+
+type WsArgV5 struct {
+	Channel  string `json:"channel"`
+	InstType string `json:"instType,omitempty"`
+	Uly      string `json:"uly,omitempty"`
+	InstId   string `json:"instId,omitempty"`
+	Ccy      string `json:"ccy,omitempty"`
+}
+
+type wsRespV5 struct {
+	Id     string          `json:"id"`
+	Op     string          `json:"op"`
+	Event  string          `json:"event"`
+	Code   string          `json:"code"`
+	Msg    string          `json:"msg"`
+	Arg    WsArgV5         `json:"arg"`
+	Action string          `json:"action"`
+	Data   json.RawMessage `json:"data"`
+}
+
+/**
+ * v5 websocket连接, 公共频道和私有频道分别使用不同的连接
+ * 私有连接在每次(重)连接成功后自动登录, 登录成功后再重新订阅
+ */
+type OKExV5Ws struct {
+	base    *OKEx
+	private bool
+	*WsBuilder
+	once       *sync.Once
+	connLock   sync.Mutex //保护WsConn, Stats可能与连接并发调用
+	WsConn     *WsConn
+	connErr    error
+	loginResp  chan error
+	respHandle func(arg WsArgV5, action string, data json.RawMessage) error
+
+	reqId   int64
+	opLock  sync.Mutex
+	opCalls map[string]chan wsRespV5
+}
+
+func NewOKExV5Ws(base *OKEx, private bool, handle func(arg WsArgV5, action string, data json.RawMessage) error) *OKExV5Ws {
+	v5Ws := &OKExV5Ws{
+		base:       base,
+		private:    private,
+		once:       new(sync.Once),
+		loginResp:  make(chan error, 1),
+		respHandle: handle,
+		opCalls:    make(map[string]chan wsRespV5, 4),
+	}
+
+	wsUrl := wsV5PublicUrl
+	switch {
+	case private && base.Simulated:
+		wsUrl = wsV5SimulatedPrivateUrl
+	case private:
+		wsUrl = wsV5PrivateUrl
+	case base.Simulated:
+		wsUrl = wsV5SimulatedPublicUrl
+	}
+
+	v5Ws.WsBuilder = NewWsBuilder().
+		WsUrl(wsUrl).
+		ReconnectInterval(time.Second).
+		AutoReconnect().
+		Heartbeat(func() []byte { return []byte("ping") }, 25*time.Second).
+		ProtoHandleFunc(v5Ws.handle).
+		TimestampFunc(wsTimestampV5)
+	if private {
+		v5Ws.WsBuilder.ConnectSuccessAfterSendMessage(v5Ws.loginMessage)
+	}
+	return v5Ws
+}
+
+//登录签名: Base64(HmacSHA256(timestamp + 'GET' + '/users/self/verify'))
+func (v5Ws *OKExV5Ws) loginMessage() []byte {
+	timestamp := fmt.Sprint(v5Ws.base.clock.Now().Unix())
+	sign, _ := GetParamHmacSHA256Base64Sign(v5Ws.base.config.ApiSecretKey, timestamp+"GET/users/self/verify")
+	data, _ := json.Marshal(map[string]interface{}{
+		"op": "login",
+		"args": []map[string]string{{
+			"apiKey":     v5Ws.base.config.ApiKey,
+			"passphrase": v5Ws.base.config.ApiPassphrase,
+			"timestamp":  timestamp,
+			"sign":       sign,
+		}}})
+	return data
+}
+
+//建立连接, 私有连接会等待登录结果
+func (v5Ws *OKExV5Ws) ConnectWs() error {
+	v5Ws.once.Do(func() {
+		conn, err := v5Ws.WsBuilder.Build()
+		v5Ws.connLock.Lock()
+		v5Ws.WsConn, v5Ws.connErr = conn, err
+		v5Ws.connLock.Unlock()
+		if v5Ws.connErr != nil || !v5Ws.private {
+			return
+		}
+		select {
+		case v5Ws.connErr = <-v5Ws.loginResp:
+		case <-time.After(wsV5LoginTimeout):
+			v5Ws.connErr = errors.New("login timeout")
+		}
+		if v5Ws.connErr != nil {
+			v5Ws.WsConn.CloseWs()
+		}
+	})
+	return v5Ws.connErr
+}
+
+func (v5Ws *OKExV5Ws) Subscribe(args ...WsArgV5) error {
+	if err := v5Ws.ConnectWs(); err != nil {
+		return err
+	}
+	return v5Ws.WsConn.Subscribe(map[string]interface{}{"op": "subscribe", "args": args})
+}
+
+//重新订阅(不记录到订阅列表), 用于深度校验失败后重新获取全量数据
+func (v5Ws *OKExV5Ws) resubscribe(arg WsArgV5) {
+	v5Ws.WsConn.SendJsonMessage(map[string]interface{}{"op": "unsubscribe", "args": []WsArgV5{arg}})
+	v5Ws.WsConn.SendJsonMessage(map[string]interface{}{"op": "subscribe", "args": []WsArgV5{arg}})
+}
+
+/**
+ * 通过ws下单/撤单/改单, op: order, batch-orders, cancel-order, batch-cancel-orders, amend-order
+ * 返回的每一项需要检查SCode
+ */
+func (v5Ws *OKExV5Ws) DoOp(op string, args interface{}) ([]PlaceOrderResponseV5, error) {
+	if !v5Ws.private {
+		return nil, errors.New("op only support private websocket")
+	}
+	if err := v5Ws.ConnectWs(); err != nil {
+		return nil, err
+	}
+
+	id := fmt.Sprint(atomic.AddInt64(&v5Ws.reqId, 1))
+	ch := make(chan wsRespV5, 1)
+	v5Ws.opLock.Lock()
+	v5Ws.opCalls[id] = ch
+	v5Ws.opLock.Unlock()
+	defer func() {
+		v5Ws.opLock.Lock()
+		delete(v5Ws.opCalls, id)
+		v5Ws.opLock.Unlock()
+	}()
+
+	err := v5Ws.WsConn.SendJsonMessage(map[string]interface{}{"id": id, "op": op, "args": args})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-ch:
+		var data []PlaceOrderResponseV5
+		json.Unmarshal(resp.Data, &data)
+		if resp.Code != "0" {
+			if len(data) > 0 && data[0].check() != nil {
+				return data, fmt.Errorf("code: %s, msg: %s, %s", resp.Code, resp.Msg, data[0].check().Error())
+			}
+			return data, fmt.Errorf("code: %s, msg: %s", resp.Code, resp.Msg)
+		}
+		return data, nil
+	case <-time.After(wsV5OpTimeout):
+		return nil, fmt.Errorf("%s timeout", op)
+	case <-v5Ws.WsConn.Done():
+		return nil, ErrWsClosed
+	}
+}
+
+func (v5Ws *OKExV5Ws) handle(msg []byte) error {
+	logger.Debug("[ws] [response] ", string(msg))
+	if string(msg) == "pong" {
+		return nil
+	}
+
+	var resp wsRespV5
+	err := json.Unmarshal(msg, &resp)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if resp.Id != "" && resp.Op != "" {
+		v5Ws.opLock.Lock()
+		ch, ok := v5Ws.opCalls[resp.Id]
+		v5Ws.opLock.Unlock()
+		if ok {
+			ch <- resp
+		}
+		return nil
+	}
+
+	switch resp.Event {
+	case "":
+	case "login":
+		logger.Info("[ws] login success")
+		v5Ws.notifyLogin(nil)
+		return nil
+	case "subscribe", "unsubscribe":
+		logger.Info(resp.Event, ":", resp.Arg.Channel, resp.Arg.InstId, resp.Arg.InstType)
+		return nil
+	case "error":
+		logger.Error(string(msg))
+		err = fmt.Errorf("code: %s, msg: %s", resp.Code, resp.Msg)
+		if strings.HasPrefix(resp.Code, "600") { //600xx 登录相关错误
+			v5Ws.notifyLogin(err)
+		}
+		return err
+	default:
+		logger.Info(string(msg))
+		return nil
+	}
+
+	if resp.Arg.Channel != "" {
+		err = v5Ws.respHandle(resp.Arg, resp.Action, resp.Data)
+		if err != nil {
+			logger.Error("handle ws data error:", err)
+		}
+		return err
+	}
+
+	return fmt.Errorf("unknown websocket message: %s", string(msg))
+}
+
+func (v5Ws *OKExV5Ws) notifyLogin(err error) {
+	select {
+	case v5Ws.loginResp <- err:
+	default:
+	}
+}
+
+//连接的统计信息, 未连接时返回零值
+func (v5Ws *OKExV5Ws) Stats() WsStats {
+	v5Ws.connLock.Lock()
+	defer v5Ws.connLock.Unlock()
+	if v5Ws.WsConn == nil {
+		return WsStats{}
+	}
+	return v5Ws.WsConn.Stats()
+}
+
+//推送数据第一条的ts(毫秒), 用于统计延迟
+//操作响应, pong和K线(数组格式, 首元素为开始时间而非推送时间)返回零值, 不统计延迟
+func wsTimestampV5(msg []byte) time.Time {
+	var resp struct {
+		Data []map[string]json.RawMessage `json:"data"`
+	}
+	if json.Unmarshal(msg, &resp) != nil || len(resp.Data) == 0 {
+		return time.Time{}
+	}
+	var ts string
+	json.Unmarshal(resp.Data[0]["ts"], &ts)
+	ms := ToInt64(ts)
+	if ms <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+func (v5Ws *OKExV5Ws) Close() {
+	v5Ws.connLock.Lock()
+	defer v5Ws.connLock.Unlock()
+	if v5Ws.WsConn != nil {
+		v5Ws.WsConn.CloseWs()
+	}
+}
+
+type wsBookRespV5 struct {
+	Asks     [][]string `json:"asks"`
+	Bids     [][]string `json:"bids"`
+	Ts       string     `json:"ts"`
+	Checksum int32      `json:"checksum"`
+}
+
+//增量深度本地维护, 价格和数量保留原始字符串用于checksum
+type depthBookV5 struct {
+	asks map[string]string
+	bids map[string]string
+	ts   int64
+}
+
+func newDepthBookV5() *depthBookV5 {
+	return &depthBookV5{asks: make(map[string]string, 400), bids: make(map[string]string, 400)}
+}
+
+func (book *depthBookV5) update(resp wsBookRespV5) {
+	merge := func(side map[string]string, levels [][]string) {
+		for _, l := range levels {
+			if len(l) < 2 {
+				continue
+			}
+			if ToFloat64(l[1]) == 0 {
+				delete(side, l[0])
+			} else {
+				side[l[0]] = l[1]
+			}
+		}
+	}
+	merge(book.asks, resp.Asks)
+	merge(book.bids, resp.Bids)
+	book.ts = ToInt64(resp.Ts)
+}
+
+//asks价格升序, bids价格降序
+func (book *depthBookV5) sorted() (asks, bids [][2]string) {
+	for p, s := range book.asks {
+		asks = append(asks, [2]string{p, s})
+	}
+	for p, s := range book.bids {
+		bids = append(bids, [2]string{p, s})
+	}
+	sort.Slice(asks, func(i, j int) bool { return ToFloat64(asks[i][0]) < ToFloat64(asks[j][0]) })
+	sort.Slice(bids, func(i, j int) bool { return ToFloat64(bids[i][0]) > ToFloat64(bids[j][0]) })
+	return
+}
+
+//前25档买卖交替拼接 bid:bidSz:ask:askSz... 的crc32
+func depthChecksumV5(asks, bids [][2]string) int32 {
+	var fields []string
+	for i := 0; i < wsV5ChecksumSize; i++ {
+		if i < len(bids) {
+			fields = append(fields, bids[i][0], bids[i][1])
+		}
+		if i < len(asks) {
+			fields = append(fields, asks[i][0], asks[i][1])
+		}
+	}
+	return int32(crc32.ChecksumIEEE([]byte(strings.Join(fields, ":"))))
+}
+
+//取前size档转换为Depth, AskList与其他实现一致为价格降序
+func (book *depthBookV5) depth(asks, bids [][2]string, size int) *Depth {
+	dep := &Depth{UTime: time.Unix(0, book.ts*int64(time.Millisecond))}
+	if size > 0 && len(asks) > size {
+		asks = asks[:size]
+	}
+	if size > 0 && len(bids) > size {
+		bids = bids[:size]
+	}
+	for i := len(asks) - 1; i >= 0; i-- {
+		dep.AskList = append(dep.AskList, DepthRecord{Price: ToFloat64(asks[i][0]), Amount: ToFloat64(asks[i][1])})
+	}
+	for _, b := range bids {
+		dep.BidList = append(dep.BidList, DepthRecord{Price: ToFloat64(b[0]), Amount: ToFloat64(b[1])})
+	}
+	return dep
+}
+
+/**
+ * 深度频道处理, books5为全量推送; books/books-l2-tbt/books50-l2-tbt为全量+增量, 每次更新校验checksum
+ * 校验失败时丢弃本地深度并重新订阅
+ */
+type depthHandlerV5 struct {
+	lock  sync.Mutex
+	books map[string]*depthBookV5 //key: channel + instId
+	size  int
+}
+
+func newDepthHandlerV5(size int) *depthHandlerV5 {
+	return &depthHandlerV5{books: make(map[string]*depthBookV5, 4), size: size}
+}
+
+func (h *depthHandlerV5) handle(arg WsArgV5, action string, data json.RawMessage) (*Depth, error) {
+	var resp []wsBookRespV5
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp) == 0 {
+		return nil, nil
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	key := arg.Channel + arg.InstId
+	book, exist := h.books[key]
+	if action != "update" || !exist {
+		if action == "update" { //没有全量数据的增量无法使用
+			return nil, nil
+		}
+		book = newDepthBookV5()
+		h.books[key] = book
+	}
+	book.update(resp[0])
+
+	asks, bids := book.sorted()
+	if action != "" && resp[0].Checksum != 0 {
+		if cs := depthChecksumV5(asks, bids); cs != resp[0].Checksum {
+			delete(h.books, key)
+			return nil, fmt.Errorf("depth checksum mismatch: %s, local=%d, remote=%d", key, cs, resp[0].Checksum)
+		}
+	}
+
+	dep := book.depth(asks, bids, h.size)
+	dep.Pair = adaptInstIdToPair(arg.InstId)
+	return dep, nil
+}
+
+func adaptBarToKlinePeriodV5(bar string) KlinePeriod {
+	for _, p := range []KlinePeriod{KLINE_PERIOD_1MIN, KLINE_PERIOD_3MIN, KLINE_PERIOD_5MIN, KLINE_PERIOD_15MIN,
+		KLINE_PERIOD_30MIN, KLINE_PERIOD_1H, KLINE_PERIOD_2H, KLINE_PERIOD_4H, KLINE_PERIOD_6H, KLINE_PERIOD_12H,
+		KLINE_PERIOD_1DAY, KLINE_PERIOD_1WEEK, KLINE_PERIOD_1MONTH} {
+		if adaptKLinePeriodV5(p) == bar {
+			return p
+		}
+	}
+	return 0
+}
+
+type wsTradeRespV5 struct {
+	InstId  string `json:"instId"`
+	TradeId string `json:"tradeId"`
+	Px      string `json:"px"`
+	Sz      string `json:"sz"`
+	Side    string `json:"side"`
+	Ts      string `json:"ts"`
+}
+
+func (t wsTradeRespV5) trade() *Trade {
+	return &Trade{
+		Tid:    ToInt64(t.TradeId),
+		Type:   AdaptTradeSide(t.Side),
+		Amount: ToFloat64(t.Sz),
+		Price:  ToFloat64(t.Px),
+		Date:   ToInt64(t.Ts),
+		Pair:   adaptInstIdToPair(t.InstId),
+	}
+}
+
+func (t tickerResponseV5) ticker() *Ticker {
+	return &Ticker{
+		Pair: adaptInstIdToPair(t.InstId),
+		Last: ToFloat64(t.Last),
+		High: ToFloat64(t.High24h),
+		Low:  ToFloat64(t.Low24h),
+		Sell: ToFloat64(t.AskPx),
+		Buy:  ToFloat64(t.BidPx),
+		Vol:  ToFloat64(t.Vol24h),
+		Date: uint64(ToInt64(t.Ts)),
+	}
+}
+
+func adaptCandleV5(instId string, candle []string) *Kline {
+	return &Kline{
+		Pair:      adaptInstIdToPair(instId),
+		Timestamp: ToInt64(candle[0]) / 1000,
+		Open:      ToFloat64(candle[1]),
+		High:      ToFloat64(candle[2]),
+		Low:       ToFloat64(candle[3]),
+		Close:     ToFloat64(candle[4]),
+		Vol:       ToFloat64(candle[5]),
+	}
+}
+
+//balance_and_position 频道推送
+type BalanceAndPositionV5 struct {
+	PTime     string `json:"pTime"`
+	EventType string `json:"eventType"`
+	BalData   []struct {
+		Ccy     string `json:"ccy"`
+		CashBal string `json:"cashBal"`
+		UTime   string `json:"uTime"`
+	} `json:"balData"`
+	PosData []struct {
+		PosId    string `json:"posId"`
+		TradeId  string `json:"tradeId"`
+		InstId   string `json:"instId"`
+		InstType string `json:"instType"`
+		MgnMode  string `json:"mgnMode"`
+		PosSide  string `json:"posSide"`
+		Pos      string `json:"pos"`
+		Ccy      string `json:"ccy"`
+		PosCcy   string `json:"posCcy"`
+		AvgPx    string `json:"avgPx"`
+		UTime    string `json:"uTime"`
+	} `json:"posData"`
+}
@@ -0,0 +1,115 @@
+package okex
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/lucas7788/goex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDepthHandlerV5_Checksum(t *testing.T) {
+	h := newDepthHandlerV5(2)
+	arg := WsArgV5{Channel: "books", InstId: "BTC-USDT"}
+
+	snapshot := `[{"asks":[["8476.98","415","0","13"],["8477","7","0","2"]],"bids":[["8476.97","256","0","12"],["8475.55","101","0","1"]],"ts":"1597026383085","checksum":%d}]`
+	cs := int32(crc32.ChecksumIEEE([]byte("8476.97:256:8476.98:415:8475.55:101:8477:7")))
+	dep, err := h.handle(arg, "snapshot", json.RawMessage(strings.Replace(snapshot, "%d", fmt.Sprint(cs), 1)))
+	assert.Nil(t, err)
+	assert.Equal(t, "BTC_USDT", dep.Pair.String())
+	assert.Equal(t, goex.DepthRecords{{Price: 8477, Amount: 7}, {Price: 8476.98, Amount: 415}}, dep.AskList)
+	assert.Equal(t, goex.DepthRecords{{Price: 8476.97, Amount: 256}, {Price: 8475.55, Amount: 101}}, dep.BidList)
+
+	update := `[{"asks":[["8476.98","0","0","0"]],"bids":[["8476.97","300","0","13"]],"ts":"1597026383086","checksum":%d}]`
+	cs = int32(crc32.ChecksumIEEE([]byte("8476.97:300:8477:7:8475.55:101")))
+	dep, err = h.handle(arg, "update", json.RawMessage(strings.Replace(update, "%d", fmt.Sprint(cs), 1)))
+	assert.Nil(t, err)
+	assert.Equal(t, goex.DepthRecords{{Price: 8477, Amount: 7}}, dep.AskList)
+	assert.Equal(t, 300.0, dep.BidList[0].Amount)
+
+	//校验失败后丢弃本地深度, 后续增量被忽略直到收到新的全量
+	_, err = h.handle(arg, "update", json.RawMessage(strings.Replace(update, "%d", "1", 1)))
+	assert.Error(t, err)
+	dep, err = h.handle(arg, "update", json.RawMessage(strings.Replace(update, "%d", "1", 1)))
+	assert.Nil(t, err)
+	assert.Nil(t, dep)
+}
+
+func TestOKExV5SpotWs_PlaceOrder(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	ops := make(chan map[string]interface{}, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v5/public/time" {
+			w.Write([]byte(`{"code":"0","msg":"","data":[{"ts":"1597026383085"}]}`))
+			return
+		}
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		for {
+			_, msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req map[string]interface{}
+			if json.Unmarshal(msg, &req) != nil {
+				continue
+			}
+			ops <- req
+			switch req["op"] {
+			case "login":
+				c.WriteMessage(websocket.TextMessage, []byte(`{"event":"login","code":"0","msg":""}`))
+			case "order":
+				param := req["args"].([]interface{})[0].(map[string]interface{})
+				c.WriteJSON(map[string]interface{}{"id": req["id"], "op": "order", "code": "0", "msg": "",
+					"data": []map[string]interface{}{{"ordId": "312269865356374016", "clOrdId": param["clOrdId"], "sCode": "0"}}})
+			}
+		}
+	}))
+	defer srv.Close()
+
+	okV5Ws := NewOKExV5SpotWs(NewOKEx(&goex.APIConfig{HttpClient: http.DefaultClient, Endpoint: srv.URL,
+		ApiKey: "key", ApiSecretKey: "secret", ApiPassphrase: "passphrase"}))
+	okV5Ws.priWs.WsUrl("ws" + strings.TrimPrefix(srv.URL, "http"))
+	defer okV5Ws.Close()
+
+	assert.Nil(t, okV5Ws.Login())
+	login := <-ops
+	args := login["args"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "key", args["apiKey"])
+	assert.Equal(t, "passphrase", args["passphrase"])
+	sign, _ := goex.GetParamHmacSHA256Base64Sign("secret", args["timestamp"].(string)+"GET/users/self/verify")
+	assert.Equal(t, sign, args["sign"])
+
+	ord, err := okV5Ws.PlaceOrder("limit", &goex.Order{Cid: "b1", Currency: goex.BTC_USDT, Side: goex.BUY, Price: 9900, Amount: 0.1})
+	assert.Nil(t, err)
+	assert.Equal(t, "312269865356374016", ord.OrderID2)
+	assert.Equal(t, "b1", ord.Cid)
+
+	req := <-ops
+	assert.Equal(t, "order", req["op"])
+	param := req["args"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "BTC-USDT", param["instId"])
+	assert.Equal(t, TdModeCash, param["tdMode"])
+	assert.Equal(t, "buy", param["side"])
+	assert.Equal(t, "9900", param["px"])
+}
+
+func TestWsTimestampV5(t *testing.T) {
+	ts := wsTimestampV5([]byte(`{"arg":{"channel":"tickers","instId":"BTC-USDT"},"data":[{"instId":"BTC-USDT","last":"9999.99","ts":"1600000000123"}]}`))
+	assert.Equal(t, int64(1600000000123), ts.UnixNano()/int64(time.Millisecond))
+
+	//K线的首元素为开始时间, 不统计延迟
+	assert.True(t, wsTimestampV5([]byte(`{"arg":{"channel":"candle1m","instId":"BTC-USDT"},"data":[["1600000000000","1","2","0.5","1.5","100","100"]]}`)).IsZero())
+	assert.True(t, wsTimestampV5([]byte(`{"event":"subscribe","arg":{"channel":"tickers","instId":"BTC-USDT"}}`)).IsZero())
+	assert.True(t, wsTimestampV5([]byte("pong")).IsZero())
+}
@@ -0,0 +1,487 @@
+package okex
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+//v5接口, 路由的key为path, 返回值为data字段
+var okexV5API = testserver.Options{
+	Fixed: map[string]testserver.Route{
+		"/api/v5/public/time": testserver.Reply(okexV5Data([]map[string]string{{"ts": "1597026383085"}})),
+	},
+	Wrap: func(r *testserver.Request, data interface{}) interface{} {
+		return okexV5Data(data)
+	},
+	NotFound: testserver.Reply(okexV5Data([]interface{}{})),
+	Key: func(r *testserver.Request) string {
+		return r.URL.Path
+	},
+}
+
+func okexV5Data(data interface{}) interface{} {
+	return map[string]interface{}{"code": "0", "msg": "", "data": data}
+}
+
+func TestOKExFuturesV5_GetInstId(t *testing.T) {
+	srv := testserver.New(okexV5API, map[string]testserver.Route{
+		"/api/v5/public/instruments": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "FUTURES", r.URL.Query().Get("instType"))
+			assert.Equal(t, "BTC-USD", r.URL.Query().Get("uly"))
+			return []map[string]string{
+				{"instId": "BTC-USD-210625", "alias": "quarter", "expTime": "4102444800000"},
+				{"instId": "BTC-USD-210924", "alias": "next_quarter", "expTime": "4102444800000"},
+			}
+		},
+	})
+	defer srv.Close()
+	ok := NewOKEx(testserver.Config(srv))
+
+	for contractType, want := range map[string]string{
+		goex.QUARTER_CONTRACT:    "BTC-USD-210625",
+		goex.BI_QUARTER_CONTRACT: "BTC-USD-210924",
+		goex.SWAP_CONTRACT:       "BTC-USD-SWAP",
+		goex.SWAP_USDT_CONTRACT:  "BTC-USDT-SWAP",
+		"btc-usd-210625-50000-c": "BTC-USD-210625-50000-C",
+	} {
+		instId, err := ok.OKExFuturesV5.GetInstId(goex.BTC_USD, contractType)
+		assert.Nil(t, err)
+		assert.Equal(t, want, instId)
+	}
+
+	_, err := ok.OKExFuturesV5.GetInstId(goex.BTC_USD, goex.THIS_WEEK_CONTRACT)
+	assert.Error(t, err)
+
+	assert.Equal(t, InstTypeOption, adaptInstTypeV5("BTC-USD-210625-50000-C"))
+	assert.Equal(t, InstTypeFutures, adaptInstTypeV5("BTC-USD-210625"))
+	assert.Equal(t, InstTypeSwap, adaptInstTypeV5("BTC-USDT-SWAP"))
+	assert.Equal(t, InstTypeSpot, adaptInstTypeV5("BTC-USDT"))
+}
+
+func TestOKExFuturesV5_LimitFuturesOrder(t *testing.T) {
+	var (
+		lock   sync.Mutex
+		params []OrderParamV5
+	)
+	srv := testserver.New(okexV5API, map[string]testserver.Route{
+		"/api/v5/account/config": func(r *testserver.Request) interface{} {
+			return []map[string]string{{"posMode": PosModeNet}}
+		},
+		"/api/v5/trade/order": func(r *testserver.Request) interface{} {
+			var param OrderParamV5
+			json.Unmarshal(r.Data, &param)
+			lock.Lock()
+			params = append(params, param)
+			lock.Unlock()
+			return []map[string]string{{"ordId": "312269865356374016", "clOrdId": param.ClOrdId, "sCode": "0"}}
+		},
+	})
+	defer srv.Close()
+	ok := NewOKEx(testserver.Config(srv))
+
+	ord, err := ok.OKExFuturesV5.LimitFuturesOrder(goex.BTC_USDT, goex.SWAP_CONTRACT, "30000", "2", goex.CLOSE_BUY, goex.PostOnly)
+	assert.Nil(t, err)
+	assert.Equal(t, "312269865356374016", ord.OrderID2)
+	assert.Equal(t, "BTC-USDT-SWAP", ord.ContractName)
+
+	ok.OKExFuturesV5.PosMode = PosModeLongShort
+	ok.OKExFuturesV5.TdMode = TdModeIsolated
+	_, err = ok.OKExFuturesV5.MarketFuturesOrder(goex.BTC_USD, goex.SWAP_CONTRACT, "1", goex.OPEN_SELL)
+	assert.Nil(t, err)
+
+	assert.Len(t, params, 2)
+	assert.Equal(t, OrderParamV5{InstId: "BTC-USDT-SWAP", TdMode: TdModeCross, ClOrdId: params[0].ClOrdId, Side: "sell",
+		PosSide: "net", OrdType: "post_only", Sz: "2", Px: "30000", ReduceOnly: true}, params[0])
+	assert.Equal(t, OrderParamV5{InstId: "BTC-USD-SWAP", TdMode: TdModeIsolated, ClOrdId: params[1].ClOrdId, Side: "sell",
+		PosSide: "short", OrdType: "market", Sz: "1"}, params[1])
+}
+
+func TestOKExFuturesV5_PosModeError(t *testing.T) {
+	var orders int
+	srv := testserver.New(okexV5API, map[string]testserver.Route{
+		"/api/v5/account/config": testserver.Reply(testserver.Response{Status: http.StatusOK,
+			Body: `{"code":"50001","msg":"Service temporarily unavailable","data":[]}`}),
+		"/api/v5/trade/order": func(r *testserver.Request) interface{} {
+			orders++
+			return []map[string]string{{"ordId": "1", "sCode": "0"}}
+		},
+	})
+	defer srv.Close()
+	ok := NewOKEx(testserver.Config(srv))
+
+	//读取持仓模式失败时不下单
+	_, err := ok.OKExFuturesV5.LimitFuturesOrder(goex.BTC_USDT, goex.SWAP_CONTRACT, "30000", "2", goex.CLOSE_BUY)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, orders)
+	assert.Equal(t, "", ok.OKExFuturesV5.PosMode)
+}
+
+func TestOKExSpotV5_GetOneOrder(t *testing.T) {
+	srv := testserver.New(okexV5API, map[string]testserver.Route{
+		"/api/v5/trade/order": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "BTC-USDT", r.URL.Query().Get("instId"))
+			return []map[string]string{{
+				"instId": "BTC-USDT", "ordId": "312269865356374016", "clOrdId": "b1", "px": "9900", "sz": "0.1",
+				"ordType": "limit", "side": "buy", "accFillSz": "0.05", "avgPx": "9899", "state": "partially_filled",
+				"fee": "-0.00005", "cTime": "1597026383085", "uTime": "1597026383085",
+			}}
+		},
+	})
+	defer srv.Close()
+	ok := NewOKEx(testserver.Config(srv))
+
+	ord, err := ok.OKExSpot.GetOneOrder("312269865356374016", goex.BTC_USD)
+	assert.Nil(t, err)
+	assert.Equal(t, goex.ORDER_PART_FINISH, ord.Status)
+	assert.Equal(t, goex.BUY, ord.Side)
+	assert.Equal(t, 0.05, ord.DealAmount)
+	assert.Equal(t, 9899.0, ord.AvgPrice)
+	assert.Equal(t, 1597026383085, ord.OrderTime)
+}
+
+func TestOKExWalletV5_Transfer(t *testing.T) {
+	var param map[string]string
+	srv := testserver.New(okexV5API, map[string]testserver.Route{
+		"/api/v5/asset/transfer": func(r *testserver.Request) interface{} {
+			json.Unmarshal(r.Data, &param)
+			return []map[string]string{{"transId": "754147"}}
+		},
+	})
+	defer srv.Close()
+	ok := NewOKEx(testserver.Config(srv))
+
+	err := ok.OKExWalletV5.Transfer(goex.TransferParameter{Currency: "usdt", From: goex.WALLET, To: goex.SWAP, Amount: 1.5})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"ccy": "USDT", "amt": "1.5", "from": "6", "to": "18", "type": "0"}, param)
+}
+
+func TestOKExWalletV5_WithdrawChainsAndHistory(t *testing.T) {
+	var cancelParam map[string]string
+	srv := testserver.New(okexV5API, map[string]testserver.Route{
+		"/api/v5/asset/currencies": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "USDT", r.URL.Query().Get("ccy"))
+			return []map[string]interface{}{
+				{"ccy": "USDT", "chain": "USDT-TRC20", "canDep": true, "canWd": true, "minWd": "2", "minFee": "0.8", "maxFee": "1.6"},
+				{"ccy": "USDT", "chain": "USDT-ERC20", "canDep": true, "canWd": false, "minWd": "10", "minFee": "5", "maxFee": "10"},
+			}
+		},
+		"/api/v5/asset/deposit-address": func(r *testserver.Request) interface{} {
+			return []map[string]interface{}{
+				{"ccy": "USDT", "chain": "USDT-TRC20", "addr": "TXyz"},
+				{"ccy": "USDT", "chain": "USDT-ERC20", "addr": "0xabc"},
+			}
+		},
+		"/api/v5/asset/withdrawal-history": func(r *testserver.Request) interface{} {
+			return []map[string]string{
+				{"ccy": "USDT", "amt": "10", "wdId": "1", "state": "2", "ts": "1597026383085"},
+				{"ccy": "USDT", "amt": "20", "wdId": "2", "state": "-2", "ts": "1597026383085"},
+				{"ccy": "USDT", "amt": "30", "wdId": "3", "state": "4", "ts": "1597026383085"},
+				{"ccy": "USDT", "amt": "40", "wdId": "4", "state": "99", "ts": "1597026383085"},
+			}
+		},
+		"/api/v5/asset/cancel-withdrawal": func(r *testserver.Request) interface{} {
+			json.Unmarshal(r.Data, &cancelParam)
+			return []map[string]string{{"wdId": "3"}}
+		},
+	})
+	defer srv.Close()
+	ok := NewOKEx(testserver.Config(srv))
+
+	wallet := ok.OKExWalletV5
+	chains, err := wallet.GetWithdrawChains(goex.USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, goex.WithdrawChain{Currency: "USDT", Chain: "USDT-TRC20", CanDeposit: true, CanWithdraw: true,
+		MinFee: 0.8, MaxFee: 1.6, MinAmount: 2}, chains[0])
+	assert.False(t, chains[1].CanWithdraw)
+
+	addresses, err := wallet.GetDepositAddress(goex.USDT, "usdt-erc20")
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.ChainAddress{{Currency: "USDT", Chain: "USDT-ERC20", Address: "0xabc"}}, addresses)
+
+	history, err := wallet.GetWithDrawHistory(&goex.USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, goex.WITHDRAW_COMPLETED, history[0].Status)
+	assert.Equal(t, goex.WITHDRAW_CANCELLED, history[1].Status)
+	assert.Equal(t, goex.WITHDRAW_PENDING, history[2].Status)
+	assert.Equal(t, goex.WITHDRAW_UNKNOWN, history[3].Status)
+
+	assert.Nil(t, wallet.CancelWithdrawal("3"))
+	assert.Equal(t, map[string]string{"wdId": "3"}, cancelParam)
+}
+
+func TestOKExWallet_GetWithDrawHistory(t *testing.T) {
+	srv := testserver.New(testserver.Options{
+		NotFound: testserver.Reply(okexV5Data([]map[string]string{{"ts": "1597026383085"}})),
+	}, map[string]testserver.Route{
+		"GET /api/account/v3/withdrawal/history/BTC": testserver.Reply(`[{"amount":"0.094","withdrawal_id":"4703879","fee":"0.01000000BTC","txid":"5bd03eb2","currency":"BTC","from":"","to":"17mEa","timestamp":"2018-04-22T23:09:45.000Z","status":"2"},
+			{"amount":"0.5","withdrawal_id":"4703880","fee":"0.01000000BTC","currency":"BTC","to":"17mEa","timestamp":"2018-04-22T23:09:45.000Z","status":"-2"},
+			{"amount":"0.5","withdrawal_id":"4703881","fee":"0.01000000BTC","currency":"BTC","to":"17mEa","timestamp":"2018-04-22T23:09:45.000Z","status":"4"}]`),
+		"GET /api/account/v3/deposit/history/BTC": testserver.Reply(`[{"amount":"0.1","txid":"6ab5b4","currency":"BTC","to":"17mEa","timestamp":"2018-09-30T02:45:50.000Z","status":"1"}]`),
+	})
+	defer srv.Close()
+
+	wallet := NewOKEx(testserver.Config(srv)).OKExWallet
+	history, err := wallet.GetWithDrawHistory(&goex.BTC)
+	assert.Nil(t, err)
+	assert.Len(t, history, 3)
+	assert.Equal(t, "4703879", history[0].WithdrawalId)
+	assert.Equal(t, 0.094, history[0].Amount)
+	assert.Equal(t, goex.WITHDRAW_COMPLETED, history[0].Status)
+	assert.Equal(t, goex.WITHDRAW_CANCELLED, history[1].Status)
+	assert.Equal(t, goex.WITHDRAW_PENDING, history[2].Status)
+	assert.Equal(t, time.Date(2018, 4, 22, 23, 9, 45, 0, time.UTC), history[0].Timestamp)
+
+	history, err = wallet.GetDepositHistory(&goex.BTC)
+	assert.Nil(t, err)
+	assert.Equal(t, goex.WITHDRAW_SENT, history[0].Status)
+}
+
+func TestOKExWalletV5_TransferAsset(t *testing.T) {
+	var param map[string]string
+	srv := testserver.New(okexV5API, map[string]testserver.Route{
+		"/api/v5/asset/transfer": func(r *testserver.Request) interface{} {
+			json.Unmarshal(r.Data, &param)
+			return []map[string]string{{"transId": "754147"}}
+		},
+		"/api/v5/asset/bills": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "USDT", r.URL.Query().Get("ccy"))
+			return []map[string]string{
+				{"billId": "1", "ccy": "USDT", "balChg": "-10", "type": "20", "ts": "1597026383085"},
+				{"billId": "2", "ccy": "USDT", "balChg": "5", "type": "130", "ts": "1597026383085"},
+				{"billId": "3", "ccy": "USDT", "balChg": "1", "type": "1", "ts": "1597026383085"},
+			}
+		},
+	})
+	defer srv.Close()
+	ok := NewOKEx(testserver.Config(srv))
+
+	wallet := ok.OKExWalletV5
+	id, err := wallet.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 10,
+		From: goex.ACCOUNT_SPOT, To: goex.ACCOUNT_SUB, SubAccount: "sub1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "754147", id)
+	assert.Equal(t, map[string]string{"ccy": "USDT", "amt": "10", "type": "1", "from": "18", "to": "6", "subAcct": "sub1"}, param)
+
+	_, err = wallet.TransferAsset(goex.TransferRequest{Currency: goex.USDT, Amount: 10,
+		From: goex.ACCOUNT_FUNDING, To: goex.ACCOUNT_FUTURES_USDT})
+	assert.Nil(t, err)
+	assert.Equal(t, "0", param["type"])
+	assert.Equal(t, "6", param["from"])
+	assert.Equal(t, "18", param["to"])
+
+	records, err := wallet.GetTransferHistory(goex.USDT, 0, 0)
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, goex.TransferRecord{TransferId: "1", Currency: goex.USDT, Amount: 10,
+		From: goex.ACCOUNT_FUNDING, To: goex.ACCOUNT_SUB, CreatedTime: 1597026383085}, records[0])
+
+	records, err = wallet.GetTransferHistory(goex.USDT, goex.ACCOUNT_SPOT, 0)
+	assert.Nil(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "2", records[0].TransferId)
+}
+
+func TestOKExWalletV5_SubAccount(t *testing.T) {
+	var param map[string]string
+	srv := testserver.New(okexV5API, map[string]testserver.Route{
+		"/api/v5/users/subaccount/list": func(r *testserver.Request) interface{} {
+			return []map[string]interface{}{{"enable": true, "subAcct": "strategy1", "label": "grid", "ts": "1597026383085"}}
+		},
+		"/api/v5/account/subaccount/balances": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "strategy1", r.URL.Query().Get("subAcct"))
+			return []map[string]interface{}{{"totalEq": "1200", "details": []map[string]string{
+				{"ccy": "USDT", "cashBal": "1200", "availBal": "1000", "frozenBal": "200"},
+			}}}
+		},
+		"/api/v5/users/subaccount/apikey": func(r *testserver.Request) interface{} {
+			json.Unmarshal(r.Data, &param)
+			return []map[string]string{{"subAcct": "strategy1", "label": "bot", "apiKey": "key", "secretKey": "secret",
+				"passphrase": "pass", "perm": "read_only,trade", "ip": "1.1.1.1"}}
+		},
+	})
+	defer srv.Close()
+	ok := NewOKEx(testserver.Config(srv))
+
+	wallet := ok.OKExWalletV5
+	users, err := wallet.GetSubAccounts()
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.SubAccountUser{{SubAccount: "strategy1", Name: "strategy1", Label: "grid", Enable: true,
+		CreatedTime: 1597026383085}}, users)
+
+	acc, err := wallet.GetSubAccountBalance("strategy1")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1200), acc.Asset)
+	assert.Equal(t, goex.SubAccount{Currency: goex.USDT, Amount: 1000, ForzenAmount: 200}, acc.SubAccounts[goex.USDT])
+
+	key, err := wallet.CreateSubAccountApiKey(goex.SubAccountApiKeyParameter{SubAccount: "strategy1", Label: "bot",
+		Passphrase: "pass", CanTrade: true, IpWhitelist: []string{"1.1.1.1"}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"subAcct": "strategy1", "label": "bot", "passphrase": "pass",
+		"perm": "read_only,trade", "ip": "1.1.1.1"}, param)
+	assert.Equal(t, &goex.SubAccountApiKey{SubAccount: "strategy1", Label: "bot", ApiKey: "key", SecretKey: "secret",
+		Passphrase: "pass", Permissions: []string{"read_only", "trade"}, IpWhitelist: []string{"1.1.1.1"}}, key)
+}
+
+func TestOKExWalletV5_Lending(t *testing.T) {
+	var params []map[string]string
+	srv := testserver.New(okexV5API, map[string]testserver.Route{
+		"/api/v5/asset/lending-rate-summary": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "USDT", r.URL.Query().Get("ccy"))
+			return []map[string]string{{"ccy": "USDT", "avgRate": "0.03", "estRate": "0.05"}}
+		},
+		"/api/v5/asset/saving-balance": func(r *testserver.Request) interface{} {
+			return []map[string]string{{"ccy": "USDT", "amt": "1000", "loanAmt": "800", "pendingAmt": "200",
+				"earnings": "1.5", "rate": "0.02"}}
+		},
+		"/api/v5/asset/purchase_redempt": func(r *testserver.Request) interface{} {
+			var param map[string]string
+			json.Unmarshal(r.Data, &param)
+			params = append(params, param)
+			return []map[string]string{param}
+		},
+	})
+	defer srv.Close()
+	ok := NewOKEx(testserver.Config(srv))
+
+	wallet := ok.OKExWalletV5
+	products, err := wallet.GetLendingProducts(goex.USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.LendingProduct{{ProductId: "USDT", Currency: goex.USDT, Rate: 5, CanSubscribe: true, CanRedeem: true}}, products)
+
+	positions, err := wallet.GetLendingPositions(goex.UNKNOWN)
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.LendingPosition{{ProductId: "USDT", Currency: goex.USDT, Amount: 1000, Lent: 800, Rate: 2,
+		Interest: 1.5}}, positions)
+
+	_, err = wallet.SubscribeLending(goex.LendingParameter{Currency: goex.USDT, Amount: 100, Rate: 3})
+	assert.Nil(t, err)
+	_, err = wallet.RedeemLending(goex.LendingParameter{Currency: goex.USDT})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{
+		{"ccy": "USDT", "amt": "100", "side": "purchase", "rate": "0.03"},
+		{"ccy": "USDT", "amt": "1000", "side": "redempt"},
+	}, params)
+}
+
+func TestOKExOptionsV5(t *testing.T) {
+	var orderParam map[string]string
+	srv := testserver.New(okexV5API, map[string]testserver.Route{
+		"/api/v5/public/instruments": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "OPTION", r.URL.Query().Get("instType"))
+			assert.Equal(t, "BTC-USD", r.URL.Query().Get("uly"))
+			return []map[string]string{
+				{"instId": "BTC-USD-210625-50000-C", "uly": "BTC-USD", "optType": "C", "stk": "50000", "expTime": "1624608000000",
+					"ctVal": "1", "ctMult": "0.1", "tickSz": "0.0005", "minSz": "1"},
+				{"instId": "BTC-USD-210625-40000-P", "uly": "BTC-USD", "optType": "P", "stk": "40000", "expTime": "1624608000000",
+					"ctVal": "1", "ctMult": "0.1", "tickSz": "0.0005", "minSz": "1"},
+			}
+		},
+		"/api/v5/market/ticker": func(r *testserver.Request) interface{} {
+			return []map[string]string{{"instId": "BTC-USD-210625-50000-C", "last": "0.05", "bidPx": "0.049", "askPx": "0.051", "ts": "1597026383085"}}
+		},
+		"/api/v5/public/mark-price": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "BTC-USD-210625-50000-C", r.URL.Query().Get("instId"))
+			return []map[string]string{{"instId": "BTC-USD-210625-50000-C", "markPx": "0.0502"}}
+		},
+		"/api/v5/public/opt-summary": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "BTC-USD", r.URL.Query().Get("uly"))
+			return []map[string]string{
+				{"instId": "BTC-USD-210625-40000-P", "deltaBS": "-0.2"},
+				{"instId": "BTC-USD-210625-50000-C", "deltaBS": "0.45", "gammaBS": "0.00003", "thetaBS": "-60", "vegaBS": "80",
+					"markVol": "0.8", "bidVol": "0.78", "askVol": "0.82", "fwdPx": "45000"},
+			}
+		},
+		"/api/v5/trade/order": func(r *testserver.Request) interface{} {
+			json.Unmarshal(r.Data, &orderParam)
+			return []map[string]string{{"ordId": "312269865356374016", "clOrdId": orderParam["clOrdId"], "sCode": "0"}}
+		},
+		"/api/v5/account/positions": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "OPTION", r.URL.Query().Get("instType"))
+			return []map[string]string{
+				{"instId": "BTC-USD-210625-50000-C", "pos": "-2", "avgPx": "0.06", "markPx": "0.0502", "upl": "0.002", "deltaBS": "-0.9"},
+				{"instId": "ETH-USD-210625-3000-C", "pos": "1"},
+			}
+		},
+	})
+	defer srv.Close()
+	ok := NewOKEx(testserver.Config(srv))
+
+	options := ok.OKExOptionsV5
+	instruments, err := options.GetOptionInstruments(goex.BTC_USD)
+	assert.Nil(t, err)
+	assert.Len(t, instruments, 2)
+	assert.True(t, goex.BTC_USD.Eq(instruments[0].Underlying))
+	assert.Equal(t, goex.OptionInstrument{InstrumentId: "BTC-USD-210625-50000-C", Underlying: instruments[0].Underlying, Strike: 50000,
+		OptionType: goex.OPTION_CALL, Expiry: time.Unix(1624608000, 0), ContractVal: 0.1, TickSize: 0.0005, MinSize: 1}, instruments[0])
+	assert.Equal(t, goex.OPTION_PUT, instruments[1].OptionType)
+
+	ticker, err := options.GetOptionTicker("BTC-USD-210625-50000-C")
+	assert.Nil(t, err)
+	assert.Equal(t, &goex.OptionTicker{InstrumentId: "BTC-USD-210625-50000-C", Last: 0.05, Buy: 0.049, Sell: 0.051, MarkPrice: 0.0502,
+		BidIV: 0.78, AskIV: 0.82, MarkIV: 0.8, UnderlyingPrice: 45000, Date: 1597026383085,
+		Greeks: goex.Greeks{Delta: 0.45, Gamma: 0.00003, Theta: -60, Vega: 80}}, ticker)
+
+	ord, err := options.LimitOptionOrder("BTC-USD-210625-50000-C", goex.SELL, "0.06", "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "312269865356374016", ord.OrderID2)
+	assert.Equal(t, "sell", orderParam["side"])
+	assert.Equal(t, "", orderParam["posSide"])
+	assert.Equal(t, "BTC-USD-210625-50000-C", orderParam["instId"])
+
+	positions, err := options.GetOptionPositions(goex.BTC_USD)
+	assert.Nil(t, err)
+	assert.Equal(t, []goex.OptionPosition{{InstrumentId: "BTC-USD-210625-50000-C", Amount: -2, AvgPrice: 0.06, MarkPrice: 0.0502,
+		UnrealizedPnl: 0.002, Greeks: goex.Greeks{Delta: -0.9}}}, positions)
+}
+
+func TestOKExMarginV5_IsolatedAccountAndBorrow(t *testing.T) {
+	var param map[string]string
+	srv := testserver.New(okexV5API, map[string]testserver.Route{
+		"/api/v5/account/balance": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "BTC,USDT", r.URL.Query().Get("ccy"))
+			return []map[string]interface{}{{"totalEq": "1000", "details": []map[string]string{
+				{"ccy": "BTC", "cashBal": "0.1", "availBal": "0.08", "frozenBal": "0.02"},
+				{"ccy": "USDT", "cashBal": "500", "availBal": "500", "frozenBal": "0"},
+			}}}
+		},
+		"/api/v5/account/positions": func(r *testserver.Request) interface{} {
+			assert.Equal(t, "MARGIN", r.URL.Query().Get("instType"))
+			assert.Equal(t, "BTC-USDT", r.URL.Query().Get("instId"))
+			return []map[string]string{{"instId": "BTC-USDT", "liab": "-300", "liabCcy": "USDT", "interest": "0.02",
+				"liqPx": "5000.5", "mgnRatio": "3.2"}}
+		},
+		"/api/v5/account/quick-margin-borrow-repay": func(r *testserver.Request) interface{} {
+			json.Unmarshal(r.Data, &param)
+			return []map[string]string{{"instId": "BTC-USDT", "ccy": "USDT", "side": "borrow", "amt": "100", "refId": "1637310691470124"}}
+		},
+	})
+	defer srv.Close()
+	ok := NewOKEx(testserver.Config(srv))
+
+	margin := ok.OKExMarginV5
+	margin.TdMode = TdModeIsolated
+
+	acc, err := margin.GetMarginAccount(goex.BTC_USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, 5000.5, acc.LiquidationPrice)
+	assert.Equal(t, 3.2, acc.RiskRate)
+	assert.Equal(t, goex.MarginSubAccount{Balance: 0.1, Frozen: 0.02, Available: 0.08, CanWithdraw: 0.08}, acc.Sub[goex.BTC])
+	assert.Equal(t, 300.0, acc.Sub[goex.USDT].Loan)
+	assert.Equal(t, 0.02, acc.Sub[goex.USDT].LendingFee)
+
+	borrowId, err := margin.Borrow(goex.BorrowParameter{CurrencyPair: goex.BTC_USDT, Currency: goex.USDT, Amount: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, "1637310691470124", borrowId)
+	assert.Equal(t, map[string]string{"instId": "BTC-USDT", "ccy": "USDT", "side": "borrow", "amt": "100"}, param)
+
+	margin.TdMode = TdModeCross
+	_, err = margin.Borrow(goex.BorrowParameter{CurrencyPair: goex.BTC_USDT, Currency: goex.USDT, Amount: 100})
+	assert.Equal(t, goex.EX_ERR_NOT_SUPPORT, err)
+}
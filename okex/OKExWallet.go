@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	. "github.com/lucas7788/goex"
+	"time"
 )
 
 const (
@@ -133,22 +134,52 @@ func (ok *OKExWallet) GetWithDrawalFee(currency *Currency) ([]WithdrawFee, error
 	return response, nil
 }
 
-func (ok *OKExWallet) GetWithDrawHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
-	urlPath := "/api/account/v3/withdrawal/history"
+//v3充提记录, status为原始状态码, 与v5的state含义相同
+type depositWithdrawResponse struct {
+	WithdrawalId string    `json:"withdrawal_id"`
+	Currency     string    `json:"currency"`
+	Txid         string    `json:"txid"`
+	Amount       string    `json:"amount"`
+	From         string    `json:"from"`
+	To           string    `json:"to"`
+	Memo         string    `json:"memo"`
+	Fee          string    `json:"fee"`
+	Status       string    `json:"status"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+func (ok *OKExWallet) getDepositWithdrawHistory(urlPath string, currency *Currency) ([]DepositWithdrawHistory, error) {
 	if currency != nil && *currency != UNKNOWN {
 		urlPath += "/" + currency.Symbol
 	}
-	var response []DepositWithdrawHistory
+	var response []depositWithdrawResponse
 	err := ok.DoRequest("GET", urlPath, "", &response)
-	return response, err
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]DepositWithdrawHistory, 0, len(response))
+	for _, itm := range response {
+		history = append(history, DepositWithdrawHistory{
+			WithdrawalId: itm.WithdrawalId,
+			Currency:     itm.Currency,
+			Txid:         itm.Txid,
+			Amount:       ToFloat64(itm.Amount),
+			From:         itm.From,
+			To:           itm.To,
+			Memo:         itm.Memo,
+			Fee:          itm.Fee,
+			Status:       adaptWithdrawStatusV5(itm.Status),
+			Timestamp:    itm.Timestamp,
+		})
+	}
+	return history, nil
+}
+
+func (ok *OKExWallet) GetWithDrawHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	return ok.getDepositWithdrawHistory("/api/account/v3/withdrawal/history", currency)
 }
 
 func (ok *OKExWallet) GetDepositHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
-	urlPath := "/api/account/v3/deposit/history"
-	if currency != nil && *currency != UNKNOWN {
-		urlPath += "/" + currency.Symbol
-	}
-	var response []DepositWithdrawHistory
-	err := ok.DoRequest("GET", urlPath, "", &response)
-	return response, err
+	return ok.getDepositWithdrawHistory("/api/account/v3/deposit/history", currency)
 }
@@ -3,6 +3,12 @@ package okex
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/lucas7788/goex"
 )
 
 type OKExWalletV5 struct {
@@ -10,11 +16,11 @@ type OKExWalletV5 struct {
 }
 
 type AcctBalance struct {
-	AvailBal  string  `json:"availBal"`  //可用余额
+	AvailBal  string `json:"availBal"`  //可用余额
 	CashBal   string `json:"cashBal"`   // 币种余额
-	Ccy       string  `json:"ccy"`       //币种，如 BTC
-	FrozenBal string  `json:"frozenBal"` //币种占用金额
-	OrdFrozen string  `json:"ordFrozen"` //挂单冻结数量
+	Ccy       string `json:"ccy"`       //币种，如 BTC
+	FrozenBal string `json:"frozenBal"` //币种占用金额
+	OrdFrozen string `json:"ordFrozen"` //挂单冻结数量
 }
 
 func (ok *OKExWalletV5) GetAccountBalance(ccy ...string) ([]*AcctBalance, error) {
@@ -54,3 +60,321 @@ func (ok *OKExWalletV5) GetAccountBalance(ccy ...string) ([]*AcctBalance, error)
 	}
 	return accs, nil
 }
+
+//资金账户资产
+func (ok *OKExWalletV5) GetAccount() (*Account, error) {
+	var response []Balance
+	err := ok.DoRequestV5("GET", "/api/v5/asset/balances", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &Account{
+		Exchange:    OKEX,
+		SubAccounts: make(map[Currency]SubAccount, len(response)),
+	}
+	for _, itm := range response {
+		currency := NewCurrency(itm.Ccy, "")
+		acc.SubAccounts[currency] = SubAccount{
+			Currency:     currency,
+			Amount:       ToFloat64(itm.AvailBal),
+			ForzenAmount: ToFloat64(itm.FrozenBal),
+		}
+	}
+	return acc, nil
+}
+
+/**
+ * 提币, 链名称为Chain或Currency中带的链名称, 如 USDT-TRC20
+ * Destination: 3 提币到OKEx账户(ToAddress为邮箱或手机号) 其他 提币到数字货币地址
+ */
+func (ok *OKExWalletV5) Withdrawal(param WithdrawParameter) (withdrawId string, err error) {
+	ccy := strings.ToUpper(param.Currency)
+	wp := WithdrawalParam{
+		Ccy:    strings.Split(ccy, "-")[0],
+		Amt:    FloatToString(param.Amount, 8),
+		Dest:   "4",
+		ToAddr: param.ToAddress,
+		Fee:    param.Fee,
+	}
+	if param.Chain != "" {
+		wp.Chain = param.Chain
+	} else if strings.Contains(ccy, "-") {
+		wp.Chain = ccy
+	}
+	if param.Memo != "" { //地址+标签
+		wp.ToAddr += ":" + param.Memo
+	}
+	if param.Destination == 3 {
+		wp.Dest = "3"
+	}
+
+	var response []WithdrawalRes
+	err = ok.DoRequestV5("POST", "/api/v5/asset/withdrawal", wp, &response)
+	if err != nil {
+		return "", err
+	}
+	if len(response) == 0 {
+		return "", fmt.Errorf("withdrawal response is empty")
+	}
+	return response[0].WdId, nil
+}
+
+//goex账户类型 => v5账户类型, 交易账户统一为18
+func adaptAccountTypeV5(accountType int) string {
+	switch accountType {
+	case WALLET:
+		return "6"
+	case SPOT, FUTURE, SPOT_MARGIN, SWAP, SWAP_USDT:
+		return "18"
+	}
+	return fmt.Sprint(accountType)
+}
+
+//资金划转, SubAccount不为空时为母账户转子账户
+func (ok *OKExWalletV5) Transfer(param TransferParameter) error {
+	tf := map[string]string{
+		"ccy":  strings.ToUpper(param.Currency),
+		"amt":  FloatToString(param.Amount, 8),
+		"from": adaptAccountTypeV5(param.From),
+		"to":   adaptAccountTypeV5(param.To),
+		"type": "0",
+	}
+	if param.SubAccount != "" {
+		tf["type"] = "1"
+		tf["subAcct"] = param.SubAccount
+	}
+	return ok.DoRequestV5("POST", "/api/v5/asset/transfer", tf, nil)
+}
+
+/**
+ * 提币: -3撤销中 -2已撤销 -1失败 0等待提币 1提币中 2提币成功 4~12等待审核或划转
+ * 充值: 0等待确认 1确认到账 2充值成功 8,11~13暂停或冻结
+ * 其他状态返回WITHDRAW_UNKNOWN
+ */
+func adaptWithdrawStatusV5(state string) WithdrawStatus {
+	switch state {
+	case "-2":
+		return WITHDRAW_CANCELLED
+	case "-1":
+		return WITHDRAW_FAILED
+	case "-3", "0", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13":
+		return WITHDRAW_PENDING
+	case "1":
+		return WITHDRAW_SENT
+	case "2":
+		return WITHDRAW_COMPLETED
+	}
+	return WITHDRAW_UNKNOWN
+}
+
+type depositWithdrawResponseV5 struct {
+	Ccy   string `json:"ccy"`
+	Chain string `json:"chain"`
+	Amt   string `json:"amt"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	TxId  string `json:"txId"`
+	Fee   string `json:"fee"`
+	State string `json:"state"`
+	Ts    string `json:"ts"`
+	WdId  string `json:"wdId"`
+}
+
+func (ok *OKExWalletV5) getDepositWithdrawHistory(urlPath string, currency *Currency) ([]DepositWithdrawHistory, error) {
+	if currency != nil {
+		urlPath += "?ccy=" + currency.Symbol
+	}
+	var response []depositWithdrawResponseV5
+	err := ok.DoRequestV5("GET", urlPath, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]DepositWithdrawHistory, 0, len(response))
+	for _, itm := range response {
+		history = append(history, DepositWithdrawHistory{
+			WithdrawalId: itm.WdId,
+			Currency:     itm.Ccy,
+			Txid:         itm.TxId,
+			Amount:       ToFloat64(itm.Amt),
+			From:         itm.From,
+			To:           itm.To,
+			Fee:          itm.Fee,
+			Status:       adaptWithdrawStatusV5(itm.State),
+			Timestamp:    time.Unix(0, ToInt64(itm.Ts)*int64(time.Millisecond)),
+		})
+	}
+	return history, nil
+}
+
+func (ok *OKExWalletV5) GetWithDrawHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	return ok.getDepositWithdrawHistory("/api/v5/asset/withdrawal-history", currency)
+}
+
+func (ok *OKExWalletV5) GetDepositHistory(currency *Currency) ([]DepositWithdrawHistory, error) {
+	return ok.getDepositWithdrawHistory("/api/v5/asset/deposit-history", currency)
+}
+
+//chain为链名称, 如USDT-TRC20
+func (ok *OKExWalletV5) GetDepositAddress(currency Currency, chain string) ([]ChainAddress, error) {
+	var response []DepositAddressOKRes
+	err := ok.DoRequestV5("GET", "/api/v5/asset/deposit-address?ccy="+currency.Symbol, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]ChainAddress, 0, len(response))
+	for _, itm := range response {
+		if chain != "" && !strings.EqualFold(itm.Chain, chain) {
+			continue
+		}
+		tag := itm.Tag
+		if tag == "" {
+			tag = itm.Memo
+		}
+		addresses = append(addresses, ChainAddress{
+			Currency: itm.Ccy,
+			Chain:    itm.Chain,
+			Address:  itm.Addr,
+			Tag:      tag,
+		})
+	}
+	return addresses, nil
+}
+
+func (ok *OKExWalletV5) GetWithdrawChains(currency Currency) ([]WithdrawChain, error) {
+	var response []CurrencyOKRes
+	err := ok.DoRequestV5("GET", "/api/v5/asset/currencies?ccy="+currency.Symbol, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	chains := make([]WithdrawChain, 0, len(response))
+	for _, itm := range response {
+		chains = append(chains, WithdrawChain{
+			Currency:    itm.Ccy,
+			Chain:       itm.Chain,
+			CanDeposit:  itm.CanDep,
+			CanWithdraw: itm.CanWd,
+			MinFee:      ToFloat64(itm.MinFee),
+			MaxFee:      ToFloat64(itm.MaxFee),
+			MinAmount:   ToFloat64(itm.MinWd),
+		})
+	}
+	return chains, nil
+}
+
+func (ok *OKExWalletV5) CancelWithdrawal(withdrawId string) error {
+	return ok.DoRequestV5("POST", "/api/v5/asset/cancel-withdrawal", map[string]string{"wdId": withdrawId}, nil)
+}
+
+//逻辑账户 => v5账户类型, 统一账户模式下现货/杠杆/合约都在交易账户(18)
+func adaptAccountKindV5(kind AccountKind) (string, error) {
+	switch kind {
+	case ACCOUNT_FUNDING:
+		return "6", nil
+	case ACCOUNT_SPOT, ACCOUNT_MARGIN, ACCOUNT_FUTURES_COIN, ACCOUNT_FUTURES_USDT:
+		return "18", nil
+	}
+	return "", fmt.Errorf("unsupported account kind: %s", kind)
+}
+
+/**
+ * 划转, 母子账户划转需要使用母账户的api key
+ * type 0:账户内划转 1:母账户转子账户 2:子账户转母账户
+ * 子账户默认为资金账户
+ */
+func (ok *OKExWalletV5) TransferAsset(req TransferRequest) (string, error) {
+	tf := map[string]string{
+		"ccy":  strings.ToUpper(req.Currency.Symbol),
+		"amt":  FloatToString(req.Amount, 8),
+		"type": "0",
+	}
+
+	from, to := req.From, req.To
+	subKind := req.SubAccountKind
+	if subKind == 0 {
+		subKind = ACCOUNT_FUNDING
+	}
+	switch {
+	case to == ACCOUNT_SUB:
+		tf["type"] = "1"
+		tf["subAcct"] = req.SubAccount
+		to = subKind
+	case from == ACCOUNT_SUB:
+		tf["type"] = "2"
+		tf["subAcct"] = req.SubAccount
+		from = subKind
+	}
+
+	var err error
+	if tf["from"], err = adaptAccountKindV5(from); err != nil {
+		return "", err
+	}
+	if tf["to"], err = adaptAccountKindV5(to); err != nil {
+		return "", err
+	}
+
+	var response []struct {
+		TransId string `json:"transId"`
+	}
+	err = ok.DoRequestV5("POST", "/api/v5/asset/transfer", tf, &response)
+	if err != nil {
+		return "", err
+	}
+	if len(response) == 0 {
+		return "", nil
+	}
+	return response[0].TransId, nil
+}
+
+/**
+ * 资金账户流水中的划转类型
+ * 20:转出至子账户 21:从子账户转入 22:(子账户)转出至母账户 23:(子账户)从母账户转入
+ * 130:从交易账户转入 131:转出至交易账户
+ */
+var transferBillTypesV5 = map[string][2]AccountKind{
+	"20":  {ACCOUNT_FUNDING, ACCOUNT_SUB},
+	"21":  {ACCOUNT_SUB, ACCOUNT_FUNDING},
+	"22":  {ACCOUNT_FUNDING, ACCOUNT_SUB},
+	"23":  {ACCOUNT_SUB, ACCOUNT_FUNDING},
+	"130": {ACCOUNT_SPOT, ACCOUNT_FUNDING},
+	"131": {ACCOUNT_FUNDING, ACCOUNT_SPOT},
+}
+
+//资金账户流水中的划转记录, 交易账户统一返回ACCOUNT_SPOT
+func (ok *OKExWalletV5) GetTransferHistory(currency Currency, from, to AccountKind, optional ...OptionalParameter) ([]TransferRecord, error) {
+	params := url.Values{}
+	params.Set("ccy", strings.ToUpper(currency.Symbol))
+	MergeOptionalParameter(&params, optional...)
+
+	var response []struct {
+		BillId string `json:"billId"`
+		Ccy    string `json:"ccy"`
+		BalChg string `json:"balChg"`
+		Type   string `json:"type"`
+		Ts     string `json:"ts"`
+	}
+	err := ok.DoRequestV5("GET", "/api/v5/asset/bills?"+params.Encode(), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []TransferRecord
+	for _, itm := range response {
+		kinds, isTransfer := transferBillTypesV5[itm.Type]
+		if !isTransfer || (from != 0 && from != kinds[0]) || (to != 0 && to != kinds[1]) {
+			continue
+		}
+		records = append(records, TransferRecord{
+			TransferId:  itm.BillId,
+			Currency:    NewCurrency(itm.Ccy, ""),
+			Amount:      math.Abs(ToFloat64(itm.BalChg)),
+			From:        kinds[0],
+			To:          kinds[1],
+			CreatedTime: ToInt64(itm.Ts),
+		})
+	}
+	return records, nil
+}
@@ -26,6 +26,7 @@ type OKExV3Ws struct {
 	*WsBuilder
 	once       *sync.Once
 	WsConn     *WsConn
+	connErr    error
 	respHandle func(channel string, data json.RawMessage) error
 }
 
@@ -61,10 +62,11 @@ func (okV3Ws *OKExV3Ws) getTablePrefix(currencyPair CurrencyPair, contractType s
 	return "futures"
 }
 
-func (okV3Ws *OKExV3Ws) ConnectWs() {
+func (okV3Ws *OKExV3Ws) ConnectWs() error {
 	okV3Ws.once.Do(func() {
-		okV3Ws.WsConn = okV3Ws.WsBuilder.Build()
+		okV3Ws.WsConn, okV3Ws.connErr = okV3Ws.WsBuilder.Build()
 	})
+	return okV3Ws.connErr
 }
 
 func (okV3Ws *OKExV3Ws) parseChannel(channel string) (string, error) {
@@ -127,6 +129,8 @@ func (okV3Ws *OKExV3Ws) handle(msg []byte) error {
 }
 
 func (okV3Ws *OKExV3Ws) Subscribe(sub map[string]interface{}) error {
-	okV3Ws.ConnectWs()
+	if err := okV3Ws.ConnectWs(); err != nil {
+		return err
+	}
 	return okV3Ws.WsConn.Subscribe(sub)
 }
@@ -137,7 +137,7 @@ func TestOKExFuture_GetRate(t *testing.T) {
 
 func TestOKExFuture_GetKlineRecords(t *testing.T) {
 	since := time.Now().Add(-24 * time.Hour).Unix()
-	kline, err := okex.OKExFuture.GetKlineRecords(goex.QUARTER_CONTRACT, goex.BTC_USD, goex.KLINE_PERIOD_4H, 0, int(since))
+	kline, err := okex.OKExFuture.GetKlineRecords(goex.QUARTER_CONTRACT, goex.BTC_USD, goex.KLINE_PERIOD_4H, 0, goex.OptionalParameter{"since": since})
 	assert.Nil(t, err)
 	for _, k := range kline {
 		t.Logf("%+v", k.Kline)
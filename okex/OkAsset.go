@@ -64,6 +64,8 @@ type DepositAddressOKRes struct {
 	To       string `json:"to"`       //转入账户 1：币币 3：交割合约 6：资金账户 9：永续合约 12：期权 18：统一账户
 	Selected bool   `json:"selected"` //该地址是否为页面选中的地址
 	Addr     string `json:"addr"`     //充值地址
+	Tag      string `json:"tag"`      //部分币种充值需要标签
+	Memo     string `json:"memo"`     //部分币种充值需要memo
 }
 
 //获取各个币种的充值地址，包括曾使用过的老地址。
@@ -186,13 +188,13 @@ func (self *OKExAssetV5) Transfer(Ccy string, Amt string, Ty string, From string
 }
 
 type WithdrawalParam struct {
-	Ccy    string `json:"ccy"`    //	币种，如 USDT
-	Chain  string `json:"chain"`  //	链
-	Amt    string `json:"amt"`    //	数量
-	Dest   string `json:"dest"`   //	提币到 3：欧易OKEx 4：数字货币地址
-	ToAddr string `json:"toAddr"` //	认证过的数字货币地址、邮箱或手机号。 某些数字货币地址格式为:地址+标签，如 ARDOR-7JF3-8F2E-QUWZ-CAN7F:123456
-	Pwd    string `json:"pwd"`    //	交易密码
-	Fee    string `json:"fee"`    //	网络手续费≥0，提币到数字货币地址所需网络手续费可通过获取币种列表接口查询
+	Ccy    string `json:"ccy"`             //	币种，如 USDT
+	Chain  string `json:"chain,omitempty"` //	链
+	Amt    string `json:"amt"`             //	数量
+	Dest   string `json:"dest"`            //	提币到 3：欧易OKEx 4：数字货币地址
+	ToAddr string `json:"toAddr"`          //	认证过的数字货币地址、邮箱或手机号。 某些数字货币地址格式为:地址+标签，如 ARDOR-7JF3-8F2E-QUWZ-CAN7F:123456
+	Pwd    string `json:"pwd,omitempty"`   //	交易密码
+	Fee    string `json:"fee"`             //	网络手续费≥0，提币到数字货币地址所需网络手续费可通过获取币种列表接口查询
 }
 
 type WithdrawalRes struct {
@@ -27,10 +27,18 @@ type Poloniex struct {
 	accessKey,
 	secretKey string
 	client *http.Client
+	clock  *ClockSync
 }
 
 func New(client *http.Client, accessKey, secretKey string) *Poloniex {
-	return &Poloniex{accessKey, secretKey, client}
+	poloniex := &Poloniex{accessKey: accessKey, secretKey: secretKey, client: client}
+	poloniex.clock = SharedClockSync(PUBLIC_URL, poloniex.GetServerTime)
+	return poloniex
+}
+
+//没有服务器时间接口, 使用公共接口响应头中的Date
+func (poloniex *Poloniex) GetServerTime() (int64, error) {
+	return HttpDateTime(poloniex.client, PUBLIC_URL+"?command=return24hVolume")
 }
 
 func (poloniex *Poloniex) GetExchangeName() string {
@@ -491,7 +499,7 @@ func (poloniex *Poloniex) GetDepositsWithdrawals(start, end string) (*PoloniexDe
 }
 
 func (poloniex *Poloniex) buildPostForm(postForm *url.Values) (string, error) {
-	postForm.Add("nonce", fmt.Sprintf("%d", time.Now().UnixNano()))
+	postForm.Add("nonce", fmt.Sprintf("%d", poloniex.clock.Now().UnixNano()))
 	payload := postForm.Encode()
 	//println(payload)
 	sign, err := GetParamHmacSHA512Sign(poloniex.secretKey, payload)
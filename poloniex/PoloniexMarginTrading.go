@@ -3,9 +3,12 @@ package poloniex
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	. "github.com/lucas7788/goex"
 	"log"
+	"net/http"
 	"net/url"
+	"time"
 )
 
 type PoloniexGenericResponse struct {
@@ -17,7 +20,7 @@ type PoloniexMarginPosition struct {
 	Amount            float64 `json:"amount,string"`
 	Total             float64 `json:"total,string"`
 	BasePrice         float64 `json:"basePrice,string"`
-	LiquidiationPrice float64 `json:"liquidiationPrice"`
+	LiquidiationPrice float64 `json:"liquidationPrice"`
 	ProfitLoss        float64 `json:"pl,string"`
 	LendingFees       float64 `json:"lendingFees,string"`
 	Type              string  `json:"type"`
@@ -78,3 +81,155 @@ func (poloniex *Poloniex) sendAuthenticatedRequest(values url.Values, result int
 
 	return nil
 }
+
+/**
+ * 保证金交易, 实现MarginAPI
+ * 下单时自动借币, 不支持主动借还
+ */
+type PoloniexMargin struct {
+	*Poloniex
+}
+
+func NewMargin(client *http.Client, accessKey, secretKey string) *PoloniexMargin {
+	return &PoloniexMargin{New(client, accessKey, secretKey)}
+}
+
+type poloniexActiveLoan struct {
+	Id       int64   `json:"id"`
+	Currency string  `json:"currency"`
+	Rate     float64 `json:"rate,string"`
+	Amount   float64 `json:"amount,string"`
+	Fees     float64 `json:"fees,string"`
+	Date     string  `json:"date"`
+}
+
+func (poloniex *PoloniexMargin) getUsedLoans() ([]poloniexActiveLoan, error) {
+	values := url.Values{}
+	values.Set("command", "returnActiveLoans")
+	var result struct {
+		Used []poloniexActiveLoan `json:"used"`
+	}
+	err := poloniex.sendAuthenticatedRequest(values, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Used, nil
+}
+
+/**
+ * 保证金账户, 返回所有币种
+ * RiskRate为currentMargin, LiquidationPrice为pair持仓的强平价
+ */
+func (poloniex *PoloniexMargin) GetMarginAccount(pair CurrencyPair) (*MarginAccount, error) {
+	values := url.Values{}
+	values.Set("command", "returnAvailableAccountBalances")
+	values.Set("account", "margin")
+	var balances struct {
+		Margin map[string]string `json:"margin"`
+	}
+	err := poloniex.sendAuthenticatedRequest(values, &balances)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &MarginAccount{Sub: make(map[Currency]MarginSubAccount, len(balances.Margin))}
+	for c, v := range balances.Margin {
+		available := ToFloat64(v)
+		acc.Sub[NewCurrency(c, "")] = MarginSubAccount{Balance: available, Available: available, CanWithdraw: available}
+	}
+
+	loans, err := poloniex.getUsedLoans()
+	if err != nil {
+		return nil, err
+	}
+	for _, loan := range loans {
+		c := NewCurrency(loan.Currency, "")
+		sub := acc.Sub[c]
+		sub.Loan += loan.Amount
+		sub.LendingFee += loan.Fees
+		acc.Sub[c] = sub
+	}
+
+	acc.RiskRate, err = poloniex.GetRiskRate(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	position, err := poloniex.GetMarginPosition(pair)
+	if err != nil {
+		return nil, err
+	}
+	acc.LiquidationPrice = position.LiquidiationPrice
+	return acc, nil
+}
+
+func (poloniex *PoloniexMargin) Borrow(parameter BorrowParameter) (borrowId string, err error) {
+	return "", EX_ERR_NOT_SUPPORT
+}
+
+func (poloniex *PoloniexMargin) Repayment(parameter RepaymentParameter) (repaymentId string, err error) {
+	return "", EX_ERR_NOT_SUPPORT
+}
+
+//正在使用的借款
+func (poloniex *PoloniexMargin) GetLoanHistory(pair CurrencyPair, currency Currency, optional ...OptionalParameter) ([]MarginLoan, error) {
+	used, err := poloniex.getUsedLoans()
+	if err != nil {
+		return nil, err
+	}
+
+	var loans []MarginLoan
+	for _, l := range used {
+		c := NewCurrency(l.Currency, "")
+		if currency.Symbol != "" && c != currency {
+			continue
+		}
+		createdTime, _ := time.Parse("2006-01-02 15:04:05", l.Date)
+		loans = append(loans, MarginLoan{
+			LoanId:      fmt.Sprint(l.Id),
+			Pair:        UNKNOWN_PAIR,
+			Currency:    c,
+			Amount:      l.Amount,
+			Interest:    l.Fees,
+			Status:      "used",
+			CreatedTime: createdTime.UnixNano() / int64(time.Millisecond),
+		})
+	}
+	return loans, nil
+}
+
+func (poloniex *PoloniexMargin) GetRiskRate(pair CurrencyPair) (float64, error) {
+	values := url.Values{}
+	values.Set("command", "returnMarginAccountSummary")
+	var summary struct {
+		CurrentMargin float64 `json:"currentMargin,string"`
+	}
+	err := poloniex.sendAuthenticatedRequest(values, &summary)
+	if err != nil {
+		return 0, err
+	}
+	return summary.CurrentMargin, nil
+}
+
+//只支持限价单
+func (poloniex *PoloniexMargin) PlaceOrder(ord *Order) (*Order, error) {
+	var command string
+	switch ord.Side {
+	case BUY:
+		command = "marginBuy"
+	case SELL:
+		command = "marginSell"
+	default:
+		return nil, EX_ERR_NOT_SUPPORT
+	}
+
+	result, err := poloniex.placeLimitOrder(command, FloatToString(ord.Amount, 8), FloatToString(ord.Price, 8), ord.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, EX_ERR_PLACE_ORDER_FAIL
+	}
+	result.Side = ord.Side
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package poloniex
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/lucas7788/goex"
+	"github.com/lucas7788/goex/internal/testserver"
+	"github.com/stretchr/testify/assert"
+)
+
+//tradingApi, 路由的key为command, 返回值为录制的响应; 请求地址是常量, 通过testserver.RedirectClient转发到测试服务器
+var poloniexAPI = testserver.Options{
+	Fixed: map[string]testserver.Route{
+		"/public": testserver.Reply(`{}`),
+	},
+	Check: func(r *testserver.Request) interface{} {
+		form, _ := url.ParseQuery(string(r.Data))
+		sign, _ := goex.GetParamHmacSHA512Sign("secret", form.Encode())
+		if r.URL.Path != "/tradingApi" || sign != r.Header.Get("Sign") || r.Header.Get("Key") != "key" || form.Get("nonce") == "" {
+			return `{"error":"Invalid API key/secret pair."}`
+		}
+		return nil
+	},
+	NotFound: testserver.Reply(`{"error":"Invalid command."}`),
+	Key: func(r *testserver.Request) string {
+		return r.Params()["command"]
+	},
+}
+
+func TestPoloniexMargin_GetMarginAccount(t *testing.T) {
+	srv := testserver.New(poloniexAPI, map[string]testserver.Route{
+		"returnAvailableAccountBalances": testserver.Reply(`{"margin":{"BTC":"1.50000000","USDT":"100.00000000"}}`),
+		"returnActiveLoans": testserver.Reply(`{"provided":[],"used":[{"id":75073,"currency":"USDT","rate":"0.00020000","amount":"50.00000000",
+			"duration":2,"autoRenew":0,"date":"2015-05-10 23:45:05","fees":"0.00006000"}]}`),
+		"returnMarginAccountSummary": testserver.Reply(`{"totalValue":"0.00346561","pl":"-0.00001220","lendingFees":"0.00000000","netValue":"0.00345341",
+			"totalBorrowedValue":"0.00123220","currentMargin":"2.80263755"}`),
+		"getMarginPosition": testserver.Reply(`{"amount":"40.94717831","total":"-0.09671314","basePrice":"0.00236190","liquidationPrice":0.00150000,
+			"pl":"-0.00058655","lendingFees":"-0.00000038","type":"long"}`),
+	})
+	defer srv.Close()
+	margin := NewMargin(testserver.RedirectClient(srv), "key", "secret")
+
+	acc, err := margin.GetMarginAccount(goex.BTC_USDT)
+	assert.Nil(t, err)
+	assert.Equal(t, 1.5, acc.Sub[goex.BTC].Balance)
+	assert.Equal(t, 1.5, acc.Sub[goex.BTC].Available)
+	assert.Equal(t, 100.0, acc.Sub[goex.USDT].Balance)
+	assert.Equal(t, 50.0, acc.Sub[goex.USDT].Loan)
+	assert.Equal(t, 0.00006, acc.Sub[goex.USDT].LendingFee)
+	assert.Equal(t, 2.80263755, acc.RiskRate)
+	assert.Equal(t, 0.0015, acc.LiquidationPrice)
+
+	loans, err := margin.GetLoanHistory(goex.BTC_USDT, goex.USDT)
+	assert.Nil(t, err)
+	assert.Len(t, loans, 1)
+	assert.Equal(t, "75073", loans[0].LoanId)
+	assert.Equal(t, int64(1431301505000), loans[0].CreatedTime)
+}
+
+func TestPoloniexMargin_BorrowAndRepayment(t *testing.T) {
+	margin := NewMargin(http.DefaultClient, "key", "secret")
+
+	//下单时自动借币, 不支持主动借还
+	_, err := margin.Borrow(goex.BorrowParameter{Currency: goex.USDT, CurrencyPair: goex.BTC_USDT, Amount: 10})
+	assert.Equal(t, goex.EX_ERR_NOT_SUPPORT, err)
+	_, err = margin.Repayment(goex.RepaymentParameter{BorrowId: "75073"})
+	assert.Equal(t, goex.EX_ERR_NOT_SUPPORT, err)
+}
@@ -1,6 +1,7 @@
 package goex
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -26,15 +27,18 @@ type WsConfig struct {
 	ConnectSuccessAfterSendMessage func() []byte //for reconnect
 	IsDump                         bool
 	DisableEnableCompression       bool
+	TopicFunc                      func([]byte) string //从消息中解析topic,用于分发
+	TopicDispatch                  map[string]DispatchConfig
+	DefaultDispatch                DispatchConfig
+	EventHandleFunc                func(event WsEvent)    //连接生命周期事件回调
+	TimestampFunc                  func([]byte) time.Time //从消息中解析交易所时间戳,用于统计延迟
+	ReconnectPolicy                ReconnectPolicy        //默认为LinearBackoffPolicy{reconnectInterval, 100}
+	StaleTimeout                   time.Duration          //有订阅但超过该时间没有收到数据时强制重连,0表示不检测
 	readDeadLineTime               time.Duration
 	reconnectInterval              time.Duration
 }
 
-var dialer = &websocket.Dialer{
-	Proxy:             http.ProxyFromEnvironment,
-	HandshakeTimeout:  30 * time.Second,
-	EnableCompression: true,
-}
+var ErrWsClosed = errors.New("websocket closed")
 
 type WsConn struct {
 	c *websocket.Conn
@@ -44,8 +48,14 @@ type WsConn struct {
 	pongMessageBufferChan  chan []byte
 	closeMessageBufferChan chan []byte
 	subs                   [][]byte
-	close                  chan bool
+	ctx                    context.Context
+	cancel                 context.CancelFunc
+	done                   chan struct{}
+	wg                     sync.WaitGroup
+	connLock               sync.RWMutex //保护c和subs,重连时会替换c
 	reConnectLock          *sync.Mutex
+	dispatcher             *wsDispatcher
+	stats                  *wsStatsCollector
 }
 
 type WsBuilder struct {
@@ -120,42 +130,119 @@ func (b *WsBuilder) ConnectSuccessAfterSendMessage(msg func() []byte) *WsBuilder
 	return b
 }
 
-func (b *WsBuilder) Build() *WsConn {
+// TopicFunc 设置从原始消息中解析topic的函数,不同topic的消息在各自的队列中异步回调
+func (b *WsBuilder) TopicFunc(f func([]byte) string) *WsBuilder {
+	b.wsConfig.TopicFunc = f
+	return b
+}
+
+// TopicDispatch 设置某个topic的分发策略和队列大小
+func (b *WsBuilder) TopicDispatch(topic string, policy DispatchPolicy, queueSize int) *WsBuilder {
+	if b.wsConfig.TopicDispatch == nil {
+		b.wsConfig.TopicDispatch = make(map[string]DispatchConfig, 2)
+	}
+	b.wsConfig.TopicDispatch[topic] = DispatchConfig{Policy: policy, QueueSize: queueSize}
+	return b
+}
+
+// DefaultDispatch 设置没有单独配置的topic的分发策略,默认为DispatchSync
+func (b *WsBuilder) DefaultDispatch(policy DispatchPolicy, queueSize int) *WsBuilder {
+	b.wsConfig.DefaultDispatch = DispatchConfig{Policy: policy, QueueSize: queueSize}
+	return b
+}
+
+// EventHandleFunc 设置连接生命周期事件(connected,disconnected,resubscribed,give up)回调
+func (b *WsBuilder) EventHandleFunc(f func(event WsEvent)) *WsBuilder {
+	b.wsConfig.EventHandleFunc = f
+	return b
+}
+
+// TimestampFunc 设置从消息中解析交易所时间戳的函数,用于统计交易所到本地的延迟
+func (b *WsBuilder) TimestampFunc(f func([]byte) time.Time) *WsBuilder {
+	b.wsConfig.TimestampFunc = f
+	return b
+}
+
+// ReconnectPolicy 设置重连策略,比如指数退避ExponentialBackoffPolicy,不重连FailFastPolicy
+func (b *WsBuilder) ReconnectPolicy(policy ReconnectPolicy) *WsBuilder {
+	b.wsConfig.ReconnectPolicy = policy
+	return b
+}
+
+// StaleTimeout 有订阅的情况下超过t没有收到任何数据(即使ping正常)时强制重连
+func (b *WsBuilder) StaleTimeout(t time.Duration) *WsBuilder {
+	b.wsConfig.StaleTimeout = t
+	return b
+}
+
+func (b *WsBuilder) Build() (*WsConn, error) {
 	wsConn := &WsConn{WsConfig: *b.wsConfig}
 	return wsConn.NewWs()
 }
 
-func (ws *WsConn) NewWs() *WsConn {
+func (ws *WsConn) NewWs() (*WsConn, error) {
 	if ws.HeartbeatIntervalTime == 0 {
 		ws.readDeadLineTime = time.Minute
 	} else {
 		ws.readDeadLineTime = ws.HeartbeatIntervalTime * 2
 	}
 
+	ws.stats = newWsStatsCollector(ws.WsUrl)
+
 	if err := ws.connect(); err != nil {
-		Log.Panic(fmt.Errorf("[%s] %s", ws.WsUrl, err.Error()))
+		return nil, fmt.Errorf("[%s] %s", ws.WsUrl, err.Error())
 	}
+	ws.stats.onConnected(false)
+	ws.fireEvent(WsEvent{Type: WsEventConnected})
 
-	ws.close = make(chan bool, 1)
+	ws.ctx, ws.cancel = context.WithCancel(context.Background())
+	ws.done = make(chan struct{})
 	ws.pingMessageBufferChan = make(chan []byte, 10)
 	ws.pongMessageBufferChan = make(chan []byte, 10)
 	ws.closeMessageBufferChan = make(chan []byte, 10)
 	ws.writeBufferChan = make(chan []byte, 10)
 	ws.reConnectLock = new(sync.Mutex)
 
+	if ws.ReconnectPolicy == nil {
+		ws.ReconnectPolicy = LinearBackoffPolicy{Interval: ws.reconnectInterval, MaxRetry: 100}
+	}
+
+	if ws.TopicFunc != nil || len(ws.TopicDispatch) > 0 || ws.DefaultDispatch.Policy != DispatchSync {
+		ws.dispatcher = newWsDispatcher(ws.ProtoHandleFunc, ws.TopicDispatch, ws.DefaultDispatch, ws.ctx.Done())
+	}
+
+	ws.wg.Add(2)
 	go ws.writeRequest()
 	go ws.receiveMessage()
+	if ws.StaleTimeout > 0 {
+		ws.wg.Add(1)
+		go ws.staleWatch()
+	}
+	go func() {
+		ws.wg.Wait()
+		if ws.dispatcher != nil {
+			ws.dispatcher.stop()
+		}
+		close(ws.done)
+	}()
 
 	if ws.ConnectSuccessAfterSendMessage != nil {
 		msg := ws.ConnectSuccessAfterSendMessage()
 		ws.SendMessage(msg)
-		Log.Infof("[ws] [%s] execute the connect success after send message=%s", ws.WsUrl, string(msg))
+		//登录消息包含api key和签名, 不记录消息内容
+		Log.Infof("[ws] [%s] execute the connect success after send message", ws.WsUrl)
 	}
 
-	return ws
+	return ws, nil
 }
 
-func (ws *WsConn) connect() error {
+//每个连接使用自己的Dialer, 代理和压缩设置不会影响其他连接
+func (ws *WsConn) newDialer() *websocket.Dialer {
+	dialer := &websocket.Dialer{
+		Proxy:             http.ProxyFromEnvironment,
+		HandshakeTimeout:  30 * time.Second,
+		EnableCompression: !ws.DisableEnableCompression,
+	}
 	if ws.ProxyUrl != "" {
 		proxy, err := url.Parse(ws.ProxyUrl)
 		if err == nil {
@@ -165,71 +252,139 @@ func (ws *WsConn) connect() error {
 			Log.Errorf("[ws][%s]parse proxy url [%s] err %s  ", ws.WsUrl, ws.ProxyUrl, err.Error())
 		}
 	}
+	return dialer
+}
 
-	if ws.DisableEnableCompression {
-		dialer.EnableCompression = false
-	}
-
-	wsConn, resp, err := dialer.Dial(ws.WsUrl, http.Header(ws.ReqHeaders))
+func (ws *WsConn) connect() error {
+	wsConn, resp, err := ws.newDialer().Dial(ws.WsUrl, http.Header(ws.ReqHeaders))
 	if err != nil {
 		Log.Errorf("[ws][%s] %s", ws.WsUrl, err.Error())
 		if ws.IsDump && resp != nil {
 			dumpData, _ := httputil.DumpResponse(resp, true)
 			Log.Debugf("[ws][%s] %s", ws.WsUrl, string(dumpData))
 		}
+		if resp != nil {
+			return &WsHandshakeError{StatusCode: resp.StatusCode, Err: err}
+		}
 		return err
 	}
 
 	wsConn.SetReadDeadline(time.Now().Add(ws.readDeadLineTime))
 
+	wsConn.SetCloseHandler(func(code int, text string) error {
+		Log.Warnf("[ws][%s] websocket exiting [code=%d , text=%s]", ws.WsUrl, code, text)
+		return nil
+	})
+
+	wsConn.SetPongHandler(func(pong string) error {
+		Log.Debugf("[%s] received [pong] %s", ws.WsUrl, pong)
+		wsConn.SetReadDeadline(time.Now().Add(ws.readDeadLineTime))
+		return nil
+	})
+
+	wsConn.SetPingHandler(func(ping string) error {
+		Log.Debugf("[%s] received [ping] %s", ws.WsUrl, ping)
+		ws.SendPongMessage([]byte(ping))
+		wsConn.SetReadDeadline(time.Now().Add(ws.readDeadLineTime))
+		return nil
+	})
+
 	if ws.IsDump {
 		dumpData, _ := httputil.DumpResponse(resp, true)
 		Log.Debugf("[ws][%s] %s", ws.WsUrl, string(dumpData))
 	}
 	Log.Infof("[ws][%s] connected", ws.WsUrl)
+
+	ws.connLock.Lock()
 	ws.c = wsConn
+	ws.connLock.Unlock()
 	return nil
 }
 
+func (ws *WsConn) conn() *websocket.Conn {
+	ws.connLock.RLock()
+	defer ws.connLock.RUnlock()
+	return ws.c
+}
+
 func (ws *WsConn) reconnect() {
 	ws.reConnectLock.Lock()
 	defer ws.reConnectLock.Unlock()
 
-	ws.c.Close() //主动关闭一次
-	var err error
-	for retry := 1; retry <= 100; retry++ {
+	ws.conn().Close() //主动关闭一次
+	var (
+		err   error
+		retry int
+	)
+	for retry = 1; ; retry++ {
+		if ws.isClosed() {
+			return
+		}
 		err = ws.connect()
-		if err != nil {
-			Log.Errorf("[ws] [%s] websocket reconnect fail , %s", ws.WsUrl, err.Error())
-		} else {
+		if err == nil {
+			if ws.isClosed() { //重连期间被关闭
+				ws.conn().Close()
+				return
+			}
+			break
+		}
+
+		Log.Errorf("[ws] [%s] websocket reconnect fail , %s", ws.WsUrl, err.Error())
+		delay, ok := ws.ReconnectPolicy.NextDelay(retry, err)
+		if !ok {
 			break
 		}
-		time.Sleep(ws.WsConfig.reconnectInterval * time.Duration(retry))
+		Log.Infof("[ws] [%s] retry connect after %s", ws.WsUrl, delay)
+		if !ws.sleep(delay) {
+			return
+		}
 	}
 
 	if err != nil {
-		Log.Errorf("[ws] [%s] retry connect 100 count fail , begin exiting. ", ws.WsUrl)
-		ws.CloseWs()
+		Log.Errorf("[ws] [%s] retry connect %d count fail , begin exiting. ", ws.WsUrl, retry)
+		ws.shutdown()
+		ws.fireEvent(WsEvent{Type: WsEventGiveUp, Attempt: retry, Err: err})
 		if ws.ErrorHandleFunc != nil {
 			ws.ErrorHandleFunc(errors.New("retry reconnect fail"))
 		}
 	} else {
+		ws.stats.onConnected(true)
+		ws.fireEvent(WsEvent{Type: WsEventConnected, Attempt: retry})
+
 		//re subscribe
 		if ws.ConnectSuccessAfterSendMessage != nil {
 			msg := ws.ConnectSuccessAfterSendMessage()
 			ws.SendMessage(msg)
-			Log.Infof("[ws] [%s] execute the connect success after send message=%s", ws.WsUrl, string(msg))
-			time.Sleep(time.Second) //wait response
+			Log.Infof("[ws] [%s] execute the connect success after send message", ws.WsUrl)
+			ws.sleep(time.Second) //wait response
 		}
 
-		for _, sub := range ws.subs {
+		ws.connLock.RLock()
+		subs := ws.subs
+		ws.connLock.RUnlock()
+		for _, sub := range subs {
 			Log.Info("[ws] re subscribe: ", string(sub))
 			ws.SendMessage(sub)
 		}
+		ws.fireEvent(WsEvent{Type: WsEventResubscribed, Attempt: retry})
+	}
+}
+
+// sleep 等待d时长,连接被关闭时立即返回false
+func (ws *WsConn) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ws.ctx.Done():
+		return false
+	case <-t.C:
+		return true
 	}
 }
 
 func (ws *WsConn) writeRequest() {
+	defer ws.wg.Done()
+
 	var (
 		heartTimer *time.Timer
 		err        error
@@ -240,23 +395,28 @@ func (ws *WsConn) writeRequest() {
 	} else {
 		heartTimer = time.NewTimer(ws.HeartbeatIntervalTime)
 	}
+	defer heartTimer.Stop()
 
 	for {
 		select {
-		case <-ws.close:
+		case <-ws.ctx.Done():
 			Log.Infof("[ws][%s] close websocket , exiting write message goroutine.", ws.WsUrl)
+			c := ws.conn()
+			c.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			c.Close()
 			return
 		case d := <-ws.writeBufferChan:
-			err = ws.c.WriteMessage(websocket.TextMessage, d)
+			err = ws.conn().WriteMessage(websocket.TextMessage, d)
 		case d := <-ws.pingMessageBufferChan:
-			err = ws.c.WriteMessage(websocket.PingMessage, d)
+			err = ws.conn().WriteMessage(websocket.PingMessage, d)
 		case d := <-ws.pongMessageBufferChan:
-			err = ws.c.WriteMessage(websocket.PongMessage, d)
+			err = ws.conn().WriteMessage(websocket.PongMessage, d)
 		case d := <-ws.closeMessageBufferChan:
-			err = ws.c.WriteMessage(websocket.CloseMessage, d)
+			err = ws.conn().WriteMessage(websocket.CloseMessage, d)
 		case <-heartTimer.C:
 			if ws.HeartbeatIntervalTime > 0 {
-				err = ws.c.WriteMessage(websocket.TextMessage, ws.HeartbeatData())
+				err = ws.conn().WriteMessage(websocket.TextMessage, ws.HeartbeatData())
 				heartTimer.Reset(ws.HeartbeatIntervalTime)
 			}
 		}
@@ -275,25 +435,60 @@ func (ws *WsConn) Subscribe(subEvent interface{}) error {
 		return err
 	}
 	Log.Debug(string(data))
-	ws.writeBufferChan <- data
+	if err = ws.send(ws.writeBufferChan, data); err != nil {
+		return err
+	}
+	ws.connLock.Lock()
 	ws.subs = append(ws.subs, data)
+	ws.connLock.Unlock()
 	return nil
 }
 
-func (ws *WsConn) SendMessage(msg []byte) {
-	ws.writeBufferChan <- msg
+// send 写入发送队列,连接关闭后返回ErrWsClosed,不会阻塞
+func (ws *WsConn) send(ch chan []byte, msg []byte) error {
+	select {
+	case <-ws.ctx.Done():
+		return ErrWsClosed
+	default:
+	}
+
+	select {
+	case ch <- msg:
+		return nil
+	case <-ws.ctx.Done():
+		return ErrWsClosed
+	}
+}
+
+func (ws *WsConn) SendMessage(msg []byte) error {
+	return ws.send(ws.writeBufferChan, msg)
+}
+
+// SendMessageContext 同SendMessage,ctx被取消时放弃发送
+func (ws *WsConn) SendMessageContext(ctx context.Context, msg []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case ws.writeBufferChan <- msg:
+		return nil
+	case <-ws.ctx.Done():
+		return ErrWsClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (ws *WsConn) SendPingMessage(msg []byte) {
-	ws.pingMessageBufferChan <- msg
+func (ws *WsConn) SendPingMessage(msg []byte) error {
+	return ws.send(ws.pingMessageBufferChan, msg)
 }
 
-func (ws *WsConn) SendPongMessage(msg []byte) {
-	ws.pongMessageBufferChan <- msg
+func (ws *WsConn) SendPongMessage(msg []byte) error {
+	return ws.send(ws.pongMessageBufferChan, msg)
 }
 
-func (ws *WsConn) SendCloseMessage(msg []byte) {
-	ws.closeMessageBufferChan <- msg
+func (ws *WsConn) SendCloseMessage(msg []byte) error {
+	return ws.send(ws.closeMessageBufferChan, msg)
 }
 
 func (ws *WsConn) SendJsonMessage(m interface{}) error {
@@ -301,97 +496,173 @@ func (ws *WsConn) SendJsonMessage(m interface{}) error {
 	if err != nil {
 		return err
 	}
-	ws.writeBufferChan <- data
-	return nil
+	return ws.send(ws.writeBufferChan, data)
 }
 
 func (ws *WsConn) receiveMessage() {
-	//exit
-	ws.c.SetCloseHandler(func(code int, text string) error {
-		Log.Warnf("[ws][%s] websocket exiting [code=%d , text=%s]", ws.WsUrl, code, text)
-		//ws.CloseWs()
-		return nil
-	})
-
-	ws.c.SetPongHandler(func(pong string) error {
-		Log.Debugf("[%s] received [pong] %s", ws.WsUrl, pong)
-		ws.c.SetReadDeadline(time.Now().Add(ws.readDeadLineTime))
-		return nil
-	})
-
-	ws.c.SetPingHandler(func(ping string) error {
-		Log.Debugf("[%s] received [ping] %s", ws.WsUrl, ping)
-		ws.SendPongMessage([]byte(ping))
-		ws.c.SetReadDeadline(time.Now().Add(ws.readDeadLineTime))
-		return nil
-	})
+	defer ws.wg.Done()
 
 	for {
-		select {
-		case <-ws.close:
+		if ws.isClosed() {
 			Log.Infof("[ws][%s] close websocket , exiting receive message goroutine.", ws.WsUrl)
 			return
-		default:
-			t, msg, err := ws.c.ReadMessage()
-			if err != nil {
-				Log.Errorf("[ws][%s] %s", ws.WsUrl, err.Error())
-				if ws.IsAutoReconnect {
-					Log.Infof("[ws][%s] Unexpected Closed , Begin Retry Connect.", ws.WsUrl)
-					ws.reconnect()
-					continue
-				}
-
-				if ws.ErrorHandleFunc != nil {
-					ws.ErrorHandleFunc(err)
-				}
+		}
 
+		c := ws.conn()
+		t, msg, err := c.ReadMessage()
+		if err != nil {
+			if ws.isClosed() {
+				Log.Infof("[ws][%s] close websocket , exiting receive message goroutine.", ws.WsUrl)
 				return
 			}
-			//			Log.Debug(string(msg))
-			ws.c.SetReadDeadline(time.Now().Add(ws.readDeadLineTime))
-			switch t {
-			case websocket.TextMessage:
-				ws.ProtoHandleFunc(msg)
-			case websocket.BinaryMessage:
-				if ws.DecompressFunc == nil {
-					ws.ProtoHandleFunc(msg)
+			Log.Errorf("[ws][%s] %s", ws.WsUrl, err.Error())
+			ws.stats.onDisconnected()
+			ws.fireEvent(WsEvent{Type: WsEventDisconnected, Err: err})
+			if ws.IsAutoReconnect {
+				Log.Infof("[ws][%s] Unexpected Closed , Begin Retry Connect.", ws.WsUrl)
+				ws.reconnect()
+				continue
+			}
+
+			if ws.ErrorHandleFunc != nil {
+				ws.ErrorHandleFunc(err)
+			}
+
+			ws.shutdown()
+			return
+		}
+		//			Log.Debug(string(msg))
+		c.SetReadDeadline(time.Now().Add(ws.readDeadLineTime))
+		switch t {
+		case websocket.TextMessage:
+			ws.handleMessage(msg)
+		case websocket.BinaryMessage:
+			if ws.DecompressFunc == nil {
+				ws.handleMessage(msg)
+			} else {
+				msg2, err := ws.DecompressFunc(msg)
+				if err != nil {
+					Log.Errorf("[ws][%s] decompress error %s", ws.WsUrl, err.Error())
 				} else {
-					msg2, err := ws.DecompressFunc(msg)
-					if err != nil {
-						Log.Errorf("[ws][%s] decompress error %s", ws.WsUrl, err.Error())
-					} else {
-						ws.ProtoHandleFunc(msg2)
-					}
+					ws.handleMessage(msg2)
 				}
-				//	case websocket.CloseMessage:
-				//	ws.CloseWs()
-			default:
-				Log.Errorf("[ws][%s] error websocket message type , content is :\n %s \n", ws.WsUrl, string(msg))
 			}
+			//	case websocket.CloseMessage:
+			//	ws.CloseWs()
+		default:
+			Log.Errorf("[ws][%s] error websocket message type , content is :\n %s \n", ws.WsUrl, string(msg))
 		}
 	}
 }
 
-func (ws *WsConn) CloseWs() {
-	//ws.close <- true
-	close(ws.close)
-	close(ws.writeBufferChan)
-	close(ws.closeMessageBufferChan)
-	close(ws.pingMessageBufferChan)
-	close(ws.pongMessageBufferChan)
-
-	err := ws.c.Close()
-	if err != nil {
-		Log.Error("[ws][", ws.WsUrl, "] close websocket error ,", err)
+func (ws *WsConn) handleMessage(msg []byte) {
+	topic := ""
+	if ws.TopicFunc != nil {
+		topic = ws.TopicFunc(msg)
 	}
+
+	var exTs time.Time
+	if ws.TimestampFunc != nil {
+		exTs = ws.TimestampFunc(msg)
+	}
+	ws.stats.onMessage(topic, len(msg), exTs)
+
+	if ws.dispatcher == nil {
+		ws.ProtoHandleFunc(msg)
+		return
+	}
+	ws.dispatcher.dispatch(topic, msg)
+}
+
+func (ws *WsConn) fireEvent(event WsEvent) {
+	if ws.EventHandleFunc == nil {
+		return
+	}
+	event.WsUrl = ws.WsUrl
+	event.Time = time.Now()
+	ws.EventHandleFunc(event)
 }
 
-func (ws *WsConn) clearChannel(c chan struct{}) {
+// Stats 返回连接的统计信息,包括每个topic的消息数,字节数,最后消息时间和延迟
+func (ws *WsConn) Stats() WsStats {
+	return ws.stats.snapshot()
+}
+
+// DispatchStats 返回每个topic的分发统计(丢弃数,阻塞数等),未配置分发时返回nil
+func (ws *WsConn) DispatchStats() []DispatchStats {
+	if ws.dispatcher == nil {
+		return nil
+	}
+	return ws.dispatcher.stats()
+}
+
+// staleWatch 有订阅但长时间收不到数据时关闭底层连接,由receiveMessage触发重连
+func (ws *WsConn) staleWatch() {
+	defer ws.wg.Done()
+
+	ticker := time.NewTicker(ws.StaleTimeout / 2)
+	defer ticker.Stop()
+
 	for {
-		if len(c) > 0 {
-			<-c
-		} else {
-			break
+		select {
+		case <-ws.ctx.Done():
+			return
+		case <-ticker.C:
+			ws.connLock.RLock()
+			subCount := len(ws.subs)
+			ws.connLock.RUnlock()
+			if subCount == 0 {
+				continue
+			}
+
+			stats := ws.stats.snapshot()
+			if !stats.Connected {
+				continue
+			}
+			last := stats.LastMessageTime
+			if stats.ConnectedTime.After(last) {
+				last = stats.ConnectedTime
+			}
+			if time.Since(last) > ws.StaleTimeout {
+				Log.Warnf("[ws][%s] no data received in %s , force reconnect", ws.WsUrl, ws.StaleTimeout)
+				ws.conn().Close()
+			}
 		}
 	}
 }
+
+func (ws *WsConn) isClosed() bool {
+	select {
+	case <-ws.ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// shutdown 通知所有协程退出,不等待.写协程退出时发送close帧并关闭底层连接,使阻塞中的ReadMessage立即返回
+func (ws *WsConn) shutdown() {
+	ws.cancel()
+}
+
+// Done 返回一个channel,在连接关闭且所有内部协程退出后被关闭
+func (ws *WsConn) Done() <-chan struct{} {
+	return ws.done
+}
+
+// Close 关闭连接并等待读写及分发协程退出,ctx超时或取消时返回ctx.Err()
+// 注意:不要在ProtoHandleFunc回调中调用Close,否则会一直等待到ctx结束
+func (ws *WsConn) Close(ctx context.Context) error {
+	ws.shutdown()
+	select {
+	case <-ws.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CloseWs 关闭连接,不等待协程退出
+func (ws *WsConn) CloseWs() {
+	ws.shutdown()
+}
@@ -1,8 +1,14 @@
 package goex
 
 import (
+	"context"
 	"encoding/json"
+	"github.com/gorilla/websocket"
+	"net/http"
 	. "github.com/lucas7788/goex/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
 	"testing"
 	"time"
 )
@@ -39,9 +45,12 @@ func TestNewWsConn(t *testing.T) {
 	//fmt.Println(ping2)
 	//fmt.Println(err, string(ping3))
 
-	ws := NewWsBuilder().Dump().WsUrl("wss://api.fcoin.com/v2/ws").
+	ws, err := NewWsBuilder().Dump().WsUrl("wss://api.fcoin.com/v2/ws").
 		ProxyUrl("socks5://127.0.0.1:1080").AutoReconnect().
 		Heartbeat(heartbeatFunc, 5*time.Second).ProtoHandleFunc(ProtoHandle).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
 	t.Log(ws.Subscribe(map[string]string{
 		//"cmd":"sub", "args":"[\"ticker.btcusdt\"]", "id": clientId}))
 		"cmd":"sub", "args":"ticker.btcusdt", "id": clientId}))
@@ -49,3 +58,86 @@ func TestNewWsConn(t *testing.T) {
 	ws.c.Close()
 	time.Sleep(time.Second*120)
 }
+
+func TestWsConn_ProxyIsPerConnection(t *testing.T) {
+	proxied := &WsConn{WsConfig: WsConfig{WsUrl: "ws://127.0.0.1/ws", ProxyUrl: "socks5://127.0.0.1:1080", DisableEnableCompression: true}}
+	d := proxied.newDialer()
+	assert.False(t, d.EnableCompression)
+	assert.NotNil(t, d.Proxy)
+
+	plain := (&WsConn{WsConfig: WsConfig{WsUrl: "ws://127.0.0.1/ws"}}).newDialer()
+	assert.True(t, plain.EnableCompression)
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/ws", nil)
+	proxyUrl, err := plain.Proxy(req)
+	assert.Nil(t, err)
+	assert.Nil(t, proxyUrl)
+}
+
+func TestWsConn_BuildError(t *testing.T) {
+	ws, err := NewWsBuilder().WsUrl("ws://127.0.0.1:1/ws").ProtoHandleFunc(ProtoHandle).Build()
+	assert.Nil(t, ws)
+	assert.Error(t, err)
+}
+
+func TestWsConn_Close(t *testing.T) {
+	srv, wsUrl := newLocalWsServer(func(c *websocket.Conn) {
+		for {
+			if err := c.WriteMessage(websocket.TextMessage, []byte("tick")); err != nil {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+	defer srv.Close()
+
+	ws, err := NewWsBuilder().WsUrl(wsUrl).AutoReconnect().
+		ProtoHandleFunc(func(data []byte) error { return nil }).Build()
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if err := ws.SendMessage([]byte("ping")); err != nil {
+					assert.Equal(t, ErrWsClosed, err)
+					return
+				}
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	assert.Nil(t, ws.Close(ctx))
+
+	select {
+	case <-ws.Done():
+	default:
+		t.Fatal("done channel not closed")
+	}
+	wg.Wait()
+
+	assert.Equal(t, ErrWsClosed, ws.SendMessage([]byte("after close")))
+	assert.Equal(t, ErrWsClosed, ws.Subscribe(map[string]string{"op": "sub"}))
+	ws.CloseWs() //重复关闭不会panic
+}
+
+func TestWsConn_SendMessageContext(t *testing.T) {
+	srv, wsUrl := newLocalWsServer(func(c *websocket.Conn) {
+		time.Sleep(time.Second)
+	})
+	defer srv.Close()
+
+	ws, err := NewWsBuilder().WsUrl(wsUrl).ProtoHandleFunc(ProtoHandle).Build()
+	require.NoError(t, err)
+	defer ws.CloseWs()
+
+	assert.Nil(t, ws.SendMessageContext(context.Background(), []byte("msg")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, ws.SendMessageContext(ctx, []byte("msg")))
+}
@@ -0,0 +1,273 @@
+package goex
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/lucas7788/goex/internal/logger"
+)
+
+type DispatchPolicy int
+
+const (
+	DispatchSync           DispatchPolicy = iota //在读协程中直接回调(默认)
+	DispatchDropOldest                           //队列满时丢弃最旧的消息,适合depth/ticker
+	DispatchCoalesceLatest                       //只保留最新一条未处理消息,适合全量depth/ticker
+	DispatchGuaranteed                           //队列满时阻塞读协程,保证不丢消息,适合trade/order
+)
+
+func (p DispatchPolicy) String() string {
+	switch p {
+	case DispatchSync:
+		return "sync"
+	case DispatchDropOldest:
+		return "drop_oldest"
+	case DispatchCoalesceLatest:
+		return "coalesce_latest"
+	case DispatchGuaranteed:
+		return "guaranteed"
+	default:
+		return "unknown"
+	}
+}
+
+type DispatchConfig struct {
+	Policy    DispatchPolicy
+	QueueSize int
+}
+
+type DispatchStats struct {
+	Topic     string
+	Policy    DispatchPolicy
+	Queued    int   //当前队列中等待处理的消息数
+	Delivered int64 //已经回调的消息数
+	Dropped   int64 //被丢弃或被合并的消息数
+	Delayed   int64 //因队列满而阻塞读协程的次数
+}
+
+type topicQueue struct {
+	topic     string
+	cfg       DispatchConfig
+	ch        chan []byte
+	latest    []byte
+	hasLatest bool
+	mu        sync.Mutex
+	notify    chan struct{}
+	delivered int64
+	dropped   int64
+	delayed   int64
+}
+
+type wsDispatcher struct {
+	handle        func([]byte) error
+	topics        map[string]DispatchConfig
+	defaultConfig DispatchConfig
+	queues        map[string]*topicQueue
+	lock          sync.RWMutex
+	close         chan struct{}
+	abort         <-chan struct{} //连接关闭时取消Guaranteed队列的阻塞等待
+	wg            sync.WaitGroup
+}
+
+//abort为连接的ctx.Done(), 读协程阻塞在满的Guaranteed队列时可以通过它退出
+func newWsDispatcher(handle func([]byte) error, topics map[string]DispatchConfig, defaultConfig DispatchConfig, abort <-chan struct{}) *wsDispatcher {
+	return &wsDispatcher{
+		handle:        handle,
+		topics:        topics,
+		defaultConfig: defaultConfig,
+		queues:        make(map[string]*topicQueue, 4),
+		close:         make(chan struct{}),
+		abort:         abort,
+	}
+}
+
+func (d *wsDispatcher) dispatch(topic string, msg []byte) {
+	q := d.queue(topic)
+	if q.cfg.Policy == DispatchSync {
+		d.deliver(q, msg)
+		return
+	}
+	q.push(msg, d.close, d.abort)
+}
+
+func (d *wsDispatcher) queue(topic string) *topicQueue {
+	d.lock.RLock()
+	q, ok := d.queues[topic]
+	d.lock.RUnlock()
+	if ok {
+		return q
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if q, ok = d.queues[topic]; ok {
+		return q
+	}
+
+	cfg, ok := d.topics[topic]
+	if !ok {
+		cfg = d.defaultConfig
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1
+	}
+
+	q = &topicQueue{topic: topic, cfg: cfg, notify: make(chan struct{}, 1)}
+	if cfg.Policy == DispatchDropOldest || cfg.Policy == DispatchGuaranteed {
+		q.ch = make(chan []byte, cfg.QueueSize)
+	}
+	d.queues[topic] = q
+
+	if cfg.Policy != DispatchSync {
+		d.wg.Add(1)
+		go d.consume(q)
+	}
+	return q
+}
+
+func (q *topicQueue) push(msg []byte, closeCh chan struct{}, abort <-chan struct{}) {
+	switch q.cfg.Policy {
+	case DispatchCoalesceLatest:
+		q.mu.Lock()
+		if q.hasLatest {
+			atomic.AddInt64(&q.dropped, 1)
+		}
+		q.latest = msg
+		q.hasLatest = true
+		q.mu.Unlock()
+		select {
+		case q.notify <- struct{}{}:
+		default:
+		}
+	case DispatchDropOldest:
+		for {
+			select {
+			case q.ch <- msg:
+				return
+			default:
+			}
+			select {
+			case <-q.ch:
+				atomic.AddInt64(&q.dropped, 1)
+			default:
+			}
+		}
+	case DispatchGuaranteed:
+		select {
+		case q.ch <- msg:
+			return
+		default:
+		}
+		atomic.AddInt64(&q.delayed, 1)
+		select {
+		case q.ch <- msg:
+		case <-closeCh:
+		case <-abort:
+		}
+	}
+}
+
+func (d *wsDispatcher) consume(q *topicQueue) {
+	defer d.wg.Done()
+	for {
+		if q.cfg.Policy == DispatchCoalesceLatest {
+			select {
+			case <-d.close:
+				d.drain(q)
+				return
+			case <-q.notify:
+				d.deliverLatest(q)
+			}
+			continue
+		}
+
+		select {
+		case <-d.close:
+			d.drain(q)
+			return
+		case msg := <-q.ch:
+			d.deliver(q, msg)
+		}
+	}
+}
+
+func (d *wsDispatcher) deliverLatest(q *topicQueue) {
+	q.mu.Lock()
+	msg, ok := q.latest, q.hasLatest
+	q.latest, q.hasLatest = nil, false
+	q.mu.Unlock()
+	if ok {
+		d.deliver(q, msg)
+	}
+}
+
+//停止时把队列中剩余的消息回调完再退出, 读协程已经退出, 不会再有新消息入队
+func (d *wsDispatcher) drain(q *topicQueue) {
+	if q.cfg.Policy == DispatchCoalesceLatest {
+		d.deliverLatest(q)
+		return
+	}
+	for {
+		select {
+		case msg := <-q.ch:
+			d.deliver(q, msg)
+		default:
+			return
+		}
+	}
+}
+
+func (d *wsDispatcher) deliver(q *topicQueue, msg []byte) {
+	defer func() {
+		if err := recover(); err != nil {
+			Log.Errorf("[ws] [topic=%s] proto handle panic: %v", q.topic, err)
+		}
+	}()
+	start := time.Now()
+	if err := d.handle(msg); err != nil {
+		Log.Debugf("[ws] [topic=%s] proto handle error: %s", q.topic, err.Error())
+	}
+	atomic.AddInt64(&q.delivered, 1)
+	if cost := time.Since(start); cost > time.Second {
+		Log.Warnf("[ws] [topic=%s] slow proto handle, cost %s", q.topic, cost)
+	}
+}
+
+func (d *wsDispatcher) stats() []DispatchStats {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	ret := make([]DispatchStats, 0, len(d.queues))
+	for _, q := range d.queues {
+		queued := 0
+		if q.ch != nil {
+			queued = len(q.ch)
+		} else {
+			q.mu.Lock()
+			if q.hasLatest {
+				queued = 1
+			}
+			q.mu.Unlock()
+		}
+		ret = append(ret, DispatchStats{
+			Topic:     q.topic,
+			Policy:    q.cfg.Policy,
+			Queued:    queued,
+			Delivered: atomic.LoadInt64(&q.delivered),
+			Dropped:   atomic.LoadInt64(&q.dropped),
+			Delayed:   atomic.LoadInt64(&q.delayed),
+		})
+	}
+	return ret
+}
+
+func (d *wsDispatcher) stop() {
+	select {
+	case <-d.close:
+		return
+	default:
+		close(d.close)
+	}
+	d.wg.Wait()
+}
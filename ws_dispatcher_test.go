@@ -0,0 +1,164 @@
+package goex
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func topicOf(msg []byte) string {
+	return strings.SplitN(string(msg), ":", 2)[0]
+}
+
+func send(d *wsDispatcher, msg string) {
+	d.dispatch(topicOf([]byte(msg)), []byte(msg))
+}
+
+func findStats(stats []DispatchStats, topic string) DispatchStats {
+	for _, s := range stats {
+		if s.Topic == topic {
+			return s
+		}
+	}
+	return DispatchStats{}
+}
+
+func TestWsDispatcher_DropOldest(t *testing.T) {
+	release := make(chan struct{})
+	var (
+		lock     sync.Mutex
+		received []string
+	)
+	d := newWsDispatcher(func(msg []byte) error {
+		<-release
+		lock.Lock()
+		received = append(received, string(msg))
+		lock.Unlock()
+		return nil
+	}, map[string]DispatchConfig{"depth": {Policy: DispatchDropOldest, QueueSize: 2}}, DispatchConfig{}, nil)
+
+	send(d, "depth:1") //consumer blocks on this one
+	time.Sleep(50 * time.Millisecond)
+	for _, m := range []string{"depth:2", "depth:3", "depth:4", "depth:5"} {
+		send(d, m)
+	}
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+	d.stop()
+
+	assert.Equal(t, []string{"depth:1", "depth:4", "depth:5"}, received)
+	s := findStats(d.stats(), "depth")
+	assert.Equal(t, int64(2), s.Dropped)
+	assert.Equal(t, int64(3), s.Delivered)
+}
+
+func TestWsDispatcher_CoalesceLatest(t *testing.T) {
+	release := make(chan struct{})
+	var (
+		lock     sync.Mutex
+		received []string
+	)
+	d := newWsDispatcher(func(msg []byte) error {
+		<-release
+		lock.Lock()
+		received = append(received, string(msg))
+		lock.Unlock()
+		return nil
+	}, nil, DispatchConfig{Policy: DispatchCoalesceLatest}, nil)
+
+	send(d, "ticker:1")
+	time.Sleep(50 * time.Millisecond)
+	send(d, "ticker:2")
+	send(d, "ticker:3")
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+	d.stop()
+
+	assert.Equal(t, []string{"ticker:1", "ticker:3"}, received)
+	assert.Equal(t, int64(1), findStats(d.stats(), "ticker").Dropped)
+}
+
+func TestWsDispatcher_Guaranteed(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		received []string
+	)
+	d := newWsDispatcher(func(msg []byte) error {
+		time.Sleep(5 * time.Millisecond)
+		lock.Lock()
+		received = append(received, string(msg))
+		lock.Unlock()
+		return nil
+	}, map[string]DispatchConfig{"trade": {Policy: DispatchGuaranteed, QueueSize: 1}}, DispatchConfig{}, nil)
+
+	for i := 0; i < 10; i++ {
+		send(d, "trade:"+string(rune('0'+i)))
+	}
+	time.Sleep(100 * time.Millisecond)
+	d.stop()
+
+	assert.Len(t, received, 10)
+	assert.Equal(t, "trade:9", received[9])
+	s := findStats(d.stats(), "trade")
+	assert.Equal(t, int64(0), s.Dropped)
+	assert.True(t, s.Delayed > 0)
+}
+
+func TestWsDispatcher_SyncByDefault(t *testing.T) {
+	called := 0
+	d := newWsDispatcher(func(msg []byte) error {
+		called++
+		return nil
+	}, nil, DispatchConfig{}, nil)
+	d.dispatch("", []byte("x"))
+	assert.Equal(t, 1, called)
+	d.stop()
+}
+
+func TestWsDispatcher_GuaranteedDrainOnStop(t *testing.T) {
+	release := make(chan struct{})
+	var received []string
+	d := newWsDispatcher(func(msg []byte) error {
+		<-release
+		received = append(received, string(msg))
+		return nil
+	}, map[string]DispatchConfig{"order": {Policy: DispatchGuaranteed, QueueSize: 4}}, DispatchConfig{}, nil)
+
+	for i := 0; i < 4; i++ {
+		send(d, "order:"+string(rune('0'+i)))
+	}
+	close(release)
+	d.stop() //队列中剩余的消息在stop返回前回调完
+
+	assert.Equal(t, []string{"order:0", "order:1", "order:2", "order:3"}, received)
+}
+
+func TestWsDispatcher_GuaranteedAbort(t *testing.T) {
+	block := make(chan struct{})
+	abort := make(chan struct{})
+	d := newWsDispatcher(func(msg []byte) error {
+		<-block
+		return nil
+	}, map[string]DispatchConfig{"order": {Policy: DispatchGuaranteed, QueueSize: 1}}, DispatchConfig{}, abort)
+
+	send(d, "order:0")
+	time.Sleep(20 * time.Millisecond)
+	send(d, "order:1")
+
+	pushed := make(chan struct{})
+	go func() {
+		send(d, "order:2") //队列已满, 阻塞到abort
+		close(pushed)
+	}()
+	close(abort)
+	select {
+	case <-pushed:
+	case <-time.After(time.Second):
+		t.Fatal("push is not aborted")
+	}
+	close(block)
+	d.stop()
+}
@@ -0,0 +1,142 @@
+package goex
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ReconnectPolicy 决定WsConn断线后的重连节奏
+type ReconnectPolicy interface {
+	// NextDelay 返回第attempt次(从1开始)重连失败后到下一次重连的等待时间,返回false表示放弃重连
+	NextDelay(attempt int, err error) (time.Duration, bool)
+}
+
+// WsHandshakeError 握手阶段服务端返回了非101的http状态码
+type WsHandshakeError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *WsHandshakeError) Error() string {
+	return fmt.Sprintf("handshake fail, http status code %d, %v", e.StatusCode, e.Err)
+}
+
+// IsWsAuthError 是否为鉴权失败(401/403),这类错误重试通常没有意义
+func IsWsAuthError(err error) bool {
+	if he, ok := err.(*WsHandshakeError); ok {
+		return he.StatusCode == http.StatusUnauthorized || he.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+// LinearBackoffPolicy 第n次失败后等待Interval*n,最多重试MaxRetry次(<=0表示无限重试)
+type LinearBackoffPolicy struct {
+	Interval time.Duration
+	MaxRetry int
+}
+
+func (p LinearBackoffPolicy) NextDelay(attempt int, err error) (time.Duration, bool) {
+	if p.MaxRetry > 0 && attempt >= p.MaxRetry {
+		return 0, false
+	}
+	return p.Interval * time.Duration(attempt), true
+}
+
+// 未设置MaxDelay时指数退避的等待上限
+const defaultMaxReconnectDelay = time.Minute
+
+// ExponentialBackoffPolicy 指数退避重连,Jitter为随机抖动比例(0~1),MaxRetry<=0表示无限重试
+type ExponentialBackoffPolicy struct {
+	InitialDelay     time.Duration
+	MaxDelay         time.Duration //<=0时为1分钟
+	Multiplier       float64       //默认为2
+	Jitter           float64
+	MaxRetry         int
+	RetryOnAuthError bool //默认鉴权失败时直接放弃
+}
+
+func (p ExponentialBackoffPolicy) NextDelay(attempt int, err error) (time.Duration, bool) {
+	if !p.RetryOnAuthError && IsWsAuthError(err) {
+		return 0, false
+	}
+	if p.MaxRetry > 0 && attempt >= p.MaxRetry {
+		return 0, false
+	}
+
+	multiplier := p.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxReconnectDelay
+	}
+	//先在float64上截断,避免attempt很大时转换time.Duration溢出为负数
+	delay := float64(p.InitialDelay) * math.Pow(multiplier, float64(attempt-1))
+	if math.IsInf(delay, 0) || math.IsNaN(delay) || delay > float64(maxDelay) {
+		delay = float64(maxDelay)
+	}
+
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		delay = delay * (1 - jitter*rand.Float64())
+	}
+
+	return time.Duration(delay), true
+}
+
+// FailFastPolicy 断线后不重连,直接关闭连接并回调ErrorHandleFunc
+type FailFastPolicy struct{}
+
+func (FailFastPolicy) NextDelay(attempt int, err error) (time.Duration, bool) {
+	return 0, false
+}
+
+// CircuitBreakerPolicy 连续失败Threshold次后熔断,等待OpenDuration后再交给Policy继续重试
+// 熔断后Policy的退避从头开始计算,但Policy的MaxRetry按总的重连次数计算,不会因熔断而重置
+type CircuitBreakerPolicy struct {
+	Policy       ReconnectPolicy
+	Threshold    int
+	OpenDuration time.Duration
+
+	lock   sync.Mutex
+	trips  int
+	offset int
+}
+
+func NewCircuitBreakerPolicy(policy ReconnectPolicy, threshold int, openDuration time.Duration) *CircuitBreakerPolicy {
+	return &CircuitBreakerPolicy{Policy: policy, Threshold: threshold, OpenDuration: openDuration}
+}
+
+func (p *CircuitBreakerPolicy) NextDelay(attempt int, err error) (time.Duration, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if attempt == 1 {
+		p.offset = 0
+	}
+
+	if _, ok := p.Policy.NextDelay(attempt, err); !ok {
+		return 0, false
+	}
+
+	if p.Threshold > 0 && attempt-p.offset >= p.Threshold {
+		p.trips++
+		p.offset = attempt
+		return p.OpenDuration, true
+	}
+
+	return p.Policy.NextDelay(attempt-p.offset, err)
+}
+
+// Trips 熔断次数
+func (p *CircuitBreakerPolicy) Trips() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.trips
+}
@@ -0,0 +1,154 @@
+package goex
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinearBackoffPolicy(t *testing.T) {
+	p := LinearBackoffPolicy{Interval: time.Second, MaxRetry: 3}
+	d, ok := p.NextDelay(2, nil)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, d)
+	_, ok = p.NextDelay(3, nil)
+	assert.False(t, ok)
+}
+
+func TestExponentialBackoffPolicy(t *testing.T) {
+	p := ExponentialBackoffPolicy{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt, want := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		4:  800 * time.Millisecond,
+		5:  time.Second,
+		50: time.Second,
+	} {
+		d, ok := p.NextDelay(attempt, errors.New("network"))
+		assert.True(t, ok)
+		assert.Equal(t, want, d, "attempt %d", attempt)
+	}
+
+	_, ok := p.NextDelay(1, &WsHandshakeError{StatusCode: http.StatusUnauthorized})
+	assert.False(t, ok)
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d, _ := p.NextDelay(5, nil)
+		assert.True(t, d >= 500*time.Millisecond && d <= time.Second, d)
+	}
+}
+
+func TestCircuitBreakerPolicy(t *testing.T) {
+	p := NewCircuitBreakerPolicy(LinearBackoffPolicy{Interval: time.Millisecond}, 3, time.Minute)
+	d, _ := p.NextDelay(1, nil)
+	assert.Equal(t, time.Millisecond, d)
+	d, _ = p.NextDelay(3, nil)
+	assert.Equal(t, time.Minute, d)
+	d, _ = p.NextDelay(4, nil)
+	assert.Equal(t, time.Millisecond, d)
+	assert.Equal(t, 1, p.Trips())
+
+	//内层的MaxRetry按总次数计算
+	p = NewCircuitBreakerPolicy(LinearBackoffPolicy{Interval: time.Millisecond, MaxRetry: 5}, 3, time.Minute)
+	for attempt := 1; attempt < 5; attempt++ {
+		_, ok := p.NextDelay(attempt, nil)
+		assert.True(t, ok, "attempt %d", attempt)
+	}
+	_, ok := p.NextDelay(5, nil)
+	assert.False(t, ok)
+}
+
+func TestExponentialBackoffPolicy_Unlimited(t *testing.T) {
+	p := ExponentialBackoffPolicy{InitialDelay: 100 * time.Millisecond}
+	for _, attempt := range []int{11, 64, 100, 2000} {
+		d, ok := p.NextDelay(attempt, nil)
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, d, "attempt %d", attempt)
+	}
+}
+
+func TestWsConn_FailFastOnAuthError(t *testing.T) {
+	var conns int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&conns, 1) > 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		c.Close() //断开第一个连接触发重连
+	}))
+	defer srv.Close()
+
+	giveUp := make(chan WsEvent, 1)
+	ws, err := NewWsBuilder().WsUrl("ws" + strings.TrimPrefix(srv.URL, "http")).AutoReconnect().
+		ReconnectPolicy(ExponentialBackoffPolicy{InitialDelay: 10 * time.Millisecond, MaxRetry: 0}).
+		ProtoHandleFunc(ProtoHandle).
+		EventHandleFunc(func(event WsEvent) {
+			if event.Type == WsEventGiveUp {
+				giveUp <- event
+			}
+		}).Build()
+	require.NoError(t, err)
+
+	select {
+	case evt := <-giveUp:
+		assert.Equal(t, 1, evt.Attempt)
+		assert.True(t, IsWsAuthError(evt.Err))
+	case <-time.After(3 * time.Second):
+		t.Fatal("expect give up on auth error")
+	}
+	<-ws.Done()
+}
+
+func TestWsConn_StaleTimeout(t *testing.T) {
+	var conns int32
+	srv, wsUrl := newLocalWsServer(func(c *websocket.Conn) {
+		atomic.AddInt32(&conns, 1)
+		//只回复控制帧,不推送数据
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	defer srv.Close()
+
+	var (
+		lock   sync.Mutex
+		events []WsEventType
+	)
+	ws, err := NewWsBuilder().WsUrl(wsUrl).AutoReconnect().
+		ReconnectPolicy(LinearBackoffPolicy{Interval: 10 * time.Millisecond}).
+		StaleTimeout(200 * time.Millisecond).
+		ProtoHandleFunc(ProtoHandle).
+		EventHandleFunc(func(event WsEvent) {
+			lock.Lock()
+			events = append(events, event.Type)
+			lock.Unlock()
+		}).Build()
+	require.NoError(t, err)
+	defer ws.CloseWs()
+
+	assert.Nil(t, ws.Subscribe(map[string]string{"op": "subscribe", "args": "ticker"}))
+	time.Sleep(600 * time.Millisecond)
+
+	assert.True(t, atomic.LoadInt32(&conns) >= 2)
+	assert.True(t, ws.Stats().ReconnectCount >= 1)
+	lock.Lock()
+	assert.Contains(t, events, WsEventResubscribed)
+	lock.Unlock()
+}
@@ -0,0 +1,125 @@
+package goex
+
+import (
+	"sync"
+	"time"
+)
+
+type WsEventType int
+
+const (
+	WsEventConnected    WsEventType = iota + 1 //连接成功(包括重连成功)
+	WsEventDisconnected                        //连接断开
+	WsEventResubscribed                        //重连后重新订阅完成
+	WsEventGiveUp                              //重连失败,放弃连接
+)
+
+func (t WsEventType) String() string {
+	switch t {
+	case WsEventConnected:
+		return "connected"
+	case WsEventDisconnected:
+		return "disconnected"
+	case WsEventResubscribed:
+		return "resubscribed"
+	case WsEventGiveUp:
+		return "give_up"
+	default:
+		return "unknown"
+	}
+}
+
+type WsEvent struct {
+	Type    WsEventType
+	WsUrl   string
+	Time    time.Time
+	Attempt int   //重连次数,首次连接为0
+	Err     error //断开或放弃的原因
+}
+
+type WsTopicStats struct {
+	Messages        int64
+	Bytes           int64
+	LastMessageTime time.Time
+	LastLatency     time.Duration //交易所时间戳到本地接收的延迟
+	AvgLatency      time.Duration
+	MaxLatency      time.Duration
+	latencySamples  int64
+	latencySum      time.Duration
+}
+
+type WsStats struct {
+	WsUrl           string
+	Connected       bool
+	ConnectedTime   time.Time
+	ReconnectCount  int64
+	Messages        int64
+	Bytes           int64
+	LastMessageTime time.Time
+	Topics          map[string]WsTopicStats
+}
+
+type wsStatsCollector struct {
+	lock  sync.Mutex
+	stats WsStats
+}
+
+func newWsStatsCollector(wsUrl string) *wsStatsCollector {
+	return &wsStatsCollector{stats: WsStats{WsUrl: wsUrl, Topics: make(map[string]WsTopicStats, 4)}}
+}
+
+func (c *wsStatsCollector) onConnected(reconnect bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stats.Connected = true
+	c.stats.ConnectedTime = time.Now()
+	if reconnect {
+		c.stats.ReconnectCount++
+	}
+}
+
+func (c *wsStatsCollector) onDisconnected() {
+	c.lock.Lock()
+	c.stats.Connected = false
+	c.lock.Unlock()
+}
+
+// onMessage 记录一条消息,exTs为交易所的消息时间戳,为零值时不统计延迟
+func (c *wsStatsCollector) onMessage(topic string, size int, exTs time.Time) {
+	now := time.Now()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.stats.Messages++
+	c.stats.Bytes += int64(size)
+	c.stats.LastMessageTime = now
+
+	ts := c.stats.Topics[topic]
+	ts.Messages++
+	ts.Bytes += int64(size)
+	ts.LastMessageTime = now
+	if !exTs.IsZero() {
+		latency := now.Sub(exTs)
+		ts.LastLatency = latency
+		ts.latencySamples++
+		ts.latencySum += latency
+		ts.AvgLatency = ts.latencySum / time.Duration(ts.latencySamples)
+		if latency > ts.MaxLatency {
+			ts.MaxLatency = latency
+		}
+	}
+	c.stats.Topics[topic] = ts
+}
+
+func (c *wsStatsCollector) snapshot() WsStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	s := c.stats
+	s.Topics = make(map[string]WsTopicStats, len(c.stats.Topics))
+	for k, v := range c.stats.Topics {
+		s.Topics[k] = v
+	}
+	return s
+}
@@ -0,0 +1,79 @@
+package goex
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLocalWsServer 启动一个本地ws服务, serve在每个连接建立后被调用
+func newLocalWsServer(serve func(c *websocket.Conn)) (*httptest.Server, string) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		serve(c)
+	}))
+	return srv, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestWsConn_Stats(t *testing.T) {
+	srv, wsUrl := newLocalWsServer(func(c *websocket.Conn) {
+		ts := time.Now().Add(-50*time.Millisecond).UnixNano() / int64(time.Millisecond)
+		for _, topic := range []string{"depth", "depth", "trade"} {
+			data, _ := json.Marshal(map[string]interface{}{"topic": topic, "ts": ts})
+			c.WriteMessage(websocket.TextMessage, data)
+		}
+		time.Sleep(time.Second)
+	})
+	defer srv.Close()
+
+	var (
+		lock   sync.Mutex
+		events []WsEventType
+	)
+	ws, err := NewWsBuilder().WsUrl(wsUrl).
+		ProtoHandleFunc(func(bytes []byte) error { return nil }).
+		TopicFunc(func(msg []byte) string {
+			var m struct{ Topic string }
+			json.Unmarshal(msg, &m)
+			return m.Topic
+		}).
+		TimestampFunc(func(msg []byte) time.Time {
+			var m struct{ Ts int64 }
+			json.Unmarshal(msg, &m)
+			return time.Unix(0, m.Ts*int64(time.Millisecond))
+		}).
+		EventHandleFunc(func(event WsEvent) {
+			lock.Lock()
+			events = append(events, event.Type)
+			lock.Unlock()
+		}).Build()
+	require.NoError(t, err)
+	defer ws.CloseWs()
+
+	time.Sleep(200 * time.Millisecond)
+
+	stats := ws.Stats()
+	assert.True(t, stats.Connected)
+	assert.Equal(t, int64(3), stats.Messages)
+	assert.Equal(t, int64(2), stats.Topics["depth"].Messages)
+	assert.Equal(t, int64(1), stats.Topics["trade"].Messages)
+	assert.True(t, stats.Topics["depth"].LastLatency >= 50*time.Millisecond)
+	assert.False(t, stats.LastMessageTime.IsZero())
+
+	lock.Lock()
+	assert.Equal(t, []WsEventType{WsEventConnected}, events)
+	lock.Unlock()
+}